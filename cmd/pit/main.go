@@ -1,6 +1,10 @@
 package main
 
-import "github.com/druarnfield/pit/internal/cli"
+import (
+	_ "time/tzdata" // schedule time zones must resolve on Windows hosts without a zoneinfo database
+
+	"github.com/druarnfield/pit/internal/cli"
+)
 
 func main() {
 	cli.Execute()
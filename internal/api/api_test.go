@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -30,7 +31,7 @@ func newTestConfigs() map[string]*config.ProjectConfig {
 		"dag_a": {
 			DAG: config.DAGConfig{
 				Name:     "dag_a",
-				Schedule: "0 6 * * *",
+				Schedule: config.Schedules{"0 6 * * *"},
 				Overlap:  "skip",
 			},
 			Tasks: []config.TaskConfig{
@@ -336,6 +337,144 @@ func TestRunDetail(t *testing.T) {
 	}
 }
 
+func TestRunAnnotation(t *testing.T) {
+	store := newTestStore(t)
+	seedTestRuns(t, store)
+	const id = "20260307_143000.000_dag_a"
+	if err := store.AnnotateRun(id, "known partner outage"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AcknowledgeRun(id, "alice", time.Date(2026, 3, 7, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(newTestConfigs(), store, "", nil, "")
+
+	for _, path := range []string{"/api/runs/" + id, "/api/runs"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+
+		type annotated struct {
+			Note           string `json:"note"`
+			AcknowledgedAt string `json:"acknowledged_at"`
+			AcknowledgedBy string `json:"acknowledged_by"`
+		}
+		var got annotated
+		if path == "/api/runs" {
+			var body struct {
+				Runs []annotated `json:"runs"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil || len(body.Runs) != 1 {
+				t.Fatalf("%s: decode: %v (%d runs)", path, err, len(body.Runs))
+			}
+			got = body.Runs[0]
+		} else if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+
+		want := annotated{Note: "known partner outage", AcknowledgedAt: "2026-03-07T15:00:00Z", AcknowledgedBy: "alice"}
+		if got != want {
+			t.Errorf("%s: annotation = %+v, want %+v", path, got, want)
+		}
+	}
+}
+
+func TestRunDetailSnapshot(t *testing.T) {
+	store := newTestStore(t)
+	seedTestRuns(t, store)
+	if err := store.UpdateRunSnapshot("20260307_143000.000_dag_a", 42, 2048, 250*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(newTestConfigs(), store, "", nil, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runs/20260307_143000.000_dag_a", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var body struct {
+		Snapshot struct {
+			Files      int   `json:"files"`
+			Bytes      int64 `json:"bytes"`
+			DurationMS int64 `json:"duration_ms"`
+		} `json:"snapshot"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Snapshot.Files != 42 || body.Snapshot.Bytes != 2048 || body.Snapshot.DurationMS != 250 {
+		t.Errorf("snapshot = %+v, want 42 files, 2048 bytes, 250ms", body.Snapshot)
+	}
+}
+
+func TestRunDetailInputs(t *testing.T) {
+	store := newTestStore(t)
+	seedTestRuns(t, store)
+	at := time.Date(2026, 3, 7, 14, 29, 0, 0, time.UTC)
+	if err := store.RecordInput("20260307_143000.000_dag_a", "sales.csv", "ftp_watch", "ftp://partner/in/sales.csv", 120, "ab12", at); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(newTestConfigs(), store, "", nil, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runs/20260307_143000.000_dag_a", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	var body struct {
+		Inputs []inputJSON `json:"inputs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := inputJSON{Path: "sales.csv", Source: "ftp_watch", Remote: "ftp://partner/in/sales.csv", Size: 120, SHA256: "ab12", FetchedAt: "2026-03-07T14:29:00Z"}
+	if len(body.Inputs) != 1 || body.Inputs[0] != want {
+		t.Errorf("inputs = %+v, want [%+v]", body.Inputs, want)
+	}
+}
+
+func TestDAGMetrics(t *testing.T) {
+	store := newTestStore(t)
+	seedTestRuns(t, store)
+	if err := store.RecordTaskMetrics("20260307_143000.000_dag_a", "load", map[string]float64{"dbt_tests_pass": 12, "dbt_tests_fail": 1}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(newTestConfigs(), store, "", nil, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dags/dag_a/metrics?task=load", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var body struct {
+		Metrics []struct {
+			RunID string  `json:"run_id"`
+			Task  string  `json:"task"`
+			Name  string  `json:"name"`
+			Value float64 `json:"value"`
+		} `json:"metrics"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(body.Metrics))
+	}
+	if m := body.Metrics[0]; m.Task != "load" || m.Name != "dbt_tests_fail" || m.Value != 1 {
+		t.Errorf("metrics[0] = %+v, want load dbt_tests_fail = 1", m)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/dags/nonexistent/metrics", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown DAG status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
 func TestRunDetailNotFound(t *testing.T) {
 	h := NewHandler(newTestConfigs(), newTestStore(t), "", nil, "")
 
@@ -596,3 +735,75 @@ func TestRunLogsLive(t *testing.T) {
 		t.Errorf("body missing 'event: complete'")
 	}
 }
+
+func TestDAGStats(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, status := range []string{"success", "failed", "success"} {
+		id := fmt.Sprintf("run%d", i)
+		started := now.Add(time.Duration(i-10) * time.Hour)
+		store.RecordRunStart(id, "dag_a", "running", "runs/"+id, "cron", started)
+		store.RecordTaskStart(id, "extract", "running", "", started)
+		store.RecordTaskEnd(id, "extract", status, started.Add(time.Minute), 2, "")
+		store.RecordRunEnd(id, status, started.Add(2*time.Minute), "")
+	}
+	// Outside a one-day window
+	store.RecordRunStart("old", "dag_a", "running", "runs/old", "cron", now.AddDate(0, 0, -3))
+	store.RecordRunEnd("old", "failed", now.AddDate(0, 0, -3).Add(time.Minute), "")
+	h := NewHandler(newTestConfigs(), store, "", nil, "")
+
+	type stats struct {
+		Runs               int     `json:"runs"`
+		Succeeded          int     `json:"succeeded"`
+		Failed             int     `json:"failed"`
+		SuccessRate        float64 `json:"success_rate"`
+		AvgDurationSeconds float64 `json:"avg_duration_seconds"`
+	}
+	get := func(path string, body any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		if err := json.NewDecoder(w.Body).Decode(body); err != nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+	}
+
+	var list struct {
+		DAGs []struct {
+			Name  string `json:"name"`
+			Stats stats  `json:"stats"`
+		} `json:"dags"`
+	}
+	get("/api/dags?days=1", &list)
+	want := stats{Runs: 3, Succeeded: 2, Failed: 1, SuccessRate: 2.0 / 3, AvgDurationSeconds: 120}
+	if list.DAGs[0].Stats != want {
+		t.Errorf("dag_a stats = %+v, want %+v", list.DAGs[0].Stats, want)
+	}
+	if list.DAGs[1].Stats != (stats{}) {
+		t.Errorf("dag_b stats = %+v, want zero", list.DAGs[1].Stats)
+	}
+	get("/api/dags", &list)
+	if list.DAGs[0].Stats.Runs != 4 {
+		t.Errorf("dag_a stats over 30 days = %+v, want 4 runs", list.DAGs[0].Stats)
+	}
+
+	var detail struct {
+		Stats     stats `json:"stats"`
+		TaskStats []struct {
+			Name string `json:"name"`
+			stats
+			AvgAttempts float64 `json:"avg_attempts"`
+		} `json:"task_stats"`
+	}
+	get("/api/dags/dag_a?days=1", &detail)
+	if detail.Stats != want {
+		t.Errorf("detail stats = %+v, want %+v", detail.Stats, want)
+	}
+	if len(detail.TaskStats) != 1 || detail.TaskStats[0].Name != "extract" || detail.TaskStats[0].Runs != 3 ||
+		detail.TaskStats[0].AvgDurationSeconds != 60 || detail.TaskStats[0].AvgAttempts != 2 {
+		t.Errorf("task_stats = %+v, want extract with 3 runs, 60s, 2 attempts", detail.TaskStats)
+	}
+}
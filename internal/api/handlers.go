@@ -6,6 +6,9 @@ import (
 	"sort"
 	"strconv"
 	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/meta"
 )
 
 // JSON response types
@@ -18,15 +21,52 @@ type runJSON struct {
 	EndedAt   *string `json:"ended_at"`
 	Trigger   string  `json:"trigger"`
 	Error     *string `json:"error"`
+
+	LogicalDate string `json:"logical_date,omitempty"`
+
+	Note           string  `json:"note,omitempty"`
+	AcknowledgedAt *string `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string  `json:"acknowledged_by,omitempty"`
+}
+
+// statsJSON summarises the runs of a DAG, or the instances of a task,
+// over the window given by ?days=N.
+type statsJSON struct {
+	Runs               int     `json:"runs"`
+	Succeeded          int     `json:"succeeded"`
+	Failed             int     `json:"failed"`
+	SuccessRate        float64 `json:"success_rate"`
+	AvgDurationSeconds float64 `json:"avg_duration_seconds"`
+}
+
+func newStatsJSON(runs, succeeded, failed int, rate float64, avg time.Duration) statsJSON {
+	return statsJSON{Runs: runs, Succeeded: succeeded, Failed: failed, SuccessRate: rate, AvgDurationSeconds: avg.Seconds()}
+}
+
+type taskStatsJSON struct {
+	Name string `json:"name"`
+	statsJSON
+	AvgAttempts float64 `json:"avg_attempts"`
 }
 
 type taskJSON struct {
-	Name      string  `json:"name"`
-	Status    string  `json:"status"`
-	StartedAt *string `json:"started_at"`
-	EndedAt   *string `json:"ended_at"`
-	Attempts  int     `json:"attempts"`
-	Error     *string `json:"error"`
+	Name           string             `json:"name"`
+	Status         string             `json:"status"`
+	StartedAt      *string            `json:"started_at"`
+	EndedAt        *string            `json:"ended_at"`
+	Attempts       int                `json:"attempts"`
+	Error          *string            `json:"error"`
+	FailedUpstream []string           `json:"failed_upstream,omitempty"`
+	Metrics        map[string]float64 `json:"metrics,omitempty"`
+}
+
+type inputJSON struct {
+	Path      string `json:"path"`
+	Source    string `json:"source"`
+	Remote    string `json:"remote,omitempty"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	FetchedAt string `json:"fetched_at"`
 }
 
 // Helper functions
@@ -53,6 +93,15 @@ func nilStr(s string) *string {
 	return &s
 }
 
+// scheduleList returns the DAG's cron expressions, never nil, so JSON
+// clients always see an array.
+func scheduleList(s config.Schedules) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
 func parseLimit(r *http.Request, defaultVal, maxVal int) int {
 	s := r.URL.Query().Get("limit")
 	if s == "" {
@@ -68,6 +117,16 @@ func parseLimit(r *http.Request, defaultVal, maxVal int) int {
 	return n
 }
 
+// statsSince returns the start of the window run statistics cover: the
+// last ?days=N days, 30 by default, at most 365.
+func statsSince(r *http.Request) time.Time {
+	days := 30
+	if n, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && n >= 1 {
+		days = min(n, 365)
+	}
+	return time.Now().AddDate(0, 0, -days)
+}
+
 // handleListDAGs returns all DAGs with their latest run status.
 func (h *handler) handleListDAGs(w http.ResponseWriter, r *http.Request) {
 	runs, err := h.store.LatestRunPerDAG()
@@ -77,6 +136,17 @@ func (h *handler) handleListDAGs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	stats, err := h.store.RunStats(statsSince(r))
+	if err != nil {
+		log.Printf("api: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	statsMap := make(map[string]statsJSON, len(stats))
+	for _, st := range stats {
+		statsMap[st.DAGName] = newStatsJSON(st.Runs, st.Succeeded, st.Failed, st.SuccessRate(), st.AvgDuration)
+	}
+
 	runMap := make(map[string]runJSON)
 	for _, r := range runs {
 		runMap[r.DAGName] = runJSON{
@@ -87,6 +157,11 @@ func (h *handler) handleListDAGs(w http.ResponseWriter, r *http.Request) {
 			EndedAt:   timePtr(r.EndedAt),
 			Trigger:   r.Trigger,
 			Error:     nilStr(r.Error),
+
+			LogicalDate:    r.LogicalDate,
+			Note:           r.Note,
+			AcknowledgedAt: timePtr(r.AcknowledgedAt),
+			AcknowledgedBy: r.AcknowledgedBy,
 		}
 	}
 
@@ -97,10 +172,12 @@ func (h *handler) handleListDAGs(w http.ResponseWriter, r *http.Request) {
 	sort.Strings(names)
 
 	type dagItem struct {
-		Name      string   `json:"name"`
-		Schedule  string   `json:"schedule"`
-		TaskCount int      `json:"task_count"`
-		LatestRun *runJSON `json:"latest_run"`
+		Name      string    `json:"name"`
+		Schedule  string    `json:"schedule"`
+		Schedules []string  `json:"schedules"`
+		TaskCount int       `json:"task_count"`
+		LatestRun *runJSON  `json:"latest_run"`
+		Stats     statsJSON `json:"stats"`
 	}
 
 	dags := make([]dagItem, 0, len(names))
@@ -108,8 +185,10 @@ func (h *handler) handleListDAGs(w http.ResponseWriter, r *http.Request) {
 		cfg := h.configs[name]
 		item := dagItem{
 			Name:      name,
-			Schedule:  cfg.DAG.Schedule,
+			Schedule:  cfg.DAG.Schedule.String(),
+			Schedules: scheduleList(cfg.DAG.Schedule),
 			TaskCount: len(cfg.Tasks),
+			Stats:     statsMap[name],
 		}
 		if rj, ok := runMap[name]; ok {
 			rj.DAGName = "" // omit dag_name inside list context
@@ -137,6 +216,34 @@ func (h *handler) handleDAGDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	since := statsSince(r)
+	runStats, err := h.store.RunStats(since)
+	if err != nil {
+		log.Printf("api: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	var stats statsJSON
+	for _, st := range runStats {
+		if st.DAGName == name {
+			stats = newStatsJSON(st.Runs, st.Succeeded, st.Failed, st.SuccessRate(), st.AvgDuration)
+		}
+	}
+	taskStats, err := h.store.TaskStats(name, since)
+	if err != nil {
+		log.Printf("api: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	taskStatsList := make([]taskStatsJSON, 0, len(taskStats))
+	for _, ts := range taskStats {
+		taskStatsList = append(taskStatsList, taskStatsJSON{
+			Name:        ts.TaskName,
+			statsJSON:   newStatsJSON(ts.Runs, ts.Succeeded, ts.Failed, ts.SuccessRate(), ts.AvgDuration),
+			AvgAttempts: ts.AvgAttempts,
+		})
+	}
+
 	type taskItem struct {
 		Name      string   `json:"name"`
 		Script    string   `json:"script"`
@@ -165,16 +272,24 @@ func (h *handler) handleDAGDetail(w http.ResponseWriter, r *http.Request) {
 			EndedAt:   timePtr(rr.EndedAt),
 			Trigger:   rr.Trigger,
 			Error:     nilStr(rr.Error),
+
+			LogicalDate:    rr.LogicalDate,
+			Note:           rr.Note,
+			AcknowledgedAt: timePtr(rr.AcknowledgedAt),
+			AcknowledgedBy: rr.AcknowledgedBy,
 		})
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"name":        name,
-		"schedule":    cfg.DAG.Schedule,
+		"schedule":    cfg.DAG.Schedule.String(),
+		"schedules":   scheduleList(cfg.DAG.Schedule),
 		"overlap":     cfg.DAG.Overlap,
 		"timeout":     cfg.DAG.Timeout.Duration.String(),
 		"tasks":       tasks,
 		"recent_runs": recentRuns,
+		"stats":       stats,
+		"task_stats":  taskStatsList,
 	})
 }
 
@@ -200,6 +315,11 @@ func (h *handler) handleListRuns(w http.ResponseWriter, r *http.Request) {
 			EndedAt:   timePtr(rr.EndedAt),
 			Trigger:   rr.Trigger,
 			Error:     nilStr(rr.Error),
+
+			LogicalDate:    rr.LogicalDate,
+			Note:           rr.Note,
+			AcknowledgedAt: timePtr(rr.AcknowledgedAt),
+			AcknowledgedBy: rr.AcknowledgedBy,
 		})
 	}
 
@@ -224,16 +344,18 @@ func (h *handler) handleRunDetail(w http.ResponseWriter, r *http.Request) {
 	taskItems := make([]taskJSON, 0, len(tasks))
 	for _, ti := range tasks {
 		taskItems = append(taskItems, taskJSON{
-			Name:      ti.TaskName,
-			Status:    ti.Status,
-			StartedAt: timePtr(ti.StartedAt),
-			EndedAt:   timePtr(ti.EndedAt),
-			Attempts:  ti.Attempts,
-			Error:     nilStr(ti.Error),
+			Name:           ti.TaskName,
+			Status:         ti.Status,
+			StartedAt:      timePtr(ti.StartedAt),
+			EndedAt:        timePtr(ti.EndedAt),
+			Attempts:       ti.Attempts,
+			Error:          nilStr(ti.Error),
+			FailedUpstream: ti.FailedUpstream,
+			Metrics:        ti.Metrics,
 		})
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	resp := map[string]any{
 		"id":         run.ID,
 		"dag_name":   run.DAGName,
 		"status":     run.Status,
@@ -242,7 +364,100 @@ func (h *handler) handleRunDetail(w http.ResponseWriter, r *http.Request) {
 		"trigger":    run.Trigger,
 		"error":      nilStr(run.Error),
 		"tasks":      taskItems,
-	})
+	}
+	if run.LogicalDate != "" {
+		resp["logical_date"] = run.LogicalDate
+	}
+	if run.ArtifactURI != "" {
+		resp["artifact_uri"] = run.ArtifactURI
+	}
+	if run.Note != "" {
+		resp["note"] = run.Note
+	}
+	if run.AcknowledgedAt != nil {
+		resp["acknowledged_at"] = timePtr(run.AcknowledgedAt)
+		if run.AcknowledgedBy != "" {
+			resp["acknowledged_by"] = run.AcknowledgedBy
+		}
+	}
+	if versions := toolVersionsJSON(run); len(versions) > 0 {
+		resp["versions"] = versions
+	}
+	if run.GitCommit != "" {
+		revision := map[string]any{"commit": run.GitCommit, "dirty": run.GitDirty}
+		if run.GitBranch != "" {
+			revision["branch"] = run.GitBranch
+		}
+		resp["revision"] = revision
+	}
+	if run.SnapshotFiles > 0 {
+		resp["snapshot"] = map[string]any{
+			"files":       run.SnapshotFiles,
+			"bytes":       run.SnapshotBytes,
+			"duration_ms": run.SnapshotDuration.Milliseconds(),
+		}
+	}
+
+	inputs, err := h.store.InputsByRun(id)
+	if err != nil {
+		log.Printf("api: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if len(inputs) > 0 {
+		items := make([]inputJSON, 0, len(inputs))
+		for _, in := range inputs {
+			items = append(items, inputJSON{
+				Path:      in.Path,
+				Source:    in.Source,
+				Remote:    in.Remote,
+				Size:      in.Size,
+				SHA256:    in.SHA256,
+				FetchedAt: timeStr(in.FetchedAt),
+			})
+		}
+		resp["inputs"] = items
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleDAGMetrics returns the task metrics recorded over a DAG's recent
+// runs, oldest first, with optional task and limit filters.
+func (h *handler) handleDAGMetrics(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := h.configs[name]; !ok {
+		writeError(w, http.StatusNotFound, "dag not found")
+		return
+	}
+	limit := parseLimit(r, 30, 500)
+
+	records, err := h.store.TaskMetricHistory(name, r.URL.Query().Get("task"), limit)
+	if err != nil {
+		log.Printf("api: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	type metricItem struct {
+		RunID     string  `json:"run_id"`
+		Task      string  `json:"task"`
+		Name      string  `json:"name"`
+		Value     float64 `json:"value"`
+		StartedAt string  `json:"started_at"`
+	}
+
+	metrics := make([]metricItem, 0, len(records))
+	for _, m := range records {
+		metrics = append(metrics, metricItem{
+			RunID:     m.RunID,
+			Task:      m.TaskName,
+			Name:      m.Name,
+			Value:     m.Value,
+			StartedAt: timeStr(m.StartedAt),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"metrics": metrics})
 }
 
 // handleListOutputs returns outputs from successful runs.
@@ -257,10 +472,13 @@ func (h *handler) handleListOutputs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type outputItem struct {
-		DAGName  string `json:"dag_name"`
-		Name     string `json:"name"`
-		Type     string `json:"type"`
-		Location string `json:"location"`
+		DAGName    string     `json:"dag_name"`
+		Name       string     `json:"name"`
+		Type       string     `json:"type"`
+		Location   string     `json:"location"`
+		TaskName   string     `json:"task_name,omitempty"`
+		Rows       *int64     `json:"rows,omitempty"`
+		ProducedAt *time.Time `json:"produced_at,omitempty"`
 	}
 
 	outputs := make([]outputItem, 0)
@@ -285,9 +503,23 @@ func (h *handler) handleListOutputs(w http.ResponseWriter, r *http.Request) {
 				Name:     o.Name,
 				Type:     o.Type,
 				Location: o.Location,
+				TaskName:   o.TaskName,
+				Rows:       o.Rows,
+				ProducedAt: o.ProducedAt,
 			})
 		}
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{"outputs": outputs})
 }
+
+// toolVersionsJSON returns the run's recorded pit, Python, uv, and dbt versions.
+func toolVersionsJSON(run *meta.RunRecord) map[string]string {
+	versions := make(map[string]string)
+	for key, v := range map[string]string{"pit": run.PitVersion, "python": run.PythonVersion, "uv": run.UVVersion, "dbt": run.DBTVersion} {
+		if v != "" {
+			versions[key] = v
+		}
+	}
+	return versions
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/druarnfield/pit/internal/buildinfo"
 	"github.com/druarnfield/pit/internal/config"
 	"github.com/druarnfield/pit/internal/loghub"
 	"github.com/druarnfield/pit/internal/meta"
@@ -30,6 +31,7 @@ func NewHandler(configs map[string]*config.ProjectConfig, store meta.Store, toke
 	mux.HandleFunc("GET /api/dags", h.handleListDAGs)
 	mux.HandleFunc("GET /api/runs/{id}/logs", h.handleRunLogs)
 	mux.HandleFunc("GET /api/dags/{name}/logs", h.handleDAGLogs)
+	mux.HandleFunc("GET /api/dags/{name}/metrics", h.handleDAGMetrics)
 	mux.HandleFunc("GET /api/dags/{name}", h.handleDAGDetail)
 	mux.HandleFunc("GET /api/runs", h.handleListRuns)
 	mux.HandleFunc("GET /api/runs/{id}", h.handleRunDetail)
@@ -58,7 +60,7 @@ func (h *handler) authMiddleware(next http.Handler) http.Handler {
 }
 
 func (h *handler) handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": buildinfo.Version()})
 }
 
 
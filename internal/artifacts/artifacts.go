@@ -0,0 +1,161 @@
+// Package artifacts uploads kept run artifacts (logs, data, snapshot, run
+// manifest) to long-term storage after a run, so run history does not have
+// to live on the scheduler's disk. Backends talk to object stores over
+// plain HTTP, so no cloud SDKs are required.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// Store is an artifact storage backend.
+type Store interface {
+	// Put writes size bytes from body to key.
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+	// URI returns a human-readable location for key, e.g. "s3://bucket/key".
+	URI(key string) string
+}
+
+// Supported backend types for artifact_store.type.
+const (
+	TypeS3    = "s3"
+	TypeAzure = "azure"
+	TypeDir   = "dir"
+)
+
+// CredentialFunc looks up a credential field from the configured secret.
+// It returns an error if the field is not set.
+type CredentialFunc func(field string) (string, error)
+
+// New creates the backend described by cfg. creds resolves fields of
+// cfg.Secret; it may be nil when no secret is configured, in which case
+// credentials come from the standard environment variables.
+func New(cfg *config.ArtifactStoreConfig, creds CredentialFunc) (Store, error) {
+	lookup := func(field, env string) string {
+		if creds != nil {
+			if v, err := creds(field); err == nil {
+				return v
+			}
+		}
+		return os.Getenv(env)
+	}
+
+	switch cfg.Type {
+	case TypeS3:
+		s := &S3Store{
+			Bucket:       cfg.Bucket,
+			Region:       cfg.Region,
+			Endpoint:     cfg.Endpoint,
+			AccessKey:    lookup("access_key_id", "AWS_ACCESS_KEY_ID"),
+			SecretKey:    lookup("secret_access_key", "AWS_SECRET_ACCESS_KEY"),
+			SessionToken: lookup("session_token", "AWS_SESSION_TOKEN"),
+		}
+		if s.AccessKey == "" || s.SecretKey == "" {
+			return nil, fmt.Errorf("s3 artifact store: access_key_id and secret_access_key are required")
+		}
+		return s, nil
+	case TypeAzure:
+		s := &AzureStore{
+			Account:   cfg.Account,
+			Container: cfg.Bucket,
+			Endpoint:  cfg.Endpoint,
+			SASToken:  lookup("sas_token", "AZURE_STORAGE_SAS_TOKEN"),
+		}
+		if s.SASToken == "" {
+			return nil, fmt.Errorf("azure artifact store: sas_token is required")
+		}
+		return s, nil
+	case TypeDir:
+		return &DirStore{Root: cfg.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown artifact store type %q (must be s3, azure, or dir)", cfg.Type)
+	}
+}
+
+// Uploader copies a run directory to a Store under a common prefix.
+type Uploader struct {
+	Store     Store
+	Prefix    string // key prefix, e.g. "pit/prod"
+	KeepLocal bool   // keep the local run directory after a successful upload
+}
+
+// UploadRun uploads every file under runDir to <prefix>/<dagName>/<runID>/
+// and, unless KeepLocal is set, removes runDir once all files are stored.
+// Returns the URI of the uploaded run and the number of files.
+func (u *Uploader) UploadRun(ctx context.Context, runDir, dagName, runID string) (string, int, error) {
+	base := path.Join(u.Prefix, dagName, runID)
+	files := 0
+	err := filepath.WalkDir(runDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(runDir, p)
+		if err != nil {
+			return err
+		}
+		if err := putFile(ctx, u.Store, path.Join(base, filepath.ToSlash(rel)), p); err != nil {
+			return fmt.Errorf("uploading %s: %w", rel, err)
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return "", files, err
+	}
+
+	if !u.KeepLocal {
+		if err := os.RemoveAll(runDir); err != nil {
+			return u.Store.URI(base), files, fmt.Errorf("removing local run dir: %w", err)
+		}
+	}
+	return u.Store.URI(base), files, nil
+}
+
+func putFile(ctx context.Context, s Store, key, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, f, info.Size())
+}
+
+// escapeKey percent-encodes each segment of an object key for use in a URL path.
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = uriEncode(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// uriEncode encodes s per RFC 3986, leaving only unreserved characters as-is.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
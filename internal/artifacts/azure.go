@@ -0,0 +1,50 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AzureStore uploads artifacts as block blobs to an Azure Storage container,
+// authenticating with a shared access signature (SAS) token.
+type AzureStore struct {
+	Account   string
+	Container string
+	Endpoint  string       // default "https://<account>.blob.core.windows.net"
+	SASToken  string       // query string, with or without the leading "?"
+	Client    *http.Client // nil = http.DefaultClient
+}
+
+// azureAPIVersion is the Blob service REST API version requested.
+const azureAPIVersion = "2021-08-06"
+
+// Put implements Store. Single-request uploads are limited to 5000 MiB,
+// well above typical log and data artifacts.
+func (s *AzureStore) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	u := s.containerURL() + "/" + escapeKey(key) + "?" + strings.TrimPrefix(s.SASToken, "?")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Version", azureAPIVersion)
+
+	return doPut(s.Client, req)
+}
+
+// URI implements Store.
+func (s *AzureStore) URI(key string) string {
+	return s.containerURL() + "/" + key
+}
+
+func (s *AzureStore) containerURL() string {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", s.Account)
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + s.Container
+}
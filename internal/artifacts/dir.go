@@ -0,0 +1,37 @@
+package artifacts
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DirStore writes artifacts to a directory, typically a network share or a
+// mounted bucket.
+type DirStore struct {
+	Root string
+}
+
+// Put implements Store.
+func (s *DirStore) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	dst := filepath.Join(s.Root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(out, body)
+	closeErr := out.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	return closeErr
+}
+
+// URI implements Store.
+func (s *DirStore) URI(key string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(key))
+}
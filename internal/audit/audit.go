@@ -0,0 +1,127 @@
+// Package audit writes an append-only JSONL log of scheduler activity:
+// trigger events, run lifecycle, and administrative actions.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Action names recorded in the audit log.
+const (
+	ActionTrigger     = "trigger"      // a trigger fired (cron, ftp_watch, webhook)
+	ActionManualRun   = "manual_run"   // a run started from the CLI or API
+	ActionRunSkipped  = "run_skipped"  // a trigger was dropped (overlap, calendar, drain, ...)
+	ActionRunDeferred = "run_deferred" // a scheduled run was held until a blackout window ends
+	ActionRunStarted  = "run_started"  // a queued run began executing
+	ActionRunFinished = "run_finished" // a run reached a terminal status
+	ActionRunRetried  = "run_retried"  // a run that failed before any task started is tried again
+	ActionCancel      = "cancel"       // a run or queue entry was cancelled
+	ActionDrain       = "drain"        // pit serve stopped accepting triggers ahead of a restart
+	ActionReload      = "reload"       // pit serve swapped in reloaded configs, or rejected them (status "rejected")
+	ActionSlowTask    = "slow_task"    // a task ran far longer than its recent median
+	ActionStalledTask = "stalled_task" // a task produced no output for its stall_timeout
+	ActionDeploy      = "deploy"       // pipeline code was installed or rolled back by pit deploy
+)
+
+// Event is a single audit log line.
+type Event struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Action    string            `json:"action"`
+	Source    string            `json:"source,omitempty"` // who/what initiated: "cron", "webhook", "cli", "api", ...
+	DAGName   string            `json:"dag_name,omitempty"`
+	RunID     string            `json:"run_id,omitempty"`
+	Status    string            `json:"status,omitempty"`
+	Detail    string            `json:"detail,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// Log is an append-only JSONL audit log. A nil *Log is valid and discards events.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// Open opens (or creates) the audit log at path for appending.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating audit log dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log %q: %w", path, err)
+	}
+	return &Log{f: f, path: path}, nil
+}
+
+// Path returns the file path of the log.
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Record appends an event. Timestamp defaults to now.
+func (l *Log) Record(e Event) error {
+	if l == nil {
+		return nil
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	e.Timestamp = e.Timestamp.UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(line); err != nil {
+		return fmt.Errorf("writing audit event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// ReadAll parses every event in the audit log at path.
+func ReadAll(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log %q: %w", path, err)
+	}
+	var events []Event
+	start := 0
+	for i, b := range data {
+		if b != '\n' {
+			continue
+		}
+		line := data[start:i]
+		start = i + 1
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parsing audit log line: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
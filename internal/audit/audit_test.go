@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLog_RecordAndReadAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	ts := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	l.Record(Event{Timestamp: ts, Action: ActionTrigger, Source: "cron", DAGName: "nightly"})
+	l.Record(Event{Action: ActionRunFinished, DAGName: "nightly", RunID: "r1", Status: "success"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	// Reopening appends rather than truncating
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() reopen error: %v", err)
+	}
+	l.Record(Event{Action: ActionDrain, Source: "api"})
+	l.Close()
+
+	events, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll() unexpected error: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("ReadAll() returned %d events, want 3", len(events))
+	}
+	if !events[0].Timestamp.Equal(ts) || events[0].Source != "cron" {
+		t.Errorf("events[0] = %+v, want cron trigger at %v", events[0], ts)
+	}
+	if events[1].Timestamp.IsZero() {
+		t.Error("events[1].Timestamp is zero, want default to now")
+	}
+	if events[2].Action != ActionDrain {
+		t.Errorf("events[2].Action = %q, want %q", events[2].Action, ActionDrain)
+	}
+}
+
+func TestLog_NilDiscards(t *testing.T) {
+	var l *Log
+	if err := l.Record(Event{Action: ActionTrigger}); err != nil {
+		t.Errorf("nil Log Record() error: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("nil Log Close() error: %v", err)
+	}
+}
+
+func TestLog_ConcurrentRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Record(Event{Action: ActionTrigger, DAGName: "d"})
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	events, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if len(events) != 50 {
+		t.Errorf("ReadAll() returned %d events, want 50", len(events))
+	}
+}
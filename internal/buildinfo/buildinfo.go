@@ -0,0 +1,91 @@
+// Package buildinfo reports the version of the running pit binary.
+//
+// Release builds set the version, commit, and build date with the linker:
+//
+//	go build -ldflags "-X github.com/druarnfield/pit/internal/buildinfo.version=v1.4.0 \
+//	  -X github.com/druarnfield/pit/internal/buildinfo.commit=$(git rev-parse HEAD) \
+//	  -X github.com/druarnfield/pit/internal/buildinfo.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/pit
+//
+// Anything not set that way is taken from the module and VCS information
+// the Go toolchain embeds, so `go install ...@v1.4.0` and plain `go build`
+// in a checkout still report something useful.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X".
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`     // build time, or commit time when unknown
+	Modified  bool   `json:"modified,omitempty"` // built from a checkout with uncommitted changes
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"` // GOOS/GOARCH
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = commit == "" && s.Value == "true"
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// Version returns the version of the running binary, "dev" when unknown.
+func Version() string { return Get().Version }
+
+// String renders info as "pit v1.4.0 (commit 3f2a9c1e8b7d, built 2024-01-15T14:30:22Z, go1.24.1 linux/amd64)".
+func (i Info) String() string {
+	s := "pit " + i.Version + " ("
+	if i.Commit != "" {
+		c := i.Commit
+		if len(c) > 12 {
+			c = c[:12]
+		}
+		s += "commit " + c
+		if i.Modified {
+			s += "-dirty"
+		}
+		s += ", "
+	}
+	if i.Date != "" {
+		s += "built " + i.Date + ", "
+	}
+	return s + i.GoVersion + " " + i.Platform + ")"
+}
@@ -0,0 +1,30 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet_LinkerValues(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "v1.4.0", "3f2a9c1e8b7d4a6f0e1c2b3a4d5e6f7a8b9c0d1e", "2024-01-15T14:30:22Z"
+
+	info := Get()
+	if info.Version != "v1.4.0" || info.Commit != commit || info.Date != date || info.Modified {
+		t.Errorf("Get() = %+v", info)
+	}
+	want := "pit v1.4.0 (commit 3f2a9c1e8b7d, built 2024-01-15T14:30:22Z, " + runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+	if got := info.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestGet_Default(t *testing.T) {
+	if v := Version(); v == "" {
+		t.Error("Version() is empty, want \"dev\" or a module version")
+	}
+	info := Info{Version: "dev", GoVersion: "go1.24.1", Platform: "linux/amd64"}
+	if got, want := info.String(), "pit dev (go1.24.1 linux/amd64)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/druarnfield/pit/internal/meta"
+	"github.com/spf13/cobra"
+)
+
+func newAnnotateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "annotate <run_id> [note]",
+		Short: "Attach a note to a run or acknowledge its failure",
+		Long: "Attach a note to a recorded run, replacing any earlier note, and optionally mark its failure as acknowledged. " +
+			"Notes and acknowledgements are shown by `pit status` and the REST API, so triaged failures can be told apart from new ones.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ack, _ := cmd.Flags().GetBool("ack")
+			by, _ := cmd.Flags().GetString("by")
+			clearNote, _ := cmd.Flags().GetBool("clear")
+
+			runID := args[0]
+			var note string
+			if len(args) == 2 {
+				note = args[1]
+			}
+			if note == "" && !ack && !clearNote {
+				return fmt.Errorf("give a note, --ack, or --clear")
+			}
+			if note != "" && clearNote {
+				return fmt.Errorf("--clear cannot be combined with a note")
+			}
+
+			dbPath := resolveMetadataDB()
+			if _, err := os.Stat(dbPath); err != nil {
+				return fmt.Errorf("no metadata store at %s", dbPath)
+			}
+			store, err := meta.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("opening metadata store: %w", err)
+			}
+			defer store.Close()
+
+			w := cmd.OutOrStdout()
+			if note != "" || clearNote {
+				if err := store.AnnotateRun(runID, note); err != nil {
+					return err
+				}
+				if clearNote {
+					fmt.Fprintf(w, "Cleared note on run %s\n", runID)
+				} else {
+					fmt.Fprintf(w, "Annotated run %s\n", runID)
+				}
+			}
+			if ack {
+				if by == "" {
+					by = currentUser()
+				}
+				if err := store.AcknowledgeRun(runID, by, time.Now()); err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "Acknowledged run %s\n", runID)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("ack", false, "mark the run's failure as acknowledged")
+	cmd.Flags().String("by", "", "who acknowledged the run (default: current OS user)")
+	cmd.Flags().Bool("clear", false, "remove the run's note")
+
+	return cmd
+}
+
+// currentUser returns the name of the OS user running pit, or "" if it
+// cannot be determined.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
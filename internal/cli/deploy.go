@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/deploy"
+	"github.com/spf13/cobra"
+)
+
+func newDeployCmd() *cobra.Command {
+	var (
+		check    bool
+		rollback bool
+		drain    bool
+		addr     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "deploy [target]",
+		Short: "Install pipeline code from the git sources in pit_config.toml",
+		Long: `Fetch each [[deploy]] source in pit_config.toml, validate every project in it,
+and atomically replace its target directory (default "projects") with the result.
+The replaced tree is kept as <target>.previous; --rollback swaps it back.
+
+pit serve loads projects at startup, so pass --drain to have a running server
+finish its current runs and exit for its service manager to restart it on the
+new code. With a target argument, only the source deploying to that directory
+is used.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, err := deploySources(args)
+			if err != nil {
+				return err
+			}
+
+			auditLog, err := audit.Open(resolveAuditLog())
+			if err != nil {
+				return err
+			}
+			defer auditLog.Close()
+
+			changed := false
+			for _, src := range sources {
+				rel := workspaceRel(src.Target)
+				if rollback {
+					if err := deploy.Rollback(src.Target); err != nil {
+						return err
+					}
+					auditLog.Record(audit.Event{Action: audit.ActionDeploy, Source: "cli", Status: "rolled_back", Detail: rel})
+					fmt.Printf("Rolled back %s\n", rel)
+					changed = true
+					continue
+				}
+
+				res, err := deploy.Deploy(src, deploy.Options{
+					CacheDir: resolveRepoCacheDir(),
+					Defaults: resolveDefaults(),
+					Check:    check,
+				})
+				if err != nil {
+					var verr *deploy.ValidationError
+					if errors.As(err, &verr) {
+						for _, e := range verr.Errs {
+							fmt.Printf("  ✗ %s\n", e)
+						}
+						err = fmt.Errorf("%s: %d validation error(s); %s left unchanged", src.URL, len(verr.Errs), rel)
+					}
+					if !check {
+						auditLog.Record(audit.Event{Action: audit.ActionDeploy, Source: "cli", Status: "failed", Detail: rel, Extra: map[string]string{"url": src.URL}})
+					}
+					return err
+				}
+
+				short := res.Commit
+				if len(short) > 12 {
+					short = short[:12]
+				}
+				switch {
+				case check:
+					fmt.Printf("✓ %s@%s is valid (%s)\n", src.URL, short, strings.Join(res.Projects, ", "))
+				case !res.Swapped:
+					fmt.Printf("%s is already at %s\n", rel, short)
+				default:
+					auditLog.Record(audit.Event{
+						Action: audit.ActionDeploy, Source: "cli", Status: "deployed",
+						Detail: fmt.Sprintf("%s@%s -> %s", src.URL, res.Commit, rel),
+					})
+					fmt.Printf("Deployed %s@%s to %s (%s)\n", src.URL, short, rel, strings.Join(res.Projects, ", "))
+					changed = true
+				}
+			}
+
+			if drain && changed {
+				var resp struct {
+					Running int `json:"running"`
+					Queued  int `json:"queued"`
+				}
+				if err := controlRequest(cmd.Context(), http.MethodPost, addr, "/control/drain", &resp); err != nil {
+					return err
+				}
+				fmt.Printf("Draining pit serve: %d run(s) still executing, %d queued run(s) will be kept for the next start\n", resp.Running, resp.Queued)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "fetch and validate without installing")
+	cmd.Flags().BoolVar(&rollback, "rollback", false, "swap the previous deploy back into place")
+	cmd.Flags().BoolVar(&drain, "drain", false, "drain a running pit serve after a change so it restarts on the new code")
+	cmd.Flags().StringVar(&addr, "addr", "http://localhost:9090", "base URL of the running pit serve (with --drain)")
+	cmd.MarkFlagsMutuallyExclusive("check", "rollback")
+	return cmd
+}
+
+// deploySources returns the [[deploy]] sources to act on: all of them, or
+// the one whose target matches args[0].
+func deploySources(args []string) ([]config.DeployConfig, error) {
+	sources := resolveDeploy()
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no [[deploy]] sources in pit_config.toml")
+	}
+	if len(args) == 0 {
+		return sources, nil
+	}
+	want := filepath.Clean(args[0])
+	for _, src := range sources {
+		if workspaceRel(src.Target) == want || src.Target == want {
+			return []config.DeployConfig{src}, nil
+		}
+	}
+	return nil, fmt.Errorf("no [[deploy]] source has target %q", args[0])
+}
+
+// workspaceRel returns path relative to the workspace root when it lies inside it.
+func workspaceRel(path string) string {
+	root, err := filepath.Abs(projectDir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path
+	}
+	return rel
+}
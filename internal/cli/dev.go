@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	pitftp "github.com/druarnfield/pit/internal/ftp"
+	"github.com/spf13/cobra"
+)
+
+func newDevCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Local stand-ins for development and integration tests",
+		Long:  "Commands that run local versions of the external services DAGs use, so they can be developed and tested without shared infrastructure.",
+		// The dev servers need no workspace, so pit_config.toml is not loaded
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	cmd.AddCommand(newDevFTPServerCmd())
+	return cmd
+}
+
+func newDevFTPServerCmd() *cobra.Command {
+	var (
+		opts       pitftp.ServerOptions
+		secretName string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ftp-server",
+		Short: "Serve a local directory over FTP or SFTP",
+		Long: `Serve a directory over FTP or SFTP on loopback, for developing and testing
+ftp_watch triggers and the SDK's FTP methods. Files dropped in the directory
+appear on the server, and files tasks upload land there.
+
+On start it prints a secrets entry that points a DAG at the server. For SFTP
+the entry includes the host key; pass --host-key so the key, and the entry,
+stay the same across restarts.`,
+		Example: `  pit dev ftp-server --dir ./fixtures
+  pit dev ftp-server --dir ./fixtures --protocol sftp --host-key .pit/dev_host_key`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Addr == "" {
+				opts.Addr = "127.0.0.1:2121"
+				if opts.Protocol == pitftp.ProtocolSFTP {
+					opts.Addr = "127.0.0.1:2222"
+				}
+			}
+			if verbose {
+				opts.Logf = log.Printf
+			}
+			srv, err := pitftp.NewServer(opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				srv.Close()
+			}()
+
+			c := srv.ClientOptions()
+			log.Printf("pit dev ftp-server: serving %s over %s on %s", opts.Root, c.Protocol, srv.Addr())
+			fmt.Printf("\nAdd to secrets.toml to connect a DAG as secret %q:\n\n", secretName)
+			fmt.Printf("[global.%s]\n", secretName)
+			fmt.Printf("host = %q\nport = \"%d\"\nuser = %q\npassword = %q\nprotocol = %q\n", c.Host, c.Port, c.User, c.Password, c.Protocol)
+			if c.HostKey != "" {
+				fmt.Printf("host_key = %q\n", c.HostKey)
+			}
+			fmt.Println()
+
+			if err := srv.Serve(); err != nil {
+				return err
+			}
+			log.Println("pit dev ftp-server: stopped")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Root, "dir", "", "directory to serve as the server root (required)")
+	cmd.Flags().StringVar(&opts.Protocol, "protocol", pitftp.ProtocolFTP, "ftp or sftp")
+	cmd.Flags().StringVar(&opts.Addr, "addr", "", "listen address (default 127.0.0.1:2121 for ftp, 127.0.0.1:2222 for sftp)")
+	cmd.Flags().StringVar(&opts.User, "user", "pit", "user to accept")
+	cmd.Flags().StringVar(&opts.Password, "password", "pit", "password to accept")
+	cmd.Flags().StringVar(&opts.HostKeyFile, "host-key", "", "SFTP host key file, created if missing (default: a new key each start)")
+	cmd.Flags().StringVar(&secretName, "secret", "dev_ftp", "secret name used in the printed secrets entry")
+	cmd.MarkFlagRequired("dir")
+	return cmd
+}
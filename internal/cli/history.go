@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/druarnfield/pit/internal/meta"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history <dag>",
+		Short: "Show past runs of a DAG",
+		Long: "Show a DAG's recorded runs, newest first, with status, duration, trigger source, and logical date. " +
+			"Run IDs can be passed to `pit logs --run-id` and `pit annotate`.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			failedOnly, _ := cmd.Flags().GetBool("failed-only")
+			if limit < 1 {
+				return fmt.Errorf("--limit must be at least 1")
+			}
+
+			dbPath := resolveMetadataDB()
+			if _, err := os.Stat(dbPath); err != nil {
+				return fmt.Errorf("no metadata store at %s", dbPath)
+			}
+			store, err := meta.Open(dbPath)
+			if err != nil {
+				return fmt.Errorf("opening metadata store: %w", err)
+			}
+			defer store.Close()
+
+			runs, err := store.RunHistory(args[0], failedOnly, limit)
+			if err != nil {
+				return fmt.Errorf("querying history: %w", err)
+			}
+			w := cmd.OutOrStdout()
+			if len(runs) == 0 {
+				if failedOnly {
+					fmt.Fprintf(w, "No failed runs recorded for DAG %q.\n", args[0])
+				} else {
+					fmt.Fprintf(w, "No runs recorded for DAG %q.\n", args[0])
+				}
+				return nil
+			}
+			printHistory(w, runs)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("limit", 20, "number of runs to show")
+	cmd.Flags().Bool("failed-only", false, "show only failed runs")
+	return cmd
+}
+
+// printHistory writes one line per run. Runs recorded before logical
+// dates were tracked show "-".
+func printHistory(w io.Writer, runs []meta.RunRecord) {
+	const format = "%-21s %-12s %-8s %-10s %-10s %-40s %s\n"
+	fmt.Fprintf(w, format, "Started", "Logical Date", "Status", "Duration", "Trigger", "Run ID", "Note")
+	fmt.Fprintf(w, format, "───────", "────────────", "──────", "────────", "───────", "──────", "────")
+	for _, r := range runs {
+		duration := "running"
+		if r.EndedAt != nil {
+			duration = r.EndedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		logical := r.LogicalDate
+		if logical == "" {
+			logical = "-"
+		}
+		note := runNote(r)
+		if note == "" && r.Status == "failed" {
+			note = r.Error
+		}
+		fmt.Fprintf(w, format,
+			r.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			logical,
+			r.Status,
+			duration,
+			r.Trigger,
+			r.ID,
+			note,
+		)
+	}
+}
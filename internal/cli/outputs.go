@@ -3,10 +3,14 @@ package cli
 import (
 	"fmt"
 	"io"
-	"path/filepath"
+	"os"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/meta"
+	"github.com/druarnfield/pit/internal/remotepath"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +20,11 @@ type outputRow struct {
 	Name     string
 	Type     string
 	Location string
+
+	// From the metadata store, when a task reported producing the output.
+	ProducedAt *time.Time
+	Rows       *int64
+	Task       string
 }
 
 func newOutputsCmd() *cobra.Command {
@@ -26,6 +35,7 @@ func newOutputsCmd() *cobra.Command {
 			projectFilter, _ := cmd.Flags().GetString("project")
 			typeFilter, _ := cmd.Flags().GetString("type")
 			locationFilter, _ := cmd.Flags().GetString("location")
+			stale, _ := cmd.Flags().GetDuration("stale")
 
 			configs, err := config.Discover(projectDir)
 			if err != nil {
@@ -33,6 +43,26 @@ func newOutputsCmd() *cobra.Command {
 			}
 
 			rows := collectOutputs(configs, projectFilter, typeFilter, locationFilter)
+
+			// Fill in freshness from the metadata store, without creating
+			// one if no run has been recorded yet.
+			dbPath := resolveMetadataDB()
+			if _, err := os.Stat(dbPath); err == nil {
+				store, err := meta.Open(dbPath)
+				if err != nil {
+					return fmt.Errorf("opening metadata store: %w", err)
+				}
+				produced, err := store.LatestProducedOutputs()
+				store.Close()
+				if err != nil {
+					return fmt.Errorf("querying outputs: %w", err)
+				}
+				addProduced(rows, produced)
+			}
+			if stale > 0 {
+				rows = staleOutputs(rows, time.Now().Add(-stale))
+			}
+
 			if len(rows) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "no outputs found")
 				return nil
@@ -46,6 +76,7 @@ func newOutputsCmd() *cobra.Command {
 	cmd.Flags().String("project", "", "filter by project name")
 	cmd.Flags().String("type", "", "filter by output type")
 	cmd.Flags().String("location", "", "filter by output location (glob pattern)")
+	cmd.Flags().Duration("stale", 0, "only outputs not produced within this long, e.g. 24h")
 
 	return cmd
 }
@@ -64,7 +95,7 @@ func collectOutputs(configs map[string]*config.ProjectConfig, projectFilter, typ
 				continue
 			}
 			if locationFilter != "" {
-				matched, err := filepath.Match(locationFilter, out.Location)
+				matched, err := remotepath.Match(locationFilter, out.Location)
 				if err != nil || !matched {
 					continue
 				}
@@ -88,6 +119,31 @@ func collectOutputs(configs map[string]*config.ProjectConfig, projectFilter, typ
 	return rows
 }
 
+// addProduced fills in when each row's output was last reported produced.
+func addProduced(rows []outputRow, produced []meta.OutputRecord) {
+	byKey := make(map[[2]string]meta.OutputRecord, len(produced))
+	for _, o := range produced {
+		byKey[[2]string{o.DAGName, o.Name}] = o
+	}
+	for i := range rows {
+		if o, ok := byKey[[2]string{rows[i].Project, rows[i].Name}]; ok {
+			rows[i].ProducedAt, rows[i].Rows, rows[i].Task = o.ProducedAt, o.Rows, o.TaskName
+		}
+	}
+}
+
+// staleOutputs returns the rows never reported produced, or last produced
+// before cutoff.
+func staleOutputs(rows []outputRow, cutoff time.Time) []outputRow {
+	var out []outputRow
+	for _, r := range rows {
+		if r.ProducedAt == nil || r.ProducedAt.Before(cutoff) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 // printOutputTable writes a formatted table of output rows to w with dynamic column widths.
 func printOutputTable(w io.Writer, rows []outputRow) {
 	// Calculate column widths
@@ -107,17 +163,36 @@ func printOutputTable(w io.Writer, rows []outputRow) {
 		}
 	}
 
-	fmtStr := fmt.Sprintf("  %%-%ds  %%-%ds  %%-%ds  %%s\n", pW, nW, tW)
-
-	// Header
-	fmt.Fprintf(w, fmtStr, "PROJECT", "NAME", "TYPE", "LOCATION")
-
-	// Separator
-	fmt.Fprintf(w, fmtStr, dashes(pW), dashes(nW), dashes(tW), dashes(lW))
+	// Freshness columns only when some output has been reported produced
+	withProduced := false
+	for _, r := range rows {
+		if r.ProducedAt != nil {
+			withProduced = true
+			break
+		}
+	}
+	if !withProduced {
+		fmtStr := fmt.Sprintf("  %%-%ds  %%-%ds  %%-%ds  %%s\n", pW, nW, tW)
+		fmt.Fprintf(w, fmtStr, "PROJECT", "NAME", "TYPE", "LOCATION")
+		fmt.Fprintf(w, fmtStr, dashes(pW), dashes(nW), dashes(tW), dashes(lW))
+		for _, r := range rows {
+			fmt.Fprintf(w, fmtStr, r.Project, r.Name, r.Type, r.Location)
+		}
+		return
+	}
 
-	// Rows
+	fmtStr := fmt.Sprintf("  %%-%ds  %%-%ds  %%-%ds  %%-%ds  %%-19s  %%s\n", pW, nW, tW, lW)
+	fmt.Fprintf(w, fmtStr, "PROJECT", "NAME", "TYPE", "LOCATION", "PRODUCED", "ROWS")
+	fmt.Fprintf(w, fmtStr, dashes(pW), dashes(nW), dashes(tW), dashes(lW), dashes(19), dashes(4))
 	for _, r := range rows {
-		fmt.Fprintf(w, fmtStr, r.Project, r.Name, r.Type, r.Location)
+		produced, count := "-", "-"
+		if r.ProducedAt != nil {
+			produced = r.ProducedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if r.Rows != nil {
+			count = strconv.FormatInt(*r.Rows, 10)
+		}
+		fmt.Fprintf(w, fmtStr, r.Project, r.Name, r.Type, r.Location, produced, count)
 	}
 }
 
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/meta"
 )
 
 func testConfigs() map[string]*config.ProjectConfig {
@@ -64,12 +68,7 @@ func TestCollectOutputs_TypeFilter(t *testing.T) {
 
 func TestCollectOutputs_LocationGlob(t *testing.T) {
 	rows := collectOutputs(testConfigs(), "", "", "warehouse.*")
-	// filepath.Match treats * as matching non-separator chars.
-	// "warehouse.*" matches "warehouse.staging.claims" only if . is not a separator.
-	// On Unix, filepath.Separator is '/', so . is just a normal char, and * matches it.
-	// Wait — filepath.Match's * does NOT match separator, but . is not filepath.Separator.
-	// However, * in filepath.Match matches any sequence of non-Separator characters.
-	// So "warehouse.*" will match "warehouse.staging.claims" because . is not /.
+	// "*" matches any run of characters except "/", so it spans the dots.
 	if len(rows) != 2 {
 		t.Fatalf("len(rows) = %d, want 2", len(rows))
 	}
@@ -80,6 +79,15 @@ func TestCollectOutputs_LocationGlob(t *testing.T) {
 	}
 }
 
+func TestCollectOutputs_LocationGlobUNC(t *testing.T) {
+	for _, filter := range []string{"//sftp/reports/*", `\\sftp\reports\*.csv`} {
+		rows := collectOutputs(testConfigs(), "", "", filter)
+		if len(rows) != 1 || rows[0].Name != "daily_report" {
+			t.Errorf("collectOutputs(%q) = %+v, want daily_report", filter, rows)
+		}
+	}
+}
+
 func TestCollectOutputs_CombinedFilters(t *testing.T) {
 	rows := collectOutputs(testConfigs(), "claims_pipeline", "table", "")
 	if len(rows) != 2 {
@@ -135,3 +143,29 @@ func TestCollectOutputs_SortOrder(t *testing.T) {
 		t.Errorf("rows[2].Name = %q, want 'beta'", rows[2].Name)
 	}
 }
+
+func TestAddProducedAndStale(t *testing.T) {
+	rows := collectOutputs(testConfigs(), "", "", "")
+	now := time.Now()
+	recent, old := now.Add(-time.Hour), now.Add(-48*time.Hour)
+	n := int64(500)
+	addProduced(rows, []meta.OutputRecord{
+		{DAGName: "claims_pipeline", Name: "claims_staging", ProducedAt: &recent, Rows: &n, TaskName: "load"},
+		{DAGName: "monthly_reports", Name: "daily_report", ProducedAt: &old},
+	})
+
+	stale := staleOutputs(rows, now.Add(-24*time.Hour))
+	var names []string
+	for _, r := range stale {
+		names = append(names, r.Name)
+	}
+	if got := strings.Join(names, ","); got != "claim_lines_staging,daily_report" {
+		t.Errorf("stale outputs = %s, want claim_lines_staging,daily_report", got)
+	}
+
+	var buf bytes.Buffer
+	printOutputTable(&buf, rows)
+	if out := buf.String(); !strings.Contains(out, "PRODUCED") || !strings.Contains(out, "500") {
+		t.Errorf("table missing freshness columns:\n%s", out)
+	}
+}
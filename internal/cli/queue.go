@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newQueueCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and manage runs queued in a running pit serve",
+	}
+	cmd.PersistentFlags().StringVar(&addr, "addr", "http://localhost:9090", "base URL of the running pit serve")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List queued runs in dispatch order",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var resp struct {
+					Running int `json:"running"`
+					Queued  []struct {
+						DAGName    string    `json:"dag_name"`
+						Source     string    `json:"source"`
+						Priority   int       `json:"priority"`
+						EnqueuedAt time.Time `json:"enqueued_at"`
+						Files      []string  `json:"files"`
+					} `json:"queued"`
+				}
+				if err := controlRequest(cmd.Context(), http.MethodGet, addr, "/control/queue", &resp); err != nil {
+					return err
+				}
+
+				fmt.Printf("%d run(s) executing, %d queued\n", resp.Running, len(resp.Queued))
+				if len(resp.Queued) == 0 {
+					return nil
+				}
+				fmt.Println()
+				fmt.Printf("%-20s %-10s %-8s %-21s %s\n", "DAG", "Source", "Priority", "Queued At", "Files")
+				fmt.Printf("%-20s %-10s %-8s %-21s %s\n", "───", "──────", "────────", "─────────", "─────")
+				for _, q := range resp.Queued {
+					fmt.Printf("%-20s %-10s %-8d %-21s %s\n",
+						q.DAGName,
+						q.Source,
+						q.Priority,
+						q.EnqueuedAt.Local().Format("2006-01-02 15:04:05"),
+						strings.Join(q.Files, ", "),
+					)
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "clear <dag>",
+			Short: "Discard all queued runs for a DAG (executing runs are not affected)",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var resp struct {
+					Removed int `json:"removed"`
+				}
+				path := "/control/queue/" + url.PathEscape(args[0])
+				if err := controlRequest(cmd.Context(), http.MethodDelete, addr, path, &resp); err != nil {
+					return err
+				}
+				fmt.Printf("Removed %d queued run(s) for %s\n", resp.Removed, args[0])
+				return nil
+			},
+		},
+	)
+
+	return cmd
+}
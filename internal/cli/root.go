@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/druarnfield/pit/internal/buildinfo"
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/serve"
 	"github.com/spf13/cobra"
 )
 
@@ -20,9 +23,10 @@ var (
 
 func newRootCmd() *cobra.Command {
 	root := &cobra.Command{
-		Use:   "pit",
-		Short: "Lightweight data pipeline orchestrator",
-		Long:  "Pit is a lightweight data orchestration tool that manages DAGs of Python tasks via UV.",
+		Use:     "pit",
+		Short:   "Lightweight data pipeline orchestrator",
+		Long:    "Pit is a lightweight data orchestration tool that manages DAGs of Python tasks via UV.",
+		Version: buildinfo.Version(),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Load workspace-level config if it exists
 			pitCfg, err := config.LoadPitConfig(projectDir)
@@ -44,7 +48,7 @@ func newRootCmd() *cobra.Command {
 
 	root.PersistentFlags().StringVar(&projectDir, "project-dir", ".", "root project directory")
 	root.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable verbose output")
-	root.PersistentFlags().StringVar(&secretsPath, "secrets", "", "path to secrets file")
+	root.PersistentFlags().StringVar(&secretsPath, "secrets", "", "path to secrets file or directory")
 
 	root.AddCommand(
 		newNewCmd(),
@@ -54,10 +58,17 @@ func newRootCmd() *cobra.Command {
 		newCompileCmd(),
 		newSyncCmd(),
 		newStatusCmd(),
+		newHistoryCmd(),
 		newOutputsCmd(),
+		newAnnotateCmd(),
 		newLogsCmd(),
 		newServeCmd(),
+		newQueueCmd(),
+		newWorkerCmd(),
 		newSecretsCmd(),
+		newDeployCmd(),
+		newDevCmd(),
+		newVersionCmd(),
 	)
 
 	return root
@@ -114,6 +125,22 @@ func resolveMetadataDB() string {
 	return filepath.Join(projectDir, "pit_metadata.db")
 }
 
+// resolveServeStateFile returns the serve state file path from workspace config or the default.
+func resolveServeStateFile() string {
+	if workspaceCfg != nil && workspaceCfg.ServeStateFile != "" {
+		return workspaceCfg.ServeStateFile
+	}
+	return filepath.Join(projectDir, "pit_serve_state.json")
+}
+
+// resolveAuditLog returns the audit log path from workspace config or the default.
+func resolveAuditLog() string {
+	if workspaceCfg != nil && workspaceCfg.AuditLog != "" {
+		return workspaceCfg.AuditLog
+	}
+	return filepath.Join(projectDir, "pit_audit.jsonl")
+}
+
 // resolveSecretsRecipients returns the recipients file path from workspace config.
 func resolveSecretsRecipients() string {
 	if workspaceCfg != nil && workspaceCfg.SecretsRecipients != "" {
@@ -130,9 +157,122 @@ func resolveAgeIdentityPath() string {
 	return ""
 }
 
+// resolveMinFreeSpace returns the runs volume headroom from workspace config
+// (0 = engine default). An explicit min_free_space = 0 turns the check off,
+// which the engine spells as a negative value.
+func resolveMinFreeSpace() int64 {
+	if workspaceCfg == nil || workspaceCfg.MinFreeSpace == nil {
+		return 0
+	}
+	if *workspaceCfg.MinFreeSpace == 0 {
+		return -1
+	}
+	return int64(*workspaceCfg.MinFreeSpace)
+}
+
+// resolveSnapshotWorkers returns the snapshot copy concurrency from workspace config (0 = engine default).
+func resolveSnapshotWorkers() int {
+	if workspaceCfg != nil && workspaceCfg.Snapshot != nil {
+		return workspaceCfg.Snapshot.Workers
+	}
+	return 0
+}
+
+// resolveSDKSocketDir returns the directory for per-run SDK sockets from workspace config ("" = the run directory).
+func resolveSDKSocketDir() string {
+	if workspaceCfg != nil {
+		return workspaceCfg.SDKSocketDir
+	}
+	return ""
+}
+
+// resolveSnapshotSymlinks returns the snapshot symlink policy from workspace config ("" = engine default).
+func resolveSnapshotSymlinks() string {
+	if workspaceCfg != nil && workspaceCfg.Snapshot != nil {
+		return workspaceCfg.Snapshot.Symlinks
+	}
+	return ""
+}
+
+// resolveSnapshotReadOnly reports whether workspace config write-protects snapshots for every DAG.
+func resolveSnapshotReadOnly() bool {
+	return workspaceCfg != nil && workspaceCfg.Snapshot != nil && workspaceCfg.Snapshot.ReadOnly
+}
+
+// resolveArtifactStore returns the artifact upload target from workspace config (nil = keep locally).
+func resolveArtifactStore() *config.ArtifactStoreConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.ArtifactStore
+	}
+	return nil
+}
+
+// resolveLoader returns the workspace [loader] defaults (nil = driver defaults).
+func resolveLoader() *config.LoaderConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Loader
+	}
+	return nil
+}
+
+// resolveEnv returns the workspace [env] policy for task processes (nil = inherit everything).
+func resolveEnv() *config.EnvConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Env
+	}
+	return nil
+}
+
+// resolveLineage returns the workspace [lineage] endpoint (nil = no lineage events).
+func resolveLineage() *config.LineageConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Lineage
+	}
+	return nil
+}
+
+// resolveLogSinks returns the workspace [[log_sink]] destinations for pit serve (nil = none).
+func resolveLogSinks() []config.LogSinkConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.LogSinks
+	}
+	return nil
+}
+
+// resolvePlugins returns the workspace [plugins.<name>] tables (nil = none).
+func resolvePlugins() map[string]config.PluginConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Plugins
+	}
+	return nil
+}
+
+// resolveDeploy returns the workspace [[deploy]] sources (nil = none).
+func resolveDeploy() []config.DeployConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Deploy
+	}
+	return nil
+}
+
+// resolveDefaults returns the workspace [defaults] (nil = none).
+func resolveDefaults() *config.DefaultsConfig {
+	if workspaceCfg != nil {
+		return workspaceCfg.Defaults
+	}
+	return nil
+}
+
+// exitDrained is the exit status of pit serve after a drain, so service
+// managers and deploy scripts can tell a planned stop from a crash.
+const exitDrained = 3
+
 // Execute runs the root command.
 func Execute() {
 	if err := newRootCmd().Execute(); err != nil {
+		if errors.Is(err, serve.ErrDrained) {
+			os.Exit(exitDrained)
+		}
 		os.Exit(1)
 	}
 }
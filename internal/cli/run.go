@@ -1,14 +1,19 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/druarnfield/pit/internal/audit"
 	"github.com/druarnfield/pit/internal/config"
 	"github.com/druarnfield/pit/internal/dag"
 	"github.com/druarnfield/pit/internal/engine"
@@ -21,14 +26,29 @@ import (
 var errRunFailed = errors.New("run failed")
 
 func newRunCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "run <dag>[/<task>]",
+	var (
+		paramArgs   []string
+		all         bool
+		concurrency int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run <dag>[/<task>] | <pattern> | --all",
 		Short: "Execute a DAG run",
-		Long:  "Run a full DAG or a single task within a DAG. Use dag/task syntax to run a single task.",
-		Args:  cobra.ExactArgs(1),
+		Long: `Run a full DAG or a single task within a DAG. Use dag/task syntax to run a single task.
+
+A glob pattern such as "claims_*" runs every matching DAG, and --all runs
+every DAG in the workspace. Each DAG is validated and applies its own
+overlap policy; DAGs run one at a time unless --concurrency is raised.`,
+		Args: cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Parse dag/task argument
-			dagName, taskName, err := parseRunArg(args[0])
+			if all == (len(args) == 1) {
+				return fmt.Errorf("give a DAG name or pattern, or --all")
+			}
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be at least 1")
+			}
+			params, err := parseParams(paramArgs)
 			if err != nil {
 				return err
 			}
@@ -39,17 +59,29 @@ func newRunCmd() *cobra.Command {
 				return err
 			}
 
-			cfg, ok := configs[dagName]
-			if !ok {
-				return fmt.Errorf("DAG %q not found (available: %s)", dagName, availableDAGs(configs))
+			var dagName, taskName string
+			multi := all
+			if !all {
+				if isDAGPattern(args[0]) {
+					if strings.Contains(args[0], "/") {
+						return fmt.Errorf("a DAG pattern cannot select a task: %q", args[0])
+					}
+					multi = true
+				} else if dagName, taskName, err = parseRunArg(args[0]); err != nil {
+					return err
+				}
 			}
-
-			// Validate before running
-			if errs := dag.Validate(cfg, cfg.Dir()); len(errs) > 0 {
-				for _, e := range errs {
-					cmd.PrintErrf("ERROR: %s\n", e)
+			var names []string
+			if multi {
+				var pattern string
+				if len(args) == 1 {
+					pattern = args[0]
+				}
+				if names, err = selectDAGs(configs, pattern); err != nil {
+					return err
 				}
-				return fmt.Errorf("validation failed with %d error(s)", len(errs))
+			} else if _, ok := configs[dagName]; !ok {
+				return fmt.Errorf("DAG %q not found (available: %s)", dagName, availableDAGs(configs))
 			}
 
 			// Open metadata store
@@ -59,35 +91,339 @@ func newRunCmd() *cobra.Command {
 			}
 			defer metaStore.Close()
 
+			// A CLI run still goes ahead without its audit trail (a nil log
+			// discards events), e.g. in a read-only workspace
+			auditLog, err := audit.Open(resolveAuditLog())
+			if err != nil {
+				cmd.PrintErrf("warning: %v; audit events for this run are not recorded\n", err)
+			}
+			defer auditLog.Close()
+
 			// Set up signal handling for graceful cancellation
 			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
 
-			opts := engine.ExecuteOpts{
-				RunsDir:       resolveRunsDir(),
-				RepoCacheDir:  resolveRepoCacheDir(),
-				TaskName:      taskName,
-				Verbose:       verbose,
-				SecretsPath:   secretsPath,
-				DBTDriver:     resolveDBTDriver(),
-				KeepArtifacts: resolveKeepArtifacts(cfg.DAG.KeepArtifacts),
-				MetaStore:     metaStore,
-				Trigger:       "manual",
-				AgeIdentity:   resolveAgeIdentityPath(),
+			r := &dagRunner{cmd: cmd, params: params, metaStore: metaStore, auditLog: auditLog}
+			if !multi {
+				_, err := r.run(ctx, configs[dagName], taskName)
+				return err
 			}
+			return r.runAll(ctx, configs, names, concurrency)
+		},
+	}
 
-			run, err := engine.Execute(ctx, cfg, opts)
-			if err != nil {
-				return err
+	cmd.Flags().StringArrayVar(&paramArgs, "param", nil, "run parameter as key=value, overriding [dag.params] (repeatable)")
+	cmd.Flags().BoolVar(&all, "all", false, "run every DAG in the workspace")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "with a pattern or --all, how many DAGs run at once")
+	return cmd
+}
+
+// dagRunner runs DAGs from the CLI, sharing one metadata store and audit
+// log between them.
+type dagRunner struct {
+	cmd       *cobra.Command
+	params    map[string]string
+	metaStore *meta.SQLiteStore
+	auditLog  *audit.Log
+}
+
+// Outcomes of a DAG in a multi-DAG run besides the run statuses.
+const (
+	dagInvalid = "invalid" // failed validation; not run
+	dagSkipped = "skipped" // overlap=skip and already running
+	dagError   = "error"   // the run could not start or finish
+	dagBlocked = "blocked" // a DAG it requires did not succeed; not run
+)
+
+// run validates and executes one DAG (or one of its tasks) and returns
+// its outcome: a run status, dagInvalid, dagSkipped, or dagError. The
+// error is errRunFailed when the run finished with failed tasks.
+func (r *dagRunner) run(ctx context.Context, cfg *config.ProjectConfig, taskName string) (string, error) {
+	cmd := r.cmd
+	dagName := cfg.DAG.Name
+
+	// Validate before running
+	if errs := dag.Validate(cfg, cfg.Dir()); len(errs) > 0 {
+		for _, e := range errs {
+			cmd.PrintErrf("ERROR: %s\n", e)
+		}
+		return dagInvalid, fmt.Errorf("validation failed with %d error(s)", len(errs))
+	}
+
+	opts := engine.ExecuteOpts{
+		RunsDir:          resolveRunsDir(),
+		RepoCacheDir:     resolveRepoCacheDir(),
+		TaskName:         taskName,
+		Verbose:          verbose,
+		SecretsPath:      secretsPath,
+		DBTDriver:        resolveDBTDriver(),
+		KeepArtifacts:    resolveKeepArtifacts(cfg.DAG.KeepArtifacts),
+		MetaStore:        r.metaStore,
+		Trigger:          "manual",
+		AgeIdentity:      resolveAgeIdentityPath(),
+		MinFreeSpace:     resolveMinFreeSpace(),
+		SnapshotWorkers:  resolveSnapshotWorkers(),
+		SDKSocketDir:     resolveSDKSocketDir(),
+		SnapshotSymlinks: resolveSnapshotSymlinks(),
+		SnapshotReadOnly: resolveSnapshotReadOnly(),
+		ArtifactStore:    resolveArtifactStore(),
+		Loader:           resolveLoader(),
+		Plugins:          resolvePlugins(),
+		Env:              resolveEnv(),
+		Lineage:          resolveLineage(),
+		Params:           r.params,
+		OnStall: func(run *engine.Run, task string, idle time.Duration) {
+			cmd.PrintErrf("warning: task %q has produced no output for %s\n", task, idle.Round(time.Second))
+		},
+	}
+
+	opts.RunID = engine.GenerateRunID(cfg.DAG.Name)
+
+	lock, err := acquireDAGLock(ctx, cmd, cfg, opts.RunsDir, opts.RunID)
+	if errors.Is(err, errOverlapSkipped) {
+		r.auditLog.Record(audit.Event{Action: audit.ActionRunSkipped, Source: "cli", DAGName: dagName, Detail: "overlap=skip"})
+		return dagSkipped, nil
+	}
+	if errors.Is(err, errMaxActiveSkipped) {
+		r.auditLog.Record(audit.Event{Action: audit.ActionRunSkipped, Source: "cli", DAGName: dagName, Detail: "max_active_runs"})
+		return dagSkipped, nil
+	}
+	if err != nil {
+		return dagError, err
+	}
+	if lock != nil {
+		defer func() {
+			if err := lock.Release(); err != nil {
+				cmd.PrintErrf("warning: %v\n", err)
 			}
+		}()
+	}
+
+	r.auditLog.Record(audit.Event{Action: audit.ActionManualRun, Source: "cli", DAGName: dagName, RunID: opts.RunID, Detail: taskName})
+
+	run, err := engine.Execute(ctx, cfg, opts)
+	if err != nil {
+		r.auditLog.Record(audit.Event{Action: audit.ActionRunFinished, Source: "cli", DAGName: dagName, RunID: opts.RunID, Status: string(engine.StatusFailed), Detail: err.Error()})
+		return dagError, err
+	}
+	r.auditLog.Record(audit.Event{Action: audit.ActionRunFinished, Source: "cli", DAGName: dagName, RunID: run.ID, Status: string(run.Status)})
+
+	if run.Status == engine.StatusFailed {
+		return string(run.Status), errRunFailed
+	}
+
+	return string(run.Status), nil
+}
+
+// runAll runs the named DAGs, up to concurrency at a time, and prints a
+// summary. A DAG starts once the DAGs it requires among names have
+// finished, and is blocked if any of them did not succeed. Otherwise one
+// DAG failing does not stop the others; the command fails if any of them
+// did. DAGs not yet started when ctx is cancelled are left out.
+func (r *dagRunner) runAll(ctx context.Context, configs map[string]*config.ProjectConfig, names []string, concurrency int) error {
+	names, err := dag.OrderByRequires(configs, names)
+	if err != nil {
+		return err
+	}
+
+	outcomes, errs := dispatchDAGs(ctx, configs, names, concurrency, func(ctx context.Context, name string) (string, error) {
+		r.cmd.PrintErrf("==> %s\n", name)
+		return r.run(ctx, configs[name], "")
+	})
 
-			if run.Status == engine.StatusFailed {
-				return errRunFailed
+	failed := 0
+	r.cmd.Printf("\n%d DAG(s):\n", len(names))
+	for i, name := range names {
+		outcome := outcomes[i]
+		if outcome != string(engine.StatusSuccess) && outcome != dagSkipped {
+			failed++
+		}
+		switch {
+		case outcome == "":
+			outcome = "not started"
+		case errs[i] != nil && !errors.Is(errs[i], errRunFailed):
+			outcome += ": " + errs[i].Error()
+		}
+		r.cmd.Printf("  %-30s %s\n", name, outcome)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d DAG(s) did not succeed", failed, len(names))
+	}
+	return nil
+}
+
+// dispatchDAGs calls run for each of names, which must be in dag.requires
+// order, with up to concurrency calls at once, and returns each DAG's
+// outcome and error by position. Whenever a slot is free it starts the
+// first DAG whose requirements have all finished, so a DAG waiting on a
+// slow requirement does not hold back unrelated DAGs listed after it. A
+// DAG whose requirement did not succeed is marked dagBlocked without
+// running. Once ctx is cancelled no more DAGs start; their outcome is "".
+func dispatchDAGs(ctx context.Context, configs map[string]*config.ProjectConfig, names []string, concurrency int, run func(ctx context.Context, name string) (string, error)) ([]string, []error) {
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	const (
+		pending = iota
+		running
+		finished
+	)
+	state := make([]int, len(names))
+	outcomes := make([]string, len(names))
+	errs := make([]error, len(names))
+	done := make(chan int, len(names))
+	active, left := 0, len(names)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for left > 0 {
+		// Names are in requires order, so marking a DAG blocked here is
+		// seen by the DAGs after it in the same pass.
+		for i, name := range names {
+			if state[i] != pending {
+				continue
+			}
+			ready, failedReq := true, ""
+			for _, req := range configs[name].DAG.Requires {
+				j, ok := index[req]
+				if !ok {
+					continue
+				}
+				if state[j] != finished {
+					ready = false
+					break
+				}
+				if outcomes[j] != string(engine.StatusSuccess) {
+					failedReq = req
+					break
+				}
+			}
+			if failedReq != "" {
+				outcomes[i] = dagBlocked
+				errs[i] = fmt.Errorf("required DAG %q did not succeed", failedReq)
+				state[i] = finished
+				left--
+				continue
+			}
+			if !ready || active == concurrency || ctx.Err() != nil {
+				continue
 			}
 
-			return nil
-		},
+			state[i] = running
+			active++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				outcomes[i], errs[i] = run(ctx, name)
+				done <- i
+			}()
+		}
+		if active == 0 {
+			return outcomes, errs
+		}
+
+		select {
+		case i := <-done:
+			state[i] = finished
+			active--
+			left--
+		case <-ctx.Done():
+			return outcomes, errs
+		}
+	}
+	return outcomes, errs
+}
+
+// isDAGPattern reports whether arg is a glob pattern rather than a DAG
+// name.
+func isDAGPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// selectDAGs returns the sorted names of the DAGs matching pattern, or of
+// every DAG when pattern is empty.
+func selectDAGs(configs map[string]*config.ProjectConfig, pattern string) ([]string, error) {
+	var names []string
+	for name := range configs {
+		if pattern == "" {
+			names = append(names, name)
+			continue
+		}
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DAG pattern %q: %w", pattern, err)
+		}
+		if ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		if pattern == "" {
+			return nil, fmt.Errorf("no DAGs found")
+		}
+		return nil, fmt.Errorf("no DAGs match %q (available: %s)", pattern, availableDAGs(configs))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// parseParams parses --param key=value flags into a map.
+func parseParams(args []string) (map[string]string, error) {
+	params := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --param %q: expected key=value", arg)
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+// errOverlapSkipped is returned by acquireDAGLock when the DAG is already
+// running and its overlap policy is "skip".
+var errOverlapSkipped = errors.New("run skipped (overlap=skip)")
+
+// errMaxActiveSkipped is returned by acquireDAGLock when the DAG already has
+// max_active_runs runs and its overflow policy is "skip".
+var errMaxActiveSkipped = errors.New("run skipped (max_active_runs)")
+
+// acquireDAGLock applies dag.overlap to concurrent CLI runs of the same DAG
+// using a lock file in the runs dir. With overlap "allow" (the default)
+// runs may proceed side by side, up to dag.max_active_runs if set; the
+// lock is nil when there is no limit.
+func acquireDAGLock(ctx context.Context, cmd *cobra.Command, cfg *config.ProjectConfig, runsDir, runID string) (*engine.DAGLock, error) {
+	switch cfg.DAG.Overlap {
+	case "skip":
+		lock, err := engine.TryDAGLock(runsDir, cfg.DAG.Name, runID)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			cmd.PrintErrf("Skipping: %v (overlap=skip)\n", locked)
+			return nil, errOverlapSkipped
+		}
+		return lock, err
+	case "wait":
+		return engine.WaitDAGLock(ctx, runsDir, cfg.DAG.Name, runID, func(holder engine.LockInfo) {
+			cmd.PrintErrf("Waiting: DAG %q is already running: %s (overlap=wait)\n", cfg.DAG.Name, holder)
+		})
+	}
+	if limit := cfg.DAG.MaxActiveRuns; limit > 0 {
+		if cfg.DAG.MaxActiveOverflow == "queue" {
+			return engine.WaitDAGSlot(ctx, runsDir, cfg.DAG.Name, runID, limit, func(holder engine.LockInfo) {
+				cmd.PrintErrf("Waiting: DAG %q already has %d active run(s), the oldest %s (max_active_runs)\n", cfg.DAG.Name, limit, holder)
+			})
+		}
+		lock, err := engine.TryDAGSlot(runsDir, cfg.DAG.Name, runID, limit)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			cmd.PrintErrf("Skipping: %v\n", locked)
+			return nil, errMaxActiveSkipped
+		}
+		return lock, err
 	}
+	return nil, nil
 }
 
 // parseRunArg splits "dag/task" into dag name and optional task name.
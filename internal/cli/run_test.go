@@ -1,10 +1,17 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/spf13/cobra"
 )
 
 func TestParseRunArg(t *testing.T) {
@@ -44,6 +51,28 @@ func TestParseRunArg(t *testing.T) {
 	}
 }
 
+func TestParseParams(t *testing.T) {
+	params, err := parseParams([]string{"full_refresh=true", "since=2024-01-01", "filter=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseParams() error: %v", err)
+	}
+	want := map[string]string{"full_refresh": "true", "since": "2024-01-01", "filter": "a=b", "empty": ""}
+	if len(params) != len(want) {
+		t.Fatalf("parseParams() = %v, want %v", params, want)
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("params[%q] = %q, want %q", k, params[k], v)
+		}
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		if _, err := parseParams([]string{bad}); err == nil {
+			t.Errorf("parseParams(%q) expected error, got nil", bad)
+		}
+	}
+}
+
 func TestAvailableDAGs(t *testing.T) {
 	configs := map[string]*config.ProjectConfig{
 		"charlie": {},
@@ -83,6 +112,77 @@ func TestAvailableDAGs_Sorted(t *testing.T) {
 	}
 }
 
+func TestSelectDAGs(t *testing.T) {
+	configs := map[string]*config.ProjectConfig{
+		"claims_daily": {}, "claims_monthly": {}, "payments": {}, "claims": {},
+	}
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{pattern: "", want: "claims claims_daily claims_monthly payments"},
+		{pattern: "claims_*", want: "claims_daily claims_monthly"},
+		{pattern: "*ly", want: "claims_daily claims_monthly"},
+		{pattern: "pay?ents", want: "payments"},
+		{pattern: "orders_*", wantErr: `no DAGs match "orders_*"`},
+		{pattern: "claims_[", wantErr: "invalid DAG pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			names, err := selectDAGs(configs, tt.pattern)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("selectDAGs(%q) error = %v, want %q", tt.pattern, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectDAGs(%q) error: %v", tt.pattern, err)
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Errorf("selectDAGs(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDAGPattern(t *testing.T) {
+	for arg, want := range map[string]bool{
+		"claims_*": true, "claims_?": true, "claims_[ab]": true,
+		"claims": false, "claims/load": false,
+	} {
+		if got := isDAGPattern(arg); got != want {
+			t.Errorf("isDAGPattern(%q) = %v, want %v", arg, got, want)
+		}
+	}
+}
+
+func TestRunCmd_Selection(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"nothing", nil, "give a DAG name or pattern, or --all"},
+		{"all and name", []string{"--all", "claims"}, "give a DAG name or pattern, or --all"},
+		{"bad concurrency", []string{"--all", "--concurrency", "0"}, "--concurrency must be at least 1"},
+		{"pattern with task", []string{"claims_*/load"}, "a DAG pattern cannot select a task"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRunCmd()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run %v: error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // splitCSV splits a comma-separated string, trimming spaces.
 func splitCSV(s string) []string {
 	if s == "" {
@@ -110,3 +210,87 @@ func trimSpace(s string) string {
 	}
 	return s[start:end]
 }
+
+func TestAcquireDAGLock(t *testing.T) {
+	runsDir := t.TempDir()
+	held, err := engine.TryDAGLock(runsDir, "my_dag", "run0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+
+	cmd := &cobra.Command{}
+	var out bytes.Buffer
+	cmd.SetErr(&out)
+
+	skip := &config.ProjectConfig{DAG: config.DAGConfig{Name: "my_dag", Overlap: "skip"}}
+	if _, err := acquireDAGLock(context.Background(), cmd, skip, runsDir, "run1"); !errors.Is(err, errOverlapSkipped) {
+		t.Errorf("overlap=skip error = %v, want errOverlapSkipped", err)
+	}
+	if !strings.Contains(out.String(), "run0") {
+		t.Errorf("skip message %q should name the running run", out.String())
+	}
+
+	allow := &config.ProjectConfig{DAG: config.DAGConfig{Name: "my_dag"}}
+	lock, err := acquireDAGLock(context.Background(), cmd, allow, runsDir, "run2")
+	if err != nil || lock != nil {
+		t.Errorf("overlap=allow = (%v, %v), want no lock", lock, err)
+	}
+}
+
+func TestDispatchDAGs_StartsAnyReadyDAG(t *testing.T) {
+	configs := map[string]*config.ProjectConfig{
+		"extract":   {DAG: config.DAGConfig{Name: "extract"}},
+		"transform": {DAG: config.DAGConfig{Name: "transform", Requires: []string{"extract"}}},
+		"report":    {DAG: config.DAGConfig{Name: "report", Requires: []string{"transform"}}},
+		"unrelated": {DAG: config.DAGConfig{Name: "unrelated"}},
+	}
+	names := []string{"extract", "transform", "report", "unrelated"}
+
+	// extract only succeeds if unrelated runs while it does; with
+	// head-of-line dispatch, unrelated would wait behind transform.
+	unrelatedDone := make(chan struct{})
+	run := func(ctx context.Context, name string) (string, error) {
+		switch name {
+		case "extract":
+			select {
+			case <-unrelatedDone:
+			case <-time.After(2 * time.Second):
+				return string(engine.StatusFailed), errRunFailed
+			}
+		case "transform":
+			return string(engine.StatusFailed), errRunFailed
+		case "unrelated":
+			close(unrelatedDone)
+		}
+		return string(engine.StatusSuccess), nil
+	}
+
+	outcomes, errs := dispatchDAGs(context.Background(), configs, names, 2, run)
+	want := []string{string(engine.StatusSuccess), string(engine.StatusFailed), dagBlocked, string(engine.StatusSuccess)}
+	for i, name := range names {
+		if outcomes[i] != want[i] {
+			t.Errorf("%s outcome = %q (err %v), want %q", name, outcomes[i], errs[i], want[i])
+		}
+	}
+	if errs[2] == nil || !strings.Contains(errs[2].Error(), `"transform"`) {
+		t.Errorf("report error = %v, want it to name transform", errs[2])
+	}
+}
+
+func TestDispatchDAGs_Cancelled(t *testing.T) {
+	configs := map[string]*config.ProjectConfig{
+		"a": {DAG: config.DAGConfig{Name: "a"}},
+		"b": {DAG: config.DAGConfig{Name: "b"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := func(ctx context.Context, name string) (string, error) {
+		cancel()
+		return string(engine.StatusSuccess), nil
+	}
+
+	outcomes, _ := dispatchDAGs(ctx, configs, []string{"a", "b"}, 1, run)
+	if outcomes[0] != string(engine.StatusSuccess) || outcomes[1] != "" {
+		t.Errorf("outcomes = %q, want a to finish and b not to start", outcomes)
+	}
+}
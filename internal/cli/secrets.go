@@ -559,6 +559,9 @@ func newSecretsAddRecipientCmd() *cobra.Command {
 // decryptSecretsFile decrypts an age-encrypted secrets file, trying PIT_AGE_KEY
 // env var first, then falling back to file-based identity resolution.
 func decryptSecretsFile(path string) ([]byte, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%s is a secrets directory; pass --secrets with one of its .age files", path)
+	}
 	ciphertext, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading encrypted secrets %q: %w", path, err)
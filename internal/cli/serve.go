@@ -1,33 +1,72 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/logship"
 	"github.com/druarnfield/pit/internal/meta"
 	"github.com/druarnfield/pit/internal/serve"
+	"github.com/druarnfield/pit/internal/service"
 	"github.com/spf13/cobra"
 )
 
 func newServeCmd() *cobra.Command {
-	var port int
+	var (
+		port int
+		ui   string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Run the scheduler (cron, FTP watch, and webhook triggers)",
 		Long:  "Start pit in serve mode. Monitors all projects for scheduled triggers, FTP file watches, and inbound webhooks, executing DAGs automatically.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// The Service Control Manager starts services in System32;
+			// relative defaults (runs/, repo_cache/) must resolve against the workspace.
+			if service.IsService() {
+				if err := os.Chdir(projectDir); err != nil {
+					return fmt.Errorf("changing to project dir: %w", err)
+				}
+			}
+
 			metaStore, err := meta.Open(resolveMetadataDB())
 			if err != nil {
 				return fmt.Errorf("opening metadata store: %w", err)
 			}
 			defer metaStore.Close()
 
+			auditLog, err := audit.Open(resolveAuditLog())
+			if err != nil {
+				return err
+			}
+			defer auditLog.Close()
+
+			shipper, err := logship.Open(resolveLogSinks())
+			if err != nil {
+				return err
+			}
+			defer shipper.Close()
+
 			var wsArtifacts []string
+			var maxConcurrent int
+			var calendar *config.CalendarConfig
 			if workspaceCfg != nil {
 				wsArtifacts = workspaceCfg.KeepArtifacts
+				maxConcurrent = workspaceCfg.MaxConcurrentRuns
+				calendar = workspaceCfg.Calendar
 			}
 			srv, err := serve.NewServer(projectDir, secretsPath, verbose, serve.Options{
 				RunsDir:            resolveRunsDir(),
@@ -38,6 +77,23 @@ func newServeCmd() *cobra.Command {
 				MetaStore:          metaStore,
 				MetaQueryStore:     metaStore,
 				APIToken:           resolveAPIToken(),
+				MaxConcurrentRuns:  maxConcurrent,
+				StateFile:          resolveServeStateFile(),
+				Workers:            resolveWorkers(),
+				AuditLog:           auditLog,
+				Calendar:           calendar,
+				MinFreeSpace:       resolveMinFreeSpace(),
+				SnapshotWorkers:    resolveSnapshotWorkers(),
+				SDKSocketDir:       resolveSDKSocketDir(),
+				SnapshotSymlinks:   resolveSnapshotSymlinks(),
+				SnapshotReadOnly:   resolveSnapshotReadOnly(),
+				ArtifactStore:      resolveArtifactStore(),
+				Loader:             resolveLoader(),
+				Env:                resolveEnv(),
+				Lineage:            resolveLineage(),
+				Plugins:            resolvePlugins(),
+				LogShipper:         shipper,
+				UIAddr:             ui,
 			})
 			if err != nil {
 				return err
@@ -46,10 +102,237 @@ func newServeCmd() *cobra.Command {
 			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
 
-			return srv.Start(ctx)
+			err = service.Run(ctx, srv.Start)
+			if errors.Is(err, serve.ErrDrained) {
+				// Planned stop: exit with exitDrained, without an error banner.
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().IntVar(&port, "port", 9090, "port for inbound webhook HTTP listener")
+	cmd.Flags().StringVar(&ui, "ui", "", "address for the read-only web dashboard, e.g. :8080 (default: off)")
+	cmd.AddCommand(newServeInstallCmd(), newServeUninstallCmd(), newServeDrainCmd(), newServeReloadCmd(), newServeTriggersCmd())
+	return cmd
+}
+
+func newServeInstallCmd() *cobra.Command {
+	var (
+		name      string
+		user      string
+		port      int
+		ui        string
+		printUnit bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Register pit serve as a system service",
+		Long:  "Register pit serve as a Windows service or systemd unit that starts on boot and restarts on failure. The service runs against the current --project-dir. Use --print to write the systemd unit to stdout instead of installing it.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			absDir, err := filepath.Abs(projectDir)
+			if err != nil {
+				return fmt.Errorf("resolving project dir: %w", err)
+			}
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("locating pit executable: %w", err)
+			}
+
+			svcArgs := []string{"serve", "--project-dir", absDir, "--port", strconv.Itoa(port)}
+			if ui != "" {
+				svcArgs = append(svcArgs, "--ui", ui)
+			}
+			if cmd.Flags().Changed("secrets") {
+				absSecrets, err := filepath.Abs(secretsPath)
+				if err != nil {
+					return fmt.Errorf("resolving secrets path: %w", err)
+				}
+				svcArgs = append(svcArgs, "--secrets", absSecrets)
+			}
+			if verbose {
+				svcArgs = append(svcArgs, "--verbose")
+			}
+
+			svcCfg := service.Config{
+				Name:        name,
+				Description: fmt.Sprintf("Pit scheduler for %s", absDir),
+				Executable:  exe,
+				Args:        svcArgs,
+				WorkDir:     absDir,
+				User:        user,
+			}
+
+			if printUnit {
+				fmt.Print(service.SystemdUnit(svcCfg))
+				return nil
+			}
+			if err := service.Install(svcCfg); err != nil {
+				return err
+			}
+			fmt.Printf("Installed service %q for %s\n", name, absDir)
+			fmt.Printf("Start it with: %s\n", service.StartHint(name))
+			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&name, "name", service.DefaultName, "service name")
+	cmd.Flags().StringVar(&user, "user", "", "account the systemd unit runs as (default: root)")
 	cmd.Flags().IntVar(&port, "port", 9090, "port for inbound webhook HTTP listener")
+	cmd.Flags().StringVar(&ui, "ui", "", "address for the read-only web dashboard, e.g. :8080 (default: off)")
+	cmd.Flags().BoolVar(&printUnit, "print", false, "print the systemd unit instead of installing it")
+	return cmd
+}
+
+func newServeUninstallCmd() *cobra.Command {
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the pit serve system service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := service.Uninstall(name); err != nil {
+				return err
+			}
+			fmt.Printf("Removed service %q\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", service.DefaultName, "service name")
 	return cmd
 }
+
+func newServeDrainCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "drain",
+		Short: "Stop a running pit serve from starting new runs and exit once idle",
+		Long:  "Ask a running pit serve to stop accepting triggers. Runs already executing finish normally; queued runs are saved to the serve state file. The server then exits with status 3 so a service manager can restart it on a new version.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp struct {
+				Running int `json:"running"`
+				Queued  int `json:"queued"`
+			}
+			if err := controlRequest(cmd.Context(), http.MethodPost, addr, "/control/drain", &resp); err != nil {
+				return err
+			}
+			fmt.Printf("Draining: %d run(s) still executing, %d queued run(s) will be kept for the next start\n", resp.Running, resp.Queued)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "http://localhost:9090", "base URL of the running pit serve")
+	return cmd
+}
+
+func newServeReloadCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "reload",
+		Short: "Reload project configs and secrets into a running pit serve",
+		Long:  "Ask a running pit serve to reread every pit.toml and the secrets file. The new configs are validated in full first, including missing scripts and secrets that are not in the secrets file; if anything fails, the server keeps running its current config and the problems are printed. Otherwise the triggers are restarted from the new configs. Queued and executing runs are not affected.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp struct {
+				DAGs     int `json:"dags"`
+				Triggers int `json:"triggers"`
+			}
+			if err := controlRequest(cmd.Context(), http.MethodPost, addr, "/control/reload", &resp); err != nil {
+				return err
+			}
+			fmt.Printf("Reloaded: %d DAG(s), %d trigger(s)\n", resp.DAGs, resp.Triggers)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "http://localhost:9090", "base URL of the running pit serve")
+	return cmd
+}
+
+func newServeTriggersCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "triggers",
+		Short: "Show the health of polling triggers in a running pit serve",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var resp struct {
+				Triggers []struct {
+					DAGName       string     `json:"dag_name"`
+					Source        string     `json:"source"`
+					Failures      int        `json:"failures"`
+					DegradedSince *time.Time `json:"degraded_since"`
+					LastError     string     `json:"last_error"`
+					NextPoll      *time.Time `json:"next_poll"`
+				} `json:"triggers"`
+			}
+			if err := controlRequest(cmd.Context(), http.MethodGet, addr, "/control/triggers", &resp); err != nil {
+				return err
+			}
+			if len(resp.Triggers) == 0 {
+				fmt.Println("No polling triggers registered.")
+				return nil
+			}
+
+			fmt.Printf("%-20s %-10s %-36s %-21s %s\n", "DAG", "Source", "Status", "Next Poll", "Last Error")
+			fmt.Printf("%-20s %-10s %-36s %-21s %s\n", "───", "──────", "──────", "─────────", "──────────")
+			for _, t := range resp.Triggers {
+				status := "ok"
+				if t.DegradedSince != nil {
+					status = "degraded since " + t.DegradedSince.Local().Format("2006-01-02 15:04:05")
+				}
+				next := "-"
+				if t.NextPoll != nil {
+					next = t.NextPoll.Local().Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%-20s %-10s %-36s %-21s %s\n", t.DAGName, t.Source, status, next, t.LastError)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "http://localhost:9090", "base URL of the running pit serve")
+	return cmd
+}
+
+// controlRequest calls a pit serve /control/ endpoint, authenticating with
+// api_token, and decodes the JSON response into out (which may be nil).
+func controlRequest(ctx context.Context, method, addr, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(addr, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	if token := resolveAPIToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("contacting pit serve at %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if body.Error == "" {
+			body.Error = resp.Status
+		}
+		return fmt.Errorf("pit serve: %s", body.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
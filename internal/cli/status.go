@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/druarnfield/pit/internal/meta"
@@ -9,44 +10,136 @@ import (
 )
 
 func newStatusCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "status",
+	cmd := &cobra.Command{
+		Use:   "status [dag]",
 		Short: "Show pipeline status",
+		Long: "Show the latest run of each DAG, with the success rate and mean duration of its runs " +
+			"over the last --days days. Given a DAG, show the same for each of its tasks.",
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			days, _ := cmd.Flags().GetInt("days")
+			if days < 1 {
+				return fmt.Errorf("--days must be at least 1")
+			}
+			since := time.Now().AddDate(0, 0, -days)
+
 			store, err := meta.Open(resolveMetadataDB())
 			if err != nil {
 				return fmt.Errorf("opening metadata store: %w", err)
 			}
 			defer store.Close()
 
+			w := cmd.OutOrStdout()
+			if len(args) == 1 {
+				tasks, err := store.TaskStats(args[0], since)
+				if err != nil {
+					return fmt.Errorf("querying status: %w", err)
+				}
+				if len(tasks) == 0 {
+					fmt.Fprintf(w, "No runs of DAG %q in the last %d days.\n", args[0], days)
+					return nil
+				}
+				printTaskStats(w, tasks)
+				return nil
+			}
+
 			runs, err := store.LatestRunPerDAG()
 			if err != nil {
 				return fmt.Errorf("querying status: %w", err)
 			}
-
 			if len(runs) == 0 {
-				fmt.Println("No runs recorded yet.")
+				fmt.Fprintln(w, "No runs recorded yet.")
 				return nil
 			}
-
-			fmt.Printf("%-20s %-21s %-8s %s\n", "DAG", "Last Run", "Status", "Duration")
-			fmt.Printf("%-20s %-21s %-8s %s\n", "───", "────────", "──────", "────────")
-
-			for _, r := range runs {
-				var duration string
-				if r.EndedAt != nil {
-					duration = r.EndedAt.Sub(r.StartedAt).Round(time.Second).String()
-				} else {
-					duration = "running"
-				}
-				fmt.Printf("%-20s %-21s %-8s %s\n",
-					r.DAGName,
-					r.StartedAt.Local().Format("2006-01-02 15:04:05"),
-					r.Status,
-					duration,
-				)
+			stats, err := store.RunStats(since)
+			if err != nil {
+				return fmt.Errorf("querying status: %w", err)
 			}
+			printStatus(w, runs, stats)
 			return nil
 		},
 	}
+
+	cmd.Flags().Int("days", 30, "days of runs the success rate and mean duration cover")
+	return cmd
+}
+
+// printStatus writes one line per DAG: its latest run, then the success
+// rate and mean duration from stats. DAGs without finished runs in the
+// window show "-".
+func printStatus(w io.Writer, runs []meta.RunRecord, stats []meta.RunStats) {
+	byDAG := make(map[string]meta.RunStats, len(stats))
+	for _, st := range stats {
+		byDAG[st.DAGName] = st
+	}
+
+	const format = "%-20s %-21s %-8s %-10s %-12s %-10s %s\n"
+	fmt.Fprintf(w, format, "DAG", "Last Run", "Status", "Duration", "Success", "Mean", "Note")
+	fmt.Fprintf(w, format, "───", "────────", "──────", "────────", "───────", "────", "────")
+	for _, r := range runs {
+		duration := "running"
+		if r.EndedAt != nil {
+			duration = r.EndedAt.Sub(r.StartedAt).Round(time.Second).String()
+		}
+		success, mean := "-", "-"
+		if st, ok := byDAG[r.DAGName]; ok {
+			success = successRate(st.Succeeded, st.Runs)
+			if st.Succeeded > 0 {
+				mean = st.AvgDuration.String()
+			}
+		}
+		fmt.Fprintf(w, format,
+			r.DAGName,
+			r.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			r.Status,
+			duration,
+			success,
+			mean,
+			runNote(r),
+		)
+	}
+}
+
+// printTaskStats writes one line per task of a DAG.
+func printTaskStats(w io.Writer, tasks []meta.TaskStats) {
+	const format = "%-24s %-12s %-8s %-10s %s\n"
+	fmt.Fprintf(w, format, "Task", "Success", "Failed", "Mean", "Attempts")
+	fmt.Fprintf(w, format, "────", "───────", "──────", "────", "────────")
+	for _, t := range tasks {
+		mean := "-"
+		if t.Succeeded > 0 {
+			mean = t.AvgDuration.String()
+		}
+		fmt.Fprintf(w, format,
+			t.TaskName,
+			successRate(t.Succeeded, t.Runs),
+			fmt.Sprint(t.Failed),
+			mean,
+			fmt.Sprintf("%.1f", t.AvgAttempts),
+		)
+	}
+}
+
+// successRate formats succeeded of runs, e.g. "92% of 13".
+func successRate(succeeded, runs int) string {
+	if runs == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%% of %d", succeeded*100/runs, runs)
+}
+
+// runNote describes a run's annotation for the status table, e.g.
+// "[ack alice] known partner outage".
+func runNote(r meta.RunRecord) string {
+	if r.AcknowledgedAt == nil {
+		return r.Note
+	}
+	ack := "[ack]"
+	if r.AcknowledgedBy != "" {
+		ack = "[ack " + r.AcknowledgedBy + "]"
+	}
+	if r.Note == "" {
+		return ack
+	}
+	return ack + " " + r.Note
 }
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/meta"
+)
+
+func TestPrintStatus(t *testing.T) {
+	started := time.Date(2026, 3, 7, 14, 30, 0, 0, time.UTC)
+	ended := started.Add(135 * time.Second)
+	runs := []meta.RunRecord{
+		{DAGName: "claims_pipeline", Status: "success", StartedAt: started, EndedAt: &ended},
+		{DAGName: "daily_report", Status: "running", StartedAt: started},
+	}
+	stats := []meta.RunStats{
+		{DAGName: "claims_pipeline", Runs: 13, Succeeded: 12, Failed: 1, AvgDuration: 2 * time.Minute},
+	}
+
+	var buf bytes.Buffer
+	printStatus(&buf, runs, stats)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("printStatus() wrote %d lines, want 4:\n%s", len(lines), buf.String())
+	}
+	if fields := strings.Fields(lines[2]); fields[4] != "2m15s" || strings.Join(fields[5:8], " ") != "92% of 13" || fields[8] != "2m0s" {
+		t.Errorf("claims_pipeline line = %q, want 2m15s, 92%% of 13, 2m0s", lines[2])
+	}
+	// No finished runs in the window
+	if fields := strings.Fields(lines[3]); fields[4] != "running" || fields[5] != "-" || fields[6] != "-" {
+		t.Errorf("daily_report line = %q, want running, -, -", lines[3])
+	}
+}
+
+func TestPrintTaskStats(t *testing.T) {
+	var buf bytes.Buffer
+	printTaskStats(&buf, []meta.TaskStats{
+		{TaskName: "extract", Runs: 4, Succeeded: 3, Failed: 1, AvgDuration: 90 * time.Second, AvgAttempts: 1.25},
+		{TaskName: "load", Runs: 2, Failed: 2, AvgAttempts: 3},
+	})
+	out := buf.String()
+	for _, want := range []string{"75% of 4", "1m30s", "1.2", "0% of 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printTaskStats() output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -1,33 +1,84 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/druarnfield/pit/internal/dag"
 	"github.com/spf13/cobra"
 )
 
 func newValidateCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate all project configurations",
-		Long:  "Parse all pit.toml files under projects/, check for errors, and detect dependency cycles.",
+		Long: "Parse all pit.toml files under projects/ (or the workspace's project_globs), check for errors, and detect dependency cycles. " +
+			"Projects are validated concurrently and the problems are reported per DAG as errors, which stop a DAG from running, or warnings.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			errs, err := dag.ValidateAll(projectDir)
+			output, _ := cmd.Flags().GetString("output")
+			if output != "table" && output != "json" {
+				return fmt.Errorf("invalid --output %q (must be table or json)", output)
+			}
+
+			report, err := dag.ValidateAll(projectDir)
 			if err != nil {
 				return err
 			}
 
-			if len(errs) == 0 {
-				fmt.Println("All projects validated successfully.")
-				return nil
+			w := cmd.OutOrStdout()
+			if output == "json" {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printValidationReport(w, report)
 			}
 
-			for _, e := range errs {
-				fmt.Fprintf(os.Stderr, "ERROR: %s\n", e)
+			if report.Errors > 0 {
+				return fmt.Errorf("validation found %d error(s)", report.Errors)
 			}
-			return fmt.Errorf("validation found %d error(s)", len(errs))
+			return nil
 		},
 	}
+
+	cmd.Flags().String("output", "table", "output format: table or json")
+	return cmd
+}
+
+// printValidationReport writes a line per DAG with its error and warning
+// counts, then the problems of each DAG that has any.
+func printValidationReport(w io.Writer, report *dag.Report) {
+	if report.Errors == 0 && report.Warnings == 0 {
+		fmt.Fprintln(w, "All projects validated successfully.")
+		return
+	}
+
+	const format = "%-30s %-8s %-7s %s\n"
+	fmt.Fprintf(w, format, "DAG", "Status", "Errors", "Warnings")
+	fmt.Fprintf(w, format, "───", "──────", "──────", "────────")
+	for _, d := range report.DAGs {
+		status := "ok"
+		if !d.Valid() {
+			status = "invalid"
+		}
+		fmt.Fprintf(w, format, d.Name, status, fmt.Sprint(d.Errors), fmt.Sprint(d.Warnings))
+	}
+
+	for _, d := range report.DAGs {
+		if len(d.Problems) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", d.Name)
+		for _, p := range d.Problems {
+			msg := p.Message
+			if p.Task != "" {
+				msg = fmt.Sprintf("task %q: %s", p.Task, p.Message)
+			}
+			fmt.Fprintf(w, "  %-8s %s\n", p.Severity, msg)
+		}
+	}
+	fmt.Fprintf(w, "\n%d DAG(s), %d error(s), %d warning(s)\n", len(report.DAGs), report.Errors, report.Warnings)
 }
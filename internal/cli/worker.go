@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/meta"
+	"github.com/druarnfield/pit/internal/worker"
+	"github.com/spf13/cobra"
+)
+
+func newWorkerCmd() *cobra.Command {
+	var port int
+
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Run a remote execution worker for pit serve",
+		Long:  "Start a worker that executes DAG runs dispatched by a pit serve coordinator and streams their logs back. The worker must have the same workspace checked out and requires worker_token in pit_config.toml.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var token string
+			var limits worker.Limits
+			if workspaceCfg != nil {
+				token = workspaceCfg.WorkerToken
+				limits.MaxRuns = workspaceCfg.WorkerMaxRuns
+				limits.MaxRequestBytes = int64(workspaceCfg.WorkerMaxRequest)
+			}
+
+			metaStore, err := meta.Open(resolveMetadataDB())
+			if err != nil {
+				return fmt.Errorf("opening metadata store: %w", err)
+			}
+			defer metaStore.Close()
+
+			srv, err := worker.NewServer(projectDir, token, limits, engine.ExecuteOpts{
+				RunsDir:          resolveRunsDir(),
+				RepoCacheDir:     resolveRepoCacheDir(),
+				Verbose:          verbose,
+				SecretsPath:      secretsPath,
+				AgeIdentity:      resolveAgeIdentityPath(),
+				DBTDriver:        resolveDBTDriver(),
+				KeepArtifacts:    resolveKeepArtifacts(nil),
+				MetaStore:        metaStore,
+				MinFreeSpace:     resolveMinFreeSpace(),
+				SnapshotWorkers:  resolveSnapshotWorkers(),
+				SDKSocketDir:     resolveSDKSocketDir(),
+				SnapshotSymlinks: resolveSnapshotSymlinks(),
+				SnapshotReadOnly: resolveSnapshotReadOnly(),
+				ArtifactStore:    resolveArtifactStore(),
+				Loader:           resolveLoader(),
+				Env:              resolveEnv(),
+				Lineage:          resolveLineage(),
+				Plugins:          resolvePlugins(),
+			})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			httpSrv := &http.Server{
+				Addr:    fmt.Sprintf(":%d", port),
+				Handler: srv.Handler(),
+			}
+			go func() {
+				<-ctx.Done()
+				httpSrv.Shutdown(context.Background())
+			}()
+
+			log.Printf("pit worker: listening on :%d", port)
+			if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			log.Println("pit worker: stopped")
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&port, "port", 9191, "port for the worker HTTP listener")
+	return cmd
+}
+
+// resolveWorkers builds worker clients from the workspace [workers] table.
+func resolveWorkers() map[string]*worker.Client {
+	if workspaceCfg == nil || len(workspaceCfg.Workers) == 0 {
+		return nil
+	}
+	workers := make(map[string]*worker.Client, len(workspaceCfg.Workers))
+	for name, w := range workspaceCfg.Workers {
+		workers[name] = &worker.Client{Name: name, URL: w.URL, Token: w.Token}
+	}
+	return workers
+}
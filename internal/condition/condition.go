@@ -0,0 +1,435 @@
+// Package condition parses and evaluates the small boolean expressions used
+// by task run_if and skip_if settings, e.g.
+//
+//	params.full_refresh == 'true' and weekday not in ['sat', 'sun']
+//
+// Operands are dotted variable names, quoted strings, numbers, and true or
+// false. Comparisons (==, !=, <, <=, >, >=) are numeric when both sides
+// parse as numbers and string comparisons otherwise. Expressions combine
+// with and/or/not (or &&, ||, !), "in [...]" / "not in [...]", and
+// parentheses. A bare operand is true unless it is empty, "false", or zero.
+package condition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Lookup resolves a variable name such as "params.full_refresh". Unknown
+// variables resolve to the empty string.
+type Lookup func(name string) (string, bool)
+
+// Expr is a parsed condition.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse compiles a condition expression.
+func Parse(src string) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source expression.
+func (e *Expr) String() string { return e.src }
+
+// Eval evaluates the expression with variables resolved by lookup.
+func (e *Expr) Eval(lookup Lookup) bool {
+	return truthy(e.root.eval(lookup))
+}
+
+// Vars returns the variable names referenced by the expression, in order of
+// first appearance.
+func (e *Expr) Vars() []string {
+	var names []string
+	seen := make(map[string]bool)
+	e.root.vars(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// truthy reports whether a value counts as true.
+func truthy(v string) bool {
+	if v == "" || v == "false" {
+		return false
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		return n != 0
+	}
+	return true
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// compare returns -1, 0, or 1, numerically when both sides are numbers.
+func compare(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// --- AST ---
+
+type node interface {
+	eval(Lookup) string
+	vars(func(string))
+}
+
+type literal string
+
+func (l literal) eval(Lookup) string { return string(l) }
+func (l literal) vars(func(string))  {}
+
+type variable string
+
+func (v variable) eval(lookup Lookup) string {
+	val, _ := lookup(string(v))
+	return val
+}
+func (v variable) vars(f func(string)) { f(string(v)) }
+
+type notNode struct{ x node }
+
+func (n notNode) eval(l Lookup) string { return boolValue(!truthy(n.x.eval(l))) }
+func (n notNode) vars(f func(string))  { n.x.vars(f) }
+
+type logicNode struct {
+	and  bool
+	l, r node
+}
+
+func (n logicNode) eval(l Lookup) string {
+	left := truthy(n.l.eval(l))
+	if n.and {
+		return boolValue(left && truthy(n.r.eval(l)))
+	}
+	return boolValue(left || truthy(n.r.eval(l)))
+}
+func (n logicNode) vars(f func(string)) { n.l.vars(f); n.r.vars(f) }
+
+type cmpNode struct {
+	op   string
+	l, r node
+}
+
+func (n cmpNode) eval(l Lookup) string {
+	c := compare(n.l.eval(l), n.r.eval(l))
+	switch n.op {
+	case "==":
+		return boolValue(c == 0)
+	case "!=":
+		return boolValue(c != 0)
+	case "<":
+		return boolValue(c < 0)
+	case "<=":
+		return boolValue(c <= 0)
+	case ">":
+		return boolValue(c > 0)
+	default: // ">="
+		return boolValue(c >= 0)
+	}
+}
+func (n cmpNode) vars(f func(string)) { n.l.vars(f); n.r.vars(f) }
+
+type inNode struct {
+	x      node
+	list   []node
+	negate bool
+}
+
+func (n inNode) eval(l Lookup) string {
+	v := n.x.eval(l)
+	found := false
+	for _, item := range n.list {
+		if compare(v, item.eval(l)) == 0 {
+			found = true
+			break
+		}
+	}
+	return boolValue(found != n.negate)
+}
+func (n inNode) vars(f func(string)) {
+	n.x.vars(f)
+	for _, item := range n.list {
+		item.vars(f)
+	}
+}
+
+// --- lexer ---
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp    // == != < <= > >= && || !
+	tokPunct // ( ) [ ] ,
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(src) && rune(src[i]) != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				sb.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start+1)
+			}
+			i++
+			toks = append(toks, token{tokString, sb.String(), start})
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			start := i
+			i++
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{tokNumber, src[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && isIdentChar(rune(src[i])) {
+				i++
+			}
+			toks = append(toks, token{tokIdent, src[start:i], start})
+		case strings.ContainsRune("()[],", c):
+			toks = append(toks, token{tokPunct, string(c), i})
+			i++
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '-'
+}
+
+// --- parser ---
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of texts (idents match
+// case-insensitively, for keywords).
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokPunct && t.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text || (t.kind == tokIdent && strings.EqualFold(t.text, text)) {
+			p.next()
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected %q, found %q at position %d", text, t.text, t.pos+1)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{and: false, l: left, r: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{and: true, l: left, r: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parseCmp()
+}
+
+func (p *parser) parseCmp() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return cmpNode{op: op, l: left, r: right}, nil
+	}
+
+	negate := false
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, "not") &&
+		p.pos+1 < len(p.toks) && strings.EqualFold(p.toks[p.pos+1].text, "in") {
+		p.next()
+		negate = true
+	}
+	if _, ok := p.accept("in"); ok {
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return inNode{x: left, list: list, negate: negate}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseList() ([]node, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var items []node
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.accept(","); !ok {
+			break
+		}
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+var keywords = map[string]bool{"and": true, "or": true, "not": true, "in": true}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokString, tokNumber:
+		return literal(t.text), nil
+	case tokIdent:
+		lower := strings.ToLower(t.text)
+		if lower == "true" || lower == "false" {
+			return literal(lower), nil
+		}
+		if keywords[lower] {
+			return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+		}
+		return variable(t.text), nil
+	case tokPunct:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+}
@@ -0,0 +1,87 @@
+package condition
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]string{
+		"params.full_refresh":    "true",
+		"params.batch":           "12",
+		"weekday":                "sat",
+		"date":                   "2026-03-07",
+		"outputs.extract.rows":   "0",
+		"outputs.extract.status": "partial",
+		"env.PIT_ENV":            "prod",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"params.full_refresh == 'true'", true},
+		{`params.full_refresh == "false"`, false},
+		{"params.full_refresh", true},
+		{"params.missing", false},
+		{"params.missing == ''", true},
+		{"params.batch > 9", true}, // numeric, not "12" < "9"
+		{"params.batch >= 12.0", true},
+		{"outputs.extract.rows > 0", false},
+		{"not outputs.extract.rows", true},
+		{"!outputs.extract.rows", true},
+		{"weekday in ['sat', 'sun']", true},
+		{"weekday not in ['sat', 'sun']", false},
+		{"date >= '2026-03-01' and date < '2026-04-01'", true},
+		{"env.PIT_ENV != 'prod' or params.full_refresh", true},
+		{"env.PIT_ENV != 'prod' && params.full_refresh", false},
+		{"(weekday == 'sun' || weekday == 'sat') && outputs.extract.status == 'partial'", true},
+		{"true", true},
+		{"FALSE", false},
+		{"NOT false AND true", true},
+		{`'it\'s' == "it's"`, true},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := e.Eval(lookup); got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"params.x ==",
+		"params.x = 'a'",
+		"(weekday == 'sat'",
+		"weekday in 'sat'",
+		"weekday in ['sat'",
+		"'unterminated",
+		"a b",
+		"and",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	e, err := Parse("params.a == 'x' or (outputs.t.k > 1 and params.a != weekday)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"params.a", "outputs.t.k", "weekday"}
+	if got := e.Vars(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Vars() = %v, want %v", got, want)
+	}
+}
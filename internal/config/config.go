@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -14,6 +16,11 @@ type Duration struct {
 	time.Duration
 }
 
+// MarshalText formats the duration as UnmarshalText accepts it, e.g. "1h30m0s".
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
 func (d *Duration) UnmarshalText(text []byte) error {
 	var err error
 	d.Duration, err = time.ParseDuration(string(text))
@@ -23,12 +30,120 @@ func (d *Duration) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// ByteSize is a size in bytes that unmarshals from strings like "500MB",
+// "2GB", or "1.5GiB". Decimal (KB, MB, GB, TB) and binary (KiB, MiB, GiB,
+// TiB) suffixes are accepted; a bare number is bytes.
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	mult   float64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30}, {"TIB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+// MarshalText formats the size as a bare number of bytes, which
+// UnmarshalText reads back exactly.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(b), 10)), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.ToUpper(strings.TrimSpace(string(text)))
+	mult := 1.0
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q (use e.g. \"500MB\" or \"2GiB\")", string(text))
+	}
+	*b = ByteSize(n * mult)
+	return nil
+}
+
+// String formats the size with a binary unit, e.g. "1.5GiB".
+func (b ByteSize) String() string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", int64(b))
+	}
+	div, exp := int64(unit), 0
+	for n := int64(b) / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGT"[exp])
+}
+
+// Schedules is one or more cron expressions. In pit.toml, schedule may be a
+// single string or an array of strings.
+type Schedules []string
+
+func (s *Schedules) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			*s = nil
+			return nil
+		}
+		*s = Schedules{v}
+	case []any:
+		out := make(Schedules, 0, len(v))
+		for _, item := range v {
+			expr, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("schedule entries must be strings, got %T", item)
+			}
+			out = append(out, expr)
+		}
+		*s = out
+	default:
+		return fmt.Errorf("schedule must be a string or an array of strings, got %T", v)
+	}
+	return nil
+}
+
+// String joins the expressions with "; " for display.
+func (s Schedules) String() string {
+	return strings.Join(s, "; ")
+}
+
+// InZone returns the expressions evaluated in the IANA time zone tz, as
+// CRON_TZ= prefixes. Expressions that already name a zone (CRON_TZ= or
+// TZ=) are left unchanged, as is everything when tz is empty.
+func (s Schedules) InZone(tz string) Schedules {
+	if tz == "" || len(s) == 0 {
+		return s
+	}
+	out := make(Schedules, len(s))
+	for i, expr := range s {
+		if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+			out[i] = expr
+		} else {
+			out[i] = "CRON_TZ=" + tz + " " + expr
+		}
+	}
+	return out
+}
+
 // ProjectConfig is the top-level structure parsed from a pit.toml file.
 type ProjectConfig struct {
 	DAG     DAGConfig    `toml:"dag"`
 	Tasks   []TaskConfig `toml:"tasks"`
 	Outputs []Output     `toml:"outputs"`
 	path    string       // unexported: filesystem path of the pit.toml
+
+	// Keys set in the file's [dag] table and in each [[tasks]] entry, so
+	// workspace defaults fill in only what the project leaves unset.
+	dagKeys  map[string]bool
+	taskKeys []map[string]bool
 }
 
 // Path returns the filesystem path this config was loaded from.
@@ -43,19 +158,66 @@ func (p *ProjectConfig) Dir() string {
 
 // DAGConfig holds the DAG-level settings.
 type DAGConfig struct {
-	Name          string          `toml:"name"`
-	Schedule      string          `toml:"schedule"`
-	Overlap       string          `toml:"overlap"`
-	Timeout       Duration        `toml:"timeout"`
-	Requires      []string        `toml:"requires"`
-	KeepArtifacts []string        `toml:"keep_artifacts"`
-	GitURL        string          `toml:"git_url"`
-	GitRef        string          `toml:"git_ref"`
-	SQL           SQLConfig        `toml:"sql"`
-	Transform     *TransformConfig `toml:"transform"`
-	FTPWatch      *FTPWatchConfig  `toml:"ftp_watch"`
-	Webhook       *WebhookConfig  `toml:"webhook"`
-	DBT           *DBTConfig      `toml:"dbt"`
+	Name              string            `toml:"name"`
+	Schedule          Schedules         `toml:"schedule"`
+	ScheduleJitter    Duration          `toml:"schedule_jitter"` // stable per-DAG delay within this window, spreads simultaneous cron fires
+	Overlap           string            `toml:"overlap"`
+	MaxActiveRuns     int               `toml:"max_active_runs"`     // runs of this DAG queued or executing at once with overlap = "allow" (0 = unlimited)
+	MaxActiveOverflow string            `toml:"max_active_overflow"` // "skip" (default) or "queue" a run beyond max_active_runs
+	MaxRunsPerHour    int               `toml:"max_runs_per_hour"`   // runs serve starts for this DAG in any hour (0 = unlimited)
+	MaxRunsOverflow   string            `toml:"max_runs_overflow"`   // "skip" (default) or "coalesce" events beyond max_runs_per_hour
+	Priority          int               `toml:"priority"`            // serve dispatch priority (higher first, default 0)
+	Worker            string            `toml:"worker"`              // remote worker name from pit_config.toml (empty = run locally)
+	IgnoreCalendar    bool              `toml:"ignore_calendar"`     // cron keeps firing during workspace holidays/blackouts
+	Timeout           Duration          `toml:"timeout"`
+	InfraRetries      int               `toml:"infra_retries"`      // pit serve retries of a run that fails before any task starts, e.g. on a snapshot or secrets error (0 = none)
+	InfraRetryDelay   Duration          `toml:"infra_retry_delay"`  // wait before the first infra retry, doubled for each one after (default 30s)
+	Timezone          string            `toml:"timezone"`           // IANA zone the schedule is evaluated in (default: the host's local zone)
+	MaxParallelTasks  int               `toml:"max_parallel_tasks"` // tasks of one run executing at once (0 = unlimited)
+	AnomalyFactor     float64           `toml:"anomaly_factor"`     // warn when a task takes this many times its median duration (default 3)
+	AnomalyWindow     int               `toml:"anomaly_window"`     // recent successful runs of each task the median is taken over (default 20)
+	Snapshot          *bool             `toml:"snapshot"`           // false runs tasks directly in the project directory instead of a per-run copy (default true)
+	ReadOnlySnapshot  bool              `toml:"read_only_snapshot"` // write-protect the run's project snapshot while tasks run
+	Offline           bool              `toml:"offline"`            // block network access for tasks that run a process (tasks may set offline = false)
+	Env               *EnvConfig        `toml:"env"`                // environment policy for every task, applied after the workspace [env] and before each task's env
+	SecretScopes      []string          `toml:"secret_scopes"`      // secrets sections searched after the DAG's own, in order (default ["global"])
+	Requires          []string          `toml:"requires"`           // DAGs that must succeed first when run together (pit run --all / pattern)
+	Params            map[string]string `toml:"params"`             // default run parameters, overridden by pit run --param
+	DataDir           string            `toml:"data_dir"`           // absolute directory each run's data dir is created in, as <data_dir>/<run_id> (default: data/ in the run directory)
+	KeepArtifacts     []string          `toml:"keep_artifacts"`
+	Report            []string          `toml:"report"` // run report formats written into the run directory: "markdown", "html"
+	GitURL            string            `toml:"git_url"`
+	GitRef            string            `toml:"git_ref"`
+	SQL               SQLConfig         `toml:"sql"`
+	Transform         *TransformConfig  `toml:"transform"`
+	FTPWatch          *FTPWatchConfig   `toml:"ftp_watch"`
+	Webhook           *WebhookConfig    `toml:"webhook"`
+	DBT               *DBTConfig        `toml:"dbt"`
+	Python            *PythonConfig     `toml:"python"`
+	Checksums         *ChecksumConfig   `toml:"checksums"`
+}
+
+// InPlace reports whether runs execute directly in the project directory
+// (snapshot = false) instead of a copy of it.
+func (d DAGConfig) InPlace() bool {
+	return d.Snapshot != nil && !*d.Snapshot
+}
+
+// ValidReportFormats is the set of valid [dag] report values.
+var ValidReportFormats = map[string]bool{
+	"markdown": true, // report.md
+	"html":     true, // report.html
+}
+
+// ChecksumConfig controls SHA-256 integrity checks on a run's data directory.
+type ChecksumConfig struct {
+	Manifest     bool `toml:"manifest"`      // write data.sha256 listing every data dir file after the run
+	VerifyInputs bool `toml:"verify_inputs"` // check seeded files against <name>.sha256 sidecars before tasks start
+}
+
+// PythonConfig pins the Python interpreter used by uv for a DAG's tasks.
+type PythonConfig struct {
+	Version string `toml:"version"` // interpreter request passed to uv --python, e.g. "3.12"
 }
 
 // DBTConfig holds the dbt project configuration for a DAG.
@@ -68,6 +230,11 @@ type DBTConfig struct {
 	Target     string   `toml:"target"`      // target name (default: "prod")
 	Threads    string   `toml:"threads"`     // number of threads to run with
 	Connection string   `toml:"connection"`  // structured secret name for db credentials
+	LogLevel   string   `toml:"log_level"`   // "info" (default) or "debug"
+	RawLog     bool     `toml:"raw_log"`     // keep dbt's JSON log in runs/<id>/dbt/dbt.jsonl
+
+	CheckSelectors bool   `toml:"check_selectors"` // pit validate checks each --select matches a dbt node
+	Manifest       string `toml:"manifest"`        // manifest.json for check_selectors, relative to the dbt project
 }
 
 // WebhookConfig defines an inbound HTTP webhook trigger for a DAG.
@@ -77,22 +244,31 @@ type WebhookConfig struct {
 
 // FTPWatchConfig defines an FTP file watch trigger for a DAG.
 type FTPWatchConfig struct {
-	Secret         string   `toml:"secret"`          // structured secret name for host, user, password
-	Host           string   `toml:"host"`             // deprecated: use secret instead
+	Secret         string   `toml:"secret"`   // structured secret name for host, user, password
+	Protocol       string   `toml:"protocol"` // "ftp" (default) or "sftp"; overrides the secret's protocol
+	Host           string   `toml:"host"`     // deprecated: use secret instead
 	Port           int      `toml:"port"`
-	User           string   `toml:"user"`             // deprecated: use secret instead
-	PasswordSecret string   `toml:"password_secret"`  // deprecated: use secret instead
+	User           string   `toml:"user"`            // deprecated: use secret instead
+	PasswordSecret string   `toml:"password_secret"` // deprecated: use secret instead
 	TLS            bool     `toml:"tls"`
 	Directory      string   `toml:"directory"`
 	Pattern        string   `toml:"pattern"`
 	ArchiveDir     string   `toml:"archive_dir"`
 	PollInterval   Duration `toml:"poll_interval"`
+	MaxBackoff     Duration `toml:"max_backoff"` // longest delay between polls while the server keeps failing (default 5m)
 	StableSeconds  int      `toml:"stable_seconds"`
+	BatchWindow    Duration `toml:"batch_window"`   // after a file is stable, wait this long for more before firing (0 = fire at once)
+	MaxBatchSize   int      `toml:"max_batch_size"` // most files in one event; a full batch fires without waiting (0 = unlimited)
+	DecryptSecret  string   `toml:"decrypt_secret"` // structured secret (private_key, passphrase) to decrypt .pgp/.gpg/.asc downloads
+	RateLimit      ByteSize `toml:"rate_limit"`     // download rate in bytes per second (0 = the secret's rate_limit, else unlimited)
 }
 
-// SQLConfig holds the default SQL connection for a project's .sql tasks.
+// SQLConfig holds the default SQL connection for a project's .sql tasks
+// and the defaults for its data loads.
 type SQLConfig struct {
-	Connection string `toml:"connection"`
+	Connection     string `toml:"connection"`
+	Schema         string `toml:"schema"`          // default target schema for loads; overrides the workspace [loader]
+	IdentifierCase string `toml:"identifier_case"` // "preserve", "lower", or "upper"; overrides the workspace [loader]
 }
 
 // TransformConfig holds the SQL transform engine configuration.
@@ -102,19 +278,59 @@ type TransformConfig struct {
 
 // TaskConfig holds a single task definition.
 type TaskConfig struct {
-	Name       string   `toml:"name"`
-	Script     string   `toml:"script"`
-	Runner     string   `toml:"runner"`
-	DependsOn  []string `toml:"depends_on"`
-	Timeout    Duration `toml:"timeout"`
-	Retries    int      `toml:"retries"`
-	RetryDelay Duration `toml:"retry_delay"`
-	Type       string   `toml:"type"`       // "load", "save", or "" (default exec)
-	Source     string   `toml:"source"`     // Parquet file for load
-	Output     string   `toml:"output"`     // Parquet file for save
-	Table      string   `toml:"table"`      // target table for load
-	Mode       string   `toml:"mode"`       // "append", "truncate_and_load", "create_or_replace"
-	Connection string   `toml:"connection"` // overrides [dag.sql].connection
+	Name           string     `toml:"name"`
+	Script         string     `toml:"script"`
+	Runner         string     `toml:"runner"`
+	DependsOn      []string   `toml:"depends_on"`
+	Timeout        Duration   `toml:"timeout"`
+	Retries        int        `toml:"retries"`
+	RetryDelay     Duration   `toml:"retry_delay"`
+	Type           string     `toml:"type"`            // "load", "save", "sensor", "quality", "http", or "" (default exec)
+	Source         string     `toml:"source"`          // Parquet file for load
+	Output         string     `toml:"output"`          // Parquet file for save; response file for http
+	Table          string     `toml:"table"`           // target table for load
+	Mode           string     `toml:"mode"`            // "append", "append_or_create", "truncate_and_load", "create_or_replace"
+	ExcludeColumns []string   `toml:"exclude_columns"` // Parquet columns not to load; identity/computed columns are skipped automatically
+	TimezonePolicy string     `toml:"timezone_policy"` // timestamps with a time zone: "error" (default), "offset", or "utc"
+	RejectFile     string     `toml:"reject_file"`     // .csv or .parquet in the data dir for rows the database rejects; the rest still load
+	MaxRejects     int        `toml:"max_rejects"`     // fail the load after this many rejected rows (0 = no limit)
+	Connection     string     `toml:"connection"`      // overrides [dag.sql].connection
+	MemoryLimit    ByteSize   `toml:"memory_limit"`    // OS-enforced memory cap for the task process (0 = none)
+	CPULimit       float64    `toml:"cpu_limit"`       // OS-enforced CPU cap in cores, e.g. 1.5 (0 = none)
+	RunIf          string     `toml:"run_if"`          // condition that must hold for the task to run; otherwise skipped
+	SkipIf         string     `toml:"skip_if"`         // condition under which the task is skipped
+	TriggerRule    string     `toml:"trigger_rule"`    // outcomes of depends_on that let the task run: "all_success" (default), "all_done", "one_failed", "none_failed"
+	MapOver        string     `toml:"map_over"`        // "trigger.files" or "outputs.<task>.<key>": run one instance per item
+	StallTimeout   Duration   `toml:"stall_timeout"`   // warn when the task produces no output for this long
+	StallAction    string     `toml:"stall_action"`    // "warn" (default) or "kill"
+	DBTTarget      string     `toml:"dbt_target"`      // dbt tasks: overrides [dag.dbt].target
+	DBTConnection  string     `toml:"dbt_connection"`  // dbt tasks: overrides [dag.dbt].connection
+	Env            *EnvConfig `toml:"env"`             // environment policy, combined with the workspace [env]
+	Workdir        string     `toml:"workdir"`         // directory the task process starts in, relative to the project (default: the project root)
+	Offline        *bool      `toml:"offline"`         // block network access for the task process (nil = the DAG's offline)
+	Freshness      Duration   `toml:"freshness"`       // skip the task if it produced its declared outputs within this long
+
+	// SQL script fields — used by .sql script tasks.
+	StmtTimeout Duration `toml:"statement_timeout"` // cancel the statement after this long (0 = no limit)
+
+	// Sensor fields — used when Type is "sensor".
+	Kind         string   `toml:"kind"`          // "file", "table", or "ftp"
+	Path         string   `toml:"path"`          // file glob (relative to the run's data dir) or remote FTP glob
+	Query        string   `toml:"query"`         // table sensor: condition query; met when the first value is truthy
+	Secret       string   `toml:"secret"`        // ftp sensor: structured secret with host, user, password
+	PollInterval Duration `toml:"poll_interval"` // time between checks (default 30s)
+
+	// Quality fields — used when Type is "quality".
+	Checks     []QualityCheck `toml:"checks"`      // data quality checks, run before any in checks_file
+	ChecksFile string         `toml:"checks_file"` // TOML file of [[checks]], relative to the project
+
+	// HTTP fields — used when Type is "http". URL, Headers, and Body may
+	// reference ${secret:name}, ${secret:name.field}, and ${param:name}.
+	URL        string            `toml:"url"`        // request URL
+	Method     string            `toml:"method"`     // HTTP method (default GET)
+	Headers    map[string]string `toml:"headers"`    // request headers
+	Body       string            `toml:"body"`       // request body
+	Pagination *HTTPPagination   `toml:"pagination"` // fetch further pages (default: one request)
 }
 
 // Output defines a DAG output artifact.
@@ -123,6 +339,26 @@ type Output struct {
 	Type       string `toml:"type"`
 	Location   string `toml:"location"`
 	Recipients string `toml:"recipients"`
+
+	// Assertions on a "table" output, checked after a successful run.
+	Connection string             `toml:"connection"`   // connection secret (default [dag.sql].connection)
+	Table      string             `toml:"table"`        // schema.table to check (default: location)
+	MinRows    int64              `toml:"min_rows"`     // fewest rows the table may hold (0 = no check)
+	MaxNullPct map[string]float64 `toml:"max_null_pct"` // column → highest percentage of NULL values
+	OnFailure  string             `toml:"on_failure"`   // "fail" (default) fails the run; "warn" only logs
+}
+
+// HasAssertions reports whether the output sets min_rows or max_null_pct.
+func (o Output) HasAssertions() bool {
+	return o.MinRows > 0 || len(o.MaxNullPct) > 0
+}
+
+// AssertionTable returns the table the output's assertions query.
+func (o Output) AssertionTable() string {
+	if o.Table != "" {
+		return o.Table
+	}
+	return o.Location
 }
 
 // Load parses a single pit.toml file and returns a ProjectConfig.
@@ -136,36 +372,131 @@ func Load(path string) (*ProjectConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading %q: %w", absPath, err)
 	}
+	return parseProject(absPath, data)
+}
 
+// parseProject parses the contents of the pit.toml at absPath.
+func parseProject(absPath string, data []byte) (*ProjectConfig, error) {
 	var cfg ProjectConfig
 	if err := toml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing %q: %w", absPath, err)
 	}
 
+	// Decoding again as plain tables records which keys the file sets;
+	// it cannot fail once the typed decode has succeeded.
+	var keys struct {
+		DAG   map[string]any   `toml:"dag"`
+		Tasks []map[string]any `toml:"tasks"`
+	}
+	toml.Unmarshal(data, &keys)
+	cfg.dagKeys = keySet(keys.DAG)
+	for _, t := range keys.Tasks {
+		cfg.taskKeys = append(cfg.taskKeys, keySet(t))
+	}
+
 	cfg.path = absPath
 	return &cfg, nil
 }
 
-// Discover finds all pit.toml files under rootDir/projects/*/pit.toml
-// and returns them keyed by DAG name.
+func keySet(table map[string]any) map[string]bool {
+	set := make(map[string]bool, len(table))
+	for k := range table {
+		set[k] = true
+	}
+	return set
+}
+
+// ApplyDefaults fills in the settings of d that the project's pit.toml
+// does not set itself. A setting counts as set when its key appears in the
+// file, so "retries = 0" keeps a task from inheriting a default of 2.
+func (p *ProjectConfig) ApplyDefaults(d *DefaultsConfig) {
+	if d == nil {
+		return
+	}
+	if p.DAG.Timezone == "" {
+		p.DAG.Timezone = d.Timezone
+	}
+	if p.DAG.Overlap == "" {
+		p.DAG.Overlap = d.Overlap
+	}
+	if !p.dagKeys["timeout"] && p.DAG.Timeout.Duration == 0 {
+		p.DAG.Timeout = d.Timeout
+	}
+	if !p.dagKeys["max_parallel_tasks"] && p.DAG.MaxParallelTasks == 0 {
+		p.DAG.MaxParallelTasks = d.MaxParallelTasks
+	}
+	for i := range p.Tasks {
+		t := &p.Tasks[i]
+		var keys map[string]bool
+		if i < len(p.taskKeys) {
+			keys = p.taskKeys[i]
+		}
+		if !keys["timeout"] && t.Timeout.Duration == 0 {
+			t.Timeout = d.TaskTimeout
+		}
+		if !keys["retries"] && t.Retries == 0 {
+			t.Retries = d.Retries
+		}
+		if !keys["retry_delay"] && t.RetryDelay.Duration == 0 {
+			t.RetryDelay = d.RetryDelay
+		}
+	}
+}
+
+// Discover finds the pit.toml of every project directory matched by the
+// project_globs of rootDir's pit_config.toml (default projects/*) and
+// returns them keyed by DAG name, with the config's [defaults] applied.
 func Discover(rootDir string) (map[string]*ProjectConfig, error) {
-	pattern := filepath.Join(rootDir, "projects", "*", "pit.toml")
-	matches, err := filepath.Glob(pattern)
+	pitCfg, err := LoadPitConfig(rootDir)
 	if err != nil {
-		return nil, fmt.Errorf("globbing %q: %w", pattern, err)
+		return nil, fmt.Errorf("loading pit_config.toml: %w", err)
+	}
+	globs := DefaultProjectGlobs
+	var defaults *DefaultsConfig
+	if pitCfg != nil {
+		if len(pitCfg.ProjectGlobs) > 0 {
+			globs = pitCfg.ProjectGlobs
+		}
+		defaults = pitCfg.Defaults
 	}
 
-	configs := make(map[string]*ProjectConfig, len(matches))
-	for _, match := range matches {
-		cfg, err := Load(match)
+	var matches []string
+	seen := make(map[string]bool)
+	for _, g := range globs {
+		pattern := filepath.Join(g, "pit.toml")
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(rootDir, pattern)
+		}
+		found, err := filepath.Glob(pattern)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("globbing %q: %w", pattern, err)
 		}
+		for _, m := range found {
+			if !seen[m] {
+				seen[m] = true
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	// Parse in parallel, reusing the parse of any pit.toml unchanged since
+	// the last call
+	cache := openDiscoverCache(discoverCachePath(rootDir, pitCfg))
+	loaded, err := cache.loadAll(matches)
+	if err != nil {
+		return nil, err
+	}
+	cache.save()
+
+	configs := make(map[string]*ProjectConfig, len(matches))
+	for i, match := range matches {
+		cfg := loaded[i]
 		if cfg.DAG.Name == "" {
 			cfg.DAG.Name = filepath.Base(filepath.Dir(match))
 		}
-		if _, exists := configs[cfg.DAG.Name]; exists {
-			return nil, fmt.Errorf("duplicate DAG name %q", cfg.DAG.Name)
+		cfg.ApplyDefaults(defaults)
+		if prev, exists := configs[cfg.DAG.Name]; exists {
+			return nil, fmt.Errorf("duplicate DAG name %q (%s and %s)", cfg.DAG.Name, prev.Dir(), cfg.Dir())
 		}
 		configs[cfg.DAG.Name] = cfg
 	}
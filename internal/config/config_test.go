@@ -3,8 +3,12 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/BurntSushi/toml"
 )
 
 func TestDuration_UnmarshalText(t *testing.T) {
@@ -42,6 +46,92 @@ func TestDuration_UnmarshalText(t *testing.T) {
 	}
 }
 
+func TestByteSize_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{input: "1024", want: 1024},
+		{input: "500MB", want: 500_000_000},
+		{input: "2gb", want: 2_000_000_000},
+		{input: "1.5GiB", want: 1536 << 20},
+		{input: "64 KiB", want: 64 << 10},
+		{input: "10B", want: 10},
+		{input: "lots", wantErr: true},
+		{input: "-1GB", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var b ByteSize
+		err := b.UnmarshalText([]byte(tt.input))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("UnmarshalText(%q) expected error, got nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("UnmarshalText(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if b != tt.want {
+			t.Errorf("UnmarshalText(%q) = %d, want %d", tt.input, b, tt.want)
+		}
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := map[ByteSize]string{
+		512:       "512B",
+		1536:      "1.5KiB",
+		100 << 20: "100.0MiB",
+		3 << 40:   "3.0TiB",
+		2 << 50:   "2048.0TiB",
+	}
+	for b, want := range tests {
+		if got := b.String(); got != want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", int64(b), got, want)
+		}
+	}
+}
+
+func TestSchedules_UnmarshalTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Schedules
+		wantErr bool
+	}{
+		{name: "single string", input: `schedule = "0 6 * * *"`, want: Schedules{"0 6 * * *"}},
+		{name: "array", input: `schedule = ["0 * * * 1-5", "0 6 * * 6"]`, want: Schedules{"0 * * * 1-5", "0 6 * * 6"}},
+		{name: "empty string", input: `schedule = ""`, want: nil},
+		{name: "unset", input: ``, want: nil},
+		{name: "non-string entry", input: `schedule = ["0 6 * * *", 5]`, wantErr: true},
+		{name: "wrong type", input: `schedule = 5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg DAGConfig
+			_, err := toml.Decode(tt.input, &cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Decode(%q) expected error, got nil", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode(%q) unexpected error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(cfg.Schedule, tt.want) {
+				t.Errorf("Schedule = %#v, want %#v", cfg.Schedule, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad(t *testing.T) {
 	t.Run("valid minimal", func(t *testing.T) {
 		cfg, err := Load(filepath.Join("testdata", "valid_minimal.toml"))
@@ -79,6 +169,9 @@ func TestLoad(t *testing.T) {
 		if cfg.DAG.SQL.Connection != "my_database" {
 			t.Errorf("DAG.SQL.Connection = %q, want %q", cfg.DAG.SQL.Connection, "my_database")
 		}
+		if cfg.DAG.Python == nil || cfg.DAG.Python.Version != "3.12" {
+			t.Errorf("DAG.Python = %+v, want version 3.12", cfg.DAG.Python)
+		}
 		if len(cfg.Tasks) != 3 {
 			t.Fatalf("len(Tasks) = %d, want 3", len(cfg.Tasks))
 		}
@@ -91,6 +184,9 @@ func TestLoad(t *testing.T) {
 		if extract.RetryDelay.Duration != 30*time.Second {
 			t.Errorf("extract.RetryDelay = %v, want 30s", extract.RetryDelay.Duration)
 		}
+		if extract.MemoryLimit != 2<<30 || extract.CPULimit != 1.5 {
+			t.Errorf("extract limits = %s / %g cpu, want 2GiB / 1.5 cpu", extract.MemoryLimit, extract.CPULimit)
+		}
 
 		// Check depends_on
 		transform := cfg.Tasks[1]
@@ -303,6 +399,97 @@ script = "tasks/b.sh"
 	}
 }
 
+func TestSchedules_InZone(t *testing.T) {
+	s := Schedules{"0 6 * * *", "CRON_TZ=UTC 0 18 * * *", "TZ=Asia/Tokyo 0 9 * * *"}
+	want := Schedules{"CRON_TZ=Australia/Sydney 0 6 * * *", "CRON_TZ=UTC 0 18 * * *", "TZ=Asia/Tokyo 0 9 * * *"}
+	if got := s.InZone("Australia/Sydney"); !reflect.DeepEqual(got, want) {
+		t.Errorf("InZone() = %q, want %q", got, want)
+	}
+	if got := s.InZone(""); !reflect.DeepEqual(got, s) {
+		t.Errorf("InZone(\"\") = %q, want unchanged", got)
+	}
+}
+
+func TestDiscover_Defaults(t *testing.T) {
+	root := t.TempDir()
+	pitCfg := `[defaults]
+timezone = "Australia/Sydney"
+overlap = "skip"
+timeout = "2h"
+max_parallel_tasks = 4
+task_timeout = "30m"
+retries = 2
+retry_delay = "1m"
+`
+	if err := os.WriteFile(filepath.Join(root, "pit_config.toml"), []byte(pitCfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mkTestProject(t, filepath.Join(root, "projects", "alpha"), `[dag]
+name = "alpha"
+overlap = "wait"
+max_parallel_tasks = 0
+
+[[tasks]]
+name = "inherits"
+script = "tasks/hello.sh"
+
+[[tasks]]
+name = "own"
+script = "tasks/hello.sh"
+timeout = "5m"
+retries = 0
+`)
+
+	configs, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	dag := configs["alpha"].DAG
+	if dag.Timezone != "Australia/Sydney" || dag.Overlap != "wait" || dag.Timeout.Duration != 2*time.Hour || dag.MaxParallelTasks != 0 {
+		t.Errorf("DAG = timezone %q overlap %q timeout %s max_parallel_tasks %d", dag.Timezone, dag.Overlap, dag.Timeout.Duration, dag.MaxParallelTasks)
+	}
+	tasks := configs["alpha"].Tasks
+	if tasks[0].Timeout.Duration != 30*time.Minute || tasks[0].Retries != 2 || tasks[0].RetryDelay.Duration != time.Minute {
+		t.Errorf("inherits = timeout %s retries %d retry_delay %s", tasks[0].Timeout.Duration, tasks[0].Retries, tasks[0].RetryDelay.Duration)
+	}
+	if tasks[1].Timeout.Duration != 5*time.Minute || tasks[1].Retries != 0 || tasks[1].RetryDelay.Duration != time.Minute {
+		t.Errorf("own = timeout %s retries %d retry_delay %s", tasks[1].Timeout.Duration, tasks[1].Retries, tasks[1].RetryDelay.Duration)
+	}
+}
+
+func TestDiscover_ProjectGlobs(t *testing.T) {
+	root := t.TempDir()
+	pitCfg := `project_globs = ["teams/*/projects/*", "shared/*", "teams/finance/projects/*"]` + "\n"
+	if err := os.WriteFile(filepath.Join(root, "pit_config.toml"), []byte(pitCfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"teams/finance/projects/ledger", "teams/claims/projects/intake", "shared/calendar", "projects/ignored"} {
+		mkTestProject(t, filepath.Join(root, dir), "[[tasks]]\nname = \"a\"\nscript = \"tasks/a.sh\"\n")
+	}
+
+	configs, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if len(configs) != 3 {
+		t.Fatalf("Discover() found %d projects, want 3: %v", len(configs), configs)
+	}
+	for _, name := range []string{"ledger", "intake", "calendar"} {
+		if _, ok := configs[name]; !ok {
+			t.Errorf("Discover() missing %q", name)
+		}
+	}
+	if got, want := configs["ledger"].Dir(), filepath.Join(root, "teams", "finance", "projects", "ledger"); got != want {
+		t.Errorf("ledger Dir() = %q, want %q", got, want)
+	}
+
+	// The same directory name under two teams is a duplicate DAG name
+	mkTestProject(t, filepath.Join(root, "teams/claims/projects/ledger"), "[[tasks]]\nname = \"a\"\nscript = \"tasks/a.sh\"\n")
+	if _, err := Discover(root); err == nil || !strings.Contains(err.Error(), `duplicate DAG name "ledger"`) {
+		t.Errorf("Discover() error = %v, want duplicate DAG name", err)
+	}
+}
+
 func TestDiscover_NoProjects(t *testing.T) {
 	root := t.TempDir()
 	configs, err := Discover(root)
@@ -340,3 +527,39 @@ func mkTestProject(t *testing.T, dir, tomlContent string) {
 		t.Fatalf("writing pit.toml: %v", err)
 	}
 }
+
+func TestLoadQualityChecks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checks.toml")
+	content := `
+[[checks]]
+check = "row_count"
+table = "dbo.claims"
+min = 1
+
+[[checks]]
+name = "member ids present"
+check = "null_ratio"
+column = "member_id"
+max = 0.01
+severity = "warn"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing checks file: %v", err)
+	}
+	checks, err := LoadQualityChecks(path)
+	if err != nil {
+		t.Fatalf("LoadQualityChecks() unexpected error: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("len(checks) = %d, want 2", len(checks))
+	}
+	if checks[0].Check != CheckRowCount || checks[0].Min == nil || *checks[0].Min != 1 || checks[0].Max != nil {
+		t.Errorf("checks[0] = %+v, want row_count with min 1", checks[0])
+	}
+	if checks[1].Severity != "warn" || checks[1].Max == nil || *checks[1].Max != 0.01 {
+		t.Errorf("checks[1] = %+v, want warn with max 0.01", checks[1])
+	}
+	if err := checks[1].Validate("dbo.claims"); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// DefaultDiscoverCache is the file in the workspace where Discover keeps
+// parsed pit.toml files between invocations.
+const DefaultDiscoverCache = "pit_discover_cache.json"
+
+// DiscoverCacheOff as discover_cache turns the cache off.
+const DiscoverCacheOff = "off"
+
+// discoverCache maps each pit.toml path to its parsed form, keyed by the
+// SHA-256 of the file so an edit of any kind invalidates it. Entries hold
+// the config as Load returned it, before workspace defaults and the
+// directory-name fallback, which Discover applies on every call.
+type discoverCache struct {
+	Format  string                  `json:"format"`
+	Entries map[string]cachedConfig `json:"entries"`
+
+	path  string
+	mu    sync.Mutex
+	dirty bool
+	used  map[string]bool
+}
+
+type cachedConfig struct {
+	SHA256 string          `json:"sha256"`
+	Config json.RawMessage `json:"config"`
+}
+
+// cachedProject is the serialised form of a ProjectConfig, including the
+// key sets that decide which defaults apply.
+type cachedProject struct {
+	DAG      DAGConfig
+	Tasks    []TaskConfig
+	Outputs  []Output
+	DAGKeys  map[string]bool
+	TaskKeys []map[string]bool
+}
+
+// discoverCacheFormat identifies the shape of cachedProject. A cache
+// written by a pit whose config types differ is discarded whole, so a new
+// field never reads as unset from a stale entry.
+var discoverCacheFormat = typeFingerprint(reflect.TypeOf(cachedProject{}))
+
+// openDiscoverCache reads the cache at path. A missing, unreadable, or
+// outdated cache starts empty; an empty path gives a cache that is never
+// written.
+func openDiscoverCache(path string) *discoverCache {
+	c := &discoverCache{path: path}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, c)
+		}
+	}
+	if c.Format != discoverCacheFormat || c.Entries == nil {
+		c.Format = discoverCacheFormat
+		c.Entries = make(map[string]cachedConfig)
+		c.dirty = path != ""
+	}
+	c.used = make(map[string]bool)
+	return c
+}
+
+// load returns the config at path, from the cache when the file is
+// unchanged since it was cached.
+func (c *discoverCache) load(path string) (*ProjectConfig, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path %q: %w", path, err)
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", absPath, err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	c.used[absPath] = true
+	entry, ok := c.Entries[absPath]
+	c.mu.Unlock()
+	if ok && entry.SHA256 == hash {
+		var cp cachedProject
+		if json.Unmarshal(entry.Config, &cp) == nil {
+			return &ProjectConfig{
+				DAG:      cp.DAG,
+				Tasks:    cp.Tasks,
+				Outputs:  cp.Outputs,
+				path:     absPath,
+				dagKeys:  cp.DAGKeys,
+				taskKeys: cp.TaskKeys,
+			}, nil
+		}
+	}
+
+	cfg, err := parseProject(absPath, data)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(cachedProject{
+		DAG:      cfg.DAG,
+		Tasks:    cfg.Tasks,
+		Outputs:  cfg.Outputs,
+		DAGKeys:  cfg.dagKeys,
+		TaskKeys: cfg.taskKeys,
+	})
+	if err == nil {
+		c.mu.Lock()
+		c.Entries[absPath] = cachedConfig{SHA256: hash, Config: raw}
+		c.dirty = true
+		c.mu.Unlock()
+	}
+	return cfg, nil
+}
+
+// loadAll loads paths in parallel, returning the configs in the same order.
+// On failure it returns the error of the first path that failed.
+func (c *discoverCache) loadAll(paths []string) ([]*ProjectConfig, error) {
+	configs := make([]*ProjectConfig, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				configs[i], errs[i] = c.load(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// save writes the cache back if it changed, dropping entries for files
+// that were not loaded this time. The cache only saves work, so failing
+// to write it is not an error.
+func (c *discoverCache) save() {
+	for path := range c.Entries {
+		if !c.used[path] {
+			delete(c.Entries, path)
+			c.dirty = true
+		}
+	}
+	if c.path == "" || !c.dirty {
+		return
+	}
+	// Leave no cache behind in a directory that is not a workspace
+	if len(c.Entries) == 0 {
+		os.Remove(c.path)
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil || os.Rename(tmp.Name(), c.path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// discoverCachePath returns where Discover caches parsed configs for the
+// workspace at rootDir, or "" when caching is off.
+func discoverCachePath(rootDir string, pitCfg *PitConfig) string {
+	if pitCfg != nil && pitCfg.DiscoverCache != "" {
+		if pitCfg.DiscoverCache == DiscoverCacheOff {
+			return ""
+		}
+		return pitCfg.DiscoverCache
+	}
+	return filepath.Join(rootDir, DefaultDiscoverCache)
+}
+
+// typeFingerprint hashes the structure of t: every field name, type, and
+// tag, recursively.
+func typeFingerprint(t reflect.Type) string {
+	var b strings.Builder
+	describeType(&b, t, make(map[reflect.Type]bool))
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+func describeType(b *strings.Builder, t reflect.Type, seen map[reflect.Type]bool) {
+	b.WriteString(t.String())
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		b.WriteByte('<')
+		describeType(b, t.Elem(), seen)
+		b.WriteByte('>')
+	case reflect.Map:
+		b.WriteByte('<')
+		describeType(b, t.Key(), seen)
+		b.WriteByte(',')
+		describeType(b, t.Elem(), seen)
+		b.WriteByte('>')
+	case reflect.Struct:
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		b.WriteByte('{')
+		for i := range t.NumField() {
+			f := t.Field(i)
+			fmt.Fprintf(b, "%s %q ", f.Name, f.Tag)
+			describeType(b, f.Type, seen)
+			b.WriteByte(';')
+		}
+		b.WriteByte('}')
+	}
+}
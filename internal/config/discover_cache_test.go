@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// richProject sets fields of every kind the cache has to round-trip:
+// durations, sizes, pointers to empty tables, empty maps and arrays.
+const richProject = `[dag]
+name = "rich"
+schedule = ["0 6 * * *", "0 18 * * *"]
+timeout = "1h30m"
+anomaly_factor = 2.5
+snapshot = true
+secret_scopes = []
+report = ["markdown"]
+
+[dag.params]
+
+[dag.checksums]
+
+[dag.ftp_watch]
+secret = "partner"
+poll_interval = "45s"
+rate_limit = "1.5MiB"
+
+[[tasks]]
+name = "extract"
+script = "extract.py"
+retries = 0
+memory_limit = "512MB"
+cpu_limit = 1.5
+offline = false
+headers = { Accept = "text/csv" }
+
+[tasks.env]
+allow = ["PATH"]
+
+[[tasks.checks]]
+name = "rows"
+query = "select count(*) from t"
+
+[[outputs]]
+name = "report"
+type = "file"
+location = "out/report.csv"
+`
+
+func TestDiscover_Cache(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "projects", "rich")
+	mkTestProject(t, dir, richProject)
+	mkTestProject(t, filepath.Join(root, "projects", "plain"), "[[tasks]]\nname = \"a\"\nscript = \"a.sh\"\n")
+	os.WriteFile(filepath.Join(root, "pit_config.toml"), []byte("[defaults]\nretries = 2\ntimezone = \"UTC\"\n"), 0o644)
+
+	parsed, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	cachePath := filepath.Join(root, DefaultDiscoverCache)
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	cached, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() from cache error: %v", err)
+	}
+	if !reflect.DeepEqual(cached, parsed) {
+		t.Errorf("configs from the cache differ from parsed ones:\ncached: %+v\nparsed: %+v", cached["rich"], parsed["rich"])
+	}
+	// retries = 0 in the file still keeps the default away
+	if got := cached["rich"].Tasks[0].Retries; got != 0 {
+		t.Errorf("extract retries = %d, want 0", got)
+	}
+	if got := cached["plain"].Tasks[0].Retries; got != 2 {
+		t.Errorf("plain task retries = %d, want the default 2", got)
+	}
+
+	// An edit is picked up, and a removed project leaves the cache
+	os.WriteFile(filepath.Join(dir, "pit.toml"), []byte("[dag]\nname = \"renamed\"\n"), 0o644)
+	os.RemoveAll(filepath.Join(root, "projects", "plain"))
+	configs, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() after edit error: %v", err)
+	}
+	if _, ok := configs["renamed"]; !ok || len(configs) != 1 {
+		t.Errorf("configs after edit = %v, want only renamed", configs)
+	}
+	cache := openDiscoverCache(cachePath)
+	if len(cache.Entries) != 1 {
+		t.Errorf("cache entries = %d, want 1", len(cache.Entries))
+	}
+}
+
+func TestDiscover_CacheUsed(t *testing.T) {
+	root := t.TempDir()
+	mkTestProject(t, filepath.Join(root, "projects", "alpha"), "[dag]\nname = \"alpha\"\n")
+	if _, err := Discover(root); err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+
+	// Doctor the cached parse; an unchanged file is not parsed again
+	cachePath := filepath.Join(root, DefaultDiscoverCache)
+	cache := openDiscoverCache(cachePath)
+	for path, entry := range cache.Entries {
+		entry.Config = []byte(`{"DAG":{"Name":"from_cache"}}`)
+		cache.Entries[path] = entry
+		cache.used[path] = true
+	}
+	cache.dirty = true
+	cache.save()
+
+	configs, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if _, ok := configs["from_cache"]; !ok {
+		t.Errorf("configs = %v, want the cached from_cache", configs)
+	}
+}
+
+func TestDiscover_CacheOff(t *testing.T) {
+	root := t.TempDir()
+	mkTestProject(t, filepath.Join(root, "projects", "alpha"), "[dag]\nname = \"alpha\"\n")
+	os.WriteFile(filepath.Join(root, "pit_config.toml"), []byte("discover_cache = \"off\"\n"), 0o644)
+
+	if _, err := Discover(root); err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, DefaultDiscoverCache)); !os.IsNotExist(err) {
+		t.Errorf("cache written with discover_cache = \"off\"")
+	}
+}
+
+func TestDiscover_CacheIgnoresOtherFormats(t *testing.T) {
+	root := t.TempDir()
+	mkTestProject(t, filepath.Join(root, "projects", "alpha"), "[dag]\nname = \"alpha\"\n")
+	path := filepath.Join(root, "projects", "alpha", "pit.toml")
+	abs, _ := filepath.Abs(path)
+	// A cache from a pit with different config types must not be trusted,
+	// even for an unchanged file.
+	os.WriteFile(filepath.Join(root, DefaultDiscoverCache), []byte(`{"format":"old","entries":{`+
+		`"`+filepath.ToSlash(abs)+`":{"sha256":"x","config":{"DAG":{"Name":"stale"}}}}}`), 0o644)
+
+	configs, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	if _, ok := configs["alpha"]; !ok {
+		t.Errorf("configs = %v, want alpha", configs)
+	}
+}
+
+// Fields the cache cannot see would silently reset to zero for cached
+// projects, so every field of the cached config types must be exported.
+func TestCachedProject_FieldsExported(t *testing.T) {
+	var check func(typ reflect.Type, seen map[reflect.Type]bool)
+	check = func(typ reflect.Type, seen map[reflect.Type]bool) {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			check(typ.Elem(), seen)
+		case reflect.Struct:
+			if seen[typ] || typ.PkgPath() != reflect.TypeOf(ProjectConfig{}).PkgPath() {
+				return
+			}
+			seen[typ] = true
+			for i := range typ.NumField() {
+				f := typ.Field(i)
+				if !f.IsExported() {
+					t.Errorf("%s.%s is unexported and would be lost from the discover cache", typ.Name(), f.Name)
+				}
+				check(f.Type, seen)
+			}
+		}
+	}
+	check(reflect.TypeOf(cachedProject{}), make(map[reflect.Type]bool))
+}
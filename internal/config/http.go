@@ -0,0 +1,54 @@
+package config
+
+import "fmt"
+
+// Pagination styles for tasks with type = "http".
+const (
+	PaginateLink   = "link"   // follow the Link header's rel="next" URL
+	PaginatePage   = "page"   // increment a page number query parameter
+	PaginateCursor = "cursor" // pass the cursor from each response to the next request
+)
+
+// DefaultHTTPMaxPages bounds a paginated http task whose pagination sets
+// no max_pages, so an API that never stops paging cannot run forever.
+const DefaultHTTPMaxPages = 100
+
+// HTTPPagination describes how an http task fetches further pages. Each
+// page must be JSON; the records from every page are written to the
+// output file as one JSON array.
+type HTTPPagination struct {
+	Style      string `toml:"style"`       // "link", "page", or "cursor"
+	Param      string `toml:"param"`       // page, cursor: query parameter (default "page" or "cursor")
+	Start      int    `toml:"start"`       // page: first page number (default 1)
+	CursorPath string `toml:"cursor_path"` // cursor: dotted path to the next cursor in each response
+	ItemsPath  string `toml:"items_path"`  // dotted path to the array of records in each response ("" = whole body)
+	MaxPages   int    `toml:"max_pages"`   // fail if more pages remain after this many (0 = DefaultHTTPMaxPages)
+}
+
+// Validate checks the pagination style and the fields it needs.
+func (p *HTTPPagination) Validate() error {
+	switch p.Style {
+	case PaginateLink, PaginatePage:
+		if p.CursorPath != "" {
+			return fmt.Errorf("cursor_path only applies to cursor pagination")
+		}
+	case PaginateCursor:
+		if p.CursorPath == "" {
+			return fmt.Errorf("cursor pagination requires cursor_path")
+		}
+	case "":
+		return fmt.Errorf("missing style (link, page, or cursor)")
+	default:
+		return fmt.Errorf("invalid style %q (must be link, page, or cursor)", p.Style)
+	}
+	if p.Style == PaginateLink && (p.Param != "" || p.Start != 0) {
+		return fmt.Errorf("param and start do not apply to link pagination")
+	}
+	if p.Start != 0 && p.Style != PaginatePage {
+		return fmt.Errorf("start only applies to page pagination")
+	}
+	if p.MaxPages < 0 {
+		return fmt.Errorf("invalid max_pages %d (must be >= 0)", p.MaxPages)
+	}
+	return nil
+}
@@ -3,21 +3,31 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
 // ValidArtifacts is the set of valid keep_artifacts values.
 var ValidArtifacts = map[string]bool{
-	"logs":    true,
-	"project": true,
-	"data":    true,
+	"logs":             true,
+	"logs_failed_only": true, // logs dir kept, but successful task logs deleted
+	"project":          true,
+	"data":             true,
+	"scratch":          true, // task scratch dirs, otherwise removed as each task finishes
 }
 
 // DefaultKeepArtifacts is the default set — keep everything.
 var DefaultKeepArtifacts = []string{"logs", "project", "data"}
 
+// DefaultProjectGlobs are the project directories discovered when
+// pit_config.toml sets no project_globs.
+var DefaultProjectGlobs = []string{"projects/*"}
+
 // DefaultDBTDriver is the default ODBC driver for dbt profiles.
 const DefaultDBTDriver = "ODBC Driver 17 for SQL Server"
 
@@ -32,6 +42,195 @@ type PitConfig struct {
 	KeepArtifacts     []string `toml:"keep_artifacts"`
 	SecretsRecipients string   `toml:"secrets_recipients"`
 	AgeIdentity       string   `toml:"age_identity"`
+	MaxConcurrentRuns int      `toml:"max_concurrent_runs"` // serve: max DAG runs at once (0 = unlimited)
+	ServeStateFile    string   `toml:"serve_state_file"`    // serve: persisted trigger/queue state
+	DiscoverCache     string   `toml:"discover_cache"`      // parsed pit.toml cache (default pit_discover_cache.json in the workspace, "off" = none)
+	WorkerToken       string   `toml:"worker_token"`        // worker: bearer token coordinators must present
+	WorkerMaxRuns     int      `toml:"worker_max_runs"`     // worker: runs executing at once (0 = unlimited)
+	WorkerMaxRequest  ByteSize `toml:"worker_max_request"`  // worker: largest run request, seed files included (default 256MB)
+	AuditLog          string   `toml:"audit_log"`           // append-only JSONL audit trail
+	SDKSocketDir      string   `toml:"sdk_socket_dir"`      // per-run SDK sockets as <dir>/<run_id>.sock (default: sdk.sock in each run directory)
+	MinFreeSpace      *ByteSize `toml:"min_free_space"`     // free space to leave on the runs volume after snapshotting (0 = no check)
+	Workers           map[string]WorkerEndpoint `toml:"workers"` // serve: remote workers by name
+	Calendar          *CalendarConfig           `toml:"calendar"` // serve: holidays and blackout windows for cron triggers
+	Snapshot          *SnapshotConfig           `toml:"snapshot"` // how projects are copied into run snapshots
+	ArtifactStore     *ArtifactStoreConfig      `toml:"artifact_store"` // upload kept run artifacts to object storage
+	Loader            *LoaderConfig             `toml:"loader"` // defaults for load tasks and the SDK's load_data
+	Env               *EnvConfig                `toml:"env"`    // which of pit's environment variables reach tasks
+	Lineage           *LineageConfig            `toml:"lineage"` // send OpenLineage run events to an HTTP endpoint
+	LogSinks          []LogSinkConfig           `toml:"log_sink"` // serve: ship run and task events to files, syslog, or Loki
+	Defaults          *DefaultsConfig           `toml:"defaults"` // settings projects inherit unless their pit.toml sets them
+	ProjectGlobs      []string                  `toml:"project_globs"` // project directories to discover, relative to the workspace (default ["projects/*"])
+	Deploy            []DeployConfig            `toml:"deploy"` // git sources pit deploy installs into the workspace
+	Plugins           map[string]PluginConfig   `toml:"plugins"` // external programs providing runners and SDK methods
+}
+
+// PluginConfig is a [plugins.<name>] table: an external program that runs
+// tasks for the runners and script extensions it claims, and answers the
+// SDK methods it lists. See internal/runner/plugin.go for the protocol.
+type PluginConfig struct {
+	Command    []string `toml:"command"`     // executable and leading arguments
+	Runners    []string `toml:"runners"`     // runner names, used as runner = "<name>"
+	Extensions []string `toml:"extensions"`  // script extensions it runs when runner is unset, e.g. ".sas"
+	SDKMethods []string `toml:"sdk_methods"` // SDK methods tasks can call
+	Timeout    Duration `toml:"timeout"`     // limit on one SDK call (default 5m)
+}
+
+// builtinRunners and builtinExtensions cannot be claimed by plugins.
+var (
+	builtinRunners    = map[string]bool{"python": true, "bash": true, "cmd": true, "powershell": true, "sql": true, "dbt": true}
+	builtinExtensions = map[string]bool{".py": true, ".sh": true, ".bat": true, ".cmd": true, ".ps1": true, ".sql": true}
+)
+
+// DefaultDeployRef is the git ref deployed when a [[deploy]] source sets none.
+const DefaultDeployRef = "main"
+
+// DeployConfig is one [[deploy]] source: a git repository whose contents
+// (or one directory of it) replace a directory of the workspace.
+type DeployConfig struct {
+	URL    string `toml:"url"`    // git remote
+	Ref    string `toml:"ref"`    // branch, tag, or commit (default "main")
+	Subdir string `toml:"subdir"` // directory within the repository to deploy (default: the repository root)
+	Target string `toml:"target"` // workspace directory it replaces (default "projects")
+}
+
+// DefaultsConfig holds DAG and task settings every project inherits when
+// its pit.toml leaves them unset, the way keep_artifacts cascades.
+type DefaultsConfig struct {
+	Timezone         string   `toml:"timezone"`           // dag.timezone: IANA zone cron schedules are evaluated in
+	Overlap          string   `toml:"overlap"`            // dag.overlap
+	Timeout          Duration `toml:"timeout"`            // dag.timeout
+	MaxParallelTasks int      `toml:"max_parallel_tasks"` // dag.max_parallel_tasks
+	TaskTimeout      Duration `toml:"task_timeout"`       // timeout of each task
+	Retries          int      `toml:"retries"`            // retries of each task
+	RetryDelay       Duration `toml:"retry_delay"`        // retry_delay of each task
+}
+
+// LogSinkConfig is one [[log_sink]] destination for the run and task
+// events pit serve produces. Which fields apply depends on Type.
+type LogSinkConfig struct {
+	Type    string            `toml:"type"`    // "file", "syslog", or "loki"
+	Path    string            `toml:"path"`    // file: JSONL file to append to
+	Address string            `toml:"address"` // syslog: "udp://host:514" or "tcp://host:514"
+	Tag     string            `toml:"tag"`     // syslog: app name (default "pit")
+	URL     string            `toml:"url"`     // loki: base URL or push endpoint, e.g. "http://loki:3100"
+	Tenant  string            `toml:"tenant"`  // loki: X-Scope-OrgID header (optional)
+	Labels  map[string]string `toml:"labels"`  // loki: extra stream labels
+}
+
+// LineageConfig points pit at an OpenLineage HTTP endpoint (e.g. Marquez or
+// DataHub). Every DAG run sends a START event and a COMPLETE or FAIL event
+// listing the datasets it read and wrote.
+type LineageConfig struct {
+	URL       string `toml:"url"`       // endpoint, e.g. "http://marquez:5000/api/v1/lineage"
+	Namespace string `toml:"namespace"` // job namespace (default "pit")
+	Token     string `toml:"token"`     // bearer token sent with each event (optional)
+}
+
+// EnvConfig controls the environment task processes start with. By default
+// a task inherits pit's whole environment; Allow narrows that to the named
+// variables, Inherit = "none" starts from an empty one, and Deny removes
+// variables in either case. Set adds variables last. Names in Allow and Deny
+// may use * wildcards, e.g. "AWS_*". PIT_* variables are always set.
+type EnvConfig struct {
+	Inherit string            `toml:"inherit"` // "all" (default) or "none"
+	Allow   []string          `toml:"allow"`   // variables passed through; if set, no others are
+	Deny    []string          `toml:"deny"`    // variables never passed through; wins over allow
+	Set     map[string]string `toml:"set"`     // variables added to the task's environment
+}
+
+// Validate checks the inherit value, the Allow and Deny patterns, and the
+// names in Set.
+func (e *EnvConfig) Validate() error {
+	if e.Inherit != "" && e.Inherit != "all" && e.Inherit != "none" {
+		return fmt.Errorf("invalid inherit %q (must be all or none)", e.Inherit)
+	}
+	for _, list := range []struct {
+		key      string
+		patterns []string
+	}{{"allow", e.Allow}, {"deny", e.Deny}} {
+		for _, p := range list.patterns {
+			if _, err := path.Match(p, ""); err != nil || p == "" || strings.Contains(p, "=") {
+				return fmt.Errorf("invalid %s pattern %q", list.key, p)
+			}
+		}
+	}
+	for name := range e.Set {
+		if name == "" || strings.Contains(name, "=") {
+			return fmt.Errorf("invalid variable name %q in set", name)
+		}
+		if strings.HasPrefix(strings.ToUpper(name), "PIT_") {
+			return fmt.Errorf("set must not contain %s: PIT_* variables are set by pit", name)
+		}
+	}
+	return nil
+}
+
+// LoaderConfig holds defaults for loading data into database tables. The
+// same keys in a DAG's [dag.sql] section take precedence.
+type LoaderConfig struct {
+	Schema         string `toml:"schema"`          // target schema when a table name has none (default: the driver's, e.g. dbo or public)
+	IdentifierCase string `toml:"identifier_case"` // "preserve", "lower", or "upper" (default: the driver's)
+}
+
+// ValidIdentifierCases is the set of valid identifier_case values.
+var ValidIdentifierCases = map[string]bool{
+	"preserve": true,
+	"lower":    true,
+	"upper":    true,
+}
+
+// ArtifactStoreConfig describes where kept run artifacts are uploaded after
+// each run. Credentials come from Secret (a structured [global] secret) or,
+// if unset, the backend's standard environment variables.
+type ArtifactStoreConfig struct {
+	Type      string `toml:"type"`       // "s3", "azure", or "dir"
+	Bucket    string `toml:"bucket"`     // s3 bucket or azure container
+	Prefix    string `toml:"prefix"`     // key prefix, e.g. "pit/prod"
+	Region    string `toml:"region"`     // s3 region (default "us-east-1")
+	Endpoint  string `toml:"endpoint"`   // s3-compatible endpoint, or azure blob endpoint override
+	Account   string `toml:"account"`    // azure storage account
+	Path      string `toml:"path"`       // dir: destination directory
+	Secret    string `toml:"secret"`     // structured secret: access_key_id/secret_access_key[/session_token] or sas_token
+	KeepLocal bool   `toml:"keep_local"` // keep the local run directory after upload
+}
+
+// SnapshotConfig tunes how project directories are copied into runs.
+type SnapshotConfig struct {
+	Workers  int    `toml:"workers"`  // concurrent file copies (0 = default, 1 = sequential, e.g. for network filesystems)
+	Symlinks string `toml:"symlinks"` // "skip" (default), "follow", or "preserve"
+	ReadOnly bool   `toml:"read_only"` // write-protect snapshots while tasks run, for every DAG
+}
+
+// ValidSymlinkPolicies is the set of valid snapshot.symlinks values.
+var ValidSymlinkPolicies = map[string]bool{
+	"skip":     true,
+	"follow":   true,
+	"preserve": true,
+}
+
+// CalendarConfig defines days and windows during which scheduled (cron)
+// triggers are suppressed or deferred. DAGs opt out with ignore_calendar.
+type CalendarConfig struct {
+	Holidays      []string         `toml:"holidays"`       // whole days, "2006-01-02"
+	HolidayAction string           `toml:"holiday_action"` // "skip" (default) or "defer"
+	Blackouts     []BlackoutWindow `toml:"blackout"`
+}
+
+// BlackoutWindow is either a fixed span (start/end) or a set of days that
+// recurs every month (month_days).
+type BlackoutWindow struct {
+	Name      string `toml:"name"`
+	Start     string `toml:"start"`      // "2006-01-02" or "2006-01-02 15:04"
+	End       string `toml:"end"`        // exclusive; a date-only end covers that whole day
+	MonthDays []int  `toml:"month_days"` // 1..31, or -1 = last day of month, -2 = second last, ...
+	Action    string `toml:"action"`     // "skip" (default) or "defer"
+}
+
+// WorkerEndpoint describes a remote worker that pit serve can dispatch runs to.
+type WorkerEndpoint struct {
+	URL   string `toml:"url"`   // base URL, e.g. "http://winbox:9191"
+	Token string `toml:"token"` // bearer token matching the worker's worker_token
 }
 
 // LoadPitConfig loads pit_config.toml from rootDir.
@@ -68,14 +267,232 @@ func LoadPitConfig(rootDir string) (*PitConfig, error) {
 	if cfg.SecretsRecipients != "" && !filepath.IsAbs(cfg.SecretsRecipients) {
 		cfg.SecretsRecipients = filepath.Join(rootDir, cfg.SecretsRecipients)
 	}
+	if cfg.AuditLog != "" && !filepath.IsAbs(cfg.AuditLog) {
+		cfg.AuditLog = filepath.Join(rootDir, cfg.AuditLog)
+	}
+	if cfg.SDKSocketDir != "" && !filepath.IsAbs(cfg.SDKSocketDir) {
+		cfg.SDKSocketDir = filepath.Join(rootDir, cfg.SDKSocketDir)
+	}
+	if cfg.ServeStateFile != "" && !filepath.IsAbs(cfg.ServeStateFile) {
+		cfg.ServeStateFile = filepath.Join(rootDir, cfg.ServeStateFile)
+	}
+	if cfg.DiscoverCache != "" && cfg.DiscoverCache != DiscoverCacheOff && !filepath.IsAbs(cfg.DiscoverCache) {
+		cfg.DiscoverCache = filepath.Join(rootDir, cfg.DiscoverCache)
+	}
 	// age_identity is NOT made absolute — it may contain ~ or be a user-level path
 
+	if cfg.MaxConcurrentRuns < 0 {
+		return nil, fmt.Errorf("invalid max_concurrent_runs %d (must be >= 0)", cfg.MaxConcurrentRuns)
+	}
+
+	if cfg.Snapshot != nil {
+		if cfg.Snapshot.Workers < 0 {
+			return nil, fmt.Errorf("invalid snapshot.workers %d (must be >= 0)", cfg.Snapshot.Workers)
+		}
+		if cfg.Snapshot.Symlinks != "" && !ValidSymlinkPolicies[cfg.Snapshot.Symlinks] {
+			return nil, fmt.Errorf("invalid snapshot.symlinks %q (must be skip, follow, or preserve)", cfg.Snapshot.Symlinks)
+		}
+	}
+
+	if cfg.Env != nil {
+		if err := cfg.Env.Validate(); err != nil {
+			return nil, fmt.Errorf("env: %w", err)
+		}
+	}
+
+	if l := cfg.Lineage; l != nil {
+		if l.URL == "" {
+			return nil, fmt.Errorf("lineage: url is required")
+		}
+		if !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+			return nil, fmt.Errorf("lineage: invalid url %q (must be http or https)", l.URL)
+		}
+	}
+
+	targets := make(map[string]bool)
+	for i := range cfg.Deploy {
+		d := &cfg.Deploy[i]
+		if d.URL == "" {
+			return nil, fmt.Errorf("deploy %d: url is required", i+1)
+		}
+		if d.Target == "" {
+			d.Target = "projects"
+		}
+		if !filepath.IsLocal(d.Target) {
+			return nil, fmt.Errorf("deploy %d: target %q must be a directory inside the workspace", i+1, d.Target)
+		}
+		if d.Subdir != "" && !filepath.IsLocal(d.Subdir) {
+			return nil, fmt.Errorf("deploy %d: subdir %q must be a directory inside the repository", i+1, d.Subdir)
+		}
+		d.Target = filepath.Join(rootDir, d.Target)
+		if targets[d.Target] {
+			return nil, fmt.Errorf("deploy %d: target %q is deployed by more than one source", i+1, d.Target)
+		}
+		targets[d.Target] = true
+	}
+
+	for _, g := range cfg.ProjectGlobs {
+		if strings.Contains(g, "**") {
+			return nil, fmt.Errorf("invalid project_globs entry %q (** is not supported; add one glob per depth)", g)
+		}
+		if _, err := filepath.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("invalid project_globs entry %q: %w", g, err)
+		}
+	}
+
+	if d := cfg.Defaults; d != nil {
+		if d.Timezone != "" {
+			if _, err := time.LoadLocation(d.Timezone); err != nil {
+				return nil, fmt.Errorf("defaults: invalid timezone %q: %w", d.Timezone, err)
+			}
+		}
+		if d.Overlap != "" && d.Overlap != "skip" && d.Overlap != "wait" && d.Overlap != "allow" {
+			return nil, fmt.Errorf("defaults: invalid overlap %q (must be skip, wait, or allow)", d.Overlap)
+		}
+		if d.Retries < 0 {
+			return nil, fmt.Errorf("defaults: invalid retries %d (must be >= 0)", d.Retries)
+		}
+		if d.MaxParallelTasks < 0 {
+			return nil, fmt.Errorf("defaults: invalid max_parallel_tasks %d (must be >= 0)", d.MaxParallelTasks)
+		}
+	}
+
+	for i := range cfg.LogSinks {
+		ls := &cfg.LogSinks[i]
+		switch ls.Type {
+		case "file":
+			if ls.Path == "" {
+				return nil, fmt.Errorf("log_sink %d: path is required for type \"file\"", i+1)
+			}
+			if !filepath.IsAbs(ls.Path) {
+				ls.Path = filepath.Join(rootDir, ls.Path)
+			}
+		case "syslog":
+			if ls.Address == "" {
+				return nil, fmt.Errorf("log_sink %d: address is required for type \"syslog\"", i+1)
+			}
+			if scheme, _, ok := strings.Cut(ls.Address, "://"); ok && scheme != "udp" && scheme != "tcp" {
+				return nil, fmt.Errorf("log_sink %d: invalid address %q (must be udp:// or tcp://)", i+1, ls.Address)
+			}
+		case "loki":
+			if !strings.HasPrefix(ls.URL, "http://") && !strings.HasPrefix(ls.URL, "https://") {
+				return nil, fmt.Errorf("log_sink %d: invalid url %q (must be http or https)", i+1, ls.URL)
+			}
+		default:
+			return nil, fmt.Errorf("invalid log_sink.type %q (must be file, syslog, or loki)", ls.Type)
+		}
+	}
+
+	if cfg.Loader != nil && cfg.Loader.IdentifierCase != "" && !ValidIdentifierCases[cfg.Loader.IdentifierCase] {
+		return nil, fmt.Errorf("invalid loader.identifier_case %q (must be preserve, lower, or upper)", cfg.Loader.IdentifierCase)
+	}
+
+	if a := cfg.ArtifactStore; a != nil {
+		switch a.Type {
+		case "s3":
+			if a.Bucket == "" {
+				return nil, fmt.Errorf("artifact_store: bucket is required for type \"s3\"")
+			}
+		case "azure":
+			if a.Account == "" && a.Endpoint == "" {
+				return nil, fmt.Errorf("artifact_store: account is required for type \"azure\"")
+			}
+			if a.Bucket == "" {
+				return nil, fmt.Errorf("artifact_store: bucket (container) is required for type \"azure\"")
+			}
+		case "dir":
+			if a.Path == "" {
+				return nil, fmt.Errorf("artifact_store: path is required for type \"dir\"")
+			}
+			if !filepath.IsAbs(a.Path) {
+				a.Path = filepath.Join(rootDir, a.Path)
+			}
+		default:
+			return nil, fmt.Errorf("invalid artifact_store.type %q (must be s3, azure, or dir)", a.Type)
+		}
+	}
+
+	for name, w := range cfg.Workers {
+		if w.URL == "" {
+			return nil, fmt.Errorf("worker %q: url is required", name)
+		}
+	}
+
+	if err := validatePlugins(rootDir, cfg.Plugins); err != nil {
+		return nil, err
+	}
+
 	// Validate keep_artifacts entries
 	for _, a := range cfg.KeepArtifacts {
 		if !ValidArtifacts[a] {
-			return nil, fmt.Errorf("invalid keep_artifacts value %q (must be logs, project, or data)", a)
+			return nil, fmt.Errorf("invalid keep_artifacts value %q (must be logs, logs_failed_only, project, data, or scratch)", a)
 		}
 	}
 
 	return &cfg, nil
 }
+
+// validatePlugins checks that each plugin has a command and claims runners,
+// extensions, and SDK methods no built-in or other plugin does. A command
+// given as a relative path is resolved from rootDir.
+func validatePlugins(rootDir string, plugins map[string]PluginConfig) error {
+	owner := make(map[string]string)
+	claim := func(plugin, kind, value string) error {
+		key := kind + " " + value
+		if other, ok := owner[key]; ok {
+			return fmt.Errorf("plugin %q: %s %q is also claimed by plugin %q", plugin, kind, value, other)
+		}
+		owner[key] = plugin
+		return nil
+	}
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := plugins[name]
+		if len(p.Command) == 0 || p.Command[0] == "" {
+			return fmt.Errorf("plugin %q: command is required", name)
+		}
+		if len(p.Runners) == 0 && len(p.Extensions) == 0 && len(p.SDKMethods) == 0 {
+			return fmt.Errorf("plugin %q: provides no runners, extensions, or sdk_methods", name)
+		}
+		for _, r := range p.Runners {
+			if r == "" || builtinRunners[r] || strings.HasPrefix(r, "$") {
+				return fmt.Errorf("plugin %q: invalid runner name %q", name, r)
+			}
+			if err := claim(name, "runner", r); err != nil {
+				return err
+			}
+		}
+		for _, ext := range p.Extensions {
+			if len(ext) < 2 || ext[0] != '.' || builtinExtensions[strings.ToLower(ext)] {
+				return fmt.Errorf("plugin %q: invalid extension %q", name, ext)
+			}
+			if err := claim(name, "extension", strings.ToLower(ext)); err != nil {
+				return err
+			}
+		}
+		for _, m := range p.SDKMethods {
+			if m == "" {
+				return fmt.Errorf("plugin %q: empty sdk_methods entry", name)
+			}
+			if err := claim(name, "sdk method", m); err != nil {
+				return err
+			}
+		}
+		if p.Timeout.Duration < 0 {
+			return fmt.Errorf("plugin %q: timeout must not be negative", name)
+		}
+
+		cmd := p.Command[0]
+		if !filepath.IsAbs(cmd) && strings.ContainsAny(cmd, `/\`) {
+			command := append([]string{filepath.Join(rootDir, cmd)}, p.Command[1:]...)
+			p.Command = command
+			plugins[name] = p
+		}
+	}
+	return nil
+}
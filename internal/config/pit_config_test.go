@@ -203,4 +203,370 @@ keep_artifacts = ["logs", "data"]
 			t.Errorf("SecretsRecipients = %q, want %q", cfg.SecretsRecipients, "/etc/pit/recipients.txt")
 		}
 	})
+
+	t.Run("calendar", func(t *testing.T) {
+		dir := t.TempDir()
+		content := `[calendar]
+holidays = ["2026-12-25"]
+holiday_action = "defer"
+
+[[calendar.blackout]]
+name = "month-end freeze"
+month_days = [-2, -1]
+
+[[calendar.blackout]]
+name = "migration"
+start = "2026-04-01 18:00"
+end = "2026-04-02"
+action = "skip"
+`
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.Calendar == nil {
+			t.Fatal("Calendar = nil, want parsed [calendar]")
+		}
+		if len(cfg.Calendar.Holidays) != 1 || cfg.Calendar.HolidayAction != "defer" {
+			t.Errorf("Calendar holidays = %v action %q", cfg.Calendar.Holidays, cfg.Calendar.HolidayAction)
+		}
+		if len(cfg.Calendar.Blackouts) != 2 {
+			t.Fatalf("len(Blackouts) = %d, want 2", len(cfg.Calendar.Blackouts))
+		}
+		if got := cfg.Calendar.Blackouts[0].MonthDays; len(got) != 2 || got[0] != -2 {
+			t.Errorf("Blackouts[0].MonthDays = %v, want [-2 -1]", got)
+		}
+		if cfg.Calendar.Blackouts[1].Start != "2026-04-01 18:00" {
+			t.Errorf("Blackouts[1].Start = %q", cfg.Calendar.Blackouts[1].Start)
+		}
+	})
+
+	t.Run("snapshot and min_free_space", func(t *testing.T) {
+		dir := t.TempDir()
+		content := `
+min_free_space = "2GB"
+
+[snapshot]
+workers = 1
+symlinks = "follow"
+`
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.MinFreeSpace == nil || *cfg.MinFreeSpace != 2_000_000_000 {
+			t.Errorf("MinFreeSpace = %v, want 2000000000", cfg.MinFreeSpace)
+		}
+		if cfg.Snapshot == nil || cfg.Snapshot.Workers != 1 || cfg.Snapshot.Symlinks != "follow" {
+			t.Errorf("Snapshot = %+v, want workers 1, symlinks follow", cfg.Snapshot)
+		}
+	})
+
+	t.Run("min_free_space zero is kept", func(t *testing.T) {
+		for _, value := range []string{"0", `"0"`} {
+			dir := t.TempDir()
+			content := "min_free_space = " + value + "\n"
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadPitConfig(dir)
+			if err != nil {
+				t.Fatalf("LoadPitConfig(min_free_space = %s) error: %v", value, err)
+			}
+			if cfg.MinFreeSpace == nil || *cfg.MinFreeSpace != 0 {
+				t.Errorf("min_free_space = %s: MinFreeSpace = %v, want explicit 0", value, cfg.MinFreeSpace)
+			}
+		}
+	})
+
+	t.Run("invalid snapshot.symlinks", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[snapshot]\nsymlinks = \"copy\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadPitConfig(dir)
+		if err == nil || !strings.Contains(err.Error(), "snapshot.symlinks") {
+			t.Errorf("LoadPitConfig() error = %v, want invalid snapshot.symlinks", err)
+		}
+	})
+
+	t.Run("loader", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[loader]\nschema = \"staging\"\nidentifier_case = \"lower\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.Loader == nil || cfg.Loader.Schema != "staging" || cfg.Loader.IdentifierCase != "lower" {
+			t.Errorf("Loader = %+v, want schema staging, identifier_case lower", cfg.Loader)
+		}
+	})
+
+	t.Run("invalid loader.identifier_case", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[loader]\nidentifier_case = \"camel\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadPitConfig(dir)
+		if err == nil || !strings.Contains(err.Error(), "loader.identifier_case") {
+			t.Errorf("LoadPitConfig() error = %v, want invalid loader.identifier_case", err)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[env]\ninherit = \"none\"\nallow = [\"PATH\", \"LANG*\"]\ndeny = [\"AWS_*\"]\n\n[env.set]\nTZ = \"UTC\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.Env == nil || cfg.Env.Inherit != "none" || len(cfg.Env.Allow) != 2 || len(cfg.Env.Deny) != 1 || cfg.Env.Set["TZ"] != "UTC" {
+			t.Errorf("Env = %+v, want inherit none, 2 allow, 1 deny, TZ set", cfg.Env)
+		}
+	})
+
+	t.Run("invalid env", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[env]\ninherit = \"some\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadPitConfig(dir)
+		if err == nil || !strings.Contains(err.Error(), "env: invalid inherit") {
+			t.Errorf("LoadPitConfig() error = %v, want invalid env.inherit", err)
+		}
+	})
+
+	t.Run("lineage", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[lineage]\nurl = \"http://marquez:5000/api/v1/lineage\"\nnamespace = \"etl\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.Lineage == nil || cfg.Lineage.URL != "http://marquez:5000/api/v1/lineage" || cfg.Lineage.Namespace != "etl" {
+			t.Errorf("Lineage = %+v", cfg.Lineage)
+		}
+	})
+
+	t.Run("invalid lineage", func(t *testing.T) {
+		for _, content := range []string{
+			"[lineage]\nnamespace = \"etl\"\n",
+			"[lineage]\nurl = \"marquez:5000\"\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "lineage:") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want lineage error", content, err)
+			}
+		}
+	})
+
+	t.Run("invalid project_globs", func(t *testing.T) {
+		for _, content := range []string{
+			"project_globs = [\"teams/**\"]\n",
+			"project_globs = [\"teams/[\"]\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "project_globs") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want project_globs error", content, err)
+			}
+		}
+	})
+
+	t.Run("deploy", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[[deploy]]\nurl = \"git@example.com:data/pipelines.git\"\nsubdir = \"pit\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if len(cfg.Deploy) != 1 || cfg.Deploy[0].Target != filepath.Join(dir, "projects") || cfg.Deploy[0].Subdir != "pit" {
+			t.Errorf("Deploy = %+v", cfg.Deploy)
+		}
+	})
+
+	t.Run("invalid deploy", func(t *testing.T) {
+		for _, content := range []string{
+			"[[deploy]]\ntarget = \"projects\"\n",
+			"[[deploy]]\nurl = \"repo.git\"\ntarget = \"../elsewhere\"\n",
+			"[[deploy]]\nurl = \"repo.git\"\nsubdir = \"/abs\"\n",
+			"[[deploy]]\nurl = \"a.git\"\n[[deploy]]\nurl = \"b.git\"\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "deploy 1") && !strings.Contains(err.Error(), "deploy 2") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want deploy error", content, err)
+			}
+		}
+	})
+
+	t.Run("plugins", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[plugins.sas]\ncommand = [\"plugins/pit-sas\", \"--quiet\"]\nrunners = [\"sas\"]\nextensions = [\".sas\"]\n\n[plugins.jobs]\ncommand = [\"pit-jobs\"]\nsdk_methods = [\"submit_job\"]\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if got := cfg.Plugins["sas"].Command; len(got) != 2 || got[0] != filepath.Join(dir, "plugins", "pit-sas") {
+			t.Errorf("sas command = %v, want path resolved from the workspace", got)
+		}
+		if got := cfg.Plugins["jobs"].Command[0]; got != "pit-jobs" {
+			t.Errorf("jobs command = %q, want PATH lookup left alone", got)
+		}
+	})
+
+	t.Run("invalid plugins", func(t *testing.T) {
+		for _, content := range []string{
+			"[plugins.sas]\nrunners = [\"sas\"]\n",
+			"[plugins.sas]\ncommand = [\"pit-sas\"]\n",
+			"[plugins.sas]\ncommand = [\"pit-sas\"]\nrunners = [\"python\"]\n",
+			"[plugins.sas]\ncommand = [\"pit-sas\"]\nextensions = [\"sas\"]\n",
+			"[plugins.sas]\ncommand = [\"pit-sas\"]\nextensions = [\".sql\"]\n",
+			"[plugins.a]\ncommand = [\"a\"]\nsdk_methods = [\"submit\"]\n[plugins.b]\ncommand = [\"b\"]\nsdk_methods = [\"submit\"]\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "plugin ") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want plugin error", content, err)
+			}
+		}
+	})
+
+	t.Run("invalid defaults", func(t *testing.T) {
+		for _, content := range []string{
+			"[defaults]\ntimezone = \"Mars/Olympus_Mons\"\n",
+			"[defaults]\noverlap = \"queue\"\n",
+			"[defaults]\nretries = -1\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "defaults:") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want defaults error", content, err)
+			}
+		}
+	})
+
+	t.Run("log_sink", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[[log_sink]]\ntype = \"file\"\npath = \"logs/pit.jsonl\"\n\n" +
+			"[[log_sink]]\ntype = \"loki\"\nurl = \"http://loki:3100\"\n[log_sink.labels]\nenv = \"prod\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if len(cfg.LogSinks) != 2 {
+			t.Fatalf("LogSinks = %+v, want 2 sinks", cfg.LogSinks)
+		}
+		if want := filepath.Join(dir, "logs/pit.jsonl"); cfg.LogSinks[0].Path != want {
+			t.Errorf("LogSinks[0].Path = %q, want %q", cfg.LogSinks[0].Path, want)
+		}
+		if cfg.LogSinks[1].Labels["env"] != "prod" {
+			t.Errorf("LogSinks[1].Labels = %v", cfg.LogSinks[1].Labels)
+		}
+	})
+
+	t.Run("invalid log_sink", func(t *testing.T) {
+		for _, content := range []string{
+			"[[log_sink]]\ntype = \"kafka\"\n",
+			"[[log_sink]]\ntype = \"file\"\n",
+			"[[log_sink]]\ntype = \"syslog\"\naddress = \"http://logs:514\"\n",
+			"[[log_sink]]\ntype = \"loki\"\nurl = \"loki:3100\"\n",
+		} {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPitConfig(dir); err == nil || !strings.Contains(err.Error(), "log_sink") {
+				t.Errorf("LoadPitConfig(%q) error = %v, want log_sink error", content, err)
+			}
+		}
+	})
+
+	t.Run("artifact_store", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "[artifact_store]\ntype = \"dir\"\npath = \"archive\"\nkeep_local = true\n"
+		if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadPitConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadPitConfig() error: %v", err)
+		}
+		if cfg.ArtifactStore == nil || cfg.ArtifactStore.Path != filepath.Join(dir, "archive") || !cfg.ArtifactStore.KeepLocal {
+			t.Errorf("ArtifactStore = %+v, want dir store at %s with keep_local", cfg.ArtifactStore, filepath.Join(dir, "archive"))
+		}
+	})
+
+	t.Run("invalid artifact_store", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			content string
+		}{
+			{"unknown type", "[artifact_store]\ntype = \"gcs\"\n"},
+			{"s3 without bucket", "[artifact_store]\ntype = \"s3\"\n"},
+			{"azure without account", "[artifact_store]\ntype = \"azure\"\nbucket = \"runs\"\n"},
+			{"dir without path", "[artifact_store]\ntype = \"dir\"\n"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(dir, "pit_config.toml"), []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				_, err := LoadPitConfig(dir)
+				if err == nil || !strings.Contains(err.Error(), "artifact_store") {
+					t.Errorf("LoadPitConfig() error = %v, want artifact_store error", err)
+				}
+			})
+		}
+	})
 }
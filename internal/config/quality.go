@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Quality check kinds for tasks with type = "quality".
+const (
+	CheckRowCount  = "row_count"  // row count within [min, max]
+	CheckNullRatio = "null_ratio" // fraction of NULLs in a column within [min, max]
+	CheckUnique    = "unique"     // no two rows share the same column values
+)
+
+// QualityCheck is one declarative data quality check run by a quality
+// task against a table.
+type QualityCheck struct {
+	Name     string   `toml:"name"`     // label in logs (default: check and target)
+	Check    string   `toml:"check"`    // "row_count", "null_ratio", or "unique"
+	Table    string   `toml:"table"`    // overrides the task's table
+	Column   string   `toml:"column"`   // null_ratio: the column; unique: a single key column
+	Columns  []string `toml:"columns"`  // unique: a composite key
+	Where    string   `toml:"where"`    // SQL condition limiting the rows checked
+	Min      *float64 `toml:"min"`      // row_count, null_ratio: lowest passing value
+	Max      *float64 `toml:"max"`      // row_count, null_ratio: highest passing value
+	Severity string   `toml:"severity"` // "error" (default) fails the task; "warn" only logs
+}
+
+// Validate checks the check kind, its required fields, and severity.
+// defaultTable is the task's table, used when the check sets none.
+func (c QualityCheck) Validate(defaultTable string) error {
+	if c.Table == "" && defaultTable == "" {
+		return fmt.Errorf("no table (set table on the check or the task)")
+	}
+	switch c.Check {
+	case CheckRowCount:
+		if c.Min == nil && c.Max == nil {
+			return fmt.Errorf("row_count check requires min or max")
+		}
+	case CheckNullRatio:
+		if c.Column == "" {
+			return fmt.Errorf("null_ratio check requires column")
+		}
+		if c.Min == nil && c.Max == nil {
+			return fmt.Errorf("null_ratio check requires min or max")
+		}
+		for _, v := range []*float64{c.Min, c.Max} {
+			if v != nil && (*v < 0 || *v > 1) {
+				return fmt.Errorf("null_ratio bounds must be between 0 and 1, got %g", *v)
+			}
+		}
+	case CheckUnique:
+		if c.Column == "" && len(c.Columns) == 0 {
+			return fmt.Errorf("unique check requires column or columns")
+		}
+		if c.Min != nil || c.Max != nil {
+			return fmt.Errorf("min and max do not apply to unique checks")
+		}
+	case "":
+		return fmt.Errorf("missing check (row_count, null_ratio, or unique)")
+	default:
+		return fmt.Errorf("invalid check %q (must be row_count, null_ratio, or unique)", c.Check)
+	}
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		return fmt.Errorf("min %g is greater than max %g", *c.Min, *c.Max)
+	}
+	if c.Severity != "" && c.Severity != "error" && c.Severity != "warn" {
+		return fmt.Errorf("invalid severity %q (must be error or warn)", c.Severity)
+	}
+	return nil
+}
+
+// LoadQualityChecks reads the [[checks]] entries from a checks file.
+func LoadQualityChecks(path string) ([]QualityCheck, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading checks file: %w", err)
+	}
+	var file struct {
+		Checks []QualityCheck `toml:"checks"`
+	}
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing checks file %q: %w", path, err)
+	}
+	return file.Checks, nil
+}
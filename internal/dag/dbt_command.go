@@ -0,0 +1,279 @@
+package dag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dbtSubcommands are the dbt subcommands, with the words each accepts as
+// its own subcommand (docs generate, source freshness).
+var dbtSubcommands = map[string][]string{
+	"build":         nil,
+	"clean":         nil,
+	"clone":         nil,
+	"compile":       nil,
+	"debug":         nil,
+	"deps":          nil,
+	"docs":          {"generate", "serve"},
+	"init":          nil,
+	"list":          nil,
+	"ls":            nil,
+	"parse":         nil,
+	"retry":         nil,
+	"run":           nil,
+	"run-operation": nil,
+	"seed":          nil,
+	"show":          nil,
+	"snapshot":      nil,
+	"source":        {"freshness"},
+	"test":          nil,
+}
+
+// dbtFlags are the dbt flags pit recognises. true marks flags that take a
+// value, so the word after them is not read as a subcommand. Boolean flags
+// also have a --no- form.
+var dbtFlags = map[string]bool{
+	// global
+	"--cache-selected-only":        false,
+	"--debug":                      false,
+	"--fail-fast":                  false,
+	"--help":                       false,
+	"--introspect":                 false,
+	"--log-cache-events":           false,
+	"--log-format":                 true,
+	"--log-format-file":            true,
+	"--log-level":                  true,
+	"--log-level-file":             true,
+	"--log-path":                   true,
+	"--macro-debugging":            false,
+	"--partial-parse":              false,
+	"--partial-parse-file-diff":    false,
+	"--populate-cache":             false,
+	"--print":                      false,
+	"--printer-width":              true,
+	"--quiet":                      false,
+	"--record-timing-info":         true,
+	"--send-anonymous-usage-stats": false,
+	"--show-all-deprecations":      false,
+	"--static-parser":              false,
+	"--target-path":                true,
+	"--use-colors":                 false,
+	"--use-colors-file":            false,
+	"--use-experimental-parser":    false,
+	"--version":                    false,
+	"--version-check":              false,
+	"--warn-error":                 false,
+	"--warn-error-options":         true,
+	"--write-json":                 false,
+	// project and profile
+	"--profile":      true,
+	"--profiles-dir": true,
+	"--project-dir":  true,
+	"--target":       true,
+	"--threads":      true,
+	"--vars":         true,
+	// selection
+	"--exclude":               true,
+	"--exclude-resource-type": true,
+	"--indirect-selection":    true,
+	"--models":                true,
+	"--resource-type":         true,
+	"--resource-types":        true,
+	"--select":                true,
+	"--selector":              true,
+	// state and deferral
+	"--defer":       false,
+	"--defer-state": true,
+	"--favor-state": false,
+	"--state":       true,
+	// subcommand options
+	"--add-package":              true,
+	"--args":                     true,
+	"--browser":                  false,
+	"--clean-project-files-only": false,
+	"--compile":                  false,
+	"--config-dir":               false,
+	"--connection":               false,
+	"--dry-run":                  false,
+	"--empty":                    false,
+	"--empty-catalog":            false,
+	"--event-time-end":           true,
+	"--event-time-start":         true,
+	"--full-refresh":             false,
+	"--host":                     true,
+	"--inline":                   true,
+	"--limit":                    true,
+	"--lock":                     false,
+	"--output":                   true,
+	"--output-keys":              true,
+	"--output-path":              true,
+	"--port":                     true,
+	"--sample":                   true,
+	"--show":                     false,
+	"--skip-profile-setup":       false,
+	"--source":                   true,
+	"--static":                   false,
+	"--store-failures":           false,
+	"--upgrade":                  false,
+}
+
+// dbtShortFlags maps dbt's single-letter flags to their long forms.
+var dbtShortFlags = map[string]string{
+	"-d": "--debug",
+	"-f": "--full-refresh",
+	"-h": "--help",
+	"-m": "--models",
+	"-o": "--output",
+	"-q": "--quiet",
+	"-r": "--record-timing-info",
+	"-s": "--select",
+	"-t": "--target",
+	"-x": "--fail-fast",
+}
+
+// dbtProfileFlags are flags that override the profiles.yml pit generates
+// for dbt tasks, with why that matters.
+var dbtProfileFlags = map[string]string{
+	"--profiles-dir": "replaces the profiles.yml pit generates, so the connection secret is not used",
+	"--profile":      "selects a profile other than the one pit generates",
+	"--target":       "overrides the target of the generated profile (use dbt_target instead)",
+}
+
+// checkDBTCommand parses a dbt task's script the way the dbt runner passes
+// it to dbt. It returns errors for problems dbt would reject — a missing
+// or unknown subcommand, a misspelled flag — and warnings for flags that
+// override the generated profile or that pit does not know.
+func checkDBTCommand(command string) (errs, warns []string) {
+	words := strings.Fields(command)
+	if len(words) > 0 && words[0] == "dbt" {
+		return []string{`dbt command must not start with "dbt" (pit runs dbt itself, e.g. "run --select staging")`}, nil
+	}
+
+	var positional []string
+	var help bool
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			// The subcommand and its arguments, and the later values of
+			// flags such as --select a b; only the first two are checked.
+			positional = append(positional, w)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(w, "=")
+		if long, ok := dbtShortFlags[name]; ok {
+			name = long
+		}
+		takesValue, known := dbtFlags[name]
+		if !known && strings.HasPrefix(name, "--no-") {
+			base := "--" + strings.TrimPrefix(name, "--no-")
+			if negated, ok := dbtFlags[base]; ok {
+				if negated {
+					errs = append(errs, fmt.Sprintf("dbt command: unknown flag %q (%s takes a value and has no --no- form)", name, base))
+					continue
+				}
+				known = true
+			}
+		}
+		if !known {
+			if guess := closestDBTFlag(name); guess != "" {
+				errs = append(errs, fmt.Sprintf("dbt command: unknown flag %q (did you mean %q?)", name, guess))
+			} else {
+				warns = append(warns, fmt.Sprintf("dbt command: flag %q is not a dbt flag pit knows; it is passed to dbt unchecked", name))
+			}
+			continue
+		}
+		if name == "--help" || name == "--version" {
+			help = true
+		}
+		if why, ok := dbtProfileFlags[name]; ok {
+			warns = append(warns, fmt.Sprintf("dbt command sets %s, which %s", name, why))
+		}
+		// A flag's value is never the subcommand
+		if takesValue && !hasValue && i+1 < len(words) {
+			i++
+		}
+	}
+
+	if len(positional) == 0 {
+		if !help {
+			errs = append(errs, "dbt command has no subcommand (e.g. \"run --select staging\")")
+		}
+		return errs, warns
+	}
+	sub := positional[0]
+	subs, ok := dbtSubcommands[sub]
+	if !ok {
+		msg := fmt.Sprintf("dbt command: unknown subcommand %q", sub)
+		if guess := closest(sub, sortedKeys(dbtSubcommands)); guess != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", guess)
+		}
+		return append(errs, msg), warns
+	}
+	if len(subs) > 0 {
+		if len(positional) < 2 && !help {
+			errs = append(errs, fmt.Sprintf("dbt command: %q needs one of %s", sub, strings.Join(subs, ", ")))
+		} else if len(positional) >= 2 && !contains(subs, positional[1]) {
+			errs = append(errs, fmt.Sprintf("dbt command: unknown %q subcommand %q (must be one of %s)", sub, positional[1], strings.Join(subs, ", ")))
+		}
+	}
+	return errs, warns
+}
+
+// closestDBTFlag returns the known flag a misspelled one was most likely
+// meant to be, or "" if none is close.
+func closestDBTFlag(name string) string {
+	return closest(name, sortedKeys(dbtFlags))
+}
+
+// closest returns the candidate within two edits of s, preferring the
+// fewest edits and then the first in order, or "" if there is none.
+func closest(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package dag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestCheckDBTCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		wantErr  string // substring of the only error, "" for none
+		wantWarn string // substring of the only warning, "" for none
+	}{
+		{command: "run --select staging"},
+		{command: "build -s tag:daily --exclude a b --full-refresh"},
+		{command: "--debug run --select=marts --no-partial-parse"},
+		{command: "--log-level debug test"},
+		{command: "docs generate --empty-catalog"},
+		{command: "source freshness --select source:raw"},
+		{command: "run-operation grant_access --args {role:reader}"},
+		{command: "--version"},
+		{command: "rn --select staging", wantErr: `unknown subcommand "rn" (did you mean "run"?)`},
+		{command: "transform", wantErr: `unknown subcommand "transform"`},
+		{command: "dbt run", wantErr: `must not start with "dbt"`},
+		{command: "--full-refresh", wantErr: "no subcommand"},
+		{command: "run --selct staging", wantErr: `unknown flag "--selct" (did you mean "--select"?)`},
+		{command: "run --no-select a", wantErr: `unknown flag "--no-select"`},
+		{command: "docs", wantErr: `"docs" needs one of generate, serve`},
+		{command: "source fresh", wantErr: `unknown "source" subcommand "fresh"`},
+		{command: "run --target dev", wantWarn: "sets --target"},
+		{command: "run -t dev", wantWarn: "sets --target"},
+		{command: "run --profiles-dir=/etc/dbt", wantWarn: "sets --profiles-dir"},
+		{command: "run --profile other", wantWarn: "sets --profile"},
+		{command: "run --experimental-thing", wantWarn: `flag "--experimental-thing" is not a dbt flag pit knows`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			errs, warns := checkDBTCommand(tt.command)
+			check := func(kind string, got []string, want string) {
+				if want == "" {
+					if len(got) != 0 {
+						t.Errorf("%s = %q, want none", kind, got)
+					}
+					return
+				}
+				if len(got) != 1 || !strings.Contains(got[0], want) {
+					t.Errorf("%s = %q, want one containing %q", kind, got, want)
+				}
+			}
+			check("errors", errs, tt.wantErr)
+			check("warnings", warns, tt.wantWarn)
+		})
+	}
+}
+
+func TestValidate_DBTCommand(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name: "dbt_dag",
+			DBT:  &config.DBTConfig{Version: "1.9.1", Adapter: "dbt-sqlserver"},
+		},
+		Tasks: []config.TaskConfig{
+			{Name: "typo", Script: "rnu --select staging", Runner: "dbt"},
+			{Name: "target", Script: "run --target dev", Runner: "dbt"},
+		},
+	}
+
+	errs := Validate(cfg, t.TempDir())
+	if len(errs) != 1 || errs[0].Task != "typo" || !strings.Contains(errs[0].Message, `did you mean "run"`) {
+		t.Errorf("Validate() = %v, want the unknown subcommand of typo", errs)
+	}
+	warns := Warnings(cfg)
+	if len(warns) != 1 || warns[0].Task != "target" || !warns[0].IsWarning() {
+		t.Errorf("Warnings() = %v, want the --target override of target", warns)
+	}
+}
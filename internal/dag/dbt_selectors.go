@@ -0,0 +1,371 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+	"go.yaml.in/yaml/v3"
+)
+
+// dbtNode is a node of a dbt project, as far as selectors need it.
+type dbtNode struct {
+	Name         string
+	ResourceType string // model, seed, snapshot, source, ...
+	Package      string
+	Path         string   // file path relative to the dbt project, slash-separated
+	FQN          []string // package, directories, name
+	Tags         []string
+	SourceName   string // sources only
+}
+
+// dbtProject is the set of nodes selectors are checked against.
+type dbtProject struct {
+	Nodes []dbtNode
+	// FromManifest is set when the nodes come from a manifest.json. Without
+	// one, tags and sources are unknown, so selectors on them are not checked.
+	FromManifest bool
+}
+
+// validateDBTSelectors checks that every --select in the DAG's dbt tasks
+// matches at least one node of the dbt project.
+func validateDBTSelectors(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
+	dbt := cfg.DAG.DBT
+	dbtDir := filepath.Join(projectDir, dbt.ProjectDir)
+	var project *dbtProject
+	var err error
+	if dbt.Manifest != "" {
+		project, err = loadDBTManifest(filepath.Join(dbtDir, dbt.Manifest))
+	} else {
+		project, err = scanDBTProject(dbtDir)
+	}
+	if err != nil {
+		return []*ValidationError{{DAG: cfg.DAG.Name, Message: fmt.Sprintf("dbt.check_selectors: %v", err)}}
+	}
+
+	var errs []*ValidationError
+	for _, t := range cfg.Tasks {
+		if t.Runner != "dbt" {
+			continue
+		}
+		for _, sel := range dbtSelectArgs(t.Script) {
+			if !project.selects(sel) {
+				errs = append(errs, &ValidationError{
+					DAG:     cfg.DAG.Name,
+					Task:    t.Name,
+					Message: fmt.Sprintf("dbt selector %q matches no nodes in the dbt project", sel),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// dbtSelectArgs returns the values given to --select (or -s, --models, -m)
+// in a dbt command. A flag takes every following word up to the next flag.
+func dbtSelectArgs(command string) []string {
+	var sels []string
+	selecting := false
+	for _, w := range strings.Fields(command) {
+		if strings.HasPrefix(w, "-") {
+			name, value, hasValue := strings.Cut(w, "=")
+			selecting = name == "--select" || name == "-s" || name == "--models" || name == "-m"
+			if selecting && hasValue {
+				sels = append(sels, value)
+				selecting = false
+			}
+			continue
+		}
+		if selecting {
+			sels = append(sels, w)
+		}
+	}
+	return sels
+}
+
+// selects reports whether sel, one space-separated selector, could select
+// anything. The parts of an intersection (a,b) must match a common node,
+// unless graph operators widen them, when each must match on its own.
+// Methods pit cannot evaluate match every node.
+func (p *dbtProject) selects(sel string) bool {
+	type criterion struct{ method, value string }
+	var criteria []criterion
+	graph := false
+	for _, atom := range strings.Split(sel, ",") {
+		if atom == "" {
+			continue
+		}
+		m := graphOperators.FindStringSubmatch(atom)
+		graph = graph || m[1] != "" || m[3] != ""
+		if method, value, ok := p.criterion(m[2]); ok {
+			criteria = append(criteria, criterion{method, value})
+		}
+	}
+
+	matchesAll := func(n *dbtNode, cs []criterion) bool {
+		for _, c := range cs {
+			if !n.matches(c.method, c.value) {
+				return false
+			}
+		}
+		return true
+	}
+	if graph {
+		for _, c := range criteria {
+			if !slices.ContainsFunc(p.Nodes, func(n dbtNode) bool { return matchesAll(&n, []criterion{c}) }) {
+				return false
+			}
+		}
+		return true
+	}
+	return len(criteria) == 0 || slices.ContainsFunc(p.Nodes, func(n dbtNode) bool { return matchesAll(&n, criteria) })
+}
+
+// graphOperators splits the graph operators from a selector: @ or n+
+// before it, +n after it.
+var graphOperators = regexp.MustCompile(`^(@|[0-9]*\+)?(.*?)(\+[0-9]*)?$`)
+
+// criterion returns the selector method and value of atom, or false if
+// pit cannot evaluate it against the project.
+func (p *dbtProject) criterion(atom string) (method, value string, ok bool) {
+	method, value, hasMethod := strings.Cut(atom, ":")
+	if !hasMethod {
+		// dbt reads a bare selector as a path if it looks like one
+		method, value = "fqn", atom
+		if strings.Contains(atom, "/") || strings.HasSuffix(atom, ".sql") || strings.HasSuffix(atom, ".py") || strings.HasSuffix(atom, ".csv") {
+			method = "path"
+		}
+	}
+	switch method {
+	case "fqn", "path", "file", "resource_type", "package":
+		return method, value, true
+	case "tag", "source":
+		return method, value, p.FromManifest
+	}
+	// config.materialized:, state:, result:, and others
+	return "", "", false
+}
+
+func (n *dbtNode) matches(method, value string) bool {
+	switch method {
+	case "fqn":
+		if n.ResourceType == "source" {
+			return false
+		}
+		return fqnMatches(n.FQN, value) || (len(n.FQN) > 1 && fqnMatches(n.FQN[1:], value))
+	case "path":
+		value = strings.TrimSuffix(path.Clean(filepath.ToSlash(value)), "/")
+		if n.Path == value || strings.HasPrefix(n.Path, value+"/") {
+			return true
+		}
+		ok, _ := path.Match(value, n.Path)
+		return ok
+	case "file":
+		base := path.Base(n.Path)
+		return globMatch(value, base) || globMatch(value, strings.TrimSuffix(base, path.Ext(base)))
+	case "resource_type":
+		return n.ResourceType == value
+	case "package":
+		return globMatch(value, n.Package)
+	case "tag":
+		for _, tag := range n.Tags {
+			if globMatch(value, tag) {
+				return true
+			}
+		}
+	case "source":
+		if n.ResourceType != "source" {
+			return false
+		}
+		// source:name, source:name.table, or source:package.name.table
+		parts := strings.Split(value, ".")
+		want := []string{n.SourceName, n.Name}
+		if len(parts) == 3 {
+			want = []string{n.Package, n.SourceName, n.Name}
+		}
+		for i, part := range parts {
+			if i >= len(want) || !globMatch(part, want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// fqnMatches follows dbt: a selector matches a node by its name, or by
+// a dotted prefix of its fully qualified name, the last part of which may
+// be a glob.
+func fqnMatches(fqn []string, selector string) bool {
+	if len(fqn) == 0 {
+		return false
+	}
+	if globMatch(selector, fqn[len(fqn)-1]) {
+		return true
+	}
+	parts := strings.Split(selector, ".")
+	if len(parts) > len(fqn) {
+		return false
+	}
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			ok, _ := path.Match(strings.Join(parts[i:], "."), strings.Join(fqn[i:], "."))
+			return ok
+		}
+		if fqn[i] != part {
+			return false
+		}
+	}
+	return true
+}
+
+func globMatch(pattern, s string) bool {
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// loadDBTManifest reads the nodes and sources of a dbt manifest.json.
+func loadDBTManifest(manifestPath string) (*dbtProject, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	type manifestNode struct {
+		Name         string   `json:"name"`
+		ResourceType string   `json:"resource_type"`
+		Package      string   `json:"package_name"`
+		Path         string   `json:"original_file_path"`
+		FQN          []string `json:"fqn"`
+		Tags         []string `json:"tags"`
+		SourceName   string   `json:"source_name"`
+	}
+	var manifest struct {
+		Nodes   map[string]manifestNode `json:"nodes"`
+		Sources map[string]manifestNode `json:"sources"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", manifestPath, err)
+	}
+
+	project := &dbtProject{FromManifest: true}
+	for _, group := range []map[string]manifestNode{manifest.Nodes, manifest.Sources} {
+		for _, n := range group {
+			project.Nodes = append(project.Nodes, dbtNode(n))
+		}
+	}
+	return project, nil
+}
+
+// snapshotBlock matches the name in a {% snapshot name %} block.
+var snapshotBlock = regexp.MustCompile(`{%-?\s*snapshot\s+(\w+)\s*-?%}`)
+
+// scanDBTProject builds the nodes of a dbt project from its files: models
+// (.sql, .py), seeds (.csv), and snapshots, found under the paths set in
+// dbt_project.yml.
+func scanDBTProject(dbtDir string) (*dbtProject, error) {
+	data, err := os.ReadFile(filepath.Join(dbtDir, "dbt_project.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("reading dbt project: %w", err)
+	}
+	var cfg struct {
+		Name          string   `yaml:"name"`
+		ModelPaths    []string `yaml:"model-paths"`
+		SeedPaths     []string `yaml:"seed-paths"`
+		SnapshotPaths []string `yaml:"snapshot-paths"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing dbt_project.yml: %w", err)
+	}
+	if cfg.ModelPaths == nil {
+		cfg.ModelPaths = []string{"models"}
+	}
+	if cfg.SeedPaths == nil {
+		cfg.SeedPaths = []string{"seeds"}
+	}
+	if cfg.SnapshotPaths == nil {
+		cfg.SnapshotPaths = []string{"snapshots"}
+	}
+
+	project := &dbtProject{}
+	// dirs are the directories between the model, seed, or snapshot path
+	// and the file, which dbt puts in the fully qualified name.
+	add := func(resourceType, rel string, dirs []string, name string) {
+		fqn := append(append([]string{cfg.Name}, dirs...), name)
+		project.Nodes = append(project.Nodes, dbtNode{
+			Name:         name,
+			ResourceType: resourceType,
+			Package:      cfg.Name,
+			Path:         rel,
+			FQN:          fqn,
+		})
+	}
+	walk := func(roots []string, visit func(rel string, dirs []string, ext string) error) error {
+		for _, root := range roots {
+			err := filepath.WalkDir(filepath.Join(dbtDir, root), func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					if os.IsNotExist(err) {
+						return filepath.SkipDir
+					}
+					return err
+				}
+				if d.IsDir() {
+					return nil
+				}
+				rel, err := filepath.Rel(dbtDir, p)
+				if err != nil {
+					return err
+				}
+				var dirs []string
+				if sub, err := filepath.Rel(filepath.Join(dbtDir, root), filepath.Dir(p)); err == nil && sub != "." {
+					dirs = strings.Split(filepath.ToSlash(sub), "/")
+				}
+				return visit(filepath.ToSlash(rel), dirs, filepath.Ext(p))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err = walk(cfg.ModelPaths, func(rel string, dirs []string, ext string) error {
+		if ext == ".sql" || ext == ".py" {
+			add("model", rel, dirs, strings.TrimSuffix(path.Base(rel), ext))
+		}
+		return nil
+	})
+	if err == nil {
+		err = walk(cfg.SeedPaths, func(rel string, dirs []string, ext string) error {
+			if ext == ".csv" {
+				add("seed", rel, dirs, strings.TrimSuffix(path.Base(rel), ext))
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = walk(cfg.SnapshotPaths, func(rel string, dirs []string, ext string) error {
+			if ext != ".sql" {
+				return nil
+			}
+			src, err := os.ReadFile(filepath.Join(dbtDir, rel))
+			if err != nil {
+				return err
+			}
+			for _, m := range snapshotBlock.FindAllSubmatch(src, -1) {
+				add("snapshot", rel, dirs, string(m[1]))
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scanning dbt project: %w", err)
+	}
+	return project, nil
+}
@@ -0,0 +1,164 @@
+package dag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// writeFiles creates each file under dir, with parent directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDBTSelectArgs(t *testing.T) {
+	got := dbtSelectArgs("run --select a b+ --full-refresh -s=c -m tag:x --exclude d")
+	if strings.Join(got, " ") != "a b+ c tag:x" {
+		t.Errorf("dbtSelectArgs() = %q, want [a b+ c tag:x]", got)
+	}
+}
+
+func TestScanDBTProject_Selectors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"dbt_project.yml":                  "name: analytics\nmodel-paths: [\"transform\"]\n",
+		"transform/staging/stg_claims.sql": "select 1",
+		"transform/marts/fct_claims.py":    "def model(dbt, session): pass",
+		"seeds/countries.csv":              "code\nAU\n",
+		"snapshots/claims.sql":             "{% snapshot claims_snapshot %}\nselect 1\n{% endsnapshot %}",
+	})
+	project, err := scanDBTProject(dir)
+	if err != nil {
+		t.Fatalf("scanDBTProject() error: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want bool
+	}{
+		{"stg_claims", true},
+		{"+fct_claims+", true},
+		{"2+stg_claims+1", true},
+		{"@stg_claims", true},
+		{"staging", true},
+		{"analytics.marts", true},
+		{"analytics.staging.stg_*", true},
+		{"stg_*", true},
+		{"transform/marts", true},
+		{"path:transform/staging/stg_claims.sql", true},
+		{"file:fct_claims.py", true},
+		{"resource_type:seed", true},
+		{"countries", true},
+		{"claims_snapshot", true},
+		{"package:analytics", true},
+		{"staging,resource_type:model", true},
+		{"staging+,resource_type:seed", true}, // descendants may be seeds
+		// unknown without a manifest, so not checked
+		{"tag:nightly", true},
+		{"source:raw", true},
+		{"config.materialized:table", true},
+		{"state:modified", true},
+
+		{"stg_claim", false},
+		{"analytics.finance", false},
+		{"models/staging", false},
+		{"staging,resource_type:seed", false},
+		{"package:other", false},
+		{"file:missing.sql", false},
+	}
+	for _, tt := range tests {
+		if got := project.selects(tt.sel); got != tt.want {
+			t.Errorf("selects(%q) = %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDBTManifest_Selectors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"target/manifest.json": `{
+		"nodes": {
+			"model.analytics.stg_claims": {"name": "stg_claims", "resource_type": "model", "package_name": "analytics",
+				"original_file_path": "models/staging/stg_claims.sql", "fqn": ["analytics", "staging", "stg_claims"], "tags": ["nightly"]}
+		},
+		"sources": {
+			"source.analytics.raw.claims": {"name": "claims", "resource_type": "source", "package_name": "analytics",
+				"source_name": "raw", "original_file_path": "models/sources.yml", "fqn": ["analytics", "raw", "claims"]}
+		}
+	}`})
+	project, err := loadDBTManifest(filepath.Join(dir, "target", "manifest.json"))
+	if err != nil {
+		t.Fatalf("loadDBTManifest() error: %v", err)
+	}
+
+	for sel, want := range map[string]bool{
+		"tag:nightly":                    true,
+		"tag:night*":                     true,
+		"source:raw":                     true,
+		"source:raw.claims":              true,
+		"source:analytics.raw.*":         true,
+		"source:raw+":                    true,
+		"staging":                        true,
+		"tag:hourly":                     false,
+		"source:raw.policies":            false,
+		"source:landing":                 false,
+		"claims":                         false, // a source is only selected with source:
+		"tag:nightly,resource_type:seed": false,
+	} {
+		if got := project.selects(sel); got != want {
+			t.Errorf("selects(%q) = %v, want %v", sel, got, want)
+		}
+	}
+}
+
+func TestValidate_DBTSelectors(t *testing.T) {
+	projectDir := t.TempDir()
+	writeFiles(t, projectDir, map[string]string{
+		"dbt_repo/dbt_project.yml":               "name: analytics\n",
+		"dbt_repo/models/staging/stg_claims.sql": "select 1",
+	})
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name: "dbt_dag",
+			DBT: &config.DBTConfig{
+				Version:        "1.9.1",
+				Adapter:        "dbt-sqlserver",
+				ProjectDir:     "dbt_repo",
+				CheckSelectors: true,
+			},
+		},
+		Tasks: []config.TaskConfig{
+			{Name: "staging", Script: "run --select staging", Runner: "dbt"},
+			{Name: "marts", Script: "run --select marts tag:daily", Runner: "dbt"},
+			{Name: "test", Script: "test", Runner: "dbt"},
+		},
+	}
+
+	errs := Validate(cfg, projectDir)
+	if len(errs) != 1 || errs[0].Task != "marts" || !strings.Contains(errs[0].Message, `dbt selector "marts" matches no nodes`) {
+		t.Errorf("Validate() = %v, want marts to match nothing", errs)
+	}
+
+	// A manifest that cannot be read is reported once for the DAG
+	cfg.DAG.DBT.Manifest = "target/manifest.json"
+	errs = Validate(cfg, projectDir)
+	if len(errs) != 1 || errs[0].Task != "" || !strings.Contains(errs[0].Message, "dbt.check_selectors: reading manifest") {
+		t.Errorf("Validate() with missing manifest = %v, want one dbt.check_selectors error", errs)
+	}
+
+	cfg.DAG.DBT.CheckSelectors = false
+	if errs := Validate(cfg, projectDir); len(errs) != 0 {
+		t.Errorf("Validate() without check_selectors = %v, want none", errs)
+	}
+}
@@ -0,0 +1,118 @@
+package dag
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// Report is the result of validating a workspace, grouped by DAG.
+type Report struct {
+	DAGs     []DAGReport `json:"dags"` // every DAG found, sorted by name
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+}
+
+// DAGReport holds the problems found in one DAG: its own, then those
+// between it and other DAGs, such as requires cycles.
+type DAGReport struct {
+	Name     string             `json:"name"`
+	Dir      string             `json:"dir,omitempty"`
+	Errors   int                `json:"errors"`
+	Warnings int                `json:"warnings"`
+	Problems []*ValidationError `json:"problems"`
+}
+
+// Valid reports whether the DAG can run: it may have warnings, but no
+// errors.
+func (d DAGReport) Valid() bool {
+	return d.Errors == 0
+}
+
+// Problems returns every problem in the report, DAG by DAG.
+func (r *Report) Problems() []*ValidationError {
+	var all []*ValidationError
+	for _, d := range r.DAGs {
+		all = append(all, d.Problems...)
+	}
+	return all
+}
+
+// add files problems under their DAGs, creating groups for DAGs not yet in
+// the report, and updates the counts.
+func (r *Report) add(byName map[string]int, problems []*ValidationError) {
+	for _, p := range problems {
+		if p.Severity == "" {
+			p.Severity = SeverityError
+		}
+		i, ok := byName[p.DAG]
+		if !ok {
+			i = len(r.DAGs)
+			byName[p.DAG] = i
+			r.DAGs = append(r.DAGs, DAGReport{Name: p.DAG})
+		}
+		d := &r.DAGs[i]
+		d.Problems = append(d.Problems, p)
+		if p.IsWarning() {
+			d.Warnings++
+			r.Warnings++
+		} else {
+			d.Errors++
+			r.Errors++
+		}
+	}
+}
+
+// ValidateAll discovers all projects under rootDir and validates them
+// concurrently, returning the problems found grouped by DAG.
+func ValidateAll(rootDir string) (*Report, error) {
+	configs, err := config.Discover(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no projects found in %s (see project_globs in pit_config.toml)", rootDir)
+	}
+
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each DAG's problems, indexed like names
+	found := make([][]*ValidationError, len(names))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(names)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				cfg := configs[names[i]]
+				found[i] = append(Validate(cfg, cfg.Dir()), Warnings(cfg)...)
+			}
+		}()
+	}
+	for i := range names {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := &Report{DAGs: make([]DAGReport, len(names))}
+	byName := make(map[string]int, len(names))
+	for i, name := range names {
+		report.DAGs[i] = DAGReport{Name: name, Dir: configs[name].Dir(), Problems: []*ValidationError{}}
+		byName[name] = i
+	}
+	for _, problems := range found {
+		report.add(byName, problems)
+	}
+	report.add(byName, ValidateRequires(configs))
+	return report, nil
+}
@@ -0,0 +1,95 @@
+package dag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// writeProject creates projects/<name>/pit.toml under root with a task
+// script for every task it lists.
+func writeProject(t *testing.T, root, name, toml string, scripts ...string) {
+	t.Helper()
+	dir := filepath.Join(root, "projects", name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "pit.toml"), []byte(toml), 0o644)
+	for _, s := range scripts {
+		os.WriteFile(filepath.Join(dir, s), []byte("echo hi\n"), 0o755)
+	}
+}
+
+func TestValidateAll_Report(t *testing.T) {
+	root := t.TempDir()
+	writeProject(t, root, "good", "[dag]\nname = \"good\"\n\n[[tasks]]\nname = \"a\"\nscript = \"a.sh\"\n", "a.sh")
+	writeProject(t, root, "broken", "[dag]\nname = \"broken\"\nrequires = [\"missing\"]\n\n[[tasks]]\nname = \"a\"\nscript = \"missing.sh\"\n")
+	writeProject(t, root, "empty", "[dag]\nname = \"empty\"\n")
+
+	report, err := ValidateAll(root)
+	if err != nil {
+		t.Fatalf("ValidateAll() error: %v", err)
+	}
+
+	var names []string
+	for _, d := range report.DAGs {
+		names = append(names, d.Name)
+	}
+	if got := strings.Join(names, ","); got != "broken,empty,good" {
+		t.Fatalf("DAGs = %s, want broken,empty,good", got)
+	}
+	broken, empty, good := report.DAGs[0], report.DAGs[1], report.DAGs[2]
+
+	if broken.Valid() || broken.Errors != 2 || broken.Warnings != 0 {
+		t.Errorf("broken = %d errors, %d warnings; want 2 errors (missing script, unknown DAG)", broken.Errors, broken.Warnings)
+	}
+	last := broken.Problems[len(broken.Problems)-1]
+	if !strings.Contains(last.Message, `requires unknown DAG "missing"`) || last.Severity != SeverityError {
+		t.Errorf("last problem of broken = %+v, want the requires error after its own", last)
+	}
+	if !empty.Valid() || empty.Warnings != 1 || empty.Problems[0].Message != "dag has no tasks" {
+		t.Errorf("empty = %+v, want one no-tasks warning", empty)
+	}
+	if !good.Valid() || len(good.Problems) != 0 || good.Dir == "" {
+		t.Errorf("good = %+v, want no problems", good)
+	}
+	if report.Errors != 2 || report.Warnings != 1 || len(report.Problems()) != 3 {
+		t.Errorf("report totals = %d errors, %d warnings, %d problems; want 2, 1, 3", report.Errors, report.Warnings, len(report.Problems()))
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProjectConfig
+		want string
+	}{
+		{"no tasks", config.ProjectConfig{DAG: config.DAGConfig{Name: "d"}}, "dag has no tasks"},
+		{"transform has model tasks", config.ProjectConfig{DAG: config.DAGConfig{Name: "d", Transform: &config.TransformConfig{Dialect: "mssql"}}}, ""},
+		{"unbounded overlap", config.ProjectConfig{
+			DAG:   config.DAGConfig{Name: "d", Schedule: config.Schedules{"0 * * * *"}, Overlap: "allow"},
+			Tasks: []config.TaskConfig{{Name: "a"}},
+		}, "lets slow runs pile up"},
+		{"bounded overlap", config.ProjectConfig{
+			DAG:   config.DAGConfig{Name: "d", Schedule: config.Schedules{"0 * * * *"}, Overlap: "allow", MaxActiveRuns: 2},
+			Tasks: []config.TaskConfig{{Name: "a"}},
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warns := Warnings(&tt.cfg)
+			if tt.want == "" {
+				if len(warns) != 0 {
+					t.Errorf("Warnings() = %v, want none", warns)
+				}
+				return
+			}
+			if len(warns) != 1 || !strings.Contains(warns[0].Message, tt.want) || !warns[0].IsWarning() {
+				t.Errorf("Warnings() = %v, want a warning containing %q", warns, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// ValidateRequires checks dag.requires across a workspace: every required
+// DAG must exist, and requirements must not form a cycle.
+func ValidateRequires(configs map[string]*config.ProjectConfig) []*ValidationError {
+	var errs []*ValidationError
+	names := make([]string, 0, len(configs))
+	for name, cfg := range configs {
+		names = append(names, name)
+		for _, req := range cfg.DAG.Requires {
+			if _, ok := configs[req]; !ok {
+				errs = append(errs, &ValidationError{DAG: name, Message: fmt.Sprintf("requires unknown DAG %q", req)})
+			}
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].DAG < errs[j].DAG })
+
+	if _, cycle := orderByRequires(configs, names); len(cycle) > 0 {
+		errs = append(errs, &ValidationError{DAG: cycle[0], Message: cycleMessage(cycle)})
+	}
+	return errs
+}
+
+// OrderByRequires sorts names so that each DAG comes after the DAGs it
+// requires. Only requirements between DAGs in names count; otherwise DAGs
+// keep name order. It fails if the requirements form a cycle.
+func OrderByRequires(configs map[string]*config.ProjectConfig, names []string) ([]string, error) {
+	order, cycle := orderByRequires(configs, names)
+	if len(cycle) > 0 {
+		return nil, errors.New(cycleMessage(cycle))
+	}
+	return order, nil
+}
+
+func cycleMessage(cycle []string) string {
+	return "requires cycle involving DAGs: " + strings.Join(cycle, ", ")
+}
+
+// orderByRequires is OrderByRequires, returning the DAGs left unordered by
+// a cycle instead of an error.
+func orderByRequires(configs map[string]*config.ProjectConfig, names []string) (order, cycle []string) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	inDegree := make(map[string]int, len(names))
+	dependents := make(map[string][]string, len(names))
+	for _, name := range names {
+		inDegree[name] += 0
+		cfg := configs[name]
+		if cfg == nil {
+			continue
+		}
+		for _, req := range cfg.DAG.Requires {
+			if selected[req] {
+				dependents[req] = append(dependents[req], name)
+				inDegree[name]++
+			}
+		}
+	}
+
+	var ready []string
+	for name, deg := range inDegree {
+		if deg == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order = make([]string, 0, len(inDegree))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		node := ready[0]
+		ready = ready[1:]
+		order = append(order, node)
+		for _, dep := range dependents[node] {
+			inDegree[dep]--
+			if inDegree[dep] == 0 {
+				ready = append(ready, dep)
+			}
+		}
+	}
+
+	for name, deg := range inDegree {
+		if deg > 0 {
+			cycle = append(cycle, name)
+		}
+	}
+	sort.Strings(cycle)
+	return order, cycle
+}
@@ -2,18 +2,38 @@ package dag
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/druarnfield/pit/internal/condition"
 	"github.com/druarnfield/pit/internal/config"
 	"github.com/robfig/cron/v3"
 )
 
+// Severity says whether a validation problem stops a DAG from running.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the DAG cannot run
+	SeverityWarning Severity = "warning" // the DAG runs, but probably not as intended
+)
+
 // ValidationError represents a single validation problem.
 type ValidationError struct {
-	DAG     string
-	Task    string
-	Message string
+	DAG      string   `json:"dag"`
+	Task     string   `json:"task,omitempty"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"` // "" is an error
+}
+
+// IsWarning reports whether the problem is only a warning.
+func (e *ValidationError) IsWarning() bool {
+	return e.Severity == SeverityWarning
 }
 
 func (e *ValidationError) Error() string {
@@ -30,6 +50,18 @@ var validOverlap = map[string]bool{
 	"allow": true,
 }
 
+var validMaxActiveOverflow = map[string]bool{
+	"":      true,
+	"skip":  true,
+	"queue": true,
+}
+
+var validMaxRunsOverflow = map[string]bool{
+	"":         true,
+	"skip":     true,
+	"coalesce": true,
+}
+
 // Validate checks a single ProjectConfig for errors.
 // projectDir is the directory containing the pit.toml (used to resolve script paths).
 func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
@@ -58,6 +90,42 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 		})
 	}
 
+	// max_active_runs caps concurrent runs, which only overlap = "allow" permits
+	switch {
+	case cfg.DAG.MaxActiveRuns < 0:
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.max_active_runs must not be negative"})
+	case cfg.DAG.MaxActiveRuns > 0 && (cfg.DAG.Overlap == "skip" || cfg.DAG.Overlap == "wait"):
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("dag.max_active_runs requires overlap = \"allow\" (overlap = %q already runs one at a time)", cfg.DAG.Overlap),
+		})
+	}
+	if !validMaxActiveOverflow[cfg.DAG.MaxActiveOverflow] {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid dag.max_active_overflow value %q (must be skip or queue)", cfg.DAG.MaxActiveOverflow),
+		})
+	} else if cfg.DAG.MaxActiveOverflow != "" && cfg.DAG.MaxActiveRuns == 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.max_active_overflow requires dag.max_active_runs"})
+	}
+
+	// max_runs_per_hour caps how many events serve turns into runs
+	if cfg.DAG.MaxRunsPerHour < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.max_runs_per_hour must not be negative"})
+	}
+	if !validMaxRunsOverflow[cfg.DAG.MaxRunsOverflow] {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid dag.max_runs_overflow value %q (must be skip or coalesce)", cfg.DAG.MaxRunsOverflow),
+		})
+	} else if cfg.DAG.MaxRunsOverflow != "" && cfg.DAG.MaxRunsPerHour == 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.max_runs_overflow requires dag.max_runs_per_hour"})
+	}
+
+	for _, o := range cfg.Outputs {
+		errs = append(errs, validateOutputAssertions(o, cfg, dagName)...)
+	}
+
 	// Build task name set and check for duplicates
 	taskNames := make(map[string]bool, len(cfg.Tasks))
 	for _, t := range cfg.Tasks {
@@ -86,12 +154,12 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 			}
 		}
 		// Validate task type
-		validTypes := map[string]bool{"": true, "load": true, "save": true}
+		validTypes := map[string]bool{"": true, "load": true, "save": true, "sensor": true, "quality": true, "http": true}
 		if !validTypes[t.Type] {
 			errs = append(errs, &ValidationError{
 				DAG:     dagName,
 				Task:    t.Name,
-				Message: fmt.Sprintf("invalid task type %q (must be load or save)", t.Type),
+				Message: fmt.Sprintf("invalid task type %q (must be load, save, sensor, quality, or http)", t.Type),
 			})
 		}
 
@@ -104,15 +172,39 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 			})
 		}
 
+		if (len(t.ExcludeColumns) > 0 || t.TimezonePolicy != "") && t.Type != "load" {
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Task:    t.Name,
+				Message: "exclude_columns and timezone_policy are only valid on type = \"load\" tasks",
+			})
+		}
+		if (t.RejectFile != "" || t.MaxRejects != 0) && t.Type != "load" {
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Task:    t.Name,
+				Message: "reject_file and max_rejects are only valid on type = \"load\" tasks",
+			})
+		}
+
 		if t.Type == "load" {
-			validModes := map[string]bool{"": true, "append": true, "truncate_and_load": true, "create_or_replace": true}
+			validModes := map[string]bool{"": true, "append": true, "append_or_create": true, "truncate_and_load": true, "create_or_replace": true}
 			if !validModes[t.Mode] {
 				errs = append(errs, &ValidationError{
 					DAG:     dagName,
 					Task:    t.Name,
-					Message: fmt.Sprintf("invalid mode %q (must be append, truncate_and_load, or create_or_replace)", t.Mode),
+					Message: fmt.Sprintf("invalid mode %q (must be append, append_or_create, truncate_and_load, or create_or_replace)", t.Mode),
 				})
 			}
+			validPolicies := map[string]bool{"": true, "error": true, "offset": true, "utc": true}
+			if !validPolicies[t.TimezonePolicy] {
+				errs = append(errs, &ValidationError{
+					DAG:     dagName,
+					Task:    t.Name,
+					Message: fmt.Sprintf("invalid timezone_policy %q (must be error, offset, or utc)", t.TimezonePolicy),
+				})
+			}
+			errs = append(errs, validateRejects(t, dagName)...)
 			if t.Source == "" {
 				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "load task requires source"})
 			}
@@ -139,6 +231,98 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 			}
 		}
 
+		if t.Type == "sensor" {
+			errs = append(errs, validateSensor(t, cfg, dagName)...)
+		}
+
+		if t.Type == "quality" {
+			errs = append(errs, validateQuality(t, cfg, dagName, projectDir)...)
+		} else if len(t.Checks) > 0 || t.ChecksFile != "" {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "checks and checks_file are only valid on type = \"quality\" tasks"})
+		}
+
+		if t.Type == "http" {
+			errs = append(errs, validateHTTP(t, dagName)...)
+		} else if t.URL != "" || t.Method != "" || len(t.Headers) > 0 || t.Body != "" || t.Pagination != nil {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "url, method, headers, body, and pagination are only valid on type = \"http\" tasks"})
+		}
+
+		errs = append(errs, validateConditions(t, cfg, dagName)...)
+		if t.MapOver != "" {
+			errs = append(errs, validateMapOver(t, cfg, dagName)...)
+		}
+		if (t.DBTTarget != "" || t.DBTConnection != "") && t.Runner != "dbt" {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "dbt_target and dbt_connection only apply to dbt tasks (runner = \"dbt\")"})
+		}
+
+		if t.StallTimeout.Duration < 0 {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("invalid stall_timeout %s (must be >= 0)", t.StallTimeout.Duration)})
+		}
+		if t.Freshness.Duration < 0 {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "freshness must not be negative"})
+		} else if t.Freshness.Duration > 0 {
+			// Freshness is judged by outputs the task registered in earlier runs
+			if len(cfg.Outputs) == 0 {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "freshness needs [[outputs]] for the task to register with register_output"})
+			}
+			if t.MapOver != "" {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "freshness is not supported on mapped tasks (map_over)"})
+			}
+		}
+		if t.StallAction != "" && t.StallAction != "warn" && t.StallAction != "kill" {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("invalid stall_action %q (must be warn or kill)", t.StallAction)})
+		}
+		switch t.TriggerRule {
+		case "", "all_success", "all_done", "none_failed":
+		case "one_failed":
+			if len(t.DependsOn) == 0 {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "trigger_rule \"one_failed\" requires depends_on (the task would never run)"})
+			}
+		default:
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Task:    t.Name,
+				Message: fmt.Sprintf("invalid trigger_rule %q (must be all_success, all_done, one_failed, or none_failed)", t.TriggerRule),
+			})
+		}
+		if t.StallTimeout.Duration > 0 && runsInProcess(t) {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "stall_timeout only applies to tasks that run a process (python, bash, dbt, or $ <command>)"})
+		}
+
+		if t.StmtTimeout.Duration < 0 {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("invalid statement_timeout %s (must be >= 0)", t.StmtTimeout.Duration)})
+		}
+		if t.StmtTimeout.Duration > 0 && !isSQLScript(t) {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "statement_timeout only applies to .sql script tasks"})
+		}
+
+		if t.Env != nil {
+			if err := t.Env.Validate(); err != nil {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "env: " + err.Error()})
+			} else if runsInProcess(t) {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "env only applies to tasks that run a process (python, bash, dbt, or $ <command>)"})
+			}
+		}
+
+		if t.Workdir != "" {
+			errs = append(errs, validateWorkdir(t, cfg.DAG.GitURL != "", projectDir, dagName)...)
+		}
+
+		if t.Offline != nil && *t.Offline && runsInProcess(t) {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "offline only applies to tasks that run a process (python, bash, dbt, or $ <command>)"})
+		}
+
+		if t.CPULimit < 0 {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("cpu_limit must not be negative, got %g", t.CPULimit)})
+		}
+		if (t.MemoryLimit > 0 || t.CPULimit > 0) && runsInProcess(t) {
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Task:    t.Name,
+				Message: "memory_limit and cpu_limit only apply to tasks that run a process (python, bash, dbt, or $ <command>)",
+			})
+		}
+
 		if t.Type != "load" {
 			if t.Runner == "dbt" {
 				// dbt tasks: script is a dbt command, not a file path
@@ -148,6 +332,11 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 						Task:    t.Name,
 						Message: "dbt task requires a non-empty script (dbt command, e.g. \"run --select staging\")",
 					})
+				} else {
+					cmdErrs, _ := checkDBTCommand(t.Script)
+					for _, msg := range cmdErrs {
+						errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: msg})
+					}
 				}
 			} else if t.Script != "" && cfg.DAG.GitURL == "" {
 				// Script existence can only be verified for local projects.
@@ -164,16 +353,69 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 		}
 	}
 
-	// Validate schedule as cron expression
-	if cfg.DAG.Schedule != "" {
-		if _, err := cron.ParseStandard(cfg.DAG.Schedule); err != nil {
+	if tz := cfg.DAG.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Message: fmt.Sprintf("invalid timezone %q: %s", tz, err),
+			})
+		}
+	}
+
+	if d := cfg.DAG.DataDir; d != "" && !filepath.IsAbs(d) {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("dag.data_dir %q must be an absolute path", d),
+		})
+	}
+
+	if cfg.DAG.MaxParallelTasks < 0 {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid max_parallel_tasks %d (must be >= 0)", cfg.DAG.MaxParallelTasks),
+		})
+	}
+
+	// Validate each schedule as a cron expression
+	for _, expr := range cfg.DAG.Schedule {
+		if _, err := cron.ParseStandard(expr); err != nil {
 			errs = append(errs, &ValidationError{
 				DAG:     dagName,
-				Message: fmt.Sprintf("invalid schedule %q: %s", cfg.DAG.Schedule, err),
+				Message: fmt.Sprintf("invalid schedule %q: %s", expr, err),
 			})
 		}
 	}
 
+	if cfg.DAG.InfraRetries < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.infra_retries must not be negative"})
+	}
+	if cfg.DAG.InfraRetryDelay.Duration < 0 {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid infra_retry_delay %s (must be >= 0)", cfg.DAG.InfraRetryDelay.Duration),
+		})
+	}
+	if cfg.DAG.ScheduleJitter.Duration < 0 {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid schedule_jitter %s (must be >= 0)", cfg.DAG.ScheduleJitter.Duration),
+		})
+	}
+
+	if f := cfg.DAG.AnomalyFactor; f != 0 && f <= 1 {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid anomaly_factor %g (must be > 1)", f),
+		})
+	}
+
+	if w := cfg.DAG.AnomalyWindow; w != 0 && w < 5 {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid anomaly_window %d (must be at least 5, the runs a task needs before it is checked)", w),
+		})
+	}
+
 	// Validate FTP watch config
 	if cfg.DAG.FTPWatch != nil {
 		errs = append(errs, validateFTPWatch(cfg.DAG.FTPWatch, dagName)...)
@@ -189,11 +431,54 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 		if !config.ValidArtifacts[a] {
 			errs = append(errs, &ValidationError{
 				DAG:     dagName,
-				Message: fmt.Sprintf("invalid keep_artifacts value %q (must be logs, project, or data)", a),
+				Message: fmt.Sprintf("invalid keep_artifacts value %q (must be logs, logs_failed_only, project, data, or scratch)", a),
 			})
 		}
 	}
 
+	for _, f := range cfg.DAG.Report {
+		if !config.ValidReportFormats[f] {
+			errs = append(errs, &ValidationError{
+				DAG:     dagName,
+				Message: fmt.Sprintf("invalid report format %q (must be markdown or html)", f),
+			})
+		}
+	}
+
+	if cfg.DAG.InPlace() {
+		var conflicts []string
+		if cfg.DAG.GitURL != "" {
+			conflicts = append(conflicts, "git_url")
+		}
+		if cfg.DAG.Transform != nil {
+			conflicts = append(conflicts, "[dag.transform]")
+		}
+		if cfg.DAG.ReadOnlySnapshot {
+			conflicts = append(conflicts, "read_only_snapshot")
+		}
+		for _, c := range conflicts {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("snapshot = false cannot be combined with %s, which needs a per-run copy of the project", c)})
+		}
+	}
+
+	seenScopes := make(map[string]bool)
+	for _, scope := range cfg.DAG.SecretScopes {
+		switch {
+		case scope == "":
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "secret_scopes must not contain an empty scope"})
+		case seenScopes[scope]:
+			errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("secret_scopes lists %q more than once", scope)})
+		}
+		seenScopes[scope] = true
+	}
+
+	if c := cfg.DAG.SQL.IdentifierCase; c != "" && !config.ValidIdentifierCases[c] {
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("invalid sql.identifier_case %q (must be preserve, lower, or upper)", c),
+		})
+	}
+
 	// Validate transform config
 	if cfg.DAG.Transform != nil {
 		if cfg.DAG.SQL.Connection == "" {
@@ -220,9 +505,25 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 		}
 	}
 
+	if cfg.DAG.Env != nil {
+		if err := cfg.DAG.Env.Validate(); err != nil {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "dag.env: " + err.Error()})
+		}
+	}
+
+	if cfg.DAG.Python != nil && cfg.DAG.Python.Version == "" {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "python.version is required when [dag.python] is set"})
+	}
+
 	// Validate dbt config
 	if cfg.DAG.DBT != nil {
-		errs = append(errs, validateDBT(cfg.DAG.DBT, dagName, projectDir, cfg.DAG.GitURL != "")...)
+		dbtErrs := validateDBT(cfg.DAG.DBT, dagName, projectDir, cfg.DAG.GitURL != "")
+		errs = append(errs, dbtErrs...)
+		// Selectors are checked against the files of the dbt project, so
+		// only when it is on disk and the [dag.dbt] settings are sound
+		if cfg.DAG.DBT.CheckSelectors && cfg.DAG.GitURL == "" && len(dbtErrs) == 0 {
+			errs = append(errs, validateDBTSelectors(cfg, projectDir)...)
+		}
 	}
 
 	// Cycle detection via Kahn's algorithm
@@ -233,18 +534,52 @@ func Validate(cfg *config.ProjectConfig, projectDir string) []*ValidationError {
 	return errs
 }
 
+// validateWorkdir checks that a task's workdir is a directory inside the
+// project and that the task runs a process that can use it.
+func validateWorkdir(t config.TaskConfig, gitBacked bool, projectDir, dagName string) []*ValidationError {
+	if runsInProcess(t) {
+		return []*ValidationError{{DAG: dagName, Task: t.Name, Message: "workdir only applies to tasks that run a process (python, bash, or $ <command>)"}}
+	}
+	if t.Runner == "dbt" {
+		return []*ValidationError{{DAG: dagName, Task: t.Name, Message: "workdir does not apply to dbt tasks (use dbt.project_dir)"}}
+	}
+	clean := filepath.Clean(filepath.FromSlash(t.Workdir))
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return []*ValidationError{{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("workdir %q must be a relative path inside the project", t.Workdir)}}
+	}
+	if gitBacked {
+		return nil
+	}
+	if info, err := os.Stat(filepath.Join(projectDir, clean)); err != nil || !info.IsDir() {
+		return []*ValidationError{{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("workdir %q is not a directory in the project", t.Workdir)}}
+	}
+	return nil
+}
+
 // validateFTPWatch checks required fields and applies defaults for FTP watch config.
 func validateFTPWatch(fw *config.FTPWatchConfig, dagName string) []*ValidationError {
 	var errs []*ValidationError
 
-	if fw.Host == "" {
-		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.host is required"})
-	}
-	if fw.User == "" {
-		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.user is required"})
+	// With a structured secret, host, user, and password come from it
+	if fw.Secret == "" {
+		if fw.Host == "" {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.host is required"})
+		}
+		if fw.User == "" {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.user is required"})
+		}
+		if fw.PasswordSecret == "" {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.password_secret is required"})
+		}
 	}
-	if fw.PasswordSecret == "" {
-		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.password_secret is required"})
+	switch fw.Protocol {
+	case "", "ftp":
+	case "sftp":
+		if fw.TLS {
+			errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.tls cannot be used with protocol \"sftp\""})
+		}
+	default:
+		errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("invalid ftp_watch.protocol %q (must be ftp or sftp)", fw.Protocol)})
 	}
 	if fw.Directory == "" {
 		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.directory is required"})
@@ -252,14 +587,30 @@ func validateFTPWatch(fw *config.FTPWatchConfig, dagName string) []*ValidationEr
 	if fw.Pattern == "" {
 		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.pattern is required"})
 	}
+	if fw.MaxBackoff.Duration < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("invalid ftp_watch.max_backoff %s (must be >= 0)", fw.MaxBackoff.Duration)})
+	}
+	if fw.BatchWindow.Duration < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("invalid ftp_watch.batch_window %s (must be >= 0)", fw.BatchWindow.Duration)})
+	}
+	if fw.MaxBatchSize < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: "ftp_watch.max_batch_size must not be negative"})
+	}
 
 	// Apply defaults
-	if fw.Port == 0 {
+	// A watch on a secret leaves the port to the secret, then the protocol
+	if fw.Port == 0 && fw.Secret == "" {
 		fw.Port = 21
+		if fw.Protocol == "sftp" {
+			fw.Port = 22
+		}
 	}
 	if fw.PollInterval.Duration == 0 {
 		fw.PollInterval.Duration = 30 * 1e9 // 30s in nanoseconds
 	}
+	if fw.MaxBackoff.Duration == 0 {
+		fw.MaxBackoff.Duration = 5 * time.Minute
+	}
 	if fw.StableSeconds == 0 {
 		fw.StableSeconds = 30
 	}
@@ -267,6 +618,41 @@ func validateFTPWatch(fw *config.FTPWatchConfig, dagName string) []*ValidationEr
 	return errs
 }
 
+// validateOutputAssertions checks the min_rows and max_null_pct
+// assertions of an output, which query its table after the run.
+func validateOutputAssertions(o config.Output, cfg *config.ProjectConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	add := func(msg string) {
+		errs = append(errs, &ValidationError{DAG: dagName, Message: fmt.Sprintf("output %q: %s", o.Name, msg)})
+	}
+
+	if o.OnFailure != "" && o.OnFailure != "fail" && o.OnFailure != "warn" {
+		add(fmt.Sprintf("invalid on_failure %q (must be fail or warn)", o.OnFailure))
+	}
+	if o.MinRows < 0 {
+		add("min_rows must not be negative")
+	}
+	for _, col := range slices.Sorted(maps.Keys(o.MaxNullPct)) {
+		if pct := o.MaxNullPct[col]; pct < 0 || pct > 100 {
+			add(fmt.Sprintf("max_null_pct for %q must be between 0 and 100, got %g", col, pct))
+		}
+	}
+	if !o.HasAssertions() {
+		return errs
+	}
+
+	if o.Type != "table" {
+		add(fmt.Sprintf("min_rows and max_null_pct apply to outputs of type \"table\", not %q", o.Type))
+	}
+	if o.Connection == "" && cfg.DAG.SQL.Connection == "" {
+		add("assertions need a connection (set connection on the output or [dag.sql])")
+	}
+	if table := o.AssertionTable(); table == "" || strings.Count(table, ".") > 1 {
+		add(fmt.Sprintf("assertions need table = \"schema.table\" (location %q is not one)", o.Location))
+	}
+	return errs
+}
+
 // validateDBT checks required fields for dbt config.
 // gitBacked indicates that the project source lives in a remote git repo and
 // is not present on local disk at validation time, so filesystem checks are skipped.
@@ -294,6 +680,14 @@ func validateDBT(dbt *config.DBTConfig, dagName string, projectDir string, gitBa
 			})
 		}
 	}
+	switch dbt.LogLevel {
+	case "", "info", "debug":
+	default:
+		errs = append(errs, &ValidationError{
+			DAG:     dagName,
+			Message: fmt.Sprintf("dbt.log_level %q must be \"info\" or \"debug\"", dbt.LogLevel),
+		})
+	}
 
 	return errs
 }
@@ -306,6 +700,266 @@ func validateWebhook(wh *config.WebhookConfig, dagName string) []*ValidationErro
 	return nil
 }
 
+// validateSensor checks the fields required by each sensor kind.
+func validateSensor(t config.TaskConfig, cfg *config.ProjectConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	add := func(msg string) {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: msg})
+	}
+
+	switch t.Kind {
+	case "file":
+		if t.Path == "" {
+			add("file sensor requires path")
+		} else if _, err := filepath.Match(t.Path, ""); err != nil {
+			add(fmt.Sprintf("invalid sensor path pattern %q: %s", t.Path, err))
+		}
+	case "table":
+		if t.Table == "" && t.Query == "" {
+			add("table sensor requires table or query")
+		}
+		if t.Connection == "" && cfg.DAG.SQL.Connection == "" {
+			add("table sensor requires a connection (set connection on task or [dag.sql])")
+		}
+	case "ftp":
+		if t.Secret == "" {
+			add("ftp sensor requires secret")
+		}
+		if t.Path == "" {
+			add("ftp sensor requires path")
+		}
+	case "":
+		add("sensor task requires kind (file, table, or ftp)")
+	default:
+		add(fmt.Sprintf("invalid sensor kind %q (must be file, table, or ftp)", t.Kind))
+	}
+
+	if t.Script != "" {
+		add("sensor task must not have script")
+	}
+	if t.PollInterval.Duration < 0 {
+		add(fmt.Sprintf("invalid poll_interval %s (must be >= 0)", t.PollInterval.Duration))
+	}
+	return errs
+}
+
+// validateQuality checks a quality task's connection and each of its
+// checks, including those in a local checks file.
+func validateQuality(t config.TaskConfig, cfg *config.ProjectConfig, dagName, projectDir string) []*ValidationError {
+	var errs []*ValidationError
+	add := func(msg string) {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: msg})
+	}
+
+	if t.Connection == "" && cfg.DAG.SQL.Connection == "" {
+		add("quality task requires a connection (set connection on task or [dag.sql])")
+	}
+	if t.Script != "" {
+		add("quality task must not have script")
+	}
+	if len(t.Checks) == 0 && t.ChecksFile == "" {
+		add("quality task requires checks or checks_file")
+	}
+	for i, c := range t.Checks {
+		if err := c.Validate(t.Table); err != nil {
+			add(fmt.Sprintf("checks[%d]: %s", i, err))
+		}
+	}
+
+	// The checks file can only be read for local projects.
+	if t.ChecksFile != "" && cfg.DAG.GitURL == "" {
+		checks, err := config.LoadQualityChecks(filepath.Join(projectDir, t.ChecksFile))
+		if err != nil {
+			add(err.Error())
+			return errs
+		}
+		if len(checks) == 0 {
+			add(fmt.Sprintf("checks_file %q has no [[checks]]", t.ChecksFile))
+		}
+		for i, c := range checks {
+			if err := c.Validate(t.Table); err != nil {
+				add(fmt.Sprintf("checks_file %s: checks[%d]: %s", t.ChecksFile, i, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateHTTP checks an http task's request and output file. References
+// to secrets and params are resolved when the task runs.
+func validateHTTP(t config.TaskConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	add := func(msg string) {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: msg})
+	}
+
+	if t.URL == "" {
+		add("http task requires url")
+	} else if !strings.HasPrefix(t.URL, "${") {
+		if u, err := url.Parse(t.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			add(fmt.Sprintf("invalid url %q (must start with http:// or https://)", t.URL))
+		}
+	}
+	validMethods := map[string]bool{"": true, "GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+	if !validMethods[strings.ToUpper(t.Method)] {
+		add(fmt.Sprintf("invalid method %q (must be GET, POST, PUT, PATCH, or DELETE)", t.Method))
+	}
+	if t.Output == "" {
+		add("http task requires output")
+	} else if !filepath.IsLocal(t.Output) {
+		add(fmt.Sprintf("output %q must be a relative path inside the data directory", t.Output))
+	}
+	if t.Script != "" {
+		add("http task must not have script")
+	}
+	if t.Pagination != nil {
+		if err := t.Pagination.Validate(); err != nil {
+			add("pagination: " + err.Error())
+		}
+	}
+	return errs
+}
+
+// conditionBuiltins are the plain variables available to run_if and skip_if.
+var conditionBuiltins = map[string]bool{
+	"weekday": true,
+	"hour":    true,
+	"day":     true,
+	"date":    true,
+	"trigger": true,
+}
+
+// validateConditions checks that a task's run_if and skip_if parse and only
+// reference known variables. outputs.<task>.<key> must name an upstream task,
+// since only those are guaranteed to have finished first.
+func validateConditions(t config.TaskConfig, cfg *config.ProjectConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	for _, c := range []struct{ field, src string }{{"run_if", t.RunIf}, {"skip_if", t.SkipIf}} {
+		if c.src == "" {
+			continue
+		}
+		expr, err := condition.Parse(c.src)
+		if err != nil {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("invalid %s: %s", c.field, err)})
+			continue
+		}
+		for _, v := range expr.Vars() {
+			if msg := checkConditionVar(v, t.Name, cfg); msg != "" {
+				errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("%s: %s", c.field, msg)})
+			}
+		}
+	}
+	return errs
+}
+
+// checkConditionVar returns a problem with a condition variable, or "".
+func checkConditionVar(name, task string, cfg *config.ProjectConfig) string {
+	if conditionBuiltins[name] {
+		return ""
+	}
+	root, rest, _ := strings.Cut(name, ".")
+	switch root {
+	case "params", "env":
+		if rest == "" {
+			return fmt.Sprintf("%q needs a name, e.g. %s.foo", name, root)
+		}
+		return ""
+	case "outputs":
+		upstream, key, _ := strings.Cut(rest, ".")
+		if upstream == "" || key == "" {
+			return fmt.Sprintf("%q must be outputs.<task>.<key>", name)
+		}
+		if !upstreamOf(cfg, task)[upstream] {
+			return fmt.Sprintf("%q refers to %q, which is not upstream of this task", name, upstream)
+		}
+		return ""
+	}
+	return fmt.Sprintf("unknown variable %q (use params.*, env.*, outputs.*, weekday, hour, day, date, or trigger)", name)
+}
+
+// validateRejects checks a load task's reject_file and max_rejects. The
+// reject file is written inside the run's data directory.
+func validateRejects(t config.TaskConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	if t.RejectFile != "" {
+		if !filepath.IsLocal(t.RejectFile) {
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("reject_file %q must be a relative path inside the data directory", t.RejectFile)})
+		}
+		switch strings.ToLower(filepath.Ext(t.RejectFile)) {
+		case ".csv", ".parquet":
+		default:
+			errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("reject_file %q must end in .csv or .parquet", t.RejectFile)})
+		}
+	}
+	if t.MaxRejects < 0 {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: fmt.Sprintf("invalid max_rejects %d (must be >= 0)", t.MaxRejects)})
+	} else if t.MaxRejects > 0 && t.RejectFile == "" {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: "max_rejects requires reject_file"})
+	}
+	return errs
+}
+
+// validateMapOver checks a mapped task's source. Only script tasks can be
+// mapped, since each instance receives its item through PIT_MAP_ITEM.
+func validateMapOver(t config.TaskConfig, cfg *config.ProjectConfig, dagName string) []*ValidationError {
+	var errs []*ValidationError
+	add := func(msg string) {
+		errs = append(errs, &ValidationError{DAG: dagName, Task: t.Name, Message: msg})
+	}
+	if t.Type != "" {
+		add(fmt.Sprintf("map_over is not supported on %s tasks", t.Type))
+	}
+	if t.MapOver == "trigger.files" {
+		return errs
+	}
+	rest, ok := strings.CutPrefix(t.MapOver, "outputs.")
+	if !ok {
+		add(fmt.Sprintf("invalid map_over %q (must be trigger.files or outputs.<task>.<key>)", t.MapOver))
+		return errs
+	}
+	if msg := checkConditionVar("outputs."+rest, t.Name, cfg); msg != "" {
+		add("map_over: " + msg)
+	}
+	return errs
+}
+
+// upstreamOf returns every task that task transitively depends on.
+func upstreamOf(cfg *config.ProjectConfig, task string) map[string]bool {
+	deps := make(map[string][]string, len(cfg.Tasks))
+	for _, t := range cfg.Tasks {
+		deps[t.Name] = t.DependsOn
+	}
+	seen := make(map[string]bool)
+	stack := append([]string(nil), deps[task]...)
+	for len(stack) > 0 {
+		name := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		stack = append(stack, deps[name]...)
+	}
+	return seen
+}
+
+// runsInProcess reports whether a task executes inside the pit process
+// (load/save tasks and SQL scripts) rather than as a child process.
+func runsInProcess(t config.TaskConfig) bool {
+	if t.Type != "" || t.Runner == "sql" {
+		return true
+	}
+	return t.Runner == "" && filepath.Ext(t.Script) == ".sql"
+}
+
+// isSQLScript reports whether t runs a .sql script with the SQL runner.
+func isSQLScript(t config.TaskConfig) bool {
+	if t.Type != "" {
+		return false
+	}
+	return t.Runner == "sql" || (t.Runner == "" && filepath.Ext(t.Script) == ".sql")
+}
+
 // detectCycles uses Kahn's algorithm for topological sort.
 // Returns errors if a cycle is found.
 func detectCycles(cfg *config.ProjectConfig, dagName string) []*ValidationError {
@@ -365,22 +1019,30 @@ func detectCycles(cfg *config.ProjectConfig, dagName string) []*ValidationError
 	return nil
 }
 
-// ValidateAll discovers all projects under rootDir and validates each one.
-func ValidateAll(rootDir string) ([]*ValidationError, error) {
-	configs, err := config.Discover(rootDir)
-	if err != nil {
-		return nil, err
+// Warnings returns the problems in cfg that do not stop it from running.
+// Validate does not include them.
+func Warnings(cfg *config.ProjectConfig) []*ValidationError {
+	var warns []*ValidationError
+	dagName := cfg.DAG.Name
+	warn := func(msg string) {
+		warns = append(warns, &ValidationError{DAG: dagName, Message: msg, Severity: SeverityWarning})
 	}
 
-	if len(configs) == 0 {
-		return nil, fmt.Errorf("no projects found in %s/projects/", rootDir)
+	// Transform projects get their tasks from models
+	if len(cfg.Tasks) == 0 && cfg.DAG.Transform == nil {
+		warn("dag has no tasks")
 	}
-
-	var allErrs []*ValidationError
-	for _, cfg := range configs {
-		errs := Validate(cfg, cfg.Dir())
-		allErrs = append(allErrs, errs...)
+	if len(cfg.DAG.Schedule) > 0 && cfg.DAG.Overlap == "allow" && cfg.DAG.MaxActiveRuns == 0 {
+		warn(`overlap = "allow" on a schedule without max_active_runs lets slow runs pile up`)
 	}
-
-	return allErrs, nil
+	for _, t := range cfg.Tasks {
+		if t.Runner != "dbt" {
+			continue
+		}
+		_, cmdWarns := checkDBTCommand(t.Script)
+		for _, msg := range cmdWarns {
+			warns = append(warns, &ValidationError{DAG: dagName, Task: t.Name, Message: msg, Severity: SeverityWarning})
+		}
+	}
+	return warns
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/druarnfield/pit/internal/config"
 )
@@ -120,6 +121,454 @@ func TestValidate_InvalidOverlap(t *testing.T) {
 	}
 }
 
+func TestValidate_MaxActiveRuns(t *testing.T) {
+	tests := []struct {
+		name    string
+		dag     config.DAGConfig
+		wantErr string
+	}{
+		{"unlimited", config.DAGConfig{}, ""},
+		{"skip overflow", config.DAGConfig{MaxActiveRuns: 3}, ""},
+		{"queue overflow", config.DAGConfig{MaxActiveRuns: 3, MaxActiveOverflow: "queue", Overlap: "allow"}, ""},
+		{"negative", config.DAGConfig{MaxActiveRuns: -1}, "must not be negative"},
+		{"with overlap skip", config.DAGConfig{MaxActiveRuns: 2, Overlap: "skip"}, "requires overlap"},
+		{"bad overflow", config.DAGConfig{MaxActiveRuns: 2, MaxActiveOverflow: "drop"}, "invalid dag.max_active_overflow"},
+		{"overflow without max", config.DAGConfig{MaxActiveOverflow: "queue"}, "requires dag.max_active_runs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dag.Name = "test"
+			errs := Validate(&config.ProjectConfig{DAG: tt.dag}, t.TempDir())
+			var msgs []string
+			for _, e := range errs {
+				msgs = append(msgs, e.Error())
+			}
+			got := strings.Join(msgs, "; ")
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("Validate() errors = %s, want none", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Validate() errors = %q, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_MaxRunsPerHour(t *testing.T) {
+	tests := []struct {
+		name    string
+		dag     config.DAGConfig
+		wantErr string
+	}{
+		{"unlimited", config.DAGConfig{}, ""},
+		{"skip overflow", config.DAGConfig{MaxRunsPerHour: 10}, ""},
+		{"coalesce overflow", config.DAGConfig{MaxRunsPerHour: 10, MaxRunsOverflow: "coalesce"}, ""},
+		{"negative", config.DAGConfig{MaxRunsPerHour: -1}, "dag.max_runs_per_hour must not be negative"},
+		{"bad overflow", config.DAGConfig{MaxRunsPerHour: 10, MaxRunsOverflow: "queue"}, "invalid dag.max_runs_overflow"},
+		{"overflow without max", config.DAGConfig{MaxRunsOverflow: "coalesce"}, "requires dag.max_runs_per_hour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dag.Name = "test"
+			errs := Validate(&config.ProjectConfig{DAG: tt.dag}, t.TempDir())
+			var msgs []string
+			for _, e := range errs {
+				msgs = append(msgs, e.Error())
+			}
+			got := strings.Join(msgs, "; ")
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("Validate() errors = %s, want none", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Validate() errors = %q, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TriggerRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{"default", config.TaskConfig{}, ""},
+		{"all_done", config.TaskConfig{DependsOn: []string{"load"}, TriggerRule: "all_done"}, ""},
+		{"one_failed", config.TaskConfig{DependsOn: []string{"load"}, TriggerRule: "one_failed"}, ""},
+		{"one_failed without deps", config.TaskConfig{TriggerRule: "one_failed"}, "requires depends_on"},
+		{"unknown", config.TaskConfig{DependsOn: []string{"load"}, TriggerRule: "all_failed"}, "invalid trigger_rule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+			tt.task.Name = "notify"
+			tt.task.Script = "run.sh"
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test"},
+				Tasks: []config.TaskConfig{{Name: "load", Script: "run.sh"}, tt.task},
+			}
+			var msgs []string
+			for _, e := range Validate(cfg, dir) {
+				msgs = append(msgs, e.Error())
+			}
+			got := strings.Join(msgs, "; ")
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("Validate() errors = %s, want none", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Validate() errors = %q, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_OutputAssertions(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  config.Output
+		wantErr string
+	}{
+		{"no assertions", config.Output{Name: "report", Type: "file", Location: "out/report.csv"}, ""},
+		{"valid", config.Output{Name: "claims", Type: "table", Location: "staging.claims", Connection: "wh", MinRows: 100, MaxNullPct: map[string]float64{"claim_id": 0}}, ""},
+		{"file output", config.Output{Name: "report", Type: "file", Location: "report.csv", Connection: "wh", MinRows: 1}, "apply to outputs of type \"table\""},
+		{"no connection", config.Output{Name: "claims", Type: "table", Location: "staging.claims", MinRows: 1}, "need a connection"},
+		{"three-part location", config.Output{Name: "claims", Type: "table", Location: "warehouse.staging.claims", Connection: "wh", MinRows: 1}, "need table = \"schema.table\""},
+		{"table overrides location", config.Output{Name: "claims", Type: "table", Location: "warehouse.staging.claims", Table: "staging.claims", Connection: "wh", MinRows: 1}, ""},
+		{"bad percentage", config.Output{Name: "claims", Type: "table", Location: "claims", Connection: "wh", MaxNullPct: map[string]float64{"id": 150}}, "between 0 and 100"},
+		{"bad on_failure", config.Output{Name: "claims", Type: "table", Location: "claims", Connection: "wh", MinRows: 1, OnFailure: "ignore"}, "invalid on_failure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Outputs: []config.Output{tt.output}}
+			var msgs []string
+			for _, e := range Validate(cfg, t.TempDir()) {
+				msgs = append(msgs, e.Error())
+			}
+			got := strings.Join(msgs, "; ")
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("Validate() errors = %s, want none", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Validate() errors = %q, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Workdir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tasks"), 0o755)
+	os.MkdirAll(filepath.Join(dir, "pipelines", "claims"), 0o755)
+	os.WriteFile(filepath.Join(dir, "tasks", "run.sh"), []byte("true\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, "tasks", "load.sql"), []byte("SELECT 1\n"), 0o644)
+
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{"subdirectory", config.TaskConfig{Script: "tasks/run.sh", Workdir: "pipelines/claims"}, ""},
+		{"missing", config.TaskConfig{Script: "tasks/run.sh", Workdir: "pipelines/other"}, "is not a directory in the project"},
+		{"escapes", config.TaskConfig{Script: "tasks/run.sh", Workdir: "../shared"}, "must be a relative path inside the project"},
+		{"absolute", config.TaskConfig{Script: "tasks/run.sh", Workdir: "/srv/share"}, "must be a relative path inside the project"},
+		{"in-process", config.TaskConfig{Script: "tasks/load.sql", Workdir: "pipelines/claims"}, "only applies to tasks that run a process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.Name = "task"
+			errs := Validate(&config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}, dir)
+			var msgs []string
+			for _, e := range errs {
+				msgs = append(msgs, e.Error())
+			}
+			got := strings.Join(msgs, "; ")
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("Validate() errors = %s, want none", got)
+			}
+			if tt.wantErr != "" && !strings.Contains(got, tt.wantErr) {
+				t.Errorf("Validate() errors = %q, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_DataDir(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "share")
+	if errs := Validate(&config.ProjectConfig{DAG: config.DAGConfig{Name: "test", DataDir: abs}}, t.TempDir()); len(errs) != 0 {
+		t.Errorf("Validate() with data_dir %q = %v, want no errors", abs, errs)
+	}
+	errs := Validate(&config.ProjectConfig{DAG: config.DAGConfig{Name: "test", DataDir: "share/data"}}, t.TempDir())
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "must be an absolute path") {
+		t.Errorf("Validate() with a relative data_dir = %v, want an absolute path error", errs)
+	}
+}
+
+func TestValidate_AnomalyFactor(t *testing.T) {
+	tests := []struct {
+		factor  float64
+		wantErr bool
+	}{
+		{0, false},
+		{2.5, false},
+		{1, true},
+		{-3, true},
+	}
+	for _, tt := range tests {
+		cfg := &config.ProjectConfig{
+			DAG: config.DAGConfig{Name: "test", AnomalyFactor: tt.factor},
+		}
+		found := false
+		for _, e := range Validate(cfg, t.TempDir()) {
+			if strings.Contains(e.Error(), "anomaly_factor") {
+				found = true
+			}
+		}
+		if found != tt.wantErr {
+			t.Errorf("anomaly_factor %g: error reported = %v, want %v", tt.factor, found, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_AnomalyWindow(t *testing.T) {
+	for _, tt := range []struct {
+		window  int
+		wantErr bool
+	}{{0, false}, {5, false}, {100, false}, {4, true}, {-1, true}} {
+		cfg := &config.ProjectConfig{
+			DAG: config.DAGConfig{Name: "test", AnomalyWindow: tt.window},
+		}
+		found := false
+		for _, e := range Validate(cfg, t.TempDir()) {
+			if strings.Contains(e.Error(), "anomaly_window") {
+				found = true
+			}
+		}
+		if found != tt.wantErr {
+			t.Errorf("anomaly_window %d: error reported = %v, want %v", tt.window, found, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_ResourceLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{"python with limits", config.TaskConfig{Name: "a", Script: "a.py", MemoryLimit: 512 << 20, CPULimit: 1.5}, ""},
+		{"negative cpu", config.TaskConfig{Name: "a", Script: "a.py", CPULimit: -1}, "cpu_limit"},
+		{"sql script", config.TaskConfig{Name: "a", Script: "a.sql", MemoryLimit: 1 << 30}, "only apply to tasks that run a process"},
+		{"load task", config.TaskConfig{Name: "a", Type: "load", Source: "a.parquet", Table: "t", CPULimit: 1}, "only apply to tasks that run a process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.task.Script != "" {
+				os.WriteFile(filepath.Join(dir, tt.task.Script), nil, 0o644)
+			}
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}
+			var got []string
+			for _, e := range Validate(cfg, dir) {
+				if strings.Contains(e.Error(), "limit") {
+					got = append(got, e.Error())
+				}
+			}
+			if tt.wantErr == "" && len(got) > 0 {
+				t.Errorf("unexpected errors: %v", got)
+			}
+			if tt.wantErr != "" && (len(got) == 0 || !strings.Contains(got[0], tt.wantErr)) {
+				t.Errorf("errors = %v, want one containing %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Offline(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr bool
+	}{
+		{"python", config.TaskConfig{Name: "a", Script: "a.py", Offline: &on}, false},
+		{"sql script", config.TaskConfig{Name: "a", Script: "a.sql", Offline: &on}, true},
+		{"http task", config.TaskConfig{Name: "a", Type: "http", URL: "https://example.com", Offline: &on}, true},
+		{"sql script opting out", config.TaskConfig{Name: "a", Script: "a.sql", Offline: &off}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.task.Script != "" {
+				os.WriteFile(filepath.Join(dir, tt.task.Script), nil, 0o644)
+			}
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}
+			found := false
+			for _, e := range Validate(cfg, dir) {
+				if strings.Contains(e.Error(), "offline only applies") {
+					found = true
+				}
+			}
+			if found != tt.wantErr {
+				t.Errorf("offline error reported = %v, want %v", found, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Sensor(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		sqlConn string
+		wantErr string
+	}{
+		{"file", config.TaskConfig{Name: "s", Type: "sensor", Kind: "file", Path: "landing/*.csv"}, "", ""},
+		{"table with dag connection", config.TaskConfig{Name: "s", Type: "sensor", Kind: "table", Table: "dbo.claims"}, "wh", ""},
+		{"ftp", config.TaskConfig{Name: "s", Type: "sensor", Kind: "ftp", Secret: "ftp", Path: "/out/*.csv"}, "", ""},
+		{"missing kind", config.TaskConfig{Name: "s", Type: "sensor"}, "", "requires kind"},
+		{"bad kind", config.TaskConfig{Name: "s", Type: "sensor", Kind: "s3"}, "", "invalid sensor kind"},
+		{"file without path", config.TaskConfig{Name: "s", Type: "sensor", Kind: "file"}, "", "requires path"},
+		{"bad glob", config.TaskConfig{Name: "s", Type: "sensor", Kind: "file", Path: "[a"}, "", "invalid sensor path pattern"},
+		{"table without connection", config.TaskConfig{Name: "s", Type: "sensor", Kind: "table", Table: "t"}, "", "requires a connection"},
+		{"table without table", config.TaskConfig{Name: "s", Type: "sensor", Kind: "table"}, "wh", "requires table or query"},
+		{"ftp without secret", config.TaskConfig{Name: "s", Type: "sensor", Kind: "ftp", Path: "/x"}, "", "requires secret"},
+		{"with script", config.TaskConfig{Name: "s", Type: "sensor", Kind: "file", Path: "x", Script: "x.sh"}, "", "must not have script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: tt.sqlConn}},
+				Tasks: []config.TaskConfig{tt.task},
+			}
+			errs := Validate(cfg, t.TempDir())
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					if strings.Contains(e.Error(), "sensor") {
+						t.Errorf("unexpected error: %v", e)
+					}
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("errors = %v, want one containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Quality(t *testing.T) {
+	ptr := func(f float64) *float64 { return &f }
+	rowCount := config.QualityCheck{Check: "row_count", Min: ptr(1)}
+
+	tests := []struct {
+		name       string
+		task       config.TaskConfig
+		sqlConn    string
+		checksFile string // contents of checks.toml in the project dir
+		wantErr    string
+	}{
+		{"inline checks", config.TaskConfig{Name: "q", Type: "quality", Table: "dbo.claims", Checks: []config.QualityCheck{rowCount}}, "wh", "", ""},
+		{"checks file", config.TaskConfig{Name: "q", Type: "quality", Table: "dbo.claims", ChecksFile: "checks.toml"}, "wh",
+			"[[checks]]\ncheck = \"unique\"\ncolumn = \"claim_id\"\n", ""},
+		{"without connection", config.TaskConfig{Name: "q", Type: "quality", Table: "t", Checks: []config.QualityCheck{rowCount}}, "", "", "requires a connection"},
+		{"without checks", config.TaskConfig{Name: "q", Type: "quality", Table: "t"}, "wh", "", "requires checks or checks_file"},
+		{"with script", config.TaskConfig{Name: "q", Type: "quality", Table: "t", Script: "x.sql", Checks: []config.QualityCheck{rowCount}}, "wh", "", "must not have script"},
+		{"no table", config.TaskConfig{Name: "q", Type: "quality", Checks: []config.QualityCheck{rowCount}}, "wh", "", "no table"},
+		{"bad check", config.TaskConfig{Name: "q", Type: "quality", Table: "t", Checks: []config.QualityCheck{{Check: "freshness"}}}, "wh", "", "invalid check"},
+		{"null ratio out of range", config.TaskConfig{Name: "q", Type: "quality", Table: "t", Checks: []config.QualityCheck{{Check: "null_ratio", Column: "c", Max: ptr(5)}}}, "wh", "", "between 0 and 1"},
+		{"bad severity", config.TaskConfig{Name: "q", Type: "quality", Table: "t", Checks: []config.QualityCheck{{Check: "row_count", Min: ptr(1), Severity: "info"}}}, "wh", "", "invalid severity"},
+		{"missing checks file", config.TaskConfig{Name: "q", Type: "quality", Table: "t", ChecksFile: "missing.toml"}, "wh", "", "reading checks file"},
+		{"bad check in file", config.TaskConfig{Name: "q", Type: "quality", Table: "t", ChecksFile: "checks.toml"}, "wh",
+			"[[checks]]\ncheck = \"unique\"\n", "checks_file checks.toml: checks[0]: unique check requires column"},
+		{"checks on exec task", config.TaskConfig{Name: "q", Checks: []config.QualityCheck{rowCount}}, "wh", "", "only valid on type = \"quality\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.checksFile != "" {
+				if err := os.WriteFile(filepath.Join(dir, "checks.toml"), []byte(tt.checksFile), 0o644); err != nil {
+					t.Fatalf("writing checks file: %v", err)
+				}
+			}
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: tt.sqlConn}},
+				Tasks: []config.TaskConfig{tt.task},
+			}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("errors = %v, want one containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_HTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{"get", config.TaskConfig{Name: "h", Type: "http", URL: "https://api.example.com/claims?since=${param:since}", Output: "raw/claims.json"}, ""},
+		{"paginated", config.TaskConfig{Name: "h", Type: "http", URL: "https://api.example.com/claims", Output: "claims.json",
+			Pagination: &config.HTTPPagination{Style: "cursor", CursorPath: "meta.next", ItemsPath: "data"}}, ""},
+		{"url from secret", config.TaskConfig{Name: "h", Type: "http", URL: "${secret:claims_api.url}", Output: "claims.json"}, ""},
+		{"without url", config.TaskConfig{Name: "h", Type: "http", Output: "claims.json"}, "requires url"},
+		{"ftp url", config.TaskConfig{Name: "h", Type: "http", URL: "ftp://example.com/x", Output: "x"}, "must start with http:// or https://"},
+		{"bad method", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com", Method: "FETCH", Output: "x"}, "invalid method"},
+		{"without output", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com"}, "requires output"},
+		{"output outside data dir", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com", Output: "../x.json"}, "inside the data directory"},
+		{"with script", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com", Output: "x", Script: "x.py"}, "must not have script"},
+		{"cursor without path", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com", Output: "x",
+			Pagination: &config.HTTPPagination{Style: "cursor"}}, "pagination: cursor pagination requires cursor_path"},
+		{"bad style", config.TaskConfig{Name: "h", Type: "http", URL: "https://example.com", Output: "x",
+			Pagination: &config.HTTPPagination{Style: "offset"}}, "invalid style"},
+		{"url on exec task", config.TaskConfig{Name: "h", Script: "x.sh", URL: "https://example.com"}, "only valid on type = \"http\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test"},
+				Tasks: []config.TaskConfig{tt.task},
+			}
+			errs := Validate(cfg, t.TempDir())
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("errors = %v, want one containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidate_MissingScript(t *testing.T) {
 	cfg := &config.ProjectConfig{
 		DAG: config.DAGConfig{Name: "test"},
@@ -169,7 +618,7 @@ func TestValidate_ValidCronSchedule(t *testing.T) {
 	cfg := &config.ProjectConfig{
 		DAG: config.DAGConfig{
 			Name:     "test",
-			Schedule: "0 6 * * *",
+			Schedule: config.Schedules{"0 6 * * *"},
 		},
 		Tasks: []config.TaskConfig{
 			{Name: "a", Script: ""},
@@ -187,7 +636,7 @@ func TestValidate_InvalidCronSchedule(t *testing.T) {
 	cfg := &config.ProjectConfig{
 		DAG: config.DAGConfig{
 			Name:     "test",
-			Schedule: "not a cron expression",
+			Schedule: config.Schedules{"not a cron expression"},
 		},
 	}
 	errs := Validate(cfg, t.TempDir())
@@ -203,6 +652,51 @@ func TestValidate_InvalidCronSchedule(t *testing.T) {
 	}
 }
 
+func TestValidate_Timezone(t *testing.T) {
+	tests := []struct {
+		tz      string
+		wantErr bool
+	}{
+		{"", false},
+		{"Australia/Sydney", false},
+		{"UTC", false},
+		{"Sydney", true},
+	}
+	for _, tt := range tests {
+		cfg := &config.ProjectConfig{
+			DAG: config.DAGConfig{Name: "test", Timezone: tt.tz, Schedule: config.Schedules{"0 6 * * *"}},
+		}
+		found := false
+		for _, e := range Validate(cfg, t.TempDir()) {
+			if strings.Contains(e.Error(), "invalid timezone") {
+				found = true
+			}
+		}
+		if found != tt.wantErr {
+			t.Errorf("Validate(timezone %q) invalid timezone error = %v, want %v", tt.tz, found, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_MultipleSchedulesOneInvalid(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name:     "test",
+			Schedule: config.Schedules{"0 * * * 1-5", "every saturday"},
+		},
+	}
+	errs := Validate(cfg, t.TempDir())
+	var scheduleErrs []string
+	for _, e := range errs {
+		if strings.Contains(e.Error(), "invalid schedule") {
+			scheduleErrs = append(scheduleErrs, e.Error())
+		}
+	}
+	if len(scheduleErrs) != 1 || !strings.Contains(scheduleErrs[0], "every saturday") {
+		t.Errorf("Validate() schedule errors = %v, want one for %q", scheduleErrs, "every saturday")
+	}
+}
+
 func TestValidate_FTPWatch_MissingFields(t *testing.T) {
 	cfg := &config.ProjectConfig{
 		DAG: config.DAGConfig{
@@ -260,6 +754,9 @@ func TestValidate_FTPWatch_Defaults(t *testing.T) {
 	if fw.PollInterval.Duration == 0 {
 		t.Error("FTPWatch.PollInterval should be defaulted, got 0")
 	}
+	if fw.MaxBackoff.Duration != 5*time.Minute {
+		t.Errorf("FTPWatch.MaxBackoff = %s, want 5m (default)", fw.MaxBackoff.Duration)
+	}
 }
 
 func TestValidate_FTPWatch_ValidComplete(t *testing.T) {
@@ -326,6 +823,98 @@ func TestValidate_KeepArtifacts_Invalid(t *testing.T) {
 	}
 }
 
+func TestValidate_InfraRetries(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name:            "test",
+			InfraRetries:    -1,
+			InfraRetryDelay: config.Duration{Duration: -time.Second},
+		},
+		Tasks: []config.TaskConfig{
+			{Name: "a"},
+		},
+	}
+	var got []string
+	for _, e := range Validate(cfg, t.TempDir()) {
+		if strings.Contains(e.Error(), "infra_retr") {
+			got = append(got, e.Error())
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("infra retry errors = %v, want infra_retries and infra_retry_delay", got)
+	}
+}
+
+func TestValidate_Report(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name:   "test",
+			Report: []string{"markdown", "html", "pdf"},
+		},
+		Tasks: []config.TaskConfig{
+			{Name: "a"},
+		},
+	}
+	var reportErrs []string
+	for _, e := range Validate(cfg, t.TempDir()) {
+		if strings.Contains(e.Error(), "report format") {
+			reportErrs = append(reportErrs, e.Error())
+		}
+	}
+	if len(reportErrs) != 1 || !strings.Contains(reportErrs[0], `"pdf"`) {
+		t.Errorf("report format errors = %v, want one for \"pdf\"", reportErrs)
+	}
+}
+
+func TestValidate_InPlace(t *testing.T) {
+	off := false
+	tests := []struct {
+		name     string
+		dag      config.DAGConfig
+		wantErrs int
+	}{
+		{"plain", config.DAGConfig{Name: "test", Snapshot: &off}, 0},
+		{"git", config.DAGConfig{Name: "test", Snapshot: &off, GitURL: "https://example.com/repo.git"}, 1},
+		{"read-only and transform", config.DAGConfig{Name: "test", Snapshot: &off, ReadOnlySnapshot: true, Transform: &config.TransformConfig{Dialect: "mssql"}}, 2},
+		{"snapshotted read-only", config.DAGConfig{Name: "test", ReadOnlySnapshot: true}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{DAG: tt.dag, Tasks: []config.TaskConfig{{Name: "a"}}}
+			var got []string
+			for _, e := range Validate(cfg, t.TempDir()) {
+				if strings.Contains(e.Error(), "snapshot = false") {
+					got = append(got, e.Error())
+				}
+			}
+			if len(got) != tt.wantErrs {
+				t.Errorf("snapshot = false errors = %v, want %d", got, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidate_SecretScopes(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{
+			Name:         "test",
+			SecretScopes: []string{"claims_team", "", "global", "claims_team"},
+		},
+		Tasks: []config.TaskConfig{
+			{Name: "a"},
+		},
+	}
+	var scopeErrs []string
+	for _, e := range Validate(cfg, t.TempDir()) {
+		if strings.Contains(e.Error(), "secret_scopes") {
+			scopeErrs = append(scopeErrs, e.Error())
+		}
+	}
+	if len(scopeErrs) != 2 || !strings.Contains(scopeErrs[0], "empty scope") || !strings.Contains(scopeErrs[1], `"claims_team" more than once`) {
+		t.Errorf("secret_scopes errors = %v, want an empty scope and a duplicate", scopeErrs)
+	}
+}
+
 func TestValidate_ValidDBT(t *testing.T) {
 	cfg := loadTestdata(t, "valid_dbt")
 	errs := Validate(cfg, cfg.Dir())
@@ -391,6 +980,40 @@ func TestValidate_DBT_ProjectDirNotExists(t *testing.T) {
 	}
 }
 
+func TestValidate_DBT_LogLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level   string
+		wantErr bool
+	}{
+		{"", false},
+		{"info", false},
+		{"debug", false},
+		{"trace", true},
+	} {
+		cfg := &config.ProjectConfig{
+			DAG: config.DAGConfig{
+				Name: "test",
+				DBT: &config.DBTConfig{
+					Version:  "1.9.1",
+					Adapter:  "dbt-sqlserver",
+					LogLevel: tc.level,
+				},
+			},
+		}
+		errs := Validate(cfg, t.TempDir())
+
+		found := false
+		for _, e := range errs {
+			if strings.Contains(e.Error(), "dbt.log_level") {
+				found = true
+			}
+		}
+		if found != tc.wantErr {
+			t.Errorf("log_level %q: got log_level error = %v, want %v (errs: %v)", tc.level, found, tc.wantErr, errs)
+		}
+	}
+}
+
 func TestValidate_DBT_TaskEmptyScript(t *testing.T) {
 	tmpDir := t.TempDir()
 	// Create the dbt project dir
@@ -777,6 +1400,18 @@ func TestValidate_LoadTask_InvalidMode(t *testing.T) {
 	}
 }
 
+func TestValidate_LoadTask_AppendOrCreate(t *testing.T) {
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: "db"}},
+		Tasks: []config.TaskConfig{
+			{Name: "load_data", Type: "load", Source: "data/output.parquet", Table: "staging.raw_data", Mode: "append_or_create"},
+		},
+	}
+	if errs := Validate(cfg, t.TempDir()); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+}
+
 func TestValidate_ModeOnNonLoadTask(t *testing.T) {
 	cfg := &config.ProjectConfig{
 		DAG: config.DAGConfig{Name: "test"},
@@ -880,3 +1515,449 @@ func loadTestdata(t *testing.T, name string) *config.ProjectConfig {
 	}
 	return cfg
 }
+
+func TestValidate_Conditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		runIf   string
+		skipIf  string
+		wantErr string
+	}{
+		{name: "params", runIf: "params.full_refresh == 'true'"},
+		{name: "builtins", skipIf: "weekday in ['sat', 'sun'] or hour < 6 or trigger == 'webhook'"},
+		{name: "env", runIf: "env.PIT_ENV != 'dev'"},
+		{name: "upstream output", runIf: "outputs.extract.rows > 0"},
+		{name: "parse error", runIf: "params.x ==", wantErr: "invalid run_if"},
+		{name: "unknown variable", skipIf: "today == 'mon'", wantErr: `unknown variable "today"`},
+		{name: "bare params", runIf: "params", wantErr: "needs a name"},
+		{name: "output missing key", runIf: "outputs.extract", wantErr: "must be outputs.<task>.<key>"},
+		{name: "output not upstream", runIf: "outputs.other.rows > 0", wantErr: "not upstream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{
+				DAG: config.DAGConfig{Name: "test"},
+				Tasks: []config.TaskConfig{
+					{Name: "extract", Type: "sensor", Kind: "file", Path: "x"},
+					{Name: "other", Type: "sensor", Kind: "file", Path: "y"},
+					{Name: "load", Type: "sensor", Kind: "file", Path: "z", DependsOn: []string{"extract"}, RunIf: tt.runIf, SkipIf: tt.skipIf},
+				},
+			}
+			errs := Validate(cfg, t.TempDir())
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Validate() errors = %v, want one containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_MapOver(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{name: "trigger files", task: config.TaskConfig{Name: "load", Script: "load.sh", MapOver: "trigger.files"}},
+		{name: "upstream output", task: config.TaskConfig{Name: "load", Script: "load.sh", MapOver: "outputs.list.files", DependsOn: []string{"list"}}},
+		{name: "not upstream", task: config.TaskConfig{Name: "load", Script: "load.sh", MapOver: "outputs.list.files"}, wantErr: "not upstream"},
+		{name: "bad source", task: config.TaskConfig{Name: "load", Script: "load.sh", MapOver: "params.files"}, wantErr: "invalid map_over"},
+		{name: "built-in type", task: config.TaskConfig{Name: "load", Type: "sensor", Kind: "file", Path: "x", MapOver: "trigger.files"}, wantErr: "not supported on sensor tasks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "load.sh"), []byte("echo"), 0o755)
+			os.WriteFile(filepath.Join(dir, "list.sh"), []byte("echo"), 0o755)
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test"},
+				Tasks: []config.TaskConfig{{Name: "list", Script: "list.sh"}, tt.task},
+			}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			found := false
+			for _, e := range errs {
+				if strings.Contains(e.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Validate() errors = %v, want one containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_DBTTaskOverrides(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{Name: "test"},
+		Tasks: []config.TaskConfig{
+			{Name: "staging", Runner: "dbt", Script: "run --select staging", DBTTarget: "staging", DBTConnection: "staging_db"},
+			{Name: "shell", Script: "run.sh", DBTTarget: "staging"},
+		},
+	}
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || errs[0].Task != "shell" || !strings.Contains(errs[0].Message, "only apply to dbt tasks") {
+		t.Errorf("Validate() = %v, want one error for the non-dbt task", errs)
+	}
+}
+
+func TestValidate_PythonVersion(t *testing.T) {
+	cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test", Python: &config.PythonConfig{}}}
+	errs := Validate(cfg, t.TempDir())
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "python.version is required") {
+		t.Errorf("Validate() = %v, want python.version error", errs)
+	}
+
+	cfg.DAG.Python.Version = "3.12"
+	if errs := Validate(cfg, t.TempDir()); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+}
+
+func TestValidate_StallTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{name: "warn", task: config.TaskConfig{Name: "t", Script: "run.sh", StallTimeout: config.Duration{Duration: 10 * time.Minute}}},
+		{name: "kill", task: config.TaskConfig{Name: "t", Script: "run.sh", StallTimeout: config.Duration{Duration: time.Minute}, StallAction: "kill"}},
+		{name: "bad action", task: config.TaskConfig{Name: "t", Script: "run.sh", StallAction: "restart"}, wantErr: "invalid stall_action"},
+		{name: "negative", task: config.TaskConfig{Name: "t", Script: "run.sh", StallTimeout: config.Duration{Duration: -time.Second}}, wantErr: "invalid stall_timeout"},
+		{name: "in-process", task: config.TaskConfig{Name: "t", Script: "run.sql", StallTimeout: config.Duration{Duration: time.Minute}}, wantErr: "only applies to tasks that run a process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+			os.WriteFile(filepath.Join(dir, "run.sql"), []byte("SELECT 1"), 0o644)
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.wantErr) {
+				t.Errorf("Validate() = %v, want one error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_StatementTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{name: "sql script", task: config.TaskConfig{Name: "t", Script: "run.sql", StmtTimeout: config.Duration{Duration: time.Hour}}},
+		{name: "negative", task: config.TaskConfig{Name: "t", Script: "run.sql", StmtTimeout: config.Duration{Duration: -time.Second}}, wantErr: "invalid statement_timeout"},
+		{name: "shell script", task: config.TaskConfig{Name: "t", Script: "run.sh", StmtTimeout: config.Duration{Duration: time.Minute}}, wantErr: "only applies to .sql script tasks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+			os.WriteFile(filepath.Join(dir, "run.sql"), []byte("SELECT 1"), 0o644)
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.wantErr) {
+				t.Errorf("Validate() = %v, want one error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TaskEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{name: "clean", task: config.TaskConfig{Name: "t", Script: "run.sh", Env: &config.EnvConfig{Inherit: "none", Allow: []string{"PATH"}, Set: map[string]string{"MODE": "prod"}}}},
+		{name: "deny", task: config.TaskConfig{Name: "t", Script: "run.sh", Env: &config.EnvConfig{Deny: []string{"AWS_*"}}}},
+		{name: "bad inherit", task: config.TaskConfig{Name: "t", Script: "run.sh", Env: &config.EnvConfig{Inherit: "some"}}, wantErr: "invalid inherit"},
+		{name: "bad pattern", task: config.TaskConfig{Name: "t", Script: "run.sh", Env: &config.EnvConfig{Deny: []string{"AWS_[*"}}}, wantErr: "invalid deny pattern"},
+		{name: "pit variable", task: config.TaskConfig{Name: "t", Script: "run.sh", Env: &config.EnvConfig{Set: map[string]string{"PIT_RUN_ID": "x"}}}, wantErr: "PIT_* variables"},
+		{name: "in-process", task: config.TaskConfig{Name: "t", Script: "run.sql", Env: &config.EnvConfig{Deny: []string{"AWS_*"}}}, wantErr: "only applies to tasks that run a process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+			os.WriteFile(filepath.Join(dir, "run.sql"), []byte("SELECT 1"), 0o644)
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}, Tasks: []config.TaskConfig{tt.task}}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				for _, e := range errs {
+					t.Errorf("unexpected error: %v", e)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.wantErr) {
+				t.Errorf("Validate() = %v, want one error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_DAGEnv(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo"), 0o755)
+	cfg := &config.ProjectConfig{
+		DAG:   config.DAGConfig{Name: "test", Env: &config.EnvConfig{Set: map[string]string{"API_KEY": "${secret:api_key}"}}},
+		Tasks: []config.TaskConfig{{Name: "t", Script: "run.sh"}},
+	}
+	if errs := Validate(cfg, dir); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+
+	cfg.DAG.Env.Set["PIT_DAG_NAME"] = "x"
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "dag.env: set must not contain PIT_DAG_NAME") {
+		t.Errorf("Validate() = %v, want the dag.env error", errs)
+	}
+}
+
+func TestValidate_SQLIdentifierCase(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test", SQL: config.SQLConfig{IdentifierCase: "upper"}}}
+	if errs := Validate(cfg, dir); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+
+	cfg.DAG.SQL.IdentifierCase = "Upper"
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "invalid sql.identifier_case") {
+		t.Errorf("Validate() = %v, want invalid sql.identifier_case", errs)
+	}
+}
+
+func TestValidate_ExcludeColumns(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "run.sql"), []byte("SELECT 1"), 0o644)
+	cfg := &config.ProjectConfig{
+		DAG: config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: "db"}},
+		Tasks: []config.TaskConfig{
+			{Name: "load", Type: "load", Source: "x.parquet", Table: "dbo.x", ExcludeColumns: []string{"id"}},
+			{Name: "exec", Script: "run.sql", ExcludeColumns: []string{"id"}},
+		},
+	}
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || errs[0].Task != "exec" || !strings.Contains(errs[0].Message, "exclude_columns and timezone_policy are only valid") {
+		t.Errorf("Validate() = %v, want one exclude_columns error on exec", errs)
+	}
+}
+
+func TestValidate_TimezonePolicy(t *testing.T) {
+	dir := t.TempDir()
+	for _, policy := range []string{"", "error", "offset", "utc"} {
+		cfg := &config.ProjectConfig{
+			DAG:   config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: "db"}},
+			Tasks: []config.TaskConfig{{Name: "load", Type: "load", Source: "x.parquet", Table: "x", TimezonePolicy: policy}},
+		}
+		if errs := Validate(cfg, dir); len(errs) != 0 {
+			t.Errorf("timezone_policy %q: Validate() = %v, want no errors", policy, errs)
+		}
+	}
+
+	cfg := &config.ProjectConfig{
+		DAG:   config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: "db"}},
+		Tasks: []config.TaskConfig{{Name: "load", Type: "load", Source: "x.parquet", Table: "x", TimezonePolicy: "local"}},
+	}
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "invalid timezone_policy") {
+		t.Errorf("Validate() = %v, want invalid timezone_policy", errs)
+	}
+}
+
+func TestValidate_RejectFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		task    config.TaskConfig
+		wantErr string
+	}{
+		{"csv", config.TaskConfig{RejectFile: "rejects.csv", MaxRejects: 10}, ""},
+		{"parquet", config.TaskConfig{RejectFile: "out/rejects.parquet"}, ""},
+		{"bad extension", config.TaskConfig{RejectFile: "rejects.json"}, "must end in .csv or .parquet"},
+		{"escapes data dir", config.TaskConfig{RejectFile: "../rejects.csv"}, "relative path inside the data directory"},
+		{"negative max", config.TaskConfig{RejectFile: "rejects.csv", MaxRejects: -1}, "invalid max_rejects"},
+		{"max without file", config.TaskConfig{MaxRejects: 5}, "max_rejects requires reject_file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			task.Name, task.Type, task.Source, task.Table = "load", "load", "x.parquet", "x"
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test", SQL: config.SQLConfig{Connection: "db"}},
+				Tasks: []config.TaskConfig{task},
+			}
+			errs := Validate(cfg, dir)
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("Validate() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+
+	cfg := &config.ProjectConfig{
+		DAG:   config.DAGConfig{Name: "test"},
+		Tasks: []config.TaskConfig{{Name: "exec", Script: "run.sh", RejectFile: "rejects.csv"}},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "reject_file and max_rejects are only valid") {
+		t.Errorf("Validate() = %v, want one reject_file error on exec", errs)
+	}
+}
+
+func TestOrderByRequires(t *testing.T) {
+	configs := map[string]*config.ProjectConfig{
+		"marts":     {DAG: config.DAGConfig{Name: "marts", Requires: []string{"staging", "reference"}}},
+		"staging":   {DAG: config.DAGConfig{Name: "staging", Requires: []string{"ingest"}}},
+		"ingest":    {DAG: config.DAGConfig{Name: "ingest"}},
+		"reference": {DAG: config.DAGConfig{Name: "reference"}},
+		"adhoc":     {DAG: config.DAGConfig{Name: "adhoc"}},
+	}
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"marts", "staging", "ingest", "reference", "adhoc"}, "adhoc ingest reference staging marts"},
+		// Requirements outside the selection are ignored.
+		{[]string{"marts", "staging"}, "staging marts"},
+		{[]string{"marts", "adhoc"}, "adhoc marts"},
+	}
+	for _, tt := range tests {
+		order, err := OrderByRequires(configs, tt.names)
+		if err != nil {
+			t.Fatalf("OrderByRequires(%v) error: %v", tt.names, err)
+		}
+		if got := strings.Join(order, " "); got != tt.want {
+			t.Errorf("OrderByRequires(%v) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+
+	configs["ingest"].DAG.Requires = []string{"marts"}
+	_, err := OrderByRequires(configs, []string{"marts", "staging", "ingest", "adhoc"})
+	if err == nil || !strings.Contains(err.Error(), "requires cycle involving DAGs: ingest, marts, staging") {
+		t.Errorf("OrderByRequires() error = %v, want cycle error", err)
+	}
+}
+
+func TestValidateRequires(t *testing.T) {
+	configs := map[string]*config.ProjectConfig{
+		"marts":   {DAG: config.DAGConfig{Name: "marts", Requires: []string{"staging", "missing"}}},
+		"staging": {DAG: config.DAGConfig{Name: "staging"}},
+	}
+	errs := ValidateRequires(configs)
+	if len(errs) != 1 || errs[0].DAG != "marts" || !strings.Contains(errs[0].Message, `requires unknown DAG "missing"`) {
+		t.Errorf("ValidateRequires() = %v, want unknown DAG error", errs)
+	}
+
+	configs["staging"].DAG.Requires = []string{"marts"}
+	configs["marts"].DAG.Requires = []string{"staging"}
+	errs = ValidateRequires(configs)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "requires cycle involving DAGs: marts, staging") {
+		t.Errorf("ValidateRequires() = %v, want cycle error", errs)
+	}
+}
+
+func TestValidate_Freshness(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "load.sh"), []byte("echo hi\n"), 0o755)
+	fresh := config.TaskConfig{Name: "load", Script: "load.sh", Freshness: config.Duration{Duration: 6 * time.Hour}}
+
+	cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "d"}, Tasks: []config.TaskConfig{fresh}}
+	errs := Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "freshness needs [[outputs]]") {
+		t.Errorf("Validate() without outputs = %v, want the missing outputs", errs)
+	}
+
+	cfg.Outputs = []config.Output{{Name: "staging", Type: "table", Location: "dbo.staging"}}
+	if errs := Validate(cfg, dir); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want none", errs)
+	}
+
+	cfg.Tasks[0].Freshness = config.Duration{Duration: -time.Hour}
+	errs = Validate(cfg, dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "must not be negative") {
+		t.Errorf("Validate() with negative freshness = %v, want one error", errs)
+	}
+}
+
+func TestValidate_FTPWatch_Protocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		fw       config.FTPWatchConfig
+		wantErr  string
+		wantPort int
+	}{
+		{"secret only", config.FTPWatchConfig{Secret: "src"}, "", 0},
+		{"secret with sftp", config.FTPWatchConfig{Secret: "src", Protocol: "sftp"}, "", 0},
+		{"legacy sftp", config.FTPWatchConfig{Protocol: "sftp", Host: "h", User: "u", PasswordSecret: "p"}, "", 22},
+		{"legacy ftp", config.FTPWatchConfig{Protocol: "ftp", Host: "h", User: "u", PasswordSecret: "p"}, "", 21},
+		{"unknown protocol", config.FTPWatchConfig{Secret: "src", Protocol: "scp"}, `invalid ftp_watch.protocol "scp"`, 0},
+		{"tls with sftp", config.FTPWatchConfig{Secret: "src", Protocol: "sftp", TLS: true}, "ftp_watch.tls cannot be used", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fw := tt.fw
+			fw.Directory, fw.Pattern = "/in", "*.csv"
+			cfg := &config.ProjectConfig{
+				DAG:   config.DAGConfig{Name: "test", FTPWatch: &fw},
+				Tasks: []config.TaskConfig{{Name: "process"}},
+			}
+			var ftpErrs []string
+			for _, e := range Validate(cfg, t.TempDir()) {
+				if strings.Contains(e.Error(), "ftp_watch") {
+					ftpErrs = append(ftpErrs, e.Error())
+				}
+			}
+			if tt.wantErr == "" && len(ftpErrs) != 0 {
+				t.Errorf("Validate() unexpected ftp_watch errors: %v", ftpErrs)
+			}
+			if tt.wantErr != "" && (len(ftpErrs) != 1 || !strings.Contains(ftpErrs[0], tt.wantErr)) {
+				t.Errorf("Validate() ftp_watch errors = %v, want one containing %q", ftpErrs, tt.wantErr)
+			}
+			if tt.wantErr == "" && fw.Port != tt.wantPort {
+				t.Errorf("FTPWatch.Port = %d, want %d", fw.Port, tt.wantPort)
+			}
+		})
+	}
+}
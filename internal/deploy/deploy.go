@@ -0,0 +1,339 @@
+// Package deploy installs pipeline code from git into a workspace: the
+// configured repository is fetched, copied to a staging directory beside its
+// target, validated, and then renamed into place so pit serve never sees a
+// half-written projects directory. The tree it replaces is kept as
+// <target>.previous for rollback.
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/dag"
+	"github.com/druarnfield/pit/internal/gitrepo"
+)
+
+// MarkerFile is written to the root of every deployed tree and records
+// where it came from.
+const MarkerFile = ".pit-deploy.json"
+
+// previousSuffix names the tree a deploy replaced.
+const previousSuffix = ".previous"
+
+// Options controls a deploy.
+type Options struct {
+	CacheDir string                 // where repositories are cloned (one subdirectory per target)
+	Defaults *config.DefaultsConfig // workspace [defaults], applied before validating
+	Check    bool                   // validate only; leave the target untouched
+}
+
+// Marker describes a deployed tree.
+type Marker struct {
+	URL        string    `json:"url"`
+	Ref        string    `json:"ref"`
+	Commit     string    `json:"commit"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// Result reports what a deploy did.
+type Result struct {
+	Target   string
+	Commit   string
+	Projects []string // DAG names found in the deployed tree, sorted
+	Swapped  bool     // false for a check, or when the commit was already deployed
+}
+
+// ValidationError is returned when the fetched tree fails validation.
+type ValidationError struct {
+	Errs []*dag.ValidationError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("validation failed:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// Deploy fetches src and installs it into src.Target. When the target
+// already holds the fetched commit nothing is swapped.
+func Deploy(src config.DeployConfig, opts Options) (*Result, error) {
+	ref := src.Ref
+	if ref == "" {
+		ref = config.DefaultDeployRef
+	}
+	cacheDir := filepath.Join(opts.CacheDir, "deploy", cacheName(src.Target))
+	if err := gitrepo.Prepare(src.URL, ref, cacheDir); err != nil {
+		return nil, err
+	}
+	commit, err := gitrepo.Head(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cur, err := ReadMarker(src.Target); err == nil && cur.URL == src.URL && cur.Commit == commit && !opts.Check {
+		projects, err := findProjects(src.Target)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Target: src.Target, Commit: commit, Projects: names(projects)}, nil
+	}
+
+	dir := cacheDir
+	if src.Subdir != "" {
+		dir = filepath.Join(cacheDir, src.Subdir)
+	}
+	marker := Marker{URL: src.URL, Ref: ref, Commit: commit}
+	return Install(dir, src.Target, marker, opts)
+}
+
+// Install copies dir to a staging directory beside target, validates every
+// project in it, and swaps it in. On any error target is left untouched.
+func Install(dir, target string, marker Marker, opts Options) (*Result, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("deploy source: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("deploy source %s is not a directory", dir)
+	}
+
+	parent := filepath.Dir(target)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", parent, err)
+	}
+	// Stage on the same filesystem as target so the swap is a rename.
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(target)+".deploy-")
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			os.RemoveAll(staging)
+		}
+	}()
+
+	if err := copyTree(dir, staging); err != nil {
+		return nil, fmt.Errorf("copying %s: %w", dir, err)
+	}
+
+	configs, err := findProjects(staging)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no pit.toml files found in %s", dir)
+	}
+	var errs []*dag.ValidationError
+	for _, cfg := range configs {
+		cfg.ApplyDefaults(opts.Defaults)
+		errs = append(errs, dag.Validate(cfg, cfg.Dir())...)
+	}
+	errs = append(errs, dag.ValidateRequires(configs)...)
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errs: errs}
+	}
+
+	res := &Result{Target: target, Commit: marker.Commit, Projects: names(configs)}
+	if opts.Check {
+		return res, nil
+	}
+
+	marker.DeployedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding deploy marker: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, MarkerFile), append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("writing deploy marker: %w", err)
+	}
+	// MkdirTemp creates the directory 0700; give it the usual mode.
+	if err := os.Chmod(staging, 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := swap(staging, target); err != nil {
+		return nil, err
+	}
+	swapped = true
+	res.Swapped = true
+	return res, nil
+}
+
+// Rollback swaps target with the tree the last deploy replaced.
+func Rollback(target string) error {
+	prev := target + previousSuffix
+	if _, err := os.Stat(prev); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no previous deploy of %s to roll back to", target)
+		}
+		return err
+	}
+	tmp := target + ".rollback"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(target, tmp); err != nil {
+		return fmt.Errorf("moving %s aside: %w", target, err)
+	}
+	if err := os.Rename(prev, target); err != nil {
+		os.Rename(tmp, target)
+		return fmt.Errorf("restoring %s: %w", prev, err)
+	}
+	return os.Rename(tmp, prev)
+}
+
+// ReadMarker reads the deploy marker of the tree at target.
+func ReadMarker(target string) (*Marker, error) {
+	data, err := os.ReadFile(filepath.Join(target, MarkerFile))
+	if err != nil {
+		return nil, err
+	}
+	var m Marker
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", MarkerFile, err)
+	}
+	return &m, nil
+}
+
+// swap moves staging to target, keeping the old target as target.previous.
+func swap(staging, target string) error {
+	prev := target + previousSuffix
+	if err := os.RemoveAll(prev); err != nil {
+		return fmt.Errorf("removing %s: %w", prev, err)
+	}
+	hadTarget := true
+	if err := os.Rename(target, prev); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("moving %s aside: %w", target, err)
+		}
+		hadTarget = false
+	}
+	if err := os.Rename(staging, target); err != nil {
+		if hadTarget {
+			os.Rename(prev, target)
+		}
+		return fmt.Errorf("installing %s: %w", target, err)
+	}
+	return nil
+}
+
+// findProjects loads every pit.toml under root, skipping hidden and
+// dependency directories. DAG names default to the project directory name.
+func findProjects(root string) (map[string]*config.ProjectConfig, error) {
+	configs := make(map[string]*config.ProjectConfig)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "pit.toml" {
+			return nil
+		}
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if cfg.DAG.Name == "" {
+			cfg.DAG.Name = filepath.Base(filepath.Dir(path))
+		}
+		if prev, exists := configs[cfg.DAG.Name]; exists {
+			rel := func(p string) string { r, _ := filepath.Rel(root, p); return r }
+			return fmt.Errorf("duplicate DAG name %q (%s and %s)", cfg.DAG.Name, rel(prev.Dir()), rel(cfg.Dir()))
+		}
+		configs[cfg.DAG.Name] = cfg
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// copyTree copies the contents of src into the existing directory dst,
+// skipping .git. Symlinks are recreated, not followed.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		out := filepath.Join(dst, rel)
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, out)
+		case d.IsDir():
+			return os.Mkdir(out, 0o755)
+		default:
+			return copyFile(path, out)
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// cacheName turns a target path into a cache directory name.
+func cacheName(target string) string {
+	name := strings.Trim(filepath.ToSlash(target), "/")
+	return strings.NewReplacer("/", "_", ":", "_").Replace(name)
+}
+
+func names(configs map[string]*config.ProjectConfig) []string {
+	out := make([]string, 0, len(configs))
+	for name := range configs {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,128 @@
+package deploy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProject writes a one-task project named name under root.
+func writeProject(t *testing.T, root, name, script string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Join(dir, "tasks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	toml := "[dag]\nname = \"" + name + "\"\n\n[[tasks]]\nname = \"hello\"\nscript = \"" + script + "\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "pit.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tasks", "hello.sh"), []byte("echo hello\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, "etl", "tasks/hello.sh")
+	if err := os.MkdirAll(filepath.Join(src, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(t.TempDir(), "projects")
+	writeProject(t, target, "old", "tasks/hello.sh")
+
+	res, err := Install(src, target, Marker{URL: "git@example.com:data/pipelines.git", Ref: "main", Commit: "abc123"}, Options{})
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if !res.Swapped || len(res.Projects) != 1 || res.Projects[0] != "etl" {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(target, "etl", "tasks", "hello.sh")); err != nil {
+		t.Errorf("deployed script missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git was copied into the target")
+	}
+	if _, err := os.Stat(filepath.Join(target+previousSuffix, "old", "pit.toml")); err != nil {
+		t.Errorf("previous tree not kept: %v", err)
+	}
+	m, err := ReadMarker(target)
+	if err != nil {
+		t.Fatalf("ReadMarker() error: %v", err)
+	}
+	if m.Commit != "abc123" || m.DeployedAt.IsZero() {
+		t.Errorf("marker = %+v", m)
+	}
+
+	if err := Rollback(target); err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "old", "pit.toml")); err != nil {
+		t.Errorf("rollback did not restore the old tree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target+previousSuffix, "etl", "pit.toml")); err != nil {
+		t.Errorf("rollback did not keep the rolled-back tree: %v", err)
+	}
+}
+
+func TestInstall_Check(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, "etl", "tasks/hello.sh")
+	target := filepath.Join(t.TempDir(), "projects")
+
+	res, err := Install(src, target, Marker{Commit: "abc123"}, Options{Check: true})
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if res.Swapped {
+		t.Error("check swapped the target in")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("check created the target")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 0 {
+		t.Errorf("staging directory left behind: %v", entries)
+	}
+}
+
+func TestInstall_Invalid(t *testing.T) {
+	src := t.TempDir()
+	writeProject(t, src, "etl", "tasks/missing.sh")
+	target := filepath.Join(t.TempDir(), "projects")
+	writeProject(t, target, "old", "tasks/hello.sh")
+
+	_, err := Install(src, target, Marker{}, Options{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Install() error = %v, want *ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "missing.sh") {
+		t.Errorf("error = %q, want it to name the missing script", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "old", "pit.toml")); err != nil {
+		t.Errorf("target changed after a failed deploy: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("staging directory left behind: %v", entries)
+	}
+}
+
+func TestInstall_NoProjects(t *testing.T) {
+	_, err := Install(t.TempDir(), filepath.Join(t.TempDir(), "projects"), Marker{}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "no pit.toml") {
+		t.Errorf("Install() error = %v, want no pit.toml error", err)
+	}
+}
+
+func TestRollback_NoPrevious(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "projects")
+	if err := Rollback(target); err == nil {
+		t.Error("Rollback() expected error with no previous deploy")
+	}
+}
@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/sdk"
+)
+
+// dataDirPath resolves name, a path relative to the run's data directory,
+// and rejects absolute paths and paths that climb out of it.
+func dataDirPath(dataDir, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("path %q escapes data directory", name)
+	}
+	return filepath.Join(dataDir, name), nil
+}
+
+// makeUnzipHandler returns a handler that extracts a zip archive in the
+// data directory. Entries whose names are absolute or climb out of the
+// destination ("zip slip") fail the call before anything is written;
+// symlink entries are skipped.
+//
+// Params: archive, dest (optional, default the data dir), pattern (optional glob on entry base names)
+// Returns: JSON array of extracted file paths (absolute, inside dataDir)
+func makeUnzipHandler(dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		archive := params["archive"]
+		if archive == "" {
+			return "", fmt.Errorf("missing required parameter: archive")
+		}
+		archivePath, err := dataDirPath(dataDir, archive)
+		if err != nil {
+			return "", err
+		}
+		dest := dataDir
+		if d := params["dest"]; d != "" {
+			if dest, err = dataDirPath(dataDir, d); err != nil {
+				return "", err
+			}
+		}
+		pattern := params["pattern"]
+		if pattern != "" {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return "", fmt.Errorf("opening %q: %w", archive, err)
+		}
+		defer zr.Close()
+
+		// Check every entry before extracting any
+		var files []*zip.File
+		for _, f := range zr.File {
+			if !filepath.IsLocal(filepath.FromSlash(f.Name)) {
+				return "", fmt.Errorf("archive entry %q escapes the destination", f.Name)
+			}
+			if f.FileInfo().IsDir() || f.Mode()&os.ModeSymlink != 0 {
+				continue
+			}
+			if pattern != "" {
+				if ok, _ := path.Match(pattern, path.Base(f.Name)); !ok {
+					continue
+				}
+			}
+			files = append(files, f)
+		}
+
+		extracted := make([]string, 0, len(files))
+		for _, f := range files {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			target := filepath.Join(dest, filepath.FromSlash(f.Name))
+			if err := extractZipFile(f, target); err != nil {
+				return "", fmt.Errorf("extracting %q: %w", f.Name, err)
+			}
+			extracted = append(extracted, target)
+		}
+
+		b, err := json.Marshal(extracted)
+		if err != nil {
+			return "", fmt.Errorf("encoding result: %w", err)
+		}
+		return string(b), nil
+	}
+}
+
+// extractZipFile writes one zip entry to target, creating its directory.
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFileFrom(target, rc)
+}
+
+// makeGzipHandler returns a handler that compresses a file in the data
+// directory to <name>.gz, keeping the original unless remove is "true".
+//
+// Params: name, remove (optional)
+// Returns: the compressed file's path (absolute, inside dataDir)
+func makeGzipHandler(dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		name := params["name"]
+		if name == "" {
+			return "", fmt.Errorf("missing required parameter: name")
+		}
+		src, err := dataDirPath(dataDir, name)
+		if err != nil {
+			return "", err
+		}
+		dst := src + ".gz"
+
+		in, err := os.Open(src)
+		if err != nil {
+			return "", err
+		}
+		defer in.Close()
+		out, err := os.Create(dst)
+		if err != nil {
+			return "", err
+		}
+		zw := gzip.NewWriter(out)
+		zw.Name = filepath.Base(src)
+		_, err = io.Copy(zw, in)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst)
+			return "", fmt.Errorf("compressing %q: %w", name, err)
+		}
+
+		if params["remove"] == "true" {
+			in.Close()
+			if err := os.Remove(src); err != nil {
+				return "", err
+			}
+		}
+		return dst, nil
+	}
+}
+
+// makeGunzipHandler returns a handler that decompresses a .gz file in the
+// data directory, by default to its name without the .gz suffix.
+//
+// Params: name, dest (optional, required when name does not end in .gz), remove (optional)
+// Returns: the decompressed file's path (absolute, inside dataDir)
+func makeGunzipHandler(dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		name := params["name"]
+		if name == "" {
+			return "", fmt.Errorf("missing required parameter: name")
+		}
+		src, err := dataDirPath(dataDir, name)
+		if err != nil {
+			return "", err
+		}
+		destName := params["dest"]
+		if destName == "" {
+			var ok bool
+			if destName, ok = strings.CutSuffix(name, ".gz"); !ok {
+				return "", fmt.Errorf("missing required parameter: dest (name does not end in .gz)")
+			}
+		}
+		dst, err := dataDirPath(dataDir, destName)
+		if err != nil {
+			return "", err
+		}
+
+		in, err := os.Open(src)
+		if err != nil {
+			return "", err
+		}
+		defer in.Close()
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return "", fmt.Errorf("reading %q: %w", name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		if err := writeFileFrom(dst, zr); err != nil {
+			os.Remove(dst)
+			return "", fmt.Errorf("decompressing %q: %w", name, err)
+		}
+
+		if params["remove"] == "true" {
+			in.Close()
+			if err := os.Remove(src); err != nil {
+				return "", err
+			}
+		}
+		return dst, nil
+	}
+}
+
+// writeFileFrom copies r into a new file at path.
+func writeFileFrom(path string, r io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
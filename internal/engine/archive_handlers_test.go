@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip creates a zip archive at path with the given entries.
+func writeZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipHandler(t *testing.T) {
+	dataDir := t.TempDir()
+	writeZip(t, filepath.Join(dataDir, "feed.zip"), map[string]string{
+		"claims.csv":        "id\n1\n",
+		"nested/lines.csv":  "id\n2\n",
+		"nested/readme.txt": "ignore me",
+	})
+
+	handler := makeUnzipHandler(dataDir)
+	result, err := handler(context.Background(), map[string]string{"archive": "feed.zip", "dest": "in", "pattern": "*.csv"})
+	if err != nil {
+		t.Fatalf("unzip error: %v", err)
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(result), &paths); err != nil {
+		t.Fatalf("decoding result %q: %v", result, err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("extracted %v, want the two .csv files", paths)
+	}
+	data, err := os.ReadFile(filepath.Join(dataDir, "in", "nested", "lines.csv"))
+	if err != nil || string(data) != "id\n2\n" {
+		t.Errorf("in/nested/lines.csv = %q (err %v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "in", "nested", "readme.txt")); !os.IsNotExist(err) {
+		t.Errorf("readme.txt should not match the pattern, Stat err = %v", err)
+	}
+}
+
+func TestUnzipHandler_Traversal(t *testing.T) {
+	dataDir := t.TempDir()
+	writeZip(t, filepath.Join(dataDir, "evil.zip"), map[string]string{
+		"ok.csv":          "fine",
+		"../../escape.sh": "rm -rf /",
+	})
+	handler := makeUnzipHandler(dataDir)
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"zip slip", map[string]string{"archive": "evil.zip"}, "escapes the destination"},
+		{"archive outside data dir", map[string]string{"archive": "../feed.zip"}, "escapes data directory"},
+		{"dest outside data dir", map[string]string{"archive": "evil.zip", "dest": "/tmp"}, "escapes data directory"},
+		{"missing archive", map[string]string{}, "missing required parameter: archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(context.Background(), tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "ok.csv")); !os.IsNotExist(err) {
+		t.Errorf("nothing should be extracted from an archive with a bad entry, Stat err = %v", err)
+	}
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	dataDir := t.TempDir()
+	content := strings.Repeat("id,amount\n1,10\n", 100)
+	os.WriteFile(filepath.Join(dataDir, "claims.csv"), []byte(content), 0o644)
+
+	gz, err := makeGzipHandler(dataDir)(context.Background(), map[string]string{"name": "claims.csv", "remove": "true"})
+	if err != nil {
+		t.Fatalf("gzip error: %v", err)
+	}
+	if gz != filepath.Join(dataDir, "claims.csv.gz") {
+		t.Errorf("gzip returned %q, want claims.csv.gz in the data dir", gz)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "claims.csv")); !os.IsNotExist(err) {
+		t.Errorf("original should be removed, Stat err = %v", err)
+	}
+	f, _ := os.Open(gz)
+	zr, err := gzip.NewReader(f)
+	if err != nil || zr.Name != "claims.csv" {
+		t.Errorf("gzip header name = %q (err %v), want claims.csv", zr.Name, err)
+	}
+	f.Close()
+
+	out, err := makeGunzipHandler(dataDir)(context.Background(), map[string]string{"name": "claims.csv.gz"})
+	if err != nil {
+		t.Fatalf("gunzip error: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != content {
+		t.Errorf("gunzip content differs from the original (%d bytes, want %d)", len(data), len(content))
+	}
+	if _, err := os.Stat(gz); err != nil {
+		t.Errorf("compressed file should be kept without remove: %v", err)
+	}
+}
+
+func TestGunzipHandler_Params(t *testing.T) {
+	dataDir := t.TempDir()
+	handler := makeGunzipHandler(dataDir)
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"missing name", map[string]string{}, "missing required parameter: name"},
+		{"no .gz suffix", map[string]string{"name": "feed.bin"}, "missing required parameter: dest"},
+		{"dest outside data dir", map[string]string{"name": "feed.gz", "dest": "../feed"}, "escapes data directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(context.Background(), tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumManifestName is the file in the run directory listing the
+// SHA-256 of every file in data/, in sha256sum format so that
+// `sha256sum -c data.sha256` run from the run directory verifies it.
+const checksumManifestName = "data.sha256"
+
+// checksumSidecarExt marks a seeded file holding the expected checksum of
+// the file with the same name minus the extension.
+const checksumSidecarExt = ".sha256"
+
+// writeChecksumManifest hashes every regular file under dataDir and writes
+// the manifest into runDir. Returns the number of files listed.
+func writeChecksumManifest(runDir, dataDir string) (int, error) {
+	var lines []string
+	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		// Listed under data/ even when data_dir puts the files elsewhere
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, sum+"  data/"+filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("hashing data dir: %w", err)
+	}
+
+	// Sort by path, which follows the hash in each line
+	sort.Slice(lines, func(i, j int) bool { return lines[i][64:] < lines[j][64:] })
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	if err := os.WriteFile(filepath.Join(runDir, checksumManifestName), []byte(content), 0o644); err != nil {
+		return 0, fmt.Errorf("writing checksum manifest: %w", err)
+	}
+	return len(lines), nil
+}
+
+// verifySeededChecksums checks each file in dataDir that has a .sha256
+// sidecar against the checksum in it. A sidecar holds a hex digest,
+// optionally followed by a file name as sha256sum writes it. Returns the
+// number of files verified.
+func verifySeededChecksums(dataDir string) (int, error) {
+	verified := 0
+	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), checksumSidecarExt) {
+			return nil
+		}
+		target := strings.TrimSuffix(path, checksumSidecarExt)
+		rel, _ := filepath.Rel(dataDir, target)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+			return fmt.Errorf("%s: no SHA-256 digest found", d.Name())
+		}
+		want := strings.ToLower(fields[0])
+
+		got, err := fileSHA256(target)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s: %s was not seeded", d.Name(), rel)
+		}
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("%s: checksum mismatch (expected %s, got %s)", rel, want, got)
+		}
+		verified++
+		return nil
+	})
+	return verified, err
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestWriteChecksumManifest(t *testing.T) {
+	runDir := t.TempDir()
+	dataDir := filepath.Join(runDir, "data")
+	os.MkdirAll(filepath.Join(dataDir, "raw"), 0o755)
+	os.WriteFile(filepath.Join(dataDir, "claims.csv"), []byte("id\n1\n"), 0o644)
+	os.WriteFile(filepath.Join(dataDir, "raw", "a.json"), []byte("{}"), 0o644)
+
+	n, err := writeChecksumManifest(runDir, dataDir)
+	if err != nil {
+		t.Fatalf("writeChecksumManifest() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("files = %d, want 2", n)
+	}
+	got, _ := os.ReadFile(filepath.Join(runDir, checksumManifestName))
+	want := sha256Hex("id\n1\n") + "  data/claims.csv\n" + sha256Hex("{}") + "  data/raw/a.json\n"
+	if string(got) != want {
+		t.Errorf("manifest =\n%s\nwant\n%s", got, want)
+	}
+
+	// The manifest is in sha256sum format
+	if _, err := exec.LookPath("sha256sum"); err == nil {
+		cmd := exec.Command("sha256sum", "-c", checksumManifestName)
+		cmd.Dir = runDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("sha256sum -c failed: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestWriteChecksumManifest_ExternalDataDir(t *testing.T) {
+	runDir := t.TempDir()
+	dataDir := filepath.Join(t.TempDir(), "20240115_143022.123-3fa9c1_claims")
+	os.MkdirAll(dataDir, 0o755)
+	os.WriteFile(filepath.Join(dataDir, "claims.csv"), []byte("id\n1\n"), 0o644)
+
+	if _, err := writeChecksumManifest(runDir, dataDir); err != nil {
+		t.Fatalf("writeChecksumManifest() error: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(runDir, checksumManifestName))
+	if want := sha256Hex("id\n1\n") + "  data/claims.csv\n"; string(got) != want {
+		t.Errorf("manifest = %q, want %q", got, want)
+	}
+}
+
+func TestVerifySeededChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    int
+		wantErr string
+	}{
+		{"no sidecars", map[string]string{"a.csv": "x"}, 0, ""},
+		{"match", map[string]string{"a.csv": "x", "a.csv.sha256": strings.ToUpper(sha256Hex("x")) + "  a.csv\n"}, 1, ""},
+		{"mismatch", map[string]string{"a.csv": "tampered", "a.csv.sha256": sha256Hex("x")}, 0, "a.csv: checksum mismatch"},
+		{"missing file", map[string]string{"b.csv.sha256": sha256Hex("x")}, 0, "b.csv was not seeded"},
+		{"bad sidecar", map[string]string{"a.csv": "x", "a.csv.sha256": "not a digest"}, 0, "no SHA-256 digest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+			}
+			n, err := verifySeededChecksums(dir)
+			if tt.wantErr == "" {
+				if err != nil || n != tt.want {
+					t.Errorf("verifySeededChecksums() = %d, %v; want %d, nil", n, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verifySeededChecksums() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
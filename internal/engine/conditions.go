@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/condition"
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// taskOutputs holds the values tasks publish through the SDK's set_output
+// method, for use in downstream run_if / skip_if conditions.
+type taskOutputs struct {
+	mu     sync.Mutex
+	values map[string]map[string]string // task -> key -> value
+}
+
+func (o *taskOutputs) set(task, key, value string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.values == nil {
+		o.values = make(map[string]map[string]string)
+	}
+	if o.values[task] == nil {
+		o.values[task] = make(map[string]string)
+	}
+	o.values[task][key] = value
+}
+
+func (o *taskOutputs) get(task, key string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	v, ok := o.values[task][key]
+	return v, ok
+}
+
+// makeSetOutputHandler returns the SDK handler that records a task output.
+//
+// Params: task, key, value
+func makeSetOutputHandler(outputs *taskOutputs) func(ctx context.Context, params map[string]string) (string, error) {
+	return func(_ context.Context, params map[string]string) (string, error) {
+		task, key := params["task"], params["key"]
+		if task == "" {
+			return "", fmt.Errorf("missing required parameter: task")
+		}
+		if key == "" {
+			return "", fmt.Errorf("missing required parameter: key")
+		}
+		outputs.set(task, key, params["value"])
+		return "ok", nil
+	}
+}
+
+// mergeParams returns the DAG's default params overridden by run params.
+func mergeParams(defaults, overrides map[string]string) map[string]string {
+	params := make(map[string]string, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		params[k] = v
+	}
+	for k, v := range overrides {
+		params[k] = v
+	}
+	return params
+}
+
+// logicalDateParam is the run parameter that sets a run's logical date,
+// e.g. pit run daily --param logical_date=2026-03-01 for a backfill.
+const logicalDateParam = "logical_date"
+
+// logicalDate returns the date, "2006-01-02", that a run processes: its
+// logical_date parameter, or else the day it started in the DAG's timezone.
+func logicalDate(params map[string]string, started time.Time, timezone string) (string, error) {
+	if v, ok := params[logicalDateParam]; ok {
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return "", fmt.Errorf("invalid %s param %q (want YYYY-MM-DD)", logicalDateParam, v)
+		}
+		return v, nil
+	}
+	loc, err := dagLocation(timezone)
+	if err != nil {
+		return "", err
+	}
+	return started.In(loc).Format(time.DateOnly), nil
+}
+
+// dagLocation loads a DAG's timezone, or returns the host's local time
+// when it sets none.
+func dagLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// paramEnv returns PIT_PARAM_<NAME> variables for a task's environment.
+func paramEnv(params map[string]string) []string {
+	env := make([]string, 0, len(params))
+	for k, v := range params {
+		env = append(env, "PIT_PARAM_"+strings.ToUpper(k)+"="+v)
+	}
+	return env
+}
+
+// parseTaskConditions compiles a task's run_if and skip_if expressions.
+func parseTaskConditions(tc config.TaskConfig) (runIf, skipIf *condition.Expr, err error) {
+	if tc.RunIf != "" {
+		if runIf, err = condition.Parse(tc.RunIf); err != nil {
+			return nil, nil, fmt.Errorf("task %q: invalid run_if: %w", tc.Name, err)
+		}
+	}
+	if tc.SkipIf != "" {
+		if skipIf, err = condition.Parse(tc.SkipIf); err != nil {
+			return nil, nil, fmt.Errorf("task %q: invalid skip_if: %w", tc.Name, err)
+		}
+	}
+	return runIf, skipIf, nil
+}
+
+// conditionLookup resolves the variables available to task conditions.
+// weekday, date, day, and hour are read from now in loc, the DAG's
+// timezone.
+func conditionLookup(run *Run, trigger string, now time.Time, loc *time.Location) condition.Lookup {
+	if trigger == "" {
+		trigger = "manual"
+	}
+	now = now.In(loc)
+	return func(name string) (string, bool) {
+		switch name {
+		case "weekday":
+			return strings.ToLower(now.Weekday().String()[:3]), true
+		case "date":
+			return now.Format("2006-01-02"), true
+		case "day":
+			return strconv.Itoa(now.Day()), true
+		case "hour":
+			return strconv.Itoa(now.Hour()), true
+		case "trigger":
+			return trigger, true
+		}
+		root, rest, _ := strings.Cut(name, ".")
+		switch root {
+		case "params":
+			v, ok := run.Params[rest]
+			return v, ok
+		case "env":
+			return os.LookupEnv(rest)
+		case "outputs":
+			task, key, _ := strings.Cut(rest, ".")
+			return run.Outputs.get(task, key)
+		}
+		return "", false
+	}
+}
+
+// conditionSkipReason evaluates ti's run_if and skip_if and returns why the
+// task should be skipped, or "" if it should run.
+func conditionSkipReason(ti *TaskInstance, lookup condition.Lookup) string {
+	if ti.RunIf != nil && !ti.RunIf.Eval(lookup) {
+		return fmt.Sprintf("run_if %q is false", ti.RunIf)
+	}
+	if ti.SkipIf != nil && ti.SkipIf.Eval(lookup) {
+		return fmt.Sprintf("skip_if %q is true", ti.SkipIf)
+	}
+	return ""
+}
+
+// markSkipped records that ti was skipped without running, e.g. by its
+// run_if / skip_if. Downstream tasks still run: a skipped dependency is not
+// a failure.
+func markSkipped(ti *TaskInstance, run *Run, reason string, opts ExecuteOpts) {
+	markSkippedAs(ti, run, StatusSkipped, reason, opts)
+}
+
+// markSkippedAs is markSkipped with the status to record, such as
+// StatusSkippedFresh.
+func markSkippedAs(ti *TaskInstance, run *Run, status TaskStatus, reason string, opts ExecuteOpts) {
+	run.mu.Lock()
+	ti.Status = status
+	ti.SkipReason = reason
+	ti.EndedAt = time.Now()
+	endedAt := ti.EndedAt
+	run.mu.Unlock()
+
+	if opts.MetaStore != nil {
+		if err := opts.MetaStore.RecordTaskStart(run.ID, ti.Name, string(status), "", endedAt); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+		if err := opts.MetaStore.RecordTaskEnd(run.ID, ti.Name, string(status), endedAt, 0, reason); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+}
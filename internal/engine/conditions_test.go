@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/condition"
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestConditionLookup(t *testing.T) {
+	t.Setenv("PIT_TEST_COND", "prod")
+	outputs := &taskOutputs{}
+	handler := makeSetOutputHandler(outputs)
+	if _, err := handler(context.Background(), map[string]string{"task": "extract", "key": "rows", "value": "42"}); err != nil {
+		t.Fatalf("set_output error: %v", err)
+	}
+	if _, err := handler(context.Background(), map[string]string{"task": "extract"}); err == nil {
+		t.Error("set_output without key expected error, got nil")
+	}
+
+	run := &Run{Params: mergeParams(map[string]string{"full_refresh": "false", "region": "au"}, map[string]string{"full_refresh": "true"}), Outputs: outputs}
+	now := time.Date(2026, 3, 7, 6, 30, 0, 0, time.UTC) // a Saturday
+	lookup := conditionLookup(run, "", now, time.UTC)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"params.full_refresh == 'true'", true},
+		{"params.region == 'au'", true},
+		{"params.missing == ''", true},
+		{"weekday in ['sat', 'sun']", true},
+		{"hour < 7 and day == 7", true},
+		{"date == '2026-03-07'", true},
+		{"trigger == 'manual'", true},
+		{"env.PIT_TEST_COND == 'prod'", true},
+		{"outputs.extract.rows > 0", true},
+		{"outputs.load.rows > 0", false},
+	}
+	for _, tt := range tests {
+		expr, err := condition.Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+		}
+		if got := expr.Eval(lookup); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+// TestConditionLookup_Timezone checks that the clock variables are read in
+// the DAG's timezone, not the host's.
+func TestConditionLookup_Timezone(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20:30 UTC on Saturday March 7 is 07:30 on Sunday March 8 in Sydney
+	now := time.Date(2026, 3, 7, 20, 30, 0, 0, time.UTC)
+	lookup := conditionLookup(&Run{}, "", now, sydney)
+
+	for name, want := range map[string]string{"weekday": "sun", "date": "2026-03-08", "day": "8", "hour": "7"} {
+		if got, _ := lookup(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestConditionSkipReason(t *testing.T) {
+	runIf, skipIf, err := parseTaskConditions(config.TaskConfig{Name: "load", RunIf: "params.go == 'yes'", SkipIf: "weekday == 'sun'"})
+	if err != nil {
+		t.Fatalf("parseTaskConditions() error: %v", err)
+	}
+	ti := &TaskInstance{Name: "load", RunIf: runIf, SkipIf: skipIf}
+
+	lookup := func(vals map[string]string) condition.Lookup {
+		return func(name string) (string, bool) { v, ok := vals[name]; return v, ok }
+	}
+	if reason := conditionSkipReason(ti, lookup(map[string]string{"params.go": "yes", "weekday": "mon"})); reason != "" {
+		t.Errorf("conditionSkipReason() = %q, want task to run", reason)
+	}
+	if reason := conditionSkipReason(ti, lookup(map[string]string{"params.go": "no"})); !strings.Contains(reason, "run_if") {
+		t.Errorf("conditionSkipReason() = %q, want run_if reason", reason)
+	}
+	if reason := conditionSkipReason(ti, lookup(map[string]string{"params.go": "yes", "weekday": "sun"})); !strings.Contains(reason, "skip_if") {
+		t.Errorf("conditionSkipReason() = %q, want skip_if reason", reason)
+	}
+
+	if _, _, err := parseTaskConditions(config.TaskConfig{Name: "bad", SkipIf: "(("}); err == nil {
+		t.Error("parseTaskConditions() with bad skip_if expected error, got nil")
+	}
+}
+
+func TestMarkConditionSkipped(t *testing.T) {
+	ti := &TaskInstance{Name: "load", Status: StatusPending}
+	run := &Run{ID: "r", DAGName: "test", Tasks: []*TaskInstance{ti}}
+	markSkipped(ti, run, `run_if "params.go == 'yes'" is false`, ExecuteOpts{})
+	if ti.Status != StatusSkipped {
+		t.Errorf("Status = %s, want skipped", ti.Status)
+	}
+	if hasUpstreamFailure(&TaskInstance{Name: "next", DependsOn: []string{"load"}}, map[string]TaskStatus{"load": ti.Status}) {
+		t.Error("a condition-skipped task should not fail its downstream tasks")
+	}
+}
+
+func TestLogicalDate(t *testing.T) {
+	// 23:30 UTC on March 1 is already March 2 in Sydney.
+	started := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		params   map[string]string
+		timezone string
+		want     string
+		wantErr  bool
+	}{
+		{name: "dag timezone", timezone: "Australia/Sydney", want: "2026-03-02"},
+		{name: "utc", timezone: "UTC", want: "2026-03-01"},
+		{name: "param", params: map[string]string{"logical_date": "2026-02-14"}, timezone: "UTC", want: "2026-02-14"},
+		{name: "bad param", params: map[string]string{"logical_date": "yesterday"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := logicalDate(tt.params, started, tt.timezone)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("logicalDate() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("logicalDate() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
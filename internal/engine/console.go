@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// consoleBufferSize bounds the verbose output waiting to reach the
+// console. Beyond it, task output is dropped from the console (never from
+// the log file) until the console catches up.
+const consoleBufferSize = 4 << 20
+
+// console streams verbose task output to the run's output from a single
+// goroutine, so a slow terminal cannot stall the tasks writing to it. Task
+// writes only queue their output; when the queue is full the output is
+// dropped and later summarised with one line per task.
+type console struct {
+	out   io.Writer
+	limit int
+
+	mu     sync.Mutex
+	queue  [][]byte
+	size   int // bytes queued or being written
+	closed bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newConsole(out io.Writer, limit int) *console {
+	c := &console{
+		out:   out,
+		limit: limit,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+func (c *console) loop() {
+	defer close(c.done)
+	for {
+		c.mu.Lock()
+		batch, closed := c.queue, c.closed
+		c.queue = nil
+		c.mu.Unlock()
+
+		written := 0
+		for _, b := range batch {
+			c.out.Write(b)
+			written += len(b)
+		}
+		c.mu.Lock()
+		c.size -= written
+		c.mu.Unlock()
+
+		if len(batch) == 0 {
+			if closed {
+				return
+			}
+			<-c.wake
+		}
+	}
+}
+
+// enqueue queues b for the console. Unless force is set, it refuses when
+// b does not fit in the buffer.
+func (c *console) enqueue(b []byte, force bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || (!force && c.size+len(b) > c.limit) {
+		return false
+	}
+	c.queue = append(c.queue, b)
+	c.size += len(b)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// close writes out everything queued and stops the console. Later writes
+// are discarded. It is safe to call more than once.
+func (c *console) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+	c.mu.Unlock()
+	<-c.done
+}
+
+// writer returns the writer for one task's verbose output. Its writes
+// never block on the console and never fail, so the log file written
+// alongside it stays complete. Call flush when the task ends.
+func (c *console) writer(task string) *consoleWriter {
+	return &consoleWriter{c: c, task: task}
+}
+
+// consoleWriter queues one task's output on a console and counts what it
+// had to drop.
+type consoleWriter struct {
+	c       *console
+	task    string
+	mu      sync.Mutex
+	dropped int64
+}
+
+func (w *consoleWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dropped > 0 {
+		if !w.c.enqueue(w.droppedNote(), false) {
+			w.dropped += int64(len(p))
+			return len(p), nil
+		}
+		w.dropped = 0
+	}
+	if !w.c.enqueue(append([]byte(nil), p...), false) {
+		w.dropped += int64(len(p))
+	}
+	return len(p), nil
+}
+
+// flush reports output still unreported as dropped, even when the buffer
+// is full.
+func (w *consoleWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dropped > 0 {
+		w.c.enqueue(w.droppedNote(), true)
+		w.dropped = 0
+	}
+}
+
+func (w *consoleWriter) droppedNote() []byte {
+	return []byte(fmt.Sprintf("\n[pit] %s of output from %s not shown (console too slow); the task log has all of it\n",
+		config.ByteSize(w.dropped), w.task))
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter holds every write until release is closed, like a
+// terminal that has stopped reading.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestConsole_PassesOutputThrough(t *testing.T) {
+	var out bytes.Buffer
+	c := newConsole(&out, consoleBufferSize)
+	w := c.writer("extract")
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("line\n"))
+	}
+	w.flush()
+	c.close()
+	c.close()
+
+	if got := out.String(); got != strings.Repeat("line\n", 100) {
+		t.Errorf("console output = %q, want 100 lines", got)
+	}
+	// Writes after close are discarded, not blocked.
+	if n, err := w.Write([]byte("late\n")); n != 5 || err != nil {
+		t.Errorf("Write() after close = %d, %v", n, err)
+	}
+}
+
+func TestConsole_DropsUnderPressure(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	c := newConsole(out, 64)
+	w := c.writer("extract")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			w.Write([]byte("0123456789\n"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task writes blocked on a stalled console")
+	}
+
+	w.flush()
+	close(out.release)
+	c.close()
+
+	got := out.buf.String()
+	if strings.Count(got, "0123456789\n") >= 1000 {
+		t.Fatal("expected some output to be dropped")
+	}
+	if !strings.Contains(got, "of output from extract not shown (console too slow)") {
+		t.Errorf("console output has no dropped-output note:\n%s", got)
+	}
+}
+
+func TestVerboseWriter_PrefixesWholeLines(t *testing.T) {
+	var out bytes.Buffer
+	run := &Run{console: newConsole(&out, consoleBufferSize)}
+	a, flushA := verboseWriter(run, ExecuteOpts{Output: &out}, &TaskInstance{Name: "a"}, true)
+	b, flushB := verboseWriter(run, ExecuteOpts{Output: &out}, &TaskInstance{Name: "b"}, true)
+	a.Write([]byte("one\n"))
+	b.Write([]byte("two\n"))
+	flushA()
+	flushB()
+	run.console.close()
+
+	if got, want := out.String(), "[a] one\n[b] two\n"; got != want {
+		t.Errorf("verbose output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// DefaultMinFreeSpace is the free space, in bytes, that must remain on the
+// runs volume after the snapshot is copied, leaving room for logs and data.
+const DefaultMinFreeSpace = 100 << 20
+
+// errFreeSpaceUnknown is returned by freeSpace on platforms where pit
+// cannot query the filesystem; the preflight check is skipped there.
+var errFreeSpaceUnknown = fmt.Errorf("free space unavailable on this platform")
+
+// checkDiskSpace verifies that the volume holding runsDir has room for a
+// snapshot of size bytes plus minFree bytes of headroom (0 =
+// DefaultMinFreeSpace, < 0 = no check). Failing here beats a run dying
+// halfway through with ENOSPC.
+func checkDiskSpace(runsDir string, size, minFree int64) error {
+	if minFree < 0 {
+		return nil
+	}
+	if minFree == 0 {
+		minFree = DefaultMinFreeSpace
+	}
+	if err := os.MkdirAll(runsDir, 0o755); err != nil {
+		return fmt.Errorf("creating runs dir: %w", err)
+	}
+
+	free, err := freeSpace(runsDir)
+	if err == errFreeSpaceUnknown {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("checking free space on %s: %w", runsDir, err)
+	}
+
+	if need := size + minFree; free < uint64(need) {
+		return fmt.Errorf("insufficient disk space on %s: %s free, need %s (snapshot %s + min_free_space %s)",
+			runsDir, config.ByteSize(free), config.ByteSize(need), config.ByteSize(size), config.ByteSize(minFree))
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package engine
+
+func freeSpace(path string) (uint64, error) {
+	return 0, errFreeSpaceUnknown
+}
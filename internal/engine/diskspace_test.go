@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanCopy_SizeSkipsExcludedDirs(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]int{
+		"pit.toml":                  10,
+		"tasks/extract.py":          100,
+		".venv/lib/site.py":         1000,
+		"tasks/__pycache__/x.pyc":   1000,
+		"node_modules/pkg/index.js": 1000,
+	}
+	for rel, size := range files {
+		path := filepath.Join(src, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan, err := planCopy(src, filepath.Join(t.TempDir(), "project"), "", "")
+	if err != nil {
+		t.Fatalf("planCopy() error: %v", err)
+	}
+	if got := plan.size(); got != 110 {
+		t.Errorf("size() = %d, want 110", got)
+	}
+}
+
+func TestPlanCopy_SizeFollowsSymlinkPolicy(t *testing.T) {
+	shared := t.TempDir()
+	if err := os.WriteFile(filepath.Join(shared, "lib.py"), make([]byte, 500), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "pit.toml"), make([]byte, 10), 0o644)
+	if err := os.Symlink(shared, filepath.Join(src, "shared")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	for _, tt := range []struct {
+		policy string
+		want   int64
+	}{
+		{SymlinksSkip, 10},
+		{SymlinksPreserve, 10},
+		{SymlinksFollow, 510},
+	} {
+		plan, err := planCopy(src, filepath.Join(t.TempDir(), "project"), tt.policy, "")
+		if err != nil {
+			t.Fatalf("planCopy(%s) error: %v", tt.policy, err)
+		}
+		if got := plan.size(); got != tt.want {
+			t.Errorf("planCopy(%s).size() = %d, want %d", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	runsDir := filepath.Join(t.TempDir(), "runs")
+
+	if err := checkDiskSpace(runsDir, 6, 1); err != nil {
+		t.Fatalf("checkDiskSpace() with small headroom: %v", err)
+	}
+	if _, err := os.Stat(runsDir); err != nil {
+		t.Errorf("runs dir not created: %v", err)
+	}
+
+	free, err := freeSpace(runsDir)
+	if err == errFreeSpaceUnknown {
+		t.Skip("free space not available on this platform")
+	}
+	err = checkDiskSpace(runsDir, 6, int64(free))
+	if err == nil || !strings.Contains(err.Error(), "insufficient disk space") {
+		t.Errorf("checkDiskSpace() with headroom above free space = %v, want insufficient disk space error", err)
+	}
+	if err := checkDiskSpace(runsDir, int64(free)*2, -1); err != nil {
+		t.Errorf("checkDiskSpace() with check off = %v, want nil", err)
+	}
+}
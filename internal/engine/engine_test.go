@@ -2,11 +2,14 @@ package engine
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/druarnfield/pit/internal/config"
 )
 
 func TestGenerateRunID(t *testing.T) {
@@ -141,6 +144,62 @@ func TestHasUpstreamFailure(t *testing.T) {
 	}
 }
 
+func TestFailedUpstream(t *testing.T) {
+	statusMap := map[string]TaskStatus{
+		"extract":  StatusFailed,
+		"download": StatusFailed,
+		"ok":       StatusSuccess,
+		"stage":    StatusUpstreamFailed,
+	}
+	upstreamMap := map[string][]string{
+		"stage": {"extract"},
+	}
+
+	tests := []struct {
+		name      string
+		dependsOn []string
+		want      []string
+	}{
+		{name: "direct failure", dependsOn: []string{"extract", "ok"}, want: []string{"extract"}},
+		{name: "traced through upstream_failed", dependsOn: []string{"stage"}, want: []string{"extract"}},
+		{name: "deduplicated and sorted", dependsOn: []string{"stage", "download", "extract"}, want: []string{"download", "extract"}},
+		{name: "no failures", dependsOn: []string{"ok"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti := &TaskInstance{Name: "target", DependsOn: tt.dependsOn}
+			got := failedUpstream(ti, statusMap, upstreamMap)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("failedUpstream() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSummary_UpstreamFailed(t *testing.T) {
+	now := time.Now()
+	ti := &TaskInstance{Name: "load", DependsOn: []string{"extract"}}
+	run := &Run{
+		ID:        "20240115_143022.123_test",
+		DAGName:   "test",
+		Status:    StatusFailed,
+		StartedAt: now,
+		EndedAt:   now.Add(time.Second),
+		Tasks: []*TaskInstance{
+			{Name: "extract", Status: StatusFailed, Error: os.ErrNotExist},
+			ti,
+		},
+	}
+	markUpstreamFailed(ti, run, []string{"extract"}, ExecuteOpts{})
+
+	var buf bytes.Buffer
+	printSummary(&buf, run)
+	if !strings.Contains(buf.String(), "upstream_failed  (upstream extract failed)") {
+		t.Errorf("printSummary() missing upstream detail, got:\n%s", buf.String())
+	}
+}
+
 func TestPrintSummary(t *testing.T) {
 	now := time.Now()
 	run := &Run{
@@ -249,7 +308,7 @@ func TestSnapshot(t *testing.T) {
 	runsDir := t.TempDir()
 	srcDir := filepath.Join("testdata", "sample_project")
 
-	snapshotDir, logDir, dataDir, err := Snapshot(srcDir, runsDir, "test_run_001")
+	snapshotDir, logDir, dataDir, _, err := Snapshot(srcDir, runsDir, "test_run_001", SnapshotOptions{})
 	if err != nil {
 		t.Fatalf("Snapshot() error: %v", err)
 	}
@@ -282,6 +341,44 @@ func TestSnapshot(t *testing.T) {
 	}
 }
 
+func TestSnapshot_Stats(t *testing.T) {
+	srcDir := t.TempDir()
+	var wantBytes int64
+	for i := 0; i < 50; i++ {
+		content := strings.Repeat("x", i*100)
+		path := filepath.Join(srcDir, fmt.Sprintf("dir%d", i%5), fmt.Sprintf("file%d.txt", i))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+		wantBytes += int64(len(content))
+	}
+
+	for _, workers := range []int{1, 0, 16} {
+		snapshotDir, _, _, stats, err := Snapshot(srcDir, t.TempDir(), "stats_test", SnapshotOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Snapshot(workers=%d) error: %v", workers, err)
+		}
+		if stats.Files != 50 || stats.Bytes != wantBytes {
+			t.Errorf("Snapshot(workers=%d) stats = %d files/%d bytes, want 50/%d", workers, stats.Files, stats.Bytes, wantBytes)
+		}
+		got, err := os.ReadFile(filepath.Join(snapshotDir, "dir4", "file49.txt"))
+		if err != nil || len(got) != 4900 {
+			t.Errorf("Snapshot(workers=%d) file49.txt = %d bytes (err %v), want 4900", workers, len(got), err)
+		}
+	}
+}
+
+func TestCopyFiles_ReturnsFirstError(t *testing.T) {
+	dst := t.TempDir()
+	jobs := []copyJob{
+		{src: filepath.Join(dst, "missing1"), dst: filepath.Join(dst, "a")},
+		{src: filepath.Join(dst, "missing2"), dst: filepath.Join(dst, "b")},
+		{src: filepath.Join(dst, "missing3"), dst: filepath.Join(dst, "c")},
+	}
+	if err := copyFiles(jobs, 4); err == nil {
+		t.Error("copyFiles() with missing sources = nil, want error")
+	}
+}
+
 func TestSnapshot_SkipsDirs(t *testing.T) {
 	// Create a source dir with skippable directories
 	srcDir := t.TempDir()
@@ -292,7 +389,7 @@ func TestSnapshot_SkipsDirs(t *testing.T) {
 	os.WriteFile(filepath.Join(srcDir, "pit.toml"), []byte("[dag]\nname = \"test\"\n"), 0o644)
 
 	runsDir := t.TempDir()
-	snapshotDir, _, _, err := Snapshot(srcDir, runsDir, "skip_test")
+	snapshotDir, _, _, _, err := Snapshot(srcDir, runsDir, "skip_test", SnapshotOptions{})
 	if err != nil {
 		t.Fatalf("Snapshot() error: %v", err)
 	}
@@ -340,7 +437,7 @@ func TestCopyDirContents(t *testing.T) {
 	os.MkdirAll(filepath.Join(srcDir, "subdir"), 0o755)
 	os.WriteFile(filepath.Join(srcDir, "subdir", "nested.txt"), []byte("nested"), 0o644)
 
-	if err := copyDirContents(srcDir, dstDir); err != nil {
+	if _, err := copyDirContents(srcDir, dstDir, "data", SnapshotOptions{}); err != nil {
 		t.Fatalf("copyDirContents() error: %v", err)
 	}
 
@@ -370,7 +467,7 @@ func TestCopyDirContents_EmptySource(t *testing.T) {
 	srcDir := t.TempDir()
 	dstDir := t.TempDir()
 
-	if err := copyDirContents(srcDir, dstDir); err != nil {
+	if _, err := copyDirContents(srcDir, dstDir, "data", SnapshotOptions{}); err != nil {
 		t.Fatalf("copyDirContents() error: %v", err)
 	}
 
@@ -450,6 +547,68 @@ func TestCleanupArtifacts_KeepLogsAndData(t *testing.T) {
 	}
 }
 
+func TestCleanupArtifacts_LogsFailedOnly(t *testing.T) {
+	runDir := t.TempDir()
+	mkRunDirs(t, runDir)
+	logDir := filepath.Join(runDir, "logs")
+	for _, name := range []string{"extract.log", "load.log", "snapshot.log"} {
+		os.WriteFile(filepath.Join(logDir, name), []byte("output"), 0o644)
+	}
+	tasks := []*TaskInstance{
+		{Name: "extract", Status: StatusSuccess},
+		{Name: "load", Status: StatusFailed},
+		{Name: "report", Status: StatusUpstreamFailed},
+	}
+
+	if err := cleanupArtifacts(runDir, []string{"logs_failed_only"}); err != nil {
+		t.Fatalf("cleanupArtifacts() error: %v", err)
+	}
+	if err := pruneSuccessfulLogs(logDir, tasks); err != nil {
+		t.Fatalf("pruneSuccessfulLogs() error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(logDir, "extract.log")); err == nil {
+		t.Error("extract.log (successful task) should be removed")
+	}
+	for _, name := range []string{"load.log", "snapshot.log"} {
+		if _, err := os.Stat(filepath.Join(logDir, name)); err != nil {
+			t.Errorf("%s should survive cleanup", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(runDir, "project")); err == nil {
+		t.Error("project dir should be removed")
+	}
+}
+
+func TestWriteRunManifest(t *testing.T) {
+	runDir := t.TempDir()
+	started := time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC)
+	run := &Run{
+		ID: "20260307_060000.000_daily", DAGName: "daily", Status: StatusFailed,
+		StartedAt: started, EndedAt: started.Add(time.Minute),
+		Tasks: []*TaskInstance{
+			{Name: "extract", Status: StatusFailed, Attempt: 2, StartedAt: started, EndedAt: started.Add(time.Minute), Error: fmt.Errorf("exit status 1")},
+			{Name: "load", Status: StatusUpstreamFailed, FailedUpstream: []string{"extract"}},
+		},
+	}
+
+	if err := writeRunManifest(runDir, run); err != nil {
+		t.Fatalf("writeRunManifest() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(runDir, runManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"dag_name": "daily"`, `"error": "exit status 1"`, `"failed_upstream": [`, `"attempts": 2`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("run.json missing %s:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), `"started_at": null`) {
+		t.Errorf("unstarted task should omit started_at:\n%s", data)
+	}
+}
+
 // mkRunDirs creates the three standard run subdirectories with dummy files.
 func mkRunDirs(t *testing.T, runDir string) {
 	t.Helper()
@@ -468,3 +627,18 @@ func taskNames(tasks []*TaskInstance) []string {
 	}
 	return names
 }
+
+func TestDBTProfilesInput(t *testing.T) {
+	dbt := &config.DBTConfig{Profile: "warehouse", Target: "prod", Connection: "prod_db"}
+
+	in := dbtProfilesInput("sales", dbt, &config.TaskConfig{Name: "run"}, "ODBC Driver 18 for SQL Server")
+	if in.Target != "prod" || in.Connection != "prod_db" || in.Profile != "warehouse" || in.DAGName != "sales" {
+		t.Errorf("dbtProfilesInput() without overrides = %+v", in)
+	}
+
+	tc := &config.TaskConfig{Name: "run_staging", DBTTarget: "staging", DBTConnection: "staging_db"}
+	in = dbtProfilesInput("sales", dbt, tc, "")
+	if in.Target != "staging" || in.Connection != "staging_db" || in.Profile != "warehouse" {
+		t.Errorf("dbtProfilesInput() with overrides = %+v, want staging target and connection", in)
+	}
+}
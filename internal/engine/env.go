@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// taskEnv builds the environment a task process starts with from pit's own
+// environment, applying policies in order: the workspace [env], the DAG's
+// [dag.env], then the task's. They combine: allow and deny lists are
+// merged, a later inherit overrides an earlier one, and later set entries
+// win. Nil policies are skipped. PIT_* variables are added by the caller
+// afterwards.
+func taskEnv(base []string, policies ...*config.EnvConfig) []string {
+	var p config.EnvConfig
+	set := map[string]string{}
+	applied := false
+	for _, e := range policies {
+		if e == nil {
+			continue
+		}
+		applied = true
+		if e.Inherit != "" {
+			p.Inherit = e.Inherit
+		}
+		p.Allow = append(p.Allow, e.Allow...)
+		p.Deny = append(p.Deny, e.Deny...)
+		for k, v := range e.Set {
+			set[k] = v
+		}
+	}
+	if !applied {
+		return base
+	}
+
+	env := make([]string, 0, len(base)+len(set))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if matchesEnvName(p.Deny, name) {
+			continue
+		}
+		if len(p.Allow) > 0 || p.Inherit == "none" {
+			if !matchesEnvName(p.Allow, name) {
+				continue
+			}
+		}
+		env = append(env, kv)
+	}
+	names := make([]string, 0, len(set))
+	for k := range set {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		env = append(env, k+"="+set[k])
+	}
+	return env
+}
+
+// expandEnvRefs returns policies with ${secret:...} and ${param:...}
+// references in their set values resolved for run. Policies without
+// references are returned as they are; the others are copied, so the
+// config is never modified.
+func expandEnvRefs(policies []*config.EnvConfig, run *Run) ([]*config.EnvConfig, error) {
+	out := make([]*config.EnvConfig, len(policies))
+	for i, e := range policies {
+		out[i] = e
+		if e == nil {
+			continue
+		}
+		var set map[string]string
+		for name, value := range e.Set {
+			if !refPattern.MatchString(value) {
+				continue
+			}
+			expanded, err := expandRefs(value, run)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %w", name, err)
+			}
+			if set == nil {
+				set = make(map[string]string, len(e.Set))
+				for k, v := range e.Set {
+					set[k] = v
+				}
+			}
+			set[name] = expanded
+		}
+		if set != nil {
+			cp := *e
+			cp.Set = set
+			out[i] = &cp
+		}
+	}
+	return out, nil
+}
+
+// matchesEnvName reports whether name matches any of the patterns.
+// Environment variable names are case-insensitive on Windows.
+func matchesEnvName(patterns []string, name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, p := range patterns {
+		if runtime.GOOS == "windows" {
+			p = strings.ToUpper(p)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestTaskEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "HOME=/home/pit", "AWS_ACCESS_KEY_ID=AKIA", "AWS_REGION=eu-west-1", "TOKEN=secret"}
+	tests := []struct {
+		name      string
+		workspace *config.EnvConfig
+		dag       *config.EnvConfig
+		task      *config.EnvConfig
+		want      []string
+	}{
+		{
+			name: "no policy",
+			want: base,
+		},
+		{
+			name:      "deny",
+			workspace: &config.EnvConfig{Deny: []string{"AWS_*", "TOKEN"}},
+			want:      []string{"PATH=/usr/bin", "HOME=/home/pit"},
+		},
+		{
+			name:      "allow",
+			workspace: &config.EnvConfig{Allow: []string{"PATH", "AWS_*"}},
+			want:      []string{"PATH=/usr/bin", "AWS_ACCESS_KEY_ID=AKIA", "AWS_REGION=eu-west-1"},
+		},
+		{
+			name:      "deny wins over allow",
+			workspace: &config.EnvConfig{Allow: []string{"AWS_*"}, Deny: []string{"AWS_ACCESS_KEY_ID"}},
+			want:      []string{"AWS_REGION=eu-west-1"},
+		},
+		{
+			name:      "clean with additions",
+			workspace: &config.EnvConfig{Inherit: "none", Set: map[string]string{"TZ": "UTC", "LANG": "C"}},
+			want:      []string{"LANG=C", "TZ=UTC"},
+		},
+		{
+			name:      "task widens workspace allow",
+			workspace: &config.EnvConfig{Inherit: "none", Allow: []string{"PATH"}},
+			task:      &config.EnvConfig{Allow: []string{"AWS_REGION"}},
+			want:      []string{"PATH=/usr/bin", "AWS_REGION=eu-west-1"},
+		},
+		{
+			name:      "task keeps workspace deny",
+			workspace: &config.EnvConfig{Deny: []string{"TOKEN"}},
+			task:      &config.EnvConfig{Allow: []string{"TOKEN", "HOME"}},
+			want:      []string{"HOME=/home/pit"},
+		},
+		{
+			name:      "task overrides inherit and set",
+			workspace: &config.EnvConfig{Inherit: "none", Set: map[string]string{"MODE": "dev"}},
+			task:      &config.EnvConfig{Inherit: "all", Deny: []string{"AWS_*", "TOKEN"}, Set: map[string]string{"MODE": "prod"}},
+			want:      []string{"PATH=/usr/bin", "HOME=/home/pit", "MODE=prod"},
+		},
+		{
+			name:      "dag between workspace and task",
+			workspace: &config.EnvConfig{Inherit: "none", Set: map[string]string{"MODE": "dev", "TZ": "UTC"}},
+			dag:       &config.EnvConfig{Allow: []string{"PATH"}, Set: map[string]string{"MODE": "staging", "REGION": "eu"}},
+			task:      &config.EnvConfig{Set: map[string]string{"MODE": "prod"}},
+			want:      []string{"PATH=/usr/bin", "MODE=prod", "REGION=eu", "TZ=UTC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taskEnv(base, tt.workspace, tt.dag, tt.task)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("taskEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandEnvRefs(t *testing.T) {
+	run := &Run{DAGName: "test", Params: map[string]string{"day": "2026-03-01"}, SecretsResolver: httpSecrets{"api_key": "k1", "db.password": "pw"}}
+	dagEnv := &config.EnvConfig{Set: map[string]string{"API_KEY": "${secret:api_key}", "MODE": "prod"}}
+	taskEnvCfg := &config.EnvConfig{Set: map[string]string{"DB_PASSWORD": "${secret:db.password}", "DAY": "day=${param:day}"}}
+	plain := &config.EnvConfig{Deny: []string{"AWS_*"}}
+
+	got, err := expandEnvRefs([]*config.EnvConfig{nil, plain, dagEnv, taskEnvCfg}, run)
+	if err != nil {
+		t.Fatalf("expandEnvRefs() error: %v", err)
+	}
+	if got[0] != nil || got[1] != plain {
+		t.Error("policies without references should be returned as they are")
+	}
+	want := map[string]string{"API_KEY": "k1", "MODE": "prod"}
+	if !reflect.DeepEqual(got[2].Set, want) {
+		t.Errorf("dag set = %v, want %v", got[2].Set, want)
+	}
+	want = map[string]string{"DB_PASSWORD": "pw", "DAY": "day=2026-03-01"}
+	if !reflect.DeepEqual(got[3].Set, want) {
+		t.Errorf("task set = %v, want %v", got[3].Set, want)
+	}
+	if dagEnv.Set["API_KEY"] != "${secret:api_key}" {
+		t.Error("expandEnvRefs() modified the config")
+	}
+
+	_, err = expandEnvRefs([]*config.EnvConfig{{Set: map[string]string{"TOKEN": "${secret:missing}"}}}, run)
+	if err == nil || !strings.HasPrefix(err.Error(), "env TOKEN: ") {
+		t.Errorf("expandEnvRefs(missing secret) error = %v, want one naming TOKEN", err)
+	}
+}
@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for telling failures apart with errors.Is. Errors from
+// Execute and task errors in a Run are tagged with one of them; their
+// messages are unchanged.
+var (
+	// ErrInvalidDAG marks a DAG that cannot run as configured: a dependency
+	// cycle, an unknown task name, or transform models that do not compile.
+	ErrInvalidDAG = errors.New("invalid DAG")
+	// ErrSnapshot marks a failure to copy the project into the run
+	// directory, seed its data directory, or write-protect the snapshot.
+	ErrSnapshot = errors.New("snapshot failed")
+	// ErrSecrets marks a failure to load the secrets file or directory.
+	ErrSecrets = errors.New("loading secrets")
+	// ErrSDKServer marks a failure to start the run's SDK server.
+	ErrSDKServer = errors.New("starting SDK server")
+	// ErrInfrastructure marks a failure of the host rather than the DAG,
+	// before any task started: copying the snapshot, creating or seeding
+	// the data directory, loading secrets, or starting the SDK server. The
+	// same run may succeed when tried again, e.g. after an NFS blip.
+	ErrInfrastructure = errors.New("infrastructure failure")
+	// ErrRunner marks a task whose runner or script could not be resolved.
+	ErrRunner = errors.New("resolving runner")
+	// ErrTimeout marks a task attempt killed by the task's timeout.
+	ErrTimeout = errors.New("timed out")
+	// ErrTaskFailed matches every TaskError.
+	ErrTaskFailed = errors.New("task failed")
+)
+
+// TaskError is the failure of one task in a run, as returned by Run.Err.
+type TaskError struct {
+	DAG     string
+	Task    string // "name[i]" for an instance of a mapped task
+	Attempt int    // the attempt that failed (0 if none started)
+	Err     error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %q: %v", e.Task, e.Err)
+}
+
+func (e *TaskError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrTaskFailed.
+func (e *TaskError) Is(target error) bool { return target == ErrTaskFailed }
+
+// Err returns nil when no task failed, and otherwise a *TaskError for each
+// failed task, mapped instances included, joined with errors.Join. Tasks
+// skipped because an upstream task failed are left out.
+func (r *Run) Err() error {
+	var errs []error
+	for _, ti := range expandedTasks(r.Tasks) {
+		if ti.Status == StatusFailed && ti.Error != nil && len(ti.Instances) == 0 {
+			errs = append(errs, &TaskError{DAG: r.DAGName, Task: ti.Name, Attempt: ti.Attempt, Err: ti.Error})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// kindError tags err with a sentinel without changing its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// tagError returns err tagged with kind, so errors.Is(err, kind) holds.
+func tagError(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// infraError tags err with kind and with ErrInfrastructure.
+func infraError(kind, err error) error {
+	return tagError(ErrInfrastructure, tagError(kind, err))
+}
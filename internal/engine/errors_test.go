@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestTagError(t *testing.T) {
+	exitErr := &exec.ExitError{}
+	err := tagError(ErrRunner, fmt.Errorf("running: %w", exitErr))
+	if err.Error() != "running: "+exitErr.Error() {
+		t.Errorf("Error() = %q, want the wrapped message unchanged", err)
+	}
+	if !errors.Is(err, ErrRunner) || errors.Is(err, ErrSnapshot) {
+		t.Errorf("errors.Is(ErrRunner, ErrSnapshot) = %v, %v; want true, false", errors.Is(err, ErrRunner), errors.Is(err, ErrSnapshot))
+	}
+	var target *exec.ExitError
+	if !errors.As(err, &target) {
+		t.Error("errors.As() did not find the wrapped *exec.ExitError")
+	}
+}
+
+func TestTopoSort_CycleIsInvalidDAG(t *testing.T) {
+	tasks := []*TaskInstance{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+	if _, err := topoSort(tasks); !errors.Is(err, ErrInvalidDAG) {
+		t.Errorf("topoSort() error = %v, want ErrInvalidDAG", err)
+	}
+}
+
+func TestRun_Err(t *testing.T) {
+	run := &Run{DAGName: "etl", Tasks: []*TaskInstance{
+		{Name: "extract", Status: StatusSuccess},
+		{Name: "load", Status: StatusFailed, Attempt: 2, Error: timeoutError(time.Minute, context.DeadlineExceeded)},
+		{Name: "report", Status: StatusUpstreamFailed, Error: errors.New("upstream load failed")},
+	}}
+	err := run.Err()
+	if !errors.Is(err, ErrTaskFailed) || !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Err() = %v, want ErrTaskFailed and ErrTimeout", err)
+	}
+	var te *TaskError
+	if !errors.As(err, &te) {
+		t.Fatalf("Err() = %v, want a *TaskError", err)
+	}
+	if te.DAG != "etl" || te.Task != "load" || te.Attempt != 2 {
+		t.Errorf("TaskError = %+v, want etl/load attempt 2", te)
+	}
+
+	mapped := &TaskInstance{Name: "fan", Status: StatusFailed, Error: errors.New("1 of 2 instances failed"), Instances: []*TaskInstance{
+		{Name: "fan[0]", Status: StatusSuccess},
+		{Name: "fan[1]", Status: StatusFailed, Error: errors.New("exit status 1")},
+	}}
+	run = &Run{DAGName: "etl", Tasks: []*TaskInstance{mapped}}
+	if !errors.As(run.Err(), &te) || te.Task != "fan[1]" {
+		t.Errorf("Err() of a mapped task = %v, want the failed instance fan[1]", run.Err())
+	}
+
+	run = &Run{Tasks: []*TaskInstance{{Name: "ok", Status: StatusSuccess}}}
+	if err := run.Err(); err != nil {
+		t.Errorf("Err() of a successful run = %v, want nil", err)
+	}
+}
+
+func TestExecute_InfrastructureError(t *testing.T) {
+	projectDir := t.TempDir()
+	os.WriteFile(filepath.Join(projectDir, "pit.toml"), []byte("[dag]\nname = \"infra\"\n\n[[tasks]]\nname = \"a\"\nscript = \"a.sh\"\n"), 0o644)
+	os.WriteFile(filepath.Join(projectDir, "a.sh"), []byte("true\n"), 0o755)
+	cfg, err := config.Load(filepath.Join(projectDir, "pit.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Execute(context.Background(), cfg, ExecuteOpts{
+		RunsDir:     t.TempDir(),
+		SecretsPath: filepath.Join(projectDir, "missing.toml"),
+		Output:      io.Discard,
+	})
+	if !errors.Is(err, ErrInfrastructure) || !errors.Is(err, ErrSecrets) {
+		t.Errorf("Execute() with a missing secrets file = %v, want ErrInfrastructure and ErrSecrets", err)
+	}
+	if errors.Is(err, ErrSnapshot) {
+		t.Errorf("Execute() error %v also matches ErrSnapshot", err)
+	}
+}
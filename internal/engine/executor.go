@@ -2,10 +2,17 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +21,7 @@ import (
 
 	"github.com/druarnfield/pit/internal/config"
 	"github.com/druarnfield/pit/internal/gitrepo"
+	"github.com/druarnfield/pit/internal/lineage"
 	"github.com/druarnfield/pit/internal/loader"
 	"github.com/druarnfield/pit/internal/loghub"
 	"github.com/druarnfield/pit/internal/runner"
@@ -24,20 +32,35 @@ import (
 
 // ExecuteOpts configures a DAG execution.
 type ExecuteOpts struct {
-	RunsDir       string           // directory for run snapshots (default: "runs")
-	RepoCacheDir  string           // directory for persistent git clones (default: "repo_cache")
-	TaskName      string           // if set, only run this single task
-	Verbose       bool             // stream task output to stdout
-	Concurrency   int              // max parallel tasks (0 = unlimited)
-	SecretsPath   string           // path to secrets.toml (optional, empty = no secrets)
-	AgeIdentity   string           // path to age identity file (optional, for encrypted secrets)
-	DataSeedDir   string           // if set, copy contents into data dir before execution
-	DBTDriver     string           // ODBC driver for dbt profiles (default: config.DefaultDBTDriver)
-	KeepArtifacts []string         // which run subdirs to keep after completion (default: all)
-	MetaStore     MetadataRecorder // nil = no metadata tracking
-	Trigger       string           // trigger source: "manual", "cron", "ftp_watch", "webhook"
-	LogHub        *loghub.Hub      // nil = no live log streaming
-	RunID         string           // if set, use this instead of generating (for webhook streaming)
+	RunsDir          string                         // directory for run snapshots (default: "runs")
+	RepoCacheDir     string                         // directory for persistent git clones (default: "repo_cache")
+	TaskName         string                         // if set, only run this single task
+	Verbose          bool                           // stream task output to stdout
+	Concurrency      int                            // max parallel tasks (0 = dag.max_parallel_tasks, unlimited if unset)
+	SecretsPath      string                         // path to secrets.toml or a directory of secrets files (optional, empty = no secrets)
+	AgeIdentity      string                         // path to age identity file (optional, for encrypted secrets)
+	DataSeedDir      string                         // if set, copy contents into data dir before execution
+	SeedOrigins      map[string]SeedOrigin          // where files in DataSeedDir came from, by slash-separated relative path (missing = "seed")
+	DBTDriver        string                         // ODBC driver for dbt profiles (default: config.DefaultDBTDriver)
+	KeepArtifacts    []string                       // which run subdirs to keep after completion (default: all)
+	MetaStore        MetadataRecorder               // nil = no metadata tracking
+	Trigger          string                         // trigger source: "manual", "cron", "ftp_watch", "webhook"
+	LogHub           *loghub.Hub                    // nil = no live log streaming
+	RunID            string                         // if set, use this instead of generating (for webhook streaming)
+	MinFreeSpace     int64                          // bytes to leave free on the runs volume after snapshotting (0 = DefaultMinFreeSpace, < 0 = no check)
+	SDKSocketDir     string                         // directory for per-run SDK sockets, as <dir>/<run_id>.sock ("" = sdk.sock in the run directory)
+	SnapshotWorkers  int                            // concurrent file copies for the snapshot (0 = DefaultSnapshotWorkers, 1 = sequential)
+	SnapshotSymlinks string                         // symlink policy for the snapshot: "skip" (default), "follow", or "preserve"
+	SnapshotReadOnly bool                           // write-protect the snapshot while tasks run (also set per DAG by read_only_snapshot)
+	ArtifactStore    *config.ArtifactStoreConfig    // nil = artifacts stay in the runs dir
+	Params           map[string]string              // run parameters, overriding [dag.params]
+	TriggerFiles     []string                       // files delivered by the trigger, for map_over = "trigger.files"
+	OnStall          StallFunc                      // called when a task exceeds its stall_timeout
+	Loader           *config.LoaderConfig           // workspace defaults for loads, below [dag.sql] (nil = driver defaults)
+	Env              *config.EnvConfig              // workspace environment policy for task processes (nil = inherit everything)
+	Lineage          *config.LineageConfig          // OpenLineage endpoint for run events (nil = none)
+	Plugins          map[string]config.PluginConfig // external runners and SDK methods by plugin name
+	Output           io.Writer                      // run summary and verbose task output (nil = os.Stdout)
 }
 
 // Execute runs a DAG to completion.
@@ -45,12 +68,18 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 	if opts.RunsDir == "" {
 		opts.RunsDir = "runs"
 	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
 
 	runID := opts.RunID
 	if runID == "" {
 		runID = GenerateRunID(cfg.DAG.Name)
 	}
 
+	// Remove credential files left behind by runs that were killed.
+	sweepCredentials(os.TempDir())
+
 	// Resolve the project source directory. For git-backed projects the repo
 	// is cloned / updated in a persistent cache and that cache becomes the
 	// source for the run snapshot. For local projects cfg.Dir() is used as
@@ -67,31 +96,71 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 		projectDir = cacheDir
 	}
 
-	// Snapshot the project
-	snapshotDir, logDir, dataDir, err := Snapshot(projectDir, opts.RunsDir, runID)
+	// Snapshot the project; fails fast if the runs volume cannot hold it.
+	// With snapshot = false tasks run in the project directory itself and
+	// only the logs and data directories are per run.
+	var snapshotDir, logDir, dataDir string
+	var snapStats SnapshotStats
+	var err error
+	if cfg.DAG.InPlace() {
+		snapshotDir, logDir, dataDir, err = PrepareInPlace(projectDir, opts.RunsDir, runID)
+	} else {
+		snapshotDir, logDir, dataDir, snapStats, err = Snapshot(projectDir, opts.RunsDir, runID, SnapshotOptions{
+			Workers:      opts.SnapshotWorkers,
+			Symlinks:     opts.SnapshotSymlinks,
+			MinFreeSpace: opts.MinFreeSpace,
+		})
+	}
 	if err != nil {
-		return nil, fmt.Errorf("snapshot: %w", err)
+		return nil, infraError(ErrSnapshot, fmt.Errorf("snapshot: %w", err))
+	}
+	runDir := filepath.Dir(logDir)
+
+	// Place the data directory on the DAG's data_dir share if configured
+	if cfg.DAG.DataDir != "" {
+		if dataDir, err = externalDataDir(cfg.DAG.DataDir, runID, dataDir); err != nil {
+			return nil, infraError(ErrSnapshot, err)
+		}
 	}
 
 	// Seed data directory with files if configured
+	inputs := &runInputs{}
 	if opts.DataSeedDir != "" {
-		if err := copyDirContents(opts.DataSeedDir, dataDir); err != nil {
-			return nil, fmt.Errorf("seeding data dir: %w", err)
+		cs, err := copyDirContents(opts.DataSeedDir, dataDir, "data", SnapshotOptions{Workers: 1, Symlinks: opts.SnapshotSymlinks})
+		if err != nil {
+			return nil, infraError(ErrSnapshot, fmt.Errorf("seeding data dir: %w", err))
+		}
+		if err := appendSnapshotLog(logDir, cs.skipped); err != nil {
+			return nil, err
+		}
+		snapStats.SkippedLinks += len(cs.skipped)
+
+		if cfg.DAG.Checksums != nil && cfg.DAG.Checksums.VerifyInputs {
+			if _, err := verifySeededChecksums(dataDir); err != nil {
+				return nil, tagError(ErrSnapshot, fmt.Errorf("verifying seeded files: %w", err))
+			}
+		}
+		if err := inputs.recordSeededInputs(opts.DataSeedDir, dataDir, opts.SeedOrigins, time.Now()); err != nil {
+			return nil, infraError(ErrSnapshot, fmt.Errorf("recording seeded inputs: %w", err))
 		}
 	}
 
-	// Load secrets — detect encrypted (.age) vs plaintext
+	// Load secrets — detect encrypted (.age) vs plaintext; a directory
+	// may hold both
 	var store *secrets.Store
 	if opts.SecretsPath != "" {
 		var err error
-		if strings.HasSuffix(opts.SecretsPath, ".age") {
+		if info, statErr := os.Stat(opts.SecretsPath); statErr == nil && info.IsDir() {
+			store, err = secrets.LoadDir(opts.SecretsPath, opts.AgeIdentity, "")
+		} else if strings.HasSuffix(opts.SecretsPath, ".age") {
 			store, err = secrets.LoadEncrypted(opts.SecretsPath, opts.AgeIdentity, "")
 		} else {
 			store, err = secrets.Load(opts.SecretsPath)
 		}
 		if err != nil {
-			return nil, fmt.Errorf("loading secrets: %w", err)
+			return nil, infraError(ErrSecrets, fmt.Errorf("loading secrets: %w", err))
 		}
+		store.SetScopes(cfg.DAG.Name, cfg.DAG.SecretScopes)
 	}
 
 	// Wire audit callback if metadata store is available
@@ -103,20 +172,49 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 		}
 	}
 
-	socketHint := filepath.Join(os.TempDir(), fmt.Sprintf("pit-%d.sock", os.Getpid()))
+	// Each run gets its own SDK socket, so concurrent runs in one process
+	// cannot replace each other's. A crashed run's socket in a shared
+	// socket dir is cleared by the next run.
+	socketHint := sdkSocketPath(runDir, opts.SDKSocketDir, runID)
+	if runtime.GOOS != "windows" {
+		switch dir := filepath.Dir(socketHint); {
+		case opts.SDKSocketDir != "" && dir == filepath.Clean(opts.SDKSocketDir):
+			os.MkdirAll(dir, 0o700)
+			sweepStaleSockets(dir, "*.sock")
+		case dir == filepath.Clean(os.TempDir()):
+			sweepStaleSockets(dir, "pit-*.sock")
+		}
+	}
 	sdkServer, err := sdk.NewServer(socketHint, store, cfg.DAG.Name)
 	if err != nil {
-		return nil, fmt.Errorf("starting SDK server: %w", err)
+		return nil, infraError(ErrSDKServer, fmt.Errorf("starting SDK server: %w", err))
 	}
 
-	// Register the load_data handler for Python SDK → Go bulk load
-	sdkServer.RegisterHandler("load_data", makeLoadDataHandler(store, cfg.DAG.Name, dataDir))
+	// Collect the datasets the run reads and writes for lineage events
+	datasets := &runDatasets{}
+	if cfg.DAG.FTPWatch != nil {
+		datasets.addTriggerFiles(cfg.DAG.FTPWatch, opts.TriggerFiles)
+	}
 
-	// Register FTP handlers for Python SDK → Go FTP operations
-	sdkServer.RegisterHandler("ftp_list", makeFTPListHandler(store, cfg.DAG.Name))
-	sdkServer.RegisterHandler("ftp_download", makeFTPDownloadHandler(store, cfg.DAG.Name, dataDir))
-	sdkServer.RegisterHandler("ftp_upload", makeFTPUploadHandler(store, cfg.DAG.Name, dataDir))
-	sdkServer.RegisterHandler("ftp_move", makeFTPMoveHandler(store, cfg.DAG.Name))
+	// Register the SDK methods pit provides itself
+	defaults := resolveLoadDefaults(cfg, opts.Loader)
+	outputs := &taskOutputs{}
+	produced := &producedOutputs{}
+	temps := newTempTables(defaults.identCase)
+	registerBuiltinHandlers(sdkServer, cfg, store, dataDir, defaults, sdkRunState{
+		inputs:   inputs,
+		datasets: datasets,
+		outputs:  outputs,
+		produced: produced,
+		temps:    temps,
+	})
+	defer temps.dropAll()
+
+	// Register SDK methods provided by plugins, after the built-ins they may not replace
+	if err := registerPluginHandlers(sdkServer, opts.Plugins, cfg.DAG.Name, runID, dataDir); err != nil {
+		sdkServer.Shutdown()
+		return nil, err
+	}
 
 	socketPath := sdkServer.Addr()
 	sdkCtx, sdkCancel := context.WithCancel(context.Background())
@@ -149,23 +247,37 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 		compileResult, err := transform.Compile(modelsDir, cfg.DAG.Transform.Dialect, compiledDir, cfg.Tasks)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "transform compilation failed: %v\n", err)
-			return nil, fmt.Errorf("compiling transform models: %w", err)
+			return nil, tagError(ErrInvalidDAG, fmt.Errorf("compiling transform models: %w", err))
 		}
 
 		cfg.Tasks = buildTasksFromCompileResult(compileResult, cfg.Tasks)
 	}
 
+	// Credential files written during the run (dbt profiles) live under
+	// one directory that is removed when Execute returns, however the
+	// tasks ended.
+	creds := newJanitor(runID)
+	defer creds.cleanup()
+
 	// Build Run from config
 	run := &Run{
 		ID:          runID,
 		DAGName:     cfg.DAG.Name,
 		ProjectDir:  projectDir,
+		RunDir:      runDir,
 		SnapshotDir: snapshotDir,
 		LogDir:      logDir,
 		DataDir:     dataDir,
+		Snapshot:    snapStats,
+		InPlace:     cfg.DAG.InPlace(),
 		Status:      StatusRunning,
 		StartedAt:   time.Now(),
 		SocketPath:  socketPath,
+		Params:      mergeParams(cfg.DAG.Params, opts.Params),
+		Outputs:     outputs,
+		Produced:    produced,
+		Datasets:    datasets,
+		janitor:     creds,
 	}
 	// Only assign when store is non-nil. Assigning a typed nil *secrets.Store
 	// directly to the SecretsResolver interface produces a non-nil interface
@@ -174,6 +286,16 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 	if store != nil {
 		run.SecretsResolver = store
 	}
+	if opts.Verbose {
+		run.console = newConsole(opts.Output, consoleBufferSize)
+		defer run.console.close()
+	}
+	if run.LogicalDate, err = logicalDate(run.Params, run.StartedAt, cfg.DAG.Timezone); err != nil {
+		return nil, err
+	}
+	if run.loc, err = dagLocation(cfg.DAG.Timezone); err != nil {
+		return nil, err
+	}
 
 	// Activate run in log hub so SSE clients can discover it
 	if opts.LogHub != nil {
@@ -181,31 +303,120 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 	}
 
 	for _, tc := range cfg.Tasks {
+		runIf, skipIf, err := parseTaskConditions(tc)
+		if err != nil {
+			return nil, err
+		}
 		ti := &TaskInstance{
-			Name:       tc.Name,
-			Script:     tc.Script,
-			Runner:     tc.Runner,
-			Status:     StatusPending,
-			DependsOn:  tc.DependsOn,
-			MaxRetries: tc.Retries,
-			RetryDelay: tc.RetryDelay.Duration,
-			Timeout:    tc.Timeout.Duration,
+			Name:         tc.Name,
+			Script:       tc.Script,
+			Runner:       tc.Runner,
+			Status:       StatusPending,
+			DependsOn:    tc.DependsOn,
+			TriggerRule:  tc.TriggerRule,
+			MaxRetries:   tc.Retries,
+			RetryDelay:   tc.RetryDelay.Duration,
+			Timeout:      tc.Timeout.Duration,
+			Limits:       runner.Limits{MemoryBytes: int64(tc.MemoryLimit), CPUs: tc.CPULimit, Offline: taskOffline(cfg.DAG, tc)},
+			Env:          tc.Env,
+			RunIf:        runIf,
+			SkipIf:       skipIf,
+			MapOver:      tc.MapOver,
+			Freshness:    tc.Freshness.Duration,
+			StallTimeout: tc.StallTimeout.Duration,
+			StallKill:    tc.StallAction == StallKill,
 		}
 		run.Tasks = append(run.Tasks, ti)
 	}
 
+	// Load recent task durations before this run adds to the history
+	var baseline map[string]DurationStats
+	if opts.MetaStore != nil {
+		var err error
+		if baseline, err = loadDurationBaseline(opts.MetaStore, cfg.DAG.Name, cfg.DAG.AnomalyWindow); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: loading task duration history failed: %v\n", err)
+		}
+	}
+
 	// Record run start in metadata store
 	if opts.MetaStore != nil {
 		trigger := opts.Trigger
 		if trigger == "" {
 			trigger = "manual"
 		}
-		runDir := filepath.Dir(snapshotDir)
 		if err := opts.MetaStore.RecordRunStart(run.ID, run.DAGName, string(run.Status), runDir, trigger, run.StartedAt); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
 		}
+		if !cfg.DAG.InPlace() {
+			if err := opts.MetaStore.RecordSnapshot(run.ID, snapStats.Files, snapStats.Bytes, snapStats.Duration); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+			}
+		}
 	}
 
+	if opts.MetaStore != nil {
+		if err := opts.MetaStore.RecordSDKSocket(run.ID, run.SocketPath); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+		if err := opts.MetaStore.RecordLogicalDate(run.ID, run.LogicalDate); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+
+	// Record the interpreter and tool versions the tasks will run with
+	versions, err := resolveToolVersions(ctx, cfg, projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: resolving tool versions: %v\n", err)
+	}
+	run.Versions = versions
+	if opts.MetaStore != nil && !versions.IsZero() {
+		if err := opts.MetaStore.RecordToolVersions(run.ID, versions.Pit, versions.Python, versions.UV, versions.DBT); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+
+	// Record the code revision the snapshot was taken from
+	revision, err := resolveGitRevision(ctx, projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: resolving git revision: %v\n", err)
+	}
+	run.Revision = revision
+	if cfg.DAG.InPlace() {
+		// The revision is the only record of the code an in-place run used
+		switch {
+		case revision.IsZero():
+			fmt.Fprintf(os.Stderr, "warning: %s runs in place and is not in a git worktree; no code revision is recorded\n", cfg.DAG.Name)
+		case revision.Dirty:
+			fmt.Fprintf(os.Stderr, "warning: %s runs in place with uncommitted changes; the recorded revision is %s\n", cfg.DAG.Name, revision)
+		}
+	}
+	if opts.MetaStore != nil && !revision.IsZero() {
+		if err := opts.MetaStore.RecordGitRevision(run.ID, revision.Commit, revision.Branch, revision.Dirty); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+
+	// Write-protect the snapshot while tasks run. Write access comes back
+	// before artifacts are cleaned up or uploaded.
+	unlockSnapshot := func() {}
+	if snapshotReadOnly(cfg, opts) {
+		if err := setReadOnly(snapshotDir, true); err != nil {
+			setReadOnly(snapshotDir, false)
+			return nil, tagError(ErrSnapshot, fmt.Errorf("making snapshot read-only: %w", err))
+		}
+		var once sync.Once
+		unlockSnapshot = func() {
+			once.Do(func() {
+				if err := setReadOnly(snapshotDir, false); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: restoring write access to snapshot: %v\n", err)
+				}
+			})
+		}
+		defer unlockSnapshot()
+	}
+
+	emitLineage(opts.Lineage, run, lineage.EventStart, run.StartedAt)
+
 	// Apply DAG-level timeout
 	if cfg.DAG.Timeout.Duration > 0 {
 		var cancel context.CancelFunc
@@ -225,7 +436,7 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 			}
 		}
 		if !found {
-			return nil, fmt.Errorf("task %q not found in DAG %q", opts.TaskName, cfg.DAG.Name)
+			return nil, tagError(ErrInvalidDAG, fmt.Errorf("task %q not found in DAG %q", opts.TaskName, cfg.DAG.Name))
 		}
 
 		// Warn about skipped dependencies
@@ -238,7 +449,7 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 
 		for _, ti := range run.Tasks {
 			if ti.Name == opts.TaskName {
-				executeTask(ctx, ti, run, cfg, opts)
+				runTask(ctx, ti, run, cfg, opts, nil, false)
 				break
 			}
 		}
@@ -252,6 +463,11 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 	}
 
 	run.EndedAt = time.Now()
+	run.console.close() // let verbose output finish before the summary
+	unlockSnapshot()
+	if !keepScratch(opts.KeepArtifacts) {
+		os.Remove(filepath.Join(runDir, scratchDirName)) // only if empty
+	}
 
 	// Determine overall run status
 	run.Status = StatusSuccess
@@ -262,6 +478,44 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 		}
 	}
 
+	// Output assertions are the last gate before consumers see the tables
+	if run.Status == StatusSuccess && ctx.Err() == nil {
+		warned, err := checkOutputAssertions(ctx, run, cfg, opts)
+		run.AssertionWarnings = warned
+		if err != nil {
+			run.Status = StatusFailed
+			run.AssertionError = err
+		}
+	}
+
+	run.Anomalies = detectAnomalies(run, baseline, cfg.DAG.AnomalyFactor)
+	if opts.MetaStore != nil {
+		recordAnomalies(opts.MetaStore, run.ID, run.Anomalies)
+	}
+
+	// Hash the data dir before keep_artifacts can remove it
+	if cfg.DAG.Checksums != nil && cfg.DAG.Checksums.Manifest {
+		if _, err := writeChecksumManifest(runDir, run.DataDir); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+
+	// Record where each input file came from
+	run.Inputs = inputs.list()
+	if err := writeInputManifest(runDir, run.Inputs); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	if opts.MetaStore != nil {
+		for _, f := range run.Inputs {
+			if err := opts.MetaStore.RecordInput(run.ID, f.Path, f.Source, f.Remote, f.Size, f.SHA256, f.FetchedAt); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: input metadata recording failed: %v\n", err)
+				break
+			}
+		}
+	}
+
+	classifyFailures(run)
+
 	// Record run end in metadata store
 	if opts.MetaStore != nil {
 		var errMsg string
@@ -272,22 +526,56 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 					break
 				}
 			}
+			if errMsg == "" && run.AssertionError != nil {
+				errMsg = run.AssertionError.Error()
+			}
 		}
 		if err := opts.MetaStore.RecordRunEnd(run.ID, string(run.Status), run.EndedAt, errMsg); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
 		}
 	}
 
-	// Record declared outputs on success
-	if opts.MetaStore != nil && run.Status == StatusSuccess {
+	// Record declared outputs on success. Outputs a task reported producing
+	// are recorded even if the run later failed, since they were written.
+	if opts.MetaStore != nil {
 		for _, o := range cfg.Outputs {
+			p, ok := run.Produced.get(o.Name)
+			if !ok && run.Status != StatusSuccess {
+				continue
+			}
 			if err := opts.MetaStore.RecordOutput(run.ID, run.DAGName, o.Name, o.Type, o.Location); err != nil {
 				fmt.Fprintf(os.Stderr, "warning: output metadata recording failed: %v\n", err)
+				continue
+			}
+			if ok {
+				if err := opts.MetaStore.RecordOutputProduced(run.ID, o.Name, p.Task, p.Rows, p.At); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: output metadata recording failed: %v\n", err)
+				}
 			}
 		}
 	}
 
-	printSummary(os.Stdout, run)
+	// Registered outputs are datasets too, named by type and location
+	for _, o := range cfg.Outputs {
+		if _, ok := run.Produced.get(o.Name); ok {
+			run.Datasets.addOutput(o.Type, o.Location)
+		}
+	}
+	lineageEvent := lineage.EventComplete
+	if run.Status == StatusFailed {
+		lineageEvent = lineage.EventFail
+	}
+	emitLineage(opts.Lineage, run, lineageEvent, run.EndedAt)
+
+	if len(cfg.DAG.Report) > 0 {
+		reports, err := writeRunReports(runDir, run, cfg.Outputs, cfg.DAG.Report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+		run.Reports = reports
+	}
+
+	printSummary(opts.Output, run)
 
 	// Signal hub that run is complete
 	if opts.LogHub != nil {
@@ -296,10 +584,19 @@ func Execute(ctx context.Context, cfg *config.ProjectConfig, opts ExecuteOpts) (
 
 	// Cleanup artifacts based on keep_artifacts config
 	if len(opts.KeepArtifacts) > 0 {
-		runDir := filepath.Dir(run.SnapshotDir) // parent of project/
 		if err := cleanupArtifacts(runDir, opts.KeepArtifacts); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: artifact cleanup failed: %v\n", err)
 		}
+		if slices.Contains(opts.KeepArtifacts, artifactLogsFailedOnly) && !slices.Contains(opts.KeepArtifacts, "logs") {
+			if err := pruneSuccessfulLogs(run.LogDir, expandedTasks(run.Tasks)); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: artifact cleanup failed: %v\n", err)
+			}
+		}
+	}
+
+	// Ship kept artifacts to long-term storage
+	if opts.ArtifactStore != nil {
+		uploadArtifacts(run, store, opts)
 	}
 
 	return run, nil
@@ -338,7 +635,7 @@ func topoSort(tasks []*TaskInstance) ([][]*TaskInstance, error) {
 			}
 		}
 		if len(level) == 0 {
-			return nil, fmt.Errorf("cycle detected in task dependencies")
+			return nil, tagError(ErrInvalidDAG, fmt.Errorf("cycle detected in task dependencies"))
 		}
 		for _, t := range level {
 			resolved[t.Name] = true
@@ -356,9 +653,16 @@ func topoSort(tasks []*TaskInstance) ([][]*TaskInstance, error) {
 func executeDAG(ctx context.Context, levels [][]*TaskInstance, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts) {
 	// Set up concurrency semaphore
 	var sem chan struct{}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = cfg.DAG.MaxParallelTasks
+	}
 	if opts.Concurrency > 0 {
 		sem = make(chan struct{}, opts.Concurrency)
 	}
+	loc := run.loc
+	if loc == nil {
+		loc = time.Local
+	}
 
 	for _, level := range levels {
 		// Check if context is already cancelled
@@ -377,8 +681,10 @@ func executeDAG(ctx context.Context, levels [][]*TaskInstance, run *Run, cfg *co
 		// Build status snapshot once per level for hasUpstreamFailure checks.
 		run.mu.Lock()
 		statusMap := make(map[string]TaskStatus, len(run.Tasks))
+		upstreamMap := make(map[string][]string, len(run.Tasks))
 		for _, t := range run.Tasks {
 			statusMap[t.Name] = t.Status
+			upstreamMap[t.Name] = t.FailedUpstream
 		}
 		run.mu.Unlock()
 
@@ -386,25 +692,31 @@ func executeDAG(ctx context.Context, levels [][]*TaskInstance, run *Run, cfg *co
 
 		var wg sync.WaitGroup
 		for _, ti := range level {
-			// Check for upstream failures using the pre-built status map
-			if hasUpstreamFailure(ti, statusMap) {
-				run.mu.Lock()
-				ti.Status = StatusUpstreamFailed
-				run.mu.Unlock()
+			// Apply the trigger rule using the pre-built status map
+			upstreamFailed, reason := applyTriggerRule(ti, statusMap)
+			if upstreamFailed {
+				markUpstreamFailed(ti, run, failedUpstream(ti, statusMap, upstreamMap), opts)
+				continue
+			}
+			if reason != "" {
+				markSkipped(ti, run, reason, opts)
+				continue
+			}
+			if reason := conditionSkipReason(ti, conditionLookup(run, opts.Trigger, time.Now(), loc)); reason != "" {
+				markSkipped(ti, run, reason, opts)
+				continue
+			}
+			if reason := freshSkipReason(ti, run, cfg.Outputs, opts.MetaStore, time.Now()); reason != "" {
+				markSkippedAs(ti, run, StatusSkippedFresh, reason, opts)
 				continue
 			}
 
 			wg.Add(1)
 			go func(t *TaskInstance) {
 				defer wg.Done()
-
-				// Acquire semaphore if configured
-				if sem != nil {
-					sem <- struct{}{}
-					defer func() { <-sem }()
-				}
-
-				executeTask(ctx, t, run, cfg, opts, concurrent)
+				// runTask acquires the semaphore if configured; a mapped task
+				// holds no slot itself so its instances can use them.
+				runTask(ctx, t, run, cfg, opts, sem, concurrent)
 			}(ti)
 		}
 		wg.Wait()
@@ -423,6 +735,47 @@ func hasUpstreamFailure(ti *TaskInstance, statusMap map[string]TaskStatus) bool
 	return false
 }
 
+// failedUpstream returns the failed tasks behind ti's failed dependencies,
+// sorted and deduplicated. A failed dependency names itself; an
+// upstream_failed dependency passes on the tasks that caused its own skip.
+func failedUpstream(ti *TaskInstance, statusMap map[string]TaskStatus, upstreamMap map[string][]string) []string {
+	seen := make(map[string]bool)
+	for _, dep := range ti.DependsOn {
+		switch statusMap[dep] {
+		case StatusFailed:
+			seen[dep] = true
+		case StatusUpstreamFailed:
+			for _, name := range upstreamMap[dep] {
+				seen[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// markUpstreamFailed records that ti was skipped because upstream tasks failed.
+func markUpstreamFailed(ti *TaskInstance, run *Run, upstream []string, opts ExecuteOpts) {
+	run.mu.Lock()
+	ti.Status = StatusUpstreamFailed
+	ti.FailedUpstream = upstream
+	ti.Error = fmt.Errorf("upstream %s failed", strings.Join(upstream, ", "))
+	ti.EndedAt = time.Now()
+	endedAt := ti.EndedAt
+	errMsg := ti.Error.Error()
+	run.mu.Unlock()
+
+	if opts.MetaStore != nil {
+		if err := opts.MetaStore.RecordTaskUpstreamFailed(run.ID, ti.Name, upstream, errMsg, endedAt); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+}
+
 // executeTask runs a single task with retries and timeout.
 // The concurrent parameter controls whether verbose output uses line prefixing.
 func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts, concurrent ...bool) {
@@ -449,18 +802,36 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 		}()
 	}
 
-	// Find the task config for load/save handling
+	// A panicking runner fails the task rather than the whole process, so
+	// the task's deferred cleanups and the run's janitor still run.
+	defer func() {
+		if p := recover(); p != nil {
+			run.mu.Lock()
+			ti.Status = StatusFailed
+			ti.Error = fmt.Errorf("task panicked: %v", p)
+			ti.EndedAt = time.Now()
+			run.mu.Unlock()
+			fmt.Fprintf(os.Stderr, "task %s panicked: %v\n%s", ti.Name, p, debug.Stack())
+		}
+	}()
+
+	// Find the task config for built-in task types and dbt overrides.
+	// Mapped instances share their parent's config.
+	cfgName := ti.Name
+	if ti.MapOf != "" {
+		cfgName = ti.MapOf
+	}
 	var tc *config.TaskConfig
 	for i := range cfg.Tasks {
-		if cfg.Tasks[i].Name == ti.Name {
+		if cfg.Tasks[i].Name == cfgName {
 			tc = &cfg.Tasks[i]
 			break
 		}
 	}
 
-	// Handle load/save SQL task types
-	if tc != nil && (tc.Type == "load" || tc.Type == "save") {
-		// Set up log file for load/save tasks
+	// Handle built-in task types: load/save (SQL), sensors, quality checks, and HTTP requests
+	if tc != nil && (tc.Type == "load" || tc.Type == "save" || tc.Type == "sensor" || tc.Type == "quality" || tc.Type == "http") {
+		// Set up log file for built-in tasks
 		logPath := filepath.Join(run.LogDir, ti.Name+".log")
 		logFile, err := os.Create(logPath)
 		if err != nil {
@@ -475,15 +846,9 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 
 		writers := []io.Writer{logFile}
 		if opts.Verbose {
-			isConcurrent := len(concurrent) > 0 && concurrent[0]
-			if isConcurrent {
-				writers = append(writers, &prefixWriter{
-					prefix: []byte("[" + ti.Name + "] "),
-					dest:   os.Stdout,
-				})
-			} else {
-				writers = append(writers, os.Stdout)
-			}
+			console, flush := verboseWriter(run, opts, ti, len(concurrent) > 0 && concurrent[0])
+			defer flush()
+			writers = append(writers, console)
 		}
 		if opts.LogHub != nil {
 			hubWriter := loghub.NewWriter(opts.LogHub, run.ID, run.DAGName, ti.Name, 1)
@@ -494,7 +859,16 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 			logWriter = io.MultiWriter(writers...)
 		}
 
-		err = executeSQLTask(ctx, ti, run, cfg, tc, opts, logWriter)
+		switch tc.Type {
+		case "sensor":
+			err = executeSensorTask(ctx, ti, run, cfg, tc, logWriter)
+		case "quality":
+			err = executeQualityTask(ctx, ti, run, cfg, tc, opts, logWriter)
+		case "http":
+			err = executeHTTPTask(ctx, ti, run, tc, logWriter)
+		default:
+			err = executeSQLTask(ctx, ti, run, cfg, tc, opts, logWriter)
+		}
 		run.mu.Lock()
 		if err != nil {
 			ti.Status = StatusFailed
@@ -512,61 +886,73 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 
 	// Resolve the runner — dbt is special-cased since it needs config + profiles
 	var r runner.Runner
-	var dbtCleanup func()
 	isDBT := ti.Runner == "dbt"
 
 	if isDBT {
 		if cfg.DAG.DBT == nil {
 			run.mu.Lock()
 			ti.Status = StatusFailed
-			ti.Error = fmt.Errorf("dbt runner requires [dag.dbt] configuration section")
+			ti.Error = tagError(ErrRunner, fmt.Errorf("dbt runner requires [dag.dbt] configuration section"))
 			ti.EndedAt = time.Now()
 			run.mu.Unlock()
 			return
 		}
 
-		profilesInput := &runner.DBTProfilesInput{
-			DAGName:    run.DAGName,
-			Profile:    cfg.DAG.DBT.Profile,
-			Target:     cfg.DAG.DBT.Target,
-			Driver:     opts.DBTDriver,
-			Connection: cfg.DAG.DBT.Connection,
-		}
+		profilesInput := dbtProfilesInput(run.DAGName, cfg.DAG.DBT, tc, opts.DBTDriver)
 
 		var profilesDir string
-		var err error
 		if run.SecretsResolver != nil {
-			profilesDir, dbtCleanup, err = runner.GenerateProfiles(profilesInput, run.SecretsResolver)
+			// Generate into the run's credential dir so the janitor removes
+			// the plaintext profile even if this task never returns normally.
+			credDir, err := run.janitor.dir()
+			if err == nil {
+				profilesInput.TempDir = credDir
+				var dbtCleanup func()
+				profilesDir, dbtCleanup, err = runner.GenerateProfiles(profilesInput, run.SecretsResolver)
+				if err == nil {
+					defer dbtCleanup()
+				}
+			}
 			if err != nil {
 				run.mu.Lock()
 				ti.Status = StatusFailed
-				ti.Error = fmt.Errorf("generating dbt profiles: %w", err)
+				ti.Error = tagError(ErrRunner, fmt.Errorf("generating dbt profiles: %w", err))
 				ti.EndedAt = time.Now()
 				run.mu.Unlock()
 				return
 			}
-		} else {
-			dbtCleanup = func() {}
 		}
 
-		r = runner.NewDBTRunner(cfg.DAG.DBT, profilesDir)
+		dbtRunner := runner.NewDBTRunner(cfg.DAG.DBT, profilesDir)
+		dbtRunner.PythonVersion = pythonVersion(cfg)
+		if cfg.DAG.DBT.RawLog {
+			dbtRunner.RawLogPath = filepath.Join(run.dir(), "dbt", "dbt.jsonl")
+		}
+		r = dbtRunner
+		if opts.MetaStore != nil {
+			defer func() {
+				if metrics := dbtTestMetrics(dbtRunner.TestResults); metrics != nil {
+					if err := opts.MetaStore.RecordTaskMetrics(run.ID, ti.Name, metrics); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+					}
+				}
+			}()
+		}
+	} else if pr, ok := resolvePluginRunner(opts.Plugins, ti.Runner, scriptPath); ok {
+		r = pr
 	} else {
 		var err error
 		r, err = runner.Resolve(ti.Runner, scriptPath)
 		if err != nil {
 			run.mu.Lock()
 			ti.Status = StatusFailed
-			ti.Error = err
+			ti.Error = tagError(ErrRunner, err)
 			ti.EndedAt = time.Now()
 			run.mu.Unlock()
 			return
 		}
 	}
 
-	if dbtCleanup != nil {
-		defer dbtCleanup()
-	}
-
 	logPath := filepath.Join(run.LogDir, ti.Name+".log")
 	logFile, err := os.Create(logPath)
 	if err != nil {
@@ -583,15 +969,9 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 	writers := []io.Writer{logFile}
 	var hubWriter *loghub.Writer
 	if opts.Verbose {
-		isConcurrent := len(concurrent) > 0 && concurrent[0]
-		if isConcurrent {
-			writers = append(writers, &prefixWriter{
-				prefix: []byte("[" + ti.Name + "] "),
-				dest:   os.Stdout,
-			})
-		} else {
-			writers = append(writers, os.Stdout)
-		}
+		console, flush := verboseWriter(run, opts, ti, len(concurrent) > 0 && concurrent[0])
+		defer flush()
+		writers = append(writers, console)
 	}
 	if opts.LogHub != nil {
 		hubWriter = loghub.NewWriter(opts.LogHub, run.ID, run.DAGName, ti.Name, 1)
@@ -602,24 +982,85 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 		logWriter = io.MultiWriter(writers...)
 	}
 
-	// Build environment
-	env := append(os.Environ(),
+	// Give the task its own scratch directory, removed when it finishes
+	// unless keep_artifacts includes "scratch"
+	scratchDir, err := makeScratchDir(run, ti)
+	if err != nil {
+		run.mu.Lock()
+		ti.Status = StatusFailed
+		ti.Error = err
+		ti.EndedAt = time.Now()
+		run.mu.Unlock()
+		return
+	}
+	if !keepScratch(opts.KeepArtifacts) {
+		defer os.RemoveAll(scratchDir)
+	}
+
+	// Build environment: workspace [env], then [dag.env], then the task's
+	envPolicies, err := expandEnvRefs([]*config.EnvConfig{opts.Env, cfg.DAG.Env, ti.Env}, run)
+	if err != nil {
+		run.mu.Lock()
+		ti.Status = StatusFailed
+		ti.Error = err
+		ti.EndedAt = time.Now()
+		run.mu.Unlock()
+		return
+	}
+	env := append(taskEnv(os.Environ(), envPolicies...),
 		"PIT_RUN_ID="+run.ID,
 		"PIT_TASK_NAME="+ti.Name,
 		"PIT_DAG_NAME="+run.DAGName,
 		"PIT_SOCKET="+run.SocketPath,
 		"PIT_DATA_DIR="+run.DataDir,
 	)
+	env = append(env, scratchEnv(scratchDir)...)
+	env = append(env, paramEnv(run.Params)...)
+	if isDBT && snapshotReadOnly(cfg, opts) {
+		// dbt writes target/ and logs/ into its project by default
+		env = append(env,
+			"DBT_TARGET_PATH="+filepath.Join(scratchDir, "target"),
+			"DBT_LOG_PATH="+filepath.Join(scratchDir, "logs"),
+		)
+	}
+	if ti.MapOf != "" {
+		env = append(env, "PIT_MAP_ITEM="+ti.MapItem, "PIT_MAP_INDEX="+strconv.Itoa(ti.MapIndex))
+	}
+	if ti.Limits.Offline {
+		env = offlineEnv(env)
+	}
 
 	rc := runner.RunContext{
 		ScriptPath:      scriptPath,
 		SnapshotDir:     run.SnapshotDir,
+		InPlace:         run.InPlace,
+		ScratchDir:      scratchDir,
+		DataDir:         run.DataDir,
 		OrigProjectDir:  run.ProjectDir,
 		Env:             env,
+		Limits:          ti.Limits,
+		PythonVersion:   pythonVersion(cfg),
 		SecretsResolver: run.SecretsResolver,
 		DAGName:         run.DAGName,
 		SQLConnection:   cfg.DAG.SQL.Connection,
 	}
+	if tc != nil {
+		rc.StmtTimeout = tc.StmtTimeout.Duration
+		if tc.Workdir != "" {
+			rc.WorkDir = filepath.Join(run.SnapshotDir, filepath.FromSlash(tc.Workdir))
+		}
+	}
+	if opts.MetaStore != nil {
+		rc.Usage = &runner.Usage{}
+		defer func() {
+			if rc.Usage.IsZero() {
+				return
+			}
+			if err := opts.MetaStore.RecordTaskMetrics(run.ID, ti.Name, usageMetrics(*rc.Usage)); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+			}
+		}()
+	}
 
 	// For dbt tasks, ScriptPath holds the dbt command (not a file path),
 	// and SnapshotDir points to the dbt project within the snapshot.
@@ -629,11 +1070,15 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 			rc.SnapshotDir = filepath.Join(run.SnapshotDir, cfg.DAG.DBT.ProjectDir)
 		}
 	} else {
-		// Validate script path is within snapshot (not applicable for dbt)
-		if err := rc.ValidateScript(); err != nil {
+		// Validate script path and workdir are within snapshot (not applicable for dbt)
+		err := rc.ValidateScript()
+		if err == nil {
+			err = rc.ValidateWorkDir()
+		}
+		if err != nil {
 			run.mu.Lock()
 			ti.Status = StatusFailed
-			ti.Error = err
+			ti.Error = tagError(ErrRunner, err)
 			ti.EndedAt = time.Now()
 			run.mu.Unlock()
 			return
@@ -672,7 +1117,14 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 			fmt.Fprintf(logWriter, "\n--- retry attempt %d/%d ---\n", attempt, maxAttempts)
 		}
 
-		err = r.Run(attemptCtx, rc, logWriter)
+		if ti.StallTimeout > 0 {
+			err = runWatched(attemptCtx, r, rc, logWriter, ti, run, opts)
+		} else {
+			err = r.Run(attemptCtx, rc, logWriter)
+		}
+		if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+			err = timeoutError(ti.Timeout, err)
+		}
 		attemptCancel()
 
 		if err == nil {
@@ -711,19 +1163,88 @@ func executeTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.Pr
 	run.mu.Unlock()
 }
 
+// dbtTestMetrics turns a dbt task's test outcomes into task metrics, or
+// returns nil if it ran no tests.
+func dbtTestMetrics(t runner.DBTTestResults) map[string]float64 {
+	if t.Total() == 0 {
+		return nil
+	}
+	return map[string]float64{
+		"dbt_tests_pass":  float64(t.Pass),
+		"dbt_tests_fail":  float64(t.Fail),
+		"dbt_tests_warn":  float64(t.Warn),
+		"dbt_tests_error": float64(t.Error),
+		"dbt_tests_skip":  float64(t.Skip),
+	}
+}
+
+// usageMetrics turns a task's process resource usage into task metrics.
+// Peak memory is left out where the platform does not report it.
+func usageMetrics(u runner.Usage) map[string]float64 {
+	m := map[string]float64{
+		"wall_seconds":       u.Wall.Seconds(),
+		"cpu_user_seconds":   u.UserCPU.Seconds(),
+		"cpu_system_seconds": u.SystemCPU.Seconds(),
+	}
+	if u.MaxRSS > 0 {
+		m["max_rss_bytes"] = float64(u.MaxRSS)
+	}
+	return m
+}
+
+// dbtProfilesInput builds the profile settings for a dbt task from [dag.dbt],
+// applying the task's dbt_target and dbt_connection overrides.
+func dbtProfilesInput(dagName string, dbt *config.DBTConfig, tc *config.TaskConfig, driver string) *runner.DBTProfilesInput {
+	in := &runner.DBTProfilesInput{
+		DAGName:    dagName,
+		Profile:    dbt.Profile,
+		Target:     dbt.Target,
+		Driver:     driver,
+		Connection: dbt.Connection,
+	}
+	if tc != nil {
+		if tc.DBTTarget != "" {
+			in.Target = tc.DBTTarget
+		}
+		if tc.DBTConnection != "" {
+			in.Connection = tc.DBTConnection
+		}
+	}
+	return in
+}
+
 // printSummary outputs a table of task results to w.
 func printSummary(w io.Writer, run *Run) {
 	fmt.Fprintf(w, "\n── Run %s ──\n", run.ID)
 	fmt.Fprintf(w, "DAG: %s  Status: %s  Duration: %s\n\n",
 		run.DAGName, run.Status, run.EndedAt.Sub(run.StartedAt).Round(time.Millisecond))
+	if run.Snapshot.Files > 0 {
+		line := fmt.Sprintf("Snapshot: %d files, %s in %s",
+			run.Snapshot.Files, config.ByteSize(run.Snapshot.Bytes), run.Snapshot.Duration.Round(time.Millisecond))
+		if run.Snapshot.SkippedLinks > 0 {
+			line += fmt.Sprintf("  (%d symlink(s) skipped, see logs/%s)", run.Snapshot.SkippedLinks, snapshotLogName)
+		}
+		fmt.Fprintf(w, "%s\n\n", line)
+	} else if run.InPlace {
+		fmt.Fprintf(w, "Snapshot: none, ran in %s\n\n", run.SnapshotDir)
+	}
+	if !run.Versions.IsZero() {
+		fmt.Fprintf(w, "Versions: %s\n\n", run.Versions)
+	}
+	if !run.Revision.IsZero() {
+		fmt.Fprintf(w, "Revision: %s\n\n", run.Revision)
+	}
 
-	for _, ti := range run.Tasks {
+	for _, ti := range expandedTasks(run.Tasks) {
 		status := string(ti.Status)
 		line := fmt.Sprintf("  %-20s %s", ti.Name, status)
 
-		if ti.Status == StatusFailed && ti.Error != nil {
+		if (ti.Status == StatusFailed || ti.Status == StatusUpstreamFailed) && ti.Error != nil {
 			line += fmt.Sprintf("  (%s)", ti.Error)
 		}
+		if ti.SkipReason != "" {
+			line += fmt.Sprintf("  (%s)", ti.SkipReason)
+		}
 		if ti.Attempt > 1 {
 			line += fmt.Sprintf("  [attempt %d/%d]", ti.Attempt, ti.MaxRetries+1)
 		}
@@ -733,6 +1254,30 @@ func printSummary(w io.Writer, run *Run) {
 		}
 
 		fmt.Fprintln(w, line)
+		if f := ti.Failure; f != nil {
+			fmt.Fprintf(w, "      cause: %s\n", f)
+			for _, l := range f.Lines {
+				fmt.Fprintf(w, "      | %s\n", l)
+			}
+		}
+	}
+	if run.AssertionError != nil {
+		fmt.Fprintf(w, "\n  %s\n", run.AssertionError)
+	}
+	if run.AssertionWarnings > 0 {
+		fmt.Fprintf(w, "\n  warning: %d output assertion(s) failed with on_failure = \"warn\" (see logs/%s)\n", run.AssertionWarnings, outputAssertionsLogName)
+	}
+	if len(run.Anomalies) > 0 {
+		fmt.Fprintln(w)
+		for _, a := range run.Anomalies {
+			fmt.Fprintf(w, "  warning: unusually slow: %s\n", a)
+		}
+	}
+	if len(run.Reports) > 0 {
+		fmt.Fprintln(w)
+		for _, p := range run.Reports {
+			fmt.Fprintf(w, "Report: %s\n", p)
+		}
 	}
 	fmt.Fprintln(w)
 }
@@ -778,6 +1323,7 @@ func buildTasksFromCompileResult(result *transform.CompileResult, existingTasks
 				if explicit.RetryDelay.Duration > 0 {
 					tc.RetryDelay = explicit.RetryDelay
 				}
+				tc.TriggerRule = explicit.TriggerRule
 				// Append any extra depends_on entries declared in pit.toml that
 				// are not already covered by the model's DAG-derived dependencies.
 				if len(explicit.DependsOn) > 0 {
@@ -816,71 +1362,144 @@ func buildTasksFromCompileResult(result *transform.CompileResult, existingTasks
 	return modelTasks
 }
 
-// makeLoadDataHandler returns a HandlerFunc that loads Parquet files into databases.
-func makeLoadDataHandler(store *secrets.Store, dagName string, dataDir string) sdk.HandlerFunc {
+// makeLoadDataHandler returns a HandlerFunc that loads Parquet files into
+// databases. Tables without a schema parameter go to defaults.schema.
+func makeLoadDataHandler(store *secrets.Store, dagName string, dataDir string, defaults loadDefaults) sdk.HandlerFunc {
 	return func(ctx context.Context, params map[string]string) (string, error) {
 		fileName := params["file"]
-		table := params["table"]
-		connKey := params["connection"]
-
 		if fileName == "" {
 			return "", fmt.Errorf("missing required parameter: file")
 		}
-		if table == "" {
-			return "", fmt.Errorf("missing required parameter: table")
+		lp, err := sdkLoadParams(store, dagName, defaults, params)
+		if err != nil {
+			return "", err
 		}
-		if connKey == "" {
-			return "", fmt.Errorf("missing required parameter: connection")
+
+		if lp.FilePath, err = dataFilePath(dataDir, fileName); err != nil {
+			return "", err
 		}
-		if store == nil {
-			return "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		if name := params["reject_file"]; name != "" {
+			if lp.RejectFile, err = dataFilePath(dataDir, name); err != nil {
+				return "", err
+			}
 		}
-
-		mode := params["mode"]
-		if mode == "" {
-			mode = "append"
+		if v := params["max_rejects"]; v != "" {
+			if lp.MaxRejects, err = strconv.Atoi(v); err != nil || lp.MaxRejects < 0 {
+				return "", fmt.Errorf("invalid max_rejects %q (must be a whole number >= 0)", v)
+			}
 		}
 
-		// Resolve file path within data directory (prevent traversal)
-		filePath := filepath.Join(dataDir, fileName)
-		absFile, err := filepath.Abs(filePath)
+		res, err := loader.Load(ctx, lp)
 		if err != nil {
-			return "", fmt.Errorf("resolving file path: %w", err)
-		}
-		absData, err := filepath.Abs(dataDir)
-		if err != nil {
-			return "", fmt.Errorf("resolving data dir: %w", err)
+			return "", fmt.Errorf("loading data: %w", err)
 		}
-		if !strings.HasPrefix(absFile, absData+string(filepath.Separator)) && absFile != absData {
-			return "", fmt.Errorf("file path %q escapes data directory", fileName)
+
+		if res.Rejected > 0 {
+			return fmt.Sprintf("%d rows loaded, %d rejected (see %s)", res.Rows, res.Rejected, params["reject_file"]), nil
 		}
+		return fmt.Sprintf("%d rows loaded", res.Rows), nil
+	}
+}
 
-		connStr, err := store.Resolve(dagName, connKey)
+// makeLoadDatasetHandler returns a HandlerFunc that loads several Parquet
+// files into one table in parallel. files is a JSON array of paths in the
+// data directory; parallel caps how many load at once.
+func makeLoadDatasetHandler(store *secrets.Store, dagName string, dataDir string, defaults loadDefaults) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		if params["files"] == "" {
+			return "", fmt.Errorf("missing required parameter: files")
+		}
+		var names []string
+		if err := json.Unmarshal([]byte(params["files"]), &names); err != nil {
+			return "", fmt.Errorf("invalid files parameter: %w", err)
+		}
+		if len(names) == 0 {
+			return "", fmt.Errorf("files must list at least one file")
+		}
+		lp, err := sdkLoadParams(store, dagName, defaults, params)
 		if err != nil {
-			return "", fmt.Errorf("resolving connection %q: %w", connKey, err)
+			return "", err
 		}
 
-		schema := params["schema"]
-		if schema == "" {
-			driverName, _ := runner.DetectDriver(connStr)
-			if drv, drvErr := loader.GetDriver(driverName); drvErr == nil {
-				schema = drv.DefaultSchema()
+		files := make([]string, len(names))
+		for i, name := range names {
+			if files[i], err = dataFilePath(dataDir, name); err != nil {
+				return "", err
+			}
+		}
+		parallel := 0
+		if v := params["parallel"]; v != "" {
+			if parallel, err = strconv.Atoi(v); err != nil || parallel < 1 {
+				return "", fmt.Errorf("invalid parallel %q (must be a whole number >= 1)", v)
 			}
 		}
 
-		rows, err := loader.Load(ctx, loader.LoadParams{
-			FilePath: absFile,
-			Table:    table,
-			Schema:   schema,
-			Mode:     loader.LoadMode(mode),
-			ConnStr:  connStr,
-		})
+		res, err := loader.LoadDataset(ctx, lp, files, parallel)
 		if err != nil {
-			return "", fmt.Errorf("loading data: %w", err)
+			return "", fmt.Errorf("loading dataset (%d rows loaded before the error): %w", res.Rows, err)
 		}
+		return fmt.Sprintf("%d rows loaded from %d files", res.Rows, len(files)), nil
+	}
+}
+
+// sdkLoadParams builds the load parameters shared by load_data and
+// load_dataset: the target table, connection, mode, and column handling.
+func sdkLoadParams(store *secrets.Store, dagName string, defaults loadDefaults, params map[string]string) (loader.LoadParams, error) {
+	table := params["table"]
+	connKey := params["connection"]
+
+	if table == "" {
+		return loader.LoadParams{}, fmt.Errorf("missing required parameter: table")
+	}
+	if connKey == "" {
+		return loader.LoadParams{}, fmt.Errorf("missing required parameter: connection")
+	}
+	if store == nil {
+		return loader.LoadParams{}, fmt.Errorf("secrets store not configured (use --secrets flag)")
+	}
+
+	mode := params["mode"]
+	if mode == "" {
+		mode = "append"
+	}
+
+	connStr, err := runner.ResolveConnection(store, dagName, connKey)
+	if err != nil {
+		return loader.LoadParams{}, fmt.Errorf("resolving connection %q: %w", connKey, err)
+	}
 
-		return fmt.Sprintf("%d rows loaded", rows), nil
+	schema := params["schema"]
+	if schema == "" {
+		schema = defaults.schema
 	}
+
+	return loader.LoadParams{
+		Table:   table,
+		Schema:  schema,
+		Mode:    loader.LoadMode(mode),
+		ConnStr: connStr,
+		Case:    defaults.identCase,
+
+		Timezone: loader.TimezonePolicy(params["timezone_policy"]),
+
+		ExcludeColumns: splitColumnList(params["exclude_columns"]),
+	}, nil
+}
+
+// dataFilePath resolves name inside dataDir, refusing paths that escape it.
+func dataFilePath(dataDir, name string) (string, error) {
+	absFile, err := filepath.Abs(filepath.Join(dataDir, name))
+	if err != nil {
+		return "", fmt.Errorf("resolving file path: %w", err)
+	}
+	absData, err := filepath.Abs(dataDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving data dir: %w", err)
+	}
+	if !strings.HasPrefix(absFile, absData+string(filepath.Separator)) && absFile != absData {
+		return "", fmt.Errorf("file path %q escapes data directory", name)
+	}
+	return absFile, nil
 }
 
 // resolveTaskConnection returns the connection key for a task, falling back to DAG default.
@@ -891,6 +1510,43 @@ func resolveTaskConnection(tc *config.TaskConfig, cfg *config.ProjectConfig) str
 	return cfg.DAG.SQL.Connection
 }
 
+// splitColumnList parses the comma-separated exclude_columns parameter sent
+// by the SDK's load_data.
+func splitColumnList(s string) []string {
+	var cols []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// loadDefaults are the schema and identifier case used by loads that do not
+// name their own schema.
+type loadDefaults struct {
+	schema    string // "" = the driver's default schema
+	identCase loader.IdentifierCase
+}
+
+// resolveLoadDefaults takes each setting from [dag.sql], falling back to the
+// workspace [loader] section.
+func resolveLoadDefaults(cfg *config.ProjectConfig, ws *config.LoaderConfig) loadDefaults {
+	d := loadDefaults{
+		schema:    cfg.DAG.SQL.Schema,
+		identCase: loader.IdentifierCase(cfg.DAG.SQL.IdentifierCase),
+	}
+	if ws != nil {
+		if d.schema == "" {
+			d.schema = ws.Schema
+		}
+		if d.identCase == loader.CaseDefault {
+			d.identCase = loader.IdentifierCase(ws.IdentifierCase)
+		}
+	}
+	return d
+}
+
 // parseSchemaTable splits "schema.table" into schema and table parts.
 // If no dot, returns empty schema and the full string as table.
 func parseSchemaTable(fqTable string) (string, string) {
@@ -911,7 +1567,7 @@ func executeSQLTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config
 		return fmt.Errorf("secrets store not configured (use --secrets flag)")
 	}
 
-	connStr, err := run.SecretsResolver.Resolve(run.DAGName, connKey)
+	connStr, err := runner.ResolveConnection(run.SecretsResolver, run.DAGName, connKey)
 	if err != nil {
 		return fmt.Errorf("resolving connection %q: %w", connKey, err)
 	}
@@ -921,24 +1577,44 @@ func executeSQLTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config
 	switch tc.Type {
 	case "load":
 		sourcePath := filepath.Join(run.DataDir, tc.Source)
+		defaults := resolveLoadDefaults(cfg, opts.Loader)
 		schema, table := parseSchemaTable(tc.Table)
+		if schema == "" {
+			schema = defaults.schema
+		}
 		mode := tc.Mode
 		if mode == "" {
 			mode = "append"
 		}
-		rows, err := loader.Load(ctx, loader.LoadParams{
+		var rejectFile string
+		if tc.RejectFile != "" {
+			rejectFile = filepath.Join(run.DataDir, tc.RejectFile)
+		}
+		res, err := loader.Load(ctx, loader.LoadParams{
 			FilePath: sourcePath,
 			Table:    table,
 			Schema:   schema,
 			Mode:     loader.LoadMode(mode),
 			ConnStr:  connStr,
+			Case:     defaults.identCase,
+
+			Timezone: loader.TimezonePolicy(tc.TimezonePolicy),
+
+			ExcludeColumns: tc.ExcludeColumns,
+
+			RejectFile: rejectFile,
+			MaxRejects: tc.MaxRejects,
 		})
+		if res.Rejected > 0 {
+			fmt.Fprintf(logWriter, "[load] %d rows rejected, written to %s\n", res.Rejected, tc.RejectFile)
+		}
 		if err != nil {
 			return fmt.Errorf("loading data: %w", err)
 		}
+		run.Datasets.addOutput(connKey, loadTarget(schema, table))
 		elapsed := time.Since(start)
 		fmt.Fprintf(logWriter, "[load] %s -> %s: %d rows loaded in %s\n",
-			tc.Source, tc.Table, rows, elapsed.Round(time.Millisecond))
+			tc.Source, tc.Table, res.Rows, elapsed.Round(time.Millisecond))
 
 	case "save":
 		scriptPath := filepath.Join(run.SnapshotDir, tc.Script)
@@ -977,8 +1653,27 @@ func hashFile(path string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// verboseWriter returns the writer for a task's verbose output and a
+// function to call when the task ends. Output goes through the run's
+// console, so a slow terminal delays the console rather than the task.
+// Concurrent tasks get each line prefixed with the task name.
+func verboseWriter(run *Run, opts ExecuteOpts, ti *TaskInstance, concurrent bool) (io.Writer, func()) {
+	var w io.Writer = opts.Output
+	flush := func() {}
+	if run.console != nil {
+		cw := run.console.writer(ti.Name)
+		w, flush = cw, cw.flush
+	}
+	if concurrent {
+		w = &prefixWriter{prefix: []byte("[" + ti.Name + "] "), dest: w}
+	}
+	return w, flush
+}
+
 // prefixWriter is an io.Writer that prepends a prefix to each line of output.
 // Used in verbose mode when tasks run concurrently to distinguish output.
+// Each line is written with its prefix in a single Write, so lines from
+// concurrent tasks do not interleave.
 type prefixWriter struct {
 	prefix []byte
 	dest   io.Writer
@@ -999,10 +1694,8 @@ func (pw *prefixWriter) Write(p []byte) (n int, err error) {
 		if idx < 0 {
 			break
 		}
-		line := pw.buf[:idx+1]
-		if _, err := pw.dest.Write(pw.prefix); err != nil {
-			return n, err
-		}
+		line := make([]byte, 0, len(pw.prefix)+idx+1)
+		line = append(append(line, pw.prefix...), pw.buf[:idx+1]...)
 		if _, err := pw.dest.Write(line); err != nil {
 			return n, err
 		}
@@ -1,11 +1,18 @@
 package engine
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/loader"
+	"github.com/druarnfield/pit/internal/runner"
 	"github.com/druarnfield/pit/internal/secrets"
 	"github.com/druarnfield/pit/internal/transform"
 )
@@ -285,3 +292,144 @@ func TestParseSchemaTable(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveLoadDefaults(t *testing.T) {
+	ws := &config.LoaderConfig{Schema: "staging", IdentifierCase: "lower"}
+	tests := []struct {
+		name string
+		sql  config.SQLConfig
+		ws   *config.LoaderConfig
+		want loadDefaults
+	}{
+		{name: "none", want: loadDefaults{}},
+		{name: "workspace", ws: ws, want: loadDefaults{schema: "staging", identCase: loader.CaseLower}},
+		{name: "dag overrides", sql: config.SQLConfig{Schema: "raw", IdentifierCase: "upper"}, ws: ws, want: loadDefaults{schema: "raw", identCase: loader.CaseUpper}},
+		{name: "dag schema only", sql: config.SQLConfig{Schema: "raw"}, ws: ws, want: loadDefaults{schema: "raw", identCase: loader.CaseLower}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{SQL: tt.sql}}
+			if got := resolveLoadDefaults(cfg, tt.ws); got != tt.want {
+				t.Errorf("resolveLoadDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitColumnList(t *testing.T) {
+	got := splitColumnList(" id, row_version ,,")
+	if len(got) != 2 || got[0] != "id" || got[1] != "row_version" {
+		t.Errorf("splitColumnList() = %q, want [id row_version]", got)
+	}
+	if got := splitColumnList(""); got != nil {
+		t.Errorf("splitColumnList(\"\") = %q, want nil", got)
+	}
+}
+
+func TestLoadDatasetHandler_Params(t *testing.T) {
+	store := loadTestStore(t, `[global]
+warehouse = "postgres://localhost/db"
+`)
+	handler := makeLoadDatasetHandler(store, "test", t.TempDir(), loadDefaults{})
+
+	base := map[string]string{"table": "orders", "connection": "warehouse"}
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"missing files", map[string]string{}, "missing required parameter: files"},
+		{"files not JSON", map[string]string{"files": "a.parquet,b.parquet"}, "invalid files parameter"},
+		{"no files", map[string]string{"files": "[]"}, "at least one file"},
+		{"missing table", map[string]string{"files": `["a.parquet"]`, "table": ""}, "missing required parameter: table"},
+		{"escapes data dir", map[string]string{"files": `["a.parquet", "../../b.parquet"]`}, "escapes data directory"},
+		{"bad parallel", map[string]string{"files": `["a.parquet"]`, "parallel": "0"}, "invalid parallel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]string{}
+			for k, v := range base {
+				params[k] = v
+			}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			_, err := handler(t.Context(), params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("handler() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsageMetrics(t *testing.T) {
+	u := runner.Usage{
+		Wall:      3 * time.Second,
+		UserCPU:   1500 * time.Millisecond,
+		SystemCPU: 250 * time.Millisecond,
+		MaxRSS:    64 << 20,
+	}
+	got := usageMetrics(u)
+	want := map[string]float64{
+		"wall_seconds":       3,
+		"cpu_user_seconds":   1.5,
+		"cpu_system_seconds": 0.25,
+		"max_rss_bytes":      64 << 20,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("usageMetrics() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("usageMetrics()[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+
+	u.MaxRSS = 0
+	if _, ok := usageMetrics(u)["max_rss_bytes"]; ok {
+		t.Error("usageMetrics() reported max_rss_bytes when the platform gave none")
+	}
+}
+
+func TestExecuteTask_Workdir(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	runDir := t.TempDir()
+	run := &Run{
+		ID:          "workdir_test",
+		DAGName:     "test",
+		SnapshotDir: filepath.Join(runDir, "project"),
+		LogDir:      filepath.Join(runDir, "logs"),
+		DataDir:     filepath.Join(t.TempDir(), "workdir_test"),
+	}
+	for _, dir := range []string{filepath.Join(run.SnapshotDir, "tasks"), filepath.Join(run.SnapshotDir, "claims"), run.LogDir, run.DataDir} {
+		os.MkdirAll(dir, 0o755)
+	}
+	os.WriteFile(filepath.Join(run.SnapshotDir, "tasks", "where.sh"), []byte("pwd > \"$PIT_DATA_DIR/pwd.txt\"\n"), 0o755)
+
+	cfg := &config.ProjectConfig{Tasks: []config.TaskConfig{{Name: "where", Script: "tasks/where.sh", Workdir: "claims"}}}
+	ti := &TaskInstance{Name: "where", Script: "tasks/where.sh", Status: StatusPending}
+	run.Tasks = []*TaskInstance{ti}
+	executeTask(context.Background(), ti, run, cfg, ExecuteOpts{})
+
+	if ti.Status != StatusSuccess {
+		t.Fatalf("task = %s (%v), want success", ti.Status, ti.Error)
+	}
+	got, err := os.ReadFile(filepath.Join(run.DataDir, "pwd.txt"))
+	if err != nil {
+		t.Fatalf("reading pwd.txt from the data dir: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(run.SnapshotDir, "claims"))
+	if gotDir, _ := filepath.EvalSymlinks(strings.TrimSpace(string(got))); gotDir != want {
+		t.Errorf("task ran in %q, want %q", gotDir, want)
+	}
+
+	cfg.Tasks[0].Workdir = "../logs"
+	ti = &TaskInstance{Name: "where", Script: "tasks/where.sh", Status: StatusPending}
+	run.Tasks = []*TaskInstance{ti}
+	executeTask(context.Background(), ti, run, cfg, ExecuteOpts{})
+	if ti.Status != StatusFailed || !errors.Is(ti.Error, ErrRunner) {
+		t.Errorf("task with an escaping workdir = %s (%v), want failed with ErrRunner", ti.Status, ti.Error)
+	}
+}
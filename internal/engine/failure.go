@@ -0,0 +1,270 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// Failure classes, assigned by the first matching rule in failureRules.
+const (
+	FailureTimeout   = "timeout"
+	FailureStalled   = "stalled"
+	FailureMemory    = "memory_limit"
+	FailureCancelled = "cancelled"
+	FailureSQL       = "sql_error"
+	FailureDBT       = "dbt_failure"
+	FailureFTP       = "ftp_error"
+	FailureExitCode  = "exit_code"
+	FailureOther     = "error"
+)
+
+// failureLogLines is how many log lines a failure keeps.
+const failureLogLines = 10
+
+// failureLogTail is how much of the end of a task log is read when
+// classifying a failure.
+const failureLogTail = 64 << 10
+
+func timeoutError(timeout time.Duration, err error) error {
+	return fmt.Errorf("%w after %s: %w", ErrTimeout, timeout, err)
+}
+
+// Failure says why a task failed, for the run summary and alerts.
+type Failure struct {
+	Class  string   `json:"class"`
+	Detail string   `json:"detail,omitempty"` // e.g. "exit status 2" or "SQLSTATE 23505"
+	Lines  []string `json:"lines,omitempty"`  // relevant lines from the end of the task log
+}
+
+func (f *Failure) String() string {
+	if f.Detail == "" {
+		return f.Class
+	}
+	return f.Class + " (" + f.Detail + ")"
+}
+
+// failureInput is what a rule sees of a failed task: its error and the
+// lines of its last attempt's log.
+type failureInput struct {
+	ti   *TaskInstance
+	err  error
+	msg  string
+	logs []string
+}
+
+// failureRule assigns class when match reports ok. lines, if set, picks
+// the relevant log lines; by default all are kept. Either way only the
+// last failureLogLines survive.
+type failureRule struct {
+	class string
+	match func(in failureInput) (detail string, ok bool)
+	lines func(logs []string) []string
+}
+
+var (
+	timeoutPattern  = regexp.MustCompile(`(?:timed out after|statement_timeout of) ([^\s:]+)`)
+	memoryPattern   = regexp.MustCompile(`exceeding memory_limit ([^\s:]+)`)
+	stalledPattern  = regexp.MustCompile(`stalled: (.+)$`)
+	sqlCodePatterns = []struct {
+		re     *regexp.Regexp
+		format string
+	}{
+		{regexp.MustCompile(`SQLSTATE[ =:]*([0-9A-Z]{5})`), "SQLSTATE %s"},
+		{regexp.MustCompile(`(ORA-\d{5})`), "%s"},
+		{regexp.MustCompile(`\((\d+)\) \(SQLExecDirectW\)`), "SQL Server error %s"}, // pyodbc
+		{regexp.MustCompile(`(?i)clickhouse.*code: (\d+)`), "ClickHouse code %s"},
+	}
+	dbtFailedNode = regexp.MustCompile(`^\[\d+/\d+\] ✗ (\S+)`)
+	ftpCall       = regexp.MustCompile(`\bin ftp_(?:list|download|upload|move)\b|pit_sdk[/\\]ftp\.py`)
+	ftpReply      = regexp.MustCompile(`SDK error: .*?\b([45]\d\d) `)
+)
+
+var failureRules = []failureRule{
+	{class: FailureTimeout, match: func(in failureInput) (string, bool) {
+		m := timeoutPattern.FindStringSubmatch(in.msg)
+		if m != nil {
+			return "after " + m[1], true
+		}
+		return "", errors.Is(in.err, ErrTimeout) || errors.Is(in.err, context.DeadlineExceeded)
+	}},
+	{class: FailureStalled, match: func(in failureInput) (string, bool) {
+		if !errors.Is(in.err, errStalled) {
+			return "", false
+		}
+		if m := stalledPattern.FindStringSubmatch(in.msg); m != nil {
+			return m[1], true
+		}
+		return "", true
+	}},
+	{class: FailureMemory, match: func(in failureInput) (string, bool) {
+		if m := memoryPattern.FindStringSubmatch(in.msg); m != nil {
+			return "memory_limit " + m[1], true
+		}
+		return "", false
+	}},
+	{class: FailureCancelled, match: func(in failureInput) (string, bool) {
+		return "", errors.Is(in.err, context.Canceled)
+	}},
+	{class: FailureDBT, match: func(in failureInput) (string, bool) {
+		if in.ti.Runner != "dbt" {
+			return "", false
+		}
+		var nodes []string
+		for _, l := range in.logs {
+			if m := dbtFailedNode.FindStringSubmatch(l); m != nil {
+				nodes = append(nodes, m[1])
+			}
+		}
+		return strings.Join(nodes, ", "), len(nodes) > 0 || anyContains(in.logs, "ERROR: ")
+	}, lines: func(logs []string) []string {
+		var out []string
+		for _, l := range logs {
+			if dbtFailedNode.MatchString(l) || strings.Contains(l, "ERROR: ") {
+				out = append(out, l)
+			}
+		}
+		return out
+	}},
+	{class: FailureSQL, match: func(in failureInput) (string, bool) {
+		if code := runner.SQLErrorCode(in.err); code != "" {
+			return code, true
+		}
+		texts := append([]string{in.msg}, in.logs...)
+		for i := len(texts) - 1; i >= 0; i-- {
+			for _, p := range sqlCodePatterns {
+				if m := p.re.FindStringSubmatch(texts[i]); m != nil {
+					return fmt.Sprintf(p.format, m[1]), true
+				}
+			}
+		}
+		return "", false
+	}},
+	{class: FailureFTP, match: func(in failureInput) (string, bool) {
+		if !anyMatch(in.logs, ftpCall) {
+			return "", false
+		}
+		for i := len(in.logs) - 1; i >= 0; i-- {
+			if m := ftpReply.FindStringSubmatch(in.logs[i]); m != nil {
+				return "reply " + m[1], true
+			}
+		}
+		return "", true
+	}},
+	{class: FailureExitCode, match: func(in failureInput) (string, bool) {
+		var exitErr *exec.ExitError
+		if errors.As(in.err, &exitErr) {
+			return exitErr.Error(), true
+		}
+		return "", false
+	}},
+}
+
+// classifyFailure returns the failure of ti, reading its log from logDir.
+func classifyFailure(ti *TaskInstance, logDir string) *Failure {
+	logs := lastAttemptLines(filepath.Join(logDir, ti.Name+".log"))
+	in := failureInput{ti: ti, err: ti.Error, msg: ti.Error.Error(), logs: logs}
+	f := &Failure{Class: FailureOther, Lines: logs}
+	for _, r := range failureRules {
+		if detail, ok := r.match(in); ok {
+			f.Class, f.Detail = r.class, detail
+			if r.lines != nil {
+				f.Lines = r.lines(logs)
+			}
+			break
+		}
+	}
+	if len(f.Lines) > failureLogLines {
+		f.Lines = f.Lines[len(f.Lines)-failureLogLines:]
+	}
+	return f
+}
+
+// classifyFailures sets Failure on every task that failed in run.
+func classifyFailures(run *Run) {
+	for _, ti := range expandedTasks(run.Tasks) {
+		if ti.Status == StatusFailed && ti.Error != nil && len(ti.Instances) == 0 {
+			ti.Failure = classifyFailure(ti, run.LogDir)
+		}
+	}
+}
+
+// FailedTasks returns the tasks of run, including mapped instances, whose
+// failure was classified.
+func (r *Run) FailedTasks() []*TaskInstance {
+	var out []*TaskInstance
+	for _, ti := range expandedTasks(r.Tasks) {
+		if ti.Failure != nil {
+			out = append(out, ti)
+		}
+	}
+	return out
+}
+
+// lastAttemptLines returns the non-blank lines of the end of a task log
+// that belong to its last attempt, leaving out pit's progress notices.
+func lastAttemptLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	partial := false
+	if info, err := f.Stat(); err == nil && info.Size() > failureLogTail {
+		_, err = f.Seek(-failureLogTail, io.SeekEnd)
+		partial = err == nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	if partial {
+		// Drop the line the seek landed in the middle of
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+
+	var lines []string
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimRight(l, "\r")
+		switch {
+		case strings.HasPrefix(l, "--- retry attempt "):
+			lines = lines[:0]
+		case strings.TrimSpace(l) == "",
+			strings.HasPrefix(l, "[sql] still executing"),
+			strings.HasPrefix(l, "[pit] warning: no output for"):
+		default:
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func anyContains(lines []string, s string) bool {
+	for _, l := range lines {
+		if strings.Contains(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyMatch(lines []string, re *regexp.Regexp) bool {
+	for _, l := range lines {
+		if re.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
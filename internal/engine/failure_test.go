@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exitError returns a real *exec.ExitError with the given exit code.
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	if err == nil {
+		t.Fatal("expected exit error")
+	}
+	return err
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		runner     string
+		err        func(t *testing.T) error
+		log        string
+		wantClass  string
+		wantDetail string
+		wantLines  []string
+	}{
+		{
+			name:       "task timeout",
+			err:        func(t *testing.T) error { return timeoutError(30*time.Second, exitError(t, 137)) },
+			log:        "working\n",
+			wantClass:  FailureTimeout,
+			wantDetail: "after 30s",
+			wantLines:  []string{"working"},
+		},
+		{
+			name: "statement timeout",
+			err: func(*testing.T) error {
+				return fmt.Errorf("statement_timeout of 5m0s exceeded: %w", context.DeadlineExceeded)
+			},
+			wantClass:  FailureTimeout,
+			wantDetail: "after 5m0s",
+		},
+		{
+			name:       "stalled",
+			err:        func(*testing.T) error { return stallError(15 * time.Minute) },
+			wantClass:  FailureStalled,
+			wantDetail: "no output for 15m0s",
+		},
+		{
+			name: "memory limit",
+			err: func(t *testing.T) error {
+				return fmt.Errorf("killed for exceeding memory_limit 4GiB: %w", exitError(t, 9))
+			},
+			wantClass:  FailureMemory,
+			wantDetail: "memory_limit 4GiB",
+		},
+		{
+			name:   "sql driver error",
+			runner: "sql",
+			err: func(*testing.T) error {
+				return fmt.Errorf("sql runner executing load.sql: %w", &pgconn.PgError{Code: "23505"})
+			},
+			wantClass:  FailureSQL,
+			wantDetail: "SQLSTATE 23505",
+		},
+		{
+			name:   "sql error in python log",
+			runner: "python",
+			err:    func(t *testing.T) error { return exitError(t, 1) },
+			log: "Traceback (most recent call last):\n" +
+				"pyodbc.IntegrityError: ('23000', \"[23000] [Microsoft][ODBC Driver 17 for SQL Server][SQL Server]Violation of PRIMARY KEY constraint (2627) (SQLExecDirectW)\")\n",
+			wantClass:  FailureSQL,
+			wantDetail: "SQL Server error 2627",
+		},
+		{
+			name:   "dbt model failure",
+			runner: "dbt",
+			err:    func(t *testing.T) error { return exitError(t, 1) },
+			log: "[1/3] ✓ stg_orders view (success in 0.5s)\n" +
+				"[2/3] ✗ orders table (error in 1.2s)\n" +
+				"  ERROR: Invalid column name 'amount'. (207) (SQLExecDirectW)\n" +
+				"[3/3] ✗ customers table (error in 0.3s)\n" +
+				"Completed with 2 errors\n",
+			wantClass:  FailureDBT,
+			wantDetail: "orders, customers",
+			wantLines: []string{
+				"[2/3] ✗ orders table (error in 1.2s)",
+				"  ERROR: Invalid column name 'amount'. (207) (SQLExecDirectW)",
+				"[3/3] ✗ customers table (error in 0.3s)",
+			},
+		},
+		{
+			name:   "ftp error",
+			runner: "python",
+			err:    func(t *testing.T) error { return exitError(t, 1) },
+			log: "Traceback (most recent call last):\n" +
+				"  File \"tasks/extract.py\", line 4, in <module>\n" +
+				"  File \".venv/lib/python3.12/site-packages/pit_sdk/ftp.py\", line 50, in ftp_download\n" +
+				"RuntimeError: SDK error: retrieving \"in/claims.csv\": 550 Failed to open file.\n",
+			wantClass:  FailureFTP,
+			wantDetail: "reply 550",
+		},
+		{
+			name:       "nonzero exit",
+			runner:     "bash",
+			err:        func(t *testing.T) error { return exitError(t, 3) },
+			log:        "--- retry attempt 2/2 ---\n" + "\n" + "rsync: connection refused\n",
+			wantClass:  FailureExitCode,
+			wantDetail: "exit status 3",
+			wantLines:  []string{"rsync: connection refused"},
+		},
+		{
+			name:      "cancelled",
+			err:       func(*testing.T) error { return context.Canceled },
+			wantClass: FailureCancelled,
+		},
+		{
+			name:      "other",
+			err:       func(*testing.T) error { return fmt.Errorf("creating log file: disk full") },
+			wantClass: FailureOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logDir := t.TempDir()
+			if tt.log != "" {
+				if err := os.WriteFile(filepath.Join(logDir, "task.log"), []byte(tt.log), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ti := &TaskInstance{Name: "task", Runner: tt.runner, Status: StatusFailed, Error: tt.err(t)}
+
+			f := classifyFailure(ti, logDir)
+			if f.Class != tt.wantClass || f.Detail != tt.wantDetail {
+				t.Errorf("classifyFailure() = %s, want class %q detail %q", f, tt.wantClass, tt.wantDetail)
+			}
+			if tt.wantLines != nil && strings.Join(f.Lines, "\n") != strings.Join(tt.wantLines, "\n") {
+				t.Errorf("Lines = %q, want %q", f.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestClassifyFailure_KeepsLastLines(t *testing.T) {
+	logDir := t.TempDir()
+	var log strings.Builder
+	for i := 1; i <= 25; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+		fmt.Fprintln(&log, "[sql] still executing (elapsed 1m0s)")
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "task.log"), []byte(log.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ti := &TaskInstance{Name: "task", Status: StatusFailed, Error: fmt.Errorf("boom")}
+	f := classifyFailure(ti, logDir)
+	if len(f.Lines) != failureLogLines {
+		t.Fatalf("got %d lines, want %d: %q", len(f.Lines), failureLogLines, f.Lines)
+	}
+	if f.Lines[0] != "line 16" || f.Lines[len(f.Lines)-1] != "line 25" {
+		t.Errorf("Lines = %q, want lines 16 to 25", f.Lines)
+	}
+}
+
+func TestPrintSummary_Failure(t *testing.T) {
+	now := time.Now()
+	run := &Run{
+		ID:        "20240115_143022.123_test",
+		DAGName:   "test",
+		Status:    StatusFailed,
+		StartedAt: now,
+		EndedAt:   now.Add(time.Second),
+		Tasks: []*TaskInstance{{
+			Name:    "load",
+			Status:  StatusFailed,
+			Error:   fmt.Errorf("exit status 1"),
+			Failure: &Failure{Class: FailureExitCode, Detail: "exit status 1", Lines: []string{"ValueError: bad row"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	printSummary(&buf, run)
+	for _, want := range []string{"cause: exit_code (exit status 1)", "| ValueError: bad row"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printSummary() missing %q, got:\n%s", want, buf.String())
+		}
+	}
+	if got := run.FailedTasks(); len(got) != 1 || got[0].Name != "load" {
+		t.Errorf("FailedTasks() = %v, want [load]", got)
+	}
+}
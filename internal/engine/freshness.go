@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// freshSkipReason returns why ti can be skipped under its freshness, or ""
+// if it should run. A task is fresh when every declared output it has
+// produced in earlier runs, as recorded by register_output, was produced
+// within its freshness. A task that has never produced an output runs.
+func freshSkipReason(ti *TaskInstance, run *Run, declared []config.Output, rec MetadataRecorder, now time.Time) string {
+	if ti.Freshness <= 0 || rec == nil {
+		return ""
+	}
+	produced, err := rec.OutputsProducedBy(run.DAGName, ti.Name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: checking freshness of task %q: %v\n", ti.Name, err)
+		return ""
+	}
+
+	// The oldest of the outputs still declared decides
+	var oldest time.Time
+	for _, o := range declared {
+		at, ok := produced[o.Name]
+		if ok && (oldest.IsZero() || at.Before(oldest)) {
+			oldest = at
+		}
+	}
+	if oldest.IsZero() {
+		return ""
+	}
+	age := now.Sub(oldest)
+	if age >= ti.Freshness {
+		return ""
+	}
+	return fmt.Sprintf("outputs produced %s ago, within freshness %s", age.Round(time.Second), ti.Freshness)
+}
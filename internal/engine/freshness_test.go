@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// freshRecorder serves a fixed record of produced outputs and keeps the
+// statuses tasks are recorded with.
+type freshRecorder struct {
+	MetadataRecorder
+	produced map[string]time.Time
+	err      error
+	statuses map[string]string
+}
+
+func (r *freshRecorder) OutputsProducedBy(dagName, taskName string) (map[string]time.Time, error) {
+	return r.produced, r.err
+}
+
+func (r *freshRecorder) RecordTaskStart(runID, taskName, status, logPath string, startedAt time.Time) error {
+	return nil
+}
+
+func (r *freshRecorder) RecordTaskEnd(runID, taskName, status string, endedAt time.Time, attempts int, errMsg string) error {
+	if r.statuses == nil {
+		r.statuses = make(map[string]string)
+	}
+	r.statuses[taskName] = status
+	return nil
+}
+
+func TestFreshSkipReason(t *testing.T) {
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	declared := []config.Output{{Name: "staging"}, {Name: "report"}}
+	run := &Run{DAGName: "claims"}
+
+	tests := []struct {
+		name      string
+		freshness time.Duration
+		produced  map[string]time.Time
+		err       error
+		want      string
+	}{
+		{"fresh", 6 * time.Hour, map[string]time.Time{"staging": now.Add(-2 * time.Hour)}, nil, "outputs produced 2h0m0s ago, within freshness 6h0m0s"},
+		{"stale", 6 * time.Hour, map[string]time.Time{"staging": now.Add(-7 * time.Hour)}, nil, ""},
+		{"oldest output decides", 6 * time.Hour, map[string]time.Time{
+			"staging": now.Add(-time.Hour),
+			"report":  now.Add(-8 * time.Hour),
+		}, nil, ""},
+		{"undeclared outputs ignored", 6 * time.Hour, map[string]time.Time{
+			"staging": now.Add(-time.Hour),
+			"removed": now.Add(-48 * time.Hour),
+		}, nil, "outputs produced 1h0m0s ago"},
+		{"never produced", 6 * time.Hour, map[string]time.Time{}, nil, ""},
+		{"no freshness", 0, map[string]time.Time{"staging": now}, nil, ""},
+		{"store error runs the task", 6 * time.Hour, nil, errors.New("locked"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti := &TaskInstance{Name: "load", Freshness: tt.freshness}
+			rec := &freshRecorder{produced: tt.produced, err: tt.err}
+			got := freshSkipReason(ti, run, declared, rec, now)
+			if tt.want == "" && got != "" || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("freshSkipReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := freshSkipReason(&TaskInstance{Name: "load", Freshness: time.Hour}, run, declared, nil, now); got != "" {
+		t.Errorf("freshSkipReason() without a store = %q, want \"\"", got)
+	}
+}
+
+func TestExecuteDAG_SkipsFreshTask(t *testing.T) {
+	rec := &freshRecorder{produced: map[string]time.Time{"staging": time.Now().Add(-time.Hour)}}
+	ti := &TaskInstance{Name: "load", Status: StatusPending, Freshness: 6 * time.Hour}
+	run := &Run{ID: "run1", DAGName: "claims", Tasks: []*TaskInstance{ti}}
+	cfg := &config.ProjectConfig{Outputs: []config.Output{{Name: "staging"}}}
+
+	executeDAG(t.Context(), [][]*TaskInstance{{ti}}, run, cfg, ExecuteOpts{MetaStore: rec})
+
+	if ti.Status != StatusSkippedFresh {
+		t.Errorf("status = %s, want %s", ti.Status, StatusSkippedFresh)
+	}
+	if !strings.Contains(ti.SkipReason, "within freshness 6h0m0s") {
+		t.Errorf("SkipReason = %q, want the freshness", ti.SkipReason)
+	}
+	if rec.statuses["load"] != string(StatusSkippedFresh) {
+		t.Errorf("recorded status = %q, want %s", rec.statuses["load"], StatusSkippedFresh)
+	}
+}
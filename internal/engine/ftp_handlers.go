@@ -5,47 +5,44 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	pitftp "github.com/druarnfield/pit/internal/ftp"
+	"github.com/druarnfield/pit/internal/remotepath"
 	"github.com/druarnfield/pit/internal/sdk"
 	"github.com/druarnfield/pit/internal/secrets"
 )
 
 // connectFTP resolves FTP credentials from a structured secret and returns a connected client.
-// The structured secret must have host, user, password fields. Optional: port (default 21), tls (default false).
-func connectFTP(store *secrets.Store, dagName, secretName string) (*pitftp.Client, error) {
+// The structured secret must have host, user, password fields. Optional: protocol ("ftp" or "sftp";
+// default ftp), port (default 21, or 22 for sftp), tls (default false), private_key, passphrase and
+// host_key (sftp; see pitftp.Options), rate_limit (transfer rate in bytes per second, e.g. "5MB";
+// default unlimited). An sftp secret with a private_key needs no password.
+func connectFTP(store *secrets.Store, dagName, secretName string) (pitftp.Client, error) {
 	if store == nil {
 		return nil, fmt.Errorf("secrets store not configured (use --secrets flag)")
 	}
 
-	host, err := store.ResolveField(dagName, secretName, "host")
+	opts, err := pitftp.OptionsFromSecret(secretName, func(name string) (string, error) {
+		return store.ResolveField(dagName, secretName, name)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("resolving %s.host: %w", secretName, err)
-	}
-	user, err := store.ResolveField(dagName, secretName, "user")
-	if err != nil {
-		return nil, fmt.Errorf("resolving %s.user: %w", secretName, err)
-	}
-	password, err := store.ResolveField(dagName, secretName, "password")
-	if err != nil {
-		return nil, fmt.Errorf("resolving %s.password: %w", secretName, err)
+		return nil, err
 	}
 
-	port := 21
-	if portStr, err := store.ResolveField(dagName, secretName, "port"); err == nil {
-		if p, err := strconv.Atoi(portStr); err == nil {
-			port = p
+	var rateLimit int64
+	if rateStr, err := store.ResolveField(dagName, secretName, "rate_limit"); err == nil {
+		if rateLimit, err = pitftp.ParseRateLimit(rateStr); err != nil {
+			return nil, fmt.Errorf("%s.rate_limit: %w", secretName, err)
 		}
 	}
 
-	useTLS := false
-	if tlsStr, err := store.ResolveField(dagName, secretName, "tls"); err == nil {
-		useTLS = tlsStr == "true"
+	client, err := pitftp.Connect(opts)
+	if err != nil {
+		return nil, err
 	}
-
-	return pitftp.Connect(host, port, user, password, useTLS)
+	client.SetRateLimit(rateLimit)
+	return client, nil
 }
 
 // makeFTPListHandler returns a handler that lists files on an FTP server.
@@ -125,7 +122,10 @@ func makeFTPDownloadHandler(store *secrets.Store, dagName string, dataDir string
 			}
 
 			for _, f := range files {
-				remotePath := directory + "/" + f.Name
+				if !remotepath.IsName(f.Name) {
+					return "", fmt.Errorf("server listed unsafe file name %q in %q", f.Name, directory)
+				}
+				remotePath := remotepath.Join(directory, f.Name)
 				localPath := filepath.Join(dataDir, f.Name)
 				if err := client.Download(remotePath, localPath); err != nil {
 					return "", fmt.Errorf("downloading %q: %w", f.Name, err)
@@ -139,7 +139,7 @@ func makeFTPDownloadHandler(store *secrets.Store, dagName string, dataDir string
 				return "", fmt.Errorf("missing required parameter: remote_path (or use directory+pattern for batch)")
 			}
 
-			fileName := filepath.Base(remotePath)
+			fileName := remotepath.Base(remotePath)
 			localPath := filepath.Join(dataDir, fileName)
 
 			// Prevent directory traversal
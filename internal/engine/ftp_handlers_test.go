@@ -2,11 +2,13 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	pitftp "github.com/druarnfield/pit/internal/ftp"
 	"github.com/druarnfield/pit/internal/secrets"
 )
 
@@ -233,3 +235,83 @@ key = "value"
 		})
 	}
 }
+
+func TestConnectFTP_InvalidRateLimit(t *testing.T) {
+	store := loadTestStore(t, `
+[global.throttled]
+host = "ftp.example.com"
+user = "u"
+password = "p"
+rate_limit = "fast"
+`)
+
+	_, err := connectFTP(store, "test", "throttled")
+	if err == nil || !strings.Contains(err.Error(), "throttled.rate_limit") {
+		t.Errorf("error = %v, want mention of throttled.rate_limit", err)
+	}
+}
+
+// TestFTPHandlers_EmbeddedServer runs the SDK's FTP methods against an
+// embedded server over each protocol.
+func TestFTPHandlers_EmbeddedServer(t *testing.T) {
+	for _, protocol := range []string{pitftp.ProtocolFTP, pitftp.ProtocolSFTP} {
+		t.Run(protocol, func(t *testing.T) {
+			root := t.TempDir()
+			os.MkdirAll(filepath.Join(root, "incoming"), 0o755)
+			os.MkdirAll(filepath.Join(root, "archive"), 0o755)
+			os.MkdirAll(filepath.Join(root, "outgoing"), 0o755)
+			for _, name := range []string{"sales_1.csv", "sales_2.csv", "notes.txt"} {
+				os.WriteFile(filepath.Join(root, "incoming", name), []byte(name), 0o644)
+			}
+			srv, err := pitftp.NewServer(pitftp.ServerOptions{Protocol: protocol, Root: root, User: "pit", Password: "secret"})
+			if err != nil {
+				t.Fatalf("NewServer() error: %v", err)
+			}
+			go srv.Serve()
+			defer srv.Close()
+
+			o := srv.ClientOptions()
+			store := loadTestStore(t, fmt.Sprintf(`
+[global.partner_ftp]
+host = %q
+port = "%d"
+user = %q
+password = %q
+protocol = %q
+host_key = %q
+`, o.Host, o.Port, o.User, o.Password, o.Protocol, o.HostKey))
+			dataDir := t.TempDir()
+			ctx := context.Background()
+
+			got, err := makeFTPListHandler(store, "test")(ctx, map[string]string{"secret": "partner_ftp", "directory": "/incoming", "pattern": "sales_*.csv"})
+			if err != nil {
+				t.Fatalf("ftp_list: %v", err)
+			}
+			if got != `["sales_1.csv","sales_2.csv"]` && got != `["sales_2.csv","sales_1.csv"]` {
+				t.Errorf("ftp_list = %s, want the two sales files", got)
+			}
+
+			if _, err := makeFTPDownloadHandler(store, "test", dataDir)(ctx, map[string]string{"secret": "partner_ftp", "directory": "/incoming", "pattern": "sales_*.csv"}); err != nil {
+				t.Fatalf("ftp_download: %v", err)
+			}
+			if b, _ := os.ReadFile(filepath.Join(dataDir, "sales_2.csv")); string(b) != "sales_2.csv" {
+				t.Errorf("downloaded sales_2.csv = %q", b)
+			}
+
+			if _, err := makeFTPMoveHandler(store, "test")(ctx, map[string]string{"secret": "partner_ftp", "src": "/incoming/sales_1.csv", "dst": "/archive/sales_1.csv"}); err != nil {
+				t.Fatalf("ftp_move: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "archive", "sales_1.csv")); err != nil {
+				t.Errorf("ftp_move left no archive/sales_1.csv: %v", err)
+			}
+
+			os.WriteFile(filepath.Join(dataDir, "report.csv"), []byte("totals"), 0o644)
+			if _, err := makeFTPUploadHandler(store, "test", dataDir)(ctx, map[string]string{"secret": "partner_ftp", "local_name": "report.csv", "remote_path": "/outgoing/report.csv"}); err != nil {
+				t.Fatalf("ftp_upload: %v", err)
+			}
+			if b, _ := os.ReadFile(filepath.Join(root, "outgoing", "report.csv")); string(b) != "totals" {
+				t.Errorf("uploaded report.csv = %q, want totals", b)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// httpErrorSnippet is how much of an error response body is quoted in the
+// task error.
+const httpErrorSnippet = 512
+
+// httpRequest is an http task's request after references are expanded.
+type httpRequest struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    string
+}
+
+// executeHTTPTask sends the request described by tc and writes the
+// response to tc.Output in the run's data directory. With pagination, the
+// records from every page are written as one JSON array. The log shows
+// tc.URL as written, so expanded secrets do not reach it.
+func executeHTTPTask(ctx context.Context, ti *TaskInstance, run *Run, tc *config.TaskConfig, logWriter io.Writer) error {
+	if ti.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ti.Timeout)
+		defer cancel()
+	}
+
+	req, err := newHTTPRequest(run, tc)
+	if err != nil {
+		return err
+	}
+	outPath := filepath.Join(run.DataDir, tc.Output)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("creating output dir: %w", err)
+	}
+
+	fmt.Fprintf(logWriter, "[http] %s %s\n", req.method, tc.URL)
+	if tc.Pagination == nil {
+		resp, err := req.do(ctx, req.url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("creating output: %w", err)
+		}
+		n, err := io.Copy(f, resp.Body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		fmt.Fprintf(logWriter, "[http] %s: wrote %s to data/%s\n", resp.Status, config.ByteSize(n), tc.Output)
+		return nil
+	}
+
+	items, err := fetchPages(ctx, req, tc.Pagination, logWriter)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("encoding records: %w", err)
+	}
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	fmt.Fprintf(logWriter, "[http] wrote %d record(s), %s to data/%s\n", len(items), config.ByteSize(len(data)), tc.Output)
+	return nil
+}
+
+// newHTTPRequest expands the references in tc's URL, headers, and body.
+func newHTTPRequest(run *Run, tc *config.TaskConfig) (*httpRequest, error) {
+	req := &httpRequest{method: strings.ToUpper(tc.Method), headers: make(map[string]string, len(tc.Headers))}
+	if req.method == "" {
+		req.method = http.MethodGet
+	}
+	var err error
+	if req.url, err = expandRefs(tc.URL, run); err != nil {
+		return nil, fmt.Errorf("url: %w", err)
+	}
+	for name, value := range tc.Headers {
+		if req.headers[name], err = expandRefs(value, run); err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+	if req.body, err = expandRefs(tc.Body, run); err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+	return req, nil
+}
+
+// do sends the request to target and returns the response if its status
+// is 2xx. The caller closes the body.
+func (r *httpRequest) do(ctx context.Context, target string) (*http.Response, error) {
+	var body io.Reader
+	if r.body != "" {
+		body = strings.NewReader(r.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", r.method, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, httpErrorSnippet))
+		return nil, fmt.Errorf("%s returned %s: %s", r.method, resp.Status, bytes.TrimSpace(snippet))
+	}
+	return resp, nil
+}
+
+// fetchPages requests pages until the pagination style says there are no
+// more, and returns the records from all of them.
+func fetchPages(ctx context.Context, req *httpRequest, p *config.HTTPPagination, logWriter io.Writer) ([]any, error) {
+	maxPages := p.MaxPages
+	if maxPages <= 0 {
+		maxPages = config.DefaultHTTPMaxPages
+	}
+	param := p.Param
+	if param == "" {
+		param = p.Style
+	}
+	pageNum := p.Start
+	if pageNum == 0 {
+		pageNum = 1
+	}
+
+	var items []any
+	target := req.url
+	if p.Style == config.PaginatePage {
+		target = withQuery(req.url, param, strconv.Itoa(pageNum))
+	}
+	for page := 1; ; page++ {
+		resp, err := req.do(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		dec := json.NewDecoder(resp.Body)
+		dec.UseNumber()
+		var doc any
+		err = dec.Decode(&doc)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("page %d: decoding JSON: %w", page, err)
+		}
+		records, err := jsonRecords(doc, p.ItemsPath)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", page, err)
+		}
+		items = append(items, records...)
+		fmt.Fprintf(logWriter, "[http] page %d: %d record(s)\n", page, len(records))
+
+		var next string
+		switch p.Style {
+		case config.PaginateLink:
+			next = nextLink(resp.Header.Values("Link"), target)
+		case config.PaginatePage:
+			if len(records) > 0 {
+				pageNum++
+				next = withQuery(req.url, param, strconv.Itoa(pageNum))
+			}
+		case config.PaginateCursor:
+			if cursor := jsonScalar(jsonPath(doc, p.CursorPath)); cursor != "" && len(records) > 0 {
+				next = withQuery(req.url, param, cursor)
+			}
+		}
+		if next == "" {
+			return items, nil
+		}
+		if page == maxPages {
+			return nil, fmt.Errorf("more pages remain after %d (raise pagination.max_pages)", maxPages)
+		}
+		target = next
+	}
+}
+
+// jsonRecords returns the array at path in doc ("" = doc itself).
+func jsonRecords(doc any, path string) ([]any, error) {
+	v := jsonPath(doc, path)
+	if v == nil {
+		return nil, nil
+	}
+	records, ok := v.([]any)
+	if !ok {
+		if path == "" {
+			return nil, fmt.Errorf("response is not a JSON array (set pagination.items_path)")
+		}
+		return nil, fmt.Errorf("%s is not a JSON array", path)
+	}
+	return records, nil
+}
+
+// jsonPath follows a dotted path of object keys and array indexes through
+// doc. Returns nil if any step is missing.
+func jsonPath(doc any, path string) any {
+	if path == "" {
+		return doc
+	}
+	v := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// jsonScalar formats a JSON string or number as a query value; anything
+// else is "".
+func jsonScalar(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	}
+	return ""
+}
+
+// withQuery returns rawURL with the query parameter name set to value.
+func withQuery(rawURL, name, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set(name, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// nextLink returns the rel="next" target from Link headers, resolved
+// against base, or "" if there is none.
+func nextLink(headers []string, base string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			if !hasRelNext(params) {
+				continue
+			}
+			ref, err := url.Parse(target[1 : len(target)-1])
+			if err != nil {
+				continue
+			}
+			baseURL, err := url.Parse(base)
+			if err != nil {
+				return ref.String()
+			}
+			return baseURL.ResolveReference(ref).String()
+		}
+	}
+	return ""
+}
+
+// hasRelNext reports whether Link parameters include rel="next".
+func hasRelNext(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+			if strings.EqualFold(rel, "next") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// httpSecrets is a SecretsResolver backed by a map; structured secrets are
+// keyed "secret.field".
+type httpSecrets map[string]string
+
+func (s httpSecrets) Resolve(project, key string) (string, error) {
+	if v, ok := s[key]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("secret %q not found", key)
+}
+
+func (s httpSecrets) ResolveField(project, secret, field string) (string, error) {
+	return s.Resolve(project, secret+"."+field)
+}
+
+func TestHTTPTask_SingleRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" || r.URL.Query().Get("since") != "2026-03-01" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"claims":[1,2]}`)
+	}))
+	defer srv.Close()
+
+	run := &Run{DAGName: "test", DataDir: t.TempDir(), Params: map[string]string{"since": "2026-03-01"}, SecretsResolver: httpSecrets{"api.token": "s3cret"}}
+	tc := &config.TaskConfig{
+		Name: "fetch", Type: "http", URL: srv.URL + "/claims?since=${param:since}", Output: "raw/claims.json",
+		Headers: map[string]string{"Authorization": "Bearer ${secret:api.token}"},
+	}
+	var log bytes.Buffer
+	if err := executeHTTPTask(context.Background(), &TaskInstance{Name: "fetch"}, run, tc, &log); err != nil {
+		t.Fatalf("executeHTTPTask() error: %v\n%s", err, log.String())
+	}
+	got, err := os.ReadFile(filepath.Join(run.DataDir, "raw", "claims.json"))
+	if err != nil || string(got) != `{"claims":[1,2]}` {
+		t.Errorf("output = %q (err %v), want the response body", got, err)
+	}
+	if strings.Contains(log.String(), "s3cret") {
+		t.Errorf("log leaks the secret:\n%s", log.String())
+	}
+}
+
+func TestHTTPTask_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	run := &Run{DAGName: "test", DataDir: t.TempDir()}
+	tc := &config.TaskConfig{Name: "fetch", Type: "http", URL: srv.URL, Output: "out.json"}
+	err := executeHTTPTask(context.Background(), &TaskInstance{Name: "fetch"}, run, tc, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "429 Too Many Requests: rate limited") {
+		t.Errorf("executeHTTPTask() error = %v, want the status and body", err)
+	}
+}
+
+func TestHTTPTask_Pagination(t *testing.T) {
+	// Three pages of two records, then an empty page
+	pageOf := func(n int) []int {
+		if n < 1 || n > 3 {
+			return []int{}
+		}
+		return []int{n*10 + 1, n*10 + 2}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/page":
+			n, _ := strconv.Atoi(q.Get("p"))
+			json.NewEncoder(w).Encode(map[string]any{"data": pageOf(n)})
+		case "/link":
+			n, _ := strconv.Atoi(q.Get("page"))
+			if n == 0 {
+				n = 1
+			}
+			if n < 3 {
+				w.Header().Set("Link", fmt.Sprintf(`</link?page=%d>; rel="next", </link?page=3>; rel="last"`, n+1))
+			}
+			json.NewEncoder(w).Encode(pageOf(n))
+		case "/cursor":
+			n := 1
+			if c := q.Get("after"); c != "" {
+				n, _ = strconv.Atoi(strings.TrimPrefix(c, "c"))
+			}
+			next := ""
+			if n < 3 {
+				next = fmt.Sprintf("c%d", n+1)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"items": pageOf(n), "meta": map[string]any{"next": next}})
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		path string
+		p    config.HTTPPagination
+	}{
+		{"page", "/page", config.HTTPPagination{Style: config.PaginatePage, Param: "p", ItemsPath: "data"}},
+		{"link", "/link", config.HTTPPagination{Style: config.PaginateLink}},
+		{"cursor", "/cursor", config.HTTPPagination{Style: config.PaginateCursor, Param: "after", CursorPath: "meta.next", ItemsPath: "items"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &Run{DAGName: "test", DataDir: t.TempDir()}
+			tc := &config.TaskConfig{Name: "fetch", Type: "http", URL: srv.URL + tt.path, Output: "all.json", Pagination: &tt.p}
+			var log bytes.Buffer
+			if err := executeHTTPTask(context.Background(), &TaskInstance{Name: "fetch"}, run, tc, &log); err != nil {
+				t.Fatalf("executeHTTPTask() error: %v\n%s", err, log.String())
+			}
+			got, _ := os.ReadFile(filepath.Join(run.DataDir, "all.json"))
+			if string(got) != "[11,12,21,22,31,32]" {
+				t.Errorf("output = %s, want the records from all three pages\n%s", got, log.String())
+			}
+		})
+	}
+
+	t.Run("max pages", func(t *testing.T) {
+		run := &Run{DAGName: "test", DataDir: t.TempDir()}
+		tc := &config.TaskConfig{Name: "fetch", Type: "http", URL: srv.URL + "/link", Output: "all.json",
+			Pagination: &config.HTTPPagination{Style: config.PaginateLink, MaxPages: 2}}
+		err := executeHTTPTask(context.Background(), &TaskInstance{Name: "fetch"}, run, tc, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "more pages remain after 2") {
+			t.Errorf("executeHTTPTask() error = %v, want max_pages error", err)
+		}
+	})
+}
+
+func TestExpandRefs(t *testing.T) {
+	run := &Run{DAGName: "test", Params: map[string]string{"day": "2026-03-01"}, SecretsResolver: httpSecrets{"key": "k1", "api.user": "svc"}}
+	got, err := expandRefs("${secret:key}/${secret:api.user}/${param:day}", run)
+	if err != nil || got != "k1/svc/2026-03-01" {
+		t.Errorf("expandRefs() = %q, %v; want k1/svc/2026-03-01", got, err)
+	}
+	if _, err := expandRefs("${param:missing}", run); err == nil || !strings.Contains(err.Error(), `unknown param "missing"`) {
+		t.Errorf("expandRefs(missing param) error = %v", err)
+	}
+	if _, err := expandRefs("${secret:key}", &Run{}); err == nil || !strings.Contains(err.Error(), "secrets store not configured") {
+		t.Errorf("expandRefs(no secrets) error = %v", err)
+	}
+}
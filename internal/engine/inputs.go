@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/remotepath"
+	"github.com/druarnfield/pit/internal/sdk"
+)
+
+// inputManifestName is the file in the run directory listing where each
+// file seeded or downloaded into data/ came from.
+const inputManifestName = "inputs.json"
+
+// Input sources recorded for files in the data directory.
+const (
+	InputSourceSeed        = "seed"         // copied from DataSeedDir with no recorded origin
+	InputSourceFTPWatch    = "ftp_watch"    // downloaded by the ftp_watch trigger before the run
+	InputSourceFTPDownload = "ftp_download" // downloaded by a task through the SDK
+)
+
+// InputFile records where a file in the run's data directory came from.
+type InputFile struct {
+	Path      string    `json:"path"`             // relative to the data directory, slash-separated
+	Source    string    `json:"source"`           // an InputSource constant
+	Remote    string    `json:"remote,omitempty"` // where it was fetched from, e.g. ftp://partner/in/sales.csv
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// SeedOrigin says where a file in ExecuteOpts.DataSeedDir was fetched from,
+// for callers that download files before the run.
+type SeedOrigin struct {
+	Source    string    `json:"source"`
+	Remote    string    `json:"remote,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// FTPRemote names a file on an FTP server the way input records and
+// lineage events do: ftp://<server>/<path>, where server is the connection
+// secret's name or the host.
+func FTPRemote(server, path string) string {
+	return ftpNamespace(server) + "/" + strings.TrimPrefix(path, "/")
+}
+
+// runInputs collects the run's input files. Safe for concurrent use by
+// SDK handlers.
+type runInputs struct {
+	mu    sync.Mutex
+	files map[string]InputFile
+}
+
+// record hashes the file at localPath, which must be inside dataDir, and
+// records it. A later record for the same path replaces the earlier one.
+func (in *runInputs) record(dataDir, localPath, source, remote string, fetchedAt time.Time) error {
+	rel, err := filepath.Rel(dataDir, localPath)
+	if err != nil {
+		return err
+	}
+	st, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	sum, err := fileSHA256(localPath)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.files == nil {
+		in.files = make(map[string]InputFile)
+	}
+	path := filepath.ToSlash(rel)
+	in.files[path] = InputFile{
+		Path:      path,
+		Source:    source,
+		Remote:    remote,
+		Size:      st.Size(),
+		SHA256:    sum,
+		FetchedAt: fetchedAt.UTC(),
+	}
+	return nil
+}
+
+// list returns the recorded inputs sorted by path.
+func (in *runInputs) list() []InputFile {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	files := make([]InputFile, 0, len(in.files))
+	for _, f := range in.files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// recordSeededInputs records each file copied from seedDir into dataDir,
+// with its origin if the caller gave one. Files the copy skipped, such as
+// symlinks, are not in dataDir and are not recorded.
+func (in *runInputs) recordSeededInputs(seedDir, dataDir string, origins map[string]SeedOrigin, seededAt time.Time) error {
+	return filepath.WalkDir(seedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(seedDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dataDir, rel)
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			return nil
+		}
+		origin, ok := origins[filepath.ToSlash(rel)]
+		if !ok {
+			origin = SeedOrigin{Source: InputSourceSeed, FetchedAt: seededAt}
+		}
+		if origin.FetchedAt.IsZero() {
+			origin.FetchedAt = seededAt
+		}
+		return in.record(dataDir, dst, origin.Source, origin.Remote, origin.FetchedAt)
+	})
+}
+
+// writeInputManifest writes the recorded inputs into runDir as JSON.
+// Nothing is written when the run had no inputs.
+func writeInputManifest(runDir string, files []InputFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(runDir, inputManifestName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing input manifest: %w", err)
+	}
+	return nil
+}
+
+// withFTPDownloadInputs wraps the ftp_download handler so each downloaded
+// file is recorded as a run input, named by the connection secret like
+// lineage datasets.
+func withFTPDownloadInputs(h sdk.HandlerFunc, inputs *runInputs, dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		result, err := h(ctx, params)
+		if err != nil {
+			return result, err
+		}
+		var local []string
+		if json.Unmarshal([]byte(result), &local) != nil {
+			return result, nil
+		}
+		now := time.Now()
+		for _, p := range local {
+			remote := params["remote_path"]
+			if params["pattern"] != "" {
+				remote = remotepath.Join(params["directory"], filepath.Base(p))
+			}
+			// The download succeeded; a file that cannot be hashed is left
+			// out of the manifest rather than failing the task.
+			if err := inputs.record(dataDir, p, InputSourceFTPDownload, FTPRemote(params["secret"], remote), now); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: recording input %s: %v\n", filepath.Base(p), err)
+			}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestRecordSeededInputs(t *testing.T) {
+	seedDir, dataDir := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(seedDir, "lookup"), 0o755)
+	os.WriteFile(filepath.Join(seedDir, "sales.csv"), []byte("id\n1\n"), 0o644)
+	os.WriteFile(filepath.Join(seedDir, "lookup", "codes.csv"), []byte("code\n"), 0o644)
+	os.WriteFile(filepath.Join(seedDir, "skipped.csv"), []byte("x\n"), 0o644)
+	os.MkdirAll(filepath.Join(dataDir, "lookup"), 0o755)
+	os.WriteFile(filepath.Join(dataDir, "sales.csv"), []byte("id\n1\n"), 0o644)
+	os.WriteFile(filepath.Join(dataDir, "lookup", "codes.csv"), []byte("code\n"), 0o644)
+
+	fetched := time.Date(2026, 3, 1, 5, 59, 0, 0, time.UTC)
+	seeded := fetched.Add(time.Minute)
+	origins := map[string]SeedOrigin{
+		"sales.csv": {Source: InputSourceFTPWatch, Remote: FTPRemote("partner", "/in/sales.csv"), FetchedAt: fetched},
+	}
+	inputs := &runInputs{}
+	if err := inputs.recordSeededInputs(seedDir, dataDir, origins, seeded); err != nil {
+		t.Fatalf("recordSeededInputs() error: %v", err)
+	}
+
+	want := []InputFile{
+		{Path: "lookup/codes.csv", Source: InputSourceSeed, Size: 5, FetchedAt: seeded},
+		{Path: "sales.csv", Source: InputSourceFTPWatch, Remote: "ftp://partner/in/sales.csv", Size: 5, FetchedAt: fetched},
+	}
+	got := inputs.list()
+	if len(got) != len(want) {
+		t.Fatalf("inputs = %+v, want %d files (skipped.csv was not copied)", got, len(want))
+	}
+	for i, w := range want {
+		sum, _ := fileSHA256(filepath.Join(dataDir, filepath.FromSlash(w.Path)))
+		w.SHA256 = sum
+		if got[i] != w {
+			t.Errorf("inputs[%d] = %+v, want %+v", i, got[i], w)
+		}
+	}
+}
+
+func TestWithFTPDownloadInputs(t *testing.T) {
+	dataDir := t.TempDir()
+	for _, name := range []string{"a.csv", "b.csv"} {
+		os.WriteFile(filepath.Join(dataDir, name), []byte(name), 0o644)
+	}
+	download := func(ctx context.Context, params map[string]string) (string, error) {
+		if params["pattern"] != "" {
+			b, _ := json.Marshal([]string{filepath.Join(dataDir, "a.csv"), filepath.Join(dataDir, "b.csv")})
+			return string(b), nil
+		}
+		b, _ := json.Marshal([]string{filepath.Join(dataDir, "a.csv")})
+		return string(b), nil
+	}
+
+	inputs := &runInputs{}
+	h := withFTPDownloadInputs(download, inputs, dataDir)
+	h(context.Background(), map[string]string{"secret": "partner", "directory": "/out", "pattern": "*.csv"})
+	h(context.Background(), map[string]string{"secret": "partner", "remote_path": "/archive/a.csv"})
+
+	got := inputs.list()
+	if len(got) != 2 {
+		t.Fatalf("inputs = %+v, want 2", got)
+	}
+	// The single-file download of a.csv replaced the batch record
+	wantRemote := map[string]string{
+		"a.csv": "ftp://partner/archive/a.csv",
+		"b.csv": "ftp://partner/out/b.csv",
+	}
+	for _, f := range got {
+		if f.Source != InputSourceFTPDownload || f.Remote != wantRemote[f.Path] || f.Size != 5 || f.SHA256 == "" {
+			t.Errorf("input %+v, want ftp_download from %s", f, wantRemote[f.Path])
+		}
+	}
+}
+
+func TestExecute_InputManifest(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	projectDir, seedDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(projectDir, "pit.toml"), []byte("[dag]\nname = \"inputs\"\n\n[[tasks]]\nname = \"a\"\nscript = \"a.sh\"\n"), 0o644)
+	os.WriteFile(filepath.Join(projectDir, "a.sh"), []byte("test -f \"$PIT_DATA_DIR/sales.csv\"\n"), 0o755)
+	os.WriteFile(filepath.Join(seedDir, "sales.csv"), []byte("id\n1\n"), 0o644)
+	cfg, err := config.Load(filepath.Join(projectDir, "pit.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	run, err := Execute(context.Background(), cfg, ExecuteOpts{
+		RunsDir:     t.TempDir(),
+		DataSeedDir: seedDir,
+		Output:      &out,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if run.Status != StatusSuccess {
+		t.Fatalf("run status = %s, want success\n%s", run.Status, out.String())
+	}
+
+	if len(run.Inputs) != 1 || run.Inputs[0].Path != "sales.csv" || run.Inputs[0].Source != InputSourceSeed {
+		t.Fatalf("run.Inputs = %+v, want sales.csv from seed", run.Inputs)
+	}
+	data, err := os.ReadFile(filepath.Join(run.RunDir, inputManifestName))
+	if err != nil {
+		t.Fatalf("reading input manifest: %v", err)
+	}
+	var manifest []InputFile
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("parsing input manifest: %v", err)
+	}
+	if len(manifest) != 1 || manifest[0].SHA256 != run.Inputs[0].SHA256 {
+		t.Errorf("manifest = %+v, want %+v", manifest, run.Inputs)
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialDirPrefix names the per-run directories under the system temp
+// dir that hold plaintext credential files such as generated dbt profiles.
+const credentialDirPrefix = "pit-cred-"
+
+// credentialOwnerFile records which process owns a credential directory,
+// in the same format as a DAG lock, so sweepCredentials can tell when the
+// owner has died.
+const credentialOwnerFile = ".owner"
+
+// janitor owns a run's temporary credential material. Tasks write into
+// the directory returned by dir and remove their own files when they
+// finish; cleanup removes whatever is left when the run ends, including
+// files from tasks that panicked or were cancelled. Directories left by a
+// process that was killed outright are removed by sweepCredentials.
+type janitor struct {
+	runID string
+
+	mu   sync.Mutex
+	root string
+}
+
+func newJanitor(runID string) *janitor {
+	return &janitor{runID: runID}
+}
+
+// dir returns the run's credential directory, creating it on first use.
+// A nil janitor returns "", which callers pass to os.MkdirTemp to use the
+// system temp dir.
+func (j *janitor) dir() (string, error) {
+	if j == nil {
+		return "", nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.root != "" {
+		return j.root, nil
+	}
+
+	root, err := os.MkdirTemp("", credentialDirPrefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("creating credential dir: %w", err)
+	}
+	host, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{PID: os.Getpid(), Host: host, RunID: j.runID, StartedAt: time.Now().UTC()})
+	if err == nil {
+		err = os.WriteFile(filepath.Join(root, credentialOwnerFile), data, 0o600)
+	}
+	if err != nil {
+		os.RemoveAll(root)
+		return "", fmt.Errorf("writing credential dir owner: %w", err)
+	}
+	j.root = root
+	return root, nil
+}
+
+// cleanup removes the credential directory and everything in it. It is
+// safe to call more than once.
+func (j *janitor) cleanup() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.root == "" {
+		return
+	}
+	if err := os.RemoveAll(j.root); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: removing credential dir %s: %v\n", j.root, err)
+		return
+	}
+	j.root = ""
+}
+
+// sweepCredentials removes credential directories in tmpDir left by runs
+// whose process is no longer alive, such as one killed while a dbt task
+// held a generated profiles.yml. Directories owned by live processes or by
+// other hosts are left alone. It returns the number of directories removed.
+func sweepCredentials(tmpDir string) int {
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return 0
+	}
+	host, _ := os.Hostname()
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), credentialDirPrefix) {
+			continue
+		}
+		dir := filepath.Join(tmpDir, e.Name())
+		_, stale, err := inspectLock(filepath.Join(dir, credentialOwnerFile), host)
+		if os.IsNotExist(err) {
+			// Killed between creating the directory and writing the owner
+			// file; give a live run the same grace as an unreadable lock.
+			st, serr := os.Stat(dir)
+			stale = serr == nil && time.Since(st.ModTime()) > unreadableLockGrace
+		} else if err != nil {
+			continue
+		}
+		if stale && os.RemoveAll(dir) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// credentialDirs lists the credential directories in tmpDir.
+func credentialDirs(t *testing.T, tmpDir string) []string {
+	t.Helper()
+	dirs, err := filepath.Glob(filepath.Join(tmpDir, credentialDirPrefix+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dirs
+}
+
+func TestJanitor_Cleanup(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	j := newJanitor("run1")
+	dir, err := j.dir()
+	if err != nil {
+		t.Fatalf("dir() error: %v", err)
+	}
+	if again, _ := j.dir(); again != dir {
+		t.Errorf("second dir() = %q, want %q", again, dir)
+	}
+	if st, err := os.Stat(dir); err != nil || st.Mode().Perm() != 0o700 {
+		t.Fatalf("credential dir mode = %v (%v), want 0700", st.Mode().Perm(), err)
+	}
+	var owner LockInfo
+	data, _ := os.ReadFile(filepath.Join(dir, credentialOwnerFile))
+	if err := json.Unmarshal(data, &owner); err != nil || owner.PID != os.Getpid() || owner.RunID != "run1" {
+		t.Errorf("owner = %+v (%v), want this process and run1", owner, err)
+	}
+	os.WriteFile(filepath.Join(dir, "profiles.yml"), []byte("password: x\n"), 0o600)
+
+	j.cleanup()
+	j.cleanup()
+	if dirs := credentialDirs(t, tmp); len(dirs) != 0 {
+		t.Errorf("credential dirs after cleanup = %v, want none", dirs)
+	}
+
+	// A nil janitor falls back to the system temp dir and cleans nothing.
+	var nilJanitor *janitor
+	if dir, err := nilJanitor.dir(); dir != "" || err != nil {
+		t.Errorf("nil dir() = %q, %v; want \"\", nil", dir, err)
+	}
+	nilJanitor.cleanup()
+}
+
+func TestSweepCredentials(t *testing.T) {
+	tmp := t.TempDir()
+	host, _ := os.Hostname()
+	mkdir := func(name string, owner *LockInfo) string {
+		t.Helper()
+		dir := filepath.Join(tmp, credentialDirPrefix+name)
+		os.MkdirAll(filepath.Join(dir, "pit-dbt-profiles-1"), 0o700)
+		if owner != nil {
+			data, _ := json.Marshal(owner)
+			os.WriteFile(filepath.Join(dir, credentialOwnerFile), data, 0o600)
+		}
+		return dir
+	}
+
+	// A PID above any kernel's pid_max is never running.
+	dead := mkdir("dead", &LockInfo{PID: 1 << 30, Host: host, RunID: "killed"})
+	live := mkdir("live", &LockInfo{PID: os.Getpid(), Host: host, RunID: "running"})
+	remote := mkdir("remote", &LockInfo{PID: 1 << 30, Host: host + "-elsewhere", RunID: "remote"})
+	fresh := mkdir("fresh", nil)
+	orphan := mkdir("orphan", nil)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(orphan, old, old)
+	other := filepath.Join(tmp, "pit-dbt-profiles-unrelated")
+	os.MkdirAll(other, 0o700)
+
+	if n := sweepCredentials(tmp); n != 2 {
+		t.Errorf("sweepCredentials() = %d, want 2", n)
+	}
+	for _, dir := range []string{dead, orphan} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", filepath.Base(dir))
+		}
+	}
+	for _, dir := range []string{live, remote, fresh, other} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s should have been kept: %v", filepath.Base(dir), err)
+		}
+	}
+}
+
+// panicSecrets panics when asked for a password, standing in for a bug
+// that aborts a dbt task after its credential dir exists.
+type panicSecrets struct{ httpSecrets }
+
+func (s panicSecrets) ResolveField(project, secret, field string) (string, error) {
+	if field == "password" {
+		panic("resolver exploded")
+	}
+	return s.httpSecrets.ResolveField(project, secret, field)
+}
+
+func TestExecuteTask_DBTProfilesCleanup(t *testing.T) {
+	fields := httpSecrets{
+		"warehouse.host": "db", "warehouse.port": "1433", "warehouse.database": "dw",
+		"warehouse.schema": "dbo", "warehouse.user": "pit", "warehouse.password": "hunter2",
+	}
+	tests := []struct {
+		name     string
+		resolver SecretsResolver
+		wantErr  string
+	}{
+		// The dbt runner rejects the config after the profile was written.
+		{"runner error", fields, "version is required"},
+		{"panic", panicSecrets{fields}, "task panicked: resolver exploded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+
+			runDir := t.TempDir()
+			run := &Run{
+				ID:              "dbt_cleanup",
+				DAGName:         "test",
+				SnapshotDir:     filepath.Join(runDir, "project"),
+				LogDir:          filepath.Join(runDir, "logs"),
+				DataDir:         filepath.Join(runDir, "data"),
+				SecretsResolver: tt.resolver,
+				janitor:         newJanitor("dbt_cleanup"),
+			}
+			for _, dir := range []string{run.SnapshotDir, run.LogDir, run.DataDir} {
+				os.MkdirAll(dir, 0o755)
+			}
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test", DBT: &config.DBTConfig{Connection: "warehouse"}}}
+			ti := &TaskInstance{Name: "models", Script: "build", Runner: "dbt", Status: StatusPending}
+			run.Tasks = []*TaskInstance{ti}
+
+			executeTask(context.Background(), ti, run, cfg, ExecuteOpts{})
+
+			if ti.Status != StatusFailed || ti.Error == nil || !strings.Contains(ti.Error.Error(), tt.wantErr) {
+				t.Fatalf("task = %s (%v), want failed with %q", ti.Status, ti.Error, tt.wantErr)
+			}
+			profiles, _ := filepath.Glob(filepath.Join(tmp, credentialDirPrefix+"*", "pit-dbt-profiles-*"))
+			if len(profiles) != 0 {
+				t.Errorf("profiles dirs left after the task = %v, want none", profiles)
+			}
+
+			run.janitor.cleanup()
+			if dirs := credentialDirs(t, tmp); len(dirs) != 0 {
+				t.Errorf("credential dirs after the run = %v, want none", dirs)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/lineage"
+	"github.com/druarnfield/pit/internal/remotepath"
+	"github.com/druarnfield/pit/internal/sdk"
+)
+
+// runDatasets collects the datasets a run reads and writes, for its
+// lineage events. Safe for concurrent use by SDK handlers.
+type runDatasets struct {
+	mu      sync.Mutex
+	inputs  map[lineage.Dataset]bool
+	outputs map[lineage.Dataset]bool
+}
+
+func (d *runDatasets) addInput(namespace, name string) {
+	d.add(&d.inputs, namespace, name)
+}
+
+func (d *runDatasets) addOutput(namespace, name string) {
+	d.add(&d.outputs, namespace, name)
+}
+
+func (d *runDatasets) add(set *map[lineage.Dataset]bool, namespace, name string) {
+	if d == nil || namespace == "" || name == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if *set == nil {
+		*set = make(map[lineage.Dataset]bool)
+	}
+	(*set)[lineage.Dataset{Namespace: namespace, Name: name}] = true
+}
+
+// lists returns the inputs and outputs collected so far, sorted.
+func (d *runDatasets) lists() (inputs, outputs []lineage.Dataset) {
+	if d == nil {
+		return []lineage.Dataset{}, []lineage.Dataset{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return sortedDatasets(d.inputs), sortedDatasets(d.outputs)
+}
+
+func sortedDatasets(set map[lineage.Dataset]bool) []lineage.Dataset {
+	list := make([]lineage.Dataset, 0, len(set))
+	for ds := range set {
+		list = append(list, ds)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Namespace != list[j].Namespace {
+			return list[i].Namespace < list[j].Namespace
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// ftpNamespace is the dataset namespace for files on an FTP server, named
+// after its secret (or host, for the older ftp_watch settings).
+func ftpNamespace(server string) string {
+	return "ftp://" + server
+}
+
+// addTriggerFiles records the files an ftp_watch trigger delivered as run
+// inputs.
+func (d *runDatasets) addTriggerFiles(w *config.FTPWatchConfig, files []string) {
+	if w == nil {
+		return
+	}
+	server := w.Secret
+	if server == "" {
+		server = w.Host
+	}
+	for _, f := range files {
+		d.addInput(ftpNamespace(server), remotepath.Join(w.Directory, f))
+	}
+}
+
+// loadTarget is the dataset name of a load target: the table, qualified
+// by schema when one is known.
+func loadTarget(schema, table string) string {
+	if s, t := parseSchemaTable(table); s != "" {
+		return s + "." + t
+	}
+	if schema == "" {
+		return table
+	}
+	return schema + "." + table
+}
+
+// withFTPDownloadLineage wraps the ftp_download handler so downloaded files
+// are recorded as run inputs.
+func withFTPDownloadLineage(h sdk.HandlerFunc, datasets *runDatasets) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		result, err := h(ctx, params)
+		if err != nil {
+			return result, err
+		}
+		ns := ftpNamespace(params["secret"])
+		if params["pattern"] == "" {
+			datasets.addInput(ns, params["remote_path"])
+			return result, nil
+		}
+		var local []string
+		if json.Unmarshal([]byte(result), &local) == nil {
+			for _, p := range local {
+				datasets.addInput(ns, remotepath.Join(params["directory"], filepath.Base(p)))
+			}
+		}
+		return result, nil
+	}
+}
+
+// withLoadLineage wraps the load_data and load_dataset handlers so the
+// target table is recorded as a run output, namespaced by connection.
+func withLoadLineage(h sdk.HandlerFunc, defaults loadDefaults, datasets *runDatasets) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		result, err := h(ctx, params)
+		if err != nil {
+			return result, err
+		}
+		schema := params["schema"]
+		if schema == "" {
+			schema = defaults.schema
+		}
+		datasets.addOutput(params["connection"], loadTarget(schema, params["table"]))
+		return result, nil
+	}
+}
+
+// emitLineage sends a lineage event for the run, if an endpoint is
+// configured. Failures are warnings: lineage never fails a run.
+func emitLineage(cfg *config.LineageConfig, run *Run, eventType string, at time.Time) {
+	if cfg == nil {
+		return
+	}
+	client := &lineage.Client{URL: cfg.URL, Token: cfg.Token, Namespace: cfg.Namespace}
+	ev := client.NewEvent(eventType, run.DAGName, run.ID, at)
+	ev.Inputs, ev.Outputs = run.Datasets.lists()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Emit(ctx, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: lineage: %v\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/lineage"
+)
+
+func TestLoadTarget(t *testing.T) {
+	tests := []struct {
+		schema, table, want string
+	}{
+		{"", "sales", "sales"},
+		{"dbo", "sales", "dbo.sales"},
+		{"dbo", "staging.sales", "staging.sales"},
+	}
+	for _, tt := range tests {
+		if got := loadTarget(tt.schema, tt.table); got != tt.want {
+			t.Errorf("loadTarget(%q, %q) = %q, want %q", tt.schema, tt.table, got, tt.want)
+		}
+	}
+}
+
+func TestRunDatasets(t *testing.T) {
+	d := &runDatasets{}
+	d.addTriggerFiles(&config.FTPWatchConfig{Secret: "partner_ftp", Directory: "/inbox"}, []string{"b.csv", "a.csv"})
+	d.addOutput("warehouse", "dbo.sales")
+	d.addOutput("warehouse", "dbo.sales")
+	d.addOutput("", "ignored")
+
+	inputs, outputs := d.lists()
+	wantIn := []lineage.Dataset{
+		{Namespace: "ftp://partner_ftp", Name: "/inbox/a.csv"},
+		{Namespace: "ftp://partner_ftp", Name: "/inbox/b.csv"},
+	}
+	if !reflect.DeepEqual(inputs, wantIn) {
+		t.Errorf("inputs = %+v, want %+v", inputs, wantIn)
+	}
+	wantOut := []lineage.Dataset{{Namespace: "warehouse", Name: "dbo.sales"}}
+	if !reflect.DeepEqual(outputs, wantOut) {
+		t.Errorf("outputs = %+v, want %+v", outputs, wantOut)
+	}
+}
+
+func TestLineageHandlers(t *testing.T) {
+	d := &runDatasets{}
+	ok := func(result string) func(context.Context, map[string]string) (string, error) {
+		return func(context.Context, map[string]string) (string, error) { return result, nil }
+	}
+
+	download := withFTPDownloadLineage(ok(`["/runs/x/data/a.csv","/runs/x/data/b.csv"]`), d)
+	download(context.Background(), map[string]string{"secret": "partner_ftp", "directory": "/inbox", "pattern": "*.csv"})
+	download = withFTPDownloadLineage(ok(`["/runs/x/data/c.csv"]`), d)
+	download(context.Background(), map[string]string{"secret": "partner_ftp", "remote_path": "/archive/c.csv"})
+
+	load := withLoadLineage(ok("10 rows loaded"), loadDefaults{schema: "dbo"}, d)
+	load(context.Background(), map[string]string{"connection": "warehouse", "table": "sales"})
+	load(context.Background(), map[string]string{"connection": "warehouse", "table": "orders", "schema": "staging"})
+
+	inputs, outputs := d.lists()
+	wantIn := []lineage.Dataset{
+		{Namespace: "ftp://partner_ftp", Name: "/archive/c.csv"},
+		{Namespace: "ftp://partner_ftp", Name: "/inbox/a.csv"},
+		{Namespace: "ftp://partner_ftp", Name: "/inbox/b.csv"},
+	}
+	if !reflect.DeepEqual(inputs, wantIn) {
+		t.Errorf("inputs = %+v, want %+v", inputs, wantIn)
+	}
+	wantOut := []lineage.Dataset{
+		{Namespace: "warehouse", Name: "dbo.sales"},
+		{Namespace: "warehouse", Name: "staging.orders"},
+	}
+	if !reflect.DeepEqual(outputs, wantOut) {
+		t.Errorf("outputs = %+v, want %+v", outputs, wantOut)
+	}
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockDirName is the directory inside the runs dir holding per-DAG lock
+// files. DiscoverRuns ignores it because it is not a valid run ID.
+const lockDirName = ".locks"
+
+// lockPollInterval is how often WaitDAGLock retries a held lock.
+var lockPollInterval = time.Second
+
+// unreadableLockGrace is how long a lock file that cannot be parsed is
+// treated as held, covering the moment between creating and writing it.
+const unreadableLockGrace = 10 * time.Second
+
+// LockInfo is the content of a DAG lock file.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	RunID     string    `json:"run_id"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+func (i LockInfo) String() string {
+	return fmt.Sprintf("run %s (pid %d on %s, since %s)",
+		i.RunID, i.PID, i.Host, i.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// LockedError is returned by TryDAGLock when another live process holds
+// the DAG's lock, and by TryDAGSlot when live processes hold all of its
+// slots.
+type LockedError struct {
+	DAGName string
+	Holder  LockInfo // for slots, the longest-running holder
+	Max     int      // number of slots (0 for TryDAGLock)
+}
+
+func (e *LockedError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("DAG %q already has %d active run(s) (max_active_runs), the oldest %s", e.DAGName, e.Max, e.Holder)
+	}
+	return fmt.Sprintf("DAG %q is already running: %s", e.DAGName, e.Holder)
+}
+
+// DAGLock is an exclusive per-DAG lock file in the runs directory. It lets
+// concurrent `pit run` invocations of the same DAG honour dag.overlap.
+type DAGLock struct {
+	path string
+	data []byte
+}
+
+// DAGLockPath returns the lock file path for dagName under runsDir.
+func DAGLockPath(runsDir, dagName string) string {
+	return filepath.Join(runsDir, lockDirName, dagName+".lock")
+}
+
+// TryDAGLock acquires the lock for dagName without waiting. If a live
+// process holds it, a *LockedError is returned. Locks left by processes
+// on this host that are no longer running are removed and taken over.
+func TryDAGLock(runsDir, dagName, runID string) (*DAGLock, error) {
+	return tryLock(DAGLockPath(runsDir, dagName), dagName, runID)
+}
+
+// dagSlotPath returns the lock file path of run slot n (from 1) of dagName.
+func dagSlotPath(runsDir, dagName string, n int) string {
+	return filepath.Join(runsDir, lockDirName, dagName+".slots", fmt.Sprintf("%d.lock", n))
+}
+
+// TryDAGSlot acquires one of the slots run slots of dagName without
+// waiting, so that processes running the DAG together honour
+// dag.max_active_runs. If live processes hold every slot, a *LockedError
+// is returned.
+func TryDAGSlot(runsDir, dagName, runID string, slots int) (*DAGLock, error) {
+	var oldest *LockedError
+	for n := 1; n <= slots; n++ {
+		lock, err := tryLock(dagSlotPath(runsDir, dagName, n), dagName, runID)
+		var locked *LockedError
+		if !errors.As(err, &locked) {
+			return lock, err
+		}
+		if oldest == nil || locked.Holder.StartedAt.Before(oldest.Holder.StartedAt) {
+			oldest = locked
+		}
+	}
+	return nil, &LockedError{DAGName: dagName, Holder: oldest.Holder, Max: slots}
+}
+
+// tryLock creates the lock file at path, taking over a stale one.
+func tryLock(path, dagName, runID string) (*DAGLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{PID: os.Getpid(), Host: host, RunID: runID, StartedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Two attempts: the second follows removal of a stale lock.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createExclusive(path, data)
+		if err == nil {
+			return &DAGLock{path: path, data: data}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+
+		holder, stale, err := inspectLock(path, host)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // released between our create and read
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !stale {
+			return nil, &LockedError{DAGName: dagName, Holder: holder}
+		}
+		if err := removeStaleLock(path); err != nil {
+			return nil, err
+		}
+	}
+	holder, _, _ := inspectLock(path, host)
+	return nil, &LockedError{DAGName: dagName, Holder: holder}
+}
+
+// WaitDAGLock acquires the lock for dagName, polling until the current
+// holder releases it or ctx is done. onWait, if non-nil, is called once
+// with the holder when the first attempt finds the lock taken.
+func WaitDAGLock(ctx context.Context, runsDir, dagName, runID string, onWait func(LockInfo)) (*DAGLock, error) {
+	return waitLock(ctx, func() (*DAGLock, error) { return TryDAGLock(runsDir, dagName, runID) }, onWait)
+}
+
+// WaitDAGSlot acquires one of the slots run slots of dagName like TryDAGSlot,
+// polling until a slot is released or ctx is done. onWait is called as
+// for WaitDAGLock.
+func WaitDAGSlot(ctx context.Context, runsDir, dagName, runID string, slots int, onWait func(LockInfo)) (*DAGLock, error) {
+	return waitLock(ctx, func() (*DAGLock, error) { return TryDAGSlot(runsDir, dagName, runID, slots) }, onWait)
+}
+
+func waitLock(ctx context.Context, try func() (*DAGLock, error), onWait func(LockInfo)) (*DAGLock, error) {
+	notified := false
+	for {
+		lock, err := try()
+		var locked *LockedError
+		if !errors.As(err, &locked) {
+			return lock, err
+		}
+		if !notified && onWait != nil {
+			onWait(locked.Holder)
+			notified = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for %s: %w", locked.Holder, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Release removes the lock file if it still belongs to this lock.
+func (l *DAGLock) Release() error {
+	current, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading lock file: %w", err)
+	}
+	if !bytes.Equal(current, l.data) {
+		return nil // taken over as stale by another process
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing lock file: %w", err)
+	}
+	return nil
+}
+
+// createExclusive creates path with data, failing with os.ErrExist if the
+// file already exists.
+func createExclusive(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, werr := f.Write(data)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(path)
+		return errors.Join(werr, cerr)
+	}
+	return nil
+}
+
+// inspectLock reads the lock at path and reports whether it is stale: held
+// by a process on this host that is no longer running, or unreadable for
+// longer than unreadableLockGrace. Locks from other hosts are never stale
+// because their processes cannot be checked.
+func inspectLock(path, host string) (LockInfo, bool, error) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, false, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		st, serr := os.Stat(path)
+		if serr != nil {
+			return info, false, serr
+		}
+		return info, time.Since(st.ModTime()) > unreadableLockGrace, nil
+	}
+	if info.Host != host || info.PID <= 0 {
+		return info, false, nil
+	}
+	return info, !processAlive(info.PID), nil
+}
+
+// removeStaleLock removes a stale lock file. The file is first renamed
+// aside so that, if another process replaced it with a live lock in the
+// meantime, that lock can be put back instead of being deleted.
+func removeStaleLock(path string) error {
+	aside := fmt.Sprintf("%s.stale.%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("removing stale lock: %w", err)
+	}
+	defer os.Remove(aside)
+
+	host, _ := os.Hostname()
+	if _, stale, err := inspectLock(aside, host); err == nil && !stale {
+		// Restore it; if a third process already locked, the link fails
+		// and that process's lock stands.
+		os.Link(aside, path)
+	}
+	return nil
+}
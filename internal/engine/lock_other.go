@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package engine
+
+// processAlive cannot check processes on this platform, so locks are only
+// released explicitly.
+func processAlive(pid int) bool {
+	return true
+}
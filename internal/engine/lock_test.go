@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryDAGLock(t *testing.T) {
+	runsDir := t.TempDir()
+
+	lock, err := TryDAGLock(runsDir, "daily", "run1")
+	if err != nil {
+		t.Fatalf("TryDAGLock() error: %v", err)
+	}
+
+	_, err = TryDAGLock(runsDir, "daily", "run2")
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second TryDAGLock() error = %v, want *LockedError", err)
+	}
+	if locked.Holder.RunID != "run1" || locked.Holder.PID != os.Getpid() {
+		t.Errorf("Holder = %+v, want run1 held by this process", locked.Holder)
+	}
+
+	// Other DAGs are unaffected
+	other, err := TryDAGLock(runsDir, "weekly", "run3")
+	if err != nil {
+		t.Fatalf("TryDAGLock(weekly) error: %v", err)
+	}
+	other.Release()
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if _, err := os.Stat(DAGLockPath(runsDir, "daily")); !os.IsNotExist(err) {
+		t.Error("lock file should be removed on release")
+	}
+	lock, err = TryDAGLock(runsDir, "daily", "run4")
+	if err != nil {
+		t.Fatalf("TryDAGLock() after release error: %v", err)
+	}
+	lock.Release()
+}
+
+func TestTryDAGLock_Stale(t *testing.T) {
+	runsDir := t.TempDir()
+	host, _ := os.Hostname()
+	path := DAGLockPath(runsDir, "daily")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+
+	writeLock := func(info LockInfo) {
+		t.Helper()
+		data, _ := json.Marshal(info)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A PID above any kernel's pid_max is never running.
+	writeLock(LockInfo{PID: 1 << 30, Host: host, RunID: "crashed"})
+	lock, err := TryDAGLock(runsDir, "daily", "run1")
+	if err != nil {
+		t.Fatalf("TryDAGLock() over stale lock error: %v", err)
+	}
+	lock.Release()
+
+	// The same PID on another host cannot be checked and is respected.
+	writeLock(LockInfo{PID: 1 << 30, Host: host + "-elsewhere", RunID: "remote"})
+	if _, err := TryDAGLock(runsDir, "daily", "run2"); err == nil {
+		t.Error("lock from another host should be treated as held")
+	}
+
+	// Unparseable locks are held briefly, then considered stale.
+	os.WriteFile(path, nil, 0o644)
+	if _, err := TryDAGLock(runsDir, "daily", "run3"); err == nil {
+		t.Error("freshly created empty lock should be treated as held")
+	}
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+	lock, err = TryDAGLock(runsDir, "daily", "run4")
+	if err != nil {
+		t.Fatalf("TryDAGLock() over old empty lock error: %v", err)
+	}
+	lock.Release()
+}
+
+func TestWaitDAGLock(t *testing.T) {
+	orig := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	defer func() { lockPollInterval = orig }()
+
+	runsDir := t.TempDir()
+	first, err := TryDAGLock(runsDir, "daily", "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waited := make(chan LockInfo, 1)
+	go func() {
+		holder := <-waited
+		if holder.RunID != "run1" {
+			t.Errorf("onWait holder = %q, want run1", holder.RunID)
+		}
+		first.Release()
+	}()
+
+	lock, err := WaitDAGLock(context.Background(), runsDir, "daily", "run2", func(h LockInfo) { waited <- h })
+	if err != nil {
+		t.Fatalf("WaitDAGLock() error: %v", err)
+	}
+	lock.Release()
+
+	// Cancellation stops the wait
+	held, _ := TryDAGLock(runsDir, "daily", "run3")
+	defer held.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := WaitDAGLock(ctx, runsDir, "daily", "run4", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitDAGLock() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestTryDAGSlot(t *testing.T) {
+	runsDir := t.TempDir()
+
+	a, err := TryDAGSlot(runsDir, "daily", "run1", 2)
+	if err != nil {
+		t.Fatalf("TryDAGSlot() error: %v", err)
+	}
+	b, err := TryDAGSlot(runsDir, "daily", "run2", 2)
+	if err != nil {
+		t.Fatalf("second TryDAGSlot() error: %v", err)
+	}
+
+	_, err = TryDAGSlot(runsDir, "daily", "run3", 2)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("third TryDAGSlot() error = %v, want *LockedError", err)
+	}
+	if locked.Max != 2 || locked.Holder.RunID != "run1" {
+		t.Errorf("LockedError = %+v, want max 2 with oldest holder run1", locked)
+	}
+
+	// Slots are separate from the overlap lock
+	lock, err := TryDAGLock(runsDir, "daily", "run4")
+	if err != nil {
+		t.Fatalf("TryDAGLock() with slots held error: %v", err)
+	}
+	lock.Release()
+
+	a.Release()
+	c, err := TryDAGSlot(runsDir, "daily", "run5", 2)
+	if err != nil {
+		t.Fatalf("TryDAGSlot() after release error: %v", err)
+	}
+	c.Release()
+	b.Release()
+}
+
+func TestWaitDAGSlot(t *testing.T) {
+	orig := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	defer func() { lockPollInterval = orig }()
+
+	runsDir := t.TempDir()
+	first, err := TryDAGSlot(runsDir, "daily", "run1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		first.Release()
+	}()
+	lock, err := WaitDAGSlot(context.Background(), runsDir, "daily", "run2", 1, nil)
+	if err != nil {
+		t.Fatalf("WaitDAGSlot() error: %v", err)
+	}
+	defer lock.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := WaitDAGSlot(ctx, runsDir, "daily", "run3", 1, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitDAGSlot() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRelease_TakenOver(t *testing.T) {
+	runsDir := t.TempDir()
+	lock, err := TryDAGLock(runsDir, "daily", "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate another process taking the lock over
+	os.WriteFile(DAGLockPath(runsDir, "daily"), []byte(`{"pid":1,"run_id":"other"}`), 0o644)
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if _, err := os.Stat(DAGLockPath(runsDir, "daily")); err != nil {
+		t.Error("Release() must not remove a lock it no longer owns")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/druarnfield/pit/internal/runid"
 )
 
 // RunInfo holds metadata about a discovered run on disk.
@@ -19,27 +21,16 @@ type RunInfo struct {
 	LogDir    string // full path to the logs directory (e.g. runs/<runID>/logs)
 }
 
-// runIDTimestampLen is the length of the timestamp portion of a run ID
-// (format: 20060102_150405.000 = 19 chars) plus the trailing underscore separator.
-const runIDTimestampLen = 20
-
-// DAGNameFromRunID extracts the DAG name from a run ID.
-// Run IDs have the format: 20060102_150405.000_dag_name
-// The timestamp portion is always 19 chars, followed by an underscore.
+// DAGNameFromRunID extracts the DAG name from a run ID. Both the current
+// format (20060102_150405.000-3fa9c1_dag_name) and the older one without
+// a random suffix (20060102_150405.000_dag_name) are accepted.
 func DAGNameFromRunID(runID string) (string, error) {
-	if len(runID) <= runIDTimestampLen {
-		return "", fmt.Errorf("run ID %q is too short to contain a DAG name", runID)
-	}
-	return runID[runIDTimestampLen:], nil
+	return runid.DAGName(runID)
 }
 
 // TimestampFromRunID parses the timestamp portion of a run ID.
 func TimestampFromRunID(runID string) (time.Time, error) {
-	if len(runID) < runIDTimestampLen {
-		return time.Time{}, fmt.Errorf("run ID %q is too short to contain a timestamp", runID)
-	}
-	ts := runID[:runIDTimestampLen-1] // exclude trailing underscore
-	return time.ParseInLocation("20060102_150405.000", ts, time.Local)
+	return runid.Timestamp(runID)
 }
 
 // DiscoverRuns scans the runsDir for run directories belonging to the given DAG.
@@ -87,9 +78,12 @@ func DiscoverRuns(runsDir, dagName string) ([]RunInfo, error) {
 		})
 	}
 
-	// Sort newest first
+	// Sort newest first; runs started in the same millisecond by ID.
 	sort.Slice(runs, func(i, j int) bool {
-		return runs[i].Timestamp.After(runs[j].Timestamp)
+		if !runs[i].Timestamp.Equal(runs[j].Timestamp) {
+			return runs[i].Timestamp.After(runs[j].Timestamp)
+		}
+		return runs[i].ID > runs[j].ID
 	})
 
 	return runs, nil
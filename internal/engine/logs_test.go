@@ -227,3 +227,69 @@ func mkRunDir(t *testing.T, runsDir, runID string) {
 		t.Fatalf("mkRunDir(%q): %v", runID, err)
 	}
 }
+
+func TestRunIDFormats(t *testing.T) {
+	tests := []struct {
+		runID   string
+		wantDAG string
+		wantErr bool
+	}{
+		{runID: "20240115_143022.123-3fa9c1_my_dag", wantDAG: "my_dag"},
+		{runID: "20240115_143022.123_my_dag", wantDAG: "my_dag"},
+		// A legacy ID whose DAG name looks like a suffix keeps its full name.
+		{runID: "20240115_143022.123_3fa9c1_dag", wantDAG: "3fa9c1_dag"},
+		{runID: "20240115_143022.123-3fa9c1_", wantErr: true},
+		{runID: "20240115_143022.123-3fa9_dag", wantErr: true},
+		{runID: "not_a_run_directory_at_all", wantErr: true},
+	}
+	for _, tt := range tests {
+		dag, err := DAGNameFromRunID(tt.runID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("DAGNameFromRunID(%q) = %q, want error", tt.runID, dag)
+			}
+			continue
+		}
+		if err != nil || dag != tt.wantDAG {
+			t.Errorf("DAGNameFromRunID(%q) = %q, %v; want %q", tt.runID, dag, err, tt.wantDAG)
+		}
+		ts, err := TimestampFromRunID(tt.runID)
+		if err != nil || ts.Format("20060102_150405.000") != "20240115_143022.123" {
+			t.Errorf("TimestampFromRunID(%q) = %v, %v", tt.runID, ts, err)
+		}
+	}
+}
+
+func TestGenerateRunID_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 1000 {
+		id := GenerateRunID("burst")
+		if seen[id] {
+			t.Fatalf("GenerateRunID returned %q twice", id)
+		}
+		seen[id] = true
+		if dag, err := DAGNameFromRunID(id); err != nil || dag != "burst" {
+			t.Fatalf("DAGNameFromRunID(%q) = %q, %v", id, dag, err)
+		}
+	}
+}
+
+func TestDiscoverRuns_MixedFormats(t *testing.T) {
+	runsDir := t.TempDir()
+	mkRunDir(t, runsDir, "20240115_143022.123_my_dag")
+	mkRunDir(t, runsDir, "20240115_143022.123-0a0a0a_my_dag")
+	mkRunDir(t, runsDir, "20240116_090000.000-ffffff_my_dag")
+
+	runs, err := DiscoverRuns(runsDir, "my_dag")
+	if err != nil {
+		t.Fatalf("DiscoverRuns() error: %v", err)
+	}
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	want := []string{"20240116_090000.000-ffffff_my_dag", "20240115_143022.123_my_dag", "20240115_143022.123-0a0a0a_my_dag"}
+	if strings.Join(ids, " ") != strings.Join(want, " ") {
+		t.Errorf("DiscoverRuns() = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// MapOverTriggerFiles expands a mapped task over the files delivered by the
+// run's trigger (an FTP watch event or files seeded into a remote run).
+const MapOverTriggerFiles = "trigger.files"
+
+// resolveMapItems returns the items a mapped task expands over. An upstream
+// output must be a JSON array; string elements are used as-is and any other
+// element as its JSON text.
+func resolveMapItems(mapOver string, run *Run, triggerFiles []string) ([]string, error) {
+	if mapOver == MapOverTriggerFiles {
+		return triggerFiles, nil
+	}
+	rest, ok := strings.CutPrefix(mapOver, "outputs.")
+	task, key, _ := strings.Cut(rest, ".")
+	if !ok || task == "" || key == "" {
+		return nil, fmt.Errorf("invalid map_over %q", mapOver)
+	}
+	value, ok := run.Outputs.get(task, key)
+	if !ok {
+		return nil, nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("map_over %s: output is not a JSON array: %w", mapOver, err)
+	}
+	items := make([]string, len(raw))
+	for i, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err == nil {
+			items[i] = s
+		} else {
+			items[i] = string(r)
+		}
+	}
+	return items, nil
+}
+
+// runTask executes ti, expanding it first if it is a mapped task.
+func runTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts, sem chan struct{}, concurrent bool) {
+	if ti.MapOver != "" {
+		executeMapped(ctx, ti, run, cfg, opts, sem)
+		return
+	}
+	if sem != nil {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+	}
+	executeTask(ctx, ti, run, cfg, opts, concurrent)
+}
+
+// executeMapped runs one instance of ti per map item. Instances share the
+// DAG's concurrency limit and are named <task>[<index>], each with its own
+// log file. ti succeeds only if every instance does; with no items it is
+// skipped.
+func executeMapped(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts, sem chan struct{}) {
+	items, err := resolveMapItems(ti.MapOver, run, opts.TriggerFiles)
+	if err == nil && len(items) == 0 {
+		markSkipped(ti, run, fmt.Sprintf("map_over %s is empty", ti.MapOver), opts)
+		return
+	}
+
+	run.mu.Lock()
+	ti.Status = StatusRunning
+	ti.StartedAt = time.Now()
+	startedAt := ti.StartedAt
+	run.mu.Unlock()
+	if opts.MetaStore != nil {
+		if err := opts.MetaStore.RecordTaskStart(run.ID, ti.Name, string(StatusRunning), "", startedAt); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+
+	if err != nil {
+		run.mu.Lock()
+		ti.Status = StatusFailed
+		ti.Error = err
+		ti.EndedAt = time.Now()
+		run.mu.Unlock()
+		recordTaskResult(ti, run, opts)
+		return
+	}
+
+	instances := make([]*TaskInstance, len(items))
+	for i, item := range items {
+		instances[i] = &TaskInstance{
+			Name:         fmt.Sprintf("%s[%d]", ti.Name, i),
+			Script:       ti.Script,
+			Runner:       ti.Runner,
+			Status:       StatusPending,
+			MaxRetries:   ti.MaxRetries,
+			RetryDelay:   ti.RetryDelay,
+			Timeout:      ti.Timeout,
+			Limits:       ti.Limits,
+			Env:          ti.Env,
+			StallTimeout: ti.StallTimeout,
+			StallKill:    ti.StallKill,
+			MapOf:        ti.Name,
+			MapIndex:     i,
+			MapItem:      item,
+		}
+	}
+	run.mu.Lock()
+	ti.Instances = instances
+	run.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, inst := range instances {
+		wg.Add(1)
+		go func(t *TaskInstance) {
+			defer wg.Done()
+			runTask(ctx, t, run, cfg, opts, sem, true)
+		}(inst)
+	}
+	wg.Wait()
+
+	failed := 0
+	run.mu.Lock()
+	for _, inst := range instances {
+		if inst.Status != StatusSuccess {
+			failed++
+		}
+	}
+	ti.Status = StatusSuccess
+	if failed > 0 {
+		ti.Status = StatusFailed
+		ti.Error = fmt.Errorf("%d of %d mapped instances failed", failed, len(instances))
+	}
+	ti.EndedAt = time.Now()
+	run.mu.Unlock()
+	recordTaskResult(ti, run, opts)
+}
+
+// recordTaskResult records ti's final status in the metadata store.
+func recordTaskResult(ti *TaskInstance, run *Run, opts ExecuteOpts) {
+	if opts.MetaStore == nil {
+		return
+	}
+	run.mu.Lock()
+	status, endedAt := string(ti.Status), ti.EndedAt
+	var errMsg string
+	if ti.Error != nil {
+		errMsg = ti.Error.Error()
+	}
+	run.mu.Unlock()
+	if err := opts.MetaStore.RecordTaskEnd(run.ID, ti.Name, status, endedAt, 0, errMsg); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+	}
+}
+
+// expandedTasks lists tasks with each mapped task followed by its instances.
+func expandedTasks(tasks []*TaskInstance) []*TaskInstance {
+	out := make([]*TaskInstance, 0, len(tasks))
+	for _, ti := range tasks {
+		out = append(out, ti)
+		out = append(out, ti.Instances...)
+	}
+	return out
+}
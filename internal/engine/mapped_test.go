@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestResolveMapItems(t *testing.T) {
+	outputs := &taskOutputs{}
+	outputs.set("list", "files", `["a.csv", "b.csv"]`)
+	outputs.set("list", "ids", `[1, 2, {"x": 3}]`)
+	outputs.set("list", "bad", `a.csv`)
+	run := &Run{Outputs: outputs}
+
+	tests := []struct {
+		mapOver string
+		want    []string
+		wantErr bool
+	}{
+		{mapOver: "trigger.files", want: []string{"x.csv", "y.csv"}},
+		{mapOver: "outputs.list.files", want: []string{"a.csv", "b.csv"}},
+		{mapOver: "outputs.list.ids", want: []string{"1", "2", `{"x": 3}`}},
+		{mapOver: "outputs.list.unset", want: nil},
+		{mapOver: "outputs.list.bad", wantErr: true},
+		{mapOver: "params.files", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mapOver, func(t *testing.T) {
+			got, err := resolveMapItems(tt.mapOver, run, []string{"x.csv", "y.csv"})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveMapItems() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveMapItems() error: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("resolveMapItems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteMapped(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	runDir := t.TempDir()
+	run := &Run{
+		ID:          "mapped_test",
+		DAGName:     "test",
+		SnapshotDir: filepath.Join(runDir, "project"),
+		LogDir:      filepath.Join(runDir, "logs"),
+		DataDir:     filepath.Join(runDir, "data"),
+		Outputs:     &taskOutputs{},
+	}
+	for _, dir := range []string{run.SnapshotDir, run.LogDir, run.DataDir} {
+		os.MkdirAll(dir, 0o755)
+	}
+	script := "echo \"$PIT_MAP_INDEX $PIT_MAP_ITEM\"\n[ \"$PIT_MAP_ITEM\" != bad.csv ]\n"
+	os.WriteFile(filepath.Join(run.SnapshotDir, "load.sh"), []byte(script), 0o755)
+
+	ti := &TaskInstance{Name: "load", Script: "load.sh", Status: StatusPending, MapOver: MapOverTriggerFiles}
+	run.Tasks = []*TaskInstance{ti}
+	opts := ExecuteOpts{TriggerFiles: []string{"a.csv", "bad.csv"}}
+	executeMapped(context.Background(), ti, run, &config.ProjectConfig{}, opts, make(chan struct{}, 1))
+
+	if ti.Status != StatusFailed || len(ti.Instances) != 2 {
+		t.Fatalf("mapped task = %s with %d instances, want failed with 2", ti.Status, len(ti.Instances))
+	}
+	if !strings.Contains(ti.Error.Error(), "1 of 2") {
+		t.Errorf("mapped task error = %v, want 1 of 2 failed", ti.Error)
+	}
+	if got := ti.Instances[0]; got.Name != "load[0]" || got.Status != StatusSuccess {
+		t.Errorf("instance 0 = %s %s, want load[0] success", got.Name, got.Status)
+	}
+	if got := ti.Instances[1].Status; got != StatusFailed {
+		t.Errorf("instance 1 = %s, want failed", got)
+	}
+	log, err := os.ReadFile(filepath.Join(run.LogDir, "load[0].log"))
+	if err != nil || !strings.Contains(string(log), "0 a.csv") {
+		t.Errorf("load[0].log = %q (err %v), want it to contain %q", log, err, "0 a.csv")
+	}
+
+	empty := &TaskInstance{Name: "none", Script: "load.sh", Status: StatusPending, MapOver: MapOverTriggerFiles}
+	executeMapped(context.Background(), empty, run, &config.ProjectConfig{}, ExecuteOpts{}, nil)
+	if empty.Status != StatusSkipped {
+		t.Errorf("mapped task with no items = %s, want skipped", empty.Status)
+	}
+}
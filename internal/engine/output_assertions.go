@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// outputAssertionsLogName is the log, in the run's log directory, of the
+// checks run for output assertions.
+const outputAssertionsLogName = "output_assertions.log"
+
+// checkOutputAssertions runs the min_rows and max_null_pct assertions of
+// the DAG's outputs against their tables once the tasks have succeeded,
+// logging each check to logs/output_assertions.log. It returns how many
+// assertions warned and an error naming the outputs whose assertions
+// failed with on_failure = "fail".
+func checkOutputAssertions(ctx context.Context, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts) (warned int, err error) {
+	var outputs []config.Output
+	for _, o := range cfg.Outputs {
+		if o.HasAssertions() {
+			outputs = append(outputs, o)
+		}
+	}
+	if len(outputs) == 0 {
+		return 0, nil
+	}
+
+	logFile, err := os.Create(filepath.Join(run.LogDir, outputAssertionsLogName))
+	if err != nil {
+		return 0, fmt.Errorf("creating output assertions log: %w", err)
+	}
+	defer logFile.Close()
+
+	var failed []string
+	for _, o := range outputs {
+		n, err := checkOutput(ctx, run, cfg, opts, o, logFile)
+		warned += n
+		if err != nil {
+			failed = append(failed, o.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return warned, fmt.Errorf("output assertions failed for %s (see logs/%s)", strings.Join(failed, ", "), outputAssertionsLogName)
+	}
+	return warned, nil
+}
+
+// checkOutput runs one output's assertions. With on_failure = "warn",
+// failures, including a connection that cannot be used, only count as
+// warnings.
+func checkOutput(ctx context.Context, run *Run, cfg *config.ProjectConfig, opts ExecuteOpts, o config.Output, logWriter io.Writer) (warned int, err error) {
+	connKey := o.Connection
+	if connKey == "" {
+		connKey = cfg.DAG.SQL.Connection
+	}
+	qdb, err := openQualityDB(run, cfg, opts, connKey)
+	if err == nil {
+		defer qdb.Close()
+		var counts map[qualityOutcome]int
+		counts, err = runQualityChecks(ctx, qdb.DB, qdb.quoting, qdb.schema, o.AssertionTable(), outputAssertionChecks(o), logWriter)
+		warned = counts[qualityWarn]
+	}
+	if err != nil {
+		fmt.Fprintf(logWriter, "[quality] output %s: %v\n", o.Name, err)
+		if o.OnFailure == "warn" {
+			return warned + 1, nil
+		}
+	}
+	return warned, err
+}
+
+// outputAssertionChecks turns an output's min_rows and max_null_pct into
+// quality checks on its table.
+func outputAssertionChecks(o config.Output) []config.QualityCheck {
+	severity := "error"
+	if o.OnFailure == "warn" {
+		severity = "warn"
+	}
+	var checks []config.QualityCheck
+	if o.MinRows > 0 {
+		minRows := float64(o.MinRows)
+		checks = append(checks, config.QualityCheck{
+			Name:     fmt.Sprintf("%s min_rows", o.Name),
+			Check:    config.CheckRowCount,
+			Min:      &minRows,
+			Severity: severity,
+		})
+	}
+	for _, col := range slices.Sorted(maps.Keys(o.MaxNullPct)) {
+		maxRatio := o.MaxNullPct[col] / 100
+		checks = append(checks, config.QualityCheck{
+			Name:     fmt.Sprintf("%s max_null_pct %s", o.Name, col),
+			Check:    config.CheckNullRatio,
+			Column:   col,
+			Max:      &maxRatio,
+			Severity: severity,
+		})
+	}
+	return checks
+}
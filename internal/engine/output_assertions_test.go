@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/loader"
+)
+
+func TestOutputAssertionChecks(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`CREATE TABLE claims (claim_id INTEGER, member_id TEXT)`,
+		`INSERT INTO claims VALUES (1, 'a'), (2, NULL), (3, 'b'), (4, 'c')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setting up table: %v", err)
+		}
+	}
+	q := loader.Quoting{Open: `"`, Close: `"`}
+
+	tests := []struct {
+		name    string
+		output  config.Output
+		wantErr string
+		wantLog string
+	}{
+		{"pass", config.Output{Name: "claims", MinRows: 4, MaxNullPct: map[string]float64{"claim_id": 0, "member_id": 25}}, "", "PASS  claims max_null_pct member_id: 1 of 4 rows NULL"},
+		{"too few rows", config.Output{Name: "claims", MinRows: 5}, "claims min_rows", "FAIL  claims min_rows: 4 rows, want >= 5"},
+		{"too many nulls", config.Output{Name: "claims", MaxNullPct: map[string]float64{"member_id": 10}}, "claims max_null_pct member_id", "FAIL"},
+		{"warn only", config.Output{Name: "claims", MinRows: 5, OnFailure: "warn"}, "", "WARN  claims min_rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			_, err := runQualityChecks(context.Background(), db, q, "", "claims", outputAssertionChecks(tt.output), &log)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("error = %v, want none\n%s", err, log.String())
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want one naming %q", err, tt.wantErr)
+			}
+			if !strings.Contains(log.String(), tt.wantLog) {
+				t.Errorf("log = %q, want it to contain %q", log.String(), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestCheckOutputAssertions(t *testing.T) {
+	logDir := t.TempDir()
+	run := &Run{DAGName: "claims", LogDir: logDir} // no secrets: the connection cannot be opened
+
+	cfg := &config.ProjectConfig{Outputs: []config.Output{{Name: "report", Type: "file"}}}
+	if warned, err := checkOutputAssertions(context.Background(), run, cfg, ExecuteOpts{}); warned != 0 || err != nil {
+		t.Errorf("without assertions = %d, %v; want 0, nil", warned, err)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, outputAssertionsLogName)); !os.IsNotExist(err) {
+		t.Error("assertions log written without assertions")
+	}
+
+	cfg.Outputs = []config.Output{
+		{Name: "staging", Type: "table", Table: "dbo.staging", Connection: "wh", MinRows: 1, OnFailure: "warn"},
+		{Name: "claims", Type: "table", Table: "dbo.claims", Connection: "wh", MinRows: 1},
+	}
+	warned, err := checkOutputAssertions(context.Background(), run, cfg, ExecuteOpts{})
+	if warned != 1 {
+		t.Errorf("warned = %d, want 1", warned)
+	}
+	if err == nil || !strings.Contains(err.Error(), "output assertions failed for claims") {
+		t.Errorf("error = %v, want claims to fail the run", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(logDir, outputAssertionsLogName))
+	if !strings.Contains(string(data), "secrets store not configured") {
+		t.Errorf("assertions log = %q, want the connection error", data)
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/druarnfield/pit/internal/pgp"
+	"github.com/druarnfield/pit/internal/sdk"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// makePGPDecryptHandler returns a handler that decrypts a file in the data
+// directory with the private key in a structured secret (fields
+// private_key and, if the key is protected, passphrase).
+//
+// Params: name, secret, dest (optional, required when name does not end in .pgp, .gpg, or .asc), remove (optional)
+// Returns: the decrypted file's path (absolute, inside dataDir)
+func makePGPDecryptHandler(store *secrets.Store, dagName, dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		name := params["name"]
+		if name == "" {
+			return "", fmt.Errorf("missing required parameter: name")
+		}
+		secretName := params["secret"]
+		if secretName == "" {
+			return "", fmt.Errorf("missing required parameter: secret")
+		}
+		destName := params["dest"]
+		if destName == "" {
+			var ok bool
+			if destName, ok = pgp.DecryptedName(name); !ok {
+				return "", fmt.Errorf("missing required parameter: dest (name does not end in .pgp, .gpg, or .asc)")
+			}
+		}
+		src, err := dataDirPath(dataDir, name)
+		if err != nil {
+			return "", err
+		}
+		dst, err := dataDirPath(dataDir, destName)
+		if err != nil {
+			return "", err
+		}
+
+		if store == nil {
+			return "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		}
+		key, err := store.ResolveField(dagName, secretName, "private_key")
+		if err != nil {
+			return "", fmt.Errorf("resolving %s.private_key: %w", secretName, err)
+		}
+		// Unprotected keys have no passphrase field
+		passphrase, _ := store.ResolveField(dagName, secretName, "passphrase")
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		if err := pgp.DecryptFile(src, dst, key, passphrase); err != nil {
+			return "", fmt.Errorf("decrypting %q: %w", name, err)
+		}
+		if params["remove"] == "true" {
+			if err := os.Remove(src); err != nil {
+				return "", err
+			}
+		}
+		return dst, nil
+	}
+}
+
+// makePGPEncryptHandler returns a handler that encrypts a file in the data
+// directory to the public key in a structured secret (field public_key).
+// The output is binary <name>.pgp, or ASCII-armored <name>.asc when armor
+// is "true".
+//
+// Params: name, secret, dest (optional), armor (optional), remove (optional)
+// Returns: the encrypted file's path (absolute, inside dataDir)
+func makePGPEncryptHandler(store *secrets.Store, dagName, dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		name := params["name"]
+		if name == "" {
+			return "", fmt.Errorf("missing required parameter: name")
+		}
+		secretName := params["secret"]
+		if secretName == "" {
+			return "", fmt.Errorf("missing required parameter: secret")
+		}
+		armored := params["armor"] == "true"
+		destName := params["dest"]
+		if destName == "" {
+			destName = name + ".pgp"
+			if armored {
+				destName = name + ".asc"
+			}
+		}
+		src, err := dataDirPath(dataDir, name)
+		if err != nil {
+			return "", err
+		}
+		dst, err := dataDirPath(dataDir, destName)
+		if err != nil {
+			return "", err
+		}
+
+		if store == nil {
+			return "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		}
+		key, err := store.ResolveField(dagName, secretName, "public_key")
+		if err != nil {
+			return "", fmt.Errorf("resolving %s.public_key: %w", secretName, err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		if err := pgp.EncryptFile(src, dst, key, armored); err != nil {
+			return "", fmt.Errorf("encrypting %q: %w", name, err)
+		}
+		if params["remove"] == "true" {
+			if err := os.Remove(src); err != nil {
+				return "", err
+			}
+		}
+		return dst, nil
+	}
+}
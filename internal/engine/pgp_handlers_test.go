@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// pgpTestStore returns a secrets store holding a new key pair as the
+// structured secret "partner" (public_key and private_key).
+func pgpTestStore(t *testing.T) string {
+	t.Helper()
+	cfg := &packet.Config{RSABits: 1024, DefaultHash: crypto.SHA256}
+	entity, err := openpgp.NewEntity("partner", "", "partner@example.com", cfg)
+	if err != nil {
+		t.Fatalf("NewEntity() error: %v", err)
+	}
+	var priv, pub bytes.Buffer
+	w, _ := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	entity.SerializePrivate(w, nil)
+	w.Close()
+	w, _ = armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	entity.Serialize(w)
+	w.Close()
+	return "[global.partner]\npublic_key = '''\n" + pub.String() + "'''\nprivate_key = '''\n" + priv.String() + "'''\n"
+}
+
+func TestPGPHandlers_RoundTrip(t *testing.T) {
+	store := loadTestStore(t, pgpTestStore(t))
+	dataDir := t.TempDir()
+	os.WriteFile(filepath.Join(dataDir, "claims.csv"), []byte("id\n1\n"), 0o644)
+
+	encrypt := makePGPEncryptHandler(store, "test", dataDir)
+	encPath, err := encrypt(context.Background(), map[string]string{"name": "claims.csv", "secret": "partner", "armor": "true", "remove": "true"})
+	if err != nil {
+		t.Fatalf("pgp_encrypt error: %v", err)
+	}
+	if encPath != filepath.Join(dataDir, "claims.csv.asc") {
+		t.Errorf("pgp_encrypt = %q, want claims.csv.asc", encPath)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "claims.csv")); !os.IsNotExist(err) {
+		t.Errorf("remove=true should delete the plaintext, Stat err = %v", err)
+	}
+
+	decrypt := makePGPDecryptHandler(store, "test", dataDir)
+	decPath, err := decrypt(context.Background(), map[string]string{"name": "claims.csv.asc", "secret": "partner"})
+	if err != nil {
+		t.Fatalf("pgp_decrypt error: %v", err)
+	}
+	data, err := os.ReadFile(decPath)
+	if err != nil || string(data) != "id\n1\n" {
+		t.Errorf("decrypted %s = %q (err %v)", decPath, data, err)
+	}
+}
+
+func TestPGPDecryptHandler_Errors(t *testing.T) {
+	store := loadTestStore(t, `
+[global.nokey]
+public_key = "x"
+`)
+	dataDir := t.TempDir()
+	handler := makePGPDecryptHandler(store, "test", dataDir)
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"missing name", map[string]string{"secret": "nokey"}, "name"},
+		{"missing secret", map[string]string{"name": "a.pgp"}, "secret"},
+		{"no dest for unknown suffix", map[string]string{"name": "a.csv", "secret": "nokey"}, "dest"},
+		{"escapes data dir", map[string]string{"name": "../a.pgp", "secret": "nokey"}, "escapes data directory"},
+		{"no private key", map[string]string{"name": "a.pgp", "secret": "nokey"}, "nokey.private_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(context.Background(), tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
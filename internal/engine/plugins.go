@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/runner"
+	"github.com/druarnfield/pit/internal/sdk"
+)
+
+// defaultPluginTimeout limits one plugin SDK call when the plugin sets no timeout.
+const defaultPluginTimeout = 5 * time.Minute
+
+// resolvePluginRunner returns the plugin runner for a task: the plugin
+// claiming taskRunner, or, when taskRunner is unset, the plugin claiming
+// the script's extension.
+func resolvePluginRunner(plugins map[string]config.PluginConfig, taskRunner, scriptPath string) (runner.Runner, bool) {
+	ext := strings.ToLower(filepath.Ext(scriptPath))
+	for name, p := range plugins {
+		if taskRunner != "" {
+			for _, r := range p.Runners {
+				if r == taskRunner {
+					return &runner.PluginRunner{Plugin: name, Command: p.Command, Runner: r}, true
+				}
+			}
+			continue
+		}
+		for _, e := range p.Extensions {
+			if strings.ToLower(e) == ext {
+				return &runner.PluginRunner{Plugin: name, Command: p.Command}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// registerPluginHandlers registers the SDK methods each plugin provides.
+// Plugins may not replace pit's own handlers.
+func registerPluginHandlers(srv *sdk.Server, plugins map[string]config.PluginConfig, dagName, runID, dataDir string) error {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := plugins[name]
+		timeout := p.Timeout.Duration
+		if timeout == 0 {
+			timeout = defaultPluginTimeout
+		}
+		for _, method := range p.SDKMethods {
+			if srv.HasHandler(method) {
+				return fmt.Errorf("plugin %q: SDK method %q is built in", name, method)
+			}
+			srv.RegisterHandler(method, makePluginHandler(name, p.Command, method, timeout, dagName, runID, dataDir))
+		}
+	}
+	return nil
+}
+
+// makePluginHandler returns an SDK handler that forwards method to a plugin.
+func makePluginHandler(plugin string, command []string, method string, timeout time.Duration, dagName, runID, dataDir string) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return runner.CallPluginSDK(ctx, plugin, command, runner.PluginSDKRequest{
+			Method:  method,
+			Params:  params,
+			DAG:     dagName,
+			RunID:   runID,
+			DataDir: dataDir,
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/runner"
+	"github.com/druarnfield/pit/internal/sdk"
+)
+
+func TestResolvePluginRunner(t *testing.T) {
+	plugins := map[string]config.PluginConfig{
+		"sas": {Command: []string{"pit-sas"}, Runners: []string{"sas"}, Extensions: []string{".SAS"}},
+	}
+	tests := []struct {
+		runner, script string
+		want           bool
+		wantRunner     string
+	}{
+		{"sas", "tasks/report.txt", true, "sas"},
+		{"", "tasks/report.sas", true, ""},
+		{"python", "tasks/report.sas", false, ""},
+		{"", "tasks/load.py", false, ""},
+	}
+	for _, tt := range tests {
+		r, ok := resolvePluginRunner(plugins, tt.runner, tt.script)
+		if ok != tt.want {
+			t.Errorf("resolvePluginRunner(%q, %q) ok = %v, want %v", tt.runner, tt.script, ok, tt.want)
+			continue
+		}
+		if ok && r.(*runner.PluginRunner).Runner != tt.wantRunner {
+			t.Errorf("resolvePluginRunner(%q, %q) runner = %+v", tt.runner, tt.script, r)
+		}
+	}
+}
+
+func TestRegisterPluginHandlers(t *testing.T) {
+	srv, err := sdk.NewServer(filepath.Join(t.TempDir(), "pit.sock"), nil, "etl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	srv.RegisterHandler("set_output", func(context.Context, map[string]string) (string, error) { return "", nil })
+
+	plugin := "pit-jobs"
+	plugins := map[string]config.PluginConfig{"jobs": {Command: []string{plugin}, SDKMethods: []string{"submit_job"}}}
+	if err := registerPluginHandlers(srv, plugins, "etl", "run1", t.TempDir()); err != nil {
+		t.Fatalf("registerPluginHandlers() error: %v", err)
+	}
+	if !srv.HasHandler("submit_job") {
+		t.Error("submit_job not registered")
+	}
+
+	plugins["jobs"] = config.PluginConfig{Command: []string{plugin}, SDKMethods: []string{"set_output"}}
+	err = registerPluginHandlers(srv, plugins, "etl", "run1", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "built in") {
+		t.Errorf("registerPluginHandlers() error = %v, want built-in clash", err)
+	}
+}
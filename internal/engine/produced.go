@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/remotepath"
+)
+
+// producedOutput is a declared output a task reported producing with the
+// SDK's register_output.
+type producedOutput struct {
+	Task string
+	Rows int64 // -1 when the task gave no row count
+	At   time.Time
+}
+
+// producedOutputs collects register_output calls during a run, keyed by
+// output name. A later call for the same output replaces an earlier one.
+type producedOutputs struct {
+	mu     sync.Mutex
+	byName map[string]producedOutput
+}
+
+func (p *producedOutputs) set(name string, o producedOutput) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byName == nil {
+		p.byName = make(map[string]producedOutput)
+	}
+	p.byName[name] = o
+}
+
+func (p *producedOutputs) get(name string) (producedOutput, bool) {
+	if p == nil {
+		return producedOutput{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	o, ok := p.byName[name]
+	return o, ok
+}
+
+// makeRegisterOutputHandler returns the SDK handler that records a task
+// producing one of the DAG's declared outputs. The output is matched by
+// name, then by location. Returns the output's name.
+//
+// Params: task, output, rows (optional)
+func makeRegisterOutputHandler(declared []config.Output, produced *producedOutputs) func(ctx context.Context, params map[string]string) (string, error) {
+	return func(_ context.Context, params map[string]string) (string, error) {
+		task, ref := params["task"], params["output"]
+		if task == "" {
+			return "", fmt.Errorf("missing required parameter: task")
+		}
+		if ref == "" {
+			return "", fmt.Errorf("missing required parameter: output")
+		}
+		rows := int64(-1)
+		if s := params["rows"]; s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 0 {
+				return "", fmt.Errorf("invalid rows %q (must be a whole number >= 0)", s)
+			}
+			rows = n
+		}
+
+		name := matchOutput(declared, ref)
+		if name == "" {
+			return "", fmt.Errorf("no output named or located at %q is declared in pit.toml", ref)
+		}
+		produced.set(name, producedOutput{Task: task, Rows: rows, At: time.Now()})
+		return name, nil
+	}
+}
+
+// matchOutput returns the name of the declared output called ref or, if
+// none is, the first one located at ref. Locations are compared after
+// remotepath.Normalize, so a task may spell a UNC path with backslashes.
+func matchOutput(declared []config.Output, ref string) string {
+	for _, o := range declared {
+		if o.Name == ref {
+			return o.Name
+		}
+	}
+	loc := remotepath.Normalize(ref)
+	for _, o := range declared {
+		if remotepath.Normalize(o.Location) == loc {
+			return o.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestRegisterOutputHandler(t *testing.T) {
+	declared := []config.Output{
+		{Name: "claims_staging", Type: "table", Location: "warehouse.staging.claims"},
+		{Name: "daily_report", Type: "file", Location: "reports/daily.csv"},
+		{Name: "close_pack", Type: "file", Location: "//fileserver/finance/close.xlsx"},
+	}
+	tests := []struct {
+		name     string
+		params   map[string]string
+		wantName string
+		wantRows int64
+		wantErr  string
+	}{
+		{"by location", map[string]string{"task": "load", "output": "warehouse.staging.claims", "rows": "1200"}, "claims_staging", 1200, ""},
+		{"by UNC location", map[string]string{"task": "report", "output": `\\fileserver\finance\close.xlsx`}, "close_pack", -1, ""},
+		{"by name", map[string]string{"task": "report", "output": "daily_report"}, "daily_report", -1, ""},
+		{"undeclared", map[string]string{"task": "load", "output": "warehouse.staging.other"}, "", 0, "no output named or located"},
+		{"bad rows", map[string]string{"task": "load", "output": "claims_staging", "rows": "-3"}, "", 0, "invalid rows"},
+		{"no task", map[string]string{"output": "claims_staging"}, "", 0, "missing required parameter: task"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			produced := &producedOutputs{}
+			got, err := makeRegisterOutputHandler(declared, produced)(context.Background(), tt.params)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("register_output error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("register_output error: %v", err)
+			}
+			if got != tt.wantName {
+				t.Errorf("register_output = %q, want %q", got, tt.wantName)
+			}
+			p, ok := produced.get(tt.wantName)
+			if !ok || p.Task != tt.params["task"] || p.Rows != tt.wantRows || p.At.IsZero() {
+				t.Errorf("produced[%s] = %+v, %v; want task %s with %d rows", tt.wantName, p, ok, tt.params["task"], tt.wantRows)
+			}
+		})
+	}
+}
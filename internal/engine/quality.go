@@ -0,0 +1,265 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/loader"
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// qualityOutcome is the result of one quality check.
+type qualityOutcome string
+
+const (
+	qualityPass  qualityOutcome = "PASS"
+	qualityWarn  qualityOutcome = "WARN"
+	qualityFail  qualityOutcome = "FAIL"
+	qualityError qualityOutcome = "ERROR"
+)
+
+// executeQualityTask runs a quality task's checks in order against its
+// connection. Every check runs even after one fails, so the log shows the
+// full picture; the task fails if any check with severity "error" fails or
+// cannot be run. Checks with severity "warn" only log.
+func executeQualityTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.ProjectConfig, tc *config.TaskConfig, opts ExecuteOpts, logWriter io.Writer) error {
+	checks := append([]config.QualityCheck(nil), tc.Checks...)
+	if tc.ChecksFile != "" {
+		fileChecks, err := config.LoadQualityChecks(filepath.Join(run.SnapshotDir, tc.ChecksFile))
+		if err != nil {
+			return err
+		}
+		checks = append(checks, fileChecks...)
+	}
+	for i, c := range checks {
+		if err := c.Validate(tc.Table); err != nil {
+			return fmt.Errorf("check %d: %w", i+1, err)
+		}
+	}
+
+	connKey := resolveTaskConnection(tc, cfg)
+	if connKey == "" {
+		return fmt.Errorf("no connection configured (set connection on task or [dag.sql])")
+	}
+	qdb, err := openQualityDB(run, cfg, opts, connKey)
+	if err != nil {
+		return err
+	}
+	defer qdb.Close()
+
+	counts, err := runQualityChecks(ctx, qdb.DB, qdb.quoting, qdb.schema, tc.Table, checks, logWriter)
+	if opts.MetaStore != nil {
+		if merr := opts.MetaStore.RecordTaskMetrics(run.ID, ti.Name, qualityMetrics(counts)); merr != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", merr)
+		}
+	}
+	return err
+}
+
+// qualityDB is a connection that quality checks run against, with the
+// quoting and default schema of its driver.
+type qualityDB struct {
+	*sql.DB
+	quoting loader.Quoting
+	schema  string
+}
+
+// openQualityDB opens the connection named connKey for quality checks.
+// The schema is the DAG or workspace load default, then the driver's.
+func openQualityDB(run *Run, cfg *config.ProjectConfig, opts ExecuteOpts, connKey string) (*qualityDB, error) {
+	if run.SecretsResolver == nil {
+		return nil, fmt.Errorf("secrets store not configured (use --secrets flag)")
+	}
+	connStr, err := runner.ResolveConnection(run.SecretsResolver, run.DAGName, connKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolving connection %q: %w", connKey, err)
+	}
+
+	driverName, err := runner.DetectDriver(connStr)
+	if err != nil {
+		return nil, err
+	}
+	defaults := resolveLoadDefaults(cfg, opts.Loader)
+	drv, err := loader.NewDriver(driverName, defaults.identCase)
+	if err != nil {
+		return nil, err
+	}
+	schema := defaults.schema
+	if schema == "" {
+		schema = drv.DefaultSchema()
+	}
+
+	db, err := runner.OpenDB(driverName, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s connection: %w", driverName, err)
+	}
+	return &qualityDB{DB: db, quoting: drv.Quoting(), schema: schema}, nil
+}
+
+// runQualityChecks runs each check, logs its outcome, and returns how many
+// checks ended with each outcome. The error lists the failed checks.
+func runQualityChecks(ctx context.Context, db *sql.DB, q loader.Quoting, schema, defaultTable string, checks []config.QualityCheck, logWriter io.Writer) (map[qualityOutcome]int, error) {
+	counts := make(map[qualityOutcome]int)
+	var failed []string
+	start := time.Now()
+	for _, c := range checks {
+		name := qualityCheckName(c, defaultTable)
+		outcome, detail := runQualityCheck(ctx, db, q, schema, defaultTable, c)
+		if outcome == qualityFail && c.Severity == "warn" {
+			outcome = qualityWarn
+		}
+		counts[outcome]++
+		fmt.Fprintf(logWriter, "[quality] %-5s %s: %s\n", outcome, name, detail)
+		if ctx.Err() != nil {
+			return counts, ctx.Err()
+		}
+		if outcome == qualityFail || outcome == qualityError {
+			failed = append(failed, name)
+		}
+	}
+	fmt.Fprintf(logWriter, "[quality] %d passed, %d warned, %d failed in %s\n",
+		counts[qualityPass], counts[qualityWarn], counts[qualityFail]+counts[qualityError],
+		time.Since(start).Round(time.Millisecond))
+	if len(failed) > 0 {
+		return counts, fmt.Errorf("%d of %d quality checks failed: %s", len(failed), len(checks), strings.Join(failed, ", "))
+	}
+	return counts, nil
+}
+
+// runQualityCheck runs one check. Query errors are reported as
+// qualityError regardless of severity, since a check that cannot run
+// says nothing about the data.
+func runQualityCheck(ctx context.Context, db *sql.DB, q loader.Quoting, schema, defaultTable string, c config.QualityCheck) (qualityOutcome, string) {
+	query := qualityQuery(c, q, schema, defaultTable)
+	var total, n sql.NullFloat64
+	var err error
+	if c.Check == config.CheckNullRatio {
+		err = db.QueryRowContext(ctx, query).Scan(&total, &n)
+	} else {
+		err = db.QueryRowContext(ctx, query).Scan(&n)
+	}
+	if err != nil {
+		return qualityError, err.Error()
+	}
+
+	switch c.Check {
+	case config.CheckRowCount:
+		detail := fmt.Sprintf("%g rows%s", n.Float64, describeBounds(c))
+		return boundsOutcome(n.Float64, c), detail
+
+	case config.CheckNullRatio:
+		if total.Float64 == 0 {
+			return qualityPass, "no rows to check"
+		}
+		ratio := n.Float64 / total.Float64
+		detail := fmt.Sprintf("%g of %g rows NULL (%.4g)%s", n.Float64, total.Float64, ratio, describeBounds(c))
+		return boundsOutcome(ratio, c), detail
+
+	default: // config.CheckUnique
+		if n.Float64 == 0 {
+			return qualityPass, "no duplicate keys"
+		}
+		return qualityFail, fmt.Sprintf("%g duplicate keys", n.Float64)
+	}
+}
+
+// qualityQuery builds the SQL for a check. row_count and unique return a
+// single count; null_ratio returns the row count and the NULL count.
+func qualityQuery(c config.QualityCheck, q loader.Quoting, schema, defaultTable string) string {
+	table := c.Table
+	if table == "" {
+		table = defaultTable
+	}
+	tableSchema, tableName := parseSchemaTable(table)
+	if tableSchema == "" {
+		tableSchema = schema
+	}
+	from := q.Qualify(tableSchema, tableName)
+	where := ""
+	if c.Where != "" {
+		where = " WHERE " + c.Where
+	}
+
+	switch c.Check {
+	case config.CheckNullRatio:
+		col := q.Quote(c.Column)
+		return fmt.Sprintf("SELECT COUNT(*), SUM(CASE WHEN %s IS NULL THEN 1 ELSE 0 END) FROM %s%s", col, from, where)
+	case config.CheckUnique:
+		var cols []string
+		for _, col := range qualityKeyColumns(c) {
+			cols = append(cols, q.Quote(col))
+		}
+		key := strings.Join(cols, ", ")
+		return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT %s FROM %s%s GROUP BY %s HAVING COUNT(*) > 1) dups", key, from, where, key)
+	default: // config.CheckRowCount
+		return fmt.Sprintf("SELECT COUNT(*) FROM %s%s", from, where)
+	}
+}
+
+// boundsOutcome passes v if it lies within the check's min and max.
+func boundsOutcome(v float64, c config.QualityCheck) qualityOutcome {
+	if (c.Min != nil && v < *c.Min) || (c.Max != nil && v > *c.Max) {
+		return qualityFail
+	}
+	return qualityPass
+}
+
+// describeBounds formats a check's min and max for the task log.
+func describeBounds(c config.QualityCheck) string {
+	switch {
+	case c.Min != nil && c.Max != nil:
+		return fmt.Sprintf(", want %g..%g", *c.Min, *c.Max)
+	case c.Min != nil:
+		return fmt.Sprintf(", want >= %g", *c.Min)
+	case c.Max != nil:
+		return fmt.Sprintf(", want <= %g", *c.Max)
+	}
+	return ""
+}
+
+// qualityCheckName returns the check's name, or a label built from its
+// kind and target.
+func qualityCheckName(c config.QualityCheck, defaultTable string) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	table := c.Table
+	if table == "" {
+		table = defaultTable
+	}
+	cols := qualityKeyColumns(c)
+	switch len(cols) {
+	case 0:
+		return c.Check + " " + table
+	case 1:
+		return c.Check + " " + table + "." + cols[0]
+	default:
+		return c.Check + " " + table + "(" + strings.Join(cols, ", ") + ")"
+	}
+}
+
+// qualityKeyColumns returns the check's column followed by its columns.
+func qualityKeyColumns(c config.QualityCheck) []string {
+	var cols []string
+	if c.Column != "" {
+		cols = append(cols, c.Column)
+	}
+	return append(cols, c.Columns...)
+}
+
+// qualityMetrics turns a quality task's check outcomes into task metrics.
+func qualityMetrics(counts map[qualityOutcome]int) map[string]float64 {
+	return map[string]float64{
+		"quality_checks_pass":  float64(counts[qualityPass]),
+		"quality_checks_warn":  float64(counts[qualityWarn]),
+		"quality_checks_fail":  float64(counts[qualityFail]),
+		"quality_checks_error": float64(counts[qualityError]),
+	}
+}
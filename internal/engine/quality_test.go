@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/loader"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestQualityQuery(t *testing.T) {
+	q := loader.Quoting{Open: "[", Close: "]"}
+	tests := []struct {
+		name  string
+		check config.QualityCheck
+		want  string
+	}{
+		{"row count", config.QualityCheck{Check: config.CheckRowCount, Where: "load_date = '2026-01-01'"},
+			"SELECT COUNT(*) FROM [dbo].[claims] WHERE load_date = '2026-01-01'"},
+		{"null ratio", config.QualityCheck{Check: config.CheckNullRatio, Column: "member_id"},
+			"SELECT COUNT(*), SUM(CASE WHEN [member_id] IS NULL THEN 1 ELSE 0 END) FROM [dbo].[claims]"},
+		{"composite unique", config.QualityCheck{Check: config.CheckUnique, Table: "stg.lines", Columns: []string{"claim_id", "line_no"}},
+			"SELECT COUNT(*) FROM (SELECT [claim_id], [line_no] FROM [stg].[lines] GROUP BY [claim_id], [line_no] HAVING COUNT(*) > 1) dups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qualityQuery(tt.check, q, "dbo", "claims"); got != tt.want {
+				t.Errorf("qualityQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunQualityChecks(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`CREATE TABLE claims (claim_id INTEGER, member_id TEXT)`,
+		`INSERT INTO claims VALUES (1, 'a'), (2, NULL), (2, 'b'), (3, 'c')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setting up table: %v", err)
+		}
+	}
+	ptr := func(f float64) *float64 { return &f }
+	q := loader.Quoting{Open: `"`, Close: `"`}
+
+	t.Run("all pass", func(t *testing.T) {
+		checks := []config.QualityCheck{
+			{Check: config.CheckRowCount, Min: ptr(1), Max: ptr(10)},
+			{Check: config.CheckNullRatio, Column: "member_id", Max: ptr(0.5)},
+			{Check: config.CheckUnique, Column: "claim_id", Where: "claim_id <> 2"},
+		}
+		var log bytes.Buffer
+		counts, err := runQualityChecks(context.Background(), db, q, "", "claims", checks, &log)
+		if err != nil {
+			t.Fatalf("runQualityChecks() error: %v\n%s", err, log.String())
+		}
+		if counts[qualityPass] != 3 {
+			t.Errorf("passed = %d, want 3\n%s", counts[qualityPass], log.String())
+		}
+	})
+
+	t.Run("warn and fail", func(t *testing.T) {
+		checks := []config.QualityCheck{
+			{Name: "enough rows", Check: config.CheckRowCount, Min: ptr(100), Severity: "warn"},
+			{Check: config.CheckUnique, Column: "claim_id"},
+			{Check: config.CheckNullRatio, Table: "no_such_table", Column: "member_id", Max: ptr(0), Severity: "warn"},
+		}
+		var log bytes.Buffer
+		counts, err := runQualityChecks(context.Background(), db, q, "", "claims", checks, &log)
+		if err == nil {
+			t.Fatalf("runQualityChecks() expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "2 of 3 quality checks failed: unique claims.claim_id, null_ratio no_such_table.member_id") {
+			t.Errorf("error = %q, want the failed checks listed", err)
+		}
+		if counts[qualityWarn] != 1 || counts[qualityFail] != 1 || counts[qualityError] != 1 {
+			t.Errorf("counts = %v, want 1 warn, 1 fail, 1 error", counts)
+		}
+		for _, want := range []string{"WARN  enough rows: 4 rows, want >= 100", "FAIL  unique claims.claim_id: 1 duplicate keys"} {
+			if !strings.Contains(log.String(), want) {
+				t.Errorf("log = %q, want it to contain %q", log.String(), want)
+			}
+		}
+	})
+}
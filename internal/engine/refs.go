@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// refPattern matches ${secret:name}, ${secret:name.field}, and
+// ${param:name} references, used in http task fields and env set values.
+var refPattern = regexp.MustCompile(`\$\{(secret|param):([^}]*)\}`)
+
+// expandRefs replaces ${secret:...} and ${param:...} references in s.
+func expandRefs(s string, run *Run) (string, error) {
+	var firstErr error
+	out := refPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := refPattern.FindStringSubmatch(ref)
+		value, err := resolveRef(m[1], m[2], run)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// resolveRef looks up one reference. A secret name containing a dot
+// selects a field of a structured secret.
+func resolveRef(kind, name string, run *Run) (string, error) {
+	if kind == "param" {
+		value, ok := run.Params[name]
+		if !ok {
+			return "", fmt.Errorf("unknown param %q", name)
+		}
+		return value, nil
+	}
+	if run.SecretsResolver == nil {
+		return "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+	}
+	if secret, field, ok := strings.Cut(name, "."); ok {
+		return run.SecretsResolver.ResolveField(run.DAGName, secret, field)
+	}
+	return run.SecretsResolver.Resolve(run.DAGName, name)
+}
@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// Report formats accepted by [dag] report, and the file each is written to
+// in the run directory.
+var reportFiles = map[string]string{
+	"markdown": "report.md",
+	"html":     "report.html",
+}
+
+// runReport is what both report templates render: the run summary in a
+// form meant for people who will not read run.json.
+type runReport struct {
+	ID        string
+	DAGName   string
+	Status    TaskStatus
+	Started   string
+	Duration  string
+	Revision  string
+	Tasks     []reportTask
+	Failures  []reportFailure
+	Outputs   []reportOutput
+	Anomalies []string
+}
+
+type reportTask struct {
+	Name     string
+	Status   TaskStatus
+	Attempts string
+	Duration string
+	Note     string
+}
+
+type reportFailure struct {
+	Task  string
+	Cause string
+	Error string
+	Lines []string
+}
+
+type reportOutput struct {
+	Name       string
+	Type       string
+	Location   string
+	ProducedBy string // empty when no task registered it
+	Rows       string
+}
+
+func buildRunReport(run *Run, outputs []config.Output) runReport {
+	r := runReport{
+		ID:       run.ID,
+		DAGName:  run.DAGName,
+		Status:   run.Status,
+		Started:  run.StartedAt.Local().Format("2006-01-02 15:04:05 MST"),
+		Duration: run.EndedAt.Sub(run.StartedAt).Round(time.Second).String(),
+	}
+	if !run.Revision.IsZero() {
+		r.Revision = run.Revision.String()
+	}
+
+	for _, ti := range expandedTasks(run.Tasks) {
+		t := reportTask{Name: ti.Name, Status: ti.Status}
+		if ti.Attempt > 1 {
+			t.Attempts = fmt.Sprintf("%d/%d", ti.Attempt, ti.MaxRetries+1)
+		}
+		if !ti.StartedAt.IsZero() && !ti.EndedAt.IsZero() {
+			t.Duration = ti.EndedAt.Sub(ti.StartedAt).Round(time.Second).String()
+		}
+		switch {
+		case ti.SkipReason != "":
+			t.Note = ti.SkipReason
+		case len(ti.FailedUpstream) > 0:
+			t.Note = "upstream failed: " + strings.Join(ti.FailedUpstream, ", ")
+		case ti.Failure != nil:
+			t.Note = ti.Failure.String()
+		}
+		r.Tasks = append(r.Tasks, t)
+
+		if ti.Status == StatusFailed && ti.Error != nil {
+			f := reportFailure{Task: ti.Name, Error: ti.Error.Error()}
+			if ti.Failure != nil {
+				f.Cause = ti.Failure.String()
+				f.Lines = ti.Failure.Lines
+			}
+			r.Failures = append(r.Failures, f)
+		}
+	}
+
+	for _, o := range outputs {
+		ro := reportOutput{Name: o.Name, Type: o.Type, Location: o.Location}
+		if p, ok := run.Produced.get(o.Name); ok {
+			ro.ProducedBy = p.Task
+			if p.Rows >= 0 {
+				ro.Rows = strconv.FormatInt(p.Rows, 10)
+			}
+		}
+		r.Outputs = append(r.Outputs, ro)
+	}
+
+	for _, a := range run.Anomalies {
+		r.Anomalies = append(r.Anomalies, "unusually slow: "+a.String())
+	}
+	return r
+}
+
+// writeRunReports renders the run report in each format into runDir and
+// returns the paths written. Formats are validated with the DAG, so an
+// unknown one is skipped.
+func writeRunReports(runDir string, run *Run, outputs []config.Output, formats []string) ([]string, error) {
+	r := buildRunReport(run, outputs)
+	var paths []string
+	for _, format := range formats {
+		name, ok := reportFiles[format]
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		var err error
+		if format == "html" {
+			err = htmlReportTmpl.Execute(&buf, r)
+		} else {
+			err = markdownReportTmpl.Execute(&buf, r)
+		}
+		if err != nil {
+			return paths, fmt.Errorf("rendering %s report: %w", format, err)
+		}
+		path := filepath.Join(runDir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return paths, fmt.Errorf("writing %s report: %w", format, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// mdCell makes s safe inside a Markdown table cell.
+func mdCell(s string) string {
+	if s == "" {
+		return "—"
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// mdFence returns a code fence longer than any run of backticks in lines,
+// so log lines cannot close the block early.
+func mdFence(lines []string) string {
+	longest := 0
+	for _, l := range lines {
+		run := 0
+		for _, c := range l {
+			if c == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+var markdownReportTmpl = template.Must(template.New("report.md").Funcs(template.FuncMap{
+	"cell":  mdCell,
+	"fence": mdFence,
+}).Parse(`# {{ .DAGName }} — {{ .Status }}
+
+| Run | Started | Duration |{{ if .Revision }} Revision |{{ end }}
+|---|---|---|{{ if .Revision }}---|{{ end }}
+| {{ cell .ID }} | {{ .Started }} | {{ .Duration }} |{{ if .Revision }} {{ cell .Revision }} |{{ end }}
+
+## Tasks
+
+| Task | Status | Attempts | Duration | Notes |
+|---|---|---|---|---|
+{{ range .Tasks }}| {{ cell .Name }} | {{ .Status }} | {{ cell .Attempts }} | {{ cell .Duration }} | {{ cell .Note }} |
+{{ end }}
+{{- if .Failures }}
+## Failures
+{{ range .Failures }}
+### {{ .Task }}{{ if .Cause }} — {{ .Cause }}{{ end }}
+
+{{ .Error }}
+{{ if .Lines }}
+{{ fence .Lines }}
+{{ range .Lines }}{{ . }}
+{{ end }}{{ fence .Lines }}
+{{ end }}{{ end }}{{ end }}
+{{- if .Outputs }}
+## Outputs
+
+| Output | Type | Location | Produced by | Rows |
+|---|---|---|---|---|
+{{ range .Outputs }}| {{ cell .Name }} | {{ cell .Type }} | {{ cell .Location }} | {{ if .ProducedBy }}{{ cell .ProducedBy }}{{ else }}not produced{{ end }} | {{ cell .Rows }} |
+{{ end }}{{ end }}
+{{- if .Anomalies }}
+## Warnings
+
+{{ range .Anomalies }}- {{ . }}
+{{ end }}{{ end }}`))
+
+var htmlReportTmpl = htmltemplate.Must(htmltemplate.New("report.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .DAGName }} — {{ .Status }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f3f3f3; }
+pre { background: #f6f6f6; padding: 8px; overflow-x: auto; }
+.success { color: #1a7f37; } .failed, .upstream_failed { color: #cf222e; } .skipped, .skipped_fresh { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{ .DAGName }} — <span class="{{ .Status }}">{{ .Status }}</span></h1>
+<table>
+<tr><th>Run</th><td>{{ .ID }}</td></tr>
+<tr><th>Started</th><td>{{ .Started }}</td></tr>
+<tr><th>Duration</th><td>{{ .Duration }}</td></tr>
+{{- if .Revision }}
+<tr><th>Revision</th><td>{{ .Revision }}</td></tr>
+{{- end }}
+</table>
+<h2>Tasks</h2>
+<table>
+<tr><th>Task</th><th>Status</th><th>Attempts</th><th>Duration</th><th>Notes</th></tr>
+{{- range .Tasks }}
+<tr><td>{{ .Name }}</td><td class="{{ .Status }}">{{ .Status }}</td><td>{{ .Attempts }}</td><td>{{ .Duration }}</td><td>{{ .Note }}</td></tr>
+{{- end }}
+</table>
+{{- if .Failures }}
+<h2>Failures</h2>
+{{- range .Failures }}
+<h3>{{ .Task }}{{ if .Cause }} — {{ .Cause }}{{ end }}</h3>
+<p>{{ .Error }}</p>
+{{- if .Lines }}
+<pre>{{ range .Lines }}{{ . }}
+{{ end }}</pre>
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Outputs }}
+<h2>Outputs</h2>
+<table>
+<tr><th>Output</th><th>Type</th><th>Location</th><th>Produced by</th><th>Rows</th></tr>
+{{- range .Outputs }}
+<tr><td>{{ .Name }}</td><td>{{ .Type }}</td><td>{{ .Location }}</td><td>{{ if .ProducedBy }}{{ .ProducedBy }}{{ else }}not produced{{ end }}</td><td>{{ .Rows }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Anomalies }}
+<h2>Warnings</h2>
+<ul>
+{{- range .Anomalies }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+</body>
+</html>
+`))
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func reportTestRun() *Run {
+	start := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+	run := &Run{
+		ID:        "20260302_060000.000_daily_sales",
+		DAGName:   "daily_sales",
+		Status:    StatusFailed,
+		StartedAt: start,
+		EndedAt:   start.Add(95 * time.Second),
+		Produced:  &producedOutputs{},
+		Tasks: []*TaskInstance{
+			{Name: "extract", Status: StatusSuccess, Attempt: 1, StartedAt: start, EndedAt: start.Add(30 * time.Second)},
+			{
+				Name: "load", Status: StatusFailed, Attempt: 2, MaxRetries: 1,
+				StartedAt: start.Add(30 * time.Second), EndedAt: start.Add(90 * time.Second),
+				Error: errors.New("exit status 1"),
+				Failure: &Failure{Class: FailureSQL, Detail: "SQLSTATE 23505", Lines: []string{
+					"INSERT INTO sales | failed",
+					"<script>alert(1)</script> ``` fence",
+				}},
+			},
+			{Name: "publish", Status: StatusUpstreamFailed, FailedUpstream: []string{"load"}},
+		},
+	}
+	run.Produced.set("raw_sales", producedOutput{Task: "extract", Rows: 1500})
+	return run
+}
+
+func TestWriteRunReports(t *testing.T) {
+	runDir := t.TempDir()
+	outputs := []config.Output{
+		{Name: "raw_sales", Type: "file", Location: "//sftp/reports/sales.csv"},
+		{Name: "sales", Type: "table", Location: "warehouse.dbo.sales"},
+	}
+	paths, err := writeRunReports(runDir, reportTestRun(), outputs, []string{"markdown", "html"})
+	if err != nil {
+		t.Fatalf("writeRunReports() error: %v", err)
+	}
+	want := []string{filepath.Join(runDir, "report.md"), filepath.Join(runDir, "report.html")}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+
+	md, _ := os.ReadFile(paths[0])
+	for _, s := range []string{
+		"# daily_sales — failed",
+		"| extract | success | — | 30s | — |",
+		"| load | failed | 2/2 | 1m0s | sql_error (SQLSTATE 23505) |",
+		"| publish | upstream_failed | — | — | upstream failed: load |",
+		"### load — sql_error (SQLSTATE 23505)",
+		"````\nINSERT INTO sales | failed\n",
+		"| raw_sales | file | //sftp/reports/sales.csv | extract | 1500 |",
+		"| sales | table | warehouse.dbo.sales | not produced | — |",
+	} {
+		if !strings.Contains(string(md), s) {
+			t.Errorf("report.md missing %q:\n%s", s, md)
+		}
+	}
+
+	html, _ := os.ReadFile(paths[1])
+	for _, s := range []string{
+		`<td class="failed">failed</td>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"<td>not produced</td>",
+	} {
+		if !strings.Contains(string(html), s) {
+			t.Errorf("report.html missing %q:\n%s", s, html)
+		}
+	}
+	if strings.Contains(string(html), "<script>") {
+		t.Error("report.html contains unescaped log output")
+	}
+}
+
+func TestMdCell(t *testing.T) {
+	tests := map[string]string{
+		"":              "—",
+		"a|b":           `a\|b`,
+		"line1\nline2":  "line1 line2",
+		"plain message": "plain message",
+	}
+	for in, want := range tests {
+		if got := mdCell(in); got != want {
+			t.Errorf("mdCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
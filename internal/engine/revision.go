@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// GitRevision is the code revision a run was snapshotted from. It is zero
+// when the project directory is not in a git worktree.
+type GitRevision struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch,omitempty"` // empty on a detached HEAD
+	Dirty  bool   `json:"dirty,omitempty"`  // the project directory had uncommitted changes
+}
+
+// IsZero reports whether no revision was resolved.
+func (g GitRevision) IsZero() bool { return g.Commit == "" }
+
+// String renders the revision as "0123456789ab (main, dirty)".
+func (g GitRevision) String() string {
+	s := g.Commit
+	if len(s) > 12 {
+		s = s[:12]
+	}
+	var notes []string
+	if g.Branch != "" {
+		notes = append(notes, g.Branch)
+	}
+	if g.Dirty {
+		notes = append(notes, "dirty")
+	}
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return s
+}
+
+// resolveGitRevision reads the commit, branch, and dirty state of the git
+// worktree containing projectDir. Only changes under projectDir count as
+// dirty, since nothing else is snapshotted. A directory outside git, or a
+// host without git, yields a zero revision and no error.
+func resolveGitRevision(ctx context.Context, projectDir string) (GitRevision, error) {
+	var rev GitRevision
+	if _, err := exec.LookPath("git"); err != nil {
+		return rev, nil
+	}
+	inside, err := probe(ctx, projectDir, "git", "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(inside) != "true" {
+		return rev, nil
+	}
+
+	out, err := probe(ctx, projectDir, "git", "rev-parse", "HEAD")
+	if err != nil {
+		// A repository with no commits yet
+		return rev, nil
+	}
+	rev.Commit = strings.TrimSpace(out)
+
+	if out, err := probe(ctx, projectDir, "git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		if branch := strings.TrimSpace(out); branch != "HEAD" {
+			rev.Branch = branch
+		}
+	}
+
+	out, err = probe(ctx, projectDir, "git", "status", "--porcelain", "--", ".")
+	if err != nil {
+		return rev, err
+	}
+	rev.Dirty = strings.TrimSpace(out) != ""
+	return rev, nil
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitRevision_String(t *testing.T) {
+	tests := []struct {
+		rev  GitRevision
+		want string
+	}{
+		{GitRevision{Commit: "3f2a9c1e8b7d4a6f0e1c2b3a4d5e6f7a8b9c0d1e", Branch: "main"}, "3f2a9c1e8b7d (main)"},
+		{GitRevision{Commit: "3f2a9c1e8b7d4a6f", Dirty: true}, "3f2a9c1e8b7d (dirty)"},
+		{GitRevision{Commit: "3f2a9c1e8b7d4a6f", Branch: "dev", Dirty: true}, "3f2a9c1e8b7d (dev, dirty)"},
+	}
+	for _, tt := range tests {
+		if got := tt.rev.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.rev, got, tt.want)
+		}
+	}
+}
+
+func TestResolveGitRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+
+	rev, err := resolveGitRevision(ctx, t.TempDir())
+	if err != nil || !rev.IsZero() {
+		t.Errorf("outside git: got %+v, %v; want zero revision", rev, err)
+	}
+
+	repo := t.TempDir()
+	project := filepath.Join(repo, "projects", "etl")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(project, "pit.toml"), []byte("[dag]\nname = \"etl\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-m", "initial commit")
+
+	rev, err = resolveGitRevision(ctx, project)
+	if err != nil {
+		t.Fatalf("resolveGitRevision() error: %v", err)
+	}
+	if len(rev.Commit) != 40 || rev.Branch != "main" || rev.Dirty {
+		t.Errorf("clean: got %+v, want a commit on main, not dirty", rev)
+	}
+
+	// Changes outside the project directory do not make it dirty
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rev, _ = resolveGitRevision(ctx, project); rev.Dirty {
+		t.Error("change outside the project marked it dirty")
+	}
+
+	if err := os.WriteFile(filepath.Join(project, "pit.toml"), []byte("[dag]\nname = \"etl2\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rev, _ = resolveGitRevision(ctx, project); !rev.Dirty {
+		t.Error("modified pit.toml not marked dirty")
+	}
+
+	git("checkout", "--detach")
+	if rev, _ = resolveGitRevision(ctx, project); rev.Branch != "" {
+		t.Errorf("detached HEAD: Branch = %q, want empty", rev.Branch)
+	}
+}
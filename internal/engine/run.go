@@ -1,9 +1,14 @@
 package engine
 
 import (
-	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/druarnfield/pit/internal/condition"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/runid"
+	"github.com/druarnfield/pit/internal/runner"
 )
 
 // TaskStatus represents the state of a task or run.
@@ -16,16 +21,29 @@ const (
 	StatusFailed         TaskStatus = "failed"
 	StatusSkipped        TaskStatus = "skipped"
 	StatusUpstreamFailed TaskStatus = "upstream_failed"
+	StatusSkippedFresh   TaskStatus = "skipped_fresh" // outputs still fresh (freshness)
 )
 
 // MetadataRecorder records run and task metadata to a persistent store.
 type MetadataRecorder interface {
 	RecordRunStart(id, dagName, status, runDir, trigger string, startedAt time.Time) error
 	RecordRunEnd(id, status string, endedAt time.Time, errMsg string) error
+	RecordSnapshot(runID string, files int, bytes int64, dur time.Duration) error
+	RecordArtifactURI(runID, uri string) error
+	RecordToolVersions(runID, pit, python, uv, dbt string) error
+	RecordGitRevision(runID, commit, branch string, dirty bool) error
+	RecordLogicalDate(runID, date string) error
+	RecordSDKSocket(runID, addr string) error
 	RecordTaskStart(runID, taskName, status, logPath string, startedAt time.Time) error
 	RecordTaskEnd(runID, taskName, status string, endedAt time.Time, attempts int, errMsg string) error
+	RecordTaskUpstreamFailed(runID, taskName string, failedUpstream []string, errMsg string, at time.Time) error
+	RecordTaskMetrics(runID, taskName string, metrics map[string]float64) error
+	TaskDurationHistory(dagName string, limit int) (map[string][]time.Duration, error)
 	RecordEnvSnapshot(dagName, hashType, hashValue, runID string) error
 	RecordOutput(runID, dagName, name, outputType, location string) error
+	RecordOutputProduced(runID, name, taskName string, rows int64, producedAt time.Time) error
+	OutputsProducedBy(dagName, taskName string) (map[string]time.Time, error)
+	RecordInput(runID, path, source, remote string, size int64, sha256 string, fetchedAt time.Time) error
 	RecordSecretAccess(project, secretKey, dagName, taskName, runID string, timestamp time.Time) error
 }
 
@@ -40,22 +58,56 @@ type Run struct {
 	ID          string
 	DAGName     string
 	ProjectDir  string     // source directory: local project dir or git repo cache
-	SnapshotDir string
+	RunDir      string     // runs/{run_id}/ ("" = the parent of SnapshotDir)
+	SnapshotDir string     // runs/{run_id}/project/, or the project directory itself with snapshot = false
 	LogDir      string
 	DataDir     string
 	Status      TaskStatus
 	StartedAt   time.Time
 	EndedAt     time.Time
 	Tasks       []*TaskInstance
+	Snapshot    SnapshotStats
+	InPlace     bool      // tasks ran in the project directory, without a snapshot (snapshot = false)
+	ArtifactURI string    // where artifacts were uploaded; empty if kept locally only
+	Reports     []string  // run reports written into the run directory ([dag] report)
+	Anomalies   []Anomaly // tasks that ran far longer than their recent median
+	AssertionError    error // output assertions that failed after the tasks succeeded, failing the run
+	AssertionWarnings int   // output assertions that failed with on_failure = "warn"
+	Params      map[string]string // [dag.params] merged with run params
+	LogicalDate string            // date the run processes, "2006-01-02"
+	Outputs     *taskOutputs      // values published by tasks via the SDK's set_output
+	Produced    *producedOutputs  // declared outputs tasks reported producing via register_output
+	Datasets    *runDatasets      // datasets read and written, for lineage events
+	Inputs      []InputFile       // files seeded or downloaded into the data dir, with their origin
+	Versions    ToolVersions      // pit, Python, uv, and dbt versions resolved for the run
+	Revision    GitRevision       // git commit the project was snapshotted from
 
 	// SDK fields — zero-value when SDK is not configured.
 	SocketPath      string           // Unix socket for task-to-orchestrator communication
 	SecretsResolver SecretsResolver  // resolves secrets by project scope
 
+	// janitor removes temporary credential files when the run ends.
+	janitor *janitor
+
+	// console streams verbose task output; nil unless verbose.
+	console *console
+
+	// loc is the DAG's timezone, which task conditions read the clock in.
+	loc *time.Location
+
 	// mu protects TaskInstance Status and Error fields during concurrent execution.
 	mu sync.Mutex
 }
 
+// dir returns the run directory holding the logs, data, and scratch
+// directories and the run's other artifacts.
+func (r *Run) dir() string {
+	if r.RunDir != "" {
+		return r.RunDir
+	}
+	return filepath.Dir(r.SnapshotDir)
+}
+
 // TaskInstance holds the state of a single task within a run.
 type TaskInstance struct {
 	Name       string
@@ -63,18 +115,43 @@ type TaskInstance struct {
 	Runner     string
 	Status     TaskStatus
 	DependsOn  []string
+	TriggerRule string // trigger_rule; "" = all_success
 	Attempt    int
 	MaxRetries int
 	RetryDelay time.Duration
 	Timeout    time.Duration
+	Limits     runner.Limits // memory_limit / cpu_limit
+	Env        *config.EnvConfig // task environment policy, applied over ExecuteOpts.Env
+	StallTimeout time.Duration // warn (or kill, with StallKill) after this long without output
+	StallKill    bool
+	RunIf      *condition.Expr
+	SkipIf     *condition.Expr
+	SkipReason string // why run_if / skip_if or freshness skipped the task
+	Freshness  time.Duration // skip when the task's outputs are younger than this
+	MapOver    string // map_over source; the task fans out at run time
+
+	// Mapped task state. A task with MapOver holds one instance per item in
+	// Instances; each instance records its parent in MapOf.
+	Instances []*TaskInstance
+	MapOf     string
+	MapIndex  int
+	MapItem   string
+
 	StartedAt  time.Time
 	EndedAt    time.Time
 	Error      error
+
+	// FailedUpstream names the failed tasks that caused an upstream_failed
+	// skip, traced through intermediate upstream_failed dependencies.
+	FailedUpstream []string
+
+	// Failure classifies the error of a failed task once the run ends.
+	Failure *Failure
 }
 
-// GenerateRunID creates a run ID in the format: 20240115_143022.123_dag_name
-// Millisecond precision reduces collision risk for rapid successive runs.
+// GenerateRunID creates a run ID in the format: 20240115_143022.123-3fa9c1_dag_name
+// The random suffix keeps IDs unique when a DAG is triggered several times
+// in the same millisecond.
 func GenerateRunID(dagName string) string {
-	now := time.Now()
-	return fmt.Sprintf("%s_%s", now.Format("20060102_150405.000"), dagName)
+	return runid.New(dagName)
 }
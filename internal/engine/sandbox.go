@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// artifactScratch is the keep_artifacts value that keeps task scratch
+// directories after each task finishes.
+const artifactScratch = "scratch"
+
+// scratchDirName is the run subdirectory holding one scratch directory
+// per task.
+const scratchDirName = "scratch"
+
+// snapshotReadOnly reports whether the run's project snapshot should be
+// write-protected while tasks run.
+func snapshotReadOnly(cfg *config.ProjectConfig, opts ExecuteOpts) bool {
+	if cfg.DAG.InPlace() {
+		// Never write-protect the project directory itself
+		return false
+	}
+	return opts.SnapshotReadOnly || cfg.DAG.ReadOnlySnapshot
+}
+
+// setReadOnly removes (or, with readOnly false, restores) write permission
+// on every file and directory under dir. Restoring only gives the owner
+// write access back. Symlinks are left alone.
+func setReadOnly(dir string, readOnly bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		// Windows ignores the read-only attribute on directories.
+		if d.IsDir() && runtime.GOOS == "windows" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := info.Mode().Perm()
+		if readOnly {
+			mode &^= 0o222
+		} else {
+			mode |= 0o200
+		}
+		return os.Chmod(path, mode)
+	})
+}
+
+// makeScratchDir creates the task's scratch directory under the run
+// directory and returns its path.
+func makeScratchDir(run *Run, ti *TaskInstance) (string, error) {
+	dir := filepath.Join(run.dir(), scratchDirName, ti.Name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating scratch dir: %w", err)
+	}
+	return dir, nil
+}
+
+// keepScratch reports whether task scratch directories outlive their task.
+func keepScratch(keep []string) bool {
+	return slices.Contains(keep, artifactScratch)
+}
+
+// scratchEnv points the usual temp directory variables at a task's scratch
+// directory, so temporary files stay inside the run.
+func scratchEnv(dir string) []string {
+	return []string{"PIT_SCRATCH_DIR=" + dir, "TMPDIR=" + dir, "TMP=" + dir, "TEMP=" + dir}
+}
+
+// proxyEnvNames are the proxy variables removed from an offline task's
+// environment, so clients fail fast instead of trying a proxy they cannot
+// reach. Matched case-insensitively.
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "ALL_PROXY", "NO_PROXY"}
+
+// taskOffline reports whether a task runs without network access: its own
+// offline setting if it has one, otherwise the DAG's.
+func taskOffline(dag config.DAGConfig, tc config.TaskConfig) bool {
+	if tc.Offline != nil {
+		return *tc.Offline
+	}
+	return dag.Offline
+}
+
+// offlineEnv removes proxy variables from env and tells uv not to reach
+// package indexes, so Python tasks run from the uv cache.
+func offlineEnv(env []string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(proxyEnvNames, strings.ToUpper(name)) {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return append(out, "UV_OFFLINE=1")
+}
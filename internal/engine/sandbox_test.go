@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestSetReadOnly(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not meaningful on Windows")
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "tasks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]os.FileMode{"pit.toml": 0o644, "tasks/run.sh": 0o755}
+	for name, mode := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("pit.toml", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setReadOnly(dir, true); err != nil {
+		t.Fatalf("setReadOnly(true) error: %v", err)
+	}
+	for _, name := range []string{".", "tasks", "pit.toml", "tasks/run.sh"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0o222 != 0 {
+			t.Errorf("%s mode = %v, want no write bits", name, info.Mode().Perm())
+		}
+	}
+
+	if err := setReadOnly(dir, false); err != nil {
+		t.Fatalf("setReadOnly(false) error: %v", err)
+	}
+	for name, mode := range files {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s mode = %v after restore, want %v", name, info.Mode().Perm(), mode)
+		}
+	}
+	// t.TempDir cleanup fails if anything is still read-only.
+}
+
+func TestMakeScratchDir(t *testing.T) {
+	runDir := t.TempDir()
+	run := &Run{SnapshotDir: filepath.Join(runDir, "project")}
+	dir, err := makeScratchDir(run, &TaskInstance{Name: "load_file[2]"})
+	if err != nil {
+		t.Fatalf("makeScratchDir() error: %v", err)
+	}
+	if want := filepath.Join(runDir, "scratch", "load_file[2]"); dir != want {
+		t.Errorf("makeScratchDir() = %q, want %q", dir, want)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("scratch dir not created: %v", err)
+	}
+}
+
+func TestTaskOffline(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		dag  bool
+		task *bool
+		want bool
+	}{
+		{false, nil, false},
+		{true, nil, true},
+		{false, &on, true},
+		{true, &off, false},
+	}
+	for _, tt := range tests {
+		got := taskOffline(config.DAGConfig{Offline: tt.dag}, config.TaskConfig{Offline: tt.task})
+		if got != tt.want {
+			t.Errorf("taskOffline(dag %v, task %v) = %v, want %v", tt.dag, tt.task, got, tt.want)
+		}
+	}
+}
+
+func TestOfflineEnv(t *testing.T) {
+	env := offlineEnv([]string{"PATH=/bin", "https_proxy=http://proxy:3128", "HTTP_PROXY=http://proxy:3128", "NO_PROXY=localhost", "PROXY_USER=x"})
+	want := []string{"PATH=/bin", "PROXY_USER=x", "UV_OFFLINE=1"}
+	if !slices.Equal(env, want) {
+		t.Errorf("offlineEnv() = %v, want %v", env, want)
+	}
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/sdk"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// sdkRunState is what the built-in SDK handlers record during a run.
+type sdkRunState struct {
+	inputs   *runInputs
+	datasets *runDatasets
+	outputs  *taskOutputs
+	produced *producedOutputs
+	temps    *tempTables
+}
+
+// registerBuiltinHandlers registers the SDK methods pit provides itself,
+// as opposed to plugins, on srv for a run of cfg with files in dataDir.
+func registerBuiltinHandlers(srv *sdk.Server, cfg *config.ProjectConfig, store *secrets.Store, dataDir string, defaults loadDefaults, st sdkRunState) {
+	dagName := cfg.DAG.Name
+
+	// Register the load_data handler for Python SDK → Go bulk load
+	srv.RegisterHandler("load_data", withLoadLineage(makeLoadDataHandler(store, dagName, dataDir, defaults), defaults, st.datasets))
+	srv.RegisterHandler("load_dataset", withLoadLineage(makeLoadDatasetHandler(store, dagName, dataDir, defaults), defaults, st.datasets))
+
+	// Register FTP handlers for Python SDK → Go FTP operations
+	srv.RegisterHandler("ftp_list", makeFTPListHandler(store, dagName))
+	srv.RegisterHandler("ftp_download", withFTPDownloadInputs(withFTPDownloadLineage(makeFTPDownloadHandler(store, dagName, dataDir), st.datasets), st.inputs, dataDir))
+	srv.RegisterHandler("ftp_upload", makeFTPUploadHandler(store, dagName, dataDir))
+	srv.RegisterHandler("ftp_move", makeFTPMoveHandler(store, dagName))
+
+	// Register archive handlers for decompressing feeds within the data dir
+	srv.RegisterHandler("unzip", makeUnzipHandler(dataDir))
+	srv.RegisterHandler("gzip", makeGzipHandler(dataDir))
+	srv.RegisterHandler("gunzip", makeGunzipHandler(dataDir))
+
+	// Register PGP handlers for encrypted partner feeds
+	srv.RegisterHandler("pgp_decrypt", makePGPDecryptHandler(store, dagName, dataDir))
+	srv.RegisterHandler("pgp_encrypt", makePGPEncryptHandler(store, dagName, dataDir))
+
+	// Register set_output so tasks can publish values for run_if / skip_if
+	srv.RegisterHandler("set_output", makeSetOutputHandler(st.outputs))
+
+	// Register register_output so tasks can report producing declared outputs
+	srv.RegisterHandler("register_output", makeRegisterOutputHandler(cfg.Outputs, st.produced))
+
+	// Register temp table handlers; tables the tasks leave are dropped when the run ends
+	srv.RegisterHandler("create_temp_table", makeCreateTempTableHandler(store, dagName, defaults, st.temps))
+	srv.RegisterHandler("drop_temp_table", makeDropTempTableHandler(st.temps))
+}
+
+// SDKSession serves the built-in SDK methods of a DAG without executing a
+// run, so task scripts can be started against it directly, as pkg/pittest
+// does. Lineage and run inputs are not recorded and plugin methods are not
+// served.
+type SDKSession struct {
+	server   *sdk.Server
+	outputs  *taskOutputs
+	produced *producedOutputs
+	temps    *tempTables
+	cancel   context.CancelFunc
+}
+
+// StartSDKSession starts an SDK server for cfg on a socket in dir (on
+// Windows, a localhost TCP port). Secrets resolve from store, which may be
+// nil, and file methods are confined to dataDir. Load methods use the
+// [dag.sql] schema and identifier case of cfg.
+func StartSDKSession(cfg *config.ProjectConfig, store *secrets.Store, dir, dataDir string) (*SDKSession, error) {
+	if store != nil {
+		store.SetScopes(cfg.DAG.Name, cfg.DAG.SecretScopes)
+	}
+	srv, err := sdk.NewServer(sdkSocketPath(dir, "", dir), store, cfg.DAG.Name)
+	if err != nil {
+		return nil, fmt.Errorf("starting SDK server: %w", err)
+	}
+
+	defaults := resolveLoadDefaults(cfg, nil)
+	s := &SDKSession{
+		server:   srv,
+		outputs:  &taskOutputs{},
+		produced: &producedOutputs{},
+		temps:    newTempTables(defaults.identCase),
+	}
+	registerBuiltinHandlers(srv, cfg, store, dataDir, defaults, sdkRunState{
+		inputs:   &runInputs{},
+		datasets: &runDatasets{},
+		outputs:  s.outputs,
+		produced: s.produced,
+		temps:    s.temps,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go srv.Serve(ctx)
+	return s, nil
+}
+
+// Addr returns the address tasks connect to, their PIT_SOCKET.
+func (s *SDKSession) Addr() string { return s.server.Addr() }
+
+// Output returns the value task published for key with set_output.
+func (s *SDKSession) Output(task, key string) (string, bool) {
+	return s.outputs.get(task, key)
+}
+
+// Produced reports whether a task registered the declared output name with
+// register_output, which task, and the row count it gave (-1 for none).
+func (s *SDKSession) Produced(name string) (task string, rows int64, ok bool) {
+	o, ok := s.produced.get(name)
+	return o.Task, o.Rows, ok
+}
+
+// Close drops the temp tables the tasks left and stops the server.
+func (s *SDKSession) Close() error {
+	s.temps.dropAll()
+	s.cancel()
+	return s.server.Shutdown()
+}
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/loader"
+	"github.com/druarnfield/pit/internal/remotepath"
+	"github.com/druarnfield/pit/internal/runner"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// Sensor kinds for tasks with type = "sensor".
+const (
+	SensorFile  = "file"
+	SensorTable = "table"
+	SensorFTP   = "ftp"
+)
+
+const (
+	// DefaultSensorPollInterval is the time between sensor checks when
+	// poll_interval is not set.
+	DefaultSensorPollInterval = 30 * time.Second
+
+	// DefaultSensorTimeout bounds a sensor whose task sets no timeout, so a
+	// condition that never arrives cannot hold the run open indefinitely.
+	DefaultSensorTimeout = time.Hour
+)
+
+// sensorCheck reports whether a sensor's condition is met, and if so, a
+// short description of what satisfied it.
+type sensorCheck func(ctx context.Context) (met bool, detail string, err error)
+
+// executeSensorTask polls the sensor described by tc until its condition is
+// met or the task timeout expires. Errors from individual checks (a dropped
+// FTP connection, a database restart) are logged and retried; only the
+// timeout fails the task.
+func executeSensorTask(ctx context.Context, ti *TaskInstance, run *Run, cfg *config.ProjectConfig, tc *config.TaskConfig, logWriter io.Writer) error {
+	check, desc, err := newSensorCheck(run, cfg, tc)
+	if err != nil {
+		return err
+	}
+
+	timeout := ti.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSensorTimeout
+	}
+	interval := tc.PollInterval.Duration
+	if interval <= 0 {
+		interval = DefaultSensorPollInterval
+	}
+
+	sensorCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Fprintf(logWriter, "[sensor] waiting for %s (every %s, timeout %s)\n", desc, interval, timeout)
+	start := time.Now()
+	for {
+		met, detail, err := check(sensorCtx)
+		switch {
+		case err != nil && sensorCtx.Err() == nil:
+			fmt.Fprintf(logWriter, "[sensor] check failed, will retry: %v\n", err)
+		case met:
+			fmt.Fprintf(logWriter, "[sensor] condition met after %s: %s\n", time.Since(start).Round(time.Second), detail)
+			return nil
+		}
+
+		select {
+		case <-sensorCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("sensor timed out after %s waiting for %s", timeout, desc)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// newSensorCheck builds the check for tc and a description of what it waits for.
+func newSensorCheck(run *Run, cfg *config.ProjectConfig, tc *config.TaskConfig) (sensorCheck, string, error) {
+	switch tc.Kind {
+	case SensorFile:
+		pattern := tc.Path
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(run.DataDir, pattern)
+		}
+		return fileSensor(pattern), fmt.Sprintf("file %s", pattern), nil
+
+	case SensorTable:
+		connKey := resolveTaskConnection(tc, cfg)
+		if connKey == "" {
+			return nil, "", fmt.Errorf("no connection configured (set connection on task or [dag.sql])")
+		}
+		if run.SecretsResolver == nil {
+			return nil, "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		}
+		connStr, err := runner.ResolveConnection(run.SecretsResolver, run.DAGName, connKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("resolving connection %q: %w", connKey, err)
+		}
+		if tc.Query != "" {
+			return querySensor(connStr, tc.Query), "query condition", nil
+		}
+		return tableSensor(connStr, tc.Table), fmt.Sprintf("table %s", tc.Table), nil
+
+	case SensorFTP:
+		store, _ := run.SecretsResolver.(*secrets.Store)
+		if store == nil {
+			return nil, "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		}
+		return ftpSensor(store, run.DAGName, tc.Secret, tc.Path), fmt.Sprintf("FTP file %s", tc.Path), nil
+
+	default:
+		return nil, "", fmt.Errorf("unknown sensor kind %q (must be file, table, or ftp)", tc.Kind)
+	}
+}
+
+// fileSensor is met when at least one regular file matches pattern.
+func fileSensor(pattern string) sensorCheck {
+	return func(ctx context.Context) (bool, string, error) {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, "", err
+		}
+		var files []string
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+				files = append(files, m)
+			}
+		}
+		if len(files) == 0 {
+			return false, "", nil
+		}
+		return true, describeMatches(files), nil
+	}
+}
+
+// tableSensor is met once schema.table exists.
+func tableSensor(connStr, fqTable string) sensorCheck {
+	schema, table := parseSchemaTable(fqTable)
+	return func(ctx context.Context) (bool, string, error) {
+		ok, err := loader.TableExists(ctx, connStr, schema, table)
+		if err != nil || !ok {
+			return false, "", err
+		}
+		return true, fmt.Sprintf("table %s exists", fqTable), nil
+	}
+}
+
+// querySensor is met when the first column of the query's first row is
+// truthy; see sensorTruthy.
+func querySensor(connStr, query string) sensorCheck {
+	return func(ctx context.Context) (bool, string, error) {
+		driver, err := runner.DetectDriver(connStr)
+		if err != nil {
+			return false, "", err
+		}
+		db, err := runner.OpenDB(driver, connStr)
+		if err != nil {
+			return false, "", fmt.Errorf("opening %s connection: %w", driver, err)
+		}
+		defer db.Close()
+
+		var v any
+		err = db.QueryRowContext(ctx, query).Scan(&v)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, "", nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if !sensorTruthy(v) {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("query returned %v", sensorValue(v)), nil
+	}
+}
+
+// ftpSensor is met when at least one file matches the remote glob. The
+// connection is opened per check so a dropped session does not stick.
+func ftpSensor(store *secrets.Store, dagName, secretName, remoteGlob string) sensorCheck {
+	dir, pattern := path.Split(remoteGlob)
+	if dir == "" {
+		dir = "."
+	}
+	return func(ctx context.Context) (bool, string, error) {
+		client, err := connectFTP(store, dagName, secretName)
+		if err != nil {
+			return false, "", err
+		}
+		defer client.Close()
+
+		files, err := client.List(dir, pattern)
+		if err != nil || len(files) == 0 {
+			return false, "", err
+		}
+		names := make([]string, len(files))
+		for i, f := range files {
+			names[i] = remotepath.Join(dir, f.Name)
+		}
+		return true, describeMatches(names), nil
+	}
+}
+
+// sensorTruthy reports whether a scanned query value counts as "met":
+// non-NULL, non-zero, and not an empty, "0", or "false" string.
+func sensorTruthy(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case int64:
+		return x != 0
+	case float64:
+		return x != 0
+	case []byte:
+		return truthyString(string(x))
+	case string:
+		return truthyString(x)
+	case time.Time:
+		return !x.IsZero()
+	default:
+		// Driver-specific numeric types (e.g. ClickHouse UInt64)
+		if n, err := strconv.ParseFloat(fmt.Sprint(x), 64); err == nil {
+			return n != 0
+		}
+		return true
+	}
+}
+
+func truthyString(s string) bool {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" || s == "false" {
+		return false
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n != 0
+	}
+	return true
+}
+
+func sensorValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// describeMatches summarises matched file names for the task log.
+func describeMatches(names []string) string {
+	if len(names) == 1 {
+		return "found " + names[0]
+	}
+	return fmt.Sprintf("found %d files (%s, ...)", len(names), names[0])
+}
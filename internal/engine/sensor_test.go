@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestSensor_File(t *testing.T) {
+	dataDir := t.TempDir()
+	run := &Run{DAGName: "test", DataDir: dataDir}
+	tc := &config.TaskConfig{
+		Name: "wait", Type: "sensor", Kind: SensorFile, Path: "landing/claims_*.csv",
+		PollInterval: config.Duration{Duration: 10 * time.Millisecond},
+	}
+	ti := &TaskInstance{Name: "wait", Timeout: 5 * time.Second}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.MkdirAll(filepath.Join(dataDir, "landing", "claims_dir.csv"), 0o755) // directories never match
+		os.WriteFile(filepath.Join(dataDir, "landing", "claims_0301.csv"), []byte("a,b\n"), 0o644)
+	}()
+
+	var log bytes.Buffer
+	if err := executeSensorTask(context.Background(), ti, run, &config.ProjectConfig{}, tc, &log); err != nil {
+		t.Fatalf("executeSensorTask() error: %v\n%s", err, log.String())
+	}
+	if !strings.Contains(log.String(), "condition met") || !strings.Contains(log.String(), "claims_0301.csv") {
+		t.Errorf("log = %q, want matched file reported", log.String())
+	}
+}
+
+func TestSensor_Timeout(t *testing.T) {
+	run := &Run{DAGName: "test", DataDir: t.TempDir()}
+	tc := &config.TaskConfig{
+		Name: "wait", Type: "sensor", Kind: SensorFile, Path: "never.csv",
+		PollInterval: config.Duration{Duration: 10 * time.Millisecond},
+	}
+	ti := &TaskInstance{Name: "wait", Timeout: 50 * time.Millisecond}
+
+	var log bytes.Buffer
+	err := executeSensorTask(context.Background(), ti, run, &config.ProjectConfig{}, tc, &log)
+	if err == nil || !strings.Contains(err.Error(), "sensor timed out") {
+		t.Errorf("executeSensorTask() error = %v, want timeout", err)
+	}
+
+	// Cancelling the run is reported as cancellation, not a sensor timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ti.Timeout = time.Minute
+	if err := executeSensorTask(ctx, ti, run, &config.ProjectConfig{}, tc, &log); !errors.Is(err, context.Canceled) {
+		t.Errorf("executeSensorTask() after cancel error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewSensorCheck_Errors(t *testing.T) {
+	run := &Run{DAGName: "test"}
+	cfg := &config.ProjectConfig{DAG: config.DAGConfig{SQL: config.SQLConfig{Connection: "warehouse"}}}
+
+	tests := []struct {
+		name string
+		tc   config.TaskConfig
+		want string
+	}{
+		{"unknown kind", config.TaskConfig{Kind: "s3"}, "unknown sensor kind"},
+		{"table without secrets", config.TaskConfig{Kind: SensorTable, Table: "dbo.claims"}, "secrets store not configured"},
+		{"ftp without secrets", config.TaskConfig{Kind: SensorFTP, Secret: "ftp", Path: "/out/*.csv"}, "secrets store not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newSensorCheck(run, cfg, &tt.tc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("newSensorCheck() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensorTruthy(t *testing.T) {
+	tests := []struct {
+		v    any
+		want bool
+	}{
+		{nil, false},
+		{int64(0), false},
+		{int64(3), true},
+		{float64(0.5), true},
+		{true, true},
+		{false, false},
+		{[]byte("0"), false},
+		{[]byte("false"), false},
+		{"", false},
+		{"ready", true},
+		{uint64(0), false},
+		{uint64(7), true},
+		{time.Time{}, false},
+		{time.Now(), true},
+	}
+	for _, tt := range tests {
+		if got := sensorTruthy(tt.v); got != tt.want {
+			t.Errorf("sensorTruthy(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
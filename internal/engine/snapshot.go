@@ -6,7 +6,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // skipDirs are directories that should not be copied into a snapshot.
@@ -17,46 +20,235 @@ var skipDirs = map[string]bool{
 	"node_modules": true,
 }
 
+// DefaultSnapshotWorkers is the number of files copied concurrently when
+// snapshotting a project.
+const DefaultSnapshotWorkers = 8
+
+// Symlink policies for snapshots.
+const (
+	SymlinksSkip     = "skip"     // leave links out of the snapshot (default)
+	SymlinksFollow   = "follow"   // copy what the link points to
+	SymlinksPreserve = "preserve" // recreate the link, pointing at the same target
+)
+
+// snapshotLogName is the file in the run's log directory that lists
+// symlinks left out of the snapshot.
+const snapshotLogName = "snapshot.log"
+
+// SnapshotOptions tunes how a project is copied into a run.
+type SnapshotOptions struct {
+	Workers      int    // concurrent file copies (0 = DefaultSnapshotWorkers, 1 = sequential)
+	Symlinks     string // SymlinksSkip (default), SymlinksFollow, or SymlinksPreserve
+	MinFreeSpace int64  // bytes to leave free on the runs volume (0 = DefaultMinFreeSpace, < 0 = no check)
+}
+
+// SnapshotStats describes the project copy made for a run.
+type SnapshotStats struct {
+	Files        int
+	Bytes        int64
+	Duration     time.Duration
+	SkippedLinks int // symlinks left out; listed in logs/snapshot.log
+}
+
 // Snapshot copies the project directory into the run snapshot directory
-// and creates the logs and data directories. Returns the snapshot, log,
-// and data directory paths.
-func Snapshot(projectDir, runsDir, runID string) (snapshotDir, logDir, dataDir string, err error) {
+// and creates the logs and data directories. Before anything is written it
+// checks that the runs volume can hold the copy plus sopts.MinFreeSpace.
+// Returns the snapshot, log, and data directory paths along with copy
+// statistics. Symlinks that are not copied are listed in snapshot.log in
+// the log directory.
+func Snapshot(projectDir, runsDir, runID string, sopts SnapshotOptions) (snapshotDir, logDir, dataDir string, stats SnapshotStats, err error) {
 	absRunsDir, err := filepath.Abs(runsDir)
 	if err != nil {
-		return "", "", "", fmt.Errorf("resolving runs dir: %w", err)
+		return "", "", "", stats, fmt.Errorf("resolving runs dir: %w", err)
 	}
 	snapshotDir = filepath.Join(absRunsDir, runID, "project")
 	logDir = filepath.Join(absRunsDir, runID, "logs")
 	dataDir = filepath.Join(absRunsDir, runID, "data")
 
+	// Plan the copy first so the preflight sizes exactly what will be
+	// copied, under the same skipDirs and symlink policy.
+	plan, err := planCopy(projectDir, snapshotDir, sopts.Symlinks, "")
+	if err != nil {
+		return "", "", "", stats, fmt.Errorf("copying project to snapshot: %w", err)
+	}
+	if err := checkDiskSpace(absRunsDir, plan.size(), sopts.MinFreeSpace); err != nil {
+		return "", "", "", stats, fmt.Errorf("disk space preflight: %w", err)
+	}
+
 	if err := os.MkdirAll(logDir, 0o755); err != nil {
-		return "", "", "", fmt.Errorf("creating log dir: %w", err)
+		return "", "", "", stats, fmt.Errorf("creating log dir: %w", err)
 	}
 
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
-		return "", "", "", fmt.Errorf("creating data dir: %w", err)
+		return "", "", "", stats, fmt.Errorf("creating data dir: %w", err)
+	}
+
+	if sopts.Workers <= 0 {
+		sopts.Workers = DefaultSnapshotWorkers
+	}
+	start := time.Now()
+	cs, err := plan.apply(sopts.Workers)
+	if err != nil {
+		return "", "", "", stats, fmt.Errorf("copying project to snapshot: %w", err)
 	}
+	stats = SnapshotStats{Files: cs.files, Bytes: cs.bytes, Duration: time.Since(start), SkippedLinks: len(cs.skipped)}
 
-	if err := copyDir(projectDir, snapshotDir); err != nil {
-		return "", "", "", fmt.Errorf("copying project to snapshot: %w", err)
+	if err := appendSnapshotLog(logDir, cs.skipped); err != nil {
+		return "", "", "", stats, err
 	}
 
-	return snapshotDir, logDir, dataDir, nil
+	return snapshotDir, logDir, dataDir, stats, nil
+}
+
+// PrepareInPlace sets up a run that executes directly in projectDir, for
+// DAGs with snapshot = false: only the run's logs and data directories are
+// created under runsDir. It returns the absolute project directory in
+// place of a snapshot directory, followed by the log and data directories.
+func PrepareInPlace(projectDir, runsDir, runID string) (projectAbs, logDir, dataDir string, err error) {
+	absRunsDir, err := filepath.Abs(runsDir)
+	if err != nil {
+		return "", "", "", fmt.Errorf("resolving runs dir: %w", err)
+	}
+	if projectAbs, err = filepath.Abs(projectDir); err != nil {
+		return "", "", "", fmt.Errorf("resolving project dir: %w", err)
+	}
+	logDir = filepath.Join(absRunsDir, runID, "logs")
+	dataDir = filepath.Join(absRunsDir, runID, "data")
+	for _, dir := range []string{logDir, dataDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", "", "", fmt.Errorf("creating run dir: %w", err)
+		}
+	}
+	return projectAbs, logDir, dataDir, nil
+}
+
+// appendSnapshotLog adds skipped-link entries to snapshot.log in logDir,
+// creating it on the first entry.
+func appendSnapshotLog(logDir string, skipped []string) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(filepath.Join(logDir, snapshotLogName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("writing snapshot log: %w", err)
+	}
+	_, err = f.WriteString(strings.Join(skipped, "\n") + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing snapshot log: %w", err)
+	}
+	return nil
+}
+
+// copyStats counts the files and bytes copied by copyDir and lists the
+// symlinks it left out.
+type copyStats struct {
+	files   int
+	bytes   int64
+	skipped []string // "path -> target (reason)"
+}
+
+// copyJob is a single file queued for copying by copyDir.
+type copyJob struct {
+	src, dst string
+	size     int64
 }
 
 // copyDir recursively copies src to dst, skipping directories in skipDirs
-// and symlinks.
-func copyDir(src, dst string) error {
+// and handling symlinks according to sopts.Symlinks.
+func copyDir(src, dst string, sopts SnapshotOptions) (copyStats, error) {
+	plan, err := planCopy(src, dst, sopts.Symlinks, "")
+	if err != nil {
+		return copyStats{}, err
+	}
+	return plan.apply(sopts.Workers)
+}
+
+// planCopy walks src once and records the directories, links, and files a
+// copy to dst would create, without touching dst. policy is one of the
+// Symlinks* values ("" = SymlinksSkip); label prefixes the paths of
+// skipped links so entries from different trees can share snapshot.log.
+func planCopy(src, dst, policy, label string) (*snapshotWalker, error) {
+	w := &snapshotWalker{policy: policy, root: src, label: label}
+	if w.policy == "" {
+		w.policy = SymlinksSkip
+	}
+	realSrc, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.walk(src, dst, []string{realSrc}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// size returns the number of bytes the planned copy will write. Followed
+// links count at the size of their targets.
+func (w *snapshotWalker) size() int64 {
+	var total int64
+	for _, j := range w.jobs {
+		total += j.size
+	}
+	return total
+}
+
+// apply carries out the plan: directories are created in walk order,
+// links are recreated, and files are copied by up to workers goroutines,
+// largest first so a few big files do not leave the pool idle at the end.
+func (w *snapshotWalker) apply(workers int) (copyStats, error) {
+	for _, d := range w.dirs {
+		if err := os.MkdirAll(d.path, d.perm); err != nil {
+			return copyStats{}, err
+		}
+	}
+	for _, l := range w.links {
+		if err := os.Symlink(l.dest, l.target); err != nil {
+			return copyStats{}, fmt.Errorf("preserving symlink %s: %w", l.src, err)
+		}
+	}
+
+	jobs := w.jobs
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].size > jobs[j].size })
+	if err := copyFiles(jobs, workers); err != nil {
+		return copyStats{}, err
+	}
+	return copyStats{files: len(jobs), bytes: w.size(), skipped: w.skipped}, nil
+}
+
+// snapshotWalker plans a copy: the directories, links, and files to create.
+type snapshotWalker struct {
+	policy  string
+	root    string // original src, for log-friendly relative paths
+	label   string // prefix for skipped-link paths ("" = relative to root)
+	dirs    []dirJob
+	links   []linkJob
+	jobs    []copyJob
+	skipped []string
+}
+
+// dirJob is a directory to create, listed parents first.
+type dirJob struct {
+	path string
+	perm fs.FileMode
+}
+
+// linkJob is a symlink recreated under SymlinksPreserve.
+type linkJob struct {
+	src, target, dest string // link in the source tree, link to create, and what it points at
+}
+
+// walk plans the copy of the tree at src into dst. stack holds the resolved paths of
+// the directories currently being walked (src and any followed links
+// above it) and is used to detect symlink loops.
+func (w *snapshotWalker) walk(src, dst string, stack []string) error {
 	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip symlinks — they may point outside the project tree.
-		if d.Type()&fs.ModeSymlink != 0 {
-			return nil
-		}
-
 		// Get relative path from source root
 		rel, err := filepath.Rel(src, path)
 		if err != nil {
@@ -75,39 +267,159 @@ func copyDir(src, dst string) error {
 
 		target := filepath.Join(dst, rel)
 
+		if d.Type()&fs.ModeSymlink != 0 {
+			return w.link(path, target, filepath.Join(stack[len(stack)-1], filepath.Dir(rel)), stack)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
 		if d.IsDir() {
-			info, err := d.Info()
-			if err != nil {
-				return err
-			}
-			return os.MkdirAll(target, info.Mode().Perm())
+			w.dirs = append(w.dirs, dirJob{path: target, perm: info.Mode().Perm()})
+			return nil
 		}
 
-		return copyFile(path, target)
+		w.jobs = append(w.jobs, copyJob{src: path, dst: target, size: info.Size()})
+		return nil
 	})
 }
 
-// copyDirContents copies all files from src into dst without creating
-// the src directory itself. dst must already exist.
-func copyDirContents(src, dst string) error {
-	entries, err := os.ReadDir(src)
+// link handles the symlink at path. realParent is the resolved directory
+// containing it.
+func (w *snapshotWalker) link(path, target, realParent string, stack []string) error {
+	dest, err := os.Readlink(path)
 	if err != nil {
-		return fmt.Errorf("reading %q: %w", src, err)
+		return err
 	}
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-		if entry.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
-				return err
+
+	switch w.policy {
+	case SymlinksPreserve:
+		w.links = append(w.links, linkJob{src: path, target: target, dest: dest})
+		return nil
+
+	case SymlinksFollow:
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			w.skip(path, dest, "broken link")
+			return nil
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			w.jobs = append(w.jobs, copyJob{src: resolved, dst: target, size: info.Size()})
+			return nil
+		}
+		// A link to a directory that contains the link, or to one already
+		// being walked, would recurse forever.
+		for _, dir := range append(stack, realParent) {
+			if isWithin(dir, resolved) {
+				w.skip(path, dest, "loop")
+				return nil
 			}
-		} else {
-			if err := copyFile(srcPath, dstPath); err != nil {
+		}
+		return w.walk(resolved, target, append(stack[:len(stack):len(stack)], resolved))
+
+	default:
+		w.skip(path, dest, "symlinks = \"skip\"")
+		return nil
+	}
+}
+
+func (w *snapshotWalker) skip(path, dest, reason string) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = path
+	} else if w.label != "" {
+		rel = filepath.Join(w.label, rel)
+	}
+	w.skipped = append(w.skipped, fmt.Sprintf("skipped symlink %s -> %s (%s)", rel, dest, reason))
+}
+
+// isWithin reports whether path is dir or lies inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// copyFiles copies jobs using up to workers goroutines and returns the first
+// error encountered. Remaining jobs are abandoned after an error.
+func copyFiles(jobs []copyJob, workers int) error {
+	if workers <= 1 || len(jobs) <= 1 {
+		for _, j := range jobs {
+			if err := copyFile(j.src, j.dst); err != nil {
 				return err
 			}
 		}
+		return nil
 	}
-	return nil
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	ch := make(chan copyJob)
+	stop := make(chan struct{})
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range ch {
+				if err := copyFile(j.src, j.dst); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						close(stop)
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, j := range jobs {
+		select {
+		case ch <- j:
+		case <-stop:
+			break feed
+		}
+	}
+	close(ch)
+	wg.Wait()
+	return firstErr
+}
+
+// externalDataDir creates <base>/<runID> as the data directory of a run
+// whose DAG sets data_dir, and removes the empty data/ that Snapshot made
+// in the run directory so nothing is written there by mistake.
+func externalDataDir(base, runID, localDataDir string) (string, error) {
+	dir := filepath.Join(base, runID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating data dir: %w", err)
+	}
+	if err := os.Remove(localDataDir); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("removing run-local data dir: %w", err)
+	}
+	return dir, nil
+}
+
+// copyDirContents copies all files from src into dst without creating
+// the src directory itself, applying sopts.Symlinks the way a project
+// snapshot does. Skipped links are reported with paths under label.
+func copyDirContents(src, dst, label string, sopts SnapshotOptions) (copyStats, error) {
+	plan, err := planCopy(src, dst, sopts.Symlinks, label)
+	if err != nil {
+		return copyStats{}, fmt.Errorf("reading %q: %w", src, err)
+	}
+	return plan.apply(sopts.Workers)
 }
 
 // artifactDirMap maps keep_artifacts names to run subdirectory names.
@@ -115,10 +427,16 @@ var artifactDirMap = map[string]string{
 	"project": "project",
 	"logs":    "logs",
 	"data":    "data",
+	"scratch": scratchDirName,
 }
 
+// artifactLogsFailedOnly keeps the logs directory but only the log files
+// of tasks that did not succeed.
+const artifactLogsFailedOnly = "logs_failed_only"
+
 // cleanupArtifacts removes run subdirectories that are not in the keep list.
-// runDir is the parent directory containing project/, logs/, and data/.
+// runDir is the parent directory containing project/, logs/, data/, and
+// scratch/.
 func cleanupArtifacts(runDir string, keep []string) error {
 	keepSet := make(map[string]bool, len(keep))
 	for _, k := range keep {
@@ -126,7 +444,7 @@ func cleanupArtifacts(runDir string, keep []string) error {
 	}
 
 	for artifact, dirName := range artifactDirMap {
-		if keepSet[artifact] {
+		if keepSet[artifact] || (artifact == "logs" && keepSet[artifactLogsFailedOnly]) {
 			continue
 		}
 		path := filepath.Join(runDir, dirName)
@@ -137,6 +455,21 @@ func cleanupArtifacts(runDir string, keep []string) error {
 	return nil
 }
 
+// pruneSuccessfulLogs removes the log files of tasks that succeeded, leaving
+// logs for failed tasks and run-level files such as snapshot.log.
+func pruneSuccessfulLogs(logDir string, tasks []*TaskInstance) error {
+	for _, ti := range tasks {
+		if ti.Status != StatusSuccess {
+			continue
+		}
+		path := filepath.Join(logDir, ti.Name+".log")
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing log for %s: %w", ti.Name, err)
+		}
+	}
+	return nil
+}
+
 // copyFile copies a single file from src to dst, preserving permissions.
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
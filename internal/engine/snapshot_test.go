@@ -0,0 +1,234 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// symlinkProject builds a project containing a file link, a directory link
+// to a shared library outside the project, a broken link, and a loop.
+func symlinkProject(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	shared := filepath.Join(base, "shared")
+	src := filepath.Join(base, "project")
+	os.MkdirAll(filepath.Join(shared, "lib"), 0o755)
+	os.WriteFile(filepath.Join(shared, "lib", "helpers.py"), []byte("def help(): pass\n"), 0o644)
+	os.MkdirAll(filepath.Join(src, "tasks"), 0o755)
+	os.WriteFile(filepath.Join(src, "pit.toml"), []byte("[dag]\nname = \"test\"\n"), 0o644)
+	os.WriteFile(filepath.Join(src, "tasks", "run.py"), []byte("print(1)\n"), 0o644)
+
+	links := map[string]string{
+		"tasks/shared":  filepath.Join(shared, "lib"),
+		"config.toml":   "pit.toml",
+		"tasks/broken":  "does_not_exist",
+		"tasks/up_loop": "..",
+	}
+	for rel, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, rel)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	return src
+}
+
+func TestSnapshot_SymlinksSkip(t *testing.T) {
+	src := symlinkProject(t)
+
+	snapshotDir, logDir, _, stats, err := Snapshot(src, t.TempDir(), "skip_links", SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(snapshotDir, "config.toml")); !os.IsNotExist(err) {
+		t.Errorf("config.toml link should be skipped, Lstat err = %v", err)
+	}
+	if stats.SkippedLinks != 4 {
+		t.Errorf("SkippedLinks = %d, want 4", stats.SkippedLinks)
+	}
+	log, err := os.ReadFile(filepath.Join(logDir, snapshotLogName))
+	if err != nil {
+		t.Fatalf("reading snapshot log: %v", err)
+	}
+	if !strings.Contains(string(log), "skipped symlink "+filepath.Join("tasks", "shared")+" -> ") {
+		t.Errorf("snapshot log missing tasks/shared entry:\n%s", log)
+	}
+}
+
+func TestSnapshot_SymlinksPreserve(t *testing.T) {
+	src := symlinkProject(t)
+
+	snapshotDir, _, _, stats, err := Snapshot(src, t.TempDir(), "preserve_links", SnapshotOptions{Symlinks: SymlinksPreserve})
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	got, err := os.Readlink(filepath.Join(snapshotDir, "config.toml"))
+	if err != nil || got != "pit.toml" {
+		t.Errorf("Readlink(config.toml) = %q, %v; want %q", got, err, "pit.toml")
+	}
+	if _, err := os.Readlink(filepath.Join(snapshotDir, "tasks", "broken")); err != nil {
+		t.Errorf("broken link should be preserved as-is: %v", err)
+	}
+	if stats.SkippedLinks != 0 {
+		t.Errorf("SkippedLinks = %d, want 0", stats.SkippedLinks)
+	}
+}
+
+func TestSnapshot_SymlinksFollow(t *testing.T) {
+	src := symlinkProject(t)
+
+	snapshotDir, logDir, _, stats, err := Snapshot(src, t.TempDir(), "follow_links", SnapshotOptions{Symlinks: SymlinksFollow})
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+
+	// Linked directory and file are copied as regular entries
+	helper := filepath.Join(snapshotDir, "tasks", "shared", "helpers.py")
+	if info, err := os.Lstat(helper); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("tasks/shared/helpers.py should be a copied file, Lstat = %v, %v", info, err)
+	}
+	if info, err := os.Lstat(filepath.Join(snapshotDir, "config.toml")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("config.toml should be a copied file, Lstat = %v, %v", info, err)
+	}
+
+	// The broken link and the loop back to the project root are skipped
+	if stats.SkippedLinks != 2 {
+		t.Errorf("SkippedLinks = %d, want 2", stats.SkippedLinks)
+	}
+	log, _ := os.ReadFile(filepath.Join(logDir, snapshotLogName))
+	for _, want := range []string{"(loop)", "(broken link)"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("snapshot log missing %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestSnapshot_SymlinksFollowMutualLoop(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "a"), 0o755)
+	os.MkdirAll(filepath.Join(src, "b"), 0o755)
+	os.WriteFile(filepath.Join(src, "a", "file.txt"), []byte("a"), 0o644)
+	if err := os.Symlink(filepath.Join(src, "b"), filepath.Join(src, "a", "to_b")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink(filepath.Join(src, "a"), filepath.Join(src, "b", "to_a"))
+
+	_, _, _, stats, err := Snapshot(src, t.TempDir(), "mutual_loop", SnapshotOptions{Symlinks: SymlinksFollow})
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if stats.SkippedLinks == 0 {
+		t.Error("mutual symlink loop should be detected and skipped")
+	}
+}
+
+func TestCopyDirContents_SymlinkPolicy(t *testing.T) {
+	base := t.TempDir()
+	shared := filepath.Join(base, "shared")
+	seed := filepath.Join(base, "seed")
+	os.MkdirAll(shared, 0o755)
+	os.MkdirAll(seed, 0o755)
+	os.WriteFile(filepath.Join(shared, "ref.csv"), []byte("id\n1\n"), 0o644)
+	os.WriteFile(filepath.Join(seed, "claims.csv"), []byte("id\n"), 0o644)
+	if err := os.Symlink(shared, filepath.Join(seed, "reference")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		dst := t.TempDir()
+		cs, err := copyDirContents(seed, dst, "data", SnapshotOptions{})
+		if err != nil {
+			t.Fatalf("copyDirContents() error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "reference")); !os.IsNotExist(err) {
+			t.Errorf("reference link should be skipped, Lstat err = %v", err)
+		}
+		if len(cs.skipped) != 1 || !strings.Contains(cs.skipped[0], "skipped symlink "+filepath.Join("data", "reference")+" -> ") {
+			t.Errorf("skipped = %q, want the data/reference link", cs.skipped)
+		}
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		dst := t.TempDir()
+		cs, err := copyDirContents(seed, dst, "data", SnapshotOptions{Symlinks: SymlinksFollow})
+		if err != nil {
+			t.Fatalf("copyDirContents() error: %v", err)
+		}
+		if data, err := os.ReadFile(filepath.Join(dst, "reference", "ref.csv")); err != nil || string(data) != "id\n1\n" {
+			t.Errorf("reference/ref.csv = %q (err %v), want the linked file", data, err)
+		}
+		if len(cs.skipped) != 0 {
+			t.Errorf("skipped = %q, want none", cs.skipped)
+		}
+	})
+}
+
+func TestExternalDataDir(t *testing.T) {
+	runDir := t.TempDir()
+	local := filepath.Join(runDir, "data")
+	os.MkdirAll(local, 0o755)
+	share := filepath.Join(t.TempDir(), "share")
+
+	dir, err := externalDataDir(share, "20240115_143022.123-3fa9c1_claims", local)
+	if err != nil {
+		t.Fatalf("externalDataDir() error: %v", err)
+	}
+	if want := filepath.Join(share, "20240115_143022.123-3fa9c1_claims"); dir != want {
+		t.Errorf("externalDataDir() = %q, want %q", dir, want)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("data dir %q was not created: %v", dir, err)
+	}
+	if _, err := os.Stat(local); !os.IsNotExist(err) {
+		t.Errorf("run-local data dir still exists (err %v)", err)
+	}
+}
+
+func TestExecute_InPlace(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	projectDir := t.TempDir()
+	os.WriteFile(filepath.Join(projectDir, "pit.toml"), []byte("[dag]\nname = \"in_place\"\nsnapshot = false\n\n[[tasks]]\nname = \"a\"\nscript = \"a.sh\"\n"), 0o644)
+	os.WriteFile(filepath.Join(projectDir, "a.sh"), []byte("pwd > \"$PIT_DATA_DIR/pwd.txt\"\ntouch ran_here\n"), 0o755)
+	cfg, err := config.Load(filepath.Join(projectDir, "pit.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	run, err := Execute(context.Background(), cfg, ExecuteOpts{RunsDir: t.TempDir(), Output: &out})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if run.Status != StatusSuccess {
+		t.Fatalf("run status = %s, want success\n%s", run.Status, out.String())
+	}
+
+	if !run.InPlace || run.SnapshotDir != projectDir {
+		t.Errorf("run.InPlace = %v, SnapshotDir = %q; want the project dir %q", run.InPlace, run.SnapshotDir, projectDir)
+	}
+	if _, err := os.Stat(filepath.Join(run.RunDir, "project")); !os.IsNotExist(err) {
+		t.Error("an in-place run should not create a project snapshot")
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "ran_here")); err != nil {
+		t.Errorf("the task did not run in the project directory: %v", err)
+	}
+	pwd, _ := os.ReadFile(filepath.Join(run.DataDir, "pwd.txt"))
+	want, _ := filepath.EvalSymlinks(projectDir)
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(pwd))); got != want {
+		t.Errorf("task working directory = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(run.LogDir, "a.log")); err != nil {
+		t.Errorf("task log missing from the run directory: %v", err)
+	}
+	if !strings.Contains(out.String(), "Snapshot: none, ran in "+projectDir) {
+		t.Errorf("summary does not say the run was in place:\n%s", out.String())
+	}
+}
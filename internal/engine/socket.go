@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// sdkSocketName is the SDK socket created in the run directory when no
+// socket directory is configured.
+const sdkSocketName = "sdk.sock"
+
+// maxSocketPath is the longest Unix socket path accepted on every supported
+// platform: sun_path holds 104 bytes on macOS and 108 on Linux, including
+// the terminating NUL.
+const maxSocketPath = 103
+
+// sdkSocketPath returns where a run's SDK server listens: sdk.sock in the
+// run directory, or <socketDir>/<run_id>.sock if socketDir is set. A path
+// too long for a Unix socket falls back to a name in the system temp dir
+// derived from the run ID, so it is still unique to the run. Windows
+// ignores the path and listens on TCP.
+func sdkSocketPath(runDir, socketDir, runID string) string {
+	p := filepath.Join(runDir, sdkSocketName)
+	if socketDir != "" {
+		p = filepath.Join(socketDir, runID+".sock")
+	}
+	if len(p) <= maxSocketPath {
+		return p
+	}
+	sum := sha256.Sum256([]byte(runID))
+	return filepath.Join(os.TempDir(), "pit-"+hex.EncodeToString(sum[:8])+".sock")
+}
+
+// sweepStaleSockets removes sockets in dir matching pattern that nothing
+// is listening on, left behind by runs whose process died. A socket that
+// refuses connections is stale; one that accepts them, or fails for any
+// other reason, is left alone.
+func sweepStaleSockets(dir, pattern string) int {
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, p := range paths {
+		st, err := os.Lstat(p)
+		if err != nil || st.Mode().Type() != os.ModeSocket {
+			continue
+		}
+		conn, err := net.DialTimeout("unix", p, time.Second)
+		if err == nil {
+			conn.Close()
+			continue
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) && os.Remove(p) == nil {
+			removed++
+		}
+	}
+	return removed
+}
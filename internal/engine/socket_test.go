@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSDKSocketPath(t *testing.T) {
+	runID := "20260302_060000.000-4c1d2e_daily"
+	if got, want := sdkSocketPath("/runs/"+runID, "", runID), "/runs/"+runID+"/sdk.sock"; got != want {
+		t.Errorf("default = %q, want %q", got, want)
+	}
+	if got, want := sdkSocketPath("/runs/"+runID, "/run/pit", runID), "/run/pit/"+runID+".sock"; got != want {
+		t.Errorf("with socket dir = %q, want %q", got, want)
+	}
+
+	deep := "/" + strings.Repeat("very-long-directory/", 6) + runID
+	a := sdkSocketPath(deep, "", runID)
+	b := sdkSocketPath(deep, "", runID+"x")
+	if filepath.Dir(a) != filepath.Clean(os.TempDir()) || !strings.HasPrefix(filepath.Base(a), "pit-") {
+		t.Errorf("long path fallback = %q, want pit-*.sock in the temp dir", a)
+	}
+	if len(a) > maxSocketPath || a == b {
+		t.Errorf("fallbacks %q and %q should be short and differ per run", a, b)
+	}
+}
+
+func TestSweepStaleSockets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SDK sockets are TCP on Windows")
+	}
+	// A short dir keeps socket paths under the sun_path limit.
+	dir, err := os.MkdirTemp("", "pitsock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	listen := func(name string) *net.UnixListener {
+		t.Helper()
+		ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: filepath.Join(dir, name), Net: "unix"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ln
+	}
+	live := listen("live.sock")
+	defer live.Close()
+	dead := listen("dead.sock")
+	dead.SetUnlinkOnClose(false)
+	dead.Close()
+	os.WriteFile(filepath.Join(dir, "plain.sock"), []byte("not a socket"), 0o644)
+
+	if n := sweepStaleSockets(dir, "*.sock"); n != 1 {
+		t.Errorf("sweepStaleSockets() = %d, want 1", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dead.sock")); !os.IsNotExist(err) {
+		t.Error("stale socket was not removed")
+	}
+	for _, name := range []string{"live.sock", "plain.sock"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should have been kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// Stall actions for stall_timeout.
+const (
+	StallWarn = "warn" // log a warning and notify (default)
+	StallKill = "kill" // also kill the attempt; it fails and may be retried
+)
+
+// maxStallCheckInterval caps how often a stall watcher polls, so a long
+// stall_timeout is still reported promptly after it elapses.
+const maxStallCheckInterval = 10 * time.Second
+
+// StallFunc is notified when task has produced no output for idle, longer
+// than its stall_timeout.
+type StallFunc func(run *Run, task string, idle time.Duration)
+
+// errStalled is the cancellation cause of an attempt killed for stalling.
+var errStalled = errors.New("stalled")
+
+// activityWriter passes writes through and records when the last one happened.
+type activityWriter struct {
+	w    io.Writer
+	last atomic.Int64 // unix nanoseconds
+}
+
+func newActivityWriter(w io.Writer) *activityWriter {
+	aw := &activityWriter{w: w}
+	aw.touch()
+	return aw
+}
+
+func (a *activityWriter) Write(p []byte) (int, error) {
+	a.touch()
+	return a.w.Write(p)
+}
+
+func (a *activityWriter) touch() { a.last.Store(time.Now().UnixNano()) }
+
+// idle returns how long ago the last write happened.
+func (a *activityWriter) idle(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, a.last.Load()))
+}
+
+// stallWatch describes what to do when a task attempt produces no output.
+type stallWatch struct {
+	timeout time.Duration
+	kill    bool
+	onStall func(idle time.Duration) // called once per stall, before any kill
+}
+
+// watch polls aw until ctx is done. Each time output has been idle for the
+// timeout it writes a warning to the task log (which also reaches verbose
+// output and live log streams) and calls onStall. The warning re-arms once
+// the task writes again. With kill set, cancel is called with errStalled.
+func (sw stallWatch) watch(ctx context.Context, aw *activityWriter, cancel context.CancelCauseFunc) {
+	interval := sw.timeout / 4
+	if interval > maxStallCheckInterval {
+		interval = maxStallCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			idle := aw.idle(now)
+			if idle < sw.timeout {
+				warned = false
+				continue
+			}
+			if warned {
+				continue
+			}
+			warned = true
+			msg := fmt.Sprintf("\n[pit] warning: no output for %s (stall_timeout %s)", idle.Round(time.Second), sw.timeout)
+			if sw.kill {
+				msg += ", killing task"
+			}
+			// Write to the underlying writer so the warning does not count as activity.
+			fmt.Fprintln(aw.w, msg)
+			if sw.onStall != nil {
+				sw.onStall(idle)
+			}
+			if sw.kill {
+				cancel(errStalled)
+				return
+			}
+		}
+	}
+}
+
+// stallError describes an attempt killed by its stall watcher.
+func stallError(timeout time.Duration) error {
+	return fmt.Errorf("%w: no output for %s", errStalled, timeout)
+}
+
+// runWatched runs one attempt of ti under a stall watcher.
+func runWatched(ctx context.Context, r runner.Runner, rc runner.RunContext, logWriter io.Writer, ti *TaskInstance, run *Run, opts ExecuteOpts) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	aw := newActivityWriter(logWriter)
+	sw := stallWatch{
+		timeout: ti.StallTimeout,
+		kill:    ti.StallKill,
+		onStall: func(idle time.Duration) {
+			if opts.OnStall != nil {
+				opts.OnStall(run, ti.Name, idle)
+			}
+		},
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sw.watch(ctx, aw, cancel)
+	}()
+
+	err := r.Run(ctx, rc, aw)
+	stalled := errors.Is(context.Cause(ctx), errStalled)
+	cancel(nil)
+	<-done
+	if err != nil && stalled {
+		return stallError(ti.StallTimeout)
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// blockingRunner writes its output lines and then waits for cancellation.
+type blockingRunner struct{ lines []string }
+
+func (r blockingRunner) Run(ctx context.Context, _ runner.RunContext, w io.Writer) error {
+	for _, l := range r.lines {
+		io.WriteString(w, l+"\n")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// lockedBuffer is a bytes.Buffer safe for the watcher and runner goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunWatched_Kill(t *testing.T) {
+	var stalls atomic.Int32
+	ti := &TaskInstance{Name: "hang", StallTimeout: 40 * time.Millisecond, StallKill: true}
+	run := &Run{ID: "r", DAGName: "test"}
+	opts := ExecuteOpts{OnStall: func(_ *Run, task string, _ time.Duration) {
+		if task == "hang" {
+			stalls.Add(1)
+		}
+	}}
+
+	var log lockedBuffer
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := runWatched(ctx, blockingRunner{lines: []string{"starting"}}, runner.RunContext{}, &log, ti, run, opts)
+
+	if !errors.Is(err, errStalled) {
+		t.Fatalf("runWatched() error = %v, want stalled", err)
+	}
+	if stalls.Load() != 1 {
+		t.Errorf("OnStall called %d times, want 1", stalls.Load())
+	}
+	if out := log.String(); !strings.Contains(out, "starting") || !strings.Contains(out, "no output for") {
+		t.Errorf("log = %q, want task output and stall warning", out)
+	}
+}
+
+func TestStallWatch_WarnRearms(t *testing.T) {
+	var log lockedBuffer
+	aw := newActivityWriter(&log)
+	var stalls atomic.Int32
+	sw := stallWatch{timeout: 40 * time.Millisecond, onStall: func(time.Duration) { stalls.Add(1) }}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sw.watch(ctx, aw, cancel)
+	}()
+
+	waitFor := func(n int32) {
+		deadline := time.Now().Add(5 * time.Second)
+		for stalls.Load() < n && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(1)
+	time.Sleep(60 * time.Millisecond) // still idle: no second warning
+	if got := stalls.Load(); got != 1 {
+		t.Fatalf("stalls = %d after one idle period, want 1", got)
+	}
+	aw.Write([]byte("progress\n"))
+	waitFor(2)
+	cancel(nil)
+	<-done
+
+	if got := stalls.Load(); got != 2 {
+		t.Errorf("stalls = %d after output resumed and stopped again, want 2", got)
+	}
+	if errors.Is(context.Cause(ctx), errStalled) {
+		t.Error("warn-only watcher should not cancel with errStalled")
+	}
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultAnomalyFactor is the multiple of a task's median duration above
+	// which a run is flagged as unusually slow.
+	DefaultAnomalyFactor = 3.0
+
+	// DefaultAnomalyWindow is how many recent successful runs of each task
+	// feed the rolling duration statistics.
+	DefaultAnomalyWindow = 20
+
+	// AnomalyMetric is the task metric recorded for a flagged task: its
+	// duration as a multiple of its median.
+	AnomalyMetric = "duration_anomaly_ratio"
+
+	// minDurationSamples is the number of prior runs a task needs before
+	// anomalies are reported, so a short history does not cause noise.
+	minDurationSamples = 5
+
+	// minAnomalyMedian ignores tasks whose median is below the metadata
+	// store's one-second timestamp resolution.
+	minAnomalyMedian = time.Second
+)
+
+// DurationStats summarises recent wall-clock durations of a task.
+type DurationStats struct {
+	Samples int
+	Mean    time.Duration
+	P50     time.Duration
+	P95     time.Duration
+}
+
+// ComputeDurationStats returns the mean and nearest-rank percentiles of durations.
+func ComputeDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return DurationStats{
+		Samples: len(sorted),
+		Mean:    total / time.Duration(len(sorted)),
+		P50:     percentile(sorted, 50),
+		P95:     percentile(sorted, 95),
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of an ascending slice.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Anomaly flags a task whose duration in this run was far above its median.
+type Anomaly struct {
+	Task     string
+	Duration time.Duration
+	Stats    DurationStats
+	Ratio    float64 // Duration / Stats.P50
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s took %s, %.1fx its median of %s (p95 %s over %d runs)",
+		a.Task, a.Duration.Round(time.Second), a.Ratio,
+		a.Stats.P50.Round(time.Second), a.Stats.P95.Round(time.Second), a.Stats.Samples)
+}
+
+// loadDurationBaseline builds per-task statistics from the recorder's history
+// of the last window successful runs of each task (0 = DefaultAnomalyWindow),
+// keeping only tasks with enough samples to judge.
+func loadDurationBaseline(rec MetadataRecorder, dagName string, window int) (map[string]DurationStats, error) {
+	if window <= 0 {
+		window = DefaultAnomalyWindow
+	}
+	history, err := rec.TaskDurationHistory(dagName, window)
+	if err != nil {
+		return nil, err
+	}
+	baseline := make(map[string]DurationStats, len(history))
+	for task, durations := range history {
+		if len(durations) >= minDurationSamples {
+			baseline[task] = ComputeDurationStats(durations)
+		}
+	}
+	return baseline, nil
+}
+
+// recordAnomalies stores each anomaly as a task metric, so slow runs can be
+// found in the run history and metrics API, not only in logs.
+func recordAnomalies(rec MetadataRecorder, runID string, anomalies []Anomaly) {
+	for _, a := range anomalies {
+		if err := rec.RecordTaskMetrics(runID, a.Task, map[string]float64{AnomalyMetric: a.Ratio}); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+}
+
+// detectAnomalies compares each finished task against its baseline and returns
+// those that took more than factor times their median duration.
+func detectAnomalies(run *Run, baseline map[string]DurationStats, factor float64) []Anomaly {
+	if factor <= 0 {
+		factor = DefaultAnomalyFactor
+	}
+	var anomalies []Anomaly
+	for _, ti := range run.Tasks {
+		if ti.StartedAt.IsZero() || ti.EndedAt.IsZero() {
+			continue
+		}
+		stats, ok := baseline[ti.Name]
+		if !ok || stats.P50 < minAnomalyMedian {
+			continue
+		}
+		dur := ti.EndedAt.Sub(ti.StartedAt)
+		ratio := float64(dur) / float64(stats.P50)
+		if ratio > factor {
+			anomalies = append(anomalies, Anomaly{Task: ti.Name, Duration: dur, Stats: stats, Ratio: ratio})
+		}
+	}
+	return anomalies
+}
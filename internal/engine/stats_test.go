@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeDurationStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 10; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+
+	got := ComputeDurationStats(durations)
+	want := DurationStats{Samples: 10, Mean: 5500 * time.Millisecond, P50: 5 * time.Second, P95: 10 * time.Second}
+	if got != want {
+		t.Errorf("ComputeDurationStats() = %+v, want %+v", got, want)
+	}
+	if durations[0] != 10*time.Second {
+		t.Error("ComputeDurationStats() must not reorder its input")
+	}
+	if got := ComputeDurationStats(nil); got != (DurationStats{}) {
+		t.Errorf("ComputeDurationStats(nil) = %+v, want zero", got)
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	start := time.Now()
+	task := func(name string, d time.Duration) *TaskInstance {
+		return &TaskInstance{Name: name, StartedAt: start, EndedAt: start.Add(d)}
+	}
+	run := &Run{Tasks: []*TaskInstance{
+		task("extract", 40*time.Second),  // 4x median
+		task("load", 25*time.Second),     // 2.5x median
+		task("fast", 5*time.Second),      // median below resolution
+		task("new_task", 10*time.Minute), // no history
+		{Name: "skipped"},
+	}}
+	baseline := map[string]DurationStats{
+		"extract": {Samples: 5, P50: 10 * time.Second, P95: 12 * time.Second},
+		"load":    {Samples: 5, P50: 10 * time.Second, P95: 12 * time.Second},
+		"fast":    {Samples: 5, P50: 100 * time.Millisecond},
+		"skipped": {Samples: 5, P50: 10 * time.Second},
+	}
+
+	tests := []struct {
+		name   string
+		factor float64
+		want   []string
+	}{
+		{name: "default factor", factor: 0, want: []string{"extract"}},
+		{name: "custom factor", factor: 2, want: []string{"extract", "load"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range detectAnomalies(run, baseline, tt.factor) {
+				got = append(got, a.Task)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("detectAnomalies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSummary_Anomalies(t *testing.T) {
+	now := time.Now()
+	run := &Run{
+		ID:        "20240115_143022.123_test",
+		DAGName:   "test",
+		Status:    StatusSuccess,
+		StartedAt: now,
+		EndedAt:   now.Add(time.Minute),
+		Anomalies: []Anomaly{{
+			Task:     "extract",
+			Duration: 40 * time.Second,
+			Stats:    DurationStats{Samples: 20, P50: 10 * time.Second, P95: 12 * time.Second},
+			Ratio:    4,
+		}},
+	}
+
+	var buf bytes.Buffer
+	printSummary(&buf, run)
+	want := "warning: unusually slow: extract took 40s, 4.0x its median of 10s (p95 12s over 20 runs)"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("printSummary() missing %q, got:\n%s", want, buf.String())
+	}
+}
+
+// historyRecorder serves a fixed duration history and keeps recorded metrics.
+type historyRecorder struct {
+	MetadataRecorder
+	history  map[string][]time.Duration
+	gotLimit int
+	metrics  map[string]map[string]float64
+}
+
+func (r *historyRecorder) TaskDurationHistory(dagName string, limit int) (map[string][]time.Duration, error) {
+	r.gotLimit = limit
+	return r.history, nil
+}
+
+func (r *historyRecorder) RecordTaskMetrics(runID, taskName string, metrics map[string]float64) error {
+	if r.metrics == nil {
+		r.metrics = make(map[string]map[string]float64)
+	}
+	r.metrics[taskName] = metrics
+	return nil
+}
+
+func TestLoadDurationBaseline_Window(t *testing.T) {
+	rec := &historyRecorder{history: map[string][]time.Duration{
+		"extract": {time.Minute, time.Minute, time.Minute, time.Minute, time.Minute},
+		"new":     {time.Minute},
+	}}
+	baseline, err := loadDurationBaseline(rec, "dag", 0)
+	if err != nil {
+		t.Fatalf("loadDurationBaseline() unexpected error: %v", err)
+	}
+	if rec.gotLimit != DefaultAnomalyWindow {
+		t.Errorf("history limit = %d, want default %d", rec.gotLimit, DefaultAnomalyWindow)
+	}
+	if _, ok := baseline["new"]; ok {
+		t.Error("task with too few samples should have no baseline")
+	}
+	if _, err := loadDurationBaseline(rec, "dag", 50); err != nil || rec.gotLimit != 50 {
+		t.Errorf("history limit = %d, want configured window 50", rec.gotLimit)
+	}
+}
+
+func TestRecordAnomalies(t *testing.T) {
+	rec := &historyRecorder{}
+	recordAnomalies(rec, "run1", []Anomaly{{Task: "extract", Ratio: 4.5}})
+	if got := rec.metrics["extract"][AnomalyMetric]; got != 4.5 {
+		t.Errorf("metric %s = %v, want 4.5", AnomalyMetric, got)
+	}
+}
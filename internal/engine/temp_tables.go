@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/loader"
+	"github.com/druarnfield/pit/internal/runner"
+	"github.com/druarnfield/pit/internal/sdk"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// defaultTempTablePrefix names temp tables created without a prefix.
+const defaultTempTablePrefix = "scratch"
+
+// tempTablePrefix is what create_temp_table accepts as a prefix: short
+// enough that the generated name fits every supported database.
+var tempTablePrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,29}$`)
+
+// dropTempTablesTimeout bounds dropping a run's leftover temp tables.
+const dropTempTablesTimeout = time.Minute
+
+// tempTable is where a table created with create_temp_table lives.
+type tempTable struct {
+	connStr string
+	schema  string
+}
+
+// tempTables tracks the tables a run's tasks created with
+// create_temp_table, so the ones not dropped with drop_temp_table are
+// dropped when the run ends.
+type tempTables struct {
+	identCase loader.IdentifierCase
+
+	mu     sync.Mutex
+	byName map[string]tempTable
+}
+
+func newTempTables(identCase loader.IdentifierCase) *tempTables {
+	return &tempTables{identCase: identCase, byName: make(map[string]tempTable)}
+}
+
+func (t *tempTables) add(name string, tt tempTable) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byName[name] = tt
+}
+
+// take removes and returns the table called name.
+func (t *tempTables) take(name string) (tempTable, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tt, ok := t.byName[name]
+	delete(t.byName, name)
+	return tt, ok
+}
+
+// dropAll drops the temp tables still left, warning about any that fail.
+func (t *tempTables) dropAll() {
+	t.mu.Lock()
+	names := make([]string, 0, len(t.byName))
+	for name := range t.byName {
+		names = append(names, name)
+	}
+	t.mu.Unlock()
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dropTempTablesTimeout)
+	defer cancel()
+	for _, name := range names {
+		tt, _ := t.take(name)
+		if err := loader.DropScratchTable(ctx, tt.connStr, tt.schema, name, t.identCase); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: dropping temp table %s.%s: %v\n", tt.schema, name, err)
+		}
+	}
+}
+
+// tempTableName returns a new table name for prefix, unique to the call.
+// Names are lower case so they survive any identifier case rule.
+func tempTableName(prefix string) string {
+	b := make([]byte, 4)
+	rand.Read(b) // never returns an error
+	return "pit_" + strings.ToLower(prefix) + "_" + hex.EncodeToString(b)
+}
+
+// makeCreateTempTableHandler returns the SDK handler that creates a
+// uniquely named table for the run's intermediate data, dropped when the
+// run ends. Without columns the name is only reserved, for load_data with
+// mode append_or_create to create. Returns a JSON object with the table,
+// its schema, and the quoted qualified name.
+//
+// Params: connection, columns (optional), prefix (optional), schema (optional)
+func makeCreateTempTableHandler(store *secrets.Store, dagName string, defaults loadDefaults, tables *tempTables) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		connKey := params["connection"]
+		if connKey == "" {
+			return "", fmt.Errorf("missing required parameter: connection")
+		}
+		prefix := params["prefix"]
+		if prefix == "" {
+			prefix = defaultTempTablePrefix
+		}
+		if !tempTablePrefix.MatchString(prefix) {
+			return "", fmt.Errorf("invalid prefix %q (letters, digits, and underscores, starting with a letter, at most 30)", prefix)
+		}
+		if store == nil {
+			return "", fmt.Errorf("secrets store not configured (use --secrets flag)")
+		}
+		connStr, err := runner.ResolveConnection(store, dagName, connKey)
+		if err != nil {
+			return "", fmt.Errorf("resolving connection %q: %w", connKey, err)
+		}
+		schema := params["schema"]
+		if schema == "" {
+			schema = defaults.schema
+		}
+
+		st, err := loader.CreateScratchTable(ctx, connStr, schema, tempTableName(prefix), params["columns"], defaults.identCase)
+		if err != nil {
+			return "", err
+		}
+		tables.add(st.Table, tempTable{connStr: connStr, schema: st.Schema})
+
+		out, err := json.Marshal(map[string]string{"table": st.Table, "schema": st.Schema, "qualified": st.Qualified})
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
+
+// makeDropTempTableHandler returns the SDK handler that drops a table
+// created by this run's create_temp_table before the run ends.
+//
+// Params: table
+func makeDropTempTableHandler(tables *tempTables) sdk.HandlerFunc {
+	return func(ctx context.Context, params map[string]string) (string, error) {
+		name := params["table"]
+		if name == "" {
+			return "", fmt.Errorf("missing required parameter: table")
+		}
+		tt, ok := tables.take(name)
+		if !ok {
+			return "", fmt.Errorf("table %q was not created by this run's create_temp_table", name)
+		}
+		if err := loader.DropScratchTable(ctx, tt.connStr, tt.schema, name, tables.identCase); err != nil {
+			// Leave it for the drop at run end
+			tables.add(name, tt)
+			return "", err
+		}
+		return fmt.Sprintf("dropped %s", name), nil
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTempTableName(t *testing.T) {
+	a, b := tempTableName("Claims_Stage"), tempTableName("Claims_Stage")
+	if !regexp.MustCompile(`^pit_claims_stage_[0-9a-f]{8}$`).MatchString(a) {
+		t.Errorf("tempTableName() = %q, want pit_claims_stage_<8 hex>", a)
+	}
+	if a == b {
+		t.Errorf("tempTableName() returned %q twice", a)
+	}
+}
+
+func TestCreateTempTableHandler_Params(t *testing.T) {
+	handler := makeCreateTempTableHandler(nil, "claims", loadDefaults{}, newTempTables(""))
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr string
+	}{
+		{"no connection", map[string]string{}, "missing required parameter: connection"},
+		{"bad prefix", map[string]string{"connection": "wh", "prefix": "1st; drop"}, "invalid prefix"},
+		{"long prefix", map[string]string{"connection": "wh", "prefix": strings.Repeat("a", 31)}, "invalid prefix"},
+		{"no secrets", map[string]string{"connection": "wh"}, "secrets store not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(t.Context(), tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("handler() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDropTempTableHandler(t *testing.T) {
+	tables := newTempTables("")
+	handler := makeDropTempTableHandler(tables)
+
+	if _, err := handler(t.Context(), map[string]string{"table": "customers"}); err == nil || !strings.Contains(err.Error(), "not created by this run") {
+		t.Errorf("dropping a table the run did not create: error = %v", err)
+	}
+
+	// A failed drop keeps the table for the drop at run end
+	tables.add("pit_scratch_0badc0de", tempTable{connStr: "nosuchdb://host", schema: "dbo"})
+	if _, err := handler(t.Context(), map[string]string{"table": "pit_scratch_0badc0de"}); err == nil {
+		t.Fatal("drop with an unusable connection succeeded")
+	}
+	if _, ok := tables.take("pit_scratch_0badc0de"); !ok {
+		t.Error("table was forgotten after a failed drop")
+	}
+}
+
+func TestTempTables_DropAllEmpties(t *testing.T) {
+	tables := newTempTables("")
+	tables.add("pit_scratch_00000001", tempTable{connStr: "nosuchdb://host", schema: "dbo"})
+	tables.dropAll() // warns about the failed drop
+
+	if len(tables.byName) != 0 {
+		t.Errorf("tables left after dropAll: %v", tables.byName)
+	}
+}
@@ -0,0 +1,29 @@
+package engine
+
+// Trigger rules for trigger_rule: which outcomes of a task's dependencies
+// let it run. pit does not propagate skips, so a skipped dependency counts
+// as done under every rule.
+const (
+	TriggerAllSuccess = "all_success" // no dependency failed (default)
+	TriggerAllDone    = "all_done"    // run whatever the dependencies' outcomes, e.g. cleanup
+	TriggerOneFailed  = "one_failed"  // run only if a dependency failed, e.g. alerting; otherwise skipped
+	TriggerNoneFailed = "none_failed" // same as all_success; accepted for Airflow users
+)
+
+// applyTriggerRule decides from the outcomes of ti's dependencies in
+// statusMap whether ti runs. It reports upstreamFailed when a failed
+// dependency blocks the task, or a reason to skip it; neither means run.
+func applyTriggerRule(ti *TaskInstance, statusMap map[string]TaskStatus) (upstreamFailed bool, skipReason string) {
+	failed := hasUpstreamFailure(ti, statusMap)
+	switch ti.TriggerRule {
+	case TriggerAllDone:
+		return false, ""
+	case TriggerOneFailed:
+		if !failed {
+			return false, "trigger_rule one_failed: no upstream task failed"
+		}
+		return false, ""
+	default:
+		return failed, ""
+	}
+}
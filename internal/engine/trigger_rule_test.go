@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestApplyTriggerRule(t *testing.T) {
+	statusMap := map[string]TaskStatus{
+		"ok":      StatusSuccess,
+		"failed":  StatusFailed,
+		"blocked": StatusUpstreamFailed,
+		"skipped": StatusSkipped,
+	}
+
+	tests := []struct {
+		rule        string
+		dependsOn   []string
+		wantBlocked bool
+		wantSkip    bool
+	}{
+		{"", []string{"ok", "skipped"}, false, false},
+		{"", []string{"ok", "failed"}, true, false},
+		{TriggerAllSuccess, []string{"blocked"}, true, false},
+		{TriggerNoneFailed, []string{"ok", "skipped"}, false, false},
+		{TriggerNoneFailed, []string{"failed"}, true, false},
+		{TriggerAllDone, []string{"ok", "failed", "blocked"}, false, false},
+		{TriggerOneFailed, []string{"ok", "failed"}, false, false},
+		{TriggerOneFailed, []string{"blocked"}, false, false},
+		{TriggerOneFailed, []string{"ok", "skipped"}, false, true},
+	}
+	for _, tt := range tests {
+		ti := &TaskInstance{Name: "target", DependsOn: tt.dependsOn, TriggerRule: tt.rule}
+		blocked, reason := applyTriggerRule(ti, statusMap)
+		if blocked != tt.wantBlocked || (reason != "") != tt.wantSkip {
+			t.Errorf("applyTriggerRule(%q, %v) = %v, %q; want blocked %v, skip %v",
+				tt.rule, tt.dependsOn, blocked, reason, tt.wantBlocked, tt.wantSkip)
+		}
+	}
+}
+
+func TestExecuteDAG_TriggerRules(t *testing.T) {
+	load := &TaskInstance{Name: "load", Status: StatusFailed}
+	report := &TaskInstance{Name: "report", Status: StatusPending, DependsOn: []string{"load"}}
+	alert := &TaskInstance{Name: "alert", Status: StatusPending, DependsOn: []string{"report"}, TriggerRule: TriggerOneFailed}
+	cleanup := &TaskInstance{Name: "cleanup", Status: StatusPending, DependsOn: []string{"report"}, TriggerRule: TriggerAllDone}
+	run := &Run{ID: "run1", DAGName: "claims", Tasks: []*TaskInstance{load, report, alert, cleanup}}
+
+	// alert and cleanup have no script, so running them fails; what matters
+	// is that they were started rather than marked upstream_failed
+	executeDAG(t.Context(), [][]*TaskInstance{{report}, {alert, cleanup}}, run, &config.ProjectConfig{}, ExecuteOpts{})
+
+	if report.Status != StatusUpstreamFailed {
+		t.Errorf("report status = %s, want %s", report.Status, StatusUpstreamFailed)
+	}
+	for _, ti := range []*TaskInstance{alert, cleanup} {
+		if ti.Status == StatusUpstreamFailed || ti.Status == StatusSkipped || ti.StartedAt.IsZero() {
+			t.Errorf("%s status = %s, want it started despite the failed upstream", ti.Name, ti.Status)
+		}
+	}
+}
+
+func TestExecuteDAG_OneFailedSkipsWithoutFailure(t *testing.T) {
+	load := &TaskInstance{Name: "load", Status: StatusSuccess}
+	alert := &TaskInstance{Name: "alert", Status: StatusPending, DependsOn: []string{"load"}, TriggerRule: TriggerOneFailed}
+	run := &Run{ID: "run1", DAGName: "claims", Tasks: []*TaskInstance{load, alert}}
+
+	executeDAG(t.Context(), [][]*TaskInstance{{alert}}, run, &config.ProjectConfig{}, ExecuteOpts{})
+
+	if alert.Status != StatusSkipped {
+		t.Errorf("alert status = %s, want %s", alert.Status, StatusSkipped)
+	}
+	if alert.SkipReason == "" {
+		t.Error("alert has no skip reason")
+	}
+}
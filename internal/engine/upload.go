@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/druarnfield/pit/internal/artifacts"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// runManifestName is the file written into the run directory before upload
+// so the stored artifacts carry the run's metadata with them.
+const runManifestName = "run.json"
+
+// artifactUploadTimeout bounds the whole upload. The run context is not
+// used because it may already be cancelled by a DAG timeout.
+const artifactUploadTimeout = 30 * time.Minute
+
+type manifestTask struct {
+	Name           string     `json:"name"`
+	Status         TaskStatus `json:"status"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+	Attempts       int        `json:"attempts,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	FailedUpstream []string   `json:"failed_upstream,omitempty"`
+	Failure        *Failure   `json:"failure,omitempty"`
+}
+
+type runManifest struct {
+	ID        string         `json:"id"`
+	DAGName   string         `json:"dag_name"`
+	Status    TaskStatus     `json:"status"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+	Versions  *ToolVersions  `json:"versions,omitempty"`
+	Revision  *GitRevision   `json:"revision,omitempty"`
+	Tasks     []manifestTask `json:"tasks"`
+}
+
+// writeRunManifest writes run.json describing run into runDir.
+func writeRunManifest(runDir string, run *Run) error {
+	m := runManifest{
+		ID:        run.ID,
+		DAGName:   run.DAGName,
+		Status:    run.Status,
+		StartedAt: run.StartedAt,
+		EndedAt:   run.EndedAt,
+	}
+	if !run.Versions.IsZero() {
+		m.Versions = &run.Versions
+	}
+	if !run.Revision.IsZero() {
+		m.Revision = &run.Revision
+	}
+	for _, ti := range expandedTasks(run.Tasks) {
+		mt := manifestTask{
+			Name:           ti.Name,
+			Status:         ti.Status,
+			Attempts:       ti.Attempt,
+			FailedUpstream: ti.FailedUpstream,
+			Failure:        ti.Failure,
+		}
+		if !ti.StartedAt.IsZero() {
+			mt.StartedAt = &ti.StartedAt
+		}
+		if !ti.EndedAt.IsZero() {
+			mt.EndedAt = &ti.EndedAt
+		}
+		if ti.Error != nil {
+			mt.Error = ti.Error.Error()
+		}
+		m.Tasks = append(m.Tasks, mt)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(runDir, runManifestName), append(data, '\n'), 0o644)
+}
+
+// uploadArtifacts writes the run manifest, uploads whatever keep_artifacts
+// left in the run directory to opts.ArtifactStore, and records where it went.
+// Failures are reported as warnings and leave the local copy in place.
+func uploadArtifacts(run *Run, secretStore *secrets.Store, opts ExecuteOpts) {
+	cfg := opts.ArtifactStore
+	runDir := run.dir()
+
+	if err := writeRunManifest(runDir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: writing run manifest failed: %v\n", err)
+	}
+
+	var creds artifacts.CredentialFunc
+	if cfg.Secret != "" && secretStore != nil {
+		creds = func(field string) (string, error) {
+			return secretStore.ResolveField(run.DAGName, cfg.Secret, field)
+		}
+	}
+	backend, err := artifacts.New(cfg, creds)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: artifact upload skipped: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), artifactUploadTimeout)
+	defer cancel()
+	u := &artifacts.Uploader{Store: backend, Prefix: cfg.Prefix, KeepLocal: cfg.KeepLocal}
+	uri, files, err := u.UploadRun(ctx, runDir, run.DAGName, run.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: artifact upload failed, local copy kept: %v\n", err)
+		return
+	}
+	run.ArtifactURI = uri
+	fmt.Fprintf(opts.Output, "Artifacts: uploaded %d files to %s\n", files, uri)
+
+	if opts.MetaStore != nil {
+		if err := opts.MetaStore.RecordArtifactURI(run.ID, uri); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: metadata recording failed: %v\n", err)
+		}
+	}
+}
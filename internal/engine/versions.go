@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/buildinfo"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// toolVersionTimeout bounds each version probe so a slow uv does not hold
+// up the run.
+const toolVersionTimeout = 30 * time.Second
+
+// ToolVersions are the pit, interpreter, and tool versions a run resolved.
+// Empty fields mean the DAG does not use the tool or the version was unknown.
+type ToolVersions struct {
+	Pit    string `json:"pit,omitempty"`
+	Python string `json:"python,omitempty"`
+	UV     string `json:"uv,omitempty"`
+	DBT    string `json:"dbt,omitempty"`
+}
+
+// IsZero reports whether no versions were resolved.
+func (v ToolVersions) IsZero() bool { return v == ToolVersions{} }
+
+func (v ToolVersions) String() string {
+	var parts []string
+	if v.Pit != "" {
+		parts = append(parts, "pit "+v.Pit)
+	}
+	if v.Python != "" {
+		parts = append(parts, "Python "+v.Python)
+	}
+	if v.UV != "" {
+		parts = append(parts, "uv "+v.UV)
+	}
+	if v.DBT != "" {
+		parts = append(parts, "dbt "+v.DBT)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// usesUV reports whether any task runs through uv (Python or dbt tasks).
+func usesUV(cfg *config.ProjectConfig) (python, dbt bool) {
+	for _, t := range cfg.Tasks {
+		switch {
+		case t.Runner == "dbt":
+			dbt = true
+		case t.Runner == "python", t.Runner == "" && t.Type == "" && filepath.Ext(t.Script) == ".py":
+			python = true
+		}
+	}
+	return python, dbt
+}
+
+// pythonVersion returns the [dag.python].version pin, or "" if unset.
+func pythonVersion(cfg *config.ProjectConfig) string {
+	if cfg.DAG.Python == nil {
+		return ""
+	}
+	return cfg.DAG.Python.Version
+}
+
+// resolveToolVersions asks uv which versions the DAG's tasks will run with.
+// The dbt version is the pinned dbt-core version, which uvx installs as-is.
+// Python is the interpreter uv selects for the project (or for dbt's
+// --python pin when the DAG has no Python tasks). Pit's own version is
+// always set. Probe failures are returned alongside whatever was resolved.
+func resolveToolVersions(ctx context.Context, cfg *config.ProjectConfig, projectDir string) (ToolVersions, error) {
+	v := ToolVersions{Pit: buildinfo.Version()}
+	usesPython, usesDBT := usesUV(cfg)
+	if !usesPython && !usesDBT {
+		return v, nil
+	}
+	if usesDBT && cfg.DAG.DBT != nil {
+		v.DBT = cfg.DAG.DBT.Version
+	}
+
+	out, err := probe(ctx, projectDir, "uv", "--version")
+	if err != nil {
+		return v, fmt.Errorf("uv --version: %w", err)
+	}
+	v.UV = versionField(out)
+
+	request := pythonVersion(cfg)
+	if !usesPython && request == "" {
+		request = runner.DefaultDBTPython
+	}
+	args := []string{"python", "find"}
+	if usesPython {
+		args = append(args, "--project", projectDir)
+	}
+	if request != "" {
+		args = append(args, request)
+	}
+	interpreter, err := probe(ctx, projectDir, "uv", args...)
+	if err != nil {
+		return v, fmt.Errorf("uv %s: %w", strings.Join(args, " "), err)
+	}
+	out, err = probe(ctx, projectDir, strings.TrimSpace(interpreter), "--version")
+	if err != nil {
+		return v, fmt.Errorf("python --version: %w", err)
+	}
+	v.Python = versionField(out)
+	return v, nil
+}
+
+// probe runs a command in dir and returns its combined output.
+func probe(ctx context.Context, dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolVersionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// versionField extracts the version from output such as "uv 0.5.11 (c4d0caaee
+// 2024-12-19)" or "Python 3.12.7".
+func versionField(out string) string {
+	fields := strings.Fields(out)
+	if len(fields) >= 2 {
+		return fields[1]
+	}
+	return strings.TrimSpace(out)
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/buildinfo"
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func TestVersionField(t *testing.T) {
+	tests := []struct{ out, want string }{
+		{"uv 0.5.11 (c4d0caaee 2024-12-19)\n", "0.5.11"},
+		{"Python 3.12.7\n", "3.12.7"},
+		{"3.12.7\n", "3.12.7"},
+	}
+	for _, tt := range tests {
+		if got := versionField(tt.out); got != tt.want {
+			t.Errorf("versionField(%q) = %q, want %q", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestUsesUV(t *testing.T) {
+	tests := []struct {
+		name            string
+		tasks           []config.TaskConfig
+		wantPy, wantDBT bool
+	}{
+		{"python script", []config.TaskConfig{{Script: "tasks/a.py"}}, true, false},
+		{"python runner", []config.TaskConfig{{Script: "tasks/a", Runner: "python"}}, true, false},
+		{"dbt", []config.TaskConfig{{Script: "run", Runner: "dbt"}}, false, true},
+		{"shell and sql", []config.TaskConfig{{Script: "a.sh"}, {Script: "b.sql"}, {Type: "sensor"}}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			py, dbt := usesUV(&config.ProjectConfig{Tasks: tt.tasks})
+			if py != tt.wantPy || dbt != tt.wantDBT {
+				t.Errorf("usesUV() = %v, %v; want %v, %v", py, dbt, tt.wantPy, tt.wantDBT)
+			}
+		})
+	}
+}
+
+func TestResolveToolVersions_NoUVTasks(t *testing.T) {
+	cfg := &config.ProjectConfig{Tasks: []config.TaskConfig{{Name: "a", Script: "a.sh"}}}
+	v, err := resolveToolVersions(context.Background(), cfg, t.TempDir())
+	if want := (ToolVersions{Pit: buildinfo.Version()}); err != nil || v != want {
+		t.Errorf("resolveToolVersions() = %+v, %v; want only the pit version, nil", v, err)
+	}
+}
+
+func TestToolVersions_String(t *testing.T) {
+	v := ToolVersions{Pit: "v1.4.0", Python: "3.12.7", UV: "0.5.11", DBT: "1.9.1"}
+	if got, want := v.String(), "pit v1.4.0, Python 3.12.7, uv 0.5.11, dbt 1.9.1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/druarnfield/pit/internal/remotepath"
 	"github.com/jlaffaye/ftp"
 )
 
@@ -17,17 +18,85 @@ type FileInfo struct {
 	Size int64
 }
 
-// Client wraps an FTP connection with higher-level operations.
-type Client struct {
-	conn *ftp.ServerConn
+// Client is a connection to a file server, over FTP or SFTP. Remote
+// paths are always "/"-separated; build them with remotepath.Join, never
+// filepath.Join, which uses "\" on Windows.
+type Client interface {
+	// List returns the regular files in dir that match the glob pattern.
+	List(dir, pattern string) ([]FileInfo, error)
+	// Download retrieves a remote file and saves it to localPath.
+	Download(remotePath, localPath string) error
+	// Upload stores a local file on the server.
+	Upload(localPath, remotePath string) error
+	// Move renames a file on the server.
+	Move(oldPath, newPath string) error
+	// MkdirAll creates the directory and all parents on the server.
+	MkdirAll(dir string) error
+	// SetRateLimit limits Download and Upload to bytesPerSec on average.
+	// Zero or less removes the limit.
+	SetRateLimit(bytesPerSec int64)
+	// Close terminates the connection.
+	Close() error
 }
 
-// Connect establishes an FTP connection and logs in.
-func Connect(host string, port int, user, password string, useTLS bool) (*Client, error) {
-	addr := fmt.Sprintf("%s:%d", host, port)
+// Protocols a Client can speak.
+const (
+	ProtocolFTP  = "ftp"
+	ProtocolSFTP = "sftp"
+)
+
+// Options says how to reach and log in to a server.
+type Options struct {
+	Protocol string // ProtocolFTP (default) or ProtocolSFTP
+	Host     string
+	Port     int // 0 = 21 for FTP, 22 for SFTP
+	User     string
+	Password string
+
+	TLS bool // FTP: explicit TLS
+
+	// SFTP: a PEM private key, tried before the password, and its
+	// passphrase if it is encrypted.
+	PrivateKey string
+	Passphrase string
+	// SFTP: the server's public key, in authorized_keys form
+	// ("ssh-ed25519 AAAA...") or as a SHA256 fingerprint ("SHA256:...").
+	// Without one the server must be in KnownHosts.
+	HostKey string
+	// SFTP: the known_hosts file checked when HostKey is empty
+	// (default ~/.ssh/known_hosts).
+	KnownHosts string
+}
+
+// Connect connects and logs in to the server described by o.
+func Connect(o Options) (Client, error) {
+	switch o.Protocol {
+	case "", ProtocolFTP:
+		if o.Port == 0 {
+			o.Port = 21
+		}
+		return connectFTP(o)
+	case ProtocolSFTP:
+		if o.Port == 0 {
+			o.Port = 22
+		}
+		return connectSFTP(o)
+	}
+	return nil, fmt.Errorf("unknown protocol %q (must be ftp or sftp)", o.Protocol)
+}
+
+// ftpClient is a Client for FTP and FTPS servers.
+type ftpClient struct {
+	conn      *ftp.ServerConn
+	rateLimit int64 // bytes per second for Download and Upload (0 = unlimited)
+}
+
+// connectFTP establishes an FTP connection and logs in.
+func connectFTP(o Options) (Client, error) {
+	addr := fmt.Sprintf("%s:%d", o.Host, o.Port)
 
 	var opts []ftp.DialOption
-	if useTLS {
+	if o.TLS {
 		opts = append(opts, ftp.DialWithExplicitTLS(nil))
 	}
 
@@ -36,21 +105,26 @@ func Connect(host string, port int, user, password string, useTLS bool) (*Client
 		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
 	}
 
-	if err := conn.Login(user, password); err != nil {
+	if err := conn.Login(o.User, o.Password); err != nil {
 		conn.Quit()
-		return nil, fmt.Errorf("login as %q: %w", user, err)
+		return nil, fmt.Errorf("login as %q: %w", o.User, err)
 	}
 
-	return &Client{conn: conn}, nil
+	return &ftpClient{conn: conn}, nil
+}
+
+// SetRateLimit implements Client.
+func (c *ftpClient) SetRateLimit(bytesPerSec int64) {
+	c.rateLimit = bytesPerSec
 }
 
 // Close gracefully terminates the FTP connection.
-func (c *Client) Close() error {
+func (c *ftpClient) Close() error {
 	return c.conn.Quit()
 }
 
 // List returns files in dir that match the glob pattern.
-func (c *Client) List(dir, pattern string) ([]FileInfo, error) {
+func (c *ftpClient) List(dir, pattern string) ([]FileInfo, error) {
 	entries, err := c.conn.List(dir)
 	if err != nil {
 		return nil, fmt.Errorf("listing %q: %w", dir, err)
@@ -72,7 +146,7 @@ func (c *Client) List(dir, pattern string) ([]FileInfo, error) {
 }
 
 // Download retrieves a remote file and saves it to localPath.
-func (c *Client) Download(remotePath, localPath string) error {
+func (c *ftpClient) Download(remotePath, localPath string) error {
 	resp, err := c.conn.Retr(remotePath)
 	if err != nil {
 		return fmt.Errorf("retrieving %q: %w", remotePath, err)
@@ -88,7 +162,7 @@ func (c *Client) Download(remotePath, localPath string) error {
 		return fmt.Errorf("creating %q: %w", localPath, err)
 	}
 
-	_, copyErr := io.Copy(out, resp)
+	_, copyErr := io.Copy(out, newRateReader(resp, c.rateLimit))
 	closeErr := out.Close()
 	if copyErr != nil {
 		return fmt.Errorf("downloading %q: %w", remotePath, copyErr)
@@ -100,21 +174,21 @@ func (c *Client) Download(remotePath, localPath string) error {
 }
 
 // Upload stores a local file on the FTP server.
-func (c *Client) Upload(localPath, remotePath string) error {
+func (c *ftpClient) Upload(localPath, remotePath string) error {
 	f, err := os.Open(localPath)
 	if err != nil {
 		return fmt.Errorf("opening %q: %w", localPath, err)
 	}
 	defer f.Close()
 
-	if err := c.conn.Stor(remotePath, f); err != nil {
+	if err := c.conn.Stor(remotePath, newRateReader(f, c.rateLimit)); err != nil {
 		return fmt.Errorf("uploading to %q: %w", remotePath, err)
 	}
 	return nil
 }
 
 // Move renames a file on the server (RNFR/RNTO).
-func (c *Client) Move(oldPath, newPath string) error {
+func (c *ftpClient) Move(oldPath, newPath string) error {
 	if err := c.conn.Rename(oldPath, newPath); err != nil {
 		return fmt.Errorf("moving %q to %q: %w", oldPath, newPath, err)
 	}
@@ -122,24 +196,34 @@ func (c *Client) Move(oldPath, newPath string) error {
 }
 
 // MkdirAll creates the directory and all parents on the FTP server.
-func (c *Client) MkdirAll(dir string) error {
-	parts := strings.Split(path.Clean(dir), "/")
+func (c *ftpClient) MkdirAll(dir string) error {
+	for _, d := range mkdirSteps(dir) {
+		// Attempt mkdir; ignore error if dir already exists
+		c.conn.MakeDir(d)
+	}
+	return nil
+}
+
+// mkdirSteps returns dir and each of its parents, outermost first, joined
+// with remotepath.Join so they use "/" whatever the local OS. A leading
+// "//" host prefix is kept as part of the first step.
+func mkdirSteps(dir string) []string {
 	current := ""
-	for _, part := range parts {
+	switch {
+	case strings.HasPrefix(dir, "//"):
+		current = "//"
+	case strings.HasPrefix(dir, "/"):
+		current = "/"
+	}
+	var steps []string
+	for _, part := range strings.Split(path.Clean(dir), "/") {
 		if part == "" || part == "." {
 			continue
 		}
-		if current == "" && strings.HasPrefix(dir, "/") {
-			current = "/" + part
-		} else if current == "" {
-			current = part
-		} else {
-			current = current + "/" + part
-		}
-		// Attempt mkdir; ignore error if dir already exists
-		c.conn.MakeDir(current)
+		current = remotepath.Join(current, part)
+		steps = append(steps, current)
 	}
-	return nil
+	return steps
 }
 
 // MatchGlob matches a filename against a glob pattern.
@@ -1,6 +1,9 @@
 package ftp
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestMatchGlob(t *testing.T) {
 	tests := []struct {
@@ -41,3 +44,18 @@ func TestMatchGlob_InvalidPattern(t *testing.T) {
 		t.Error("MatchGlob() expected error for invalid pattern, got nil")
 	}
 }
+
+func TestMkdirSteps(t *testing.T) {
+	tests := map[string]string{
+		"/archive/sales/2024": "/archive|/archive/sales|/archive/sales/2024",
+		"archive/sales":       "archive|archive/sales",
+		"/archive//sales/":    "/archive|/archive/sales",
+		"//sftp/archive":      "//sftp|//sftp/archive",
+		"/":                   "",
+	}
+	for dir, want := range tests {
+		if got := strings.Join(mkdirSteps(dir), "|"); got != want {
+			t.Errorf("mkdirSteps(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package ftp
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// OptionsFromSecret builds Options from the fields of a structured secret,
+// read through field. host and user are required, and password unless
+// the protocol is sftp and private_key is set. Optional: port, tls
+// ("true"), protocol, private_key, passphrase, host_key.
+func OptionsFromSecret(secret string, field func(name string) (string, error)) (Options, error) {
+	var o Options
+	var err error
+	if o.Host, err = field("host"); err != nil {
+		return Options{}, fmt.Errorf("resolving %s.host: %w", secret, err)
+	}
+	if o.User, err = field("user"); err != nil {
+		return Options{}, fmt.Errorf("resolving %s.user: %w", secret, err)
+	}
+
+	optional := func(name string) string {
+		v, _ := field(name)
+		return v
+	}
+	o.Protocol = optional("protocol")
+	if o.Protocol != "" && o.Protocol != ProtocolFTP && o.Protocol != ProtocolSFTP {
+		return Options{}, fmt.Errorf("%s.protocol: unknown protocol %q (must be ftp or sftp)", secret, o.Protocol)
+	}
+	o.PrivateKey = optional("private_key")
+	o.Passphrase = optional("passphrase")
+	o.HostKey = optional("host_key")
+	o.TLS = optional("tls") == "true"
+	if portStr := optional("port"); portStr != "" {
+		if o.Port, err = strconv.Atoi(portStr); err != nil {
+			return Options{}, fmt.Errorf("%s.port: %w", secret, err)
+		}
+	}
+
+	o.Password, err = field("password")
+	if err != nil && (o.Protocol != ProtocolSFTP || o.PrivateKey == "") {
+		return Options{}, fmt.Errorf("resolving %s.password: %w", secret, err)
+	}
+	return o, nil
+}
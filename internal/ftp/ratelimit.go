@@ -0,0 +1,59 @@
+package ftp
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// ParseRateLimit parses a transfer rate in bytes per second, written as a
+// size: "5MB" is 5 MB/s.
+func ParseRateLimit(s string) (int64, error) {
+	var b config.ByteSize
+	if err := b.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid rate_limit: %w", err)
+	}
+	return int64(b), nil
+}
+
+// rateReader limits reads from r to an average of rate bytes per second.
+// Reads are split into chunks of a tenth of a second's worth so the
+// transfer is paced smoothly rather than in one-second bursts.
+type rateReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// newRateReader wraps r so that it reads at most rate bytes per second.
+// A rate <= 0 returns r unchanged.
+func newRateReader(r io.Reader, rate int64) io.Reader {
+	if rate <= 0 {
+		return r
+	}
+	return &rateReader{r: r, rate: rate, now: time.Now, sleep: time.Sleep}
+}
+
+func (rr *rateReader) Read(p []byte) (int, error) {
+	if rr.start.IsZero() {
+		rr.start = rr.now()
+	}
+	if chunk := max(rr.rate/10, 1); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := rr.r.Read(p)
+	rr.read += int64(n)
+
+	// Wait until the bytes read so far are within the rate
+	due := time.Duration(float64(rr.read) / float64(rr.rate) * float64(time.Second))
+	if wait := due - rr.now().Sub(rr.start); wait > 0 {
+		rr.sleep(wait)
+	}
+	return n, err
+}
@@ -0,0 +1,69 @@
+package ftp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 5000)
+	clock := time.Unix(1_700_000_000, 0)
+	var slept time.Duration
+	rr := newRateReader(bytes.NewReader(data), 1000).(*rateReader)
+	rr.now = func() time.Time { return clock }
+	rr.sleep = func(d time.Duration) {
+		slept += d
+		clock = clock.Add(d)
+	}
+
+	got, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, want the %d written", len(got), len(data))
+	}
+	// 5000 bytes at 1000 B/s takes 5s on the fake clock
+	if slept != 5*time.Second {
+		t.Errorf("slept %v, want 5s", slept)
+	}
+}
+
+func TestRateReader_ChunksReads(t *testing.T) {
+	rr := newRateReader(strings.NewReader(strings.Repeat("x", 100)), 100).(*rateReader)
+	rr.sleep = func(time.Duration) {}
+	n, _ := rr.Read(make([]byte, 100))
+	if n != 10 {
+		t.Errorf("Read() = %d bytes, want a tenth of a second's worth (10)", n)
+	}
+}
+
+func TestNewRateReader_Unlimited(t *testing.T) {
+	r := strings.NewReader("x")
+	if got := newRateReader(r, 0); got != io.Reader(r) {
+		t.Error("newRateReader(r, 0) should return r unchanged")
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"5MB", 5_000_000, false},
+		{"512KiB", 512 << 10, false},
+		{"1000", 1000, false},
+		{"fast", 0, true},
+		{"-1MB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimit(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRateLimit(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package ftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ServerOptions configures a Server.
+type ServerOptions struct {
+	Protocol string // ProtocolFTP (default) or ProtocolSFTP
+	Root     string // directory served as "/"
+	Addr     string // listen address; "" = 127.0.0.1 on a free port
+	User     string
+	Password string
+	// SFTP: file holding the server's private key, created on first use
+	// so the host key stays the same across restarts. Empty generates a
+	// new key each time.
+	HostKeyFile string
+	// Logf, if set, receives one line per session and per failed
+	// command.
+	Logf func(format string, args ...any)
+}
+
+// Server serves a local directory over FTP or SFTP, for pit dev ftp-server
+// and for tests of ftp_watch DAGs and the SDK's FTP methods. It accepts one
+// user, speaks plain FTP in passive mode only, and serves just the
+// requests pit's own clients send plus those common clients need to
+// browse. It is meant for loopback, not for facing a network.
+type Server struct {
+	opts     ServerOptions
+	ln       net.Listener
+	hostKey  ssh.Signer // SFTP only
+	sshCfg   *ssh.ServerConfig
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	closed   bool
+	closeErr error
+}
+
+// NewServer checks o, listens on o.Addr, and for SFTP loads or creates the
+// host key. Call Serve to accept connections.
+func NewServer(o ServerOptions) (*Server, error) {
+	if o.Protocol == "" {
+		o.Protocol = ProtocolFTP
+	}
+	if o.Protocol != ProtocolFTP && o.Protocol != ProtocolSFTP {
+		return nil, fmt.Errorf("unknown protocol %q (must be ftp or sftp)", o.Protocol)
+	}
+	if o.User == "" || o.Password == "" {
+		return nil, errors.New("a user and password are required")
+	}
+	root, err := filepath.Abs(o.Root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("serving %s: %w", o.Root, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("serving %s: not a directory", o.Root)
+	}
+	o.Root = root
+	if o.Addr == "" {
+		o.Addr = "127.0.0.1:0"
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...any) {}
+	}
+
+	s := &Server{opts: o, conns: make(map[net.Conn]bool)}
+	if o.Protocol == ProtocolSFTP {
+		if s.hostKey, err = loadHostKey(o.HostKeyFile); err != nil {
+			return nil, err
+		}
+		s.sshCfg = &ssh.ServerConfig{
+			PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+				if c.User() == o.User && string(pass) == o.Password {
+					return nil, nil
+				}
+				return nil, errors.New("login incorrect")
+			},
+		}
+		s.sshCfg.AddHostKey(s.hostKey)
+	}
+
+	if s.ln, err = net.Listen("tcp", o.Addr); err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", o.Addr, err)
+	}
+	return s, nil
+}
+
+// loadHostKey reads the PEM private key in path, creating an ed25519 key
+// there if the file does not exist. An empty path returns a new key.
+func loadHostKey(path string) (ssh.Signer, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			signer, err := ssh.ParsePrivateKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("host key %s: %w", path, err)
+			}
+			return signer, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading host key: %w", err)
+		}
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating host key: %w", err)
+	}
+	if path != "" {
+		block, err := ssh.MarshalPrivateKey(priv, "pit dev ftp-server")
+		if err != nil {
+			return nil, fmt.Errorf("encoding host key: %w", err)
+		}
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			return nil, fmt.Errorf("writing host key: %w", err)
+		}
+	}
+	return ssh.NewSignerFromKey(priv)
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() *net.TCPAddr { return s.ln.Addr().(*net.TCPAddr) }
+
+// HostKey returns the SHA256 fingerprint of the SFTP host key, the
+// host_key a connection secret needs, or "" for FTP.
+func (s *Server) HostKey() string {
+	if s.hostKey == nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(s.hostKey.PublicKey())
+}
+
+// ClientOptions returns the Options that connect to the server.
+func (s *Server) ClientOptions() Options {
+	host := s.Addr().IP
+	if host.IsUnspecified() {
+		host = net.IPv4(127, 0, 0, 1)
+	}
+	return Options{
+		Protocol: s.opts.Protocol,
+		Host:     host.String(),
+		Port:     s.Addr().Port,
+		User:     s.opts.User,
+		Password: s.opts.Password,
+		HostKey:  s.HostKey(),
+	}
+}
+
+// Serve accepts connections until Close is called, then returns nil.
+func (s *Server) Serve() error {
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+		if !s.track(nc, true) {
+			nc.Close()
+			return nil
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.track(nc, false)
+			defer nc.Close()
+			s.opts.Logf("%s: session from %s", s.opts.Protocol, nc.RemoteAddr())
+			if s.opts.Protocol == ProtocolSFTP {
+				s.serveSSH(nc)
+			} else {
+				s.serveFTP(nc)
+			}
+		}()
+	}
+}
+
+// track adds or removes an open connection. Adding fails once the server
+// is closed.
+func (s *Server) track(nc net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !add {
+		delete(s.conns, nc)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	s.conns[nc] = true
+	return true
+}
+
+// Close stops the listener, drops open sessions, and waits for them to end.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return s.closeErr
+	}
+	s.closed = true
+	s.closeErr = s.ln.Close()
+	for nc := range s.conns {
+		nc.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return s.closeErr
+}
+
+// localPath maps a remote path, absolute or relative to cwd, to a path
+// under root. ".." cannot climb above root.
+func localPath(root, cwd, remote string) string {
+	if !path.IsAbs(remote) {
+		remote = path.Join(cwd, remote)
+	}
+	return filepath.Join(root, filepath.FromSlash(path.Clean("/"+remote)))
+}
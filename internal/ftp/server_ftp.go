@@ -0,0 +1,418 @@
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dataTimeout bounds how long a transfer waits for the client to open the
+// passive data connection.
+const dataTimeout = 30 * time.Second
+
+// ftpFeatures is the FEAT reply body. MLST makes clients list with MLSD,
+// whose lines carry exact sizes and times.
+var ftpFeatures = []string{"EPSV", "MDTM", "MLST type*;size*;modify*;", "PASV", "REST STREAM", "SIZE", "UTF8"}
+
+// ftpSession is one FTP control connection.
+type ftpSession struct {
+	s        *Server
+	ctrl     *textproto.Conn
+	nc       net.Conn
+	user     string
+	loggedIn bool
+	cwd      string       // remote working directory, always absolute
+	pasv     net.Listener // data listener opened by PASV or EPSV
+	rest     int64        // offset set by REST for the next RETR
+	rnfr     string       // local path set by RNFR for the next RNTO
+}
+
+func (s *Server) serveFTP(nc net.Conn) {
+	f := &ftpSession{s: s, ctrl: textproto.NewConn(nc), nc: nc, cwd: "/"}
+	defer func() {
+		if f.pasv != nil {
+			f.pasv.Close()
+		}
+	}()
+
+	f.reply(220, "pit dev ftp-server ready")
+	for {
+		line, err := f.ctrl.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		cmd = strings.ToUpper(cmd)
+		if cmd == "QUIT" {
+			f.reply(221, "Goodbye")
+			return
+		}
+		f.command(cmd, arg)
+	}
+}
+
+// reply sends a one-line reply, logging failures other than login prompts.
+func (f *ftpSession) reply(code int, msg string) {
+	if code >= 400 {
+		f.s.opts.Logf("ftp: %s: %d %s", f.nc.RemoteAddr(), code, msg)
+	}
+	f.ctrl.PrintfLine("%d %s", code, msg)
+}
+
+// replyLines sends a multi-line reply: first, then each line indented by a
+// space, then last.
+func (f *ftpSession) replyLines(code int, first string, lines []string, last string) {
+	f.ctrl.PrintfLine("%d-%s", code, first)
+	for _, l := range lines {
+		f.ctrl.PrintfLine(" %s", l)
+	}
+	f.ctrl.PrintfLine("%d %s", code, last)
+}
+
+// replyErr sends 550 with err, shortened to its cause so replies do not
+// show paths on the server's disk.
+func (f *ftpSession) replyErr(err error) {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		err = le.Err
+	}
+	f.reply(550, err.Error())
+}
+
+// remote returns arg as an absolute, cleaned remote path.
+func (f *ftpSession) remote(arg string) string {
+	if !path.IsAbs(arg) {
+		arg = path.Join(f.cwd, arg)
+	}
+	return path.Clean("/" + arg)
+}
+
+// local returns the file arg names under the server root.
+func (f *ftpSession) local(arg string) string {
+	return localPath(f.s.opts.Root, f.cwd, arg)
+}
+
+func (f *ftpSession) command(cmd, arg string) {
+	switch cmd {
+	case "USER":
+		f.user, f.loggedIn = arg, false
+		f.reply(331, "Password required")
+		return
+	case "PASS":
+		if f.user == f.s.opts.User && arg == f.s.opts.Password {
+			f.loggedIn = true
+			f.reply(230, "Logged in")
+		} else {
+			f.reply(530, "Login incorrect")
+		}
+		return
+	case "FEAT":
+		f.replyLines(211, "Features:", ftpFeatures, "End")
+		return
+	case "SYST":
+		f.reply(215, "UNIX Type: L8")
+		return
+	case "NOOP":
+		f.reply(200, "OK")
+		return
+	}
+	if !f.loggedIn {
+		f.reply(530, "Not logged in")
+		return
+	}
+
+	switch cmd {
+	case "TYPE":
+		// Everything is sent as is; ASCII mode does not convert line endings
+		if t := strings.ToUpper(arg); t == "I" || t == "A" || t == "L 8" {
+			f.reply(200, "Type set to "+t)
+		} else {
+			f.reply(504, "Unsupported type "+arg)
+		}
+	case "OPTS":
+		if strings.EqualFold(arg, "UTF8 ON") {
+			f.reply(200, "UTF8 is on")
+		} else {
+			f.reply(501, "Unsupported option "+arg)
+		}
+	case "PWD", "XPWD":
+		f.reply(257, strconv.Quote(f.cwd)+" is the current directory")
+	case "CWD", "CDUP":
+		if cmd == "CDUP" {
+			arg = ".."
+		}
+		if info, err := os.Stat(f.local(arg)); err != nil {
+			f.replyErr(err)
+		} else if !info.IsDir() {
+			f.reply(550, "Not a directory")
+		} else {
+			f.cwd = f.remote(arg)
+			f.reply(250, "Directory changed to "+f.cwd)
+		}
+	case "EPSV", "PASV":
+		f.passive(cmd)
+	case "LIST", "NLST", "MLSD":
+		f.list(cmd, arg)
+	case "MLST":
+		info, err := os.Stat(f.local(arg))
+		if err != nil {
+			f.replyErr(err)
+			return
+		}
+		f.replyLines(250, "Listing "+f.remote(arg), []string{mlsxLine(info, f.remote(arg))}, "End")
+	case "REST":
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n < 0 {
+			f.reply(501, "Bad offset")
+			return
+		}
+		f.rest = n
+		f.reply(350, "Restarting at "+arg)
+	case "RETR":
+		f.retr(arg)
+	case "STOR":
+		f.stor(arg)
+	case "SIZE", "MDTM":
+		info, err := os.Stat(f.local(arg))
+		switch {
+		case err != nil:
+			f.replyErr(err)
+		case info.IsDir():
+			f.reply(550, "Not a file")
+		case cmd == "SIZE":
+			f.reply(213, strconv.FormatInt(info.Size(), 10))
+		default:
+			f.reply(213, info.ModTime().UTC().Format("20060102150405"))
+		}
+	case "MKD", "XMKD":
+		if err := os.Mkdir(f.local(arg), 0o755); err != nil {
+			f.replyErr(err)
+		} else {
+			f.reply(257, strconv.Quote(f.remote(arg))+" created")
+		}
+	case "RMD", "XRMD":
+		if info, err := os.Stat(f.local(arg)); err == nil && !info.IsDir() {
+			f.reply(550, "Not a directory")
+		} else if err := os.Remove(f.local(arg)); err != nil {
+			f.replyErr(err)
+		} else {
+			f.reply(250, "Directory removed")
+		}
+	case "DELE":
+		if info, err := os.Stat(f.local(arg)); err == nil && info.IsDir() {
+			f.reply(550, "Is a directory")
+		} else if err := os.Remove(f.local(arg)); err != nil {
+			f.replyErr(err)
+		} else {
+			f.reply(250, "File deleted")
+		}
+	case "RNFR":
+		if _, err := os.Stat(f.local(arg)); err != nil {
+			f.replyErr(err)
+			return
+		}
+		f.rnfr = f.local(arg)
+		f.reply(350, "Ready for RNTO")
+	case "RNTO":
+		from := f.rnfr
+		f.rnfr = ""
+		if from == "" {
+			f.reply(503, "RNFR required first")
+		} else if err := os.Rename(from, f.local(arg)); err != nil {
+			f.replyErr(err)
+		} else {
+			f.reply(250, "Renamed")
+		}
+	default:
+		f.reply(502, "Command not implemented")
+	}
+}
+
+// passive opens a data listener on the control connection's local address
+// and tells the client where it is.
+func (f *ftpSession) passive(cmd string) {
+	if f.pasv != nil {
+		f.pasv.Close()
+		f.pasv = nil
+	}
+	ip := f.nc.LocalAddr().(*net.TCPAddr).IP
+	ip4 := ip.To4()
+	if cmd == "PASV" && ip4 == nil {
+		f.reply(425, "PASV needs IPv4; use EPSV")
+		return
+	}
+	ln, err := net.ListenTCP("tcp", &net.TCPAddr{IP: ip})
+	if err != nil {
+		f.reply(425, "Cannot open data connection")
+		return
+	}
+	f.pasv = ln
+	port := ln.Addr().(*net.TCPAddr).Port
+	if cmd == "EPSV" {
+		f.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+	} else {
+		f.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)", ip4[0], ip4[1], ip4[2], ip4[3], port/256, port%256))
+	}
+}
+
+// transfer accepts the data connection from the last PASV or EPSV and
+// runs fn on it, with the 150 and 226 replies around it.
+func (f *ftpSession) transfer(fn func(conn net.Conn) error) {
+	ln := f.pasv
+	f.pasv = nil
+	if ln == nil {
+		f.reply(425, "Use PASV or EPSV first")
+		return
+	}
+	defer ln.Close()
+
+	f.reply(150, "Opening data connection")
+	ln.(*net.TCPListener).SetDeadline(time.Now().Add(dataTimeout))
+	conn, err := ln.Accept()
+	if err != nil {
+		f.reply(425, "Cannot open data connection")
+		return
+	}
+	// Tracked so Close does not wait on a stalled transfer
+	if !f.s.track(conn, true) {
+		conn.Close()
+		return
+	}
+	err = fn(conn)
+	conn.Close()
+	f.s.track(conn, false)
+	if err != nil {
+		f.reply(426, "Transfer aborted: "+err.Error())
+		return
+	}
+	f.reply(226, "Transfer complete")
+}
+
+func (f *ftpSession) list(cmd, arg string) {
+	// Clients send ls flags such as "LIST -a"; they are ignored
+	for strings.HasPrefix(arg, "-") {
+		_, arg, _ = strings.Cut(arg, " ")
+	}
+	p := f.local(arg)
+	info, err := os.Stat(p)
+	if err != nil {
+		f.replyErr(err)
+		return
+	}
+	var infos []fs.FileInfo
+	if info.IsDir() {
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			f.replyErr(err)
+			return
+		}
+		for _, e := range entries {
+			if i, err := e.Info(); err == nil {
+				infos = append(infos, i)
+			}
+		}
+	} else if cmd == "MLSD" {
+		f.reply(501, "Not a directory")
+		return
+	} else {
+		infos = []fs.FileInfo{info}
+	}
+
+	now := time.Now()
+	f.transfer(func(conn net.Conn) error {
+		var b strings.Builder
+		for _, i := range infos {
+			switch cmd {
+			case "MLSD":
+				b.WriteString(mlsxLine(i, i.Name()))
+			case "NLST":
+				b.WriteString(i.Name())
+			default:
+				b.WriteString(listLine(i, now))
+			}
+			b.WriteString("\r\n")
+		}
+		_, err := io.WriteString(conn, b.String())
+		return err
+	})
+}
+
+// mlsxLine formats info as an MLSD or MLST line (RFC 3659) for name.
+func mlsxLine(info fs.FileInfo, name string) string {
+	typ := "file"
+	if info.IsDir() {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s", typ, info.Size(), info.ModTime().UTC().Format("20060102150405"), name)
+}
+
+// listLine formats info the way ls -l does, for LIST.
+func listLine(info fs.FileInfo, now time.Time) string {
+	stamp := info.ModTime().Format("Jan _2 15:04")
+	if age := now.Sub(info.ModTime()); age > 180*24*time.Hour || age < -time.Hour {
+		stamp = info.ModTime().Format("Jan _2  2006")
+	}
+	mode := info.Mode().String()
+	if info.IsDir() {
+		mode = "d" + mode[1:]
+	}
+	return fmt.Sprintf("%s 1 pit pit %d %s %s", mode, info.Size(), stamp, info.Name())
+}
+
+func (f *ftpSession) retr(arg string) {
+	offset := f.rest
+	f.rest = 0
+	file, err := os.Open(f.local(arg))
+	if err != nil {
+		f.replyErr(err)
+		return
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		f.reply(550, "Is a directory")
+		return
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		f.replyErr(err)
+		return
+	}
+	f.transfer(func(conn net.Conn) error {
+		_, err := io.Copy(conn, file)
+		return err
+	})
+}
+
+func (f *ftpSession) stor(arg string) {
+	f.rest = 0
+	p := f.local(arg)
+	if info, err := os.Stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		f.reply(550, "No such directory")
+		return
+	}
+	file, err := os.Create(p)
+	if err != nil {
+		f.replyErr(err)
+		return
+	}
+	f.transfer(func(conn net.Conn) error {
+		_, err := io.Copy(file, conn)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+	file.Close()
+}
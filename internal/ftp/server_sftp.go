@@ -0,0 +1,180 @@
+package ftp
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// serveSSH completes the SSH handshake on nc and serves the sftp subsystem
+// on each session channel. Shells and commands are refused.
+func (s *Server) serveSSH(nc net.Conn) {
+	conn, chans, reqs, err := ssh.NewServerConn(nc, s.sshCfg)
+	if err != nil {
+		s.opts.Logf("sftp: %s: %v", nc.RemoteAddr(), err)
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			nch.Reject(ssh.UnknownChannelType, "only session channels are served")
+			continue
+		}
+		ch, chReqs, err := nch.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					go func() {
+						srv := sftp.NewRequestServer(ch, sftpHandlers(s.opts.Root))
+						if err := srv.Serve(); err != nil && !errors.Is(err, io.EOF) {
+							s.opts.Logf("sftp: %s: %v", nc.RemoteAddr(), err)
+						}
+						srv.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// sftpRoot serves the files under a directory to an SFTP request server.
+// Request paths arrive cleaned and absolute, and are mapped under the
+// directory as FTP paths are.
+type sftpRoot string
+
+func sftpHandlers(root string) sftp.Handlers {
+	h := sftpRoot(root)
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+func (root sftpRoot) local(p string) string { return localPath(string(root), "/", p) }
+
+// Fileread opens a file for download.
+func (root sftpRoot) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	f, err := os.Open(root.local(r.Filepath))
+	if err != nil {
+		return nil, hideLocalPath(err)
+	}
+	return f, nil
+}
+
+// Filewrite opens a file for upload.
+func (root sftpRoot) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	flags := os.O_WRONLY
+	pflags := r.Pflags()
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(root.local(r.Filepath), flags, 0o644)
+	if err != nil {
+		return nil, hideLocalPath(err)
+	}
+	return f, nil
+}
+
+// Filecmd creates, removes, and renames files and directories.
+func (root sftpRoot) Filecmd(r *sftp.Request) error {
+	p := root.local(r.Filepath)
+	switch r.Method {
+	case "Setstat":
+		// Times and permissions are left as the local filesystem sets them
+		return nil
+	case "Mkdir":
+		return hideLocalPath(os.Mkdir(p, 0o755))
+	case "Rmdir":
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return errors.New("not a directory")
+		}
+		return hideLocalPath(os.Remove(p))
+	case "Remove":
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			return errors.New("is a directory")
+		}
+		return hideLocalPath(os.Remove(p))
+	case "Rename":
+		// A plain SFTP rename does not replace an existing file
+		if _, err := os.Lstat(root.local(r.Target)); err == nil {
+			return fs.ErrExist
+		}
+		return hideLocalPath(os.Rename(p, root.local(r.Target)))
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// PosixRename renames over an existing file.
+func (root sftpRoot) PosixRename(r *sftp.Request) error {
+	return hideLocalPath(os.Rename(root.local(r.Filepath), root.local(r.Target)))
+}
+
+// Filelist lists directories and stats files.
+func (root sftpRoot) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	p := root.local(r.Filepath)
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, hideLocalPath(err)
+		}
+		infos := make(listerAt, 0, len(entries))
+		for _, e := range entries {
+			// Entries removed since the listing are left out
+			if info, err := e.Info(); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		return infos, nil
+	case "Stat":
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, hideLocalPath(err)
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// listerAt serves a listing already read in full.
+type listerAt []fs.FileInfo
+
+func (l listerAt) ListAt(dst []fs.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(dst, l[offset:])
+	if n < len(dst) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// hideLocalPath drops the local path from err, so error replies do not
+// show where the server's files are on disk.
+func hideLocalPath(err error) error {
+	var pe *fs.PathError
+	var le *os.LinkError
+	switch {
+	case errors.As(err, &pe):
+		return pe.Err
+	case errors.As(err, &le):
+		return le.Err
+	}
+	return err
+}
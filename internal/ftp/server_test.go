@@ -0,0 +1,171 @@
+package ftp
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// startServer serves o on loopback until the test ends, with a test user
+// unless o sets one.
+func startServer(t *testing.T, o ServerOptions) *Server {
+	t.Helper()
+	if o.User == "" {
+		o.User, o.Password = "pit", "secret"
+	}
+	srv, err := NewServer(o)
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	go srv.Serve()
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestServer_FTP(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "incoming", "sub.csv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("0123456789", 10_000)
+	for name, content := range map[string]string{"sales_1.csv": big, "sales_2.csv": "b", "notes.txt": "c"} {
+		if err := os.WriteFile(filepath.Join(root, "incoming", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := startServer(t, ServerOptions{Root: root})
+
+	c, err := Connect(srv.ClientOptions())
+	if err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	defer c.Close()
+
+	files, err := c.List("/incoming", "*.csv")
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	want := []FileInfo{{Name: "sales_1.csv", Size: int64(len(big))}, {Name: "sales_2.csv", Size: 1}}
+	if !slices.Equal(files, want) {
+		t.Errorf("List() = %v, want %v (no directories)", files, want)
+	}
+
+	local := filepath.Join(t.TempDir(), "sales_1.csv")
+	if err := c.Download("/incoming/sales_1.csv", local); err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if got, _ := os.ReadFile(local); string(got) != big {
+		t.Errorf("Download() wrote %d bytes, want %d", len(got), len(big))
+	}
+
+	if err := c.MkdirAll("/outgoing/2026/03"); err != nil {
+		t.Fatalf("MkdirAll() error: %v", err)
+	}
+	if err := c.Upload(local, "/outgoing/2026/03/copy.csv"); err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "outgoing", "2026", "03", "copy.csv")); string(got) != big {
+		t.Errorf("Upload() stored %d bytes, want %d", len(got), len(big))
+	}
+
+	if err := c.Move("/incoming/sales_2.csv", "/incoming/archive.csv"); err != nil {
+		t.Fatalf("Move() error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "incoming", "archive.csv")); string(got) != "b" {
+		t.Errorf("after Move() archive.csv = %q, want %q", got, "b")
+	}
+
+	if err := c.Download("/incoming/missing.csv", local); err == nil {
+		t.Error("Download() of a missing file succeeded, want error")
+	}
+	// The client keeps working after a failed command
+	if _, err := c.List("/incoming", "*"); err != nil {
+		t.Errorf("List() after an error: %v", err)
+	}
+}
+
+func TestServer_Login(t *testing.T) {
+	for _, protocol := range []string{ProtocolFTP, ProtocolSFTP} {
+		t.Run(protocol, func(t *testing.T) {
+			srv := startServer(t, ServerOptions{Protocol: protocol, Root: t.TempDir()})
+			opts := srv.ClientOptions()
+			opts.Password = "wrong"
+			if c, err := Connect(opts); err == nil {
+				c.Close()
+				t.Error("Connect() with a wrong password succeeded, want error")
+			}
+		})
+	}
+}
+
+// TestServer_Confined checks that ".." cannot reach files above the root.
+func TestServer_Confined(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "private.csv"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, protocol := range []string{ProtocolFTP, ProtocolSFTP} {
+		t.Run(protocol, func(t *testing.T) {
+			srv := startServer(t, ServerOptions{Protocol: protocol, Root: root})
+			c, err := Connect(srv.ClientOptions())
+			if err != nil {
+				t.Fatalf("Connect() error: %v", err)
+			}
+			defer c.Close()
+
+			if err := c.Download("/../private.csv", filepath.Join(t.TempDir(), "out")); err == nil {
+				t.Error("Download() of a file above the root succeeded")
+			}
+			files, err := c.List("../..", "private*")
+			if err != nil || len(files) != 0 {
+				t.Errorf("List() above the root = %v, %v, want nothing", files, err)
+			}
+			local := filepath.Join(t.TempDir(), "up.csv")
+			os.WriteFile(local, []byte("x"), 0o644)
+			if err := c.Upload(local, "../../up.csv"); err != nil {
+				t.Fatalf("Upload() error: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "up.csv")); err != nil {
+				t.Errorf("upload to ../../up.csv did not land in the root: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewServer_HostKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "host_key")
+	o := ServerOptions{Protocol: ProtocolSFTP, Root: t.TempDir(), User: "pit", Password: "secret", HostKeyFile: keyFile}
+
+	first, err := NewServer(o)
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	first.Close()
+	second, err := NewServer(o)
+	if err != nil {
+		t.Fatalf("NewServer() with an existing key error: %v", err)
+	}
+	second.Close()
+	if first.HostKey() == "" || first.HostKey() != second.HostKey() {
+		t.Errorf("host keys %q and %q, want the same key from %s", first.HostKey(), second.HostKey(), keyFile)
+	}
+
+	for name, o := range map[string]ServerOptions{
+		"protocol": {Protocol: "scp", Root: t.TempDir(), User: "pit", Password: "secret"},
+		"password": {Root: t.TempDir(), User: "pit"},
+		"root":     {Root: filepath.Join(t.TempDir(), "missing"), User: "pit", Password: "secret"},
+	} {
+		if srv, err := NewServer(o); err == nil {
+			srv.Close()
+			t.Errorf("NewServer() with a bad %s succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package ftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// pipeSFTP returns a client talking to a server on root over pipes.
+func pipeSFTP(t *testing.T, root string) *sftpClient {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverR, serverW}, sftpHandlers(root))
+	go func() {
+		err := server.Serve()
+		serverW.CloseWithError(err)
+	}()
+	client, err := sftp.NewClientPipe(clientR, clientW)
+	if err != nil {
+		t.Fatalf("NewClientPipe() error: %v", err)
+	}
+	c := newSFTPClient(client, nil)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestSFTPClient(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "incoming", "sub.csv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("0123456789", 10_000) // more than one read
+	for name, content := range map[string]string{"sales_1.csv": big, "sales_2.csv": "b", "notes.txt": "c"} {
+		if err := os.WriteFile(filepath.Join(root, "incoming", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := pipeSFTP(t, root)
+
+	files, err := c.List("/incoming", "*.csv")
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.Name, b.Name) })
+	want := []FileInfo{{Name: "sales_1.csv", Size: int64(len(big))}, {Name: "sales_2.csv", Size: 1}}
+	if !slices.Equal(files, want) {
+		t.Errorf("List() = %v, want %v (no directories)", files, want)
+	}
+
+	local := filepath.Join(t.TempDir(), "nested", "sales_1.csv")
+	if err := c.Download("/incoming/sales_1.csv", local); err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if got, _ := os.ReadFile(local); string(got) != big {
+		t.Errorf("Download() wrote %d bytes, want %d", len(got), len(big))
+	}
+
+	if err := c.MkdirAll("/outgoing/2026/03"); err != nil {
+		t.Fatalf("MkdirAll() error: %v", err)
+	}
+	if err := c.MkdirAll("/outgoing/2026"); err != nil {
+		t.Errorf("MkdirAll() on an existing dir error: %v", err)
+	}
+	if err := c.Upload(local, "/outgoing/2026/03/copy.csv"); err != nil {
+		t.Fatalf("Upload() error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "outgoing", "2026", "03", "copy.csv")); string(got) != big {
+		t.Errorf("Upload() stored %d bytes, want %d", len(got), len(big))
+	}
+
+	// posix-rename replaces an existing file
+	if err := c.Move("/incoming/sales_2.csv", "/incoming/notes.txt"); err != nil {
+		t.Fatalf("Move() error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "incoming", "notes.txt")); string(got) != "b" {
+		t.Errorf("after Move() notes.txt = %q, want %q", got, "b")
+	}
+
+	if err := c.Download("/incoming/missing.csv", local); err == nil {
+		t.Error("Download() of a missing file succeeded, want error")
+	}
+	if _, err := c.List("/missing", "*"); err == nil {
+		t.Error("List() of a missing dir succeeded, want error")
+	}
+}
+
+func TestSFTPClient_RenameWithoutExtension(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.csv", "b.csv"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := pipeSFTP(t, root)
+	c.posixRename = false
+
+	if err := c.Move("/a.csv", "/c.csv"); err != nil {
+		t.Fatalf("Move() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "c.csv")); err != nil {
+		t.Errorf("c.csv not there after Move(): %v", err)
+	}
+	// Plain RENAME does not replace a file
+	if err := c.Move("/b.csv", "/c.csv"); err == nil || !strings.Contains(err.Error(), "exists") {
+		t.Errorf("Move() onto an existing file error = %v, want file exists", err)
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	other, _ := ssh.NewPublicKey(otherPub)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+
+	for _, hostKey := range []string{ssh.FingerprintSHA256(key), string(ssh.MarshalAuthorizedKey(key))} {
+		check, err := hostKeyCallback(Options{HostKey: hostKey})
+		if err != nil {
+			t.Fatalf("hostKeyCallback(%q) error: %v", hostKey, err)
+		}
+		if err := check("sftp.example.com:22", addr, key); err != nil {
+			t.Errorf("host_key %q rejected its own key: %v", hostKey, err)
+		}
+		if err := check("sftp.example.com:22", addr, other); err == nil {
+			t.Errorf("host_key %q accepted another key", hostKey)
+		}
+	}
+
+	if _, err := hostKeyCallback(Options{HostKey: "not a key"}); err == nil {
+		t.Error("hostKeyCallback() with a bad host_key succeeded, want error")
+	}
+	if _, err := hostKeyCallback(Options{KnownHosts: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("hostKeyCallback() without host_key or known_hosts succeeded, want error")
+	}
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := "[127.0.0.1]:2222 " + string(ssh.MarshalAuthorizedKey(key))
+	if err := os.WriteFile(knownHosts, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	check, err := hostKeyCallback(Options{KnownHosts: knownHosts})
+	if err != nil {
+		t.Fatalf("hostKeyCallback() with known_hosts error: %v", err)
+	}
+	if err := check("127.0.0.1:2222", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}, key); err != nil {
+		t.Errorf("known_hosts rejected a listed key: %v", err)
+	}
+}
+
+// TestConnectSFTP runs the whole connection against a Server on loopback.
+func TestConnectSFTP(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "data.csv"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "dir.csv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	srv := startServer(t, ServerOptions{Protocol: ProtocolSFTP, Root: root})
+
+	opts := srv.ClientOptions()
+	c, err := Connect(opts)
+	if err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	files, err := c.List("/", "*.csv")
+	if err != nil || len(files) != 1 || files[0].Name != "data.csv" {
+		t.Errorf("List() = %v, %v, want data.csv", files, err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error: %v", err)
+	}
+
+	opts.Password = "wrong"
+	if _, err := Connect(opts); err == nil {
+		t.Error("Connect() with a wrong password succeeded, want error")
+	}
+	opts.Password = srv.ClientOptions().Password
+	opts.HostKey = "SHA256:not-the-server"
+	if _, err := Connect(opts); err == nil || !strings.Contains(err.Error(), "does not match host_key") {
+		t.Errorf("Connect() to an unknown host key error = %v, want host key mismatch", err)
+	}
+}
+
+func TestOptionsFromSecret(t *testing.T) {
+	fields := func(m map[string]string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			if v, ok := m[name]; ok {
+				return v, nil
+			}
+			return "", errors.New("no field " + name)
+		}
+	}
+
+	o, err := OptionsFromSecret("src", fields(map[string]string{
+		"host": "ftp.example.com", "user": "pit", "password": "pw", "port": "2121", "tls": "true",
+	}))
+	if err != nil {
+		t.Fatalf("OptionsFromSecret() error: %v", err)
+	}
+	want := Options{Host: "ftp.example.com", User: "pit", Password: "pw", Port: 2121, TLS: true}
+	if o != want {
+		t.Errorf("OptionsFromSecret() = %+v, want %+v", o, want)
+	}
+
+	// An sftp key needs no password
+	o, err = OptionsFromSecret("src", fields(map[string]string{
+		"host": "sftp.example.com", "user": "pit", "protocol": "sftp", "private_key": "KEY", "host_key": "SHA256:abc",
+	}))
+	if err != nil {
+		t.Fatalf("OptionsFromSecret() with a private key error: %v", err)
+	}
+	if o.Protocol != ProtocolSFTP || o.PrivateKey != "KEY" || o.HostKey != "SHA256:abc" || o.Password != "" {
+		t.Errorf("OptionsFromSecret() = %+v, want sftp with key", o)
+	}
+
+	for name, m := range map[string]map[string]string{
+		"src.host":     {"user": "pit", "password": "pw"},
+		"src.password": {"host": "h", "user": "pit", "private_key": "KEY"}, // ftp ignores the key
+		"src.protocol": {"host": "h", "user": "pit", "password": "pw", "protocol": "scp"},
+		"src.port":     {"host": "h", "user": "pit", "password": "pw", "port": "ftp"},
+	} {
+		if _, err := OptionsFromSecret("src", fields(m)); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("OptionsFromSecret() error = %v, want one about %s", err, name)
+		}
+	}
+}
+
+func TestConnect_UnknownProtocol(t *testing.T) {
+	if _, err := Connect(Options{Protocol: "scp", Host: "h"}); err == nil || !strings.Contains(err.Error(), "unknown protocol") {
+		t.Errorf("Connect() error = %v, want unknown protocol", err)
+	}
+}
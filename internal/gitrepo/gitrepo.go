@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // Prepare ensures that the repository at url with ref checked out is present
@@ -41,6 +42,21 @@ func Prepare(url, ref, cacheDir string) error {
 	return nil
 }
 
+// Head returns the commit SHA checked out in the repository at dir.
+func Head(dir string) (string, error) {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "HEAD")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := stderr.String(); msg != "" {
+			return "", fmt.Errorf("git rev-parse HEAD: %w\n%s", err, msg)
+		}
+		return "", fmt.Errorf("git rev-parse HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // gitRun executes git with the given arguments. If dir is non-empty it is
 // used as the working directory (equivalent to git -C dir). Stderr is
 // captured and included in the error on failure.
@@ -122,3 +122,23 @@ func TestPrepare_InvalidRef(t *testing.T) {
 		t.Errorf("error = %q, want it to mention 'git checkout'", err)
 	}
 }
+
+func TestHead(t *testing.T) {
+	remote := mkBareRepo(t, "hello.txt", "hello world\n")
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	if err := Prepare(remote, "main", cacheDir); err != nil {
+		t.Fatalf("Prepare() error: %v", err)
+	}
+
+	sha, err := Head(cacheDir)
+	if err != nil {
+		t.Fatalf("Head() error: %v", err)
+	}
+	out, err := exec.Command("git", "-C", remote, "rev-parse", "main").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSpace(string(out)); sha != want {
+		t.Errorf("Head() = %q, want %q", sha, want)
+	}
+}
@@ -0,0 +1,130 @@
+// Package lineage sends OpenLineage run events to an HTTP endpoint such as
+// Marquez or DataHub, so pit DAGs appear in their lineage graphs. Each DAG
+// is an OpenLineage job; each pit run is an OpenLineage run.
+package lineage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event types sent by pit.
+const (
+	EventStart    = "START"
+	EventComplete = "COMPLETE"
+	EventFail     = "FAIL"
+)
+
+// Producer identifies pit as the source of events.
+const Producer = "https://github.com/druarnfield/pit"
+
+// SchemaURL is the OpenLineage run event schema the events follow.
+const SchemaURL = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+
+// DefaultNamespace is the job namespace when none is configured.
+const DefaultNamespace = "pit"
+
+// Event is an OpenLineage run event.
+type Event struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+}
+
+// Run identifies an OpenLineage run.
+type Run struct {
+	RunID string `json:"runId"`
+}
+
+// Job identifies an OpenLineage job.
+type Job struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Dataset is an input or output of a run. Namespace is the data source,
+// e.g. "ftp://partner_ftp" or a database connection name; Name is the
+// path or table within it.
+type Dataset struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Client posts events to an OpenLineage HTTP endpoint.
+type Client struct {
+	URL       string       // endpoint, e.g. "http://marquez:5000/api/v1/lineage"
+	Token     string       // bearer token ("" = none)
+	Namespace string       // job namespace ("" = DefaultNamespace)
+	HTTP      *http.Client // nil = a client with a 10s timeout
+}
+
+// NewEvent returns an event of eventType for the pit run runID of dagName.
+func (c *Client) NewEvent(eventType, dagName, runID string, at time.Time) Event {
+	ns := c.Namespace
+	if ns == "" {
+		ns = DefaultNamespace
+	}
+	return Event{
+		EventType: eventType,
+		EventTime: at.UTC(),
+		Run:       Run{RunID: RunUUID(runID)},
+		Job:       Job{Namespace: ns, Name: dagName},
+		Inputs:    []Dataset{},
+		Outputs:   []Dataset{},
+		Producer:  Producer,
+		SchemaURL: SchemaURL,
+	}
+}
+
+// Emit posts ev to the endpoint.
+func (c *Client) Emit(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encoding lineage event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building lineage request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending lineage event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sending lineage event: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// RunUUID maps a pit run ID to the UUID OpenLineage requires, the same
+// way every time (a name-based, version 5 style UUID).
+func RunUUID(runID string) string {
+	sum := sha1.Sum([]byte("pit-run:" + runID))
+	b := sum[:16]
+	b[6] = (b[6] & 0x0f) | 0x50 // version 5
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
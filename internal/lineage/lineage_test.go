@@ -0,0 +1,75 @@
+package lineage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Emit(t *testing.T) {
+	var got Event
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding event: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Token: "s3cret"}
+	ev := c.NewEvent(EventStart, "daily_sales", "20260101_120000.000_ab12", time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	ev.Inputs = []Dataset{{Namespace: "ftp://partner_ftp", Name: "/inbox/sales.csv"}}
+	if err := c.Emit(context.Background(), ev); err != nil {
+		t.Fatalf("Emit() error: %v", err)
+	}
+
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got.EventType != EventStart || got.Job.Namespace != DefaultNamespace || got.Job.Name != "daily_sales" {
+		t.Errorf("event = %+v", got)
+	}
+	if got.Run.RunID != RunUUID("20260101_120000.000_ab12") {
+		t.Errorf("runId = %q", got.Run.RunID)
+	}
+	if len(got.Inputs) != 1 || got.Inputs[0].Name != "/inbox/sales.csv" {
+		t.Errorf("inputs = %+v", got.Inputs)
+	}
+	if got.Outputs == nil {
+		t.Error("outputs = nil, want empty list")
+	}
+}
+
+func TestClient_Emit_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad event", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL}
+	err := c.Emit(context.Background(), c.NewEvent(EventFail, "d", "r", time.Now()))
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "bad event") {
+		t.Errorf("Emit() error = %v, want 400 with body", err)
+	}
+}
+
+func TestRunUUID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a := RunUUID("20260101_120000.000_ab12")
+	if !uuid.MatchString(a) {
+		t.Errorf("RunUUID() = %q, not a version 5 UUID", a)
+	}
+	if b := RunUUID("20260101_120000.000_ab12"); b != a {
+		t.Errorf("RunUUID() not stable: %q != %q", a, b)
+	}
+	if c := RunUUID("20260101_120000.000_cd34"); c == a {
+		t.Errorf("RunUUID() same for different runs: %q", c)
+	}
+}
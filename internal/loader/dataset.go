@@ -0,0 +1,153 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// DefaultDatasetWorkers is the number of files LoadDataset loads at once
+// when the caller does not say.
+const DefaultDatasetWorkers = 4
+
+// LoadDataset loads several Parquet files with the same columns into one
+// table, up to workers files at a time, each over its own connection.
+// params.FilePath is ignored. The mode's create or truncate runs once,
+// before any file is loaded, and the files are then appended. Every file
+// is committed on its own: when one fails the rest are cancelled, but
+// files already loaded stay. Reject files are not supported.
+func LoadDataset(ctx context.Context, params LoadParams, files []string, workers int) (LoadResult, error) {
+	if len(files) == 0 {
+		return LoadResult{}, fmt.Errorf("no files to load")
+	}
+	if params.RejectFile != "" {
+		return LoadResult{}, fmt.Errorf("reject files are not supported when loading a dataset")
+	}
+	if workers <= 0 {
+		workers = DefaultDatasetWorkers
+	}
+	workers = min(workers, len(files))
+
+	drv, driverName, params, err := resolveLoad(params)
+	if err != nil {
+		return LoadResult{}, err
+	}
+
+	// The first file decides the columns: the table is created from it,
+	// and every other file must match it.
+	first, err := openParquetStream(ctx, files[0])
+	if err != nil {
+		return LoadResult{}, fmt.Errorf("reading parquet file %s: %w", filepath.Base(files[0]), err)
+	}
+	t, err := connectTarget(ctx, drv, driverName, params)
+	if err != nil {
+		first.Close()
+		return LoadResult{}, err
+	}
+	defer t.db.Close()
+	if err := t.prepareStream(first); err != nil {
+		first.Close()
+		return LoadResult{}, fmt.Errorf("%s: %w", filepath.Base(files[0]), err)
+	}
+	schema := first.Schema()
+	first.Close()
+
+	if err := t.prepareTable(ctx, schema); err != nil {
+		return LoadResult{}, err
+	}
+	if workers > 1 {
+		t.db.SetMaxIdleConns(workers)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		total    int64
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	ch := make(chan string)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range ch {
+				rows, err := t.loadFile(ctx, path, schema)
+				mu.Lock()
+				total += rows
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", filepath.Base(path), err)
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, path := range files {
+		select {
+		case ch <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return LoadResult{Rows: total}, firstErr
+}
+
+// loadFile appends one file of a dataset, checking its columns against
+// schema first.
+func (t *loadTarget) loadFile(ctx context.Context, path string, schema *arrow.Schema) (int64, error) {
+	stream, err := openParquetStream(ctx, path)
+	if err != nil {
+		return 0, fmt.Errorf("reading parquet file: %w", err)
+	}
+	defer stream.Close()
+
+	if err := t.prepareStream(stream); err != nil {
+		return 0, err
+	}
+	if err := sameColumns(schema, stream.Schema()); err != nil {
+		return 0, err
+	}
+	return t.drv.BulkLoad(ctx, t.db, t.params, stream)
+}
+
+// sameColumns reports an error unless got has want's column names and
+// types, in the same order. Nullability and metadata may differ between
+// files of a dataset.
+func sameColumns(want, got *arrow.Schema) error {
+	if want.NumFields() != got.NumFields() {
+		return fmt.Errorf("has columns %s, want %s", columnList(got), columnList(want))
+	}
+	for i, wf := range want.Fields() {
+		gf := got.Field(i)
+		if gf.Name != wf.Name {
+			return fmt.Errorf("has columns %s, want %s", columnList(got), columnList(want))
+		}
+		if !arrow.TypeEqual(gf.Type, wf.Type) {
+			return fmt.Errorf("column %q is %s, want %s", gf.Name, gf.Type, wf.Type)
+		}
+	}
+	return nil
+}
+
+func columnList(schema *arrow.Schema) string {
+	names := make([]string, schema.NumFields())
+	for i, f := range schema.Fields() {
+		names[i] = f.Name
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
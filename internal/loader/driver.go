@@ -11,27 +11,96 @@ import (
 // Driver abstracts database-specific bulk load and DDL operations.
 type Driver interface {
 	BulkLoad(ctx context.Context, db *sql.DB, params LoadParams, stream *parquetStream) (int64, error)
+	// RowInserter prepares batch inserts into the load's target table for
+	// loads that set rows aside instead of failing (see LoadParams.RejectFile).
+	RowInserter(ctx context.Context, db *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error)
 	CreateTable(ctx context.Context, db *sql.DB, schema, table string, arrowSchema *arrow.Schema) error
 	DropTable(ctx context.Context, db *sql.DB, schema, table string) error
 	TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error
+	TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error)
+	// NonInsertableColumns lists the columns of an existing table that the
+	// database fills in itself and rejects values for, such as identity
+	// and computed columns.
+	NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error)
 	ArrowType(dt arrow.DataType) (string, error)
 	SQLTypeToArrow(dbTypeName string) (arrow.DataType, error)
 	DefaultSchema() string
+	Quoting() Quoting
 	QuoteIdentifier(name string) string
 }
 
-var drivers = map[string]Driver{
-	"clickhouse": &ClickHouseDriver{},
-	"mssql":      &MSSQLDriver{},
-	"oracle":     &OracleDriver{},
-	"postgres":   &PostgresDriver{},
+var drivers = map[string]func(IdentifierCase) Driver{
+	"clickhouse": func(c IdentifierCase) Driver { return &ClickHouseDriver{Case: c} },
+	"mssql":      func(c IdentifierCase) Driver { return &MSSQLDriver{Case: c} },
+	"oracle":     func(c IdentifierCase) Driver { return &OracleDriver{Case: c} },
+	"postgres":   func(c IdentifierCase) Driver { return &PostgresDriver{Case: c} },
+	"sqlite":     func(c IdentifierCase) Driver { return &SQLiteDriver{Case: c} },
 }
 
-// GetDriver returns the Driver for the given name.
+// GetDriver returns the Driver for the given name, quoting identifiers the
+// driver's default way.
 func GetDriver(name string) (Driver, error) {
-	d, ok := drivers[name]
+	return NewDriver(name, CaseDefault)
+}
+
+// NewDriver returns the Driver for the given name with its identifier case
+// rule overridden by identCase (CaseDefault keeps the driver's own).
+func NewDriver(name string, identCase IdentifierCase) (Driver, error) {
+	newDriver, ok := drivers[name]
 	if !ok {
 		return nil, fmt.Errorf("unsupported database driver %q", name)
 	}
-	return d, nil
+	return newDriver(identCase), nil
+}
+
+// queryColumnNames runs a metadata query whose rows are single column names.
+func queryColumnNames(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("reading column metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("reading column metadata: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading column metadata: %w", err)
+	}
+	return names, nil
+}
+
+// txInserter runs a prepared INSERT for each row of a batch inside one
+// transaction, for drivers without a separate bulk path.
+type txInserter struct {
+	db    *sql.DB
+	query string
+}
+
+func (ins *txInserter) InsertRows(ctx context.Context, rows [][]any) error {
+	txn, err := ins.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer txn.Rollback()
+
+	stmt, err := txn.PrepareContext(ctx, ins.query)
+	if err != nil {
+		return fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, vals := range rows {
+		if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
 }
+
+func (ins *txInserter) Close() error { return nil }
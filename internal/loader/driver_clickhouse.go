@@ -12,13 +12,21 @@ import (
 )
 
 // ClickHouseDriver implements the Driver interface for ClickHouse.
-type ClickHouseDriver struct{}
+type ClickHouseDriver struct {
+	Case IdentifierCase // overrides the default (preserve) when set
+}
 
 // DefaultSchema returns an empty string; ClickHouse uses databases, not schemas.
 func (d *ClickHouseDriver) DefaultSchema() string { return "" }
 
+// Quoting returns ClickHouse backtick quoting. ClickHouse names are
+// always case-sensitive.
+func (d *ClickHouseDriver) Quoting() Quoting {
+	return Quoting{Open: "`", Close: "`"}.withCase(d.Case)
+}
+
 // QuoteIdentifier wraps a name in backtick quoting for ClickHouse.
-func (d *ClickHouseDriver) QuoteIdentifier(name string) string { return "`" + name + "`" }
+func (d *ClickHouseDriver) QuoteIdentifier(name string) string { return d.Quoting().Quote(name) }
 
 // ArrowType maps an Arrow data type to a ClickHouse column type string.
 func (d *ClickHouseDriver) ArrowType(dt arrow.DataType) (string, error) {
@@ -48,6 +56,14 @@ func (d *ClickHouseDriver) ArrowType(dt arrow.DataType) (string, error) {
 	case arrow.BOOL:
 		return "Bool", nil
 	case arrow.TIMESTAMP:
+		// ClickHouse stores instants; the zone only affects display and
+		// must be a named zone.
+		if tz := timestampZone(dt); tz != "" {
+			if tz[0] == '+' || tz[0] == '-' {
+				tz = "UTC"
+			}
+			return fmt.Sprintf("DateTime64(6, '%s')", tz), nil
+		}
 		return "DateTime64(6)", nil
 	case arrow.DATE32:
 		return "Date", nil
@@ -140,12 +156,7 @@ func (d *ClickHouseDriver) buildCreateTableDDL(schemaName, tableName string, sch
 		cols = append(cols, fmt.Sprintf("    %s %s", d.QuoteIdentifier(f.Name), colDef))
 	}
 
-	var qualifiedName string
-	if schemaName == "" {
-		qualifiedName = d.QuoteIdentifier(tableName)
-	} else {
-		qualifiedName = d.QuoteIdentifier(schemaName) + "." + d.QuoteIdentifier(tableName)
-	}
+	qualifiedName := d.Quoting().Qualify(schemaName, tableName)
 
 	ddl := fmt.Sprintf("CREATE TABLE %s (\n%s\n) ENGINE = MergeTree() ORDER BY tuple()",
 		qualifiedName, joinStrings(cols, ",\n"))
@@ -166,12 +177,7 @@ func (d *ClickHouseDriver) CreateTable(ctx context.Context, db *sql.DB, schema,
 
 // DropTable drops a table if it exists.
 func (d *ClickHouseDriver) DropTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	var qualifiedName string
-	if schema == "" {
-		qualifiedName = d.QuoteIdentifier(table)
-	} else {
-		qualifiedName = d.QuoteIdentifier(schema) + "." + d.QuoteIdentifier(table)
-	}
+	qualifiedName := d.Quoting().Qualify(schema, table)
 	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", qualifiedName)
 	if _, err := db.ExecContext(ctx, dropSQL); err != nil {
 		return fmt.Errorf("dropping table: %w", err)
@@ -181,12 +187,7 @@ func (d *ClickHouseDriver) DropTable(ctx context.Context, db *sql.DB, schema, ta
 
 // TruncateTable truncates a table.
 func (d *ClickHouseDriver) TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	var qualifiedName string
-	if schema == "" {
-		qualifiedName = d.QuoteIdentifier(table)
-	} else {
-		qualifiedName = d.QuoteIdentifier(schema) + "." + d.QuoteIdentifier(table)
-	}
+	qualifiedName := d.Quoting().Qualify(schema, table)
 	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s", qualifiedName)
 	if _, err := db.ExecContext(ctx, truncateSQL); err != nil {
 		return fmt.Errorf("truncating table: %w", err)
@@ -194,6 +195,43 @@ func (d *ClickHouseDriver) TruncateTable(ctx context.Context, db *sql.DB, schema
 	return nil
 }
 
+// TableExists reports whether the table exists, in the current database
+// when schema is empty.
+func (d *ClickHouseDriver) TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error) {
+	var n uint64
+	var err error
+	if schema == "" {
+		err = db.QueryRowContext(ctx,
+			"SELECT count() FROM system.tables WHERE database = currentDatabase() AND name = ?",
+			d.Quoting().Name(table)).Scan(&n)
+	} else {
+		err = db.QueryRowContext(ctx,
+			"SELECT count() FROM system.tables WHERE database = ? AND name = ?",
+			d.Quoting().Name(schema), d.Quoting().Name(table)).Scan(&n)
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking table: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NonInsertableColumns returns MATERIALIZED and ALIAS columns, which
+// ClickHouse computes and does not accept in an INSERT, in the current
+// database when schema is empty.
+func (d *ClickHouseDriver) NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error) {
+	q := d.Quoting()
+	if schema == "" {
+		return queryColumnNames(ctx, db,
+			"SELECT name FROM system.columns WHERE database = currentDatabase() AND table = ? "+
+				"AND default_kind IN ('MATERIALIZED', 'ALIAS') ORDER BY position",
+			q.Name(table))
+	}
+	return queryColumnNames(ctx, db,
+		"SELECT name FROM system.columns WHERE database = ? AND table = ? "+
+			"AND default_kind IN ('MATERIALIZED', 'ALIAS') ORDER BY position",
+		q.Name(schema), q.Name(table))
+}
+
 // BulkLoad streams Arrow record batches into a ClickHouse table using batch inserts.
 // The clickhouse-go driver accumulates rows in the prepared statement and sends them
 // as a batch on tx.Commit().
@@ -208,12 +246,7 @@ func (d *ClickHouseDriver) BulkLoad(ctx context.Context, db *sql.DB, params Load
 		placeholders[i] = "?"
 	}
 
-	var qualifiedName string
-	if params.Schema == "" {
-		qualifiedName = d.QuoteIdentifier(params.Table)
-	} else {
-		qualifiedName = d.QuoteIdentifier(params.Schema) + "." + d.QuoteIdentifier(params.Table)
-	}
+	qualifiedName := d.Quoting().Qualify(params.Schema, params.Table)
 
 	insertSQL := fmt.Sprintf("INSERT INTO %s (%s)",
 		qualifiedName, joinStrings(colNames, ", "))
@@ -261,3 +294,12 @@ func (d *ClickHouseDriver) BulkLoad(ctx context.Context, db *sql.DB, params Load
 
 	return totalRows, nil
 }
+
+func (d *ClickHouseDriver) RowInserter(_ context.Context, db *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error) {
+	colNames := make([]string, arrowSchema.NumFields())
+	for i, f := range arrowSchema.Fields() {
+		colNames[i] = d.QuoteIdentifier(f.Name)
+	}
+	return &txInserter{db: db, query: fmt.Sprintf("INSERT INTO %s (%s)",
+		d.Quoting().Qualify(params.Schema, params.Table), joinStrings(colNames, ", "))}, nil
+}
@@ -11,13 +11,21 @@ import (
 )
 
 // MSSQLDriver implements the Driver interface for Microsoft SQL Server.
-type MSSQLDriver struct{}
+type MSSQLDriver struct {
+	Case IdentifierCase // overrides the default (preserve) when set
+}
 
 // DefaultSchema returns the default schema for MSSQL.
 func (d *MSSQLDriver) DefaultSchema() string { return "dbo" }
 
+// Quoting returns MSSQL bracket quoting. SQL Server compares names using
+// the database collation, usually case-insensitively.
+func (d *MSSQLDriver) Quoting() Quoting {
+	return Quoting{Open: "[", Close: "]"}.withCase(d.Case)
+}
+
 // QuoteIdentifier wraps a name in MSSQL bracket-quoting.
-func (d *MSSQLDriver) QuoteIdentifier(name string) string { return "[" + name + "]" }
+func (d *MSSQLDriver) QuoteIdentifier(name string) string { return d.Quoting().Quote(name) }
 
 // ArrowType maps an Arrow data type to a MSSQL column type string.
 func (d *MSSQLDriver) ArrowType(dt arrow.DataType) (string, error) {
@@ -47,6 +55,9 @@ func (d *MSSQLDriver) ArrowType(dt arrow.DataType) (string, error) {
 	case arrow.BOOL:
 		return "BIT", nil
 	case arrow.TIMESTAMP:
+		if timestampZone(dt) != "" {
+			return "DATETIMEOFFSET", nil
+		}
 		return "DATETIME2", nil
 	case arrow.DATE32:
 		return "DATE", nil
@@ -103,9 +114,9 @@ func (d *MSSQLDriver) buildCreateTableDDL(schemaName, tableName string, schema *
 		if f.Nullable {
 			null = "NULL"
 		}
-		cols = append(cols, fmt.Sprintf("    [%s] %s %s", f.Name, sqlType, null))
+		cols = append(cols, fmt.Sprintf("    %s %s %s", d.QuoteIdentifier(f.Name), sqlType, null))
 	}
-	ddl := fmt.Sprintf("CREATE TABLE [%s].[%s] (\n%s\n)", schemaName, tableName, joinStrings(cols, ",\n"))
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n%s\n)", d.Quoting().Qualify(schemaName, tableName), joinStrings(cols, ",\n"))
 	return ddl, nil
 }
 
@@ -123,8 +134,9 @@ func (d *MSSQLDriver) CreateTable(ctx context.Context, db *sql.DB, schema, table
 
 // DropTable drops a table if it exists.
 func (d *MSSQLDriver) DropTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	dropSQL := fmt.Sprintf("IF OBJECT_ID('[%s].[%s]', 'U') IS NOT NULL DROP TABLE [%s].[%s]",
-		schema, table, schema, table)
+	ref := d.Quoting().Qualify(schema, table)
+	dropSQL := fmt.Sprintf("IF OBJECT_ID(N'%s', 'U') IS NOT NULL DROP TABLE %s",
+		strings.ReplaceAll(ref, "'", "''"), ref)
 	if _, err := db.ExecContext(ctx, dropSQL); err != nil {
 		return fmt.Errorf("dropping table: %w", err)
 	}
@@ -133,22 +145,44 @@ func (d *MSSQLDriver) DropTable(ctx context.Context, db *sql.DB, schema, table s
 
 // TruncateTable truncates a table.
 func (d *MSSQLDriver) TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	truncateSQL := fmt.Sprintf("TRUNCATE TABLE [%s].[%s]", schema, table)
+	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s", d.Quoting().Qualify(schema, table))
 	if _, err := db.ExecContext(ctx, truncateSQL); err != nil {
 		return fmt.Errorf("truncating table: %w", err)
 	}
 	return nil
 }
 
+// TableExists reports whether schema.table exists.
+func (d *MSSQLDriver) TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = @p1 AND TABLE_NAME = @p2",
+		d.Quoting().Name(schema), d.Quoting().Name(table)).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("checking table: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NonInsertableColumns returns identity, computed, and rowversion columns,
+// which bulk copy cannot write.
+func (d *MSSQLDriver) NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error) {
+	return queryColumnNames(ctx, db,
+		"SELECT name FROM sys.columns WHERE object_id = OBJECT_ID(@p1) "+
+			"AND (is_identity = 1 OR is_computed = 1 OR system_type_id = 189) ORDER BY column_id",
+		d.Quoting().Qualify(schema, table))
+}
+
 // BulkLoad streams Arrow record batches from the parquetStream into an MSSQL table.
 // Only one row group's worth of data is held in memory at a time.
 func (d *MSSQLDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadParams, stream *parquetStream) (int64, error) {
 	schema := stream.Schema()
 
 	// Build column names from Arrow schema
+	// CopyIn quotes column names itself; only the case rule applies here.
 	colNames := make([]string, schema.NumFields())
 	for i, f := range schema.Fields() {
-		colNames[i] = f.Name
+		colNames[i] = d.Quoting().Name(f.Name)
 	}
 
 	txn, err := db.BeginTx(ctx, nil)
@@ -158,7 +192,7 @@ func (d *MSSQLDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadParam
 	defer txn.Rollback()
 
 	stmt, err := txn.PrepareContext(ctx, mssql.CopyIn(
-		fmt.Sprintf("[%s].[%s]", params.Schema, params.Table),
+		d.Quoting().Qualify(params.Schema, params.Table),
 		mssql.BulkOptions{},
 		colNames...,
 	))
@@ -216,3 +250,46 @@ func joinStrings(elems []string, sep string) string {
 	}
 	return out.String()
 }
+
+func (d *MSSQLDriver) RowInserter(_ context.Context, db *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error) {
+	colNames := make([]string, arrowSchema.NumFields())
+	for i, f := range arrowSchema.Fields() {
+		colNames[i] = d.Quoting().Name(f.Name)
+	}
+	return &mssqlInserter{
+		db:    db,
+		query: mssql.CopyIn(d.Quoting().Qualify(params.Schema, params.Table), mssql.BulkOptions{}, colNames...),
+	}, nil
+}
+
+// mssqlInserter bulk-copies each batch in its own transaction.
+type mssqlInserter struct {
+	db    *sql.DB
+	query string
+}
+
+func (ins *mssqlInserter) InsertRows(ctx context.Context, rows [][]any) error {
+	txn, err := ins.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer txn.Rollback()
+
+	stmt, err := txn.PrepareContext(ctx, ins.query)
+	if err != nil {
+		return fmt.Errorf("preparing bulk copy: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, vals := range rows {
+		if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+			return err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (ins *mssqlInserter) Close() error { return nil }
@@ -10,17 +10,24 @@ import (
 	_ "github.com/sijms/go-ora/v2"
 )
 
-// OracleDriver implements the Driver interface for Oracle Database.
-type OracleDriver struct{}
+// OracleDriver implements the Driver interface for Oracle.
+type OracleDriver struct {
+	Case IdentifierCase // overrides the default (upper) when set
+}
 
 // DefaultSchema returns an empty string; Oracle derives the schema from the connection user.
 func (d *OracleDriver) DefaultSchema() string { return "" }
 
-// QuoteIdentifier wraps a name in double-quote identifiers with upper-casing for Oracle.
-func (d *OracleDriver) QuoteIdentifier(name string) string {
-	return "\"" + strings.ToUpper(name) + "\""
+// Quoting returns Oracle double-quote quoting, upper-casing names by
+// default to match how Oracle stores unquoted identifiers.
+func (d *OracleDriver) Quoting() Quoting {
+	return Quoting{Open: `"`, Close: `"`, Case: CaseUpper}.withCase(d.Case)
 }
 
+// QuoteIdentifier wraps a name in double-quote identifiers, upper-cased by
+// default, for Oracle.
+func (d *OracleDriver) QuoteIdentifier(name string) string { return d.Quoting().Quote(name) }
+
 // ArrowType maps an Arrow data type to an Oracle column type string.
 func (d *OracleDriver) ArrowType(dt arrow.DataType) (string, error) {
 	switch dt.ID() {
@@ -49,6 +56,9 @@ func (d *OracleDriver) ArrowType(dt arrow.DataType) (string, error) {
 	case arrow.BOOL:
 		return "NUMBER(1)", nil
 	case arrow.TIMESTAMP:
+		if timestampZone(dt) != "" {
+			return "TIMESTAMP WITH TIME ZONE", nil
+		}
 		return "TIMESTAMP", nil
 	case arrow.DATE32:
 		return "DATE", nil
@@ -85,11 +95,7 @@ func (d *OracleDriver) SQLTypeToArrow(dbTypeName string) (arrow.DataType, error)
 // qualifiedTable returns a fully qualified table reference for Oracle.
 // If schema is empty, only the quoted table name is returned.
 func (d *OracleDriver) qualifiedTable(schema, table string) string {
-	qt := d.QuoteIdentifier(table)
-	if schema == "" {
-		return qt
-	}
-	return d.QuoteIdentifier(schema) + "." + qt
+	return d.Quoting().Qualify(schema, table)
 }
 
 // buildCreateTableDDL builds a CREATE TABLE statement from an Arrow schema.
@@ -138,6 +144,44 @@ func (d *OracleDriver) DropTable(ctx context.Context, db *sql.DB, schema, table
 	return nil
 }
 
+// TableExists reports whether the table exists, in the connected user's
+// schema when schema is empty. Names are cased as in QuoteIdentifier.
+func (d *OracleDriver) TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error) {
+	var n int
+	var err error
+	if schema == "" {
+		err = db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = :1",
+			d.Quoting().Name(table)).Scan(&n)
+	} else {
+		err = db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM ALL_TABLES WHERE OWNER = :1 AND TABLE_NAME = :2",
+			d.Quoting().Name(schema), d.Quoting().Name(table)).Scan(&n)
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking table: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NonInsertableColumns returns virtual columns and GENERATED ALWAYS
+// identity columns, in the connected user's schema when schema is empty.
+func (d *OracleDriver) NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error) {
+	q := d.Quoting()
+	owner := "SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA')"
+	args := []any{q.Name(table)}
+	if schema != "" {
+		owner = ":2"
+		args = append(args, q.Name(schema))
+	}
+	return queryColumnNames(ctx, db,
+		"SELECT c.COLUMN_NAME FROM ALL_TAB_COLS c WHERE c.TABLE_NAME = :1 AND c.OWNER = "+owner+
+			" AND c.HIDDEN_COLUMN = 'NO' AND (c.VIRTUAL_COLUMN = 'YES' OR EXISTS ("+
+			"SELECT 1 FROM ALL_TAB_IDENTITY_COLS i WHERE i.OWNER = c.OWNER AND i.TABLE_NAME = c.TABLE_NAME "+
+			"AND i.COLUMN_NAME = c.COLUMN_NAME AND i.GENERATION_TYPE = 'ALWAYS')) ORDER BY c.COLUMN_ID",
+		args...)
+}
+
 // TruncateTable truncates a table.
 func (d *OracleDriver) TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error {
 	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s", d.qualifiedTable(schema, table))
@@ -210,3 +254,17 @@ func (d *OracleDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadPara
 
 	return totalRows, nil
 }
+
+func (d *OracleDriver) RowInserter(_ context.Context, db *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error) {
+	colNames := make([]string, arrowSchema.NumFields())
+	placeholders := make([]string, arrowSchema.NumFields())
+	for i, f := range arrowSchema.Fields() {
+		colNames[i] = d.QuoteIdentifier(f.Name)
+		placeholders[i] = fmt.Sprintf(":%d", i+1)
+	}
+	return &txInserter{db: db, query: fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		d.qualifiedTable(params.Schema, params.Table),
+		joinStrings(colNames, ", "),
+		joinStrings(placeholders, ", "),
+	)}, nil
+}
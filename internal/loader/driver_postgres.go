@@ -12,13 +12,22 @@ import (
 )
 
 // PostgresDriver implements the Driver interface for PostgreSQL.
-type PostgresDriver struct{}
+type PostgresDriver struct {
+	Case IdentifierCase // overrides the default (preserve) when set
+}
 
 // DefaultSchema returns the default schema for PostgreSQL.
 func (d *PostgresDriver) DefaultSchema() string { return "public" }
 
+// Quoting returns PostgreSQL double-quote quoting. Names keep their case,
+// so a table created unquoted as my_table must be loaded as my_table; set
+// Case to CaseLower to fold mixed-case names the way PostgreSQL does.
+func (d *PostgresDriver) Quoting() Quoting {
+	return Quoting{Open: `"`, Close: `"`}.withCase(d.Case)
+}
+
 // QuoteIdentifier wraps a name in double-quote identifiers for PostgreSQL.
-func (d *PostgresDriver) QuoteIdentifier(name string) string { return "\"" + name + "\"" }
+func (d *PostgresDriver) QuoteIdentifier(name string) string { return d.Quoting().Quote(name) }
 
 // ArrowType maps an Arrow data type to a PostgreSQL column type string.
 func (d *PostgresDriver) ArrowType(dt arrow.DataType) (string, error) {
@@ -42,6 +51,9 @@ func (d *PostgresDriver) ArrowType(dt arrow.DataType) (string, error) {
 	case arrow.BOOL:
 		return "BOOLEAN", nil
 	case arrow.TIMESTAMP:
+		if timestampZone(dt) != "" {
+			return "TIMESTAMPTZ", nil
+		}
 		return "TIMESTAMP", nil
 	case arrow.DATE32:
 		return "DATE", nil
@@ -96,9 +108,8 @@ func (d *PostgresDriver) buildCreateTableDDL(schemaName, tableName string, schem
 		}
 		cols = append(cols, fmt.Sprintf("    %s %s %s", d.QuoteIdentifier(f.Name), sqlType, null))
 	}
-	ddl := fmt.Sprintf("CREATE TABLE %s.%s (\n%s\n)",
-		d.QuoteIdentifier(schemaName), d.QuoteIdentifier(tableName),
-		joinStrings(cols, ",\n"))
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n%s\n)",
+		d.Quoting().Qualify(schemaName, tableName), joinStrings(cols, ",\n"))
 	return ddl, nil
 }
 
@@ -116,8 +127,7 @@ func (d *PostgresDriver) CreateTable(ctx context.Context, db *sql.DB, schema, ta
 
 // DropTable drops a table if it exists.
 func (d *PostgresDriver) DropTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s.%s",
-		d.QuoteIdentifier(schema), d.QuoteIdentifier(table))
+	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quoting().Qualify(schema, table))
 	if _, err := db.ExecContext(ctx, dropSQL); err != nil {
 		return fmt.Errorf("dropping table: %w", err)
 	}
@@ -126,23 +136,48 @@ func (d *PostgresDriver) DropTable(ctx context.Context, db *sql.DB, schema, tabl
 
 // TruncateTable truncates a table.
 func (d *PostgresDriver) TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error {
-	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s.%s",
-		d.QuoteIdentifier(schema), d.QuoteIdentifier(table))
+	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s", d.Quoting().Qualify(schema, table))
 	if _, err := db.ExecContext(ctx, truncateSQL); err != nil {
 		return fmt.Errorf("truncating table: %w", err)
 	}
 	return nil
 }
 
+// TableExists reports whether schema.table exists.
+func (d *PostgresDriver) TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2",
+		d.Quoting().Name(schema), d.Quoting().Name(table)).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("checking table: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NonInsertableColumns returns generated columns and GENERATED ALWAYS
+// identity columns. BY DEFAULT identity columns accept values, so they are
+// loaded.
+func (d *PostgresDriver) NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error) {
+	q := d.Quoting()
+	return queryColumnNames(ctx, db,
+		"SELECT column_name FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 "+
+			"AND (is_generated = 'ALWAYS' OR identity_generation = 'ALWAYS') ORDER BY ordinal_position",
+		q.Name(schema), q.Name(table))
+}
+
 // BulkLoad streams Arrow record batches into a PostgreSQL table using pgx COPY protocol.
 // It opens a separate pgx native connection for the COPY operation (the db *sql.DB param
-// is used by the shared Load() caller for DDL but is not needed here).
+// is used by the shared Load() caller for DDL but is not needed here). The whole file
+// goes in one COPY, so a failure part way leaves the table as it was.
 func (d *PostgresDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadParams, stream *parquetStream) (int64, error) {
 	schema := stream.Schema()
 
+	// pgx quotes the identifiers itself; only the case rule applies here.
+	q := d.Quoting()
 	colNames := make([]string, schema.NumFields())
 	for i, f := range schema.Fields() {
-		colNames[i] = f.Name
+		colNames[i] = q.Name(f.Name)
 	}
 
 	conn, err := pgx.Connect(ctx, params.ConnStr)
@@ -151,39 +186,100 @@ func (d *PostgresDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadPa
 	}
 	defer conn.Close(ctx)
 
-	var totalRows int64
-	for stream.Next() {
-		rec := stream.Record()
-		numRows := int(rec.NumRows())
-		numCols := int(rec.NumCols())
-
-		rows := make([][]interface{}, numRows)
-		for row := 0; row < numRows; row++ {
-			vals := make([]interface{}, numCols)
-			for col := 0; col < numCols; col++ {
-				v, err := arrowValue(rec.Column(col), row)
-				if err != nil {
-					return totalRows, fmt.Errorf("row %d col %d: %w", row, col, err)
-				}
-				vals[col] = v
+	src := &arrowCopySource{stream: stream}
+	copied, err := conn.CopyFrom(
+		ctx,
+		pgx.Identifier{q.Name(params.Schema), q.Name(params.Table)},
+		colNames,
+		src,
+	)
+	if err != nil {
+		if src.err != nil {
+			return 0, src.err
+		}
+		return 0, fmt.Errorf("copy from: %w", err)
+	}
+	return copied, nil
+}
+
+// arrowCopySource feeds the rows of a parquetStream to pgx's CopyFrom one
+// at a time, so only the current record batch is held in memory.
+type arrowCopySource struct {
+	stream *parquetStream
+	rec    arrow.Record // current batch; nil before the first
+	row    int          // index of the current row in rec
+	rows   int64        // rows read so far, for error messages
+	vals   []any
+	err    error
+}
+
+// Next implements pgx.CopyFromSource.
+func (s *arrowCopySource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.row++
+	for s.rec == nil || s.row >= int(s.rec.NumRows()) {
+		if !s.stream.Next() {
+			if err := s.stream.Err(); err != nil {
+				s.err = fmt.Errorf("reading parquet: %w", err)
 			}
-			rows[row] = vals
+			return false
 		}
+		s.rec, s.row = s.stream.Record(), 0
+	}
 
-		copied, err := conn.CopyFrom(
-			ctx,
-			pgx.Identifier{params.Schema, params.Table},
-			colNames,
-			pgx.CopyFromRows(rows),
-		)
+	numCols := int(s.rec.NumCols())
+	if cap(s.vals) < numCols {
+		s.vals = make([]any, numCols)
+	}
+	s.vals = s.vals[:numCols]
+	for col := range numCols {
+		v, err := arrowValue(s.rec.Column(col), s.row)
 		if err != nil {
-			return totalRows, fmt.Errorf("copy from: %w", err)
+			s.err = fmt.Errorf("row %d col %d: %w", s.rows, col, err)
+			return false
 		}
-		totalRows += copied
+		s.vals[col] = v
+	}
+	s.rows++
+	return true
+}
+
+// Values implements pgx.CopyFromSource.
+func (s *arrowCopySource) Values() ([]any, error) { return s.vals, nil }
+
+// Err implements pgx.CopyFromSource.
+func (s *arrowCopySource) Err() error { return s.err }
+
+func (d *PostgresDriver) RowInserter(ctx context.Context, _ *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error) {
+	q := d.Quoting()
+	colNames := make([]string, arrowSchema.NumFields())
+	for i, f := range arrowSchema.Fields() {
+		colNames[i] = q.Name(f.Name)
 	}
-	if err := stream.Err(); err != nil {
-		return totalRows, fmt.Errorf("reading parquet: %w", err)
+
+	conn, err := pgx.Connect(ctx, params.ConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting via pgx: %w", err)
 	}
+	return &postgresInserter{
+		conn:    conn,
+		table:   pgx.Identifier{q.Name(params.Schema), q.Name(params.Table)},
+		columns: colNames,
+	}, nil
+}
+
+// postgresInserter copies each batch with COPY, which is all-or-nothing.
+type postgresInserter struct {
+	conn    *pgx.Conn
+	table   pgx.Identifier
+	columns []string
+}
 
-	return totalRows, nil
+func (ins *postgresInserter) InsertRows(ctx context.Context, rows [][]any) error {
+	_, err := ins.conn.CopyFrom(ctx, ins.table, ins.columns, pgx.CopyFromRows(rows))
+	return err
 }
+
+func (ins *postgresInserter) Close() error { return ins.conn.Close(context.Background()) }
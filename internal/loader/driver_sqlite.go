@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteDriver implements the Driver interface for SQLite. It is meant for
+// local development and tests (see pkg/pittest), not as a warehouse: loads
+// are plain INSERTs in one transaction.
+type SQLiteDriver struct {
+	Case IdentifierCase // overrides the default (preserve) when set
+}
+
+// DefaultSchema returns "main", the database SQLite opens first.
+func (d *SQLiteDriver) DefaultSchema() string { return "main" }
+
+// Quoting returns SQLite double-quote quoting. SQLite matches names
+// case-insensitively either way.
+func (d *SQLiteDriver) Quoting() Quoting {
+	return Quoting{Open: `"`, Close: `"`}.withCase(d.Case)
+}
+
+// QuoteIdentifier wraps a name in double-quote identifiers for SQLite.
+func (d *SQLiteDriver) QuoteIdentifier(name string) string { return d.Quoting().Quote(name) }
+
+// ArrowType maps an Arrow data type to a SQLite column type string. The
+// declared types are kept specific so that Save can map them back.
+func (d *SQLiteDriver) ArrowType(dt arrow.DataType) (string, error) {
+	switch dt.ID() {
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return "INTEGER", nil
+	case arrow.FLOAT32, arrow.FLOAT64:
+		return "REAL", nil
+	case arrow.STRING, arrow.LARGE_STRING:
+		return "TEXT", nil
+	case arrow.BOOL:
+		return "BOOLEAN", nil
+	case arrow.TIMESTAMP:
+		return "TIMESTAMP", nil
+	case arrow.DATE32:
+		return "DATE", nil
+	case arrow.BINARY:
+		return "BLOB", nil
+	default:
+		return "", fmt.Errorf("unsupported Arrow type %s for SQLite column", dt)
+	}
+}
+
+// SQLTypeToArrow maps a SQLite declared column type to an Arrow data type.
+// Expression columns have no declared type and are read as strings.
+func (d *SQLiteDriver) SQLTypeToArrow(dbTypeName string) (arrow.DataType, error) {
+	switch strings.ToUpper(dbTypeName) {
+	case "INTEGER", "INT", "BIGINT", "SMALLINT", "TINYINT":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "REAL", "DOUBLE", "FLOAT":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "TEXT", "VARCHAR", "CHAR", "", "NUMERIC", "DECIMAL":
+		return arrow.BinaryTypes.String, nil
+	case "BOOLEAN", "BOOL":
+		return arrow.FixedWidthTypes.Boolean, nil
+	case "TIMESTAMP", "DATETIME":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}, nil
+	case "DATE":
+		return arrow.FixedWidthTypes.Date32, nil
+	case "BLOB":
+		return arrow.BinaryTypes.Binary, nil
+	default:
+		return nil, fmt.Errorf("unsupported SQLite type %q for Arrow mapping", dbTypeName)
+	}
+}
+
+// buildCreateTableDDL builds a CREATE TABLE statement from an Arrow schema.
+func (d *SQLiteDriver) buildCreateTableDDL(schemaName, tableName string, schema *arrow.Schema) (string, error) {
+	var cols []string
+	for _, f := range schema.Fields() {
+		sqlType, err := d.ArrowType(f.Type)
+		if err != nil {
+			return "", fmt.Errorf("column %q: %w", f.Name, err)
+		}
+		null := "NOT NULL"
+		if f.Nullable {
+			null = "NULL"
+		}
+		cols = append(cols, fmt.Sprintf("    %s %s %s", d.QuoteIdentifier(f.Name), sqlType, null))
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n%s\n)",
+		d.Quoting().Qualify(schemaName, tableName), joinStrings(cols, ",\n"))
+	return ddl, nil
+}
+
+// CreateTable creates a table in the database from an Arrow schema.
+func (d *SQLiteDriver) CreateTable(ctx context.Context, db *sql.DB, schema, table string, arrowSchema *arrow.Schema) error {
+	ddl, err := d.buildCreateTableDDL(schema, table, arrowSchema)
+	if err != nil {
+		return fmt.Errorf("building create table DDL: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("creating table: %w", err)
+	}
+	return nil
+}
+
+// DropTable drops a table if it exists.
+func (d *SQLiteDriver) DropTable(ctx context.Context, db *sql.DB, schema, table string) error {
+	dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", d.Quoting().Qualify(schema, table))
+	if _, err := db.ExecContext(ctx, dropSQL); err != nil {
+		return fmt.Errorf("dropping table: %w", err)
+	}
+	return nil
+}
+
+// TruncateTable deletes every row of a table; SQLite has no TRUNCATE.
+func (d *SQLiteDriver) TruncateTable(ctx context.Context, db *sql.DB, schema, table string) error {
+	deleteSQL := fmt.Sprintf("DELETE FROM %s", d.Quoting().Qualify(schema, table))
+	if _, err := db.ExecContext(ctx, deleteSQL); err != nil {
+		return fmt.Errorf("truncating table: %w", err)
+	}
+	return nil
+}
+
+// TableExists reports whether schema.table exists.
+func (d *SQLiteDriver) TableExists(ctx context.Context, db *sql.DB, schema, table string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM %s.sqlite_master WHERE type = 'table' AND name = ?", d.QuoteIdentifier(schema)),
+		d.Quoting().Name(table)).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("checking table: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NonInsertableColumns returns generated columns, which pragma
+// table_xinfo marks hidden 2 (virtual) or 3 (stored).
+func (d *SQLiteDriver) NonInsertableColumns(ctx context.Context, db *sql.DB, schema, table string) ([]string, error) {
+	q := d.Quoting()
+	return queryColumnNames(ctx, db,
+		"SELECT name FROM pragma_table_xinfo(?, ?) WHERE hidden IN (2, 3) ORDER BY cid",
+		q.Name(table), q.Name(schema))
+}
+
+// insertSQL builds the INSERT statement for the fields of arrowSchema.
+func (d *SQLiteDriver) insertSQL(params LoadParams, arrowSchema *arrow.Schema) string {
+	colNames := make([]string, arrowSchema.NumFields())
+	placeholders := make([]string, arrowSchema.NumFields())
+	for i, f := range arrowSchema.Fields() {
+		colNames[i] = d.QuoteIdentifier(f.Name)
+		placeholders[i] = "?"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		d.Quoting().Qualify(params.Schema, params.Table),
+		joinStrings(colNames, ", "),
+		joinStrings(placeholders, ", "),
+	)
+}
+
+// BulkLoad inserts the rows of the stream with one prepared statement in a
+// single transaction, so a failure part way leaves the table as it was.
+func (d *SQLiteDriver) BulkLoad(ctx context.Context, db *sql.DB, params LoadParams, stream *parquetStream) (int64, error) {
+	txn, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer txn.Rollback()
+
+	stmt, err := txn.PrepareContext(ctx, d.insertSQL(params, stream.Schema()))
+	if err != nil {
+		return 0, fmt.Errorf("preparing insert: %w", err)
+	}
+	defer stmt.Close()
+
+	var totalRows int64
+	for stream.Next() {
+		rec := stream.Record()
+		numRows := int(rec.NumRows())
+		numCols := int(rec.NumCols())
+
+		vals := make([]any, numCols)
+		for row := range numRows {
+			for col := range numCols {
+				v, err := arrowValue(rec.Column(col), row)
+				if err != nil {
+					return totalRows, fmt.Errorf("row %d col %d: %w", row, col, err)
+				}
+				vals[col] = v
+			}
+			if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+				return totalRows, fmt.Errorf("exec row %d: %w", row, err)
+			}
+		}
+		totalRows += int64(numRows)
+	}
+	if err := stream.Err(); err != nil {
+		return totalRows, fmt.Errorf("reading parquet: %w", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return totalRows, fmt.Errorf("committing transaction: %w", err)
+	}
+	return totalRows, nil
+}
+
+func (d *SQLiteDriver) RowInserter(_ context.Context, db *sql.DB, params LoadParams, arrowSchema *arrow.Schema) (RowInserter, error) {
+	return &txInserter{db: db, query: d.insertSQL(params, arrowSchema)}, nil
+}
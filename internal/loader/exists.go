@@ -0,0 +1,32 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// TableExists reports whether schema.table exists in the database at connStr.
+// An empty schema means the driver's default schema.
+func TableExists(ctx context.Context, connStr, schema, table string) (bool, error) {
+	driverName, err := runner.DetectDriver(connStr)
+	if err != nil {
+		return false, fmt.Errorf("detecting driver: %w", err)
+	}
+	drv, err := GetDriver(driverName)
+	if err != nil {
+		return false, fmt.Errorf("getting driver: %w", err)
+	}
+	if schema == "" {
+		schema = drv.DefaultSchema()
+	}
+
+	db, err := runner.OpenDB(driverName, connStr)
+	if err != nil {
+		return false, fmt.Errorf("opening database connection: %w", err)
+	}
+	defer db.Close()
+
+	return drv.TableExists(ctx, db, schema, table)
+}
@@ -103,7 +103,7 @@ func testRoundTrip(t *testing.T, connStr, schema, table string) {
 	inputPath := writeTestParquet(t, dir, "input.parquet", arrowSchema, rec)
 
 	// Step 2: Load into database with ModeCreateOrReplace
-	rows, err := Load(ctx, LoadParams{
+	res, err := Load(ctx, LoadParams{
 		FilePath: inputPath,
 		Table:    table,
 		Schema:   schema,
@@ -113,8 +113,8 @@ func testRoundTrip(t *testing.T, connStr, schema, table string) {
 	if err != nil {
 		t.Fatalf("Load() error: %v", err)
 	}
-	if rows != 3 {
-		t.Errorf("Load() rows = %d, want 3", rows)
+	if res.Rows != 3 {
+		t.Errorf("Load() rows = %d, want 3", res.Rows)
 	}
 
 	// Step 3: Save back to Parquet via SELECT
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/druarnfield/pit/internal/runner"
 )
 
@@ -13,31 +14,88 @@ type LoadMode string
 
 const (
 	ModeAppend          LoadMode = "append"
+	ModeAppendOrCreate  LoadMode = "append_or_create"
 	ModeTruncateAndLoad LoadMode = "truncate_and_load"
 	ModeCreateOrReplace LoadMode = "create_or_replace"
 )
 
 // LoadParams configures a data load operation.
 type LoadParams struct {
-	FilePath string   // path to the Parquet file
-	Table    string   // target table name
-	Schema   string   // target schema (default depends on driver)
-	Mode     LoadMode // append, truncate_and_load, or create_or_replace
-	ConnStr  string   // database connection string
+	FilePath string         // path to the Parquet file
+	Table    string         // target table name
+	Schema   string         // target schema (default depends on driver)
+	Mode     LoadMode       // append, append_or_create, truncate_and_load, or create_or_replace
+	ConnStr  string         // database connection string
+	Case     IdentifierCase // identifier case rule (default depends on driver)
+	Timezone TimezonePolicy // how timestamps with a time zone are loaded (default: error)
+
+	// ExcludeColumns are Parquet columns not to load. Columns the target
+	// table fills in itself (identity, computed) are excluded automatically
+	// unless the table is being recreated.
+	ExcludeColumns []string
+
+	// RejectFile turns on error tolerance: rows the database refuses are
+	// written to this .csv or .parquet file with the reason, and the rest
+	// are loaded. MaxRejects caps how many rows may be rejected (0 = no
+	// limit). Without a RejectFile one bad row fails the whole load.
+	RejectFile string
+	MaxRejects int
+}
+
+// LoadResult reports the outcome of a load.
+type LoadResult struct {
+	Rows       int64  // rows loaded
+	Rejected   int64  // rows written to the reject file
+	RejectFile string // set when Rejected > 0
 }
 
 // Load reads a Parquet file and bulk-loads it into the target database.
 // Data is streamed one row group at a time to keep memory usage steady.
-// Returns the number of rows loaded.
-func Load(ctx context.Context, params LoadParams) (int64, error) {
+// With params.RejectFile set, rows the database rejects are set aside
+// rather than failing the load; see loadTolerant.
+func Load(ctx context.Context, params LoadParams) (LoadResult, error) {
+	drv, driverName, params, err := resolveLoad(params)
+	if err != nil {
+		return LoadResult{}, err
+	}
+
+	stream, err := openParquetStream(ctx, params.FilePath)
+	if err != nil {
+		return LoadResult{}, fmt.Errorf("reading parquet file: %w", err)
+	}
+	defer stream.Close()
+
+	t, err := connectTarget(ctx, drv, driverName, params)
+	if err != nil {
+		return LoadResult{}, err
+	}
+	defer t.db.Close()
+
+	if err := t.prepareStream(stream); err != nil {
+		return LoadResult{}, err
+	}
+	if err := t.prepareTable(ctx, stream.Schema()); err != nil {
+		return LoadResult{}, err
+	}
+
+	if params.RejectFile != "" {
+		return loadTolerant(ctx, drv, t.db, params, stream)
+	}
+	rows, err := drv.BulkLoad(ctx, t.db, params, stream)
+	return LoadResult{Rows: rows}, err
+}
+
+// resolveLoad picks the driver for params and fills in and checks the
+// schema, mode, and reject file.
+func resolveLoad(params LoadParams) (Driver, string, LoadParams, error) {
 	driverName, err := runner.DetectDriver(params.ConnStr)
 	if err != nil {
-		return 0, fmt.Errorf("detecting driver: %w", err)
+		return nil, "", params, fmt.Errorf("detecting driver: %w", err)
 	}
 
-	drv, err := GetDriver(driverName)
+	drv, err := NewDriver(driverName, params.Case)
 	if err != nil {
-		return 0, fmt.Errorf("getting driver: %w", err)
+		return nil, "", params, fmt.Errorf("getting driver: %w", err)
 	}
 
 	if params.Schema == "" {
@@ -47,38 +105,91 @@ func Load(ctx context.Context, params LoadParams) (int64, error) {
 		params.Mode = ModeAppend
 	}
 	switch params.Mode {
-	case ModeAppend, ModeTruncateAndLoad, ModeCreateOrReplace:
+	case ModeAppend, ModeAppendOrCreate, ModeTruncateAndLoad, ModeCreateOrReplace:
 		// valid
 	default:
-		return 0, fmt.Errorf("unsupported load mode %q (must be append, truncate_and_load, or create_or_replace)", params.Mode)
+		return nil, "", params, fmt.Errorf("unsupported load mode %q (must be append, append_or_create, truncate_and_load, or create_or_replace)", params.Mode)
 	}
+	if params.RejectFile != "" {
+		if err := ValidateRejectFile(params.RejectFile); err != nil {
+			return nil, "", params, err
+		}
+	}
+	return drv, driverName, params, nil
+}
 
-	stream, err := openParquetStream(ctx, params.FilePath)
+// loadTarget is an open connection to a load's target table along with
+// the Parquet columns that must not be loaded into it.
+type loadTarget struct {
+	drv     Driver
+	db      *sql.DB
+	params  LoadParams
+	exclude []string
+	create  bool // append_or_create found no table; prepareTable creates it
+}
+
+// connectTarget opens the database, checks that the table exists unless
+// the mode creates it, and works out which columns to skip:
+// params.ExcludeColumns plus, for an existing table, the columns it fills
+// in itself. The caller closes t.db.
+func connectTarget(ctx context.Context, drv Driver, driverName string, params LoadParams) (*loadTarget, error) {
+	db, err := runner.OpenDB(driverName, params.ConnStr)
 	if err != nil {
-		return 0, fmt.Errorf("reading parquet file: %w", err)
+		return nil, fmt.Errorf("opening database connection: %w", err)
+	}
+	t := &loadTarget{drv: drv, db: db, params: params, exclude: params.ExcludeColumns}
+	if params.Mode == ModeCreateOrReplace {
+		return t, nil
 	}
-	defer stream.Close()
 
-	db, err := sql.Open(driverName, params.ConnStr)
+	exists, err := drv.TableExists(ctx, db, params.Schema, params.Table)
 	if err != nil {
-		return 0, fmt.Errorf("opening database connection: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("checking table exists: %w", err)
 	}
-	defer db.Close()
-
-	if params.Mode == ModeCreateOrReplace {
-		if err := drv.DropTable(ctx, db, params.Schema, params.Table); err != nil {
-			return 0, err
-		}
-		if err := drv.CreateTable(ctx, db, params.Schema, params.Table, stream.Schema()); err != nil {
-			return 0, err
+	if !exists {
+		if params.Mode != ModeAppendOrCreate {
+			db.Close()
+			return nil, fmt.Errorf("table %s does not exist; create it first or use mode %q",
+				drv.Quoting().Qualify(params.Schema, params.Table), ModeAppendOrCreate)
 		}
+		t.create = true
+		return t, nil
 	}
 
-	if params.Mode == ModeTruncateAndLoad {
-		if err := drv.TruncateTable(ctx, db, params.Schema, params.Table); err != nil {
-			return 0, err
-		}
+	generated, err := drv.NonInsertableColumns(ctx, db, params.Schema, params.Table)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	t.exclude = append(t.exclude[:len(t.exclude):len(t.exclude)], generated...)
+	return t, nil
+}
+
+// prepareStream drops the excluded columns from stream and applies the
+// time zone policy.
+func (t *loadTarget) prepareStream(stream *parquetStream) error {
+	if err := stream.dropColumns(t.exclude); err != nil {
+		return err
 	}
+	return stream.applyTimezonePolicy(t.params.Timezone)
+}
 
-	return drv.BulkLoad(ctx, db, params, stream)
+// prepareTable creates, recreates, or truncates the table as the load
+// mode asks. schema is used for any table it creates.
+func (t *loadTarget) prepareTable(ctx context.Context, schema *arrow.Schema) error {
+	p := t.params
+	if t.create {
+		return t.drv.CreateTable(ctx, t.db, p.Schema, p.Table, schema)
+	}
+	switch p.Mode {
+	case ModeCreateOrReplace:
+		if err := t.drv.DropTable(ctx, t.db, p.Schema, p.Table); err != nil {
+			return err
+		}
+		return t.drv.CreateTable(ctx, t.db, p.Schema, p.Table, schema)
+	case ModeTruncateAndLoad:
+		return t.drv.TruncateTable(ctx, t.db, p.Schema, p.Table)
+	}
+	return nil
 }
@@ -1,15 +1,19 @@
 package loader
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 )
 
@@ -475,9 +479,9 @@ func TestGetDriver_MSSQL(t *testing.T) {
 }
 
 func TestGetDriver_Unknown(t *testing.T) {
-	_, err := GetDriver("sqlite")
+	_, err := GetDriver("mysql")
 	if err == nil {
-		t.Fatal("GetDriver(\"sqlite\") expected error, got nil")
+		t.Fatal("GetDriver(\"mysql\") expected error, got nil")
 	}
 	if !containsStr(err.Error(), "unsupported database driver") {
 		t.Errorf("error = %q, want it to contain %q", err, "unsupported database driver")
@@ -857,3 +861,294 @@ func TestOracleDriver_BuildCreateTableDDL_NoSchema(t *testing.T) {
 		t.Errorf("DDL should use unqualified table when schema is empty, got:\n%s", ddl)
 	}
 }
+
+func TestParquetStream_DropColumns(t *testing.T) {
+	pool := memory.DefaultAllocator
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "ID", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "total", Type: arrow.PrimitiveTypes.Float64},
+	}, nil)
+	builder := array.NewRecordBuilder(pool, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.Int32Builder).AppendValues([]int32{1, 2}, nil)
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+	builder.Field(2).(*array.Float64Builder).AppendValues([]float64{1.5, 2.5}, nil)
+	rec := builder.NewRecord()
+	defer rec.Release()
+	path := writeTestParquet(t, t.TempDir(), "cols.parquet", schema, rec)
+
+	stream, err := openParquetStream(context.Background(), path)
+	if err != nil {
+		t.Fatalf("openParquetStream() error: %v", err)
+	}
+	defer stream.Close()
+
+	// Matching is case-insensitive; unknown names are ignored.
+	if err := stream.dropColumns([]string{"id", "TOTAL", "missing"}); err != nil {
+		t.Fatalf("dropColumns() error: %v", err)
+	}
+	if got := stream.Schema().NumFields(); got != 1 || stream.Schema().Field(0).Name != "name" {
+		t.Fatalf("schema after dropColumns = %v, want only name", stream.Schema())
+	}
+	if !stream.Next() {
+		t.Fatalf("Next() = false: %v", stream.Err())
+	}
+	got := stream.Record()
+	if got.NumCols() != 1 || got.Column(0).(*array.String).Value(1) != "b" {
+		t.Errorf("record = %v, want the name column only", got)
+	}
+
+	if err := stream.dropColumns([]string{"name"}); err == nil {
+		t.Error("dropColumns() of every column expected error, got nil")
+	}
+}
+
+func TestSameColumns(t *testing.T) {
+	schema := func(fields ...arrow.Field) *arrow.Schema { return arrow.NewSchema(fields, nil) }
+	id := arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64}
+	name := arrow.Field{Name: "name", Type: arrow.BinaryTypes.String}
+	want := schema(id, name)
+
+	tests := []struct {
+		name    string
+		got     *arrow.Schema
+		wantErr string
+	}{
+		{"same", schema(id, name), ""},
+		{"nullability differs", schema(id, arrow.Field{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true}), ""},
+		{"missing column", schema(id), "has columns (id), want (id, name)"},
+		{"reordered", schema(name, id), "has columns (name, id)"},
+		{"type differs", schema(arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int32}, name), `column "id" is int32, want int64`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sameColumns(want, tt.got)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("sameColumns() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("sameColumns() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDataset_Rejects(t *testing.T) {
+	ctx := t.Context()
+	params := LoadParams{Table: "t", ConnStr: "postgres://localhost/db"}
+	if _, err := LoadDataset(ctx, params, nil, 2); err == nil || !strings.Contains(err.Error(), "no files") {
+		t.Errorf("LoadDataset(no files) error = %v, want no files", err)
+	}
+	params.RejectFile = "rejects.csv"
+	if _, err := LoadDataset(ctx, params, []string{"a.parquet"}, 2); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("LoadDataset(reject file) error = %v, want not supported", err)
+	}
+}
+
+// tableDriver reports whether its table exists and records what
+// connectTarget and prepareTable do with it.
+type tableDriver struct {
+	Driver
+	exists  bool
+	created bool
+}
+
+func (d *tableDriver) TableExists(context.Context, *sql.DB, string, string) (bool, error) {
+	return d.exists, nil
+}
+
+func (d *tableDriver) NonInsertableColumns(context.Context, *sql.DB, string, string) ([]string, error) {
+	return []string{"id"}, nil
+}
+
+func (d *tableDriver) CreateTable(context.Context, *sql.DB, string, string, *arrow.Schema) error {
+	d.created = true
+	return nil
+}
+
+func (d *tableDriver) Quoting() Quoting { return Quoting{Open: "[", Close: "]"} }
+
+func TestConnectTarget_TableExistence(t *testing.T) {
+	ctx := t.Context()
+	params := LoadParams{Schema: "dbo", Table: "orders", ConnStr: "sqlserver://localhost"}
+
+	for _, mode := range []LoadMode{ModeAppend, ModeTruncateAndLoad} {
+		params.Mode = mode
+		_, err := connectTarget(ctx, &tableDriver{}, "mssql", params)
+		if err == nil || !strings.Contains(err.Error(), "table [dbo].[orders] does not exist") || !strings.Contains(err.Error(), `"append_or_create"`) {
+			t.Errorf("%s into missing table: error = %v, want does not exist", mode, err)
+		}
+	}
+
+	params.Mode = ModeAppendOrCreate
+	drv := &tableDriver{}
+	target, err := connectTarget(ctx, drv, "mssql", params)
+	if err != nil {
+		t.Fatalf("append_or_create into missing table: %v", err)
+	}
+	defer target.db.Close()
+	if len(target.exclude) != 0 {
+		t.Errorf("exclude = %v, want none for a new table", target.exclude)
+	}
+	if err := target.prepareTable(ctx, arrow.NewSchema(nil, nil)); err != nil {
+		t.Fatalf("prepareTable() error: %v", err)
+	}
+	if !drv.created {
+		t.Error("append_or_create did not create the missing table")
+	}
+
+	drv = &tableDriver{exists: true}
+	target, err = connectTarget(ctx, drv, "mssql", params)
+	if err != nil {
+		t.Fatalf("append_or_create into existing table: %v", err)
+	}
+	defer target.db.Close()
+	if err := target.prepareTable(ctx, arrow.NewSchema(nil, nil)); err != nil {
+		t.Fatalf("prepareTable() error: %v", err)
+	}
+	if drv.created || len(target.exclude) != 1 {
+		t.Errorf("existing table: created = %v, exclude = %v; want no create and generated columns excluded", drv.created, target.exclude)
+	}
+}
+
+func TestArrowCopySource(t *testing.T) {
+	pool := memory.DefaultAllocator
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(pool, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2, 3, 4, 5}, nil)
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "", "c", "d", "e"}, []bool{true, false, true, true, true})
+	rec := builder.NewRecord()
+	defer rec.Release()
+
+	// Row groups of two rows, so the source crosses record batches
+	path := filepath.Join(t.TempDir(), "batches.parquet")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := pqarrow.NewFileWriter(schema, f, parquet.NewWriterProperties(parquet.WithMaxRowGroupLength(2)),
+		pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Write(rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := openParquetStream(context.Background(), path)
+	if err != nil {
+		t.Fatalf("openParquetStream() error: %v", err)
+	}
+	defer stream.Close()
+
+	src := &arrowCopySource{stream: stream}
+	var got []string
+	for src.Next() {
+		vals, err := src.Values()
+		if err != nil {
+			t.Fatalf("Values() error: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%v/%v", vals...))
+	}
+	if err := src.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	want := "1/a 2/<nil> 3/c 4/d 5/e"
+	if strings.Join(got, " ") != want {
+		t.Errorf("rows = %q, want %q", strings.Join(got, " "), want)
+	}
+	if src.Next() {
+		t.Error("Next() after the last row = true, want false")
+	}
+}
+
+func TestSQLiteDriver_ArrowType(t *testing.T) {
+	d := &SQLiteDriver{}
+	tests := []struct {
+		dt   arrow.DataType
+		want string
+	}{
+		{arrow.PrimitiveTypes.Int32, "INTEGER"},
+		{arrow.PrimitiveTypes.Uint64, "INTEGER"},
+		{arrow.PrimitiveTypes.Float32, "REAL"},
+		{arrow.BinaryTypes.String, "TEXT"},
+		{arrow.FixedWidthTypes.Boolean, "BOOLEAN"},
+		{&arrow.TimestampType{Unit: arrow.Microsecond}, "TIMESTAMP"},
+		{arrow.FixedWidthTypes.Date32, "DATE"},
+		{arrow.BinaryTypes.Binary, "BLOB"},
+	}
+	for _, tt := range tests {
+		got, err := d.ArrowType(tt.dt)
+		if err != nil {
+			t.Fatalf("ArrowType(%s) error: %v", tt.dt, err)
+		}
+		if got != tt.want {
+			t.Errorf("ArrowType(%s) = %q, want %q", tt.dt, got, tt.want)
+		}
+		// The declared type maps back to an Arrow type for Save
+		if _, err := d.SQLTypeToArrow(got); err != nil {
+			t.Errorf("SQLTypeToArrow(%q) error: %v", got, err)
+		}
+	}
+}
+
+func TestLoad_SQLite(t *testing.T) {
+	pool := memory.DefaultAllocator
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(pool, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2, 3}, nil)
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "b", ""}, []bool{true, true, false})
+	rec := builder.NewRecord()
+	defer rec.Release()
+
+	dir := t.TempDir()
+	path := writeTestParquet(t, dir, "rows.parquet", schema, rec)
+	params := LoadParams{
+		FilePath: path,
+		Table:    "rows",
+		ConnStr:  "sqlite://" + filepath.Join(dir, "test.db"),
+		Mode:     ModeAppendOrCreate,
+	}
+	for range 2 {
+		if _, err := Load(t.Context(), params); err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+	}
+
+	exists, err := TableExists(t.Context(), params.ConnStr, "", "rows")
+	if err != nil || !exists {
+		t.Fatalf("TableExists() = %v, %v; want true", exists, err)
+	}
+	params.Mode = ModeTruncateAndLoad
+	res, err := Load(t.Context(), params)
+	if err != nil {
+		t.Fatalf("truncate_and_load: %v", err)
+	}
+	if res.Rows != 3 {
+		t.Errorf("truncate_and_load loaded %d rows, want 3", res.Rows)
+	}
+
+	out := filepath.Join(dir, "out.parquet")
+	n, err := Save(t.Context(), SaveParams{Query: "SELECT id, name FROM rows WHERE name IS NOT NULL", FilePath: out, ConnStr: params.ConnStr})
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Save() wrote %d rows, want 2", n)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
@@ -28,6 +29,10 @@ type parquetStream struct {
 	curTR  *array.TableReader // current batch reader within the row group
 	curRec arrow.Record       // most recent record from Record()
 	err    error
+
+	// stripZones relabels zoned timestamp columns as zone-free; see
+	// applyTimezonePolicy. curRec is then owned by the stream.
+	stripZones bool
 }
 
 // openParquetStream opens a Parquet file for streaming reads.
@@ -68,16 +73,54 @@ func openParquetStream(ctx context.Context, filePath string) (*parquetStream, er
 	return &parquetStream{ctx: ctx, file: f, pf: pf, reader: reader, schema: schema, colIndices: colIndices}, nil
 }
 
+// dropColumns removes the named columns from the stream so they are neither
+// read nor loaded. Names are matched case-insensitively, as most databases
+// compare column names that way. Must be called before the first Next.
+func (ps *parquetStream) dropColumns(names []string) error {
+	drop := make(map[string]bool, len(names))
+	for _, n := range names {
+		drop[strings.ToLower(n)] = true
+	}
+
+	var (
+		fields  []arrow.Field
+		indices []int
+		dropped []string
+	)
+	for i, f := range ps.schema.Fields() {
+		if drop[strings.ToLower(f.Name)] {
+			dropped = append(dropped, f.Name)
+			continue
+		}
+		fields = append(fields, f)
+		indices = append(indices, ps.colIndices[i])
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no columns left to load after excluding %s", strings.Join(dropped, ", "))
+	}
+	meta := ps.schema.Metadata()
+	ps.schema = arrow.NewSchema(fields, &meta)
+	ps.colIndices = indices
+	return nil
+}
+
 // Schema returns the Arrow schema of the Parquet file.
 func (ps *parquetStream) Schema() *arrow.Schema { return ps.schema }
 
 // Next advances to the next record batch. Returns false when exhausted or on error.
 // The previous batch's memory is released when Next is called again.
 func (ps *parquetStream) Next() bool {
+	ps.releaseOwned()
 	for {
 		// Try the current row group's batch reader first
 		if ps.curTR != nil && ps.curTR.Next() {
 			ps.curRec = ps.curTR.Record()
+			if ps.stripZones {
+				ps.curRec = ps.withSchema(ps.curRec)
+			}
 			return true
 		}
 
@@ -114,8 +157,18 @@ func (ps *parquetStream) Record() arrow.Record { return ps.curRec }
 // Err returns any error encountered during iteration.
 func (ps *parquetStream) Err() error { return ps.err }
 
+// releaseOwned releases curRec if the stream built it rather than the
+// batch reader.
+func (ps *parquetStream) releaseOwned() {
+	if ps.stripZones && ps.curRec != nil {
+		ps.curRec.Release()
+	}
+	ps.curRec = nil
+}
+
 // Close releases all resources held by the stream.
 func (ps *parquetStream) Close() {
+	ps.releaseOwned()
 	if ps.curTR != nil {
 		ps.curTR.Release()
 	}
@@ -172,7 +225,16 @@ func arrowValue(col arrow.Array, idx int) (interface{}, error) {
 	case *boolArray:
 		return c.Value(idx), nil
 	case *timestampArray:
-		return c.Value(idx).ToTime(c.DataType().(*arrow.TimestampType).Unit), nil
+		dt := c.DataType().(*arrow.TimestampType)
+		t := c.Value(idx).ToTime(dt.Unit)
+		if dt.TimeZone != "" {
+			loc, err := timeLocation(dt.TimeZone)
+			if err != nil {
+				return nil, err
+			}
+			t = t.In(loc)
+		}
+		return t, nil
 	case *date32Array:
 		return c.Value(idx).ToTime(), nil
 	case *binaryArray:
@@ -0,0 +1,60 @@
+package loader
+
+import "strings"
+
+// IdentifierCase controls how schema, table, and column names are cased
+// before they are quoted. Quoted identifiers are case-sensitive in
+// PostgreSQL, Oracle, and Snowflake, so a name has to match the case the
+// object was created with.
+type IdentifierCase string
+
+const (
+	CaseDefault  IdentifierCase = ""         // the driver's default
+	CasePreserve IdentifierCase = "preserve" // names exactly as given
+	CaseLower    IdentifierCase = "lower"    // how PostgreSQL folds unquoted names
+	CaseUpper    IdentifierCase = "upper"    // how Oracle and Snowflake fold unquoted names
+)
+
+// Quoting is a database's strategy for writing identifiers into SQL.
+type Quoting struct {
+	Open, Close string         // delimiters, e.g. "[" and "]"
+	Case        IdentifierCase // CaseDefault behaves as CasePreserve
+}
+
+// Name applies the case rule to name without quoting it, for drivers that
+// pass identifiers to an API that quotes them itself.
+func (q Quoting) Name(name string) string {
+	switch q.Case {
+	case CaseLower:
+		return strings.ToLower(name)
+	case CaseUpper:
+		return strings.ToUpper(name)
+	default:
+		return name
+	}
+}
+
+// Quote returns name cased and delimited. A closing delimiter inside the
+// name is doubled, which every supported database reads as a literal.
+func (q Quoting) Quote(name string) string {
+	name = strings.ReplaceAll(q.Name(name), q.Close, q.Close+q.Close)
+	return q.Open + name + q.Close
+}
+
+// Qualify returns the quoted schema.table reference, or just the quoted
+// table when schema is empty.
+func (q Quoting) Qualify(schema, table string) string {
+	if schema == "" {
+		return q.Quote(table)
+	}
+	return q.Quote(schema) + "." + q.Quote(table)
+}
+
+// withCase returns q with its case rule replaced by c, unless c is
+// CaseDefault.
+func (q Quoting) withCase(c IdentifierCase) Quoting {
+	if c != CaseDefault {
+		q.Case = c
+	}
+	return q
+}
@@ -0,0 +1,53 @@
+package loader
+
+import "testing"
+
+func TestQuoting(t *testing.T) {
+	brackets := Quoting{Open: "[", Close: "]"}
+	double := Quoting{Open: `"`, Close: `"`}
+	tests := []struct {
+		name   string
+		q      Quoting
+		schema string
+		table  string
+		want   string
+	}{
+		{name: "brackets", q: brackets, schema: "dbo", table: "Orders", want: "[dbo].[Orders]"},
+		{name: "no schema", q: brackets, table: "Orders", want: "[Orders]"},
+		{name: "escaped close", q: brackets, schema: "dbo", table: "odd]name", want: "[dbo].[odd]]name]"},
+		{name: "escaped quote", q: double, schema: "public", table: `say "hi"`, want: `"public"."say ""hi"""`},
+		{name: "lower", q: Quoting{Open: `"`, Close: `"`, Case: CaseLower}, schema: "Sales", table: "Orders", want: `"sales"."orders"`},
+		{name: "upper", q: Quoting{Open: `"`, Close: `"`, Case: CaseUpper}, schema: "sales", table: "orders", want: `"SALES"."ORDERS"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Qualify(tt.schema, tt.table); got != tt.want {
+				t.Errorf("Qualify(%q, %q) = %s, want %s", tt.schema, tt.table, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDriver_IdentifierCase(t *testing.T) {
+	tests := []struct {
+		driver string
+		c      IdentifierCase
+		want   string
+	}{
+		{driver: "oracle", c: CaseDefault, want: `"MY_TABLE"`},
+		{driver: "oracle", c: CasePreserve, want: `"My_Table"`},
+		{driver: "postgres", c: CaseDefault, want: `"My_Table"`},
+		{driver: "postgres", c: CaseLower, want: `"my_table"`},
+		{driver: "mssql", c: CaseUpper, want: "[MY_TABLE]"},
+		{driver: "clickhouse", c: CaseDefault, want: "`My_Table`"},
+	}
+	for _, tt := range tests {
+		d, err := NewDriver(tt.driver, tt.c)
+		if err != nil {
+			t.Fatalf("NewDriver(%q) error: %v", tt.driver, err)
+		}
+		if got := d.QuoteIdentifier("My_Table"); got != tt.want {
+			t.Errorf("%s with case %q: QuoteIdentifier() = %s, want %s", tt.driver, tt.c, got, tt.want)
+		}
+	}
+}
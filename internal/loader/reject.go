@@ -0,0 +1,308 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// RejectErrorColumn is the column of a reject file that holds the reason
+// each row was rejected.
+const RejectErrorColumn = "_pit_error"
+
+// ValidateRejectFile checks that path names a reject file format pit can
+// write: .csv or .parquet.
+func ValidateRejectFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".parquet":
+		return nil
+	default:
+		return fmt.Errorf("reject file %q must end in .csv or .parquet", path)
+	}
+}
+
+// RowInserter writes batches of rows to a load's target table. Each call
+// to InsertRows commits the whole batch or none of it, so a failed batch
+// can be retried in smaller pieces.
+type RowInserter interface {
+	InsertRows(ctx context.Context, rows [][]any) error
+	Close() error
+}
+
+// loadTolerant loads the stream batch by batch, diverting rows the
+// database rejects to a reject file instead of failing the load. A batch
+// that fails is split in halves until the offending rows are isolated, so
+// every good row is committed. The load fails once more than
+// params.MaxRejects rows (when set) have been rejected; rows committed by
+// then stay loaded.
+func loadTolerant(ctx context.Context, drv Driver, db *sql.DB, params LoadParams, stream *parquetStream) (LoadResult, error) {
+	result := LoadResult{}
+
+	ins, err := drv.RowInserter(ctx, db, params, stream.Schema())
+	if err != nil {
+		return result, err
+	}
+	defer ins.Close()
+
+	rw := newRejectWriter(params.RejectFile, params.MaxRejects, stream.Schema())
+	defer rw.close()
+
+	// A load where nothing gets in is a problem with the table or the
+	// connection, not with the data; don't write every row off as bad.
+	checkFirstBatch := true
+
+	for stream.Next() {
+		rec := stream.Record()
+		numRows := int(rec.NumRows())
+		numCols := int(rec.NumCols())
+
+		rows := make([][]any, 0, numRows)
+		source := make([]int, 0, numRows) // record row of each entry in rows
+		var rejectErr error
+	rowLoop:
+		for row := range numRows {
+			vals := make([]any, numCols)
+			for col := range numCols {
+				v, err := arrowValue(rec.Column(col), row)
+				if err != nil {
+					err = fmt.Errorf("column %q: %w", rec.ColumnName(col), err)
+					if rejectErr = rw.add(rec, row, err); rejectErr != nil {
+						break rowLoop
+					}
+					continue rowLoop
+				}
+				vals[col] = v
+			}
+			rows = append(rows, vals)
+			source = append(source, row)
+		}
+		if rejectErr != nil {
+			return result.with(rw), rejectErr
+		}
+
+		var lastErr error
+		loaded, err := insertIsolating(ctx, ins, rows, func(i int, err error) error {
+			lastErr = err
+			return rw.add(rec, source[i], err)
+		})
+		result.Rows += loaded
+		if err != nil {
+			return result.with(rw), err
+		}
+		if checkFirstBatch && loaded == 0 && lastErr != nil {
+			return result.with(rw), fmt.Errorf("every row of the first batch was rejected: %w", lastErr)
+		}
+		checkFirstBatch = false
+	}
+	if err := stream.Err(); err != nil {
+		return result.with(rw), fmt.Errorf("reading parquet: %w", err)
+	}
+
+	if err := rw.close(); err != nil {
+		return result.with(rw), err
+	}
+	return result.with(rw), nil
+}
+
+// with fills in the reject counts from rw.
+func (r LoadResult) with(rw *rejectWriter) LoadResult {
+	r.Rejected = rw.count
+	if rw.count > 0 {
+		r.RejectFile = rw.path
+	}
+	return r
+}
+
+// insertIsolating inserts rows and returns how many were committed. When
+// a batch fails it is split in halves and each half retried, down to
+// single rows; a single row that still fails is passed to reject with the
+// database's error. Errors from reject or a cancelled ctx stop the load.
+func insertIsolating(ctx context.Context, ins RowInserter, rows [][]any, reject func(i int, err error) error) (int64, error) {
+	var insert func(lo, hi int) (int64, error)
+	insert = func(lo, hi int) (int64, error) {
+		if lo == hi {
+			return 0, nil
+		}
+		err := ins.InsertRows(ctx, rows[lo:hi])
+		if err == nil {
+			return int64(hi - lo), nil
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if hi-lo == 1 {
+			return 0, reject(lo, err)
+		}
+		mid := lo + (hi-lo)/2
+		n, err := insert(lo, mid)
+		if err != nil {
+			return n, err
+		}
+		m, err := insert(mid, hi)
+		return n + m, err
+	}
+	return insert(0, len(rows))
+}
+
+// rejectWriter records rejected rows in a CSV or Parquet file (chosen by
+// extension) with every column as text plus RejectErrorColumn. The file is
+// only created once the first row is rejected.
+type rejectWriter struct {
+	path   string
+	max    int           // rejects allowed before the load fails (0 = no limit)
+	schema *arrow.Schema // source columns
+	count  int64
+
+	file *os.File
+	csv  *csv.Writer
+
+	pq      *pqarrow.FileWriter
+	builder *array.RecordBuilder
+	pending int
+}
+
+func newRejectWriter(path string, max int, schema *arrow.Schema) *rejectWriter {
+	return &rejectWriter{path: path, max: max, schema: schema}
+}
+
+// add records row of rec as rejected because of reason. It fails once
+// more rows than the writer's limit have been rejected.
+func (w *rejectWriter) add(rec arrow.Record, row int, reason error) error {
+	if err := w.write(rec, row, reason); err != nil {
+		return err
+	}
+	if w.max > 0 && w.count > int64(w.max) {
+		return fmt.Errorf("more than max_rejects (%d) rows rejected; see %s", w.max, w.path)
+	}
+	return nil
+}
+
+func (w *rejectWriter) write(rec arrow.Record, row int, reason error) error {
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	w.count++
+
+	if w.csv != nil {
+		fields := make([]string, 0, rec.NumCols()+1)
+		for _, col := range rec.Columns() {
+			if col.IsNull(row) {
+				fields = append(fields, "")
+			} else {
+				fields = append(fields, col.ValueStr(row))
+			}
+		}
+		fields = append(fields, reason.Error())
+		if err := w.csv.Write(fields); err != nil {
+			return fmt.Errorf("writing reject file: %w", err)
+		}
+		return nil
+	}
+
+	for i, col := range rec.Columns() {
+		b := w.builder.Field(i).(*array.StringBuilder)
+		if col.IsNull(row) {
+			b.AppendNull()
+		} else {
+			b.Append(col.ValueStr(row))
+		}
+	}
+	w.builder.Field(int(rec.NumCols())).(*array.StringBuilder).Append(reason.Error())
+	w.pending++
+	if w.pending >= saveBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *rejectWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return fmt.Errorf("creating reject file directory: %w", err)
+	}
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("creating reject file: %w", err)
+	}
+
+	header := make([]string, 0, w.schema.NumFields()+1)
+	for _, f := range w.schema.Fields() {
+		header = append(header, f.Name)
+	}
+	header = append(header, RejectErrorColumn)
+
+	if strings.EqualFold(filepath.Ext(w.path), ".csv") {
+		w.file = f
+		w.csv = csv.NewWriter(f)
+		if err := w.csv.Write(header); err != nil {
+			return fmt.Errorf("writing reject file: %w", err)
+		}
+		return nil
+	}
+
+	fields := make([]arrow.Field, len(header))
+	for i, name := range header {
+		fields[i] = arrow.Field{Name: name, Type: arrow.BinaryTypes.String, Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	writer, err := pqarrow.NewFileWriter(schema, f, nil, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("creating reject file writer: %w", err)
+	}
+	w.file = f
+	w.pq = writer
+	w.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	return nil
+}
+
+// flush writes buffered Parquet rows as a row group.
+func (w *rejectWriter) flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+	w.pending = 0
+	if err := w.pq.Write(rec); err != nil {
+		return fmt.Errorf("writing reject file: %w", err)
+	}
+	return nil
+}
+
+// close finishes the reject file, if one was started. It is safe to call
+// more than once.
+func (w *rejectWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	var errs []error
+	if w.csv != nil {
+		w.csv.Flush()
+		errs = append(errs, w.csv.Error())
+	}
+	if w.pq != nil {
+		errs = append(errs, w.flush())
+		w.builder.Release()
+		// The Parquet writer closes the file itself.
+		errs = append(errs, w.pq.Close())
+	} else {
+		errs = append(errs, w.file.Close())
+	}
+	w.file, w.csv, w.pq, w.builder = nil, nil, nil, nil
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("closing reject file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// fakeInserter fails any batch containing a row whose first value is in
+// bad, the way a database rejects a whole batch for one bad row.
+type fakeInserter struct {
+	bad      map[int64]bool
+	inserted []int64
+	calls    int
+}
+
+func (f *fakeInserter) InsertRows(_ context.Context, rows [][]any) error {
+	f.calls++
+	for _, r := range rows {
+		if id := r[0].(int64); f.bad[id] {
+			return fmt.Errorf("bad id %d", id)
+		}
+	}
+	for _, r := range rows {
+		f.inserted = append(f.inserted, r[0].(int64))
+	}
+	return nil
+}
+
+func (f *fakeInserter) Close() error { return nil }
+
+// fakeDriver hands out a fakeInserter; it supports nothing else.
+type fakeDriver struct {
+	Driver
+	ins *fakeInserter
+}
+
+func (d *fakeDriver) RowInserter(context.Context, *sql.DB, LoadParams, *arrow.Schema) (RowInserter, error) {
+	return d.ins, nil
+}
+
+func TestInsertIsolating(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		bad  []int64
+	}{
+		{"all good", 8, nil},
+		{"one bad", 8, []int64{5}},
+		{"first and last bad", 7, []int64{0, 6}},
+		{"adjacent bad", 10, []int64{3, 4}},
+		{"all bad", 3, []int64{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ins := &fakeInserter{bad: map[int64]bool{}}
+			for _, id := range tt.bad {
+				ins.bad[id] = true
+			}
+			rows := make([][]any, tt.n)
+			for i := range rows {
+				rows[i] = []any{int64(i)}
+			}
+
+			var rejected []int64
+			loaded, err := insertIsolating(context.Background(), ins, rows, func(i int, err error) error {
+				if !strings.Contains(err.Error(), fmt.Sprintf("bad id %d", i)) {
+					t.Errorf("row %d rejected with %v", i, err)
+				}
+				rejected = append(rejected, int64(i))
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("insertIsolating() error: %v", err)
+			}
+			if want := int64(tt.n - len(tt.bad)); loaded != want || int64(len(ins.inserted)) != want {
+				t.Errorf("loaded = %d, inserted %v, want %d rows", loaded, ins.inserted, want)
+			}
+			if fmt.Sprint(rejected) != fmt.Sprint(append([]int64{}, tt.bad...)) {
+				t.Errorf("rejected = %v, want %v", rejected, tt.bad)
+			}
+			if len(tt.bad) == 0 && ins.calls != 1 {
+				t.Errorf("calls = %d, want a single batch when nothing fails", ins.calls)
+			}
+		})
+	}
+}
+
+func TestInsertIsolating_RejectErrorStops(t *testing.T) {
+	ins := &fakeInserter{bad: map[int64]bool{1: true, 2: true}}
+	rows := [][]any{{int64(0)}, {int64(1)}, {int64(2)}, {int64(3)}}
+	_, err := insertIsolating(context.Background(), ins, rows, func(int, error) error {
+		return fmt.Errorf("too many")
+	})
+	if err == nil || err.Error() != "too many" {
+		t.Fatalf("insertIsolating() error = %v, want the reject error", err)
+	}
+}
+
+// writeRejectTestParquet writes ids 0..n-1 with a name column where
+// every third name is null.
+func writeRejectTestParquet(t *testing.T, n int) string {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for i := range n {
+		builder.Field(0).(*array.Int64Builder).Append(int64(i))
+		if i%3 == 0 {
+			builder.Field(1).(*array.StringBuilder).AppendNull()
+		} else {
+			builder.Field(1).(*array.StringBuilder).Append(fmt.Sprintf("row %d", i))
+		}
+	}
+	rec := builder.NewRecord()
+	defer rec.Release()
+	return writeTestParquet(t, t.TempDir(), "input.parquet", schema, rec)
+}
+
+func runTolerant(t *testing.T, n int, bad []int64, params LoadParams) (LoadResult, error) {
+	t.Helper()
+	stream, err := openParquetStream(context.Background(), writeRejectTestParquet(t, n))
+	if err != nil {
+		t.Fatalf("openParquetStream() error: %v", err)
+	}
+	defer stream.Close()
+	ins := &fakeInserter{bad: map[int64]bool{}}
+	for _, id := range bad {
+		ins.bad[id] = true
+	}
+	return loadTolerant(context.Background(), &fakeDriver{ins: ins}, nil, params, stream)
+}
+
+func TestLoadTolerant_CSV(t *testing.T) {
+	rejectPath := filepath.Join(t.TempDir(), "rejects.csv")
+	res, err := runTolerant(t, 10, []int64{3, 7}, LoadParams{RejectFile: rejectPath})
+	if err != nil {
+		t.Fatalf("loadTolerant() error: %v", err)
+	}
+	if res.Rows != 8 || res.Rejected != 2 || res.RejectFile != rejectPath {
+		t.Errorf("result = %+v, want 8 loaded, 2 rejected to %s", res, rejectPath)
+	}
+
+	f, err := os.Open(rejectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading reject file: %v", err)
+	}
+	want := [][]string{
+		{"id", "name", RejectErrorColumn},
+		{"3", "", "bad id 3"},
+		{"7", "row 7", "bad id 7"},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("reject file = %v, want %v", records, want)
+	}
+}
+
+func TestLoadTolerant_Parquet(t *testing.T) {
+	rejectPath := filepath.Join(t.TempDir(), "rejects.parquet")
+	res, err := runTolerant(t, 10, []int64{4}, LoadParams{RejectFile: rejectPath})
+	if err != nil {
+		t.Fatalf("loadTolerant() error: %v", err)
+	}
+	if res.Rows != 9 || res.Rejected != 1 {
+		t.Errorf("result = %+v, want 9 loaded, 1 rejected", res)
+	}
+
+	records, schema, err := readParquet(rejectPath)
+	if err != nil {
+		t.Fatalf("reading reject file: %v", err)
+	}
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+	if got := schema.Field(2).Name; got != RejectErrorColumn {
+		t.Errorf("last column = %q, want %q", got, RejectErrorColumn)
+	}
+	if len(records) != 1 || records[0].NumRows() != 1 {
+		t.Fatalf("reject file has %d records, want one row", len(records))
+	}
+	rec := records[0]
+	if id := rec.Column(0).(*array.String).Value(0); id != "4" {
+		t.Errorf("id = %q, want \"4\"", id)
+	}
+	if reason := rec.Column(2).(*array.String).Value(0); reason != "bad id 4" {
+		t.Errorf("%s = %q, want \"bad id 4\"", RejectErrorColumn, reason)
+	}
+}
+
+func TestLoadTolerant_NoRejectsNoFile(t *testing.T) {
+	rejectPath := filepath.Join(t.TempDir(), "rejects.csv")
+	res, err := runTolerant(t, 5, nil, LoadParams{RejectFile: rejectPath})
+	if err != nil {
+		t.Fatalf("loadTolerant() error: %v", err)
+	}
+	if res.Rows != 5 || res.Rejected != 0 || res.RejectFile != "" {
+		t.Errorf("result = %+v, want 5 loaded and no rejects", res)
+	}
+	if _, err := os.Stat(rejectPath); !os.IsNotExist(err) {
+		t.Errorf("reject file exists (err = %v), want none without rejects", err)
+	}
+}
+
+func TestLoadTolerant_MaxRejects(t *testing.T) {
+	rejectPath := filepath.Join(t.TempDir(), "rejects.csv")
+	res, err := runTolerant(t, 10, []int64{1, 2, 8}, LoadParams{RejectFile: rejectPath, MaxRejects: 2})
+	if err == nil || !strings.Contains(err.Error(), "max_rejects (2)") {
+		t.Fatalf("loadTolerant() error = %v, want max_rejects error", err)
+	}
+	if res.Rejected != 3 {
+		t.Errorf("rejected = %d, want 3", res.Rejected)
+	}
+}
+
+func TestLoadTolerant_AllRejected(t *testing.T) {
+	rejectPath := filepath.Join(t.TempDir(), "rejects.csv")
+	_, err := runTolerant(t, 3, []int64{0, 1, 2}, LoadParams{RejectFile: rejectPath})
+	if err == nil || !strings.Contains(err.Error(), "every row of the first batch was rejected") {
+		t.Fatalf("loadTolerant() error = %v, want first-batch error", err)
+	}
+}
+
+func TestValidateRejectFile(t *testing.T) {
+	for _, path := range []string{"rejects.csv", "out/rejects.parquet", "R.CSV"} {
+		if err := ValidateRejectFile(path); err != nil {
+			t.Errorf("ValidateRejectFile(%q) error: %v", path, err)
+		}
+	}
+	for _, path := range []string{"rejects.json", "rejects"} {
+		if err := ValidateRejectFile(path); err == nil {
+			t.Errorf("ValidateRejectFile(%q) = nil, want error", path)
+		}
+	}
+}
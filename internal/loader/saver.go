@@ -2,7 +2,6 @@ package loader
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -31,7 +30,7 @@ func Save(ctx context.Context, params SaveParams) (int64, error) {
 		return 0, fmt.Errorf("getting driver: %w", err)
 	}
 
-	db, err := sql.Open(driverName, params.ConnStr)
+	db, err := runner.OpenDB(driverName, params.ConnStr)
 	if err != nil {
 		return 0, fmt.Errorf("opening database connection: %w", err)
 	}
@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/druarnfield/pit/internal/runner"
+)
+
+// ScratchTable is a table created for a run's intermediate data.
+type ScratchTable struct {
+	Schema    string // resolved schema, never empty
+	Table     string
+	Qualified string // quoted schema.table for use in SQL
+}
+
+// CreateScratchTable creates schema.table at connStr with columns, column
+// definitions in the database's own SQL such as "id int, name
+// varchar(100)". With no columns, no table is created and only the name is
+// resolved, for a load that creates it. An empty schema means the
+// driver's default schema.
+func CreateScratchTable(ctx context.Context, connStr, schema, table, columns string, identCase IdentifierCase) (ScratchTable, error) {
+	drv, driverName, schema, err := scratchDriver(connStr, schema, identCase)
+	if err != nil {
+		return ScratchTable{}, err
+	}
+	st := ScratchTable{Schema: schema, Table: table, Qualified: drv.Quoting().Qualify(schema, table)}
+	if columns == "" {
+		return st, nil
+	}
+
+	db, err := runner.OpenDB(driverName, connStr)
+	if err != nil {
+		return ScratchTable{}, fmt.Errorf("opening database connection: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", st.Qualified, columns)); err != nil {
+		return ScratchTable{}, fmt.Errorf("creating table %s: %w", st.Qualified, err)
+	}
+	return st, nil
+}
+
+// DropScratchTable drops schema.table at connStr if it exists.
+func DropScratchTable(ctx context.Context, connStr, schema, table string, identCase IdentifierCase) error {
+	drv, driverName, schema, err := scratchDriver(connStr, schema, identCase)
+	if err != nil {
+		return err
+	}
+	db, err := runner.OpenDB(driverName, connStr)
+	if err != nil {
+		return fmt.Errorf("opening database connection: %w", err)
+	}
+	defer db.Close()
+	return drv.DropTable(ctx, db, schema, table)
+}
+
+// scratchDriver returns the driver for connStr and schema, defaulted to
+// the driver's.
+func scratchDriver(connStr, schema string, identCase IdentifierCase) (Driver, string, string, error) {
+	driverName, err := runner.DetectDriver(connStr)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("detecting driver: %w", err)
+	}
+	drv, err := NewDriver(driverName, identCase)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("getting driver: %w", err)
+	}
+	if schema == "" {
+		schema = drv.DefaultSchema()
+	}
+	return drv, driverName, schema, nil
+}
@@ -0,0 +1,133 @@
+package loader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+)
+
+// TimezonePolicy says how timestamp columns that carry a time zone are
+// loaded. Timestamps without a time zone are loaded as they are under every
+// policy.
+type TimezonePolicy string
+
+const (
+	TimezoneError  TimezonePolicy = "error"  // refuse to load them (the default)
+	TimezoneOffset TimezonePolicy = "offset" // keep the offset, e.g. DATETIMEOFFSET or TIMESTAMPTZ
+	TimezoneUTC    TimezonePolicy = "utc"    // convert to UTC and load as plain timestamps
+)
+
+// applyTimezonePolicy checks the stream's zoned timestamp columns against
+// policy. Loading them into a plain timestamp column would silently drop
+// the offset, so unless a policy says otherwise they are an error. Under
+// TimezoneUTC the zone is stripped from the schema and from every record,
+// so drivers see UTC wall-clock timestamps.
+func (ps *parquetStream) applyTimezonePolicy(policy TimezonePolicy) error {
+	var zoned []string
+	for _, f := range ps.schema.Fields() {
+		if tz := timestampZone(f.Type); tz != "" {
+			zoned = append(zoned, fmt.Sprintf("%s (%s)", f.Name, tz))
+			if _, err := timeLocation(tz); err != nil {
+				return fmt.Errorf("column %q: %w", f.Name, err)
+			}
+		}
+	}
+	if len(zoned) == 0 {
+		return nil
+	}
+
+	switch policy {
+	case "", TimezoneError:
+		return fmt.Errorf("timestamp columns with a time zone: %s; set timezone_policy to \"offset\" to keep the offset or \"utc\" to convert to UTC",
+			strings.Join(zoned, ", "))
+	case TimezoneOffset:
+		return nil
+	case TimezoneUTC:
+		fields := ps.schema.Fields()
+		for i, f := range fields {
+			if timestampZone(f.Type) != "" {
+				fields[i].Type = &arrow.TimestampType{Unit: f.Type.(*arrow.TimestampType).Unit}
+			}
+		}
+		meta := ps.schema.Metadata()
+		ps.schema = arrow.NewSchema(fields, &meta)
+		ps.stripZones = true
+		return nil
+	default:
+		return fmt.Errorf("unsupported timezone policy %q (must be error, offset, or utc)", policy)
+	}
+}
+
+// withSchema returns rec relabelled with ps.schema. It shares rec's
+// buffers; only zoned timestamp columns get a new (zone-free) type.
+func (ps *parquetStream) withSchema(rec arrow.Record) arrow.Record {
+	cols := make([]arrow.Array, rec.NumCols())
+	for i, col := range rec.Columns() {
+		want := ps.schema.Field(i).Type
+		if arrow.TypeEqual(col.DataType(), want) {
+			col.Retain()
+			cols[i] = col
+			continue
+		}
+		d := col.Data()
+		nd := array.NewData(want, d.Len(), d.Buffers(), d.Children(), d.NullN(), d.Offset())
+		cols[i] = array.MakeFromData(nd)
+		nd.Release()
+	}
+	out := array.NewRecord(ps.schema, cols, rec.NumRows())
+	for _, c := range cols {
+		c.Release()
+	}
+	return out
+}
+
+// timestampZone returns the time zone of a timestamp type, or "" for
+// other types and zone-less timestamps.
+func timestampZone(dt arrow.DataType) string {
+	if ts, ok := dt.(*arrow.TimestampType); ok {
+		return ts.TimeZone
+	}
+	return ""
+}
+
+var locations sync.Map // Arrow time zone string → *time.Location
+
+// timeLocation resolves an Arrow time zone: an IANA name such as
+// "Europe/London" or a fixed offset such as "+05:30".
+func timeLocation(tz string) (*time.Location, error) {
+	if loc, ok := locations.Load(tz); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := parseTimeZone(tz)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(tz, loc)
+	return loc, nil
+}
+
+func parseTimeZone(tz string) (*time.Location, error) {
+	if tz[0] != '+' && tz[0] != '-' {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", tz)
+		}
+		return loc, nil
+	}
+	h, m, ok := strings.Cut(tz[1:], ":")
+	hours, herr := strconv.Atoi(h)
+	mins, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hours > 23 || mins > 59 {
+		return nil, fmt.Errorf("invalid time zone offset %q (want ±HH:MM)", tz)
+	}
+	secs := hours*3600 + mins*60
+	if tz[0] == '-' {
+		secs = -secs
+	}
+	return time.FixedZone(tz, secs), nil
+}
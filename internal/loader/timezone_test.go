@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// writeZonedParquet writes one zoned and one plain timestamp column holding
+// 2025-01-15 12:00 UTC.
+func writeZonedParquet(t *testing.T, tz string) string {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "created_at", Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: tz}},
+		{Name: "loaded_at", Type: &arrow.TimestampType{Unit: arrow.Microsecond}},
+	}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	ts := arrow.Timestamp(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC).UnixMicro())
+	builder.Field(0).(*array.TimestampBuilder).Append(ts)
+	builder.Field(1).(*array.TimestampBuilder).Append(ts)
+	rec := builder.NewRecord()
+	defer rec.Release()
+	return writeTestParquet(t, t.TempDir(), "zoned.parquet", schema, rec)
+}
+
+func openZoned(t *testing.T, tz string, policy TimezonePolicy) (*parquetStream, error) {
+	t.Helper()
+	stream, err := openParquetStream(context.Background(), writeZonedParquet(t, tz))
+	if err != nil {
+		t.Fatalf("openParquetStream() error: %v", err)
+	}
+	t.Cleanup(stream.Close)
+	return stream, stream.applyTimezonePolicy(policy)
+}
+
+func TestApplyTimezonePolicy_Error(t *testing.T) {
+	for _, policy := range []TimezonePolicy{"", TimezoneError} {
+		_, err := openZoned(t, "Europe/Paris", policy)
+		if err == nil || !strings.Contains(err.Error(), "created_at (Europe/Paris)") {
+			t.Errorf("policy %q: error = %v, want the zoned column named", policy, err)
+		}
+	}
+	if _, err := openZoned(t, "Mars/Olympus", TimezoneOffset); err == nil || !strings.Contains(err.Error(), "unknown time zone") {
+		t.Errorf("unknown zone: error = %v, want unknown time zone", err)
+	}
+	if _, err := openZoned(t, "UTC", "local"); err == nil || !strings.Contains(err.Error(), "unsupported timezone policy") {
+		t.Errorf("bad policy: error = %v, want unsupported timezone policy", err)
+	}
+}
+
+func TestApplyTimezonePolicy_Offset(t *testing.T) {
+	stream, err := openZoned(t, "+05:30", TimezoneOffset)
+	if err != nil {
+		t.Fatalf("applyTimezonePolicy() error: %v", err)
+	}
+	if got, _ := (&MSSQLDriver{}).ArrowType(stream.Schema().Field(0).Type); got != "DATETIMEOFFSET" {
+		t.Errorf("MSSQL type = %s, want DATETIMEOFFSET", got)
+	}
+	if !stream.Next() {
+		t.Fatalf("Next() = false: %v", stream.Err())
+	}
+	v, err := arrowValue(stream.Record().Column(0), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := v.(time.Time)
+	if got.Format(time.RFC3339) != "2025-01-15T17:30:00+05:30" {
+		t.Errorf("value = %s, want the instant in +05:30", got.Format(time.RFC3339))
+	}
+}
+
+func TestApplyTimezonePolicy_UTC(t *testing.T) {
+	stream, err := openZoned(t, "America/New_York", TimezoneUTC)
+	if err != nil {
+		t.Fatalf("applyTimezonePolicy() error: %v", err)
+	}
+	if tz := timestampZone(stream.Schema().Field(0).Type); tz != "" {
+		t.Errorf("schema zone = %q, want none", tz)
+	}
+	if got, _ := (&PostgresDriver{}).ArrowType(stream.Schema().Field(0).Type); got != "TIMESTAMP" {
+		t.Errorf("PostgreSQL type = %s, want TIMESTAMP", got)
+	}
+	if !stream.Next() {
+		t.Fatalf("Next() = false: %v", stream.Err())
+	}
+	v, err := arrowValue(stream.Record().Column(0), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.(time.Time); !got.Equal(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)) || got.Location() != time.UTC {
+		t.Errorf("value = %v, want 2025-01-15 12:00 UTC", got)
+	}
+}
+
+func TestTimeLocation(t *testing.T) {
+	tests := []struct {
+		tz      string
+		offset  int
+		wantErr bool
+	}{
+		{tz: "UTC", offset: 0},
+		{tz: "+05:30", offset: 5*3600 + 30*60},
+		{tz: "-08:00", offset: -8 * 3600},
+		{tz: "+5", wantErr: true},
+		{tz: "+25:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		loc, err := timeLocation(tt.tz)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("timeLocation(%q) expected error", tt.tz)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("timeLocation(%q) error: %v", tt.tz, err)
+			continue
+		}
+		if _, off := time.Date(2025, 1, 15, 0, 0, 0, 0, loc).Zone(); off != tt.offset {
+			t.Errorf("timeLocation(%q) offset = %d, want %d", tt.tz, off, tt.offset)
+		}
+	}
+}
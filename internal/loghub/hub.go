@@ -3,6 +3,8 @@ package loghub
 import (
 	"sync"
 	"time"
+
+	"github.com/druarnfield/pit/internal/runid"
 )
 
 // Entry is a structured log line emitted by a task execution.
@@ -136,7 +138,7 @@ func (h *Hub) ActiveRunForDAG(dagName string) string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for runID := range h.active {
-		if len(runID) > 20 && runID[20:] == dagName {
+		if name, err := runid.DAGName(runID); err == nil && name == dagName {
 			return runID
 		}
 	}
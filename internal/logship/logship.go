@@ -0,0 +1,215 @@
+// Package logship sends pit serve's run and task events to external log
+// sinks — a JSONL file, a syslog server, or Grafana Loki — so runs on many
+// hosts can be followed in one place without reading each runs directory.
+//
+// Records are queued and sent in the background; a slow or unreachable
+// sink never holds up a run. When the queue is full, records are dropped
+// and the count is logged.
+package logship
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// Event names shipped by pit serve.
+const (
+	EventRunStarted   = "run_started"
+	EventRunFinished  = "run_finished"
+	EventTaskFinished = "task_finished"
+	EventWarning      = "warning" // a stalled or unusually slow task
+)
+
+// Levels of shipped records.
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// queueSize is how many records may wait to be sent before new ones are dropped.
+const queueSize = 1024
+
+// maxBatch is the most records handed to a sink at once.
+const maxBatch = 100
+
+// sendTimeout bounds one batch sent to one sink.
+const sendTimeout = 10 * time.Second
+
+// Record is one shipped event.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Level    string    `json:"level"`
+	Event    string    `json:"event"`
+	Host     string    `json:"host,omitempty"`
+	DAG      string    `json:"dag"`
+	RunID    string    `json:"run_id,omitempty"`
+	Task     string    `json:"task,omitempty"`
+	Trigger  string    `json:"trigger,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Duration float64   `json:"duration_seconds,omitempty"`
+	Attempts int       `json:"attempts,omitempty"`
+	Failure  string    `json:"failure,omitempty"` // classified cause, e.g. "exit_code (exit status 1)"
+	Lines    []string  `json:"lines,omitempty"`   // relevant task log lines of a failure
+	Message  string    `json:"message,omitempty"`
+}
+
+// Sink is a destination for records.
+type Sink interface {
+	// Name identifies the sink in warnings, e.g. "loki http://loki:3100".
+	Name() string
+	Send(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// Shipper queues records and sends them to its sinks in the background.
+// A nil *Shipper is valid and discards records.
+type Shipper struct {
+	sinks []Sink
+	host  string
+	queue chan Record
+	done  chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+// New starts a shipper sending to sinks.
+func New(sinks ...Sink) *Shipper {
+	host, _ := os.Hostname()
+	s := &Shipper{
+		sinks: sinks,
+		host:  host,
+		queue: make(chan Record, queueSize),
+		done:  make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+// Open builds the sinks in cfgs and starts a shipper for them. It returns
+// nil when cfgs is empty.
+func Open(cfgs []config.LogSinkConfig) (*Shipper, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	var sinks []Sink
+	for _, c := range cfgs {
+		sink, err := NewSink(c)
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return New(sinks...), nil
+}
+
+// NewSink builds the sink described by c.
+func NewSink(c config.LogSinkConfig) (Sink, error) {
+	switch c.Type {
+	case "file":
+		return OpenFile(c.Path)
+	case "syslog":
+		return NewSyslog(c.Address, c.Tag), nil
+	case "loki":
+		return &Loki{URL: c.URL, Tenant: c.Tenant, Labels: c.Labels}, nil
+	default:
+		return nil, fmt.Errorf("unknown log sink type %q", c.Type)
+	}
+}
+
+// Ship queues r. It never blocks: if the queue is full, r is dropped.
+// Time and Host are filled in when unset.
+func (s *Shipper) Ship(r Record) {
+	if s == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	if r.Host == "" {
+		r.Host = s.host
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- r:
+	default:
+		s.dropped++
+	}
+}
+
+// Close sends the records still queued, then closes every sink.
+func (s *Shipper) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+
+	var firstErr error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("closing log sink %s: %w", sink.Name(), err)
+		}
+	}
+	return firstErr
+}
+
+func (s *Shipper) loop() {
+	defer close(s.done)
+	for r := range s.queue {
+		batch := []Record{r}
+	fill:
+		for len(batch) < maxBatch {
+			select {
+			case r, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, r)
+			default:
+				break fill
+			}
+		}
+		s.send(batch)
+	}
+}
+
+func (s *Shipper) send(batch []Record) {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("warning: log shipping queue full, dropped %d record(s)", dropped)
+	}
+
+	for _, sink := range s.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		if err := sink.Send(ctx, batch); err != nil {
+			log.Printf("warning: log sink %s: %v", sink.Name(), err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,175 @@
+package logship
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+var testTime = time.Date(2024, 1, 15, 14, 30, 22, 0, time.UTC)
+
+func TestShipper_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "pit.jsonl")
+	s, err := Open([]config.LogSinkConfig{{Type: "file", Path: path}})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	s.Ship(Record{Level: LevelInfo, Event: EventRunStarted, DAG: "etl", RunID: "r1"})
+	s.Ship(Record{Level: LevelError, Event: EventTaskFinished, DAG: "etl", RunID: "r1", Task: "load", Status: "failed", Failure: "exit_code (exit status 1)"})
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	s.Ship(Record{Event: EventRunFinished}) // dropped after Close
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), data)
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(lines[1]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Task != "load" || r.Failure != "exit_code (exit status 1)" || r.Time.IsZero() {
+		t.Errorf("record = %+v", r)
+	}
+}
+
+func TestShipper_NilDiscards(t *testing.T) {
+	var s *Shipper
+	s.Ship(Record{Event: EventRunStarted})
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() error: %v", err)
+	}
+	if s, err := Open(nil); s != nil || err != nil {
+		t.Errorf("Open(nil) = %v, %v, want nil, nil", s, err)
+	}
+}
+
+func TestSyslog_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp not available: %v", err)
+	}
+	defer pc.Close()
+
+	sink := NewSyslog("udp://"+pc.LocalAddr().String(), "")
+	defer sink.Close()
+	r := Record{Time: testTime, Level: LevelWarning, Event: EventWarning, Host: "etl01", DAG: "etl", Message: "load produced no output for 15m0s"}
+	if err := sink.Send(context.Background(), []Record{r}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	want := "<132>1 2024-01-15T14:30:22Z etl01 pit - warning - {"
+	if !strings.HasPrefix(msg, want) || !strings.Contains(msg, `"message":"load produced no output for 15m0s"`) {
+		t.Errorf("message = %q, want prefix %q", msg, want)
+	}
+}
+
+func TestSyslog_TCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp not available: %v", err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		got <- string(data)
+	}()
+
+	sink := NewSyslog("tcp://"+ln.Addr().String(), "etl")
+	r := Record{Time: testTime, Level: LevelInfo, Event: EventRunStarted, DAG: "etl"}
+	if err := sink.Send(context.Background(), []Record{r, r}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	sink.Close()
+
+	data := <-got
+	msg, _ := sink.format(r)
+	want := strings.Repeat(strconv.Itoa(len(msg))+" "+string(msg), 2)
+	if data != want {
+		t.Errorf("stream = %q, want %q", data, want)
+	}
+}
+
+func TestLoki_Push(t *testing.T) {
+	var body struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	var path, tenant string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, tenant = r.URL.Path, r.Header.Get("X-Scope-OrgID")
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding push: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := &Loki{URL: srv.URL, Tenant: "data", Labels: map[string]string{"env": "prod"}}
+	err := sink.Send(context.Background(), []Record{
+		{Time: testTime.Add(time.Second), Level: LevelInfo, Event: EventRunFinished, DAG: "etl"},
+		{Time: testTime, Level: LevelInfo, Event: EventRunStarted, DAG: "etl"},
+		{Time: testTime, Level: LevelError, Event: EventTaskFinished, DAG: "etl", Task: "load"},
+	})
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	if path != lokiPushPath || tenant != "data" {
+		t.Errorf("path = %q tenant = %q", path, tenant)
+	}
+	if len(body.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2: %+v", len(body.Streams), body.Streams)
+	}
+	info := body.Streams[0]
+	if info.Stream["job"] != "pit" || info.Stream["dag"] != "etl" || info.Stream["level"] != LevelInfo || info.Stream["env"] != "prod" {
+		t.Errorf("labels = %v", info.Stream)
+	}
+	if len(info.Values) != 2 || !strings.Contains(info.Values[0][1], EventRunStarted) {
+		t.Errorf("values = %v, want run_started first", info.Values)
+	}
+}
+
+func TestLoki_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry out of order", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sink := &Loki{URL: srv.URL + "/custom/push"}
+	err := sink.Send(context.Background(), []Record{{Time: testTime, Event: EventRunStarted}})
+	if err == nil || !strings.Contains(err.Error(), "entry out of order") {
+		t.Errorf("Send() error = %v, want loki error body", err)
+	}
+	if sink.pushURL() != srv.URL+"/custom/push" {
+		t.Errorf("pushURL() = %q, want URL unchanged", sink.pushURL())
+	}
+}
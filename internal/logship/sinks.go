@@ -0,0 +1,248 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File appends records to a JSONL file.
+type File struct {
+	mu   sync.Mutex
+	f    *os.File
+	path string
+}
+
+// OpenFile opens (or creates) path for appending records.
+func OpenFile(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating log sink dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening log sink %q: %w", path, err)
+	}
+	return &File{f: f, path: path}, nil
+}
+
+func (f *File) Name() string { return "file " + f.path }
+
+func (f *File) Send(_ context.Context, records []Record) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encoding record: %w", err)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err := f.f.Write(buf.Bytes())
+	return err
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
+
+// syslogFacility is local0, the facility every record is sent with.
+const syslogFacility = 16
+
+// syslogSeverity maps record levels to syslog severities.
+var syslogSeverity = map[string]int{
+	LevelError:   3,
+	LevelWarning: 4,
+	LevelInfo:    6,
+}
+
+// Syslog sends each record as an RFC 5424 message whose body is the
+// record's JSON. Over TCP, messages are octet-counted (RFC 6587). It does
+// not use log/syslog, which is unavailable on Windows.
+type Syslog struct {
+	network string // "udp" or "tcp"
+	addr    string
+	tag     string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslog returns a sink for address, "udp://host:port" or
+// "tcp://host:port" (no scheme means UDP). tag is the syslog app name
+// ("" = "pit"). The connection is made on first send.
+func NewSyslog(address, tag string) *Syslog {
+	network, addr := "udp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = scheme, rest
+	}
+	if tag == "" {
+		tag = "pit"
+	}
+	return &Syslog{network: network, addr: addr, tag: tag}
+}
+
+func (s *Syslog) Name() string { return "syslog " + s.network + "://" + s.addr }
+
+func (s *Syslog) Send(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, s.network, s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+	for _, r := range records {
+		msg, err := s.format(r)
+		if err != nil {
+			return err
+		}
+		if s.network == "tcp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			// Redial on the next send
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// format renders r as "<PRI>1 TIMESTAMP HOST APP - MSGID - JSON".
+func (s *Syslog) format(r Record) ([]byte, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("encoding record: %w", err)
+	}
+	sev, ok := syslogSeverity[r.Level]
+	if !ok {
+		sev = syslogSeverity[LevelInfo]
+	}
+	host := r.Host
+	if host == "" {
+		host = "-"
+	}
+	head := fmt.Sprintf("<%d>1 %s %s %s - %s - ",
+		syslogFacility*8+sev, r.Time.Format(time.RFC3339Nano), host, s.tag, r.Event)
+	return append([]byte(head), body...), nil
+}
+
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// lokiPushPath is the push API path appended to a Loki base URL.
+const lokiPushPath = "/loki/api/v1/push"
+
+// Loki pushes records to Grafana Loki. Each record's JSON is one log line
+// in a stream labelled job="pit" plus host, dag, and level, and Labels.
+type Loki struct {
+	URL    string            // base URL ("http://loki:3100") or full push endpoint
+	Tenant string            // X-Scope-OrgID header ("" = none)
+	Labels map[string]string // extra stream labels
+	HTTP   *http.Client      // nil = a client with a 10s timeout
+}
+
+func (l *Loki) Name() string { return "loki " + l.URL }
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (l *Loki) Send(ctx context.Context, records []Record) error {
+	body, err := json.Marshal(map[string][]*lokiStream{"streams": l.streams(records)})
+	if err != nil {
+		return fmt.Errorf("encoding loki push: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.pushURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building loki request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.Tenant != "" {
+		req.Header.Set("X-Scope-OrgID", l.Tenant)
+	}
+
+	hc := l.HTTP
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing to loki: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pushing to loki: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// streams groups records by their labels, oldest first within a stream
+// as Loki requires.
+func (l *Loki) streams(records []Record) []*lokiStream {
+	byKey := make(map[string]*lokiStream)
+	var out []*lokiStream
+	sorted := append([]Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	for _, r := range sorted {
+		key := r.Host + "\x00" + r.DAG + "\x00" + r.Level
+		st, ok := byKey[key]
+		if !ok {
+			labels := map[string]string{"job": "pit", "dag": r.DAG, "level": r.Level}
+			if r.Host != "" {
+				labels["host"] = r.Host
+			}
+			for k, v := range l.Labels {
+				labels[k] = v
+			}
+			st = &lokiStream{Stream: labels}
+			byKey[key] = st
+			out = append(out, st)
+		}
+		line, _ := json.Marshal(r)
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(r.Time.UnixNano(), 10), string(line)})
+	}
+	return out
+}
+
+// pushURL returns URL, with the push API path added when URL has no path.
+func (l *Loki) pushURL() string {
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return l.URL
+	}
+	return strings.TrimSuffix(l.URL, "/") + lokiPushPath
+}
+
+func (l *Loki) Close() error { return nil }
@@ -1,7 +1,9 @@
 package meta
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -491,6 +493,221 @@ func TestRecordTaskStartEnd(t *testing.T) {
 	}
 }
 
+func TestRecordTaskUpstreamFailed(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", now)
+
+	err := s.RecordTaskUpstreamFailed("run1", "load", []string{"download", "extract"}, "upstream download, extract failed", now)
+	if err != nil {
+		t.Fatalf("RecordTaskUpstreamFailed: %v", err)
+	}
+
+	_, tasks, err := s.RunDetail("run1")
+	if err != nil {
+		t.Fatalf("RunDetail: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	if tasks[0].Status != "upstream_failed" {
+		t.Errorf("task status = %q, want %q", tasks[0].Status, "upstream_failed")
+	}
+	if got := strings.Join(tasks[0].FailedUpstream, ","); got != "download,extract" {
+		t.Errorf("FailedUpstream = %v, want [download extract]", tasks[0].FailedUpstream)
+	}
+}
+
+func TestTaskDurationHistory(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 4; i++ {
+		runID := fmt.Sprintf("run%d", i)
+		started := base.Add(time.Duration(i) * time.Hour)
+		s.RecordRunStart(runID, "my_dag", "running", "runs/"+runID, "cron", started)
+		s.RecordTaskStart(runID, "extract", "running", "", started)
+		status := "success"
+		if i == 3 {
+			status = "failed"
+		}
+		s.RecordTaskEnd(runID, "extract", status, started.Add(time.Duration(i+1)*time.Minute), 1, "")
+	}
+	s.RecordRunStart("other", "other_dag", "running", "runs/other", "cron", base)
+	s.RecordTaskStart("other", "extract", "running", "", base)
+	s.RecordTaskEnd("other", "extract", "success", base.Add(time.Hour), 1, "")
+
+	history, err := s.TaskDurationHistory("my_dag", 2)
+	if err != nil {
+		t.Fatalf("TaskDurationHistory: %v", err)
+	}
+	// Newest successful first, failed run excluded, capped at limit
+	want := []time.Duration{3 * time.Minute, 2 * time.Minute}
+	got := history["extract"]
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("history[extract] = %v, want %v", got, want)
+	}
+}
+
+func TestRecordSnapshot(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", now)
+
+	if err := s.RecordSnapshot("run1", 120, 4096, 1500*time.Millisecond); err != nil {
+		t.Fatalf("RecordSnapshot: %v", err)
+	}
+
+	run, _, err := s.RunDetail("run1")
+	if err != nil {
+		t.Fatalf("RunDetail: %v", err)
+	}
+	if run.SnapshotFiles != 120 || run.SnapshotBytes != 4096 || run.SnapshotDuration != 1500*time.Millisecond {
+		t.Errorf("snapshot = %d files/%d bytes/%s, want 120/4096/1.5s",
+			run.SnapshotFiles, run.SnapshotBytes, run.SnapshotDuration)
+	}
+}
+
+func TestRecordArtifactURI(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", now)
+
+	if err := s.RecordArtifactURI("run1", "s3://bucket/pit/my_dag/run1"); err != nil {
+		t.Fatalf("RecordArtifactURI: %v", err)
+	}
+
+	run, _, err := s.RunDetail("run1")
+	if err != nil {
+		t.Fatalf("RunDetail: %v", err)
+	}
+	if run.ArtifactURI != "s3://bucket/pit/my_dag/run1" {
+		t.Errorf("ArtifactURI = %q, want s3://bucket/pit/my_dag/run1", run.ArtifactURI)
+	}
+}
+
+func TestRecordToolVersions(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", now)
+
+	if err := s.RecordToolVersions("run1", "v1.4.0", "3.12.7", "0.5.11", ""); err != nil {
+		t.Fatalf("RecordToolVersions: %v", err)
+	}
+
+	runs, err := s.LatestRuns("my_dag", 1)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("LatestRuns: %v (%d runs)", err, len(runs))
+	}
+	if runs[0].PitVersion != "v1.4.0" || runs[0].PythonVersion != "3.12.7" || runs[0].UVVersion != "0.5.11" || runs[0].DBTVersion != "" {
+		t.Errorf("versions = pit %q, python %q, uv %q, dbt %q; want v1.4.0, 3.12.7, 0.5.11, empty",
+			runs[0].PitVersion, runs[0].PythonVersion, runs[0].UVVersion, runs[0].DBTVersion)
+	}
+}
+
+func TestRecordGitRevision(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", now)
+
+	if err := s.RecordGitRevision("run1", "3f2a9c1e8b7d", "main", true); err != nil {
+		t.Fatalf("RecordGitRevision: %v", err)
+	}
+
+	run, _, err := s.RunDetail("run1")
+	if err != nil || run == nil {
+		t.Fatalf("RunDetail: %v", err)
+	}
+	if run.GitCommit != "3f2a9c1e8b7d" || run.GitBranch != "main" || !run.GitDirty {
+		t.Errorf("revision = %q %q dirty=%v, want 3f2a9c1e8b7d main dirty=true", run.GitCommit, run.GitBranch, run.GitDirty)
+	}
+}
+
+func TestRecordSDKSocket(t *testing.T) {
+	s := newTestStore(t)
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "manual", time.Now().UTC())
+
+	if err := s.RecordSDKSocket("run1", "runs/run1/sdk.sock"); err != nil {
+		t.Fatalf("RecordSDKSocket: %v", err)
+	}
+	run, _, err := s.RunDetail("run1")
+	if err != nil || run == nil {
+		t.Fatalf("RunDetail: %v", err)
+	}
+	if run.SDKSocket != "runs/run1/sdk.sock" {
+		t.Errorf("SDKSocket = %q, want runs/run1/sdk.sock", run.SDKSocket)
+	}
+}
+
+func TestRecordInput(t *testing.T) {
+	s := newTestStore(t)
+	s.RecordRunStart("run1", "my_dag", "running", "runs/run1", "ftp_watch", time.Now().UTC())
+	at := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+
+	if err := s.RecordInput("run1", "sales.csv", "ftp_watch", "ftp://partner/in/sales.csv", 120, "ab12", at); err != nil {
+		t.Fatalf("RecordInput: %v", err)
+	}
+	if err := s.RecordInput("run1", "lookup/codes.csv", "seed", "", 40, "cd34", at); err != nil {
+		t.Fatalf("RecordInput: %v", err)
+	}
+	// Downloading the same file again replaces its record
+	if err := s.RecordInput("run1", "sales.csv", "ftp_download", "ftp://partner/in/sales.csv", 150, "ef56", at.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordInput: %v", err)
+	}
+
+	inputs, err := s.InputsByRun("run1")
+	if err != nil {
+		t.Fatalf("InputsByRun: %v", err)
+	}
+	want := []InputRecord{
+		{RunID: "run1", Path: "lookup/codes.csv", Source: "seed", Size: 40, SHA256: "cd34", FetchedAt: at},
+		{RunID: "run1", Path: "sales.csv", Source: "ftp_download", Remote: "ftp://partner/in/sales.csv", Size: 150, SHA256: "ef56", FetchedAt: at.Add(time.Minute)},
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("InputsByRun() = %+v, want %+v", inputs, want)
+	}
+	for i := range want {
+		if inputs[i] != want[i] {
+			t.Errorf("inputs[%d] = %+v, want %+v", i, inputs[i], want[i])
+		}
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	for i, status := range []string{"success", "failed", "success", "failed"} {
+		id := fmt.Sprintf("run%d", i+1)
+		s.RecordRunStart(id, "daily", "running", "runs/"+id, "cron", base.Add(time.Duration(i)*24*time.Hour))
+		s.RecordRunEnd(id, status, base.Add(time.Duration(i)*24*time.Hour+time.Minute), "")
+		s.RecordLogicalDate(id, base.AddDate(0, 0, i-1).Format("2006-01-02"))
+	}
+	s.RecordRunStart("other1", "weekly", "running", "runs/other1", "manual", base)
+
+	runs, err := s.RunHistory("daily", false, 3)
+	if err != nil {
+		t.Fatalf("RunHistory: %v", err)
+	}
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "run4,run3,run2" {
+		t.Errorf("RunHistory(daily, all, 3) = %s, want run4,run3,run2", got)
+	}
+	if runs[0].LogicalDate != "2026-03-03" || runs[0].Trigger != "cron" {
+		t.Errorf("run4 logical date = %q, trigger = %q; want 2026-03-03, cron", runs[0].LogicalDate, runs[0].Trigger)
+	}
+
+	failed, err := s.RunHistory("daily", true, 20)
+	if err != nil {
+		t.Fatalf("RunHistory failed only: %v", err)
+	}
+	if len(failed) != 2 || failed[0].ID != "run4" || failed[1].ID != "run2" {
+		t.Errorf("RunHistory(daily, failed only) = %+v, want run4, run2", failed)
+	}
+}
+
 func TestRecordOutput(t *testing.T) {
 	s := newTestStore(t)
 	now := time.Now().UTC()
@@ -509,3 +726,222 @@ func TestRecordOutput(t *testing.T) {
 		t.Fatalf("expected 1 output, got %d", len(outputs))
 	}
 }
+
+func TestLatestProducedOutputs(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC)
+	for i, runID := range []string{"run1", "run2"} {
+		s.RecordRunStart(runID, "claims", "running", "runs/"+runID, "manual", base.Add(time.Duration(i)*time.Hour))
+		if err := s.RecordOutput(runID, "claims", "claims_staging", "table", "warehouse.staging.claims"); err != nil {
+			t.Fatalf("RecordOutput: %v", err)
+		}
+		if err := s.RecordOutput(runID, "claims", "daily_report", "file", "reports/daily.csv"); err != nil {
+			t.Fatalf("RecordOutput: %v", err)
+		}
+	}
+	if err := s.RecordOutputProduced("run1", "claims_staging", "load", 100, base.Add(10*time.Minute)); err != nil {
+		t.Fatalf("RecordOutputProduced: %v", err)
+	}
+	if err := s.RecordOutputProduced("run2", "claims_staging", "load", 250, base.Add(70*time.Minute)); err != nil {
+		t.Fatalf("RecordOutputProduced: %v", err)
+	}
+	if err := s.RecordOutputProduced("run1", "daily_report", "report", -1, base.Add(20*time.Minute)); err != nil {
+		t.Fatalf("RecordOutputProduced: %v", err)
+	}
+
+	outs, err := s.LatestProducedOutputs()
+	if err != nil {
+		t.Fatalf("LatestProducedOutputs: %v", err)
+	}
+	if len(outs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outs))
+	}
+	claims, report := outs[0], outs[1]
+	if claims.RunID != "run2" || claims.TaskName != "load" || claims.Rows == nil || *claims.Rows != 250 {
+		t.Errorf("claims_staging = %+v, want run2 from task load with 250 rows", claims)
+	}
+	if claims.ProducedAt == nil || !claims.ProducedAt.Equal(base.Add(70*time.Minute)) {
+		t.Errorf("claims_staging produced at %v, want %v", claims.ProducedAt, base.Add(70*time.Minute))
+	}
+	if report.RunID != "run1" || report.Rows != nil {
+		t.Errorf("daily_report = %+v, want run1 with no row count", report)
+	}
+}
+
+func TestOutputsProducedBy(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC)
+	for i, runID := range []string{"run1", "run2"} {
+		s.RecordRunStart(runID, "claims", "running", "runs/"+runID, "manual", base.Add(time.Duration(i)*time.Hour))
+		s.RecordOutput(runID, "claims", "claims_staging", "table", "warehouse.staging.claims")
+		s.RecordOutput(runID, "claims", "daily_report", "file", "reports/daily.csv")
+	}
+	s.RecordOutputProduced("run1", "claims_staging", "load", 100, base.Add(10*time.Minute))
+	s.RecordOutputProduced("run2", "claims_staging", "load", 250, base.Add(70*time.Minute))
+	s.RecordOutputProduced("run1", "daily_report", "report", -1, base.Add(20*time.Minute))
+
+	produced, err := s.OutputsProducedBy("claims", "load")
+	if err != nil {
+		t.Fatalf("OutputsProducedBy: %v", err)
+	}
+	if len(produced) != 1 || !produced["claims_staging"].Equal(base.Add(70*time.Minute)) {
+		t.Errorf("produced by load = %v, want claims_staging at %v", produced, base.Add(70*time.Minute))
+	}
+	if produced, _ := s.OutputsProducedBy("other", "load"); len(produced) != 0 {
+		t.Errorf("produced by load in another DAG = %v, want none", produced)
+	}
+}
+
+func TestTaskMetrics(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 7, 6, 0, 0, 0, time.UTC)
+	for i, runID := range []string{"run1", "run2", "run3"} {
+		s.RecordRunStart(runID, "claims", "running", "runs/"+runID, "manual", base.Add(time.Duration(i)*time.Hour))
+		s.RecordTaskStart(runID, "dbt_test", "running", "", base.Add(time.Duration(i)*time.Hour))
+		metrics := map[string]float64{"dbt_tests_pass": float64(10 + i), "dbt_tests_fail": float64(i)}
+		if err := s.RecordTaskMetrics(runID, "dbt_test", metrics); err != nil {
+			t.Fatalf("RecordTaskMetrics: %v", err)
+		}
+	}
+	if err := s.RecordTaskMetrics("run3", "dbt_test", map[string]float64{"dbt_tests_fail": 5}); err != nil {
+		t.Fatalf("RecordTaskMetrics again: %v", err)
+	}
+
+	_, tasks, err := s.RunDetail("run3")
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("RunDetail: %v (%d tasks)", err, len(tasks))
+	}
+	if got := tasks[0].Metrics; got["dbt_tests_pass"] != 12 || got["dbt_tests_fail"] != 5 {
+		t.Errorf("run3 metrics = %v, want pass 12, fail 5", got)
+	}
+
+	history, err := s.TaskMetricHistory("claims", "dbt_test", 2)
+	if err != nil {
+		t.Fatalf("TaskMetricHistory: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("got %d metrics, want 4 (2 runs x 2 metrics)", len(history))
+	}
+	if history[0].RunID != "run2" || history[3].RunID != "run3" {
+		t.Errorf("history runs = %s..%s, want run2..run3 oldest first", history[0].RunID, history[3].RunID)
+	}
+	if !history[0].StartedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("history[0].StartedAt = %v, want %v", history[0].StartedAt, base.Add(time.Hour))
+	}
+
+	if other, err := s.TaskMetricHistory("claims", "other_task", 10); err != nil || len(other) != 0 {
+		t.Errorf("TaskMetricHistory(other_task) = %v, %v; want none", other, err)
+	}
+}
+
+func TestAnnotateAndAcknowledgeRun(t *testing.T) {
+	s := newTestStore(t)
+	id := insertTestRun(t, s)
+
+	if err := s.AnnotateRun(id, "known partner outage"); err != nil {
+		t.Fatalf("AnnotateRun() error: %v", err)
+	}
+	at := time.Date(2026, 3, 7, 9, 30, 0, 0, time.UTC)
+	if err := s.AcknowledgeRun(id, "alice", at); err != nil {
+		t.Fatalf("AcknowledgeRun() error: %v", err)
+	}
+
+	run, _, err := s.RunDetail(id)
+	if err != nil {
+		t.Fatalf("RunDetail() error: %v", err)
+	}
+	if run.Note != "known partner outage" {
+		t.Errorf("Note = %q, want %q", run.Note, "known partner outage")
+	}
+	if run.AcknowledgedAt == nil || !run.AcknowledgedAt.Equal(at) || run.AcknowledgedBy != "alice" {
+		t.Errorf("acknowledged = %v by %q, want %v by alice", run.AcknowledgedAt, run.AcknowledgedBy, at)
+	}
+
+	runs, err := s.LatestRunPerDAG()
+	if err != nil {
+		t.Fatalf("LatestRunPerDAG() error: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Note != "known partner outage" || runs[0].AcknowledgedAt == nil {
+		t.Errorf("LatestRunPerDAG() = %+v, want the annotation", runs)
+	}
+
+	// An empty note clears it
+	if err := s.AnnotateRun(id, ""); err != nil {
+		t.Fatalf("AnnotateRun() error: %v", err)
+	}
+	if run, _, _ := s.RunDetail(id); run.Note != "" {
+		t.Errorf("Note = %q after clearing, want empty", run.Note)
+	}
+}
+
+func TestAnnotateRun_NotFound(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.AnnotateRun("missing", "note"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("AnnotateRun() error = %v, want ErrRunNotFound", err)
+	}
+	if err := s.AcknowledgeRun("missing", "alice", time.Now()); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("AcknowledgeRun() error = %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	for i, status := range []string{"success", "failed", "success", "success"} {
+		id := fmt.Sprintf("run%d", i+1)
+		started := base.Add(time.Duration(i) * 24 * time.Hour)
+		s.RecordRunStart(id, "daily", "running", "runs/"+id, "cron", started)
+		s.RecordRunEnd(id, status, started.Add(time.Duration(i+1)*time.Minute), "")
+		s.RecordTaskStart(id, "extract", "running", "", started)
+		s.RecordTaskEnd(id, "extract", status, started.Add(time.Duration(i+1)*time.Minute), i+1, "")
+	}
+	s.RecordRunStart("run5", "daily", "running", "runs/run5", "cron", base.Add(96*time.Hour))
+	s.RecordTaskStart("run4", "report", "running", "", base.Add(72*time.Hour))
+	s.RecordTaskEnd("run4", "report", "skipped_fresh", base.Add(72*time.Hour), 0, "")
+	s.RecordRunStart("other1", "weekly", "running", "runs/other1", "manual", base)
+	s.RecordRunEnd("other1", "failed", base.Add(time.Minute), "boom")
+
+	stats, err := s.RunStats(time.Time{})
+	if err != nil {
+		t.Fatalf("RunStats: %v", err)
+	}
+	if len(stats) != 2 || stats[0].DAGName != "daily" || stats[1].DAGName != "weekly" {
+		t.Fatalf("RunStats() = %+v, want daily and weekly", stats)
+	}
+	daily := stats[0]
+	// The running run5 is not counted; the mean covers successes only
+	if daily.Runs != 4 || daily.Succeeded != 3 || daily.Failed != 1 {
+		t.Errorf("daily counts = %d/%d/%d, want 4 runs, 3 succeeded, 1 failed", daily.Runs, daily.Succeeded, daily.Failed)
+	}
+	if want := (time.Minute + 3*time.Minute + 4*time.Minute) / 3; daily.AvgDuration != want.Round(time.Second) {
+		t.Errorf("daily AvgDuration = %s, want %s", daily.AvgDuration, want.Round(time.Second))
+	}
+	if daily.SuccessRate() != 0.75 {
+		t.Errorf("daily SuccessRate() = %v, want 0.75", daily.SuccessRate())
+	}
+	if daily.LastSuccess == nil || !daily.LastSuccess.Equal(base.Add(72*time.Hour)) {
+		t.Errorf("daily LastSuccess = %v, want run4's start", daily.LastSuccess)
+	}
+	if weekly := stats[1]; weekly.SuccessRate() != 0 || weekly.AvgDuration != 0 || weekly.LastSuccess != nil {
+		t.Errorf("weekly = %+v, want no successes", weekly)
+	}
+
+	recent, err := s.RunStats(base.Add(48 * time.Hour))
+	if err != nil {
+		t.Fatalf("RunStats(since): %v", err)
+	}
+	if len(recent) != 1 || recent[0].Runs != 2 || recent[0].Succeeded != 2 {
+		t.Errorf("RunStats(since day 3) = %+v, want daily with 2 successes", recent)
+	}
+
+	tasks, err := s.TaskStats("daily", time.Time{})
+	if err != nil {
+		t.Fatalf("TaskStats: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].TaskName != "extract" || tasks[0].Runs != 4 || tasks[0].Failed != 1 {
+		t.Fatalf("TaskStats() = %+v, want extract with 4 runs, 1 failed, and no skipped report", tasks)
+	}
+	if tasks[0].AvgAttempts != 2.5 || tasks[0].AvgDuration != daily.AvgDuration {
+		t.Errorf("extract AvgAttempts = %v, AvgDuration = %s; want 2.5, %s", tasks[0].AvgAttempts, tasks[0].AvgDuration, daily.AvgDuration)
+	}
+}
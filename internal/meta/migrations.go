@@ -64,7 +64,113 @@ CREATE INDEX idx_secret_audit_key ON secret_audit(project, secret_key);
 CREATE INDEX idx_secret_audit_event ON secret_audit(event_type, timestamp);
 `
 
+// v3FailedUpstream records, for upstream_failed tasks, which upstream tasks
+// caused the skip (comma-separated names).
+const v3FailedUpstream = `
+ALTER TABLE task_instances ADD COLUMN failed_upstream TEXT;
+`
+
+// v4SnapshotStats records how much was copied into each run's project
+// snapshot and how long the copy took.
+const v4SnapshotStats = `
+ALTER TABLE runs ADD COLUMN snapshot_files INTEGER;
+ALTER TABLE runs ADD COLUMN snapshot_bytes INTEGER;
+ALTER TABLE runs ADD COLUMN snapshot_ms INTEGER;
+`
+
+// v5ArtifactURI records where a run's artifacts were uploaded.
+const v5ArtifactURI = `
+ALTER TABLE runs ADD COLUMN artifact_uri TEXT;
+`
+
+// v6ToolVersions records the Python, uv, and dbt versions a run used.
+const v6ToolVersions = `
+ALTER TABLE runs ADD COLUMN python_version TEXT;
+ALTER TABLE runs ADD COLUMN uv_version TEXT;
+ALTER TABLE runs ADD COLUMN dbt_version TEXT;
+`
+
+// v7ProducedOutputs records which task reported producing an output, when,
+// and how many rows it had.
+const v7ProducedOutputs = `
+ALTER TABLE outputs ADD COLUMN task_name TEXT;
+ALTER TABLE outputs ADD COLUMN row_count INTEGER;
+ALTER TABLE outputs ADD COLUMN produced_at TEXT;
+CREATE INDEX idx_outputs_produced ON outputs(dag_name, name, produced_at DESC);
+`
+
+// v8TaskMetrics records numeric task metrics, such as dbt test outcome
+// counts, one row per metric.
+const v8TaskMetrics = `
+CREATE TABLE task_metrics (
+	run_id    TEXT NOT NULL REFERENCES runs(id),
+	task_name TEXT NOT NULL,
+	name      TEXT NOT NULL,
+	value     REAL NOT NULL,
+	PRIMARY KEY (run_id, task_name, name)
+);
+`
+
+// v9RunAnnotations records an operator's note on a run and when, and by
+// whom, its failure was acknowledged.
+const v9RunAnnotations = `
+ALTER TABLE runs ADD COLUMN note TEXT;
+ALTER TABLE runs ADD COLUMN acknowledged_at TEXT;
+ALTER TABLE runs ADD COLUMN acknowledged_by TEXT;
+`
+
+// v10GitRevision records the git commit, branch, and dirty state of the
+// project a run was snapshotted from.
+const v10GitRevision = `
+ALTER TABLE runs ADD COLUMN git_commit TEXT;
+ALTER TABLE runs ADD COLUMN git_branch TEXT;
+ALTER TABLE runs ADD COLUMN git_dirty INTEGER;
+`
+
+// v11PitVersion records the version of pit that executed a run.
+const v11PitVersion = `
+ALTER TABLE runs ADD COLUMN pit_version TEXT;
+`
+
+// v12LogicalDate records the date a run processes, which differs from the
+// day it started for backfills and runs just after midnight.
+const v12LogicalDate = `
+ALTER TABLE runs ADD COLUMN logical_date TEXT;
+`
+
+// v13SDKSocket records the address of the SDK server a run's tasks used.
+const v13SDKSocket = `
+ALTER TABLE runs ADD COLUMN sdk_socket TEXT;
+`
+
+// v14RunInputs records where each file a run started from or downloaded
+// into its data directory came from.
+const v14RunInputs = `
+CREATE TABLE run_inputs (
+	run_id     TEXT NOT NULL REFERENCES runs(id),
+	path       TEXT NOT NULL,
+	source     TEXT NOT NULL,
+	remote     TEXT,
+	size       INTEGER NOT NULL,
+	sha256     TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	PRIMARY KEY (run_id, path)
+);
+`
+
 var migrations = []string{
 	v1Schema,
 	v2SecretAudit,
+	v3FailedUpstream,
+	v4SnapshotStats,
+	v5ArtifactURI,
+	v6ToolVersions,
+	v7ProducedOutputs,
+	v8TaskMetrics,
+	v9RunAnnotations,
+	v10GitRevision,
+	v11PitVersion,
+	v12LogicalDate,
+	v13SDKSocket,
+	v14RunInputs,
 }
@@ -2,7 +2,9 @@ package meta
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -91,6 +93,15 @@ func (s *SQLiteStore) UpdateRun(id, status string, endedAt time.Time, errMsg str
 	return err
 }
 
+// UpdateRunSnapshot records the project snapshot statistics for a run.
+func (s *SQLiteStore) UpdateRunSnapshot(id string, files int, bytes int64, dur time.Duration) error {
+	_, err := s.db.Exec(
+		`UPDATE runs SET snapshot_files = ?, snapshot_bytes = ?, snapshot_ms = ? WHERE id = ?`,
+		files, bytes, dur.Milliseconds(), id,
+	)
+	return err
+}
+
 // InsertTaskInstance inserts a new task instance record.
 func (s *SQLiteStore) InsertTaskInstance(ti TaskInstanceRecord) error {
 	var startedAt, endedAt *string
@@ -103,10 +114,11 @@ func (s *SQLiteStore) InsertTaskInstance(ti TaskInstanceRecord) error {
 		endedAt = &v
 	}
 	_, err := s.db.Exec(
-		`INSERT INTO task_instances (run_id, task_name, status, started_at, ended_at, attempts, error, log_path)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO task_instances (run_id, task_name, status, started_at, ended_at, attempts, error, log_path, failed_upstream)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		ti.RunID, ti.TaskName, ti.Status, startedAt, endedAt,
 		ti.Attempts, nilIfEmpty(ti.Error), nilIfEmpty(ti.LogPath),
+		nilIfEmpty(strings.Join(ti.FailedUpstream, ",")),
 	)
 	return err
 }
@@ -153,8 +165,8 @@ func (s *SQLiteStore) RecordOutputs(runID, dagName string, outputs []OutputRecor
 		return err
 	}
 	stmt, err := tx.Prepare(
-		`INSERT INTO outputs (run_id, dag_name, name, type, location)
-		 VALUES (?, ?, ?, ?, ?)`,
+		`INSERT INTO outputs (run_id, dag_name, name, type, location, task_name, row_count, produced_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		tx.Rollback()
@@ -162,7 +174,12 @@ func (s *SQLiteStore) RecordOutputs(runID, dagName string, outputs []OutputRecor
 	}
 	defer stmt.Close()
 	for _, o := range outputs {
-		if _, err := stmt.Exec(runID, dagName, o.Name, nilIfEmpty(o.Type), nilIfEmpty(o.Location)); err != nil {
+		var producedAt any
+		if o.ProducedAt != nil {
+			producedAt = o.ProducedAt.UTC().Format(time.RFC3339)
+		}
+		if _, err := stmt.Exec(runID, dagName, o.Name, nilIfEmpty(o.Type), nilIfEmpty(o.Location),
+			nilIfEmpty(o.TaskName), o.Rows, producedAt); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -183,7 +200,14 @@ func (s *SQLiteStore) scanRuns(query string, args ...any) ([]RunRecord, error) {
 		var r RunRecord
 		var startedAt string
 		var endedAt, trigger, errMsg sql.NullString
-		if err := rows.Scan(&r.ID, &r.DAGName, &r.Status, &startedAt, &endedAt, &r.RunDir, &trigger, &errMsg); err != nil {
+		var snapFiles, snapBytes, snapMS sql.NullInt64
+		var artifactURI, pitVersion, pythonVersion, uvVersion, dbtVersion sql.NullString
+		var gitCommit, gitBranch sql.NullString
+		var gitDirty sql.NullBool
+		var note, ackedAt, ackedBy, logicalDate, sdkSocket sql.NullString
+		if err := rows.Scan(&r.ID, &r.DAGName, &r.Status, &startedAt, &endedAt, &r.RunDir, &trigger, &errMsg,
+			&snapFiles, &snapBytes, &snapMS, &artifactURI, &pitVersion, &pythonVersion, &uvVersion, &dbtVersion,
+			&gitCommit, &gitBranch, &gitDirty, &note, &ackedAt, &ackedBy, &logicalDate, &sdkSocket); err != nil {
 			return nil, err
 		}
 		r.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
@@ -197,6 +221,25 @@ func (s *SQLiteStore) scanRuns(query string, args ...any) ([]RunRecord, error) {
 		if errMsg.Valid {
 			r.Error = errMsg.String
 		}
+		r.SnapshotFiles = int(snapFiles.Int64)
+		r.SnapshotBytes = snapBytes.Int64
+		r.SnapshotDuration = time.Duration(snapMS.Int64) * time.Millisecond
+		r.ArtifactURI = artifactURI.String
+		r.PitVersion = pitVersion.String
+		r.PythonVersion = pythonVersion.String
+		r.UVVersion = uvVersion.String
+		r.DBTVersion = dbtVersion.String
+		r.GitCommit = gitCommit.String
+		r.GitBranch = gitBranch.String
+		r.GitDirty = gitDirty.Bool
+		r.Note = note.String
+		if ackedAt.Valid {
+			t, _ := time.Parse(time.RFC3339, ackedAt.String)
+			r.AcknowledgedAt = &t
+		}
+		r.AcknowledgedBy = ackedBy.String
+		r.LogicalDate = logicalDate.String
+		r.SDKSocket = sdkSocket.String
 		runs = append(runs, r)
 	}
 	return runs, rows.Err()
@@ -206,25 +249,46 @@ func (s *SQLiteStore) scanRuns(query string, args ...any) ([]RunRecord, error) {
 func (s *SQLiteStore) LatestRuns(dagName string, limit int) ([]RunRecord, error) {
 	if dagName == "" {
 		return s.scanRuns(
-			`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error
-			 FROM runs ORDER BY started_at DESC LIMIT ?`, limit)
+			`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error,
+			 snapshot_files, snapshot_bytes, snapshot_ms, artifact_uri, pit_version, python_version, uv_version, dbt_version,
+			 git_commit, git_branch, git_dirty, note, acknowledged_at, acknowledged_by, logical_date, sdk_socket
+			 FROM runs ORDER BY started_at DESC, id DESC LIMIT ?`, limit)
 	}
 	return s.scanRuns(
-		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error
-		 FROM runs WHERE dag_name = ? ORDER BY started_at DESC LIMIT ?`, dagName, limit)
+		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error,
+		 snapshot_files, snapshot_bytes, snapshot_ms, artifact_uri, pit_version, python_version, uv_version, dbt_version,
+		 git_commit, git_branch, git_dirty, note, acknowledged_at, acknowledged_by, logical_date, sdk_socket
+		 FROM runs WHERE dag_name = ? ORDER BY started_at DESC, id DESC LIMIT ?`, dagName, limit)
+}
+
+// RunHistory returns a DAG's most recent runs, newest first. With
+// failedOnly, only failed runs are returned.
+func (s *SQLiteStore) RunHistory(dagName string, failedOnly bool, limit int) ([]RunRecord, error) {
+	query := `SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error,
+		 snapshot_files, snapshot_bytes, snapshot_ms, artifact_uri, pit_version, python_version, uv_version, dbt_version,
+		 git_commit, git_branch, git_dirty, note, acknowledged_at, acknowledged_by, logical_date, sdk_socket
+		 FROM runs WHERE dag_name = ?`
+	if failedOnly {
+		query += ` AND status = 'failed'`
+	}
+	return s.scanRuns(query+` ORDER BY started_at DESC, id DESC LIMIT ?`, dagName, limit)
 }
 
 // RunsByStatus returns runs filtered by status.
 func (s *SQLiteStore) RunsByStatus(status string, limit int) ([]RunRecord, error) {
 	return s.scanRuns(
-		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error
-		 FROM runs WHERE status = ? ORDER BY started_at DESC LIMIT ?`, status, limit)
+		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error,
+		 snapshot_files, snapshot_bytes, snapshot_ms, artifact_uri, pit_version, python_version, uv_version, dbt_version,
+		 git_commit, git_branch, git_dirty, note, acknowledged_at, acknowledged_by, logical_date, sdk_socket
+		 FROM runs WHERE status = ? ORDER BY started_at DESC, id DESC LIMIT ?`, status, limit)
 }
 
 // RunDetail returns a run and its task instances, or nil,nil,nil if not found.
 func (s *SQLiteStore) RunDetail(runID string) (*RunRecord, []TaskInstanceRecord, error) {
 	runs, err := s.scanRuns(
-		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error
+		`SELECT id, dag_name, status, started_at, ended_at, run_dir, trigger_source, error,
+		 snapshot_files, snapshot_bytes, snapshot_ms, artifact_uri, pit_version, python_version, uv_version, dbt_version,
+		 git_commit, git_branch, git_dirty, note, acknowledged_at, acknowledged_by, logical_date, sdk_socket
 		 FROM runs WHERE id = ?`, runID)
 	if err != nil {
 		return nil, nil, err
@@ -235,7 +299,7 @@ func (s *SQLiteStore) RunDetail(runID string) (*RunRecord, []TaskInstanceRecord,
 	run := runs[0]
 
 	rows, err := s.db.Query(
-		`SELECT run_id, task_name, status, started_at, ended_at, attempts, error, log_path
+		`SELECT run_id, task_name, status, started_at, ended_at, attempts, error, log_path, failed_upstream
 		 FROM task_instances WHERE run_id = ?`, runID)
 	if err != nil {
 		return &run, nil, err
@@ -245,8 +309,8 @@ func (s *SQLiteStore) RunDetail(runID string) (*RunRecord, []TaskInstanceRecord,
 	var tasks []TaskInstanceRecord
 	for rows.Next() {
 		var ti TaskInstanceRecord
-		var startedAt, endedAt, errMsg, logPath sql.NullString
-		if err := rows.Scan(&ti.RunID, &ti.TaskName, &ti.Status, &startedAt, &endedAt, &ti.Attempts, &errMsg, &logPath); err != nil {
+		var startedAt, endedAt, errMsg, logPath, failedUpstream sql.NullString
+		if err := rows.Scan(&ti.RunID, &ti.TaskName, &ti.Status, &startedAt, &endedAt, &ti.Attempts, &errMsg, &logPath, &failedUpstream); err != nil {
 			return &run, nil, err
 		}
 		if startedAt.Valid {
@@ -263,9 +327,99 @@ func (s *SQLiteStore) RunDetail(runID string) (*RunRecord, []TaskInstanceRecord,
 		if logPath.Valid {
 			ti.LogPath = logPath.String
 		}
+		if failedUpstream.Valid && failedUpstream.String != "" {
+			ti.FailedUpstream = strings.Split(failedUpstream.String, ",")
+		}
 		tasks = append(tasks, ti)
 	}
-	return &run, tasks, rows.Err()
+	if err := rows.Err(); err != nil {
+		return &run, nil, err
+	}
+
+	metrics, err := s.db.Query(`SELECT task_name, name, value FROM task_metrics WHERE run_id = ?`, runID)
+	if err != nil {
+		return &run, tasks, err
+	}
+	defer metrics.Close()
+	byTask := make(map[string]map[string]float64)
+	for metrics.Next() {
+		var taskName, name string
+		var value float64
+		if err := metrics.Scan(&taskName, &name, &value); err != nil {
+			return &run, tasks, err
+		}
+		if byTask[taskName] == nil {
+			byTask[taskName] = make(map[string]float64)
+		}
+		byTask[taskName][name] = value
+	}
+	for i := range tasks {
+		tasks[i].Metrics = byTask[tasks[i].TaskName]
+	}
+	return &run, tasks, metrics.Err()
+}
+
+// TaskDurationHistory returns wall-clock durations of the most recent
+// successful task instances for a DAG, keyed by task name, newest first.
+// At most limit durations are returned per task.
+func (s *SQLiteStore) TaskDurationHistory(dagName string, limit int) (map[string][]time.Duration, error) {
+	rows, err := s.db.Query(
+		`SELECT task_name, started_at, ended_at FROM (
+		   SELECT ti.task_name, ti.started_at, ti.ended_at,
+		          ROW_NUMBER() OVER (PARTITION BY ti.task_name ORDER BY ti.started_at DESC) AS n
+		   FROM task_instances ti JOIN runs r ON r.id = ti.run_id
+		   WHERE r.dag_name = ? AND ti.status = 'success'
+		     AND ti.started_at IS NOT NULL AND ti.ended_at IS NOT NULL)
+		 WHERE n <= ?
+		 ORDER BY started_at DESC`, dagName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string][]time.Duration)
+	for rows.Next() {
+		var taskName, startedAt, endedAt string
+		if err := rows.Scan(&taskName, &startedAt, &endedAt); err != nil {
+			return nil, err
+		}
+		start, err1 := time.Parse(time.RFC3339, startedAt)
+		end, err2 := time.Parse(time.RFC3339, endedAt)
+		if err1 != nil || err2 != nil || end.Before(start) {
+			continue
+		}
+		history[taskName] = append(history[taskName], end.Sub(start))
+	}
+	return history, rows.Err()
+}
+
+// TaskMetricHistory returns the metrics recorded for a DAG's tasks over its
+// most recent limit runs, oldest first so they plot left to right. An empty
+// taskName returns every task's metrics.
+func (s *SQLiteStore) TaskMetricHistory(dagName, taskName string, limit int) ([]TaskMetricRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT m.run_id, m.task_name, m.name, m.value, r.started_at
+		 FROM task_metrics m
+		 JOIN (SELECT id, started_at FROM runs WHERE dag_name = ? ORDER BY started_at DESC LIMIT ?) r ON r.id = m.run_id
+		 WHERE ? = '' OR m.task_name = ?
+		 ORDER BY r.started_at, m.run_id, m.task_name, m.name`,
+		dagName, limit, taskName, taskName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []TaskMetricRecord
+	for rows.Next() {
+		var m TaskMetricRecord
+		var startedAt string
+		if err := rows.Scan(&m.RunID, &m.TaskName, &m.Name, &m.Value, &startedAt); err != nil {
+			return nil, err
+		}
+		m.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+		records = append(records, m)
+	}
+	return records, rows.Err()
 }
 
 // EnvHistory returns environment snapshot history for a DAG and hash type.
@@ -298,9 +452,25 @@ func (s *SQLiteStore) EnvHistory(dagName, hashType string, limit int) ([]EnvSnap
 
 // OutputsByRun returns outputs for a given run, ordered by name.
 func (s *SQLiteStore) OutputsByRun(runID string) ([]OutputRecord, error) {
-	rows, err := s.db.Query(
-		`SELECT run_id, dag_name, name, type, location
+	return s.scanOutputs(
+		`SELECT run_id, dag_name, name, type, location, task_name, row_count, produced_at
 		 FROM outputs WHERE run_id = ? ORDER BY name`, runID)
+}
+
+// LatestProducedOutputs returns, for each DAG and output name, the most
+// recent time a task reported producing it. Ordered by DAG then name.
+func (s *SQLiteStore) LatestProducedOutputs() ([]OutputRecord, error) {
+	return s.scanOutputs(
+		`SELECT run_id, dag_name, name, type, location, task_name, row_count, produced_at
+		 FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY dag_name, name ORDER BY produced_at DESC, run_id DESC) AS rn
+		       FROM outputs WHERE produced_at IS NOT NULL)
+		 WHERE rn = 1
+		 ORDER BY dag_name, name`)
+}
+
+// scanOutputs is a helper to execute a query and scan the results into OutputRecords.
+func (s *SQLiteStore) scanOutputs(query string, args ...any) ([]OutputRecord, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -309,8 +479,9 @@ func (s *SQLiteStore) OutputsByRun(runID string) ([]OutputRecord, error) {
 	var outs []OutputRecord
 	for rows.Next() {
 		var o OutputRecord
-		var typ, loc sql.NullString
-		if err := rows.Scan(&o.RunID, &o.DAGName, &o.Name, &typ, &loc); err != nil {
+		var typ, loc, task, producedAt sql.NullString
+		var rowCount sql.NullInt64
+		if err := rows.Scan(&o.RunID, &o.DAGName, &o.Name, &typ, &loc, &task, &rowCount, &producedAt); err != nil {
 			return nil, err
 		}
 		if typ.Valid {
@@ -319,6 +490,15 @@ func (s *SQLiteStore) OutputsByRun(runID string) ([]OutputRecord, error) {
 		if loc.Valid {
 			o.Location = loc.String
 		}
+		o.TaskName = task.String
+		if rowCount.Valid {
+			n := rowCount.Int64
+			o.Rows = &n
+		}
+		if producedAt.Valid {
+			t, _ := time.Parse(time.RFC3339, producedAt.String)
+			o.ProducedAt = &t
+		}
 		outs = append(outs, o)
 	}
 	return outs, rows.Err()
@@ -327,13 +507,129 @@ func (s *SQLiteStore) OutputsByRun(runID string) ([]OutputRecord, error) {
 // LatestRunPerDAG returns the most recent run for each DAG.
 func (s *SQLiteStore) LatestRunPerDAG() ([]RunRecord, error) {
 	return s.scanRuns(
-		`SELECT r.id, r.dag_name, r.status, r.started_at, r.ended_at, r.run_dir, r.trigger_source, r.error
+		`SELECT r.id, r.dag_name, r.status, r.started_at, r.ended_at, r.run_dir, r.trigger_source, r.error,
+		 r.snapshot_files, r.snapshot_bytes, r.snapshot_ms, r.artifact_uri, r.pit_version, r.python_version, r.uv_version, r.dbt_version,
+		 r.git_commit, r.git_branch, r.git_dirty, r.note, r.acknowledged_at, r.acknowledged_by, r.logical_date, r.sdk_socket
 		 FROM runs r
 		 INNER JOIN (SELECT dag_name, MAX(started_at) AS max_started FROM runs GROUP BY dag_name) sub
 		 ON r.dag_name = sub.dag_name AND r.started_at = sub.max_started
 		 ORDER BY r.dag_name`)
 }
 
+// RunStats returns the run counts and mean duration of every DAG with
+// runs started at or after since, ordered by DAG name. A zero since
+// covers all recorded runs.
+func (s *SQLiteStore) RunStats(since time.Time) ([]RunStats, error) {
+	rows, err := s.db.Query(
+		`SELECT dag_name, COUNT(*),
+		        SUM(status = 'success'), SUM(status = 'failed'),
+		        AVG(CASE WHEN status = 'success' THEN (julianday(ended_at) - julianday(started_at)) * 86400 END),
+		        MAX(CASE WHEN status = 'success' THEN started_at END)
+		 FROM runs
+		 WHERE ended_at IS NOT NULL AND started_at >= ?
+		 GROUP BY dag_name
+		 ORDER BY dag_name`, sinceStr(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []RunStats
+	for rows.Next() {
+		var st RunStats
+		var avgSecs sql.NullFloat64
+		var lastSuccess sql.NullString
+		if err := rows.Scan(&st.DAGName, &st.Runs, &st.Succeeded, &st.Failed, &avgSecs, &lastSuccess); err != nil {
+			return nil, err
+		}
+		st.AvgDuration = secondsDuration(avgSecs)
+		if lastSuccess.Valid {
+			if t, err := time.Parse(time.RFC3339, lastSuccess.String); err == nil {
+				st.LastSuccess = &t
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// TaskStats returns the outcome counts, mean duration, and mean attempts
+// of each task of a DAG that ran in runs started at or after since,
+// ordered by task name. A zero since covers all recorded runs.
+func (s *SQLiteStore) TaskStats(dagName string, since time.Time) ([]TaskStats, error) {
+	rows, err := s.db.Query(
+		`SELECT ti.task_name, COUNT(*),
+		        SUM(ti.status = 'success'), SUM(ti.status = 'failed'),
+		        AVG(CASE WHEN ti.status = 'success' AND ti.started_at IS NOT NULL
+		                 THEN (julianday(ti.ended_at) - julianday(ti.started_at)) * 86400 END),
+		        AVG(ti.attempts)
+		 FROM task_instances ti JOIN runs r ON r.id = ti.run_id
+		 WHERE r.dag_name = ? AND ti.status IN ('success', 'failed') AND r.started_at >= ?
+		 GROUP BY ti.task_name
+		 ORDER BY ti.task_name`, dagName, sinceStr(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []TaskStats
+	for rows.Next() {
+		var st TaskStats
+		var avgSecs, avgAttempts sql.NullFloat64
+		if err := rows.Scan(&st.TaskName, &st.Runs, &st.Succeeded, &st.Failed, &avgSecs, &avgAttempts); err != nil {
+			return nil, err
+		}
+		st.AvgDuration = secondsDuration(avgSecs)
+		st.AvgAttempts = avgAttempts.Float64
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// sinceStr formats a lower bound on started_at; timestamps are stored as
+// UTC RFC 3339, so they compare as strings.
+func sinceStr(since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+	return since.UTC().Format(time.RFC3339)
+}
+
+func secondsDuration(secs sql.NullFloat64) time.Duration {
+	if !secs.Valid {
+		return 0
+	}
+	return time.Duration(secs.Float64 * float64(time.Second)).Round(time.Second)
+}
+
+// ErrRunNotFound is returned when annotating a run that is not recorded.
+var ErrRunNotFound = errors.New("run not found")
+
+// AnnotateRun sets the operator's note on a run, replacing any earlier
+// note. An empty note clears it.
+func (s *SQLiteStore) AnnotateRun(runID, note string) error {
+	return s.updateRun(runID, `UPDATE runs SET note = ? WHERE id = ?`, nilIfEmpty(note), runID)
+}
+
+// AcknowledgeRun marks a run's failure as triaged by an operator.
+func (s *SQLiteStore) AcknowledgeRun(runID, by string, at time.Time) error {
+	return s.updateRun(runID, `UPDATE runs SET acknowledged_at = ?, acknowledged_by = ? WHERE id = ?`,
+		at.UTC().Format(time.RFC3339), nilIfEmpty(by), runID)
+}
+
+// updateRun runs an UPDATE of one run, returning ErrRunNotFound if runID
+// matched no row.
+func (s *SQLiteStore) updateRun(runID, query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	return nil
+}
+
 // RecordRunStart implements engine.MetadataRecorder.
 func (s *SQLiteStore) RecordRunStart(id, dagName, status, runDir, trigger string, startedAt time.Time) error {
 	return s.InsertRun(RunRecord{
@@ -347,6 +643,80 @@ func (s *SQLiteStore) RecordRunEnd(id, status string, endedAt time.Time, errMsg
 	return s.UpdateRun(id, status, endedAt, errMsg)
 }
 
+// RecordSnapshot implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordSnapshot(runID string, files int, bytes int64, dur time.Duration) error {
+	return s.UpdateRunSnapshot(runID, files, bytes, dur)
+}
+
+// RecordArtifactURI implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordArtifactURI(runID, uri string) error {
+	_, err := s.db.Exec(`UPDATE runs SET artifact_uri = ? WHERE id = ?`, uri, runID)
+	return err
+}
+
+// RecordToolVersions implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordToolVersions(runID, pit, python, uv, dbt string) error {
+	_, err := s.db.Exec(`UPDATE runs SET pit_version = ?, python_version = ?, uv_version = ?, dbt_version = ? WHERE id = ?`,
+		nilIfEmpty(pit), nilIfEmpty(python), nilIfEmpty(uv), nilIfEmpty(dbt), runID)
+	return err
+}
+
+// RecordGitRevision implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordGitRevision(runID, commit, branch string, dirty bool) error {
+	_, err := s.db.Exec(`UPDATE runs SET git_commit = ?, git_branch = ?, git_dirty = ? WHERE id = ?`,
+		nilIfEmpty(commit), nilIfEmpty(branch), dirty, runID)
+	return err
+}
+
+// RecordLogicalDate implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordLogicalDate(runID, date string) error {
+	_, err := s.db.Exec(`UPDATE runs SET logical_date = ? WHERE id = ?`, nilIfEmpty(date), runID)
+	return err
+}
+
+// RecordSDKSocket implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordSDKSocket(runID, addr string) error {
+	_, err := s.db.Exec(`UPDATE runs SET sdk_socket = ? WHERE id = ?`, nilIfEmpty(addr), runID)
+	return err
+}
+
+// RecordInput implements engine.MetadataRecorder. Recording the same path
+// again replaces the earlier record, as a later download overwrites the
+// file.
+func (s *SQLiteStore) RecordInput(runID, path, source, remote string, size int64, sha256 string, fetchedAt time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO run_inputs (run_id, path, source, remote, size, sha256, fetched_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		runID, path, source, nilIfEmpty(remote), size, sha256, fetchedAt.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// InputsByRun returns the files recorded as a run's inputs, ordered by path.
+func (s *SQLiteStore) InputsByRun(runID string) ([]InputRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT run_id, path, source, remote, size, sha256, fetched_at
+		 FROM run_inputs WHERE run_id = ? ORDER BY path`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []InputRecord
+	for rows.Next() {
+		var in InputRecord
+		var remote sql.NullString
+		var fetchedAt string
+		if err := rows.Scan(&in.RunID, &in.Path, &in.Source, &remote, &in.Size, &in.SHA256, &fetchedAt); err != nil {
+			return nil, err
+		}
+		in.Remote = remote.String
+		in.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+		inputs = append(inputs, in)
+	}
+	return inputs, rows.Err()
+}
+
 // RecordTaskStart implements engine.MetadataRecorder.
 func (s *SQLiteStore) RecordTaskStart(runID, taskName, status, logPath string, startedAt time.Time) error {
 	return s.InsertTaskInstance(TaskInstanceRecord{
@@ -360,6 +730,14 @@ func (s *SQLiteStore) RecordTaskEnd(runID, taskName, status string, endedAt time
 	return s.UpdateTaskInstance(runID, taskName, status, endedAt, attempts, errMsg)
 }
 
+// RecordTaskUpstreamFailed implements engine.MetadataRecorder.
+func (s *SQLiteStore) RecordTaskUpstreamFailed(runID, taskName string, failedUpstream []string, errMsg string, at time.Time) error {
+	return s.InsertTaskInstance(TaskInstanceRecord{
+		RunID: runID, TaskName: taskName, Status: "upstream_failed",
+		EndedAt: &at, Error: errMsg, FailedUpstream: failedUpstream,
+	})
+}
+
 // RecordOutput implements engine.MetadataRecorder.
 func (s *SQLiteStore) RecordOutput(runID, dagName, name, outputType, location string) error {
 	_, err := s.db.Exec(
@@ -369,6 +747,65 @@ func (s *SQLiteStore) RecordOutput(runID, dagName, name, outputType, location st
 	return err
 }
 
+// RecordOutputProduced implements engine.MetadataRecorder. A negative rows
+// records no row count.
+func (s *SQLiteStore) RecordOutputProduced(runID, name, taskName string, rows int64, producedAt time.Time) error {
+	var rowCount any
+	if rows >= 0 {
+		rowCount = rows
+	}
+	_, err := s.db.Exec(
+		`UPDATE outputs SET task_name = ?, row_count = ?, produced_at = ? WHERE run_id = ? AND name = ?`,
+		nilIfEmpty(taskName), rowCount, producedAt.UTC().Format(time.RFC3339), runID, name,
+	)
+	return err
+}
+
+// OutputsProducedBy implements engine.MetadataRecorder. It returns when
+// taskName last produced each of the DAG's outputs it has produced, keyed
+// by output name.
+func (s *SQLiteStore) OutputsProducedBy(dagName, taskName string) (map[string]time.Time, error) {
+	rows, err := s.db.Query(
+		`SELECT name, MAX(produced_at) FROM outputs
+		 WHERE dag_name = ? AND task_name = ? AND produced_at IS NOT NULL
+		 GROUP BY name`, dagName, taskName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	produced := make(map[string]time.Time)
+	for rows.Next() {
+		var name, at string
+		if err := rows.Scan(&name, &at); err != nil {
+			return nil, err
+		}
+		if t, err := time.Parse(time.RFC3339, at); err == nil {
+			produced[name] = t
+		}
+	}
+	return produced, rows.Err()
+}
+
+// RecordTaskMetrics implements engine.MetadataRecorder. A metric recorded
+// again for the same task instance replaces the earlier value.
+func (s *SQLiteStore) RecordTaskMetrics(runID, taskName string, metrics map[string]float64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for name, value := range metrics {
+		if _, err := tx.Exec(
+			`INSERT OR REPLACE INTO task_metrics (run_id, task_name, name, value) VALUES (?, ?, ?, ?)`,
+			runID, taskName, name, value,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // RecordSecretAccess implements engine.MetadataRecorder.
 func (s *SQLiteStore) RecordSecretAccess(project, secretKey, dagName, taskName, runID string, timestamp time.Time) error {
 	return s.RecordSecretEvent(SecretAuditRecord{
@@ -7,16 +7,27 @@ type Store interface {
 	Close() error
 	InsertRun(r RunRecord) error
 	UpdateRun(id string, status string, endedAt time.Time, errMsg string) error
+	UpdateRunSnapshot(id string, files int, bytes int64, dur time.Duration) error
 	InsertTaskInstance(ti TaskInstanceRecord) error
 	UpdateTaskInstance(runID, taskName, status string, endedAt time.Time, attempts int, errMsg string) error
 	RecordEnvSnapshot(dagName, hashType, hashValue, runID string) error
 	RecordOutputs(runID, dagName string, outputs []OutputRecord) error
 	LatestRuns(dagName string, limit int) ([]RunRecord, error)
+	RunHistory(dagName string, failedOnly bool, limit int) ([]RunRecord, error)
 	RunsByStatus(status string, limit int) ([]RunRecord, error)
 	RunDetail(runID string) (*RunRecord, []TaskInstanceRecord, error)
 	EnvHistory(dagName, hashType string, limit int) ([]EnvSnapshotRecord, error)
+	TaskDurationHistory(dagName string, limit int) (map[string][]time.Duration, error)
 	OutputsByRun(runID string) ([]OutputRecord, error)
+	InputsByRun(runID string) ([]InputRecord, error)
+	LatestProducedOutputs() ([]OutputRecord, error)
+	OutputsProducedBy(dagName, taskName string) (map[string]time.Time, error)
 	LatestRunPerDAG() ([]RunRecord, error)
+	RunStats(since time.Time) ([]RunStats, error)
+	TaskStats(dagName string, since time.Time) ([]TaskStats, error)
+	AnnotateRun(runID, note string) error
+	AcknowledgeRun(runID, by string, at time.Time) error
+	TaskMetricHistory(dagName, taskName string, limit int) ([]TaskMetricRecord, error)
 	RecordSecretEvent(event SecretAuditRecord) error
 	SecretAuditHistory(project, secretKey string, limit int) ([]SecretAuditRecord, error)
 }
@@ -31,6 +42,74 @@ type RunRecord struct {
 	RunDir    string
 	Trigger   string
 	Error     string
+
+	// LogicalDate is the date the run processes, "2006-01-02"; empty for
+	// runs recorded before it was tracked.
+	LogicalDate string
+
+	// Snapshot statistics; zero when not recorded.
+	SnapshotFiles    int
+	SnapshotBytes    int64
+	SnapshotDuration time.Duration
+
+	ArtifactURI string // where artifacts were uploaded; empty if kept locally only
+	SDKSocket   string // SDK server address: a Unix socket path, or host:port on Windows
+
+	// Tool versions resolved for the run; empty when not used or unknown.
+	PitVersion    string
+	PythonVersion string
+	UVVersion     string
+	DBTVersion    string
+
+	// Git revision of the project; GitCommit is empty when the project was
+	// not in a git worktree.
+	GitCommit string
+	GitBranch string
+	GitDirty  bool
+
+	// Set by `pit annotate`; AcknowledgedAt is nil until a failure is
+	// acknowledged.
+	Note           string
+	AcknowledgedAt *time.Time
+	AcknowledgedBy string
+}
+
+// RunStats summarises the finished runs of one DAG.
+type RunStats struct {
+	DAGName     string
+	Runs        int // finished runs; runs still going are not counted
+	Succeeded   int
+	Failed      int
+	AvgDuration time.Duration // mean wall-clock time of successful runs
+	LastSuccess *time.Time    // start of the latest successful run
+}
+
+// SuccessRate returns the fraction of finished runs that succeeded, or 0
+// if none finished.
+func (r RunStats) SuccessRate() float64 {
+	if r.Runs == 0 {
+		return 0
+	}
+	return float64(r.Succeeded) / float64(r.Runs)
+}
+
+// TaskStats summarises the finished instances of one task.
+type TaskStats struct {
+	TaskName    string
+	Runs        int // instances that succeeded or failed; skipped ones are not counted
+	Succeeded   int
+	Failed      int
+	AvgDuration time.Duration // mean wall-clock time of successful instances
+	AvgAttempts float64       // mean attempts
+}
+
+// SuccessRate returns the fraction of finished instances that succeeded,
+// or 0 if none finished.
+func (t TaskStats) SuccessRate() float64 {
+	if t.Runs == 0 {
+		return 0
+	}
+	return float64(t.Succeeded) / float64(t.Runs)
 }
 
 // TaskInstanceRecord represents a single task within a run.
@@ -43,6 +122,19 @@ type TaskInstanceRecord struct {
 	Attempts  int
 	Error     string
 	LogPath   string
+	// FailedUpstream names the failed tasks that caused an upstream_failed skip.
+	FailedUpstream []string
+	// Metrics holds numeric task metrics by name, e.g. dbt test outcome counts.
+	Metrics map[string]float64
+}
+
+// TaskMetricRecord is one metric recorded for a task instance.
+type TaskMetricRecord struct {
+	RunID     string
+	TaskName  string
+	Name      string
+	Value     float64
+	StartedAt time.Time // when the run started, for plotting trends
 }
 
 // EnvSnapshotRecord represents a captured environment hash.
@@ -74,4 +166,21 @@ type OutputRecord struct {
 	Name     string
 	Type     string
 	Location string
+
+	// Set when a task reported producing the output with the SDK's
+	// register_output; Rows is nil if it gave no row count.
+	TaskName   string
+	Rows       *int64
+	ProducedAt *time.Time
+}
+
+// InputRecord is one file seeded or downloaded into a run's data directory.
+type InputRecord struct {
+	RunID     string
+	Path      string // relative to the data directory, slash-separated
+	Source    string // "seed", "ftp_watch", or "ftp_download"
+	Remote    string // where the file was fetched from; empty for local seeds
+	Size      int64
+	SHA256    string
+	FetchedAt time.Time
 }
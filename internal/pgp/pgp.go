@@ -0,0 +1,157 @@
+// Package pgp encrypts and decrypts files with OpenPGP keys held in the
+// secrets store, for partner feeds that are delivered or sent encrypted.
+package pgp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// encryptedExts are the suffixes DecryptedName strips.
+var encryptedExts = []string{".pgp", ".gpg", ".asc"}
+
+// DecryptedName returns name without a .pgp, .gpg, or .asc suffix, and
+// whether it had one.
+func DecryptedName(name string) (string, bool) {
+	for _, ext := range encryptedExts {
+		if base, ok := strings.CutSuffix(name, ext); ok && base != "" {
+			return base, true
+		}
+	}
+	return name, false
+}
+
+// readKeys parses an armored key block. The passphrase, if any, unlocks
+// the private keys in it.
+func readKeys(key, passphrase string) (openpgp.EntityList, error) {
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+	for _, k := range keys.DecryptionKeys() {
+		if k.PrivateKey == nil || !k.PrivateKey.Encrypted {
+			continue
+		}
+		if passphrase == "" {
+			return nil, fmt.Errorf("private key is protected and no passphrase was given")
+		}
+		if err := k.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("unlocking private key: %w", err)
+		}
+	}
+	return keys, nil
+}
+
+// Decrypt writes the plaintext of the message in src to dst. The message
+// may be binary or armored. privateKey is an armored private key block.
+func Decrypt(dst io.Writer, src io.Reader, privateKey, passphrase string) error {
+	keys, err := readKeys(privateKey, passphrase)
+	if err != nil {
+		return err
+	}
+	if len(keys.DecryptionKeys()) == 0 {
+		return fmt.Errorf("key has no private decryption key")
+	}
+
+	br := bufio.NewReader(src)
+	var msg io.Reader = br
+	if head, _ := br.Peek(len("-----BEGIN PGP")); bytes.Equal(head, []byte("-----BEGIN PGP")) {
+		block, err := armor.Decode(br)
+		if err != nil {
+			return fmt.Errorf("reading armor: %w", err)
+		}
+		msg = block.Body
+	}
+
+	md, err := openpgp.ReadMessage(msg, keys, nil, nil)
+	if err != nil {
+		return fmt.Errorf("decrypting: %w", err)
+	}
+	if _, err := io.Copy(dst, md.UnverifiedBody); err != nil {
+		return fmt.Errorf("decrypting: %w", err)
+	}
+	// Signatures are only checked once the body has been read in full, and
+	// only when the signer's key is in the key block
+	if md.SignatureError != nil {
+		return fmt.Errorf("bad signature: %w", md.SignatureError)
+	}
+	return nil
+}
+
+// Encrypt writes src encrypted to publicKey, an armored public key block,
+// to dst. With armored set the output is ASCII-armored.
+func Encrypt(dst io.Writer, src io.Reader, publicKey, fileName string, armored bool) error {
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+
+	out := dst
+	var armorWriter io.WriteCloser
+	if armored {
+		if armorWriter, err = armor.Encode(dst, "PGP MESSAGE", nil); err != nil {
+			return err
+		}
+		out = armorWriter
+	}
+	plaintext, err := openpgp.Encrypt(out, keys, nil, &openpgp.FileHints{IsBinary: true, FileName: fileName}, nil)
+	if err != nil {
+		return fmt.Errorf("encrypting: %w", err)
+	}
+	if _, err := io.Copy(plaintext, src); err != nil {
+		return fmt.Errorf("encrypting: %w", err)
+	}
+	if err := plaintext.Close(); err != nil {
+		return fmt.Errorf("encrypting: %w", err)
+	}
+	if armorWriter != nil {
+		return armorWriter.Close()
+	}
+	return nil
+}
+
+// DecryptFile decrypts the file at src to dst. A partly written dst is
+// removed on failure.
+func DecryptFile(src, dst, privateKey, passphrase string) error {
+	return transformFile(src, dst, func(w io.Writer, r io.Reader) error {
+		return Decrypt(w, r, privateKey, passphrase)
+	})
+}
+
+// EncryptFile encrypts the file at src to dst. A partly written dst is
+// removed on failure.
+func EncryptFile(src, dst, publicKey string, armored bool) error {
+	return transformFile(src, dst, func(w io.Writer, r io.Reader) error {
+		return Encrypt(w, r, publicKey, filepath.Base(src), armored)
+	})
+}
+
+// transformFile streams src through fn into a new file at dst.
+func transformFile(src, dst string, fn func(io.Writer, io.Reader) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	err = fn(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
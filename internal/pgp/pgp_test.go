@@ -0,0 +1,153 @@
+package pgp
+
+import (
+	"bytes"
+	"crypto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// testKeys returns an armored private and public key block for a new key.
+func testKeys(t *testing.T) (private, public string) {
+	t.Helper()
+	cfg := &packet.Config{RSABits: 1024, DefaultHash: crypto.SHA256, DefaultCipher: packet.CipherAES256}
+	entity, err := openpgp.NewEntity("pit test", "", "test@example.com", cfg)
+	if err != nil {
+		t.Fatalf("NewEntity() error: %v", err)
+	}
+	var priv, pub bytes.Buffer
+	w, _ := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatalf("SerializePrivate() error: %v", err)
+	}
+	w.Close()
+	w, _ = armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize() error: %v", err)
+	}
+	w.Close()
+	return priv.String(), pub.String()
+}
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	private, public := testKeys(t)
+	plaintext := "id,amount\n1,9.50\n"
+
+	for _, armored := range []bool{false, true} {
+		var enc bytes.Buffer
+		if err := Encrypt(&enc, strings.NewReader(plaintext), public, "claims.csv", armored); err != nil {
+			t.Fatalf("Encrypt(armored=%v) error: %v", armored, err)
+		}
+		if got := strings.HasPrefix(enc.String(), "-----BEGIN PGP MESSAGE-----"); got != armored {
+			t.Errorf("armored=%v: output armored = %v", armored, got)
+		}
+		var dec bytes.Buffer
+		if err := Decrypt(&dec, &enc, private, ""); err != nil {
+			t.Fatalf("Decrypt(armored=%v) error: %v", armored, err)
+		}
+		if dec.String() != plaintext {
+			t.Errorf("armored=%v: Decrypt() = %q, want %q", armored, dec.String(), plaintext)
+		}
+	}
+}
+
+// TestCurve25519Key uses a GnuPG key with an ed25519 primary key and a
+// cv25519 encryption subkey, protected by the passphrase "pit-test". The
+// message in testdata was encrypted and signed with gpg.
+func TestCurve25519Key(t *testing.T) {
+	private, _ := os.ReadFile(filepath.Join("testdata", "ed25519.key"))
+	public, _ := os.ReadFile(filepath.Join("testdata", "ed25519.pub"))
+	const plaintext = "id,amount\n1,9.50\n"
+
+	dst := filepath.Join(t.TempDir(), "claims.csv")
+	if err := DecryptFile(filepath.Join("testdata", "claims.csv.gpg"), dst, string(private), "pit-test"); err != nil {
+		t.Fatalf("DecryptFile() error: %v", err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != plaintext {
+		t.Errorf("DecryptFile() wrote %q, want %q", got, plaintext)
+	}
+
+	var enc, dec bytes.Buffer
+	if err := Encrypt(&enc, strings.NewReader(plaintext), string(public), "claims.csv", true); err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if err := Decrypt(&dec, &enc, string(private), "pit-test"); err != nil {
+		t.Fatalf("Decrypt() error: %v", err)
+	}
+	if dec.String() != plaintext {
+		t.Errorf("Decrypt() = %q, want %q", dec.String(), plaintext)
+	}
+
+	if err := Decrypt(&bytes.Buffer{}, strings.NewReader(""), string(private), "wrong"); err == nil || !strings.Contains(err.Error(), "unlocking private key") {
+		t.Errorf("Decrypt() with a wrong passphrase error = %v, want an unlocking error", err)
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	_, public := testKeys(t)
+	otherPrivate, _ := testKeys(t)
+
+	var enc bytes.Buffer
+	if err := Encrypt(&enc, strings.NewReader("secret"), public, "x", false); err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{"wrong key", otherPrivate, "decrypting"},
+		{"public key only", public, "no private decryption key"},
+		{"not a key", "hello", "reading key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Decrypt(&bytes.Buffer{}, bytes.NewReader(enc.Bytes()), tt.key, "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecryptFile_RemovesPartialOutput(t *testing.T) {
+	private, _ := testKeys(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "claims.csv.pgp")
+	dst := filepath.Join(dir, "claims.csv")
+	os.WriteFile(src, []byte("not a pgp message"), 0o644)
+
+	if err := DecryptFile(src, dst, private, ""); err == nil {
+		t.Fatal("DecryptFile() should fail on a non-PGP file")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("output should be removed after a failure, stat err = %v", err)
+	}
+}
+
+func TestDecryptedName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"claims.csv.pgp", "claims.csv", true},
+		{"claims.csv.gpg", "claims.csv", true},
+		{"claims.csv.asc", "claims.csv", true},
+		{"claims.csv", "claims.csv", false},
+		{".pgp", ".pgp", false},
+	}
+	for _, tt := range tests {
+		got, ok := DecryptedName(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("DecryptedName(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package remotepath handles paths that do not name files on the machine
+// pit runs on: directories on an FTP server, and output locations such as
+//
+//	//sftp/reports/daily.csv
+//	\\fileserver\finance\close.xlsx
+//
+// path/filepath uses the local separator, so on Windows filepath.Join
+// turns "/incoming" and "a.csv" into "\incoming\a.csv", which an FTP server
+// rejects, and filepath.Match treats "/" and "\" differently than on Linux.
+// The functions here behave the same on every OS.
+package remotepath
+
+import (
+	"path"
+	"strings"
+)
+
+// Join joins path elements with "/" and cleans the result like path.Join.
+// A leading "//" on the first non-empty element is kept, since it names a
+// network host rather than the root directory.
+func Join(elem ...string) string {
+	joined := path.Join(elem...)
+	for _, e := range elem {
+		if e == "" {
+			continue
+		}
+		if strings.HasPrefix(e, "//") && !strings.HasPrefix(joined, "//") {
+			joined = "/" + joined
+		}
+		break
+	}
+	return joined
+}
+
+// Base returns the last element of p. Both "/" and "\" separate elements,
+// so the result is a bare file name however the path was written.
+func Base(p string) string {
+	p = strings.TrimRight(p, `/\`)
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		p = p[i+1:]
+	}
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// IsName reports whether name is a single path element that is safe to
+// join onto a local directory: not empty, "." or "..", and free of "/"
+// and "\" on every OS.
+func IsName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// Normalize returns location with backslashes turned into forward slashes
+// and repeated separators and "." and ".." elements removed, so a UNC path
+// and its forward-slash spelling compare equal. A leading "//" is kept.
+// URLs (anything containing "://") are returned unchanged.
+func Normalize(location string) string {
+	if location == "" || strings.Contains(location, "://") {
+		return location
+	}
+	s := strings.ReplaceAll(location, `\`, "/")
+	network := strings.HasPrefix(s, "//")
+	s = path.Clean(s)
+	if network {
+		s = "/" + s
+	}
+	return s
+}
+
+// Match reports whether location matches the shell pattern, with both
+// normalized first. "*" never matches "/". Because backslashes become
+// separators, they cannot be used to escape pattern characters.
+func Match(pattern, location string) (bool, error) {
+	return path.Match(Normalize(pattern), Normalize(location))
+}
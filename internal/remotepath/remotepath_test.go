@@ -0,0 +1,93 @@
+package remotepath
+
+import "testing"
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		elem []string
+		want string
+	}{
+		{[]string{"/incoming", "a.csv"}, "/incoming/a.csv"},
+		{[]string{"/incoming/", "a.csv"}, "/incoming/a.csv"},
+		{[]string{"incoming", "a.csv"}, "incoming/a.csv"},
+		{[]string{"//sftp/reports", "daily.csv"}, "//sftp/reports/daily.csv"},
+		{[]string{"", "//sftp/reports", "daily.csv"}, "//sftp/reports/daily.csv"},
+		{[]string{"/in", "//a.csv"}, "/in/a.csv"},
+		{[]string{"", "a.csv"}, "a.csv"},
+	}
+	for _, tt := range tests {
+		if got := Join(tt.elem...); got != tt.want {
+			t.Errorf("Join(%q) = %q, want %q", tt.elem, got, tt.want)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := map[string]string{
+		"/incoming/a.csv":               "a.csv",
+		"//sftp/reports/daily.csv":      "daily.csv",
+		`\\fileserver\share\close.xlsx`: "close.xlsx",
+		`/in/..\..\evil.csv`:            "evil.csv",
+		"/incoming/":                    "incoming",
+		"a.csv":                         "a.csv",
+		"/":                             ".",
+		"":                              ".",
+	}
+	for in, want := range tests {
+		if got := Base(in); got != want {
+			t.Errorf("Base(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsName(t *testing.T) {
+	for _, name := range []string{"a.csv", "..a.csv", "sales 2024.csv"} {
+		if !IsName(name) {
+			t.Errorf("IsName(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "sub/a.csv", `..\evil.csv`} {
+		if IsName(name) {
+			t.Errorf("IsName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		`\\fileserver\share\close.xlsx`: "//fileserver/share/close.xlsx",
+		"//sftp/reports//daily.csv":     "//sftp/reports/daily.csv",
+		`C:\exports\.\out.csv`:          "C:/exports/out.csv",
+		"/data/../out.csv":              "/out.csv",
+		"s3://bucket//key.csv":          "s3://bucket//key.csv",
+		"warehouse.dbo.sales":           "warehouse.dbo.sales",
+		"":                              "",
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, location string
+		want              bool
+	}{
+		{"//sftp/reports/*", "//sftp/reports/daily.csv", true},
+		{"//sftp/reports/*", `\\sftp\reports\daily.csv`, true},
+		{`\\sftp\reports\*.csv`, "//sftp/reports/daily.csv", true},
+		{"//sftp/*", "//sftp/reports/daily.csv", false},
+		{"*.dbo.*", "warehouse.dbo.sales", true},
+	}
+	for _, tt := range tests {
+		got, err := Match(tt.pattern, tt.location)
+		if err != nil {
+			t.Fatalf("Match(%q, %q) error: %v", tt.pattern, tt.location, err)
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.location, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package runid generates and parses run IDs. A run ID names a run's
+// directory and its metadata rows:
+//
+//	20240115_143022.123-3fa9c1_dag_name
+//
+// a zero-padded local timestamp with millisecond precision, a random
+// suffix so runs of the same DAG started in the same millisecond do not
+// collide, and the DAG name. IDs sort in start order. IDs written before
+// the suffix was added (20240115_143022.123_dag_name) still parse.
+package runid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	// timestampLayout is the time.Format layout of an ID's first part.
+	timestampLayout = "20060102_150405.000"
+
+	// suffixLen is the number of hex characters in the random suffix.
+	suffixLen = 6
+)
+
+// New returns a new run ID for dagName, stamped with the current time.
+func New(dagName string) string {
+	return format(time.Now(), dagName)
+}
+
+func format(t time.Time, dagName string) string {
+	b := make([]byte, suffixLen/2)
+	rand.Read(b) // never returns an error
+	return fmt.Sprintf("%s-%s_%s", t.Format(timestampLayout), hex.EncodeToString(b), dagName)
+}
+
+// prefixLen returns the length of the timestamp and suffix part of runID,
+// including the underscore before the DAG name, or 0 if there is none.
+func prefixLen(runID string) int {
+	n := len(timestampLayout)
+	if len(runID) <= n {
+		return 0
+	}
+	switch runID[n] {
+	case '_': // legacy ID without a suffix
+		return n + 1
+	case '-':
+		if len(runID) > n+1+suffixLen && runID[n+1+suffixLen] == '_' && isHex(runID[n+1:n+1+suffixLen]) {
+			return n + 1 + suffixLen + 1
+		}
+	}
+	return 0
+}
+
+// isHex reports whether s holds only lowercase hex digits, as New writes.
+func isHex(s string) bool {
+	for _, c := range []byte(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// DAGName extracts the DAG name from a run ID.
+func DAGName(runID string) (string, error) {
+	if _, err := Timestamp(runID); err != nil {
+		return "", err
+	}
+	n := prefixLen(runID)
+	if n == len(runID) {
+		return "", fmt.Errorf("run ID %q does not contain a DAG name", runID)
+	}
+	return runID[n:], nil
+}
+
+// Timestamp parses the time a run ID was generated, in the local zone.
+func Timestamp(runID string) (time.Time, error) {
+	if prefixLen(runID) == 0 {
+		return time.Time{}, fmt.Errorf("run ID %q does not start with a timestamp", runID)
+	}
+	return time.ParseInLocation(timestampLayout, runID[:len(timestampLayout)], time.Local)
+}
@@ -0,0 +1,65 @@
+package runid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	start := time.Date(2024, 1, 15, 14, 30, 22, 123_000_000, time.Local)
+	id := format(start, "my_dag")
+	if !strings.HasPrefix(id, "20240115_143022.123-") || !strings.HasSuffix(id, "_my_dag") {
+		t.Errorf("format() = %q, want 20240115_143022.123-<suffix>_my_dag", id)
+	}
+	if other := format(start, "my_dag"); other == id {
+		t.Errorf("format() returned %q twice for the same millisecond", id)
+	}
+
+	ts, err := Timestamp(id)
+	if err != nil || !ts.Equal(start) {
+		t.Errorf("Timestamp(%q) = %v, %v; want %v", id, ts, err, start)
+	}
+	if name, err := DAGName(New("daily_sales")); err != nil || name != "daily_sales" {
+		t.Errorf("DAGName(New()) = %q, %v; want daily_sales", name, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr bool
+	}{
+		{name: "suffixed", id: "20240115_143022.123-3fa9c1_my_dag", want: "my_dag"},
+		{name: "legacy", id: "20240115_143022.123_my_dag", want: "my_dag"},
+		{name: "dag name with dashes", id: "20240115_143022.123-3fa9c1_load-sales", want: "load-sales"},
+		{name: "no dag name", id: "20240115_143022.123-3fa9c1_", wantErr: true},
+		{name: "legacy without dag name", id: "20240115_143022.123_", wantErr: true},
+		{name: "timestamp only", id: "20240115_143022.123", wantErr: true},
+		{name: "short suffix", id: "20240115_143022.123-3fa_my_dag", wantErr: true},
+		{name: "non-hex suffix", id: "20240115_143022.123-zzzzzz_my_dag", wantErr: true},
+		{name: "bad timestamp", id: "20241315_143022.123-3fa9c1_my_dag", wantErr: true},
+		{name: "no millis", id: "20240115_143022_my_dag", wantErr: true},
+		{name: "empty", id: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DAGName(tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DAGName(%q) = %q, want error", tt.id, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("DAGName(%q) = %q, %v; want %q", tt.id, got, err, tt.want)
+			}
+			ts, err := Timestamp(tt.id)
+			if want := time.Date(2024, 1, 15, 14, 30, 22, 123_000_000, time.Local); err != nil || !ts.Equal(want) {
+				t.Errorf("Timestamp(%q) = %v, %v; want %v", tt.id, ts, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+)
+
+// BatchRunner executes .bat and .cmd scripts with cmd.exe. Batch scripts
+// only run on Windows.
+type BatchRunner struct{}
+
+func (r *BatchRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) error {
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("batch runner %s: .bat and .cmd scripts only run on Windows", rc.ScriptPath)
+	}
+	// /d skips AutoRun commands from the registry, which would otherwise run
+	// before every task.
+	cmd := exec.CommandContext(ctx, "cmd.exe", "/d", "/c", rc.ScriptPath)
+	cmd.Dir = rc.Dir()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = rc.Env
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
+		return fmt.Errorf("batch runner %s: %w", rc.ScriptPath, err)
+	}
+	return nil
+}
+
+// PowerShellRunner executes .ps1 scripts. It uses PowerShell 7 (pwsh) when
+// installed and Windows PowerShell otherwise. The execution policy is
+// bypassed for the task only, since snapshot scripts are never signed.
+type PowerShellRunner struct{}
+
+func (r *PowerShellRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) error {
+	shell, err := powerShell()
+	if err != nil {
+		return fmt.Errorf("powershell runner %s: %w", rc.ScriptPath, err)
+	}
+	cmd := exec.CommandContext(ctx, shell, powerShellArgs(rc.ScriptPath)...)
+	cmd.Dir = rc.Dir()
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = rc.Env
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
+		return fmt.Errorf("powershell runner %s: %w", rc.ScriptPath, err)
+	}
+	return nil
+}
+
+// powerShell returns the PowerShell executable to use.
+func powerShell() (string, error) {
+	if p, err := exec.LookPath("pwsh"); err == nil {
+		return p, nil
+	}
+	if runtime.GOOS == "windows" {
+		if p, err := exec.LookPath("powershell.exe"); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("PowerShell not found (install pwsh)")
+}
+
+// powerShellArgs returns the arguments that run script non-interactively.
+// -File makes the script's exit code the process exit code.
+func powerShellArgs(script string) []string {
+	return []string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", script}
+}
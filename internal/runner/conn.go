@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// connFields are the structured secret fields BuildConnString consumes;
+// any other field becomes a query parameter of the connection string.
+var connFields = map[string]bool{
+	"driver": true, "host": true, "port": true, "user": true, "password": true, "database": true,
+}
+
+// ResolveConnection resolves a database connection secret to a connection
+// string. A plain secret is returned as is; a structured secret is built
+// into a connection string with BuildConnString.
+func ResolveConnection(sr SecretsResolver, project, key string) (string, error) {
+	value, err := sr.Resolve(project, key)
+	if err != nil {
+		return "", err
+	}
+	// Resolve returns a structured secret as a JSON object of its fields
+	if !strings.HasPrefix(value, "{") {
+		return value, nil
+	}
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return value, nil
+	}
+	connStr, err := BuildConnString(fields)
+	if err != nil {
+		return "", fmt.Errorf("secret %q: %w", key, err)
+	}
+	return connStr, nil
+}
+
+// BuildConnString formats a connection string from structured secret
+// fields: driver (sqlserver, postgres, clickhouse, or oracle), host, and
+// optionally port, user, password, and database (the service name for
+// oracle). Other fields are passed as query parameters, e.g.
+// encrypt = "disable" for sqlserver or sslmode = "require" for postgres.
+func BuildConnString(fields map[string]string) (string, error) {
+	host := fields["host"]
+	if host == "" {
+		return "", fmt.Errorf("structured connection secret needs a host field")
+	}
+	if port := fields["port"]; port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	u := &url.URL{Host: host}
+	if user := fields["user"]; user != "" {
+		if password, ok := fields["password"]; ok {
+			u.User = url.UserPassword(user, password)
+		} else {
+			u.User = url.User(user)
+		}
+	}
+
+	q := url.Values{}
+	var extra []string
+	for name := range fields {
+		if !connFields[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		q.Set(name, fields[name])
+	}
+
+	database := fields["database"]
+	switch strings.ToLower(fields["driver"]) {
+	case "sqlserver", "mssql":
+		u.Scheme = "sqlserver"
+		if database != "" {
+			q.Set("database", database)
+		}
+	case "postgres", "postgresql":
+		u.Scheme = "postgres"
+		u.Path = "/" + database
+	case "clickhouse":
+		u.Scheme = "clickhouse"
+		u.Path = "/" + database
+	case "oracle":
+		u.Scheme = "oracle"
+		u.Path = "/" + database
+	case "":
+		return "", fmt.Errorf("structured connection secret needs a driver field (sqlserver, postgres, clickhouse, or oracle)")
+	default:
+		return "", fmt.Errorf("unsupported driver %q in connection secret (supported: sqlserver, postgres, clickhouse, oracle)", fields["driver"])
+	}
+	if u.Path == "/" {
+		u.Path = ""
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -29,11 +29,11 @@ func (r *CustomRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer
 	}
 
 	cmd := exec.CommandContext(ctx, parts[0], args...)
-	cmd.Dir = rc.SnapshotDir
+	cmd.Dir = rc.Dir()
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 	cmd.Env = rc.Env
-	if err := cmd.Run(); err != nil {
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
 		return fmt.Errorf("custom runner %q %s: %w", r.Command, rc.ScriptPath, err)
 	}
 	return nil
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/druarnfield/pit/internal/config"
@@ -12,10 +14,20 @@ import (
 
 // DBTRunner executes dbt commands via uvx.
 type DBTRunner struct {
-	Config      *config.DBTConfig
-	ProfilesDir string
+	Config        *config.DBTConfig
+	ProfilesDir   string
+	PythonVersion string // interpreter for uvx; DefaultDBTPython if empty
+	RawLogPath    string // if set, dbt's unparsed output is appended here
+
+	// TestResults counts the test outcomes from the last Run, parsed from
+	// dbt's log events. Zero if the command ran no tests.
+	TestResults DBTTestResults
 }
 
+// DefaultDBTPython is the interpreter dbt runs under when [dag.python] does
+// not set a version.
+const DefaultDBTPython = "3.10"
+
 // NewDBTRunner creates a DBTRunner from a dbt config and a profiles directory.
 func NewDBTRunner(cfg *config.DBTConfig, profilesDir string) *DBTRunner {
 	return &DBTRunner{Config: cfg, ProfilesDir: profilesDir}
@@ -33,12 +45,18 @@ func (r *DBTRunner) BuildArgs(dbtCommand string) []string {
 	for _, dep := range r.Config.ExtraDeps {
 		args = append(args, "--with", dep)
 	}
-	// TODO: add this to config
-	args = append(args, "--python", "3.10")
+	python := r.PythonVersion
+	if python == "" {
+		python = DefaultDBTPython
+	}
+	args = append(args, "--python", python)
 
 	// dbt executable + subcommand + args + log format
 	args = append(args, "dbt")
 	args = append(args, "--log-format", "json")
+	if r.Config.LogLevel == "debug" {
+		args = append(args, "--log-level", "debug")
+	}
 	args = append(args, strings.Fields(dbtCommand)...)
 
 	return args
@@ -74,17 +92,41 @@ func (r *DBTRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) e
 	// dbt writes structured log events to stderr, not stdout.
 	// Wire both through the parser so nothing is missed.
 	parser := newDBTLogParser(logFile)
+	parser.debug = r.Config.LogLevel == "debug"
+	if r.RawLogPath != "" {
+		raw, err := openRawLog(r.RawLogPath)
+		if err != nil {
+			parser.Close()
+			return fmt.Errorf("dbt runner: %w", err)
+		}
+		defer raw.Close()
+		parser.raw = raw
+	}
 	cmd.Stdout = parser
 	cmd.Stderr = parser
 
-	err := cmd.Run()
+	err := runProcess(cmd, rc.Limits, rc.Usage, parser)
 
 	// Close the pipe so the scanner goroutine gets EOF and flushes.
 	// Must happen after cmd.Run() returns, before we check the error.
 	parser.Close()
+	r.TestResults = parser.testResults()
 
 	if err != nil {
 		return fmt.Errorf("dbt runner: %w", err)
 	}
 	return nil
 }
+
+// openRawLog opens the raw log for appending, so retries of a task and
+// other dbt tasks in the same run add to one file.
+func openRawLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating raw log dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening raw log: %w", err)
+	}
+	return f, nil
+}
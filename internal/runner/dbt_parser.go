@@ -20,14 +20,48 @@ import (
 //   - log_version 3 (dbt 1.5+):    nested info{name,code,msg,level} + data{}
 type dbtLogParser struct {
 	dest io.Writer
+	raw  io.Writer // receives every line unparsed; nil to skip
 	pr   *io.PipeReader
 	pw   *io.PipeWriter
 	done chan struct{}
 
+	debug bool // show debug-level events instead of dropping them
+
 	mu       sync.Mutex
 	running  []runningNode // nodes started but not yet finished, in start order
 	total    int           // total node count from the first Q033 event
 	finished int           // how many have completed so far
+	tests    DBTTestResults
+}
+
+// DBTTestResults counts the outcomes of the dbt tests in one invocation.
+type DBTTestResults struct {
+	Pass  int
+	Fail  int
+	Warn  int
+	Error int
+	Skip  int
+}
+
+// Total returns the number of tests counted.
+func (t DBTTestResults) Total() int {
+	return t.Pass + t.Fail + t.Warn + t.Error + t.Skip
+}
+
+// count adds one test with the given dbt status.
+func (t *DBTTestResults) count(status string) {
+	switch status {
+	case "pass":
+		t.Pass++
+	case "fail":
+		t.Fail++
+	case "warn":
+		t.Warn++
+	case "error":
+		t.Error++
+	case "skipped":
+		t.Skip++
+	}
 }
 
 type runningNode struct {
@@ -38,6 +72,20 @@ type runningNode struct {
 
 // ── Unified event types ──────────────────────────────────────────
 
+// dbtEventCodes maps log_version 3 event names to the codes handleEvent
+// switches on, so node results are recognised by name whichever code a
+// dbt version assigns them.
+var dbtEventCodes = map[string]string{
+	"LogStartLine":                  "Q033",
+	"LogModelResult":                "Q012",
+	"LogSeedResult":                 "Q034",
+	"LogSnapshotResult":             "Q015",
+	"LogTestResult":                 "Q035",
+	"LogFreshnessResult":            "Q037",
+	"RunningOperationCaughtError":   "Q001",
+	"RunningOperationUncaughtError": "Q001",
+}
+
 type dbtEvent struct {
 	Code  string
 	Name  string // empty in log_version 2
@@ -67,6 +115,7 @@ type dbtEventData struct {
 
 	Elapsed           float64        `json:"elapsed"`
 	Execution         string         `json:"execution"`
+	ResourceType      string         `json:"resource_type"`
 	Stats             map[string]int `json:"stats"`
 	KeyboardInterrupt bool           `json:"keyboard_interrupt"`
 	NumErrors         int            `json:"num_errors"`
@@ -144,6 +193,13 @@ func (p *dbtLogParser) processLines() {
 	}
 }
 
+// testResults returns the test outcomes seen so far.
+func (p *dbtLogParser) testResults() DBTTestResults {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tests
+}
+
 func (p *dbtLogParser) emit(msg string) {
 	fmt.Fprintln(p.dest, msg)
 }
@@ -156,6 +212,11 @@ func (p *dbtLogParser) handleLine(line []byte) {
 		return
 	}
 
+	// One write per line keeps lines whole when tasks share the raw log
+	if p.raw != nil {
+		p.raw.Write(append(append([]byte(nil), line...), '\n'))
+	}
+
 	// Non-JSON passthrough
 	if line[0] != '{' {
 		p.emit(string(line))
@@ -169,6 +230,9 @@ func (p *dbtLogParser) handleLine(line []byte) {
 	}
 
 	if event.Level == "debug" {
+		if p.debug && event.Msg != "" {
+			p.emit(event.Msg)
+		}
 		return
 	}
 
@@ -176,6 +240,10 @@ func (p *dbtLogParser) handleLine(line []byte) {
 }
 
 func (p *dbtLogParser) handleEvent(event dbtEvent) {
+	if code, ok := dbtEventCodes[event.Name]; ok {
+		event.Code = code
+	}
+
 	switch event.Code {
 
 	// ── Header info ───────────────────────────────────────────
@@ -219,39 +287,17 @@ func (p *dbtLogParser) handleEvent(event dbtEvent) {
 		p.mu.Unlock()
 		// Don't emit anything — we'll show it when something finishes
 
-	// ── Model completed ───────────────────────────────────────
+	// ── Model, seed, or snapshot completed ────────────────────
 	case "Q012": // LogModelResult
-		p.mu.Lock()
-		p.finished++
-
-		name := event.Data.NodeInfo.resolvedName()
-		uid := event.Data.NodeInfo.UniqueID
-		p.removeRunning(uid, name)
-
-		progress := fmt.Sprintf("[%d/%d]", p.finished, p.total)
-		still := p.runningStatus(event.Ts)
-		p.mu.Unlock()
+		p.nodeResult(event, event.Data.NodeInfo.Materialized)
 
-		mat := event.Data.NodeInfo.Materialized
-		if mat != "" {
-			mat = " " + mat
-		}
+	case "Q034": // LogSeedResult
+		p.nodeResult(event, "seed")
 
-		icon := "✓"
-		status := event.Data.Status
-		if status == "error" {
-			icon = "✗"
-		}
-
-		line := fmt.Sprintf("%s %s %s%s (%s in %.1fs)",
-			progress, icon, name, mat, status, event.Data.ExecutionTime)
-
-		if len(still) > 0 {
-			line += "  |  Running: " + strings.Join(still, ", ")
-		}
-		p.emit(line)
+	case "Q015": // LogSnapshotResult
+		p.nodeResult(event, "snapshot")
 
-	// ── Test completed ────────────────────────────────────────
+	// ── Test or unit test (dbt 1.8+) completed ────────────────
 	case "Q035": // LogTestResult
 		p.mu.Lock()
 		p.finished++
@@ -260,6 +306,11 @@ func (p *dbtLogParser) handleEvent(event dbtEvent) {
 		uid := event.Data.NodeInfo.UniqueID
 		p.removeRunning(uid, name)
 
+		p.tests.count(event.Data.Status)
+		if event.Data.NodeInfo.ResourceType == "unit_test" {
+			name = "unit test " + name
+		}
+
 		progress := fmt.Sprintf("[%d/%d]", p.finished, p.total)
 		still := p.runningStatus(event.Ts)
 		p.mu.Unlock()
@@ -317,13 +368,29 @@ func (p *dbtLogParser) handleEvent(event dbtEvent) {
 		p.emit(event.Msg)
 
 	// ── Errors ────────────────────────────────────────────────
-	case "E001", "E002", "E003", "E004", "E005":
+	case "E001", "E002", "E003", "E004", "E005",
+		"Q001": // RunningOperationCaughtError: a run-operation macro failed
 		msg := event.Data.Msg
 		if msg == "" {
 			msg = event.Msg
 		}
 		p.emit(fmt.Sprintf("  ERROR: %s", msg))
 
+	// ── Skipped node ──────────────────────────────────────────
+	case "Z033": // SkippingDetails
+		resourceType := event.Data.ResourceType
+		if resourceType == "" {
+			resourceType = event.Data.NodeInfo.ResourceType
+		}
+		if resourceType == "test" {
+			p.mu.Lock()
+			p.tests.count("skipped")
+			p.mu.Unlock()
+		}
+		if event.Msg != "" {
+			p.emit(event.Msg)
+		}
+
 	// ── Skip uninteresting events ─────────────────────────────
 	case "I030": // PartialParseNotFound
 		// skip
@@ -336,6 +403,39 @@ func (p *dbtLogParser) handleEvent(event dbtEvent) {
 	}
 }
 
+// nodeResult emits the progress line for a finished model, seed, or
+// snapshot. kind follows the node name, e.g. "table" or "seed".
+func (p *dbtLogParser) nodeResult(event dbtEvent, kind string) {
+	p.mu.Lock()
+	p.finished++
+
+	name := event.Data.NodeInfo.resolvedName()
+	uid := event.Data.NodeInfo.UniqueID
+	p.removeRunning(uid, name)
+
+	progress := fmt.Sprintf("[%d/%d]", p.finished, p.total)
+	still := p.runningStatus(event.Ts)
+	p.mu.Unlock()
+
+	if kind != "" {
+		kind = " " + kind
+	}
+
+	icon := "✓"
+	status := event.Data.Status
+	if status == "error" {
+		icon = "✗"
+	}
+
+	line := fmt.Sprintf("%s %s %s%s (%s in %.1fs)",
+		progress, icon, name, kind, status, event.Data.ExecutionTime)
+
+	if len(still) > 0 {
+		line += "  |  Running: " + strings.Join(still, ", ")
+	}
+	p.emit(line)
+}
+
 // removeRunning removes a node from the running list by unique_id or name.
 // Must be called with p.mu held.
 func (p *dbtLogParser) removeRunning(uid, name string) {
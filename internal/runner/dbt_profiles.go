@@ -44,7 +44,9 @@ type profileData struct {
 // the structured secret whose fields (host, port, database, schema, user,
 // password) are used to generate the profile.
 //
-// Returns the directory path and a cleanup function that removes the temp directory.
+// The directory is created under cfg.TempDir, or the system temp dir if it
+// is empty. Returns the directory path and a cleanup function that removes
+// the temp directory.
 func GenerateProfiles(cfg *DBTProfilesInput, resolver SecretsResolver) (string, func(), error) {
 	noop := func() {}
 
@@ -52,7 +54,7 @@ func GenerateProfiles(cfg *DBTProfilesInput, resolver SecretsResolver) (string,
 		return "", noop, fmt.Errorf("secrets resolver is required for dbt profiles generation")
 	}
 	if cfg.Connection == "" {
-		return "", noop, fmt.Errorf("dbt connection secret name is required (set connection in [dag.dbt])")
+		return "", noop, fmt.Errorf("dbt connection secret name is required (set connection in [dag.dbt] or dbt_connection on the task)")
 	}
 
 	// Resolve required fields from the structured secret
@@ -86,7 +88,7 @@ func GenerateProfiles(cfg *DBTProfilesInput, resolver SecretsResolver) (string,
 	}
 
 	// Create temp directory for profiles.yml
-	tmpDir, err := os.MkdirTemp("", "pit-dbt-profiles-*")
+	tmpDir, err := os.MkdirTemp(cfg.TempDir, "pit-dbt-profiles-*")
 	if err != nil {
 		return "", noop, fmt.Errorf("creating temp dir for profiles: %w", err)
 	}
@@ -110,7 +112,7 @@ func GenerateProfiles(cfg *DBTProfilesInput, resolver SecretsResolver) (string,
 		threads = "4"
 	}
 
-	f, err := os.Create(tmpDir + "/profiles.yml")
+	f, err := os.OpenFile(tmpDir+"/profiles.yml", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
 	if err != nil {
 		cleanup()
 		return "", noop, fmt.Errorf("creating profiles.yml: %w", err)
@@ -145,4 +147,5 @@ type DBTProfilesInput struct {
 	Driver     string // ODBC driver string; defaults to config.DefaultDBTDriver if empty
 	Threads    string
 	Connection string // structured secret name for db credentials
+	TempDir    string // parent of the generated profiles dir; "" = system temp dir
 }
@@ -1,6 +1,10 @@
 package runner
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -125,3 +129,114 @@ func TestResolve_DBT(t *testing.T) {
 		t.Errorf("error = %q, want it to mention executor", err)
 	}
 }
+
+func TestDBTLogParser_TestResults(t *testing.T) {
+	var out bytes.Buffer
+	p := newDBTLogParser(&out)
+	lines := []string{
+		`{"info":{"code":"Q035","level":"info","msg":"PASS not_null_id"},"data":{"status":"pass","node_info":{"node_name":"not_null_id","unique_id":"test.a"}}}`,
+		`{"info":{"code":"Q035","level":"info","msg":"PASS unique_id"},"data":{"status":"pass","node_info":{"node_name":"unique_id","unique_id":"test.b"}}}`,
+		`{"info":{"code":"Q035","level":"warn","msg":"WARN accepted_values"},"data":{"status":"warn","node_info":{"node_name":"accepted_values","unique_id":"test.c"}}}`,
+		`{"info":{"code":"Q035","level":"error","msg":"FAIL relationships"},"data":{"status":"fail","node_info":{"node_name":"relationships","unique_id":"test.d"}}}`,
+		`{"info":{"code":"Z033","level":"info","msg":"SKIP test"},"data":{"resource_type":"test","node_name":"downstream"}}`,
+		`{"info":{"code":"Z033","level":"info","msg":"SKIP model"},"data":{"resource_type":"model","node_name":"orders"}}`,
+		`{"info":{"code":"Q012","level":"info","msg":"OK orders"},"data":{"status":"success","node_info":{"node_name":"orders","unique_id":"model.orders"}}}`,
+	}
+	for _, l := range lines {
+		fmt.Fprintln(p, l)
+	}
+	p.Close()
+
+	want := DBTTestResults{Pass: 2, Fail: 1, Warn: 1, Skip: 1}
+	if got := p.testResults(); got != want {
+		t.Errorf("testResults() = %+v, want %+v", got, want)
+	}
+	if want.Total() != 5 {
+		t.Errorf("Total() = %d, want 5", want.Total())
+	}
+}
+
+func TestDBTLogParser_NodeTypes(t *testing.T) {
+	var out bytes.Buffer
+	p := newDBTLogParser(&out)
+	lines := []string{
+		`{"info":{"code":"Q033","level":"info","msg":"START seed"},"data":{"total":4,"node_info":{"node_name":"country_codes","unique_id":"seed.country_codes"}}}`,
+		`{"info":{"code":"Q034","level":"info","msg":"OK loaded seed"},"data":{"status":"INSERT 250","execution_time":0.42,"node_info":{"node_name":"country_codes","unique_id":"seed.country_codes"}}}`,
+		// Recognised by name, whatever the code
+		`{"info":{"name":"LogSnapshotResult","code":"Q999","level":"info","msg":"OK snapshotted"},"data":{"status":"success","execution_time":1.5,"node_info":{"node_name":"orders_snapshot","unique_id":"snapshot.orders_snapshot"}}}`,
+		`{"info":{"name":"LogTestResult","code":"Q007","level":"info","msg":"PASS"},"data":{"status":"pass","execution_time":0.2,"node_info":{"node_name":"test_is_valid_email","unique_id":"unit_test.a","resource_type":"unit_test"}}}`,
+		`{"info":{"name":"LogSeedResult","code":"Q016","level":"error","msg":"ERROR loading seed"},"data":{"status":"error","execution_time":0.1,"node_info":{"node_name":"bad_seed","unique_id":"seed.bad_seed"}}}`,
+		`{"info":{"name":"RunningOperationCaughtError","code":"Q001","level":"error","msg":"Encountered an error while running operation: boom"},"data":{}}`,
+	}
+	for _, l := range lines {
+		fmt.Fprintln(p, l)
+	}
+	p.Close()
+
+	got := out.String()
+	for _, want := range []string{
+		"[1/4] ✓ country_codes seed (INSERT 250 in 0.4s)",
+		"[2/4] ✓ orders_snapshot snapshot (success in 1.5s)",
+		"[3/4] ✓ unit test test_is_valid_email (pass, 0.2s)",
+		"[4/4] ✗ bad_seed seed (error in 0.1s)",
+		"  ERROR: Encountered an error while running operation: boom",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\ngot:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "{") {
+		t.Errorf("output contains raw JSON:\n%s", got)
+	}
+	if tests := p.testResults(); tests.Pass != 1 {
+		t.Errorf("testResults().Pass = %d, want 1 (the unit test)", tests.Pass)
+	}
+}
+
+func TestDBTLogParser_RawLogAndDebug(t *testing.T) {
+	lines := []string{
+		`{"info":{"code":"A001","level":"info","msg":"Running with dbt=1.9.1"},"data":{"v":"1.9.1"}}`,
+		`{"info":{"code":"E001","level":"debug","msg":"Acquiring new connection"},"data":{}}`,
+		`not json`,
+	}
+
+	for _, debug := range []bool{false, true} {
+		var out, raw bytes.Buffer
+		p := newDBTLogParser(&out)
+		p.raw = &raw
+		p.debug = debug
+		for _, l := range lines {
+			fmt.Fprintln(p, l)
+		}
+		p.Close()
+
+		if want := strings.Join(lines, "\n") + "\n"; raw.String() != want {
+			t.Errorf("debug=%v: raw log = %q, want %q", debug, raw.String(), want)
+		}
+		if got := strings.Contains(out.String(), "Acquiring new connection"); got != debug {
+			t.Errorf("debug=%v: debug event shown = %v\ngot:\n%s", debug, got, out.String())
+		}
+		if !strings.Contains(out.String(), "Running with dbt=1.9.1") {
+			t.Errorf("debug=%v: output missing info event\ngot:\n%s", debug, out.String())
+		}
+	}
+}
+
+func TestOpenRawLog_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dbt", "dbt.jsonl")
+	for _, line := range []string{"first\n", "second\n"} {
+		f, err := openRawLog(path)
+		if err != nil {
+			t.Fatalf("openRawLog() error: %v", err)
+		}
+		f.WriteString(line)
+		f.Close()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("raw log = %q, want both writes", data)
+	}
+}
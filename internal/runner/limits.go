@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Limits caps the resources a task process and its children may use. The
+// zero value applies no limits.
+type Limits struct {
+	MemoryBytes int64   // hard memory cap; the task is killed when exceeded
+	CPUs        float64 // CPU time cap in cores, e.g. 0.5 or 2
+	Offline     bool    // no network access (Linux only; elsewhere a warning is logged)
+}
+
+// IsZero reports whether no limits are set.
+func (l Limits) IsZero() bool {
+	return l.MemoryBytes <= 0 && l.CPUs <= 0 && !l.Offline
+}
+
+// limiter confines a process to Limits. Implementations are per platform:
+// cgroup v2 on Linux and Job Objects on Windows.
+type limiter interface {
+	// prepare is called before the process starts.
+	prepare(cmd *exec.Cmd) error
+	// attach is called once the process has started.
+	attach(cmd *exec.Cmd) error
+	// explain wraps the process's exit error with the limit that caused
+	// it, if any.
+	explain(err error) error
+	// close releases the limiter's OS resources.
+	close()
+}
+
+// runProcess runs cmd as the root of its own process tree, confined to
+// limits where the platform supports it. When the task ends — by exiting,
+// timing out, or being cancelled — anything it left running is killed. If
+// the tree or the limits cannot be set up the task still runs, and a
+// warning is written to logFile so the gap is visible next to the task
+// output. The process's resource usage is added to usage if it is non-nil.
+//
+// Offline is the exception: where the platform can cut a task off from the
+// network and fails to, the task does not run.
+func runProcess(cmd *exec.Cmd, limits Limits, usage *Usage, logFile io.Writer) error {
+	tree, err := newProcessTree()
+	if err == nil {
+		err = tree.prepare(cmd)
+	}
+	if err != nil {
+		fmt.Fprintf(logFile, "[pit] warning: child processes will not be cleaned up: %v\n", err)
+		tree = nil
+	}
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = orphanPipeGrace
+	}
+
+	if limits.Offline {
+		if err := isolateNetwork(cmd); errors.Is(err, errOfflineUnsupported) {
+			fmt.Fprintf(logFile, "[pit] warning: network access not blocked: %v\n", err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	var lim limiter
+	if limits.MemoryBytes > 0 || limits.CPUs > 0 {
+		lim, err = newLimiter(limits)
+		if err == nil {
+			if err = lim.prepare(cmd); err != nil {
+				lim.close()
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(logFile, "[pit] warning: resource limits not applied: %v\n", err)
+			lim = nil
+		}
+	}
+	if lim != nil {
+		defer lim.close()
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		if tree != nil {
+			tree.reap()
+		}
+		if limits.Offline {
+			return explainOfflineStart(err)
+		}
+		return err
+	}
+	if tree != nil {
+		if err := tree.attach(cmd); err != nil {
+			fmt.Fprintf(logFile, "[pit] warning: child processes will not be cleaned up: %v\n", err)
+		}
+	}
+	if lim != nil {
+		if err := lim.attach(cmd); err != nil {
+			fmt.Fprintf(logFile, "[pit] warning: resource limits not applied: %v\n", err)
+		}
+	}
+
+	err = cmd.Wait()
+	usage.add(cmd.ProcessState, time.Since(start))
+	// A success exit with output still held open by a background child;
+	// the child is killed below.
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	if tree != nil && tree.reap() {
+		fmt.Fprintln(logFile, "[pit] killed processes left running by the task")
+	}
+	if lim != nil {
+		err = lim.explain(err)
+	}
+	return err
+}
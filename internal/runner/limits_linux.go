@@ -0,0 +1,189 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+const (
+	cgroupMount = "/sys/fs/cgroup"
+
+	// cpuPeriod is the cpu.max accounting period in microseconds.
+	cpuPeriod = 100000
+	// minCPUQuota is the smallest quota the kernel accepts.
+	minCPUQuota = 1000
+)
+
+var (
+	cgroupOnce sync.Once
+	cgroupBase string
+	cgroupErr  error
+	cgroupSeq  atomic.Int64
+)
+
+// taskCgroupBase returns the cgroup under which per-task cgroups are
+// created, preparing it on first use.
+func taskCgroupBase() (string, error) {
+	cgroupOnce.Do(func() { cgroupBase, cgroupErr = setupCgroupBase() })
+	return cgroupBase, cgroupErr
+}
+
+// setupCgroupBase enables the memory and cpu controllers for children of
+// pit's own cgroup. cgroup v2 only allows that when the cgroup has no
+// member processes, so pit first moves itself into a "pit" leaf child —
+// the layout systemd expects for services with Delegate=yes.
+func setupCgroupBase() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupMount)
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("reading own cgroup: %w", err)
+	}
+	var rel string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			rel = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if rel == "" {
+		return "", fmt.Errorf("no cgroup v2 entry in /proc/self/cgroup")
+	}
+
+	own := filepath.Join(cgroupMount, rel)
+	leaf := filepath.Join(own, "pit")
+	hint := " (pit needs a delegated cgroup, e.g. Delegate=yes in its systemd unit)"
+	if err := os.MkdirAll(leaf, 0o755); err != nil {
+		return "", fmt.Errorf("creating cgroup: %w%s", err, hint)
+	}
+	if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return "", fmt.Errorf("moving pit into %s: %w%s", leaf, err, hint)
+	}
+	if err := os.WriteFile(filepath.Join(own, "cgroup.subtree_control"), []byte("+memory +cpu"), 0o644); err != nil {
+		return "", fmt.Errorf("enabling memory and cpu controllers in %s: %w%s", own, err, hint)
+	}
+	return own, nil
+}
+
+// cgroupLimiter places a task in its own cgroup v2 group.
+type cgroupLimiter struct {
+	dir    string
+	fd     *os.File
+	limits Limits
+}
+
+func newLimiter(limits Limits) (limiter, error) {
+	base, err := taskCgroupBase()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, fmt.Sprintf("task-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cgroup: %w", err)
+	}
+	c := &cgroupLimiter{dir: dir, limits: limits}
+
+	if limits.MemoryBytes > 0 {
+		if err := c.write("memory.max", strconv.FormatInt(limits.MemoryBytes, 10)); err != nil {
+			c.close()
+			return nil, err
+		}
+		// Without this the kernel pushes the task into swap instead of
+		// enforcing the cap. Ignored where swap accounting is disabled.
+		c.write("memory.swap.max", "0")
+	}
+	if limits.CPUs > 0 {
+		quota := int64(limits.CPUs * cpuPeriod)
+		if quota < minCPUQuota {
+			quota = minCPUQuota
+		}
+		if err := c.write("cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *cgroupLimiter) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(c.dir, file), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("setting %s: %w", file, err)
+	}
+	return nil
+}
+
+// prepare starts the process directly inside the cgroup (CLONE_INTO_CGROUP,
+// Linux 5.7+), so no child it forks can escape the limits.
+func (c *cgroupLimiter) prepare(cmd *exec.Cmd) error {
+	fd, err := os.Open(c.dir)
+	if err != nil {
+		return fmt.Errorf("opening cgroup: %w", err)
+	}
+	c.fd = fd
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(fd.Fd())
+	return nil
+}
+
+func (c *cgroupLimiter) attach(cmd *exec.Cmd) error {
+	return nil
+}
+
+// explain reports an OOM kill by the memory cap, which otherwise shows up
+// only as "signal: killed".
+func (c *cgroupLimiter) explain(err error) error {
+	if err == nil || c.limits.MemoryBytes <= 0 {
+		return err
+	}
+	data, rerr := os.ReadFile(filepath.Join(c.dir, "memory.events"))
+	if rerr != nil {
+		return err
+	}
+	if oomKills(data) > 0 {
+		return fmt.Errorf("killed for exceeding memory_limit %s: %w", config.ByteSize(c.limits.MemoryBytes), err)
+	}
+	return err
+}
+
+// close kills anything the task left behind and removes the cgroup.
+func (c *cgroupLimiter) close() {
+	if c.fd != nil {
+		c.fd.Close()
+	}
+	os.WriteFile(filepath.Join(c.dir, "cgroup.kill"), []byte("1"), 0o644)
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(c.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// oomKills returns the oom_kill count from a memory.events file.
+func oomKills(events []byte) int {
+	sc := bufio.NewScanner(bytes.NewReader(events))
+	for sc.Scan() {
+		if n, ok := strings.CutPrefix(sc.Text(), "oom_kill "); ok {
+			v, _ := strconv.Atoi(n)
+			return v
+		}
+	}
+	return 0
+}
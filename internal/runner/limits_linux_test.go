@@ -0,0 +1,15 @@
+//go:build linux
+
+package runner
+
+import "testing"
+
+func TestOOMKills(t *testing.T) {
+	events := []byte("low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 0\n")
+	if got := oomKills(events); got != 2 {
+		t.Errorf("oomKills() = %d, want 2", got)
+	}
+	if got := oomKills([]byte("low 0\n")); got != 0 {
+		t.Errorf("oomKills() without oom_kill = %d, want 0", got)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newLimiter(limits Limits) (limiter, error) {
+	return nil, fmt.Errorf("not supported on %s", runtime.GOOS)
+}
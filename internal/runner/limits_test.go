@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestLimits_IsZero(t *testing.T) {
+	if !(Limits{}).IsZero() {
+		t.Error("zero Limits should report IsZero")
+	}
+	if (Limits{CPUs: 0.5}).IsZero() || (Limits{MemoryBytes: 1 << 20}).IsZero() || (Limits{Offline: true}).IsZero() {
+		t.Error("Limits with a cap should not report IsZero")
+	}
+}
+
+func TestRunProcess(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// Whether or not this host lets pit create cgroups, the task runs;
+	// limits that cannot be applied produce a warning in the log.
+	var log bytes.Buffer
+	cmd := exec.Command("sh", "-c", "echo done")
+	cmd.Stdout = &log
+	cmd.Stderr = &log
+	if err := runProcess(cmd, Limits{MemoryBytes: 256 << 20, CPUs: 1}, nil, &log); err != nil {
+		t.Fatalf("runProcess() error: %v\n%s", err, log.String())
+	}
+	if !strings.Contains(log.String(), "done") {
+		t.Errorf("log = %q, want task output", log.String())
+	}
+	if strings.Contains(log.String(), "warning") && !strings.Contains(log.String(), "resource limits not applied") {
+		t.Errorf("unexpected warning: %q", log.String())
+	}
+}
@@ -0,0 +1,87 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION, which x/sys/windows does not define.
+type jobCPURateControl struct {
+	ControlFlags uint32
+	CPURate      uint32 // percentage of all processors, times 100
+}
+
+const (
+	jobCPURateControlEnable  = 0x1
+	jobCPURateControlHardCap = 0x4
+)
+
+// jobLimiter places a task in a Job Object with memory and CPU rate limits.
+// Closing the job kills every process still in it.
+type jobLimiter struct {
+	job windows.Handle
+}
+
+func newLimiter(limits Limits) (limiter, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating job object: %w", err)
+	}
+	j := &jobLimiter{job: job}
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if limits.MemoryBytes > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(limits.MemoryBytes)
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		j.close()
+		return nil, fmt.Errorf("setting job memory limit: %w", err)
+	}
+
+	if limits.CPUs > 0 {
+		rate := uint32(limits.CPUs / float64(runtime.NumCPU()) * 10000)
+		rate = max(1, min(rate, 10000))
+		cpu := jobCPURateControl{ControlFlags: jobCPURateControlEnable | jobCPURateControlHardCap, CPURate: rate}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&cpu)), uint32(unsafe.Sizeof(cpu))); err != nil {
+			j.close()
+			return nil, fmt.Errorf("setting job CPU rate: %w", err)
+		}
+	}
+	return j, nil
+}
+
+func (j *jobLimiter) prepare(cmd *exec.Cmd) error {
+	return nil
+}
+
+// attach assigns the started process to the job. Children it creates from
+// then on inherit the job.
+func (j *jobLimiter) attach(cmd *exec.Cmd) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return fmt.Errorf("opening task process: %w", err)
+	}
+	defer windows.CloseHandle(h)
+	if err := windows.AssignProcessToJobObject(j.job, h); err != nil {
+		return fmt.Errorf("assigning task to job object: %w", err)
+	}
+	return nil
+}
+
+func (j *jobLimiter) explain(err error) error {
+	return err
+}
+
+func (j *jobLimiter) close() {
+	windows.CloseHandle(j.job)
+}
@@ -0,0 +1,8 @@
+package runner
+
+import "errors"
+
+// errOfflineUnsupported is returned by isolateNetwork on platforms that
+// cannot cut a process off from the network. The task still runs there,
+// with a warning in its log.
+var errOfflineUnsupported = errors.New("offline tasks are only isolated on Linux")
@@ -0,0 +1,41 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolateNetwork starts the task in a new network namespace holding only
+// a loopback interface that is down, so every connection attempt fails.
+// The SDK socket is a file-system Unix socket and still works. Without
+// root the namespace needs an unprivileged user namespace, in which the
+// task keeps pit's user and group IDs.
+func isolateNetwork(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	attr.Cloneflags |= syscall.CLONE_NEWNET
+	if uid := os.Geteuid(); uid != 0 {
+		gid := os.Getegid()
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+	}
+	return nil
+}
+
+// explainOfflineStart adds the likely cause to a start failure of an
+// offline task: the kernel refused to create the namespaces.
+func explainOfflineStart(err error) error {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("offline: creating a network namespace failed (run pit as root or enable unprivileged user namespaces): %w", err)
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package runner
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRunProcess_Offline(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// /proc/net/dev lists the interfaces of the process's network namespace.
+	var log bytes.Buffer
+	cmd := exec.Command("sh", "-c", "cat /proc/net/dev")
+	cmd.Stdout = &log
+	cmd.Stderr = &log
+	err := runProcess(cmd, Limits{Offline: true}, nil, &log)
+	if err != nil && strings.Contains(err.Error(), "creating a network namespace failed") {
+		t.Skipf("network namespaces unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("runProcess() error: %v\n%s", err, log.String())
+	}
+	for _, line := range strings.Split(log.String(), "\n") {
+		name, _, ok := strings.Cut(line, ":")
+		if !ok || strings.Contains(name, "|") {
+			continue
+		}
+		if name = strings.TrimSpace(name); name != "lo" {
+			t.Errorf("offline task sees interface %q, want only lo", name)
+		}
+	}
+}
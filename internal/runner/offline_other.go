@@ -0,0 +1,13 @@
+//go:build !linux
+
+package runner
+
+import "os/exec"
+
+func isolateNetwork(cmd *exec.Cmd) error {
+	return errOfflineUnsupported
+}
+
+func explainOfflineStart(err error) error {
+	return err
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// PluginProtocol is the version of the plugin protocol, sent in every
+// request so a plugin can refuse versions it does not understand.
+//
+// A plugin is an executable listed under [plugins.<name>] in
+// pit_config.toml. pit starts it once per task or SDK call, appending a
+// subcommand to its configured command:
+//
+//   - "run" executes a task. The request is a PluginRunRequest on stdin;
+//     the working directory and environment are the task's, including
+//     PIT_SOCKET for SDK calls. stdout and stderr go to the task log, and
+//     exit status 0 means success.
+//   - "sdk" answers an SDK method. The request is a PluginSDKRequest on
+//     stdin; the plugin writes one PluginSDKResponse to stdout. stderr is
+//     included in the error when it exits non-zero.
+const PluginProtocol = 1
+
+// PluginRunRequest is sent to a plugin's "run" subcommand.
+type PluginRunRequest struct {
+	Protocol    int    `json:"protocol"`
+	Runner      string `json:"runner"` // runner name, or "" when chosen by extension
+	Script      string `json:"script"` // absolute path in the run snapshot
+	SnapshotDir string `json:"snapshot_dir"`
+	WorkDir     string `json:"work_dir"` // the process's working directory
+	DataDir     string `json:"data_dir"`
+	ProjectDir  string `json:"project_dir"` // original project directory
+	DAG         string `json:"dag"`
+}
+
+// PluginSDKRequest is sent to a plugin's "sdk" subcommand.
+type PluginSDKRequest struct {
+	Protocol int               `json:"protocol"`
+	Method   string            `json:"method"`
+	Params   map[string]string `json:"params"`
+	DAG      string            `json:"dag"`
+	RunID    string            `json:"run_id"`
+	DataDir  string            `json:"data_dir"`
+}
+
+// PluginSDKResponse is a plugin's reply to an SDK call. A non-empty Error
+// fails the call.
+type PluginSDKResponse struct {
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PluginRunner runs tasks through a plugin's "run" subcommand.
+type PluginRunner struct {
+	Plugin  string   // plugin name, for errors
+	Command []string // executable and leading arguments
+	Runner  string   // runner name the task asked for ("" = by extension)
+}
+
+func (r *PluginRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) error {
+	req, err := json.Marshal(PluginRunRequest{
+		Protocol:    PluginProtocol,
+		Runner:      r.Runner,
+		Script:      rc.ScriptPath,
+		SnapshotDir: rc.SnapshotDir,
+		WorkDir:     rc.Dir(),
+		DataDir:     rc.DataDir,
+		ProjectDir:  rc.OrigProjectDir,
+		DAG:         rc.DAGName,
+	})
+	if err != nil {
+		return fmt.Errorf("plugin %q: encoding request: %w", r.Plugin, err)
+	}
+	if _, err := exec.LookPath(r.Command[0]); err != nil {
+		return fmt.Errorf("plugin %q: command %q not found: %w", r.Plugin, r.Command[0], err)
+	}
+
+	args := append(r.Command[1:len(r.Command):len(r.Command)], "run")
+	cmd := exec.CommandContext(ctx, r.Command[0], args...)
+	cmd.Dir = rc.Dir()
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = rc.Env
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
+		return fmt.Errorf("plugin %q %s: %w", r.Plugin, rc.ScriptPath, err)
+	}
+	return nil
+}
+
+// CallPluginSDK runs a plugin's "sdk" subcommand for req and returns its result.
+func CallPluginSDK(ctx context.Context, plugin string, command []string, req PluginSDKRequest) (string, error) {
+	req.Protocol = PluginProtocol
+	in, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("plugin %q: encoding request: %w", plugin, err)
+	}
+
+	args := append(command[1:len(command):len(command)], "sdk")
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Stdin = bytes.NewReader(in)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return "", fmt.Errorf("plugin %q: %w: %s", plugin, err, msg)
+		}
+		return "", fmt.Errorf("plugin %q: %w", plugin, err)
+	}
+
+	var resp PluginSDKResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("plugin %q: decoding response: %w", plugin, err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("%s", resp.Error)
+	}
+	return resp.Result, nil
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a shell-script plugin that echoes its run request to
+// the task log and answers SDK calls by method.
+func writePlugin(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin test script needs a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "pit-sas")
+	script := `#!/bin/sh
+req=$(cat)
+case "$1" in
+run)
+	echo "request: $req"
+	echo "task: $PIT_TASK_NAME"
+	case "$req" in *fail.sas*) exit 3 ;; esac
+	;;
+sdk)
+	case "$req" in
+	*'"method":"submit_job"'*) echo '{"result":"job-42"}' ;;
+	*'"method":"bad_job"'*) echo '{"error":"queue is closed"}' ;;
+	*) echo "unknown method" >&2; exit 2 ;;
+	esac
+	;;
+esac
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginRunner(t *testing.T) {
+	plugin := writePlugin(t)
+	dir := t.TempDir()
+	r := &PluginRunner{Plugin: "sas", Command: []string{plugin}, Runner: "sas"}
+	rc := RunContext{
+		ScriptPath:  filepath.Join(dir, "tasks", "report.sas"),
+		SnapshotDir: dir,
+		DAGName:     "etl",
+		Env:         []string{"PIT_TASK_NAME=report"},
+	}
+
+	var log bytes.Buffer
+	if err := r.Run(context.Background(), rc, &log); err != nil {
+		t.Fatalf("Run() error: %v\n%s", err, log.String())
+	}
+	line, _, _ := strings.Cut(strings.TrimPrefix(log.String(), "request: "), "\n")
+	var req PluginRunRequest
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		t.Fatalf("decoding request %q: %v", line, err)
+	}
+	if req.Protocol != PluginProtocol || req.Runner != "sas" || req.Script != rc.ScriptPath || req.DAG != "etl" {
+		t.Errorf("request = %+v", req)
+	}
+	if !strings.Contains(log.String(), "task: report") {
+		t.Errorf("log = %q, want the task environment passed through", log.String())
+	}
+
+	rc.ScriptPath = filepath.Join(dir, "tasks", "fail.sas")
+	if err := r.Run(context.Background(), rc, &log); err == nil || !strings.Contains(err.Error(), `plugin "sas"`) {
+		t.Errorf("Run() error = %v, want plugin failure", err)
+	}
+}
+
+func TestCallPluginSDK(t *testing.T) {
+	plugin := writePlugin(t)
+	ctx := context.Background()
+
+	got, err := CallPluginSDK(ctx, "sas", []string{plugin}, PluginSDKRequest{Method: "submit_job", Params: map[string]string{"program": "x.sas"}})
+	if err != nil || got != "job-42" {
+		t.Errorf("CallPluginSDK(submit_job) = %q, %v; want job-42, nil", got, err)
+	}
+	if _, err := CallPluginSDK(ctx, "sas", []string{plugin}, PluginSDKRequest{Method: "bad_job"}); err == nil || err.Error() != "queue is closed" {
+		t.Errorf("CallPluginSDK(bad_job) error = %v, want the plugin's error", err)
+	}
+	if _, err := CallPluginSDK(ctx, "sas", []string{plugin}, PluginSDKRequest{Method: "other"}); err == nil || !strings.Contains(err.Error(), "unknown method") {
+		t.Errorf("CallPluginSDK(other) error = %v, want stderr in the error", err)
+	}
+}
@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"os/exec"
+	"time"
+)
+
+// orphanPipeGrace is how long Wait keeps reading a task's output after the
+// task process exits. A background child that inherited stdout would
+// otherwise hold the pipe open and block the task until it exits too.
+const orphanPipeGrace = 2 * time.Second
+
+// processTree groups a task process with every process it spawns so the
+// whole tree can be killed at once: a process group on Unix and a Job
+// Object on Windows.
+//
+// newProcessTree is implemented per platform.
+type processTree interface {
+	// prepare is called before the process starts. It also arranges for
+	// context cancellation to kill the whole tree, not just the root.
+	prepare(cmd *exec.Cmd) error
+	// attach is called once the process has started.
+	attach(cmd *exec.Cmd) error
+	// reap kills any processes still in the tree and reports whether
+	// there were any.
+	reap() bool
+}
@@ -0,0 +1,12 @@
+//go:build !unix && !windows
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newProcessTree() (processTree, error) {
+	return nil, fmt.Errorf("not supported on %s", runtime.GOOS)
+}
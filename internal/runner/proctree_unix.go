@@ -0,0 +1,48 @@
+//go:build unix
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// pgroupTree runs a task as the leader of a new process group. Children
+// that move to their own session (setsid, daemonising tools) leave the
+// group; on Linux a cgroup from resource limits still catches those.
+type pgroupTree struct {
+	pgid int
+}
+
+func newProcessTree() (processTree, error) {
+	return &pgroupTree{}, nil
+}
+
+func (p *pgroupTree) prepare(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	// Cancel is set only for commands created with CommandContext.
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error {
+			return unix.Kill(-cmd.Process.Pid, unix.SIGKILL)
+		}
+	}
+	return nil
+}
+
+func (p *pgroupTree) attach(cmd *exec.Cmd) error {
+	p.pgid = cmd.Process.Pid
+	return nil
+}
+
+func (p *pgroupTree) reap() bool {
+	if p.pgid <= 0 || unix.Kill(-p.pgid, 0) != nil {
+		return false
+	}
+	unix.Kill(-p.pgid, unix.SIGKILL)
+	return true
+}
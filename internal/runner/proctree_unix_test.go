@@ -0,0 +1,57 @@
+//go:build unix
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunProcess_KillsLeftoverChildren(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// The background sleep inherits stdout, so without cleanup Wait would
+	// block until it exits.
+	var log bytes.Buffer
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo started")
+	cmd.Stdout = &log
+	cmd.Stderr = &log
+	start := time.Now()
+	if err := runProcess(cmd, Limits{}, nil, &log); err != nil {
+		t.Fatalf("runProcess() error: %v\n%s", err, log.String())
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("runProcess() took %s, want it to return once sh exits", d)
+	}
+	if !strings.Contains(log.String(), "killed processes left running by the task") {
+		t.Errorf("log = %q, want cleanup notice", log.String())
+	}
+}
+
+func TestRunProcess_CancelKillsTree(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var log bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30 & sleep 30")
+	cmd.Stdout = &log
+	cmd.Stderr = &log
+	start := time.Now()
+	if err := runProcess(cmd, Limits{}, nil, &log); err == nil {
+		t.Fatal("runProcess() expected error after cancellation")
+	}
+	// Killing only sh would leave the sleeps holding stdout until
+	// orphanPipeGrace expires.
+	if d := time.Since(start); d >= orphanPipeGrace {
+		t.Errorf("runProcess() took %s after cancellation, want the whole tree killed at once", d)
+	}
+}
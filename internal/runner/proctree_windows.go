@@ -0,0 +1,77 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// JOBOBJECT_BASIC_ACCOUNTING_INFORMATION, which x/sys/windows does not define.
+type jobBasicAccounting struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// jobTree places a task in a Job Object that kills every process still in
+// it when the job is closed.
+type jobTree struct {
+	job windows.Handle
+}
+
+func newProcessTree() (processTree, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating job object: %w", err)
+	}
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("configuring job object: %w", err)
+	}
+	return &jobTree{job: job}, nil
+}
+
+func (j *jobTree) prepare(cmd *exec.Cmd) error {
+	// Cancel is set only for commands created with CommandContext.
+	if cmd.Cancel != nil {
+		cmd.Cancel = func() error {
+			windows.TerminateJobObject(j.job, 1)
+			return cmd.Process.Kill()
+		}
+	}
+	return nil
+}
+
+// attach assigns the started process to the job. Children it creates from
+// then on inherit the job.
+func (j *jobTree) attach(cmd *exec.Cmd) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return fmt.Errorf("opening task process: %w", err)
+	}
+	defer windows.CloseHandle(h)
+	if err := windows.AssignProcessToJobObject(j.job, h); err != nil {
+		return fmt.Errorf("assigning task to job object: %w", err)
+	}
+	return nil
+}
+
+func (j *jobTree) reap() bool {
+	var acct jobBasicAccounting
+	err := windows.QueryInformationJobObject(j.job, windows.JobObjectBasicAccountingInformation,
+		uintptr(unsafe.Pointer(&acct)), uint32(unsafe.Sizeof(acct)), nil)
+	windows.CloseHandle(j.job)
+	return err == nil && acct.ActiveProcesses > 0
+}
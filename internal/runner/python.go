@@ -13,13 +13,22 @@ import (
 type PythonRunner struct{}
 
 func (r *PythonRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) error {
-	cmd := exec.CommandContext(ctx, "uv", "run", "--project", rc.OrigProjectDir, rc.ScriptPath)
-	cmd.Dir = rc.SnapshotDir
+	cmd := exec.CommandContext(ctx, "uv", pythonArgs(rc)...)
+	cmd.Dir = rc.Dir()
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
 	cmd.Env = rc.Env
-	if err := cmd.Run(); err != nil {
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
 		return fmt.Errorf("python runner %s: %w", rc.ScriptPath, err)
 	}
 	return nil
 }
+
+// pythonArgs returns the uv arguments that run rc.ScriptPath.
+func pythonArgs(rc RunContext) []string {
+	args := []string{"run", "--project", rc.OrigProjectDir}
+	if rc.PythonVersion != "" {
+		args = append(args, "--python", rc.PythonVersion)
+	}
+	return append(args, rc.ScriptPath)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // SecretsResolver resolves secrets by project scope. nil if no secrets configured.
@@ -17,14 +18,22 @@ type SecretsResolver interface {
 // RunContext holds the information a runner needs to execute a task.
 type RunContext struct {
 	ScriptPath     string   // absolute path to script in snapshot
-	SnapshotDir    string   // runs/{run_id}/project/
+	SnapshotDir    string   // runs/{run_id}/project/, or the project itself when InPlace
+	InPlace        bool     // SnapshotDir is the live project (snapshot = false); runners must not modify it
+	ScratchDir     string   // the task's scratch directory, also in PIT_SCRATCH_DIR
+	WorkDir        string   // directory the task process starts in, inside SnapshotDir ("" = SnapshotDir)
+	DataDir        string   // the run's data directory, also in PIT_DATA_DIR
 	OrigProjectDir string   // original projects/{name}/ (for uv --project)
 	Env            []string // full process environment (os.Environ() + PIT_* vars)
+	Limits         Limits   // OS-level resource caps for process-based runners
+	PythonVersion  string   // [dag.python].version, passed to uv as --python
+	Usage          *Usage   // if set, process-based runners add their usage here
 
 	// SQL-specific fields — zero-value when unused.
 	SecretsResolver SecretsResolver // resolves secrets by project scope
 	DAGName         string          // for scoped secret resolution
 	SQLConnection   string          // connection name from [dag.sql].connection
+	StmtTimeout     time.Duration   // cancel the statement after this long (0 = no limit)
 }
 
 // ValidateScript checks that ScriptPath is contained within SnapshotDir,
@@ -40,6 +49,31 @@ func (rc RunContext) ValidateScript() error {
 	return nil
 }
 
+// ValidateWorkDir checks that WorkDir, when set, is contained within
+// SnapshotDir, so a task cannot start outside its run's project snapshot.
+func (rc RunContext) ValidateWorkDir() error {
+	if rc.WorkDir == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(rc.SnapshotDir, rc.WorkDir)
+	if err != nil {
+		return fmt.Errorf("resolving workdir: %w", err)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("workdir %q escapes snapshot directory", rc.WorkDir)
+	}
+	return nil
+}
+
+// Dir returns the directory the task process starts in: WorkDir when set,
+// otherwise SnapshotDir.
+func (rc RunContext) Dir() string {
+	if rc.WorkDir != "" {
+		return rc.WorkDir
+	}
+	return rc.SnapshotDir
+}
+
 // Runner executes a task script.
 //
 // Contract:
@@ -55,6 +89,8 @@ var (
 	shellRunner  = &ShellRunner{}
 	pythonRunner = &PythonRunner{}
 	sqlRunner    = &SQLRunner{}
+	batchRunner  = &BatchRunner{}
+	psRunner     = &PowerShellRunner{}
 )
 
 // Resolve returns the appropriate Runner for a task based on the runner field
@@ -62,9 +98,10 @@ var (
 //
 // Dispatch rules:
 //   - If runner is set and starts with "$ ", use CustomRunner with the command after "$ "
-//   - If runner is set to "python", "bash", or "sql", use the corresponding runner
+//   - If runner is set to "python", "bash", "cmd", "powershell", or "sql", use the corresponding runner
 //   - If runner is set to anything else, return an error
-//   - If runner is unset, dispatch by file extension: .py→Python, .sh→Shell, .sql→SQL
+//   - If runner is unset, dispatch by file extension: .py→Python, .sh→Shell,
+//     .bat/.cmd→Batch, .ps1→PowerShell, .sql→SQL
 //   - If no extension matches, return an error (no silent fallback)
 func Resolve(taskRunner string, scriptPath string) (Runner, error) {
 	if taskRunner != "" {
@@ -80,24 +117,32 @@ func Resolve(taskRunner string, scriptPath string) (Runner, error) {
 			return pythonRunner, nil
 		case "bash":
 			return shellRunner, nil
+		case "cmd":
+			return batchRunner, nil
+		case "powershell":
+			return psRunner, nil
 		case "sql":
 			return sqlRunner, nil
 		case "dbt":
 			return nil, fmt.Errorf("dbt runner is created by the executor — not available via Resolve()")
 		default:
-			return nil, fmt.Errorf("unknown runner %q (use python, bash, sql, dbt, or $ <command>)", taskRunner)
+			return nil, fmt.Errorf("unknown runner %q (use python, bash, cmd, powershell, sql, dbt, or $ <command>)", taskRunner)
 		}
 	}
 
-	ext := filepath.Ext(scriptPath)
+	ext := strings.ToLower(filepath.Ext(scriptPath))
 	switch ext {
 	case ".py":
 		return pythonRunner, nil
 	case ".sh":
 		return shellRunner, nil
+	case ".bat", ".cmd":
+		return batchRunner, nil
+	case ".ps1":
+		return psRunner, nil
 	case ".sql":
 		return sqlRunner, nil
 	default:
-		return nil, fmt.Errorf("unsupported script extension %q — set runner explicitly in pit.toml (python, bash, sql, or $ <command>)", ext)
+		return nil, fmt.Errorf("unsupported script extension %q — set runner explicitly in pit.toml (python, bash, cmd, powershell, sql, or $ <command>)", ext)
 	}
 }
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -16,6 +17,8 @@ func TestResolve_ExplicitRunner(t *testing.T) {
 		{name: "python", runner: "python", script: "x.py", wantType: "*runner.PythonRunner"},
 		{name: "bash", runner: "bash", script: "x.sh", wantType: "*runner.ShellRunner"},
 		{name: "sql", runner: "sql", script: "x.sql", wantType: "*runner.SQLRunner"},
+		{name: "cmd", runner: "cmd", script: "x.bat", wantType: "*runner.BatchRunner"},
+		{name: "powershell", runner: "powershell", script: "x.ps1", wantType: "*runner.PowerShellRunner"},
 		{name: "custom", runner: "$ node", script: "x.js", wantType: "*runner.CustomRunner"},
 		{name: "custom with args", runner: "$ dbt run --target", script: "x.sql", wantType: "*runner.CustomRunner"},
 		{name: "empty custom", runner: "$ ", script: "x.sh", wantErr: true, errContain: "empty"},
@@ -55,6 +58,10 @@ func TestResolve_ExtensionDispatch(t *testing.T) {
 		{name: "py", script: "tasks/hello.py", wantType: "*runner.PythonRunner"},
 		{name: "sh", script: "tasks/hello.sh", wantType: "*runner.ShellRunner"},
 		{name: "sql", script: "tasks/query.sql", wantType: "*runner.SQLRunner"},
+		{name: "bat", script: "tasks/load.bat", wantType: "*runner.BatchRunner"},
+		{name: "cmd", script: "tasks/load.cmd", wantType: "*runner.BatchRunner"},
+		{name: "upper-case bat", script: `tasks\LOAD.BAT`, wantType: "*runner.BatchRunner"},
+		{name: "ps1", script: "tasks/load.ps1", wantType: "*runner.PowerShellRunner"},
 		{name: "unknown ext", script: "tasks/run.rb", wantErr: true},
 		{name: "no ext", script: "tasks/Makefile", wantErr: true},
 	}
@@ -144,6 +151,30 @@ func TestValidateScript(t *testing.T) {
 	}
 }
 
+func TestValidateWorkDir(t *testing.T) {
+	tests := []struct {
+		workDir string
+		wantErr bool
+		wantDir string
+	}{
+		{"", false, "/runs/123/project"},
+		{"/runs/123/project/pipelines/claims", false, "/runs/123/project/pipelines/claims"},
+		{"/runs/123/project/..foo", false, "/runs/123/project/..foo"},
+		{"/runs/123/project/../data", true, ""},
+		{"/tmp", true, ""},
+	}
+	for _, tt := range tests {
+		rc := RunContext{SnapshotDir: "/runs/123/project", WorkDir: tt.workDir}
+		err := rc.ValidateWorkDir()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateWorkDir() with workdir %q = %v, want error %v", tt.workDir, err, tt.wantErr)
+		}
+		if !tt.wantErr && rc.Dir() != tt.wantDir {
+			t.Errorf("Dir() = %q, want %q", rc.Dir(), tt.wantDir)
+		}
+	}
+}
+
 // typeName returns the type name of a value as a string for comparison.
 func typeName(v interface{}) string {
 	return typeNameFmt(v)
@@ -159,6 +190,10 @@ func typeNameFmt(v interface{}) string {
 		return "*runner.SQLRunner"
 	case *CustomRunner:
 		return "*runner.CustomRunner"
+	case *BatchRunner:
+		return "*runner.BatchRunner"
+	case *PowerShellRunner:
+		return "*runner.PowerShellRunner"
 	default:
 		return "unknown"
 	}
@@ -178,6 +213,7 @@ func TestDetectDriver(t *testing.T) {
 		{name: "postgresql prefix", connStr: "postgresql://host/db", wantDriver: "postgres"},
 		{name: "clickhouse prefix", connStr: "clickhouse://host/db", wantDriver: "clickhouse"},
 		{name: "oracle prefix", connStr: "oracle://host/db", wantDriver: "oracle"},
+		{name: "sqlite prefix", connStr: "sqlite:///data/warehouse.db", wantDriver: "sqlite"},
 		{name: "unsupported mysql", connStr: "mysql://host/db", wantErr: true},
 		{name: "unsupported http", connStr: "http://example.com", wantErr: true},
 		{name: "empty string", connStr: "", wantErr: true},
@@ -205,6 +241,20 @@ func TestDetectDriver(t *testing.T) {
 	}
 }
 
+func TestPythonArgs(t *testing.T) {
+	rc := RunContext{OrigProjectDir: "/projects/sales", ScriptPath: "/runs/r1/project/tasks/extract.py"}
+	got := strings.Join(pythonArgs(rc), " ")
+	if want := "run --project /projects/sales /runs/r1/project/tasks/extract.py"; got != want {
+		t.Errorf("pythonArgs() = %q, want %q", got, want)
+	}
+
+	rc.PythonVersion = "3.12"
+	got = strings.Join(pythonArgs(rc), " ")
+	if want := "run --project /projects/sales --python 3.12 /runs/r1/project/tasks/extract.py"; got != want {
+		t.Errorf("pythonArgs() with version = %q, want %q", got, want)
+	}
+}
+
 func containsStr(s, substr string) bool {
 	return len(s) >= len(substr) && searchStr(s, substr)
 }
@@ -1,23 +1,106 @@
 package runner
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
-// ShellRunner executes scripts using bash.
+// ShellRunner executes scripts using bash. On Windows, bash comes from Git
+// for Windows or, failing that, WSL; see bashCommand.
 type ShellRunner struct{}
 
 func (r *ShellRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) error {
-	cmd := exec.CommandContext(ctx, "bash", rc.ScriptPath)
-	cmd.Dir = rc.SnapshotDir
+	// bash needs LF line endings: a stray \r ends up in every command name
+	// and argument. The snapshot may be read-only and an in-place project
+	// must not be rewritten, so CRLF scripts run from a converted copy.
+	lf, err := lfCopy(rc.ScriptPath, rc.ScratchDir)
+	if err != nil {
+		return fmt.Errorf("shell runner %s: %w", rc.ScriptPath, err)
+	}
+	if lf != "" {
+		fmt.Fprintf(logFile, "[pit] %s has CRLF line endings; running an LF copy from the scratch directory\n", filepath.Base(rc.ScriptPath))
+		rc.ScriptPath = lf
+	}
+
+	name, args, env, err := bashCommand(rc)
+	if err != nil {
+		return fmt.Errorf("shell runner %s: %w", rc.ScriptPath, err)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = rc.Dir()
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
-	cmd.Env = rc.Env
-	if err := cmd.Run(); err != nil {
+	cmd.Env = env
+	if err := runProcess(cmd, rc.Limits, rc.Usage, logFile); err != nil {
 		return fmt.Errorf("shell runner %s: %w", rc.ScriptPath, err)
 	}
 	return nil
 }
+
+// lfCopy writes an LF copy of a CRLF script into dir and returns its
+// path, leaving the original untouched. It returns "" when the script
+// needs no conversion.
+func lfCopy(path, dir string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Contains(data, []byte("\r\n")) {
+		return "", nil
+	}
+	f, err := os.CreateTemp(dir, "*-"+filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("converting line endings: %w", err)
+	}
+	_, err = f.Write(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("converting line endings: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// wslPath translates a Windows path such as C:\runs\x\project to the path
+// WSL mounts it at, /mnt/c/runs/x/project. Other paths are returned with
+// forward slashes.
+func wslPath(path string) string {
+	p := strings.ReplaceAll(path, `\`, "/")
+	if len(p) >= 2 && p[1] == ':' {
+		return "/mnt/" + strings.ToLower(p[:1]) + p[2:]
+	}
+	return p
+}
+
+// wslEnv returns env with a WSLENV entry that forwards the PIT_* variables
+// into WSL, which otherwise passes through none of the Windows environment.
+// Variables holding paths are marked /p so WSL translates them.
+func wslEnv(env []string) []string {
+	out := make([]string, 0, len(env)+1)
+	var forward []string
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		switch {
+		case strings.EqualFold(name, "WSLENV"):
+			if value != "" {
+				forward = append(forward, value)
+			}
+			continue
+		case strings.HasPrefix(name, "PIT_"):
+			if strings.HasSuffix(name, "_DIR") || name == "PIT_SOCKET" {
+				name += "/p"
+			}
+			forward = append(forward, name)
+		}
+		out = append(out, kv)
+	}
+	return append(out, "WSLENV="+strings.Join(forward, ":"))
+}
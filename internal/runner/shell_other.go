@@ -0,0 +1,8 @@
+//go:build !windows
+
+package runner
+
+// bashCommand returns the command that runs rc.ScriptPath with bash.
+func bashCommand(rc RunContext) (name string, args, env []string, err error) {
+	return "bash", []string{rc.ScriptPath}, rc.Env, nil
+}
@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestShellRunner_CRLFReadOnlySnapshot runs a CRLF script from a read-only
+// snapshot: it must run converted, and the snapshot copy stay as it was.
+func TestShellRunner_CRLFReadOnlySnapshot(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	snapshot, scratch := t.TempDir(), t.TempDir()
+	script := filepath.Join(snapshot, "load.sh")
+	const content = "name=world\r\necho \"hello $name.\"\r\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(script, 0o555)
+	os.Chmod(snapshot, 0o555)
+	t.Cleanup(func() { os.Chmod(snapshot, 0o755) })
+
+	var log bytes.Buffer
+	rc := RunContext{ScriptPath: script, SnapshotDir: snapshot, ScratchDir: scratch, Env: os.Environ()}
+	if err := (&ShellRunner{}).Run(context.Background(), rc, &log); err != nil {
+		t.Fatalf("Run() error: %v\n%s", err, log.String())
+	}
+	if !strings.Contains(log.String(), "hello world.\n") {
+		t.Errorf("log = %q, want the script run with LF line endings", log.String())
+	}
+	if !strings.Contains(log.String(), "CRLF line endings") {
+		t.Errorf("log = %q, want a note about the conversion", log.String())
+	}
+	if data, _ := os.ReadFile(script); string(data) != content {
+		t.Errorf("snapshot script = %q, want it untouched", data)
+	}
+}
+
+func TestLFCopy(t *testing.T) {
+	project, scratch := t.TempDir(), t.TempDir()
+	crlf := filepath.Join(project, "crlf.sh")
+	os.WriteFile(crlf, []byte("echo hi\r\n"), 0o755)
+
+	cp, err := lfCopy(crlf, scratch)
+	if err != nil {
+		t.Fatalf("lfCopy() error: %v", err)
+	}
+	if filepath.Dir(cp) != scratch {
+		t.Errorf("lfCopy() = %q, want a file in the scratch dir", cp)
+	}
+	if data, _ := os.ReadFile(cp); string(data) != "echo hi\n" {
+		t.Errorf("copy = %q, want LF line endings", data)
+	}
+	if data, _ := os.ReadFile(crlf); string(data) != "echo hi\r\n" {
+		t.Errorf("original = %q, want it untouched", data)
+	}
+
+	lf := filepath.Join(project, "lf.sh")
+	os.WriteFile(lf, []byte("echo hi\n"), 0o755)
+	if cp, err := lfCopy(lf, scratch); cp != "" || err != nil {
+		t.Errorf("lfCopy() on LF script = %q, %v; want \"\", nil", cp, err)
+	}
+}
+
+func TestWSLPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`C:\runs\20240115_143022.123_etl\project\tasks\load.sh`, "/mnt/c/runs/20240115_143022.123_etl/project/tasks/load.sh"},
+		{`d:\data`, "/mnt/d/data"},
+		{`tasks\load.sh`, "tasks/load.sh"},
+	}
+	for _, tt := range tests {
+		if got := wslPath(tt.in); got != tt.want {
+			t.Errorf("wslPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWSLEnv(t *testing.T) {
+	env := []string{"PATH=C:\\Windows", "WSLENV=FOO", "PIT_RUN_ID=r1", "PIT_DATA_DIR=C:\\runs\\r1\\data", "PIT_SOCKET=C:\\tmp\\pit.sock"}
+	got := wslEnv(env)
+	want := []string{"PATH=C:\\Windows", "PIT_RUN_ID=r1", "PIT_DATA_DIR=C:\\runs\\r1\\data", "PIT_SOCKET=C:\\tmp\\pit.sock",
+		"WSLENV=FOO:PIT_RUN_ID:PIT_DATA_DIR/p:PIT_SOCKET/p"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wslEnv() =\n  %q\nwant\n  %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// bashCommand returns the command that runs rc.ScriptPath with bash. Git
+// Bash is preferred because it sees Windows paths and the environment as
+// they are; WSL is the fallback, with paths and PIT_* variables translated.
+func bashCommand(rc RunContext) (name string, args, env []string, err error) {
+	if bash := findGitBash(); bash != "" {
+		return bash, []string{rc.ScriptPath}, rc.Env, nil
+	}
+	if wsl, err := exec.LookPath("wsl.exe"); err == nil {
+		return wsl, []string{"-e", "bash", wslPath(rc.ScriptPath)}, wslEnv(rc.Env), nil
+	}
+	return "", nil, nil, fmt.Errorf("bash not found: install Git for Windows or WSL, or use a .bat, .cmd, or .ps1 script")
+}
+
+// findGitBash returns the path to a bash.exe that is not the WSL launcher:
+// one on PATH, or Git for Windows in its default install locations.
+func findGitBash() string {
+	if p, err := exec.LookPath("bash.exe"); err == nil && !isWSLLauncher(p) {
+		return p
+	}
+	for _, root := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)"),
+		filepath.Join(os.Getenv("LocalAppData"), "Programs")} {
+		if root == "" {
+			continue
+		}
+		p := filepath.Join(root, "Git", "bin", "bash.exe")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// isWSLLauncher reports whether p is the bash.exe that Windows ships to
+// start WSL, which takes Linux paths and drops the Windows environment.
+func isWSLLauncher(p string) bool {
+	dir := strings.ToLower(filepath.Dir(p))
+	sys := strings.ToLower(filepath.Join(os.Getenv("SystemRoot"), "System32"))
+	return dir == sys || strings.HasSuffix(dir, `\windowsapps`)
+}
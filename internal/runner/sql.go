@@ -3,15 +3,30 @@ package runner
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/golang-sql/sqlexp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/microsoft/go-mssqldb" // register "mssql" driver
 )
 
+// cancelGrace is how long a cancelled Postgres statement has to stop on
+// the server before the connection is dropped.
+const cancelGrace = 5 * time.Second
+
+// sqlHeartbeat is how often a running statement logs that it is still
+// executing. A variable so tests can shorten it.
+var sqlHeartbeat = time.Minute
+
 // SQLRunner executes .sql files against a database connection resolved from the secrets store.
 type SQLRunner struct{}
 
@@ -26,7 +41,7 @@ func (r *SQLRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) e
 	}
 
 	// Resolve the connection string from the secrets store
-	connStr, err := rc.SecretsResolver.Resolve(rc.DAGName, rc.SQLConnection)
+	connStr, err := ResolveConnection(rc.SecretsResolver, rc.DAGName, rc.SQLConnection)
 	if err != nil {
 		return fmt.Errorf("sql runner resolving connection %q: %w", rc.SQLConnection, err)
 	}
@@ -41,27 +56,158 @@ func (r *SQLRunner) Run(ctx context.Context, rc RunContext, logFile io.Writer) e
 		return fmt.Errorf("sql runner reading %s: %w", rc.ScriptPath, err)
 	}
 
-	db, err := sql.Open(driver, connStr)
+	db, err := OpenDB(driver, connStr)
 	if err != nil {
 		return fmt.Errorf("sql runner opening %s connection: %w", driver, err)
 	}
 	defer db.Close()
 
+	// Heartbeats and server messages arrive while the statement runs
+	log := &lockedWriter{w: logFile}
 	start := time.Now()
-	result, err := db.ExecContext(ctx, string(content))
+	stopHeartbeat := startHeartbeat(log, start)
+	rows, err := execStatement(ctx, db, driver, string(content), rc.StmtTimeout, log)
+	stopHeartbeat()
 	elapsed := time.Since(start)
 
 	if err != nil {
 		return fmt.Errorf("sql runner executing %s: %w", rc.ScriptPath, err)
 	}
 
-	rows, _ := result.RowsAffected()
 	fmt.Fprintf(logFile, "[sql] %s executed in %s (%d rows affected)\n",
 		rc.ScriptPath, elapsed.Round(time.Millisecond), rows)
 
 	return nil
 }
 
+// execStatement runs query, cancelling it after timeout (0 = no limit),
+// and returns the number of rows affected. On SQL Server, PRINT and
+// RAISERROR messages are written to log as the server sends them.
+func execStatement(ctx context.Context, db *sql.DB, driver, query string, timeout time.Duration, log io.Writer) (int64, error) {
+	execCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var rows int64
+	var err error
+	if driver == "mssql" {
+		rows, err = execWithMessages(execCtx, db, query, log)
+	} else {
+		var result sql.Result
+		if result, err = db.ExecContext(execCtx, query); err == nil {
+			rows, _ = result.RowsAffected()
+		}
+	}
+	if err != nil && ctx.Err() == nil && errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+		return 0, fmt.Errorf("statement_timeout of %s exceeded: %w", timeout, err)
+	}
+	return rows, err
+}
+
+// execWithMessages runs query on a driver that supports sqlexp messages
+// (go-mssqldb), logging notices as they arrive. Result sets are discarded.
+// The first error the server reports is returned once the batch ends.
+func execWithMessages(ctx context.Context, db *sql.DB, query string, log io.Writer) (int64, error) {
+	msgs := &sqlexp.ReturnMessage{}
+	rs, err := db.QueryContext(ctx, query, msgs)
+	if err != nil {
+		return 0, err
+	}
+	defer rs.Close()
+
+	var affected int64
+	var firstErr error
+	for active := true; active; {
+		switch m := msgs.Message(ctx).(type) {
+		case sqlexp.MsgNotice:
+			fmt.Fprintf(log, "[sql] %s\n", m.Message)
+		case sqlexp.MsgRowsAffected:
+			affected += m.Count
+		case sqlexp.MsgError:
+			if firstErr == nil {
+				firstErr = m.Error
+			}
+		case sqlexp.MsgNext:
+			for rs.Next() {
+			}
+		case sqlexp.MsgNextResultSet:
+			active = rs.NextResultSet()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return affected, err
+	}
+	if firstErr != nil {
+		return affected, firstErr
+	}
+	return affected, rs.Err()
+}
+
+// startHeartbeat logs "still executing" every sqlHeartbeat until the
+// returned function is called.
+func startHeartbeat(log io.Writer, start time.Time) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(sqlHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fmt.Fprintf(log, "[sql] still executing (elapsed %s)\n", time.Since(start).Round(time.Second))
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// lockedWriter serialises writes from the statement and its heartbeat.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// OpenDB opens a database handle for a connection string whose driver was
+// found with DetectDriver. Statements stop on the server when their
+// context is cancelled: go-mssqldb, go-ora, and clickhouse-go do this
+// themselves; pgx by default only closes the client side of the
+// connection, leaving the statement running, so Postgres connections are
+// set to send a cancel request first. A sqlite:// connection string names
+// the database file after the scheme.
+func OpenDB(driver, connStr string) (*sql.DB, error) {
+	switch driver {
+	case "postgres":
+		cfg, err := pgx.ParseConfig(connStr)
+		if err != nil {
+			return nil, err
+		}
+		cfg.BuildContextWatcherHandler = func(conn *pgconn.PgConn) ctxwatch.Handler {
+			return &pgconn.CancelRequestContextWatcherHandler{Conn: conn, DeadlineDelay: cancelGrace}
+		}
+		return stdlib.OpenDB(*cfg), nil
+	case "sqlite":
+		return sql.Open(driver, connStr[len("sqlite://"):])
+	default:
+		return sql.Open(driver, connStr)
+	}
+}
+
 // runStub provides backwards-compatible stub behaviour when no secrets are configured.
 func (r *SQLRunner) runStub(ctx context.Context, rc RunContext, logFile io.Writer) error {
 	if err := ctx.Err(); err != nil {
@@ -80,6 +226,20 @@ func (r *SQLRunner) runStub(ctx context.Context, rc RunContext, logFile io.Write
 	return nil
 }
 
+// SQLErrorCode returns the code the database gave a failed statement, such
+// as "SQLSTATE 23505" or "SQL Server error 2627", or "" if err has none.
+func SQLErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return "SQLSTATE " + pgErr.Code
+	}
+	var msErr interface{ SQLErrorNumber() int32 }
+	if errors.As(err, &msErr) {
+		return fmt.Sprintf("SQL Server error %d", msErr.SQLErrorNumber())
+	}
+	return ""
+}
+
 // DetectDriver determines the database/sql driver name from a connection string.
 func DetectDriver(connStr string) (string, error) {
 	lower := strings.ToLower(connStr)
@@ -92,7 +252,9 @@ func DetectDriver(connStr string) (string, error) {
 		return "clickhouse", nil
 	case strings.HasPrefix(lower, "oracle://"):
 		return "oracle", nil
+	case strings.HasPrefix(lower, "sqlite://"):
+		return "sqlite", nil
 	default:
-		return "", fmt.Errorf("cannot detect SQL driver from connection string (supported: sqlserver://, postgres://, clickhouse://, oracle://)")
+		return "", fmt.Errorf("cannot detect SQL driver from connection string (supported: sqlserver://, postgres://, clickhouse://, oracle://, sqlite://)")
 	}
 }
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"os"
+	"time"
+)
+
+// Usage is the resource usage of a task, summed over the processes a
+// runner started for it and the children they waited for.
+type Usage struct {
+	Wall      time.Duration // time the processes were running
+	UserCPU   time.Duration
+	SystemCPU time.Duration
+	MaxRSS    int64 // peak resident set size in bytes; 0 where unknown
+}
+
+// IsZero reports whether no process usage was recorded.
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// add counts one finished process. MaxRSS keeps the largest peak seen.
+func (u *Usage) add(ps *os.ProcessState, wall time.Duration) {
+	if u == nil || ps == nil {
+		return
+	}
+	u.Wall += wall
+	u.UserCPU += ps.UserTime()
+	u.SystemCPU += ps.SystemTime()
+	if rss := maxRSS(ps); rss > u.MaxRSS {
+		u.MaxRSS = rss
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package runner
+
+import "os"
+
+// maxRSS is not available from the process state on this platform.
+func maxRSS(ps *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package runner
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestRunProcess_RecordsUsage(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var usage Usage
+	for i := 0; i < 2; i++ {
+		var log bytes.Buffer
+		cmd := exec.Command("sh", "-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done")
+		cmd.Stdout = &log
+		cmd.Stderr = &log
+		if err := runProcess(cmd, Limits{}, &usage, &log); err != nil {
+			t.Fatalf("runProcess() error: %v\n%s", err, log.String())
+		}
+	}
+
+	if usage.Wall <= 0 {
+		t.Errorf("Wall = %s, want > 0", usage.Wall)
+	}
+	if usage.UserCPU+usage.SystemCPU <= 0 {
+		t.Errorf("CPU time = %s user + %s system, want > 0", usage.UserCPU, usage.SystemCPU)
+	}
+	// A shell needs far more than 64 KiB resident, and far less than 1 GiB
+	if usage.MaxRSS < 64<<10 || usage.MaxRSS > 1<<30 {
+		t.Errorf("MaxRSS = %d bytes, want a plausible shell footprint", usage.MaxRSS)
+	}
+}
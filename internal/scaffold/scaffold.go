@@ -370,6 +370,9 @@ repo_cache/
 compiled_models/
 *.db
 secrets/
+pit_serve_state.json
+pit_audit.jsonl
+pit_discover_cache.json
 `
 }
 
@@ -384,6 +387,13 @@ func workspacePitConfig() string {
 # api_token = ""
 # dbt_driver = "ODBC Driver 17 for SQL Server"
 # keep_artifacts = ["logs", "project", "data"]
+# audit_log = "pit_audit.jsonl"
+# min_free_space = "100MiB"
+#
+# [artifact_store]
+# type = "s3"
+# bucket = "pit-artifacts"
+# secret = "artifact_store"
 `
 }
 
@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+)
+
+// Call sends one request to the server at addr, as returned by Addr, and
+// returns its result. A streamed result is read in full. An error the
+// handler returned comes back as the error.
+func Call(ctx context.Context, addr, method string, params map[string]string) (string, error) {
+	network := "unix"
+	if runtime.GOOS == "windows" {
+		network = "tcp"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return "", fmt.Errorf("connecting to SDK server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(Request{Method: method, Params: params, Stream: true}); err != nil {
+		return "", fmt.Errorf("sending %s request: %w", method, err)
+	}
+	var result strings.Builder
+	dec := json.NewDecoder(conn)
+	for {
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			return "", fmt.Errorf("reading %s response: %w", method, err)
+		}
+		if resp.Error != "" {
+			return "", errors.New(resp.Error)
+		}
+		result.WriteString(resp.Result)
+		if !resp.More {
+			return result.String(), nil
+		}
+	}
+}
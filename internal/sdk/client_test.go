@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv, err := NewServer(filepath.Join(t.TempDir(), "test.sock"), nil, "test_dag")
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	big := strings.Repeat("x", 3*ChunkSize+10)
+	srv.RegisterHandler("echo", func(_ context.Context, params map[string]string) (string, error) {
+		return params["msg"], nil
+	})
+	srv.RegisterHandler("big", func(context.Context, map[string]string) (string, error) { return big, nil })
+	ctx, cancel := context.WithCancel(t.Context())
+	go srv.Serve(ctx)
+	t.Cleanup(func() {
+		cancel()
+		srv.Shutdown()
+	})
+
+	if got, err := Call(t.Context(), srv.Addr(), "echo", map[string]string{"msg": "hi"}); err != nil || got != "hi" {
+		t.Errorf("Call(echo) = %q, %v; want hi", got, err)
+	}
+	if got, err := Call(t.Context(), srv.Addr(), "big", nil); err != nil || got != big {
+		t.Errorf("Call(big) returned %d bytes, %v; want %d", len(got), err, len(big))
+	}
+	if _, err := Call(t.Context(), srv.Addr(), "nope", nil); err == nil || !strings.Contains(err.Error(), "unknown method: nope") {
+		t.Errorf("Call(nope) error = %v, want unknown method", err)
+	}
+}
@@ -4,22 +4,37 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"runtime"
 	"sync"
+	"unicode/utf8"
 )
 
+// MaxMessageSize is the largest request the server accepts, and the largest
+// result it returns in a single response. Larger results must be requested
+// with Stream set.
+const MaxMessageSize = 4 << 20
+
+// ChunkSize is the most result bytes carried by one streamed response.
+const ChunkSize = 64 << 10
+
 // Request is the JSON message sent by a task to the SDK server.
 type Request struct {
 	Method string            `json:"method"`
 	Params map[string]string `json:"params"`
+	Stream bool              `json:"stream,omitempty"` // reply with a sequence of chunked responses
 }
 
-// Response is the JSON reply from the SDK server to a task.
+// Response is the JSON reply from the SDK server to a task. A streamed
+// reply is a sequence of newline-delimited responses, each holding the next
+// piece of the result, with More set on all but the last. An error ends the
+// sequence.
 type Response struct {
 	Result string `json:"result"`
 	Error  string `json:"error,omitempty"`
+	More   bool   `json:"more,omitempty"`
 }
 
 // HandlerFunc processes an SDK request and returns a result or error string.
@@ -91,6 +106,12 @@ func (s *Server) RegisterHandler(method string, handler HandlerFunc) {
 	s.handlers[method] = handler
 }
 
+// HasHandler reports whether a handler is registered for method.
+func (s *Server) HasHandler(method string) bool {
+	_, ok := s.handlers[method]
+	return ok
+}
+
 // listen creates a platform-appropriate network listener.
 // On Windows, it returns a TCP listener on 127.0.0.1 with an OS-assigned port.
 // On other platforms, it returns a Unix domain socket listener at socketPath.
@@ -162,8 +183,12 @@ func (s *Server) handleConn(conn net.Conn) {
 	defer conn.Close()
 
 	var req Request
-	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+	body := &io.LimitedReader{R: conn, N: MaxMessageSize + 1}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
 		resp := Response{Error: fmt.Sprintf("invalid request: %v", err)}
+		if body.N <= 0 {
+			resp.Error = fmt.Sprintf("invalid request: larger than %d bytes", MaxMessageSize)
+		}
 		json.NewEncoder(conn).Encode(resp)
 		return
 	}
@@ -183,11 +208,37 @@ func (s *Server) handleConn(conn net.Conn) {
 	}
 
 	result, err := handler(ctx, req.Params)
-	var resp Response
-	if err != nil {
-		resp.Error = err.Error()
-	} else {
-		resp.Result = result
+	enc := json.NewEncoder(conn)
+	switch {
+	case err != nil:
+		enc.Encode(Response{Error: err.Error()})
+	case req.Stream:
+		for _, chunk := range chunks(result, ChunkSize) {
+			if err := enc.Encode(chunk); err != nil {
+				return
+			}
+		}
+	case len(result) > MaxMessageSize:
+		enc.Encode(Response{Error: fmt.Sprintf("%s result is %d bytes, over the %d byte message limit; request it with stream set", req.Method, len(result), MaxMessageSize)})
+	default:
+		enc.Encode(Response{Result: result})
+	}
+}
+
+// chunks splits result into streamed responses of at most size bytes,
+// cutting only between UTF-8 characters so each piece encodes unchanged.
+func chunks(result string, size int) []Response {
+	var out []Response
+	for len(result) > size {
+		n := size
+		for n > 0 && !utf8.RuneStart(result[n]) {
+			n--
+		}
+		if n == 0 {
+			n = size
+		}
+		out = append(out, Response{Result: result[:n], More: true})
+		result = result[n:]
 	}
-	json.NewEncoder(conn).Encode(resp)
+	return append(out, Response{Result: result})
 }
@@ -406,3 +406,88 @@ func TestGetSecretField_MissingParams(t *testing.T) {
 		t.Errorf("error = %q, want it to mention 'field'", resp.Error)
 	}
 }
+
+func startEchoServer(t *testing.T, result string) string {
+	t.Helper()
+	sockPath := filepath.Join(t.TempDir(), "test.sock")
+	srv, err := NewServer(sockPath, nil, "test")
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error: %v", err)
+	}
+	srv.RegisterHandler("big", func(context.Context, map[string]string) (string, error) {
+		return result, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Serve(ctx)
+	t.Cleanup(func() {
+		cancel()
+		srv.Shutdown()
+	})
+	return srv.Addr()
+}
+
+func TestStreamedResponse(t *testing.T) {
+	// Multi-byte characters straddle chunk boundaries.
+	result := strings.Repeat("héllo wörld ✓ ", 20000)
+	addr := startEchoServer(t, result)
+
+	conn, err := net.Dial(testNetwork(), addr)
+	if err != nil {
+		t.Fatalf("connecting to socket: %v", err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(Request{Method: "big", Stream: true}); err != nil {
+		t.Fatalf("encoding request: %v", err)
+	}
+
+	dec := json.NewDecoder(conn)
+	var got strings.Builder
+	frames := 0
+	for {
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decoding frame %d: %v", frames, err)
+		}
+		if resp.Error != "" {
+			t.Fatalf("frame %d error: %s", frames, resp.Error)
+		}
+		if len(resp.Result) > ChunkSize {
+			t.Errorf("frame %d is %d bytes, want at most %d", frames, len(resp.Result), ChunkSize)
+		}
+		frames++
+		got.WriteString(resp.Result)
+		if !resp.More {
+			break
+		}
+	}
+	if got.String() != result {
+		t.Errorf("reassembled result differs from the original (%d bytes, want %d)", got.Len(), len(result))
+	}
+	if want := len(result)/ChunkSize + 1; frames < want {
+		t.Errorf("frames = %d, want at least %d", frames, want)
+	}
+}
+
+func TestStreamedResponse_Small(t *testing.T) {
+	addr := startEchoServer(t, "tiny")
+	resp := sendRequest(t, addr, Request{Method: "big", Stream: true})
+	if resp.Result != "tiny" || resp.More {
+		t.Errorf("response = %+v, want a single final frame", resp)
+	}
+}
+
+func TestLargeResultWithoutStream(t *testing.T) {
+	addr := startEchoServer(t, strings.Repeat("x", MaxMessageSize+1))
+	resp := sendRequest(t, addr, Request{Method: "big"})
+	if !strings.Contains(resp.Error, "message limit") {
+		t.Errorf("error = %q, want message limit error", resp.Error)
+	}
+}
+
+func TestRequestTooLarge(t *testing.T) {
+	addr := startEchoServer(t, "")
+	resp := sendRequest(t, addr, Request{Method: "big", Params: map[string]string{"pad": strings.Repeat("x", MaxMessageSize)}})
+	if !strings.Contains(resp.Error, "larger than") {
+		t.Errorf("error = %q, want request size error", resp.Error)
+	}
+}
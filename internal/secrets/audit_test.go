@@ -96,3 +96,26 @@ func TestAuditFunc_NilIsOK(t *testing.T) {
 		t.Fatalf("Resolve() error: %v", err)
 	}
 }
+
+func TestHas_NoAccessRecorded(t *testing.T) {
+	path := writeSecretsFile(t, validTOML)
+	store, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	calls := 0
+	store.OnAccess = func(AuditEvent) { calls++ }
+
+	if !store.Has("claims_pipeline", "claims_db") {
+		t.Error("Has(claims_db) = false, want true")
+	}
+	if !store.Has("claims_pipeline", "shared_db") {
+		t.Error("Has(shared_db) = false, want true via [global]")
+	}
+	if store.Has("claims_pipeline", "missing") {
+		t.Error("Has(missing) = true, want false")
+	}
+	if calls != 0 {
+		t.Errorf("OnAccess called %d time(s), want 0", calls)
+	}
+}
@@ -4,26 +4,69 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
 
 // Secret holds either a plain string value or a set of named fields.
-// Exactly one of Value or Fields is populated.
+// Exactly one of Value or Fields is populated. A field value is a string,
+// or a map[string]any of further fields for nested tables such as
+// [scope.name.credentials].
 type Secret struct {
-	Value  string            // non-empty for plain "key = value" secrets
-	Fields map[string]string // non-nil for structured [scope.name] secrets
+	Value  string         // non-empty for plain "key = value" secrets
+	Fields map[string]any // non-nil for structured [scope.name] secrets
 }
 
+// globalScope is the section every project falls back to unless it sets
+// its own scopes with SetScopes.
+const globalScope = "global"
+
 // Store holds secrets parsed from a TOML file, organised by section.
-// Resolution checks the project-scoped section first, then falls back to [global].
+// Resolution checks the project-scoped section first, then falls back to
+// [global], or to the project's scopes if SetScopes was called for it.
 type Store struct {
 	data     map[string]map[string]Secret
-	OnAccess func(AuditEvent) // optional callback, fired on successful resolve
+	scopes   map[string][]string // project → fallback sections, in order
+	OnAccess func(AuditEvent)    // optional callback, fired on successful resolve
+}
+
+// SetScopes sets the sections searched, in order, after project's own
+// section, replacing the default of [global]. Listing "global" keeps the
+// global fallback; leaving it out means the project cannot see it. An
+// empty list restores the default. SetScopes must not be called while
+// the store is resolving secrets for other goroutines.
+func (s *Store) SetScopes(project string, scopes []string) {
+	if len(scopes) == 0 {
+		delete(s.scopes, project)
+		return
+	}
+	if s.scopes == nil {
+		s.scopes = make(map[string][]string)
+	}
+	s.scopes[project] = append([]string(nil), scopes...)
+}
+
+// Scopes returns the sections searched for project's secrets, in order,
+// starting with the project's own.
+func (s *Store) Scopes(project string) []string {
+	fallback, ok := s.scopes[project]
+	if !ok {
+		fallback = []string{globalScope}
+	}
+	scopes := []string{project}
+	for _, scope := range fallback {
+		if scope != project {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
 }
 
 // Load parses a TOML secrets file and returns a Store.
 // If path is empty, returns nil (secrets are optional).
+// If path is a directory, its files are merged as by LoadDir.
 //
 // The TOML format supports both plain and structured secrets:
 //
@@ -47,6 +90,9 @@ func Load(path string) (*Store, error) {
 	if path == "" {
 		return nil, nil
 	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return LoadDir(path, "", "")
+	}
 
 	raw, err := os.ReadFile(path)
 	if err != nil {
@@ -56,6 +102,68 @@ func Load(path string) (*Store, error) {
 	return LoadFromBytes(raw)
 }
 
+// LoadDir loads every *.toml and *.toml.age file directly inside dir and
+// merges them into one Store, so secrets can be split into files with
+// their own owners and permissions (one per project, say). A scope may
+// appear in several files, but defining the same secret in two files is
+// an error. Encrypted files are decrypted as by LoadEncrypted. Files that
+// cannot be read are errors, not skipped.
+func LoadDir(dir, identityPath, configIdentity string) (*Store, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets directory %q: %w", dir, err)
+	}
+
+	merged := &Store{data: make(map[string]map[string]Secret)}
+	origin := make(map[string]string) // "scope\x00key" → file that defined it
+	files := 0
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		var plaintext []byte
+		switch {
+		case strings.HasSuffix(name, ".toml"):
+			if plaintext, err = os.ReadFile(path); err != nil {
+				return nil, fmt.Errorf("reading secrets file %q: %w", path, err)
+			}
+		case strings.HasSuffix(name, ".toml.age"):
+			if plaintext, err = decryptFile(path, identityPath, configIdentity); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		store, err := LoadFromBytes(plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		files++
+
+		for scope, section := range store.data {
+			dst, ok := merged.data[scope]
+			if !ok {
+				dst = make(map[string]Secret, len(section))
+				merged.data[scope] = dst
+			}
+			for key, sec := range section {
+				id := scope + "\x00" + key
+				if prev, ok := origin[id]; ok {
+					return nil, fmt.Errorf("secrets: %q.%q is defined in both %s and %s", scope, key, prev, name)
+				}
+				origin[id] = name
+				dst[key] = sec
+			}
+		}
+	}
+	if files == 0 {
+		return nil, fmt.Errorf("secrets directory %q has no *.toml or *.toml.age files", dir)
+	}
+	return merged, nil
+}
+
 // LoadFromBytes parses TOML secrets from raw bytes and returns a Store.
 func LoadFromBytes(data []byte) (*Store, error) {
 	var parsed map[string]interface{}
@@ -76,13 +184,9 @@ func LoadFromBytes(data []byte) (*Store, error) {
 			case string:
 				secrets[key] = Secret{Value: v}
 			case map[string]interface{}:
-				fields := make(map[string]string, len(v))
-				for fk, fv := range v {
-					s, ok := fv.(string)
-					if !ok {
-						return nil, fmt.Errorf("secrets: field %q.%q.%q must be a string", scope, key, fk)
-					}
-					fields[fk] = s
+				fields, err := parseFields(v, fmt.Sprintf("%q.%q", scope, key))
+				if err != nil {
+					return nil, err
 				}
 				secrets[key] = Secret{Fields: fields}
 			default:
@@ -95,14 +199,49 @@ func LoadFromBytes(data []byte) (*Store, error) {
 	return &Store{data: store}, nil
 }
 
+// parseFields converts a structured secret's table, keeping nested tables
+// as nested maps. Every other value must be a string. path names the
+// table in errors.
+func parseFields(table map[string]interface{}, path string) (map[string]any, error) {
+	fields := make(map[string]any, len(table))
+	for k, v := range table {
+		switch v := v.(type) {
+		case string:
+			fields[k] = v
+		case map[string]interface{}:
+			sub, err := parseFields(v, fmt.Sprintf("%s.%q", path, k))
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = sub
+		default:
+			return nil, fmt.Errorf("secrets: field %s.%q must be a string or table", path, k)
+		}
+	}
+	return fields, nil
+}
+
 // LoadEncrypted reads an age-encrypted secrets file, decrypts it, and returns a Store.
-// If path is empty, returns nil, nil. Identity resolution tries PIT_AGE_KEY env var
+// If path is empty, returns nil, nil. If path is a directory, its files are
+// merged as by LoadDir. Identity resolution tries PIT_AGE_KEY env var
 // first (raw key), then falls back to file-based identity using identityPath or configIdentity.
 func LoadEncrypted(path, identityPath, configIdentity string) (*Store, error) {
 	if path == "" {
 		return nil, nil
 	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return LoadDir(path, identityPath, configIdentity)
+	}
 
+	plaintext, err := decryptFile(path, identityPath, configIdentity)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFromBytes(plaintext)
+}
+
+// decryptFile reads and decrypts the age-encrypted file at path.
+func decryptFile(path, identityPath, configIdentity string) ([]byte, error) {
 	ciphertext, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading encrypted secrets %q: %w", path, err)
@@ -114,7 +253,7 @@ func LoadEncrypted(path, identityPath, configIdentity string) (*Store, error) {
 		if err != nil {
 			return nil, fmt.Errorf("decrypting secrets with PIT_AGE_KEY: %w", err)
 		}
-		return LoadFromBytes(plaintext)
+		return plaintext, nil
 	}
 
 	// Fall back to file-based identity.
@@ -133,14 +272,16 @@ func LoadEncrypted(path, identityPath, configIdentity string) (*Store, error) {
 	if err != nil {
 		return nil, fmt.Errorf("decrypting secrets %q: %w", path, err)
 	}
-
-	return LoadFromBytes(plaintext)
+	return plaintext, nil
 }
 
 // Resolve looks up a plain secret by key, checking the project-scoped section first
-// then falling back to the [global] section.
+// then falling back to the [global] section (or the project's scopes).
 //
-// For structured secrets, Resolve returns a JSON object of the fields.
+// For structured secrets, Resolve returns a JSON object of the fields,
+// with nested tables as nested objects. A key such as "gcp_sa.credentials"
+// that is not itself a secret resolves the dotted path inside the secret
+// named by its first part, as ResolveField does.
 func (s *Store) Resolve(project, key string) (string, error) {
 	if sec, ok := s.lookup(project, key); ok {
 		var val string
@@ -158,36 +299,78 @@ func (s *Store) Resolve(project, key string) (string, error) {
 		}
 		return val, nil
 	}
+	if secret, field, ok := strings.Cut(key, "."); ok {
+		if _, ok := s.lookup(project, secret); ok {
+			return s.ResolveField(project, secret, field)
+		}
+	}
 	return "", fmt.Errorf("secret %q not found for project %q", key, project)
 }
 
 // ResolveField looks up a single field within a structured secret.
-// Checks the project-scoped section first, then falls back to [global].
+// Checks the project-scoped section first, then falls back to [global]
+// (or the project's scopes).
+//
+// field may be a dotted path into nested tables, e.g.
+// "credentials.private_key". A path ending at a table returns the table
+// as a JSON object.
 func (s *Store) ResolveField(project, secret, field string) (string, error) {
 	if sec, ok := s.lookup(project, secret); ok {
 		if sec.Fields == nil {
 			return "", fmt.Errorf("secret %q is a plain value, not a structured secret (use Resolve instead)", secret)
 		}
-		if val, ok := sec.Fields[field]; ok {
+		if val, ok := lookupField(sec.Fields, field); ok {
+			str, isString := val.(string)
+			if !isString {
+				b, err := json.Marshal(val)
+				if err != nil {
+					return "", fmt.Errorf("marshalling field %q of secret %q: %w", field, secret, err)
+				}
+				str = string(b)
+			}
 			if s.OnAccess != nil {
 				s.OnAccess(AuditEvent{Project: project, Key: secret})
 			}
-			return val, nil
+			return str, nil
 		}
 		return "", fmt.Errorf("field %q not found in secret %q for project %q", field, secret, project)
 	}
 	return "", fmt.Errorf("secret %q not found for project %q", secret, project)
 }
 
-// lookup finds a Secret by key, checking project scope first then global.
-func (s *Store) lookup(project, key string) (Secret, bool) {
-	if section, ok := s.data[project]; ok {
-		if sec, ok := section[key]; ok {
-			return sec, true
+// lookupField follows a dotted path through nested fields. A key that
+// itself contains dots (a quoted TOML key) is matched before the path is
+// split.
+func lookupField(fields map[string]any, path string) (any, bool) {
+	if v, ok := fields[path]; ok {
+		return v, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if sub, ok := fields[path[:i]].(map[string]any); ok {
+			if v, ok := lookupField(sub, path[i+1:]); ok {
+				return v, true
+			}
 		}
 	}
-	if section, ok := s.data["global"]; ok {
-		if sec, ok := section[key]; ok {
+	return nil, false
+}
+
+// Has reports whether key names a secret visible to project, plain or
+// structured. It reads no values and does not call OnAccess, so checking
+// references does not show up in the access audit.
+func (s *Store) Has(project, key string) bool {
+	_, ok := s.lookup(project, key)
+	return ok
+}
+
+// lookup finds a Secret by key in the first of project's scopes that
+// defines it.
+func (s *Store) lookup(project, key string) (Secret, bool) {
+	for _, scope := range s.Scopes(project) {
+		if sec, ok := s.data[scope][key]; ok {
 			return sec, true
 		}
 	}
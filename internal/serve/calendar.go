@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"log"
+	"time"
+
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// deferredRun is a scheduled run held until a blackout window ends, or an
+// event held until max_runs_per_hour has room. It is saved in the serve
+// state file, so a restart does not lose it.
+type deferredRun struct {
+	Event    trigger.Event
+	Blackout string    // name of the window that deferred the run, or "max_runs_per_hour"
+	Until    time.Time // when the run is released
+}
+
+// holdForBlackout drops a scheduled event during a blackout, or holds it
+// until the blackout lifts when the window's action is "defer". At most one
+// run per DAG is deferred; further fires during the same blackout are dropped.
+func (s *Server) holdForBlackout(ev trigger.Event, b *trigger.Blackout) {
+	if !b.Defer {
+		log.Printf("[%s] skipping: %s", ev.DAGName, b.Name)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "calendar: " + b.Name})
+		return
+	}
+
+	s.mu.Lock()
+	_, already := s.deferred[ev.DAGName]
+	s.mu.Unlock()
+	if already {
+		log.Printf("[%s] skipping: run already deferred (%s)", ev.DAGName, b.Name)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "calendar: " + b.Name + " (already deferred)"})
+		return
+	}
+
+	log.Printf("[%s] deferred until %s (%s)", ev.DAGName, b.End.Format("2006-01-02 15:04"), b.Name)
+	s.recordAudit(audit.Event{Action: audit.ActionRunDeferred, Source: ev.Source, DAGName: ev.DAGName, Detail: "calendar: " + b.Name + ", until " + b.End.Format(time.RFC3339)})
+	s.deferRun(&deferredRun{Event: ev, Blackout: b.Name, Until: b.End})
+	s.saveState()
+}
+
+// deferRun records d and arms a timer that releases it at d.Until.
+func (s *Server) deferRun(d *deferredRun) {
+	s.mu.Lock()
+	s.deferred[d.Event.DAGName] = d
+	s.mu.Unlock()
+	time.AfterFunc(time.Until(d.Until), func() { s.releaseDeferred(d) })
+}
+
+// releaseDeferred hands a deferred run back to handleEvent, which re-checks
+// the calendar, so back-to-back windows defer again. While the server is
+// draining the run stays deferred and is persisted for the next process.
+func (s *Server) releaseDeferred(d *deferredRun) {
+	s.mu.Lock()
+	if s.draining || s.deferred[d.Event.DAGName] != d {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.deferred, d.Event.DAGName)
+	s.mu.Unlock()
+
+	log.Printf("[%s] releasing run deferred by %s", d.Event.DAGName, d.Blackout)
+	s.handleEvent(d.Event)
+}
+
+// deferredRuns returns copies of the runs currently held, which
+// holdForRateLimit may still be adding files to.
+func (s *Server) deferredRuns() []*deferredRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]*deferredRun, 0, len(s.deferred))
+	for _, d := range s.deferred {
+		c := *d
+		runs = append(runs, &c)
+	}
+	return runs
+}
@@ -0,0 +1,117 @@
+package serve
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// newCalendarServer builds a Server whose calendar blacks out today.
+func newCalendarServer(t *testing.T, action string) *Server {
+	t.Helper()
+	today := time.Now().Format("2006-01-02")
+	cal, err := trigger.NewCalendar(&config.CalendarConfig{Holidays: []string{today}, HolidayAction: action})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Server{
+		configs: map[string]*config.ProjectConfig{
+			"nightly": {DAG: config.DAGConfig{Name: "nightly"}},
+			"always":  {DAG: config.DAGConfig{Name: "always", IgnoreCalendar: true}},
+		},
+		eventCh:    make(chan trigger.Event, 8),
+		queue:      newRunQueue(),
+		calendar:   cal,
+		activeRuns: make(map[string]bool),
+		deferred:   make(map[string]*deferredRun),
+	}
+}
+
+func TestHandleEvent_CalendarSkip(t *testing.T) {
+	s := newCalendarServer(t, "skip")
+
+	s.handleEvent(trigger.Event{DAGName: "nightly", Source: "cron"})
+	if s.queue.Len() != 0 {
+		t.Errorf("queue.Len() = %d, want 0 (cron run suppressed on holiday)", s.queue.Len())
+	}
+
+	// Non-scheduled triggers and opted-out DAGs are unaffected
+	s.handleEvent(trigger.Event{DAGName: "nightly", Source: "webhook"})
+	s.handleEvent(trigger.Event{DAGName: "always", Source: "cron"})
+	if s.queue.Len() != 2 {
+		t.Errorf("queue.Len() = %d, want 2", s.queue.Len())
+	}
+}
+
+func TestHandleEvent_CalendarDefer(t *testing.T) {
+	s := newCalendarServer(t, "defer")
+
+	s.handleEvent(trigger.Event{DAGName: "nightly", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "nightly", Source: "cron"})
+
+	if s.queue.Len() != 0 {
+		t.Errorf("queue.Len() = %d, want 0 while deferred", s.queue.Len())
+	}
+	if s.deferred["nightly"] == nil {
+		t.Error("nightly should be marked deferred")
+	}
+}
+
+func TestHoldForBlackout_ReleasesAtEnd(t *testing.T) {
+	s := newCalendarServer(t, "defer")
+	s.calendar = nil // the blackout is over when the run is released
+
+	s.holdForBlackout(trigger.Event{DAGName: "nightly", Source: "cron"},
+		&trigger.Blackout{Name: "test", Defer: true, End: time.Now().Add(20 * time.Millisecond)})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.queue.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("deferred run was not queued after blackout end")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if qr := s.queue.Snapshot()[0]; qr.Event.DAGName != "nightly" {
+		t.Errorf("released run DAG = %q, want %q", qr.Event.DAGName, "nightly")
+	}
+	if len(s.deferredRuns()) != 0 {
+		t.Error("deferred marker should be cleared after release")
+	}
+}
+
+func TestDeferredRun_PersistedAcrossRestart(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	s := newCalendarServer(t, "defer")
+	s.statePath = statePath
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	s.holdForBlackout(trigger.Event{DAGName: "nightly", Source: "cron"},
+		&trigger.Blackout{Name: "month end", Defer: true, End: until})
+
+	restarted := newCalendarServer(t, "defer")
+	restarted.statePath = statePath
+	restarted.restoreState()
+
+	d := restarted.deferred["nightly"]
+	if d == nil {
+		t.Fatal("deferred run was not restored from the state file")
+	}
+	if !d.Until.Equal(until) || d.Blackout != "month end" || d.Event.Source != "cron" {
+		t.Errorf("restored deferred run = %+v, want cron run until %s by %q", d, until, "month end")
+	}
+}
+
+func TestReleaseDeferred_KeptWhileDraining(t *testing.T) {
+	s := newCalendarServer(t, "defer")
+	s.draining = true
+	d := &deferredRun{Event: trigger.Event{DAGName: "nightly", Source: "cron"}, Blackout: "test", Until: time.Now()}
+	s.deferred["nightly"] = d
+
+	s.releaseDeferred(d)
+	if s.queue.Len() != 0 || s.deferred["nightly"] != d {
+		t.Error("a deferred run released while draining should stay deferred for the state file")
+	}
+}
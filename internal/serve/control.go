@@ -0,0 +1,205 @@
+package serve
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/audit"
+)
+
+// ErrDrained is returned by Start when the server stopped because of a drain
+// request rather than a shutdown signal.
+var ErrDrained = errors.New("pit serve drained")
+
+// controlHandler returns the /control/ routes used by operator commands.
+// Requests are authenticated with the API token, when one is configured.
+// Without a token, the routes change server state with no credentials, so
+// they only accept requests from loopback addresses.
+func (s *Server) controlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /control/drain", s.handleDrain)
+	mux.HandleFunc("POST /control/reload", s.handleReload)
+	mux.HandleFunc("GET /control/queue", s.handleQueueList)
+	mux.HandleFunc("DELETE /control/queue/{dag}", s.handleQueueClear)
+	mux.HandleFunc("GET /control/triggers", s.handleTriggers)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.apiToken != "" {
+			authHeader := r.Header.Get("Authorization")
+			var provided string
+			if strings.HasPrefix(authHeader, "Bearer ") {
+				provided = authHeader[len("Bearer "):]
+			}
+			expected := sha256.Sum256([]byte(s.apiToken))
+			got := sha256.Sum256([]byte(provided))
+			if subtle.ConstantTimeCompare(expected[:], got[:]) != 1 {
+				writeControlJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+		} else if !isLoopback(r.RemoteAddr) {
+			writeControlJSON(w, http.StatusForbidden, map[string]string{"error": "control endpoints require api_token for non-loopback clients"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether a request's remote address is a loopback IP.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleDrain puts the server into drain mode and reports what is still running.
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	s.Drain("api")
+
+	writeControlJSON(w, http.StatusAccepted, map[string]any{
+		"status":  "draining",
+		"running": s.runningCount(),
+		"queued":  s.queue.Len(),
+	})
+}
+
+// handleReload swaps in reloaded configs, or reports why they were
+// rejected and the running config kept.
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reload("api"); err != nil {
+		resp := map[string]any{"error": err.Error()}
+		var rejected *ReloadError
+		if errors.As(err, &rejected) {
+			resp["problems"] = rejected.Problems
+		}
+		writeControlJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	set := s.current()
+	writeControlJSON(w, http.StatusOK, map[string]any{
+		"status":   "reloaded",
+		"dags":     len(set.configs),
+		"triggers": len(set.triggers),
+	})
+}
+
+// queueEntryJSON is one queued run in the GET /control/queue response.
+type queueEntryJSON struct {
+	DAGName    string    `json:"dag_name"`
+	Source     string    `json:"source"`
+	Priority   int       `json:"priority"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	Files      []string  `json:"files,omitempty"`
+}
+
+// handleQueueList returns queued runs in dispatch order.
+func (s *Server) handleQueueList(w http.ResponseWriter, r *http.Request) {
+	entries := []queueEntryJSON{}
+	for _, qr := range s.queue.Snapshot() {
+		entries = append(entries, queueEntryJSON{
+			DAGName:    qr.Event.DAGName,
+			Source:     qr.Event.Source,
+			Priority:   qr.Priority,
+			EnqueuedAt: qr.EnqueuedAt.UTC(),
+			Files:      qr.Event.Files,
+		})
+	}
+	writeControlJSON(w, http.StatusOK, map[string]any{
+		"running": s.runningCount(),
+		"queued":  entries,
+	})
+}
+
+// handleQueueClear discards every queued run for one DAG. Runs already
+// executing are not affected.
+func (s *Server) handleQueueClear(w http.ResponseWriter, r *http.Request) {
+	dagName := r.PathValue("dag")
+	if s.dagConfig(dagName) == nil {
+		writeControlJSON(w, http.StatusNotFound, map[string]string{"error": "unknown DAG"})
+		return
+	}
+
+	removed := s.clearQueued(dagName)
+	if removed > 0 {
+		log.Printf("[%s] cleared %d queued run(s)", dagName, removed)
+		s.recordAudit(audit.Event{Action: audit.ActionCancel, Source: "api", DAGName: dagName, Detail: fmt.Sprintf("cleared %d queued run(s)", removed)})
+		s.saveState()
+	}
+	writeControlJSON(w, http.StatusOK, map[string]any{"dag_name": dagName, "removed": removed})
+}
+
+// clearQueued removes dagName's queued runs and releases its overlap marker
+// when nothing for the DAG is left queued or executing. Streams following
+// a removed run are closed. It holds s.mu, which enqueuers hold from
+// marking a DAG active to pushing its run and nextRun holds from popping a
+// run to counting it, so every run is seen as either queued or running.
+func (s *Server) clearQueued(dagName string) int {
+	s.mu.Lock()
+	removed := s.queue.Remove(dagName)
+	if s.running[dagName] == 0 && !s.queue.Contains(dagName) {
+		s.activeRuns[dagName] = false
+	}
+	s.mu.Unlock()
+
+	for _, qr := range removed {
+		if qr.RunID != "" && s.logHub != nil {
+			s.logHub.Complete(qr.RunID, "cancelled")
+		}
+	}
+	return len(removed)
+}
+
+// runningCount returns the number of runs currently executing.
+func (s *Server) runningCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.running {
+		n += c
+	}
+	return n
+}
+
+// Drain stops the server from accepting new trigger events. Runs already
+// executing are allowed to finish, runs still queued are persisted to the
+// state file, and Start then returns ErrDrained. Safe to call more than once.
+func (s *Server) Drain(source string) {
+	s.mu.Lock()
+	already := s.draining
+	s.draining = true
+	s.mu.Unlock()
+	if already {
+		return
+	}
+
+	log.Printf("pit serve: drain requested (%s), no new runs will start", source)
+	s.recordAudit(audit.Event{Action: audit.ActionDrain, Source: source})
+	if s.drainCh != nil {
+		close(s.drainCh)
+	}
+}
+
+// isDraining reports whether Drain has been called.
+func (s *Server) isDraining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+func writeControlJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("control: json encode error: %v", err)
+	}
+}
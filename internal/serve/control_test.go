@@ -0,0 +1,364 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+func newControlServer(token string) *Server {
+	return &Server{
+		configs: map[string]*config.ProjectConfig{
+			"test":  {DAG: config.DAGConfig{Name: "test"}},
+			"other": {DAG: config.DAGConfig{Name: "other", Priority: 3}},
+		},
+		webhookTokens: map[string]string{"test": "hooksecret"},
+		eventCh:       make(chan trigger.Event, 8),
+		apiToken:      token,
+		queue:         newRunQueue(),
+		drainCh:       make(chan struct{}),
+		activeRuns:    make(map[string]bool),
+		running:       make(map[string]int),
+	}
+}
+
+// localRequest returns a request from a loopback client.
+func localRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = "127.0.0.1:40000"
+	return req
+}
+
+func TestControlDrain_NoTokenLoopbackOnly(t *testing.T) {
+	s := newControlServer("")
+
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/control/drain", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("remote status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if s.isDraining() {
+		t.Fatal("server should not drain on a remote request without api_token")
+	}
+
+	w = httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, localRequest(http.MethodPost, "/control/drain", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("loopback status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if !s.isDraining() {
+		t.Error("server should drain on a loopback request")
+	}
+}
+
+func TestControlDrain_Unauthorized(t *testing.T) {
+	s := newControlServer("secret")
+
+	req := httptest.NewRequest(http.MethodPost, "/control/drain", nil)
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if s.isDraining() {
+		t.Error("server should not drain on an unauthorized request")
+	}
+}
+
+func TestControlDrain_RejectsNewRuns(t *testing.T) {
+	s := newControlServer("secret")
+
+	req := httptest.NewRequest(http.MethodPost, "/control/drain", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body["status"] != "draining" {
+		t.Errorf("status field = %v, want %q", body["status"], "draining")
+	}
+	select {
+	case <-s.drainCh:
+	default:
+		t.Error("drainCh should be closed after drain")
+	}
+
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	if s.queue.Len() != 0 {
+		t.Errorf("queue.Len() = %d, want 0 while draining", s.queue.Len())
+	}
+
+	hookReq := httptest.NewRequest(http.MethodPost, "/webhook/test", nil)
+	hookReq.Header.Set("Authorization", "Bearer hooksecret")
+	hw := httptest.NewRecorder()
+	s.webhookHandler(hw, hookReq)
+	if hw.Code != http.StatusServiceUnavailable {
+		t.Errorf("webhook status = %d, want %d", hw.Code, http.StatusServiceUnavailable)
+	}
+
+	// A second drain is a no-op, not a double close
+	s.Drain("test")
+}
+
+func TestStart_DrainReturnsErrDrained(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "idle", `[dag]
+name = "idle"
+
+[[tasks]]
+name = "hello"
+script = "tasks/hello.sh"
+`)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	statePath := filepath.Join(dir, "state.json")
+	s, err := NewServer(dir, "", false, Options{WebhookPort: port, StateFile: statePath})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+	s.Drain("test")
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrDrained) {
+			t.Errorf("Start() = %v, want ErrDrained", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after drain")
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Errorf("state file not written on drain: %v", err)
+	}
+}
+
+func TestControlQueue_ListAndClear(t *testing.T) {
+	s := newControlServer("")
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "webhook"})
+	s.handleEvent(trigger.Event{DAGName: "other", Source: "ftp_watch", Files: []string{"a.csv"}})
+	h := s.controlHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, localRequest(http.MethodGet, "/control/queue", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var list struct {
+		Queued []queueEntryJSON `json:"queued"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding list: %v", err)
+	}
+	if len(list.Queued) != 3 {
+		t.Fatalf("len(queued) = %d, want 3", len(list.Queued))
+	}
+	if list.Queued[0].DAGName != "other" || list.Queued[0].Priority != 3 {
+		t.Errorf("queued[0] = %+v, want higher-priority DAG %q first", list.Queued[0], "other")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, localRequest(http.MethodDelete, "/control/queue/test", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("clear status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var cleared struct {
+		Removed int `json:"removed"`
+	}
+	json.NewDecoder(w.Body).Decode(&cleared)
+	if cleared.Removed != 2 {
+		t.Errorf("removed = %d, want 2", cleared.Removed)
+	}
+	if s.queue.Len() != 1 {
+		t.Errorf("queue.Len() = %d, want 1", s.queue.Len())
+	}
+	if s.activeRuns["test"] {
+		t.Error("cleared DAG should no longer be marked active")
+	}
+	if !s.activeRuns["other"] {
+		t.Error("other DAG should still be marked active")
+	}
+}
+
+func TestControlQueue_ClearKeepsRunningMarker(t *testing.T) {
+	s := newControlServer("")
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	s.running["test"] = 1
+
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, localRequest(http.MethodDelete, "/control/queue/test", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !s.activeRuns["test"] {
+		t.Error("DAG with an executing run should stay marked active after clear")
+	}
+}
+
+// TestClearQueued_WhileDispatching clears a DAG's queue while its runs are
+// being queued and dispatched. The DAG must stay marked active whenever a
+// run is queued or executing, or overlap = "skip" would let a second run in.
+// Run with -race.
+func TestClearQueued_WhileDispatching(t *testing.T) {
+	// Every queued run is logged
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	s := newControlServer("")
+	check := func() {
+		if (s.running["test"] > 0 || s.queue.Contains("test")) && !s.activeRuns["test"] {
+			t.Errorf("running = %d, queued = %v, but the DAG is not marked active", s.running["test"], s.queue.Contains("test"))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			qr := s.nextRun(ctx)
+			if qr == nil {
+				return
+			}
+			s.mu.Lock()
+			check()
+			// End the run, releasing the marker if nothing else is left
+			s.running["test"]--
+			if s.running["test"] == 0 && !s.queue.Contains("test") {
+				s.activeRuns["test"] = false
+			}
+			s.mu.Unlock()
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				s.handleEvent(trigger.Event{DAGName: "test", Source: "webhook"})
+				s.mu.Lock()
+				check()
+				s.mu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				s.clearQueued("test")
+				s.mu.Lock()
+				check()
+				s.mu.Unlock()
+			}
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	wg.Wait()
+}
+
+func TestControlQueue_ClearUnknownDAG(t *testing.T) {
+	s := newControlServer("")
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, localRequest(http.MethodDelete, "/control/queue/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestControlQueue_ClearRemoteWithoutToken(t *testing.T) {
+	s := newControlServer("")
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/control/queue/test", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if s.queue.Len() != 1 {
+		t.Errorf("queue.Len() = %d, want 1 after a refused clear", s.queue.Len())
+	}
+}
+
+// fakePollTrigger is a HealthReporter with fixed health.
+type fakePollTrigger struct {
+	health trigger.Health
+}
+
+func (f *fakePollTrigger) Start(ctx context.Context, events chan<- trigger.Event) error { return nil }
+func (f *fakePollTrigger) Name() string                                                 { return "fake → " + f.health.DAGName }
+func (f *fakePollTrigger) Health() trigger.Health                                       { return f.health }
+
+func TestHealth_DegradedTrigger(t *testing.T) {
+	s := newControlServer("secret")
+	since := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	ok := &fakePollTrigger{health: trigger.Health{DAGName: "other", Source: "ftp_watch"}}
+	failing := &fakePollTrigger{health: trigger.Health{DAGName: "test", Source: "ftp_watch", Failures: 3, DegradedSince: since, LastError: "connect: refused"}}
+	s.triggers = []trigger.Trigger{ok, failing}
+
+	w := httptest.NewRecorder()
+	s.handleHealth(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	var health struct {
+		Status   string `json:"status"`
+		Degraded []struct {
+			DAGName       string    `json:"dag_name"`
+			DegradedSince time.Time `json:"degraded_since"`
+		} `json:"degraded_triggers"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+		t.Fatalf("decoding health: %v", err)
+	}
+	if health.Status != "degraded" || len(health.Degraded) != 1 || health.Degraded[0].DAGName != "test" || !health.Degraded[0].DegradedSince.Equal(since) {
+		t.Errorf("health = %+v, want test degraded since %s", health, since)
+	}
+
+	w = httptest.NewRecorder()
+	req := localRequest(http.MethodGet, "/control/triggers", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	s.controlHandler().ServeHTTP(w, req)
+	var list struct {
+		Triggers []triggerHealthJSON `json:"triggers"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding triggers: %v", err)
+	}
+	if len(list.Triggers) != 2 || list.Triggers[1].LastError != "connect: refused" || list.Triggers[0].DegradedSince != nil {
+		t.Errorf("triggers = %+v, want one healthy and one failing trigger", list.Triggers)
+	}
+
+	failing.health = trigger.Health{DAGName: "test", Source: "ftp_watch"}
+	w = httptest.NewRecorder()
+	s.handleHealth(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	json.NewDecoder(w.Body).Decode(&health)
+	if health.Status != "ok" {
+		t.Errorf("status after recovery = %q, want ok", health.Status)
+	}
+}
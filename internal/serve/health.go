@@ -0,0 +1,79 @@
+package serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/druarnfield/pit/internal/buildinfo"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// triggerHealthJSON is one polling trigger in the GET /control/triggers
+// response.
+type triggerHealthJSON struct {
+	Name          string     `json:"name"`
+	DAGName       string     `json:"dag_name"`
+	Source        string     `json:"source"`
+	Failures      int        `json:"failures"`
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextPoll      *time.Time `json:"next_poll,omitempty"`
+}
+
+// triggerHealth returns the health of every trigger that reports it.
+func (s *Server) triggerHealth() []triggerHealthJSON {
+	out := []triggerHealthJSON{}
+	for _, t := range s.current().triggers {
+		hr, ok := t.(trigger.HealthReporter)
+		if !ok {
+			continue
+		}
+		h := hr.Health()
+		entry := triggerHealthJSON{
+			Name:      t.Name(),
+			DAGName:   h.DAGName,
+			Source:    h.Source,
+			Failures:  h.Failures,
+			LastError: h.LastError,
+		}
+		if h.Degraded() {
+			since := h.DegradedSince.UTC()
+			entry.DegradedSince = &since
+		}
+		if !h.NextPoll.IsZero() {
+			next := h.NextPoll.UTC()
+			entry.NextPoll = &next
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// handleTriggers lists polling triggers with their recent failures.
+func (s *Server) handleTriggers(w http.ResponseWriter, r *http.Request) {
+	writeControlJSON(w, http.StatusOK, map[string]any{"triggers": s.triggerHealth()})
+}
+
+// handleHealth reports "degraded" while any polling trigger is failing.
+// It needs no credentials, so it names the DAGs but leaves out trigger
+// details and error messages; those are under /control/triggers.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	type degradedJSON struct {
+		DAGName       string    `json:"dag_name"`
+		Source        string    `json:"source"`
+		DegradedSince time.Time `json:"degraded_since"`
+	}
+	var degraded []degradedJSON
+	for _, th := range s.triggerHealth() {
+		if th.DegradedSince != nil {
+			degraded = append(degraded, degradedJSON{DAGName: th.DAGName, Source: th.Source, DegradedSince: *th.DegradedSince})
+		}
+	}
+
+	resp := map[string]any{"status": "ok", "version": buildinfo.Version()}
+	if len(degraded) > 0 {
+		resp["status"] = "degraded"
+		resp["degraded_triggers"] = degraded
+	}
+	writeControlJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,199 @@
+package serve
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// queuedRun is a triggered run waiting for a free execution slot.
+type queuedRun struct {
+	Event      trigger.Event
+	RunID      string    // assigned at queue time for streaming webhooks ("" = generated at dispatch)
+	Priority   int       // higher runs first
+	EnqueuedAt time.Time // older runs first within the same priority
+	seq        uint64    // tie-breaker for identical timestamps
+}
+
+// runHeap orders queued runs by priority (descending), then age (ascending).
+type runHeap []*queuedRun
+
+func (h runHeap) Len() int { return len(h) }
+
+func (h runHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	if !h[i].EnqueuedAt.Equal(h[j].EnqueuedAt) {
+		return h[i].EnqueuedAt.Before(h[j].EnqueuedAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x any) { *h = append(*h, x.(*queuedRun)) }
+
+func (h *runHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// runQueue is a concurrency-safe priority queue of pending runs.
+type runQueue struct {
+	mu     sync.Mutex
+	items  runHeap
+	seq    uint64
+	notify chan struct{} // signalled (non-blocking) whenever an item is pushed or Wake is called
+}
+
+func newRunQueue() *runQueue {
+	return &runQueue{notify: make(chan struct{}, 1)}
+}
+
+// Push adds a run to the queue.
+func (q *runQueue) Push(r *queuedRun) {
+	q.mu.Lock()
+	q.seq++
+	r.seq = q.seq
+	if r.EnqueuedAt.IsZero() {
+		r.EnqueuedAt = time.Now()
+	}
+	heap.Push(&q.items, r)
+	q.mu.Unlock()
+	q.Wake()
+}
+
+// TryPop removes and returns the highest-priority run, or nil if the queue is empty.
+func (q *runQueue) TryPop() *queuedRun {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return nil
+	}
+	return heap.Pop(&q.items).(*queuedRun)
+}
+
+// TryPopFunc removes and returns the highest-priority run that ok accepts,
+// or nil if there is none. ok is called with the queue locked.
+func (q *runQueue) TryPopFunc(ok func(*queuedRun) bool) *queuedRun {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	best := -1
+	for i, r := range q.items {
+		if ok(r) && (best < 0 || q.items.Less(i, best)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	return heap.Remove(&q.items, best).(*queuedRun)
+}
+
+// Wake wakes a waiting Pop or Wait without adding a run, for when a run it
+// passed over may have become eligible.
+func (q *runQueue) Wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Wait returns a channel that receives when a run is pushed or Wake is called.
+func (q *runQueue) Wait() <-chan struct{} {
+	return q.notify
+}
+
+// Pop blocks until a run is available or ctx is cancelled.
+// Returns nil when ctx is cancelled.
+func (q *runQueue) Pop(ctx context.Context) *queuedRun {
+	for {
+		if r := q.TryPop(); r != nil {
+			return r
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.notify:
+		}
+	}
+}
+
+// Len returns the number of queued runs.
+func (q *runQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Snapshot returns a copy of the queued runs in dispatch order without
+// removing them.
+func (q *runQueue) Snapshot() []*queuedRun {
+	q.mu.Lock()
+	cp := make(runHeap, len(q.items))
+	for i, r := range q.items {
+		c := *r
+		cp[i] = &c
+	}
+	q.mu.Unlock()
+
+	out := make([]*queuedRun, 0, len(cp))
+	for cp.Len() > 0 {
+		out = append(out, heap.Pop(&cp).(*queuedRun))
+	}
+	return out
+}
+
+// Remove discards all queued runs for dagName and returns them.
+func (q *runQueue) Remove(dagName string) []*queuedRun {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.items[:0]
+	var removed []*queuedRun
+	for _, r := range q.items {
+		if r.Event.DAGName == dagName {
+			removed = append(removed, r)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	for i := len(kept); i < len(q.items); i++ {
+		q.items[i] = nil
+	}
+	q.items = kept
+	heap.Init(&q.items)
+	return removed
+}
+
+// Count returns the number of queued runs for dagName.
+func (q *runQueue) Count(dagName string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, r := range q.items {
+		if r.Event.DAGName == dagName {
+			n++
+		}
+	}
+	return n
+}
+
+// Contains reports whether any run for dagName is queued.
+func (q *runQueue) Contains(dagName string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, r := range q.items {
+		if r.Event.DAGName == dagName {
+			return true
+		}
+	}
+	return false
+}
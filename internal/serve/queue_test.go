@@ -0,0 +1,259 @@
+package serve
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+func TestRunQueue_PriorityThenAge(t *testing.T) {
+	q := newRunQueue()
+	base := time.Now()
+
+	q.Push(&queuedRun{Event: trigger.Event{DAGName: "low_old"}, Priority: 0, EnqueuedAt: base})
+	q.Push(&queuedRun{Event: trigger.Event{DAGName: "high_new"}, Priority: 10, EnqueuedAt: base.Add(2 * time.Second)})
+	q.Push(&queuedRun{Event: trigger.Event{DAGName: "high_old"}, Priority: 10, EnqueuedAt: base.Add(time.Second)})
+	q.Push(&queuedRun{Event: trigger.Event{DAGName: "negative"}, Priority: -5, EnqueuedAt: base})
+
+	want := []string{"high_old", "high_new", "low_old", "negative"}
+	for i, name := range want {
+		got := q.TryPop()
+		if got == nil {
+			t.Fatalf("TryPop() #%d = nil, want %q", i, name)
+		}
+		if got.Event.DAGName != name {
+			t.Errorf("TryPop() #%d = %q, want %q", i, got.Event.DAGName, name)
+		}
+	}
+	if got := q.TryPop(); got != nil {
+		t.Errorf("TryPop() on empty queue = %+v, want nil", got)
+	}
+}
+
+func TestRunQueue_SameTimestampFIFO(t *testing.T) {
+	q := newRunQueue()
+	ts := time.Now()
+	for _, name := range []string{"a", "b", "c"} {
+		q.Push(&queuedRun{Event: trigger.Event{DAGName: name}, EnqueuedAt: ts})
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		if got := q.TryPop(); got.Event.DAGName != want {
+			t.Errorf("TryPop() = %q, want %q", got.Event.DAGName, want)
+		}
+	}
+}
+
+func TestRunQueue_PopCancelled(t *testing.T) {
+	q := newRunQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := q.Pop(ctx); got != nil {
+		t.Errorf("Pop() with cancelled context = %+v, want nil", got)
+	}
+}
+
+func TestRunQueue_PopWakesOnPush(t *testing.T) {
+	q := newRunQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan *queuedRun, 1)
+	go func() { done <- q.Pop(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Push(&queuedRun{Event: trigger.Event{DAGName: "late"}})
+
+	select {
+	case got := <-done:
+		if got == nil || got.Event.DAGName != "late" {
+			t.Errorf("Pop() = %+v, want event for %q", got, "late")
+		}
+	case <-ctx.Done():
+		t.Fatal("Pop() did not wake after Push")
+	}
+}
+
+func TestHandleEvent_QueuesWithPriority(t *testing.T) {
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"urgent": {DAG: config.DAGConfig{Name: "urgent", Priority: 5}},
+			"batch":  {DAG: config.DAGConfig{Name: "batch"}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+	}
+
+	s.handleEvent(trigger.Event{DAGName: "batch", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "urgent", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "unknown", Source: "cron"})
+
+	if s.queue.Len() != 2 {
+		t.Fatalf("queue.Len() = %d, want 2", s.queue.Len())
+	}
+	if got := s.queue.TryPop(); got.Event.DAGName != "urgent" {
+		t.Errorf("first dispatched = %q, want %q", got.Event.DAGName, "urgent")
+	}
+	if !s.activeRuns["batch"] {
+		t.Error("queued DAG should be marked active for overlap checks")
+	}
+}
+
+func TestHandleEvent_OverlapSkipWhileQueued(t *testing.T) {
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"test": {DAG: config.DAGConfig{Name: "test", Overlap: "skip"}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+	}
+
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+
+	if s.queue.Len() != 1 {
+		t.Errorf("queue.Len() = %d, want 1 (second event skipped)", s.queue.Len())
+	}
+}
+
+func TestHandleEvent_RecordsAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"test": {DAG: config.DAGConfig{Name: "test", Overlap: "skip"}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+		audit:      auditLog,
+	}
+
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "ftp_watch", Files: []string{"a.csv"}})
+	auditLog.Close()
+
+	events, err := audit.ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	want := []string{audit.ActionTrigger, audit.ActionTrigger, audit.ActionRunSkipped}
+	if strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Errorf("audit actions = %v, want %v", actions, want)
+	}
+	if events[1].Detail != "files: a.csv" {
+		t.Errorf("events[1].Detail = %q, want %q", events[1].Detail, "files: a.csv")
+	}
+}
+
+func TestRunQueue_Remove(t *testing.T) {
+	q := newRunQueue()
+	for i, name := range []string{"a", "b", "a", "c", "a"} {
+		q.Push(&queuedRun{Event: trigger.Event{DAGName: name}, Priority: i})
+	}
+
+	if got := len(q.Remove("a")); got != 3 {
+		t.Errorf("Remove(a) = %d, want 3", got)
+	}
+	if q.Contains("a") {
+		t.Error("Contains(a) = true after Remove")
+	}
+	// Heap order must survive removal: c (priority 3) before b (priority 1)
+	for _, want := range []string{"c", "b"} {
+		if got := q.TryPop(); got == nil || got.Event.DAGName != want {
+			t.Errorf("TryPop() = %+v, want %q", got, want)
+		}
+	}
+}
+
+func TestRunQueue_TryPopFunc(t *testing.T) {
+	q := newRunQueue()
+	for i, name := range []string{"a", "b", "a"} {
+		q.Push(&queuedRun{Event: trigger.Event{DAGName: name}, Priority: i})
+	}
+	notA := func(r *queuedRun) bool { return r.Event.DAGName != "a" }
+	if got := q.TryPopFunc(notA); got == nil || got.Event.DAGName != "b" {
+		t.Errorf("TryPopFunc() = %+v, want b", got)
+	}
+	if got := q.TryPopFunc(notA); got != nil {
+		t.Errorf("TryPopFunc() = %+v, want nil", got)
+	}
+	if got := q.TryPop(); got == nil || got.Priority != 2 {
+		t.Errorf("TryPop() = %+v, want the priority 2 run of a", got)
+	}
+}
+
+func TestHandleEvent_MaxActiveRunsSkip(t *testing.T) {
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"test":   {DAG: config.DAGConfig{Name: "test", MaxActiveRuns: 2}},
+			"queued": {DAG: config.DAGConfig{Name: "queued", MaxActiveRuns: 1, MaxActiveOverflow: "queue"}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+		running:    map[string]int{"test": 1},
+	}
+
+	for i := 0; i < 3; i++ {
+		s.handleEvent(trigger.Event{DAGName: "test", Source: "webhook"})
+		s.handleEvent(trigger.Event{DAGName: "queued", Source: "webhook"})
+	}
+	if got := s.queue.Count("test"); got != 1 {
+		t.Errorf("queued runs of test = %d, want 1 (one running, max_active_runs = 2)", got)
+	}
+	if got := s.queue.Count("queued"); got != 3 {
+		t.Errorf("queued runs of queued = %d, want 3 (overflow = queue)", got)
+	}
+}
+
+func TestNextRun_MaxActiveRuns(t *testing.T) {
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"capped": {DAG: config.DAGConfig{Name: "capped", MaxActiveRuns: 1, MaxActiveOverflow: "queue", Priority: 10}},
+			"other":  {DAG: config.DAGConfig{Name: "other"}},
+		},
+		queue:   newRunQueue(),
+		running: make(map[string]int),
+	}
+	s.queue.Push(&queuedRun{Event: trigger.Event{DAGName: "capped"}, Priority: 10})
+	s.queue.Push(&queuedRun{Event: trigger.Event{DAGName: "capped"}, Priority: 10})
+	s.queue.Push(&queuedRun{Event: trigger.Event{DAGName: "other"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, want := range []string{"capped", "other"} {
+		if got := s.nextRun(ctx); got == nil || got.Event.DAGName != want {
+			t.Fatalf("nextRun() = %+v, want %s", got, want)
+		}
+	}
+	if s.running["capped"] != 1 || s.running["other"] != 1 {
+		t.Errorf("running = %v, want one of each", s.running)
+	}
+
+	// The second capped run waits until the first finishes
+	done := make(chan *queuedRun, 1)
+	go func() { done <- s.nextRun(ctx) }()
+	select {
+	case got := <-done:
+		t.Fatalf("nextRun() = %+v while capped is at max_active_runs", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.mu.Lock()
+	s.running["capped"]--
+	s.mu.Unlock()
+	s.queue.Wake()
+	if got := <-done; got == nil || got.Event.DAGName != "capped" {
+		t.Errorf("nextRun() after a run finished = %+v, want capped", got)
+	}
+}
@@ -0,0 +1,94 @@
+package serve
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// runWindow is the span max_runs_per_hour counts accepted runs over.
+const runWindow = time.Hour
+
+// rateLimitHold names runs deferred by max_runs_overflow = "coalesce" in
+// deferredRun.Blackout.
+const rateLimitHold = "max_runs_per_hour"
+
+// takeRunSlot reports whether cfg's DAG may start another run at now under
+// max_runs_per_hour, counting the run if so. Otherwise next is when the
+// oldest counted run leaves the window. Call with s.mu held.
+func (s *Server) takeRunSlot(cfg *config.ProjectConfig, now time.Time) (ok bool, next time.Time) {
+	limit := cfg.DAG.MaxRunsPerHour
+	if limit <= 0 {
+		return true, time.Time{}
+	}
+	name := cfg.DAG.Name
+	starts := s.runStarts[name]
+	cutoff := now.Add(-runWindow)
+	i := 0
+	for i < len(starts) && !starts[i].After(cutoff) {
+		i++
+	}
+	starts = starts[i:]
+	if len(starts) >= limit {
+		s.runStarts[name] = starts
+		return false, starts[0].Add(runWindow)
+	}
+	s.runStarts[name] = append(starts, now)
+	return true, time.Time{}
+}
+
+// holdForRateLimit handles an event beyond max_runs_per_hour. By default it
+// is dropped; with max_runs_overflow = "coalesce" it is held until next,
+// when the window has room again, and events of the same source arriving
+// meanwhile fold their files into the held run, so a burst of drops on an
+// FTP server becomes one run.
+func (s *Server) holdForRateLimit(ev trigger.Event, cfg *config.ProjectConfig, next time.Time) {
+	limit := cfg.DAG.MaxRunsPerHour
+	if cfg.DAG.MaxRunsOverflow != "coalesce" {
+		log.Printf("[%s] skipping: %d run(s) in the last hour (max_runs_per_hour)", ev.DAGName, limit)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: rateLimitHold})
+		return
+	}
+
+	s.mu.Lock()
+	held, already := s.deferred[ev.DAGName]
+	merged := already && held.Blackout == rateLimitHold && held.Event.Source == ev.Source
+	if merged {
+		held.Event.Files = mergeFiles(held.Event.Files, ev.Files)
+	}
+	s.mu.Unlock()
+	switch {
+	case merged:
+		log.Printf("[%s] coalesced into run held until %s (max_runs_per_hour)", ev.DAGName, held.Until.Format("15:04:05"))
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: rateLimitHold + " (coalesced)"})
+		s.saveState()
+		return
+	case already:
+		log.Printf("[%s] skipping: %d run(s) in the last hour and a run already deferred (%s)", ev.DAGName, limit, held.Blackout)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: rateLimitHold + " (already deferred)"})
+		return
+	}
+
+	log.Printf("[%s] deferred until %s: %d run(s) in the last hour (max_runs_per_hour)", ev.DAGName, next.Format("15:04:05"), limit)
+	s.recordAudit(audit.Event{Action: audit.ActionRunDeferred, Source: ev.Source, DAGName: ev.DAGName, Detail: fmt.Sprintf("%s, until %s", rateLimitHold, next.Format(time.RFC3339))})
+	ev.Files = slices.Clone(ev.Files)
+	s.deferRun(&deferredRun{Event: ev, Blackout: rateLimitHold, Until: next})
+	s.saveState()
+}
+
+// mergeFiles returns held followed by the names in files it lacks. It
+// builds a new slice, so copies of held taken by deferredRuns stay intact.
+func mergeFiles(held, files []string) []string {
+	out := slices.Clone(held)
+	for _, f := range files {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package serve
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// newRateLimitServer builds a Server whose "drops" DAG may start two runs
+// an hour, with the given overflow policy.
+func newRateLimitServer(overflow string) *Server {
+	return &Server{
+		configs: map[string]*config.ProjectConfig{
+			"drops": {DAG: config.DAGConfig{Name: "drops", MaxRunsPerHour: 2, MaxRunsOverflow: overflow}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+		deferred:   make(map[string]*deferredRun),
+		runStarts:  make(map[string][]time.Time),
+	}
+}
+
+func TestTakeRunSlot(t *testing.T) {
+	s := newRateLimitServer("")
+	cfg := s.configs["drops"]
+	now := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+
+	for i, at := range []time.Time{now, now.Add(10 * time.Minute)} {
+		if ok, _ := s.takeRunSlot(cfg, at); !ok {
+			t.Fatalf("run %d refused, want accepted", i+1)
+		}
+	}
+	ok, next := s.takeRunSlot(cfg, now.Add(30*time.Minute))
+	if ok {
+		t.Fatal("third run within the hour accepted, want refused")
+	}
+	if want := now.Add(time.Hour); !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+
+	// The first run has left the window an hour later
+	if ok, _ := s.takeRunSlot(cfg, now.Add(time.Hour)); !ok {
+		t.Error("run after the oldest left the window refused, want accepted")
+	}
+	if got := len(s.runStarts["drops"]); got != 2 {
+		t.Errorf("len(runStarts) = %d, want 2", got)
+	}
+
+	unlimited := &config.ProjectConfig{DAG: config.DAGConfig{Name: "free"}}
+	for range 5 {
+		if ok, _ := s.takeRunSlot(unlimited, now); !ok {
+			t.Fatal("run refused without max_runs_per_hour")
+		}
+	}
+}
+
+func TestHandleEvent_MaxRunsPerHourSkip(t *testing.T) {
+	s := newRateLimitServer("skip")
+
+	for range 5 {
+		s.handleEvent(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"a.csv"}})
+	}
+	if s.queue.Len() != 2 {
+		t.Errorf("queue.Len() = %d, want 2", s.queue.Len())
+	}
+	if len(s.deferred) != 0 {
+		t.Errorf("deferred = %v, want none with max_runs_overflow = skip", s.deferred)
+	}
+}
+
+func TestHandleEvent_MaxRunsPerHourCoalesce(t *testing.T) {
+	s := newRateLimitServer("coalesce")
+
+	s.handleEvent(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"1.csv"}})
+	s.handleEvent(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"2.csv"}})
+	s.handleEvent(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"3.csv"}})
+	s.handleEvent(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"4.csv", "3.csv"}})
+	s.handleEvent(trigger.Event{DAGName: "drops", Source: "webhook"})
+
+	if s.queue.Len() != 2 {
+		t.Errorf("queue.Len() = %d, want 2", s.queue.Len())
+	}
+	held := s.deferred["drops"]
+	if held == nil {
+		t.Fatal("drops should have a run held")
+	}
+	if held.Blackout != rateLimitHold {
+		t.Errorf("held by %q, want %q", held.Blackout, rateLimitHold)
+	}
+	if want := []string{"3.csv", "4.csv"}; !slices.Equal(held.Event.Files, want) {
+		t.Errorf("held files = %v, want %v", held.Event.Files, want)
+	}
+	if until := time.Until(held.Until); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("held until %v from now, want about an hour", until)
+	}
+}
+
+func TestHoldForRateLimit_ReleasesWhenWindowHasRoom(t *testing.T) {
+	s := newRateLimitServer("coalesce")
+	cfg := s.configs["drops"]
+	s.runStarts["drops"] = []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)}
+
+	s.holdForRateLimit(trigger.Event{DAGName: "drops", Source: "ftp_watch", Files: []string{"a.csv"}}, cfg, time.Now().Add(20*time.Millisecond))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.queue.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("held run was not queued once the window had room")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if qr := s.queue.Snapshot()[0]; !slices.Equal(qr.Event.Files, []string{"a.csv"}) {
+		t.Errorf("released run files = %v, want [a.csv]", qr.Event.Files)
+	}
+}
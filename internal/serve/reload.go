@@ -0,0 +1,319 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/druarnfield/pit/internal/api"
+	"github.com/druarnfield/pit/internal/audit"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/dag"
+	"github.com/druarnfield/pit/internal/secrets"
+	"github.com/druarnfield/pit/internal/trigger"
+	"github.com/druarnfield/pit/internal/worker"
+)
+
+// configSet is everything pit serve derives from the project configs and
+// the secrets file. Reload builds a new one next to the running one and
+// swaps it in whole, or not at all.
+type configSet struct {
+	configs       map[string]*config.ProjectConfig
+	store         *secrets.Store
+	triggers      []trigger.Trigger
+	ftpConfigs    map[string]*config.FTPWatchConfig
+	webhookTokens map[string]string // dagName → resolved bearer token
+}
+
+// loadConfigSet discovers the projects under rootDir, loads the secrets
+// file, and builds the triggers and webhook tokens of every DAG. It does
+// not touch a running server.
+func loadConfigSet(rootDir, secretsPath string, workers map[string]*worker.Client) (*configSet, error) {
+	configs, err := config.Discover(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("discovering projects: %w", err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no projects found in %s (see project_globs in pit_config.toml)", rootDir)
+	}
+
+	// Load secrets if configured
+	var store *secrets.Store
+	if secretsPath != "" {
+		store, err = secrets.Load(secretsPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading secrets: %w", err)
+		}
+	}
+
+	set := &configSet{
+		configs:       configs,
+		store:         store,
+		ftpConfigs:    make(map[string]*config.FTPWatchConfig),
+		webhookTokens: make(map[string]string),
+	}
+	for dagName, cfg := range configs {
+		if store != nil {
+			store.SetScopes(dagName, cfg.DAG.SecretScopes)
+		}
+
+		if cfg.DAG.Worker != "" {
+			if _, ok := workers[cfg.DAG.Worker]; !ok {
+				return nil, fmt.Errorf("DAG %q: unknown worker %q (define it under [workers] in pit_config.toml)", dagName, cfg.DAG.Worker)
+			}
+		}
+
+		// One cron trigger per expression; handleEvent dedups same-minute fires
+		for _, expr := range cfg.DAG.Schedule.InZone(cfg.DAG.Timezone) {
+			ct, err := trigger.NewCronTrigger(dagName, expr, cfg.DAG.ScheduleJitter.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("DAG %q: %w", dagName, err)
+			}
+			set.triggers = append(set.triggers, ct)
+		}
+
+		if cfg.DAG.FTPWatch != nil {
+			var resolver trigger.SecretsResolver
+			if store != nil {
+				resolver = store
+			}
+			ft, err := trigger.NewFTPWatchTrigger(dagName, cfg.DAG.FTPWatch, resolver)
+			if err != nil {
+				return nil, fmt.Errorf("DAG %q: %w", dagName, err)
+			}
+			set.triggers = append(set.triggers, ft)
+			set.ftpConfigs[dagName] = cfg.DAG.FTPWatch
+		}
+
+		if cfg.DAG.Webhook != nil {
+			if store == nil {
+				return nil, fmt.Errorf("DAG %q: webhook requires a secrets file (--secrets)", dagName)
+			}
+			token, err := store.Resolve(dagName, cfg.DAG.Webhook.TokenSecret)
+			if err != nil {
+				return nil, fmt.Errorf("DAG %q: resolving webhook token: %w", dagName, err)
+			}
+			set.webhookTokens[dagName] = token
+		}
+	}
+
+	// Stable order for logs and for matching triggers across a reload
+	sort.SliceStable(set.triggers, func(i, j int) bool { return set.triggers[i].Name() < set.triggers[j].Name() })
+	return set, nil
+}
+
+// check validates the set strictly: every validation error, including
+// missing scripts, and every secret a DAG names that the secrets file does
+// not hold. Warnings pass. Returns the problems in DAG order.
+func (set *configSet) check() []string {
+	names := make([]string, 0, len(set.configs))
+	for name := range set.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		cfg := set.configs[name]
+		for _, e := range dag.Validate(cfg, cfg.Dir()) {
+			if !e.IsWarning() {
+				problems = append(problems, e.Error())
+			}
+		}
+		for _, ref := range secretRefs(cfg) {
+			switch {
+			case set.store == nil:
+				problems = append(problems, fmt.Sprintf("%s: %s names secret %q but no secrets file is configured", name, ref.setting, ref.secret))
+			case !set.store.Has(name, ref.secret):
+				problems = append(problems, fmt.Sprintf("%s: %s names secret %q, which is not in the secrets file", name, ref.setting, ref.secret))
+			}
+		}
+	}
+	return problems
+}
+
+// secretRef is a secret named by a DAG's config.
+type secretRef struct {
+	setting string // e.g. "tasks.load.connection"
+	secret  string
+}
+
+// secretRefs lists the secrets cfg names by setting. ${secret:...}
+// references inside http task fields are resolved at run time and not
+// listed.
+func secretRefs(cfg *config.ProjectConfig) []secretRef {
+	var refs []secretRef
+	add := func(setting, secret string) {
+		if secret != "" {
+			refs = append(refs, secretRef{setting: setting, secret: secret})
+		}
+	}
+
+	add("dag.sql.connection", cfg.DAG.SQL.Connection)
+	if cfg.DAG.DBT != nil {
+		add("dag.dbt.connection", cfg.DAG.DBT.Connection)
+	}
+	if fw := cfg.DAG.FTPWatch; fw != nil {
+		add("dag.ftp_watch.secret", fw.Secret)
+		add("dag.ftp_watch.password_secret", fw.PasswordSecret)
+		add("dag.ftp_watch.decrypt_secret", fw.DecryptSecret)
+	}
+	if cfg.DAG.Webhook != nil {
+		add("dag.webhook.token_secret", cfg.DAG.Webhook.TokenSecret)
+	}
+	for _, t := range cfg.Tasks {
+		add("tasks."+t.Name+".connection", t.Connection)
+		add("tasks."+t.Name+".dbt_connection", t.DBTConnection)
+		add("tasks."+t.Name+".secret", t.Secret)
+	}
+	for _, o := range cfg.Outputs {
+		add("outputs."+o.Name+".connection", o.Connection)
+	}
+	return refs
+}
+
+// ReloadError reports why Reload rejected a new config set. The server
+// keeps running the config it had.
+type ReloadError struct {
+	Problems []string
+}
+
+func (e *ReloadError) Error() string {
+	return "reload rejected, keeping the running config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// current returns the config set the server is running. The maps are
+// replaced, never modified, by a reload, so callers may keep reading them.
+func (s *Server) current() *configSet {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return &configSet{
+		configs:       s.configs,
+		store:         s.store,
+		triggers:      s.triggers,
+		ftpConfigs:    s.ftpConfigs,
+		webhookTokens: s.webhookTokens,
+	}
+}
+
+// dagConfig returns the running config of dagName, or nil if no such DAG
+// is registered.
+func (s *Server) dagConfig(dagName string) *config.ProjectConfig {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.configs[dagName]
+}
+
+// serveAPI passes a request to the API handler of the running config.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.cfgMu.RLock()
+	h := s.apiHandler
+	s.cfgMu.RUnlock()
+	h.ServeHTTP(w, r)
+}
+
+// Reload rereads the project configs and the secrets file and validates
+// them in full before anything changes. Only when the new set passes are
+// the triggers stopped, handed the state of the trigger they replace, and
+// restarted from the new set, with webhooks and the API following. A set
+// that fails is reported as a *ReloadError and the running config stays.
+// Runs already queued or executing are not affected either way.
+func (s *Server) Reload(source string) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	set, err := loadConfigSet(s.rootDir, s.opts.SecretsPath, s.workers)
+	var problems []string
+	if err != nil {
+		problems = []string{err.Error()}
+	} else {
+		problems = set.check()
+	}
+	if len(problems) > 0 {
+		log.Printf("pit serve: reload rejected (%d problem(s)), keeping the running config", len(problems))
+		for _, p := range problems {
+			log.Printf("  %s", p)
+		}
+		s.recordAudit(audit.Event{Action: audit.ActionReload, Source: source, Status: "rejected", Detail: strings.Join(problems, "; ")})
+		return &ReloadError{Problems: problems}
+	}
+
+	if s.stopTriggers != nil {
+		s.stopTriggers()
+		s.stopTriggers = nil
+	}
+	carryTriggerState(s.current().triggers, set.triggers)
+
+	s.cfgMu.Lock()
+	s.configs = set.configs
+	s.store = set.store
+	s.triggers = set.triggers
+	s.ftpConfigs = set.ftpConfigs
+	s.webhookTokens = set.webhookTokens
+	if s.metaQuery != nil {
+		s.apiHandler = api.NewHandler(set.configs, s.metaQuery, s.apiToken, s.logHub, s.opts.RunsDir)
+	}
+	s.cfgMu.Unlock()
+
+	if s.triggerCtx != nil {
+		s.stopTriggers = s.launchTriggers(s.triggerCtx, set.triggers)
+	}
+
+	log.Printf("pit serve: reloaded %d DAG(s), %d trigger(s)", len(set.configs), len(set.triggers))
+	s.recordAudit(audit.Event{Action: audit.ActionReload, Source: source, Status: "success",
+		Detail: fmt.Sprintf("%d DAG(s), %d trigger(s)", len(set.configs), len(set.triggers))})
+	return nil
+}
+
+// carryTriggerState hands the saved state of each stopped trigger, such as
+// ftp_watch stability timers, to the new trigger of the same name.
+func carryTriggerState(old, next []trigger.Trigger) {
+	byName := make(map[string]trigger.Stateful, len(old))
+	for _, t := range old {
+		if sf, ok := t.(trigger.Stateful); ok {
+			byName[t.Name()] = sf
+		}
+	}
+	for _, t := range next {
+		sf, ok := t.(trigger.Stateful)
+		if !ok {
+			continue
+		}
+		prev, ok := byName[t.Name()]
+		if !ok {
+			continue
+		}
+		data, err := prev.SaveState()
+		if err != nil {
+			log.Printf("warning: saving state for %s: %v", t.Name(), err)
+			continue
+		}
+		if err := sf.RestoreState(data); err != nil {
+			log.Printf("warning: restoring state for %s: %v", t.Name(), err)
+		}
+	}
+}
+
+// launchTriggers starts triggers sending to s.eventCh and returns a
+// function that cancels them and waits for them to return.
+func (s *Server) launchTriggers(ctx context.Context, triggers []trigger.Trigger) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, t := range triggers {
+		wg.Add(1)
+		go func(trig trigger.Trigger) {
+			defer wg.Done()
+			if err := trig.Start(ctx, s.eventCh); err != nil {
+				log.Printf("trigger %s error: %v", trig.Name(), err)
+			}
+		}(t)
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,167 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+const reloadCronDAG = `[dag]
+name = "nightly"
+schedule = "0 6 * * *"
+
+[[tasks]]
+name = "hello"
+script = "tasks/hello.sh"
+`
+
+func TestReload_RejectsInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "nightly", reloadCronDAG)
+	s, err := NewServer(dir, "", false, Options{})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	before := s.current()
+
+	mkProject(t, dir, "nightly", reloadCronDAG+`
+[[tasks]]
+name = "load"
+script = "tasks/missing.sh"
+connection = "warehouse"
+`)
+	err = s.Reload("test")
+	var rejected *ReloadError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("Reload() error = %v, want *ReloadError", err)
+	}
+	joined := strings.Join(rejected.Problems, "\n")
+	for _, want := range []string{"missing.sh", `tasks.load.connection names secret "warehouse"`} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("problems = %q, want one mentioning %s", rejected.Problems, want)
+		}
+	}
+
+	after := s.current()
+	if len(after.configs["nightly"].Tasks) != 1 {
+		t.Errorf("running config has %d task(s), want the original 1", len(after.configs["nightly"].Tasks))
+	}
+	if len(after.triggers) != 1 || after.triggers[0] != before.triggers[0] {
+		t.Error("triggers were replaced by a rejected reload")
+	}
+}
+
+func TestReload_RejectsMissingSecret(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "nightly", reloadCronDAG)
+	secretsFile := filepath.Join(dir, "secrets.toml")
+	os.WriteFile(secretsFile, []byte("[global]\nwarehouse = \"Server=db\"\n"), 0o644)
+	s, err := NewServer(dir, secretsFile, false, Options{})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+
+	// The secret exists: the reload is accepted
+	mkProject(t, dir, "nightly", reloadCronDAG+`
+[dag.sql]
+connection = "warehouse"
+`)
+	if err := s.Reload("test"); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+
+	// The secrets file loses it: rejected, the accepted config stays
+	os.WriteFile(secretsFile, []byte("[global]\nother = \"x\"\n"), 0o644)
+	err = s.Reload("test")
+	if err == nil || !strings.Contains(err.Error(), `dag.sql.connection names secret "warehouse"`) {
+		t.Fatalf("Reload() error = %v, want the missing secret", err)
+	}
+	if s.current().configs["nightly"].DAG.SQL.Connection != "warehouse" {
+		t.Error("running config changed after a rejected reload")
+	}
+}
+
+func TestReload_SwapsConfigAndCarriesTriggerState(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "nightly", reloadCronDAG)
+	s, err := NewServer(dir, "", false, Options{})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	fired := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	if err := s.triggers[0].(trigger.Stateful).RestoreState([]byte(`{"last_fired":"` + fired.Format(time.RFC3339) + `"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend Start is running the triggers
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	stopped := make(chan struct{})
+	s.triggerCtx = ctx
+	s.stopTriggers = func() { close(stopped) }
+
+	mkProject(t, dir, "hourly", `[dag]
+name = "hourly"
+schedule = "0 * * * *"
+
+[[tasks]]
+name = "hello"
+script = "tasks/hello.sh"
+`)
+	if err := s.Reload("test"); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	defer s.stopTriggers()
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("Reload() did not stop the running triggers")
+	}
+	set := s.current()
+	if len(set.configs) != 2 || len(set.triggers) != 2 {
+		t.Fatalf("after reload: %d DAG(s), %d trigger(s), want 2 and 2", len(set.configs), len(set.triggers))
+	}
+	for _, tr := range set.triggers {
+		ct := tr.(*trigger.CronTrigger)
+		if strings.Contains(ct.Name(), "nightly") && !ct.LastFired().Equal(fired) {
+			t.Errorf("nightly LastFired = %v, want %v carried over", ct.LastFired(), fired)
+		}
+	}
+}
+
+func TestHandleReload_Rejected(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "nightly", reloadCronDAG)
+	s, err := NewServer(dir, "", false, Options{})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	mkProject(t, dir, "nightly", strings.Replace(reloadCronDAG, "tasks/hello.sh", "tasks/gone.sh", 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/control/reload", nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	w := httptest.NewRecorder()
+	s.controlHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	var resp struct {
+		Problems []string `json:"problems"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(resp.Problems) != 1 || !strings.Contains(resp.Problems[0], "gone.sh") {
+		t.Errorf("problems = %q, want the missing script", resp.Problems)
+	}
+}
@@ -0,0 +1,120 @@
+package serve
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/loghub"
+	"github.com/druarnfield/pit/internal/trigger"
+	"github.com/druarnfield/pit/internal/worker"
+)
+
+// executeRemote dispatches a run to the DAG's configured worker and relays
+// the streamed logs into the local log hub so API clients can follow along.
+// The run and its tasks are recorded in the coordinator's metadata store
+// from the worker's result, so status and history include remote runs.
+func (s *Server) executeRemote(ctx context.Context, cfg *config.ProjectConfig, ev trigger.Event, runID, seedDir string, origins map[string]engine.SeedOrigin) (engine.TaskStatus, error) {
+	w := s.workers[cfg.DAG.Worker]
+	if w == nil {
+		return "", fmt.Errorf("unknown worker %q", cfg.DAG.Worker)
+	}
+
+	req := worker.RunRequest{
+		DAGName: ev.DAGName,
+		Trigger: ev.Source,
+		RunID:   runID,
+	}
+	if seedDir != "" {
+		files, err := encodeSeedFiles(seedDir)
+		if err != nil {
+			return "", err
+		}
+		req.Files = files
+		req.Origins = origins
+	}
+
+	if s.logHub != nil {
+		s.logHub.Activate(req.RunID)
+	}
+	log.Printf("[%s] dispatching run %s to worker %q", ev.DAGName, req.RunID, w.Name)
+	if ms := s.opts.MetaStore; ms != nil {
+		warnMeta(ms.RecordRunStart(req.RunID, ev.DAGName, string(engine.StatusRunning), "", ev.Source, time.Now()))
+	}
+
+	res, err := w.Run(ctx, req, func(e loghub.Entry) {
+		if s.logHub != nil {
+			s.logHub.Publish(req.RunID, e)
+		}
+	})
+	if err != nil {
+		if s.logHub != nil {
+			s.logHub.Complete(req.RunID, string(engine.StatusFailed))
+		}
+		if ms := s.opts.MetaStore; ms != nil {
+			warnMeta(ms.RecordRunEnd(req.RunID, string(engine.StatusFailed), time.Now(), err.Error()))
+		}
+		return "", err
+	}
+	if ms := s.opts.MetaStore; ms != nil {
+		recordRemoteResult(ms, req.RunID, res)
+	}
+	if s.logHub != nil {
+		s.logHub.Complete(req.RunID, res.Status)
+	}
+	return engine.TaskStatus(res.Status), nil
+}
+
+// recordRemoteResult records a remote run's tasks and outcome.
+func recordRemoteResult(ms engine.MetadataRecorder, runID string, res *worker.Result) {
+	for _, t := range res.Tasks {
+		if t.Status == string(engine.StatusUpstreamFailed) {
+			warnMeta(ms.RecordTaskUpstreamFailed(runID, t.Name, t.FailedUpstream, t.Error, t.EndedAt))
+			continue
+		}
+		startedAt := t.StartedAt
+		if startedAt.IsZero() {
+			startedAt = t.EndedAt // skipped tasks never start
+		}
+		warnMeta(ms.RecordTaskStart(runID, t.Name, t.Status, "", startedAt))
+		warnMeta(ms.RecordTaskEnd(runID, t.Name, t.Status, t.EndedAt, t.Attempts, t.Error))
+	}
+	endedAt := res.EndedAt
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+	warnMeta(ms.RecordRunEnd(runID, res.Status, endedAt, res.Error))
+}
+
+// warnMeta logs a metadata recording error without failing the run.
+func warnMeta(err error) {
+	if err != nil {
+		log.Printf("warning: metadata recording failed: %v", err)
+	}
+}
+
+// encodeSeedFiles base64-encodes the top-level files in dir for transfer to a worker.
+func encodeSeedFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading seed dir: %w", err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading seed file %q: %w", e.Name(), err)
+		}
+		files[e.Name()] = base64.StdEncoding.EncodeToString(data)
+	}
+	return files, nil
+}
@@ -0,0 +1,57 @@
+package serve
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/meta"
+	"github.com/druarnfield/pit/internal/worker"
+)
+
+func TestRecordRemoteResult(t *testing.T) {
+	store, err := meta.Open(filepath.Join(t.TempDir(), "pit.db"))
+	if err != nil {
+		t.Fatalf("meta.Open() unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	start := time.Now().Add(-time.Minute)
+	runID := "20240115_143022.123-3fa9c1_remote_dag"
+	if err := store.RecordRunStart(runID, "remote_dag", "running", "", "cron", start); err != nil {
+		t.Fatalf("RecordRunStart() unexpected error: %v", err)
+	}
+	recordRemoteResult(store, runID, &worker.Result{
+		RunID:     runID,
+		Status:    "failed",
+		StartedAt: start,
+		EndedAt:   start.Add(30 * time.Second),
+		Error:     "exit status 1",
+		Tasks: []worker.TaskResult{
+			{Name: "extract", Status: "success", StartedAt: start, EndedAt: start.Add(10 * time.Second), Attempts: 1},
+			{Name: "load", Status: "failed", StartedAt: start.Add(10 * time.Second), EndedAt: start.Add(30 * time.Second), Attempts: 2, Error: "exit status 1"},
+			{Name: "report", Status: "upstream_failed", EndedAt: start.Add(30 * time.Second), Error: "upstream load failed", FailedUpstream: []string{"load"}},
+		},
+	})
+
+	run, tasks, err := store.RunDetail(runID)
+	if err != nil {
+		t.Fatalf("RunDetail() unexpected error: %v", err)
+	}
+	if run.Status != "failed" || run.Error != "exit status 1" || run.EndedAt == nil {
+		t.Errorf("run = %+v, want failed with error and end time", run)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("len(tasks) = %d, want 3", len(tasks))
+	}
+	got := map[string]string{}
+	for _, ti := range tasks {
+		got[ti.TaskName] = ti.Status
+	}
+	want := map[string]string{"extract": "success", "load": "failed", "report": "upstream_failed"}
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("task %s status = %q, want %q", name, got[name], status)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,36 +12,63 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/druarnfield/pit/internal/api"
+	"github.com/druarnfield/pit/internal/audit"
 	"github.com/druarnfield/pit/internal/config"
 	"github.com/druarnfield/pit/internal/dag"
 	"github.com/druarnfield/pit/internal/engine"
-	"github.com/druarnfield/pit/internal/loghub"
 	pitftp "github.com/druarnfield/pit/internal/ftp"
+	"github.com/druarnfield/pit/internal/loghub"
+	"github.com/druarnfield/pit/internal/logship"
 	"github.com/druarnfield/pit/internal/meta"
+	"github.com/druarnfield/pit/internal/pgp"
+	"github.com/druarnfield/pit/internal/remotepath"
 	"github.com/druarnfield/pit/internal/secrets"
 	"github.com/druarnfield/pit/internal/trigger"
+	"github.com/druarnfield/pit/internal/web"
+	"github.com/druarnfield/pit/internal/worker"
 )
 
 // Server manages triggers and executes DAGs in response to events.
 type Server struct {
-	rootDir    string
-	configs    map[string]*config.ProjectConfig
-	store      *secrets.Store
-	triggers   []trigger.Trigger
-	ftpConfigs    map[string]*config.FTPWatchConfig
-	webhookTokens map[string]string // dagName → resolved bearer token
-	webhookPort   int
-	logHub        *loghub.Hub
+	rootDir            string
+	cfgMu              sync.RWMutex // guards the fields a reload swaps: configs through webhookTokens, and apiHandler
+	configs            map[string]*config.ProjectConfig
+	store              *secrets.Store
+	triggers           []trigger.Trigger
+	ftpConfigs         map[string]*config.FTPWatchConfig
+	webhookTokens      map[string]string // dagName → resolved bearer token
+	webhookPort        int
+	uiAddr             string // "" = no dashboard listener
+	logHub             *loghub.Hub
 	eventCh            chan trigger.Event
 	opts               engine.ExecuteOpts
 	workspaceArtifacts []string // workspace-level keep_artifacts (nil = use default)
 	apiToken           string
 	apiHandler         http.Handler
+	metaQuery          meta.Store // for rebuilding apiHandler on reload (nil = no API)
+	queue              *runQueue
+	statePath          string // "" = state is not persisted
+	workers            map[string]*worker.Client
+	audit              *audit.Log
+	logShip            *logship.Shipper  // nil = no log shipping
+	slots              chan struct{}     // nil = unlimited concurrent runs
+	drainCh            chan struct{}     // closed by Drain
+	calendar           *trigger.Calendar // nil = no blackouts
+
+	reloadMu     sync.Mutex      // serialises Reload with Start launching and stopping triggers
+	triggerCtx   context.Context // parent of running triggers; nil until Start and after shutdown
+	stopTriggers func()          // cancels and waits for the running triggers
 
 	mu         sync.Mutex
-	activeRuns map[string]bool
+	activeRuns map[string]bool         // DAGs with a run queued or executing
+	running    map[string]int          // DAG → runs currently executing
+	deferred   map[string]*deferredRun // DAG → run held until a blackout ends
+	runStarts  map[string][]time.Time  // DAG → runs accepted within the last hour (max_runs_per_hour)
+	cronFired  map[string]time.Time    // DAG → minute of the last accepted cron fire
+	draining   bool
 }
 
 // Options holds workspace-level settings passed from the CLI layer.
@@ -48,29 +76,41 @@ type Options struct {
 	RunsDir            string
 	RepoCacheDir       string
 	DBTDriver          string
-	WorkspaceArtifacts []string                // workspace-level keep_artifacts (nil = use default)
-	WebhookPort        int                     // port for inbound webhook HTTP server (0 = use default 9090)
-	MetaStore          engine.MetadataRecorder  // nil = no metadata tracking
-	MetaQueryStore     meta.Store               // for API query endpoints (can be same instance as MetaStore)
-	APIToken           string                   // optional bearer token for /api/ endpoints (empty = no auth)
+	WorkspaceArtifacts []string                       // workspace-level keep_artifacts (nil = use default)
+	WebhookPort        int                            // port for inbound webhook HTTP server (0 = use default 9090)
+	MetaStore          engine.MetadataRecorder        // nil = no metadata tracking
+	MetaQueryStore     meta.Store                     // for API query endpoints (can be same instance as MetaStore)
+	APIToken           string                         // optional bearer token for /api/ endpoints (empty = no auth)
+	MaxConcurrentRuns  int                            // max DAG runs executing at once (0 = unlimited)
+	StateFile          string                         // path for persisted trigger/queue state ("" = in-memory only)
+	Workers            map[string]*worker.Client      // remote workers by name, for DAGs with dag.worker set
+	AuditLog           *audit.Log                     // nil = no audit trail
+	Calendar           *config.CalendarConfig         // workspace holidays/blackouts applied to cron triggers (nil = none)
+	MinFreeSpace       int64                          // bytes to leave free on the runs volume (0 = engine default)
+	SnapshotWorkers    int                            // concurrent snapshot file copies (0 = engine default)
+	SDKSocketDir       string                         // directory for per-run SDK sockets ("" = each run's directory)
+	SnapshotSymlinks   string                         // snapshot symlink policy ("" = engine default)
+	SnapshotReadOnly   bool                           // write-protect snapshots while tasks run
+	ArtifactStore      *config.ArtifactStoreConfig    // upload kept artifacts after each run (nil = keep locally)
+	Loader             *config.LoaderConfig           // workspace defaults for loads (nil = driver defaults)
+	Plugins            map[string]config.PluginConfig // external runners and SDK methods
+	Env                *config.EnvConfig              // workspace environment policy for task processes (nil = inherit everything)
+	Lineage            *config.LineageConfig          // OpenLineage endpoint for run events (nil = none)
+	LogShipper         *logship.Shipper               // ships run and task events to external sinks (nil = none)
+	UIAddr             string                         // listen address for the web dashboard, e.g. ":8080" ("" = no dashboard)
 }
 
 // NewServer discovers projects, validates them, and registers triggers.
 func NewServer(rootDir, secretsPath string, verbose bool, srvOpts Options) (*Server, error) {
-	configs, err := config.Discover(rootDir)
+	set, err := loadConfigSet(rootDir, secretsPath, srvOpts.Workers)
 	if err != nil {
-		return nil, fmt.Errorf("discovering projects: %w", err)
-	}
-	if len(configs) == 0 {
-		return nil, fmt.Errorf("no projects found in %s/projects/", rootDir)
+		return nil, err
 	}
 
-	// Load secrets if configured
-	var store *secrets.Store
-	if secretsPath != "" {
-		store, err = secrets.Load(secretsPath)
-		if err != nil {
-			return nil, fmt.Errorf("loading secrets: %w", err)
+	// Validation problems only warn at startup; Reload rejects them
+	for _, cfg := range set.configs {
+		for _, e := range dag.Validate(cfg, cfg.Dir()) {
+			log.Printf("WARNING: %s", e)
 		}
 	}
 
@@ -83,72 +123,63 @@ func NewServer(rootDir, secretsPath string, verbose bool, srvOpts Options) (*Ser
 
 	s := &Server{
 		rootDir:       rootDir,
-		configs:       configs,
-		store:         store,
-		ftpConfigs:    make(map[string]*config.FTPWatchConfig),
-		webhookTokens: make(map[string]string),
+		configs:       set.configs,
+		store:         set.store,
+		triggers:      set.triggers,
+		ftpConfigs:    set.ftpConfigs,
+		webhookTokens: set.webhookTokens,
+		metaQuery:     srvOpts.MetaQueryStore,
 		webhookPort:   webhookPort,
+		uiAddr:        srvOpts.UIAddr,
 		logHub:        logHub,
 		eventCh:       make(chan trigger.Event, 64),
 		opts: engine.ExecuteOpts{
-			RunsDir:      srvOpts.RunsDir,
-			RepoCacheDir: srvOpts.RepoCacheDir,
-			Verbose:      verbose,
-			SecretsPath:  secretsPath,
-			DBTDriver:    srvOpts.DBTDriver,
-			MetaStore:    srvOpts.MetaStore,
-			LogHub:       logHub,
+			RunsDir:          srvOpts.RunsDir,
+			RepoCacheDir:     srvOpts.RepoCacheDir,
+			Verbose:          verbose,
+			SecretsPath:      secretsPath,
+			DBTDriver:        srvOpts.DBTDriver,
+			MetaStore:        srvOpts.MetaStore,
+			LogHub:           logHub,
+			MinFreeSpace:     srvOpts.MinFreeSpace,
+			SnapshotWorkers:  srvOpts.SnapshotWorkers,
+			SDKSocketDir:     srvOpts.SDKSocketDir,
+			SnapshotSymlinks: srvOpts.SnapshotSymlinks,
+			SnapshotReadOnly: srvOpts.SnapshotReadOnly,
+			ArtifactStore:    srvOpts.ArtifactStore,
+			Loader:           srvOpts.Loader,
+			Env:              srvOpts.Env,
+			Lineage:          srvOpts.Lineage,
+			Plugins:          srvOpts.Plugins,
 		},
 		workspaceArtifacts: srvOpts.WorkspaceArtifacts,
 		apiToken:           srvOpts.APIToken,
+		queue:              newRunQueue(),
+		statePath:          srvOpts.StateFile,
+		workers:            srvOpts.Workers,
+		audit:              srvOpts.AuditLog,
+		logShip:            srvOpts.LogShipper,
+		drainCh:            make(chan struct{}),
 		activeRuns:         make(map[string]bool),
+		running:            make(map[string]int),
+		deferred:           make(map[string]*deferredRun),
+		runStarts:          make(map[string][]time.Time),
 	}
-
-	// Create API handler if metadata store is available
-	if srvOpts.MetaQueryStore != nil {
-		s.apiHandler = api.NewHandler(configs, srvOpts.MetaQueryStore, srvOpts.APIToken, logHub, srvOpts.RunsDir)
+	if srvOpts.MaxConcurrentRuns > 0 {
+		s.slots = make(chan struct{}, srvOpts.MaxConcurrentRuns)
 	}
 
-	// Register triggers for each DAG
-	for dagName, cfg := range configs {
-		// Validate before registering
-		if errs := dag.Validate(cfg, cfg.Dir()); len(errs) > 0 {
-			for _, e := range errs {
-				log.Printf("WARNING: %s", e)
-			}
-		}
-
-		if cfg.DAG.Schedule != "" {
-			ct, err := trigger.NewCronTrigger(dagName, cfg.DAG.Schedule)
-			if err != nil {
-				return nil, fmt.Errorf("DAG %q: %w", dagName, err)
-			}
-			s.triggers = append(s.triggers, ct)
-		}
-
-		if cfg.DAG.FTPWatch != nil {
-			var resolver trigger.SecretsResolver
-			if store != nil {
-				resolver = store
-			}
-			ft, err := trigger.NewFTPWatchTrigger(dagName, cfg.DAG.FTPWatch, resolver)
-			if err != nil {
-				return nil, fmt.Errorf("DAG %q: %w", dagName, err)
-			}
-			s.triggers = append(s.triggers, ft)
-			s.ftpConfigs[dagName] = cfg.DAG.FTPWatch
+	if srvOpts.Calendar != nil {
+		cal, err := trigger.NewCalendar(srvOpts.Calendar)
+		if err != nil {
+			return nil, err
 		}
+		s.calendar = cal
+	}
 
-		if cfg.DAG.Webhook != nil {
-			if store == nil {
-				return nil, fmt.Errorf("DAG %q: webhook requires a secrets file (--secrets)", dagName)
-			}
-			token, err := store.Resolve(dagName, cfg.DAG.Webhook.TokenSecret)
-			if err != nil {
-				return nil, fmt.Errorf("DAG %q: resolving webhook token: %w", dagName, err)
-			}
-			s.webhookTokens[dagName] = token
-		}
+	// Create API handler if metadata store is available
+	if srvOpts.MetaQueryStore != nil {
+		s.apiHandler = api.NewHandler(set.configs, srvOpts.MetaQueryStore, srvOpts.APIToken, logHub, srvOpts.RunsDir)
 	}
 
 	if len(s.triggers) == 0 && len(s.webhookTokens) == 0 {
@@ -158,79 +189,157 @@ func NewServer(rootDir, secretsPath string, verbose bool, srvOpts Options) (*Ser
 	return s, nil
 }
 
-// Start launches all triggers and processes events until the context is cancelled.
+// Start launches all triggers and processes events until the context is
+// cancelled or Drain is called. After a drain it returns ErrDrained once
+// every executing run has finished.
 func (s *Server) Start(ctx context.Context) error {
-	log.Printf("pit serve: %d trigger(s) registered", len(s.triggers))
-	for _, t := range s.triggers {
+	triggers := s.current().triggers
+	log.Printf("pit serve: %d trigger(s) registered", len(triggers))
+	for _, t := range triggers {
 		log.Printf("  %s", t.Name())
 	}
 
-	// Launch triggers
-	triggerCtx, triggerCancel := context.WithCancel(ctx)
-	defer triggerCancel()
+	// Restore persisted trigger state and queued runs before anything fires
+	s.restoreState()
 
-	var triggerWg sync.WaitGroup
-	for _, t := range s.triggers {
-		triggerWg.Add(1)
-		go func(trig trigger.Trigger) {
-			defer triggerWg.Done()
-			if err := trig.Start(triggerCtx, s.eventCh); err != nil {
-				log.Printf("trigger %s error: %v", trig.Name(), err)
-			}
-		}(t)
-	}
+	// Launch triggers; Reload restarts them under the same parent
+	s.reloadMu.Lock()
+	s.triggerCtx = ctx
+	s.stopTriggers = s.launchTriggers(ctx, s.current().triggers)
+	s.reloadMu.Unlock()
 
-	// Start HTTP server (API + webhooks)
+	// Start HTTP server (API + webhooks + control). It outlives the triggers
+	// during a drain so operators can keep following the remaining runs.
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", s.handleHealth)
 	if s.apiHandler != nil {
-		mux.Handle("/api/", s.apiHandler)
-	}
-	if len(s.webhookTokens) > 0 {
-		mux.HandleFunc("/webhook/", s.webhookHandler)
+		mux.HandleFunc("/api/", s.serveAPI)
 	}
+	// Always mounted: a reload may add webhooks to a server started without any
+	mux.HandleFunc("/webhook/", s.webhookHandler)
+	mux.Handle("/control/", s.controlHandler())
 
 	httpSrv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.webhookPort),
 		Handler: mux,
 	}
-	triggerWg.Add(1)
+	httpDone := make(chan struct{})
 	go func() {
-		defer triggerWg.Done()
+		defer close(httpDone)
 		log.Printf("pit serve: HTTP server on :%d", s.webhookPort)
 		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("HTTP server error: %v", err)
 		}
 	}()
-	go func() {
-		<-triggerCtx.Done()
+	defer func() {
 		if err := httpSrv.Shutdown(context.Background()); err != nil {
 			log.Printf("HTTP server shutdown error: %v", err)
 		}
+		<-httpDone
 	}()
 
-	// Process events
+	// The dashboard gets its own listener so it can be bound to an
+	// operator-facing address while webhooks stay where they are.
+	if s.uiAddr != "" {
+		uiSrv := &http.Server{
+			Addr:    s.uiAddr,
+			Handler: web.Handler(mux),
+		}
+		uiDone := make(chan struct{})
+		go func() {
+			defer close(uiDone)
+			log.Printf("pit serve: dashboard on %s", s.uiAddr)
+			if err := uiSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("dashboard server error: %v", err)
+			}
+		}()
+		defer func() {
+			if err := uiSrv.Shutdown(context.Background()); err != nil {
+				log.Printf("dashboard server shutdown error: %v", err)
+			}
+			<-uiDone
+		}()
+	}
+
+	// Process events: triggers enqueue, the dispatcher drains by priority
 	var runWg sync.WaitGroup
+	eventCtx, eventCancel := context.WithCancel(ctx)
+	defer eventCancel()
+	eventDone := make(chan struct{})
 	go func() {
+		defer close(eventDone)
 		for {
 			select {
-			case <-ctx.Done():
+			case <-eventCtx.Done():
 				return
 			case ev := <-s.eventCh:
-				s.handleEvent(ctx, ev, &runWg)
+				s.handleEvent(ev)
 			}
 		}
 	}()
+	dispatchCtx, dispatchCancel := context.WithCancel(ctx)
+	defer dispatchCancel()
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		s.dispatch(dispatchCtx, ctx, &runWg)
+	}()
+
+	// Periodically flush state so a crash loses at most one interval
+	if s.statePath != "" {
+		go func() {
+			ticker := time.NewTicker(stateSaveInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.saveState()
+				}
+			}
+		}()
+	}
+
+	// Wait for shutdown signal or drain request
+	drained := false
+	select {
+	case <-ctx.Done():
+		log.Println("pit serve: shutting down...")
+	case <-s.drainCh:
+		drained = true
+	}
 
-	// Wait for shutdown signal
-	<-ctx.Done()
-	log.Println("pit serve: shutting down...")
+	// Stop starting new runs; queued runs stay queued and are persisted below
+	dispatchCancel()
+	<-dispatchDone
+
+	// Stop handling events, then cancel triggers and wait
+	eventCancel()
+	<-eventDone
+	s.reloadMu.Lock()
+	if s.stopTriggers != nil {
+		s.stopTriggers()
+		s.stopTriggers = nil
+	}
+	s.triggerCtx = nil
+	s.reloadMu.Unlock()
 
-	// Cancel triggers and wait
-	triggerCancel()
-	triggerWg.Wait()
+	// Persist stability timers, any runs still waiting in the queue, and
+	// events the triggers sent that were never handled
+	s.queuePending()
+	s.saveState()
 
 	// Wait for active runs to finish
+	if drained {
+		log.Printf("pit serve: draining, waiting for %d running DAG(s) to finish...", s.runningCount())
+	}
 	runWg.Wait()
+
+	if drained {
+		log.Println("pit serve: drained")
+		return ErrDrained
+	}
 	log.Println("pit serve: stopped")
 	return nil
 }
@@ -248,7 +357,7 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expected, ok := s.webhookTokens[dagName]
+	expected, ok := s.current().webhookTokens[dagName]
 	if !ok {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
@@ -264,6 +373,11 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.isDraining() {
+		http.Error(w, "server draining", http.StatusServiceUnavailable)
+		return
+	}
+
 	stream := r.URL.Query().Get("stream") == "true"
 
 	if stream {
@@ -280,10 +394,13 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// webhookStreamRun triggers a run and streams its logs via SSE.
+// webhookStreamRun queues a run and streams its logs via SSE. The run goes
+// through the same queue, execution slots, and dispatch as any other
+// trigger; the stream follows it from the moment it is queued. The run
+// keeps going if the client disconnects.
 func (s *Server) webhookStreamRun(w http.ResponseWriter, r *http.Request, dagName string) {
-	cfg, ok := s.configs[dagName]
-	if !ok {
+	cfg := s.dagConfig(dagName)
+	if cfg == nil {
 		http.Error(w, "unknown DAG", http.StatusNotFound)
 		return
 	}
@@ -293,52 +410,42 @@ func (s *Server) webhookStreamRun(w http.ResponseWriter, r *http.Request, dagNam
 	if overlap == "" {
 		overlap = "allow"
 	}
+	s.recordAudit(audit.Event{Action: audit.ActionTrigger, Source: "webhook", DAGName: dagName, Detail: "stream"})
+
 	s.mu.Lock()
 	isActive := s.activeRuns[dagName]
 	if isActive && overlap == "skip" {
 		s.mu.Unlock()
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: "webhook", DAGName: dagName, Detail: "overlap=skip"})
 		http.Error(w, "DAG already running (overlap=skip)", http.StatusConflict)
 		return
 	}
-	s.activeRuns[dagName] = true
-	s.mu.Unlock()
-
-	defer func() {
-		s.mu.Lock()
-		s.activeRuns[dagName] = false
+	if s.atMaxActive(cfg) {
 		s.mu.Unlock()
-	}()
-
-	opts := s.opts
-	opts.Trigger = "webhook"
-	opts.KeepArtifacts = resolveArtifacts(cfg.DAG.KeepArtifacts, s.workspaceArtifacts)
-
-	// Generate run ID before execution so we can subscribe to the hub
-	runID := engine.GenerateRunID(dagName)
-	opts.RunID = runID
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: "webhook", DAGName: dagName, Detail: "max_active_runs"})
+		http.Error(w, fmt.Sprintf("DAG already has %d active run(s) (max_active_runs)", cfg.DAG.MaxActiveRuns), http.StatusConflict)
+		return
+	}
+	if ok, _ := s.takeRunSlot(cfg, time.Now()); !ok {
+		s.mu.Unlock()
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: "webhook", DAGName: dagName, Detail: rateLimitHold})
+		http.Error(w, fmt.Sprintf("DAG already started %d run(s) in the last hour (max_runs_per_hour)", cfg.DAG.MaxRunsPerHour), http.StatusTooManyRequests)
+		return
+	}
+	s.activeRuns[dagName] = true
 
-	// Activate and subscribe in hub BEFORE starting execution
+	// Assign the run ID up front and subscribe in the hub BEFORE queueing
 	// so no log entries are missed
+	runID := engine.GenerateRunID(dagName)
 	var ch <-chan loghub.Entry
 	if s.logHub != nil {
 		s.logHub.Activate(runID)
 		ch = s.logHub.Subscribe(runID)
 	}
+	s.queue.Push(&queuedRun{Event: trigger.Event{DAGName: dagName, Source: "webhook"}, Priority: cfg.DAG.Priority, RunID: runID})
+	s.mu.Unlock()
 
-	// Start execution in background
-	go func() {
-		log.Printf("[%s] triggered by webhook (streaming)", dagName)
-		run, err := engine.Execute(r.Context(), cfg, opts)
-		if err != nil {
-			log.Printf("[%s] execution error: %v", dagName, err)
-			// Ensure hub is completed so SSE subscriber unblocks
-			if s.logHub != nil {
-				s.logHub.Complete(runID, "failed")
-			}
-			return
-		}
-		log.Printf("[%s] completed: %s", dagName, run.Status)
-	}()
+	log.Printf("[%s] triggered by webhook (streaming)", dagName)
 
 	// Stream logs via SSE — blocks until run completes or client disconnects
 	flusher, ok := w.(http.Flusher)
@@ -382,13 +489,38 @@ func (s *Server) webhookStreamRun(w http.ResponseWriter, r *http.Request, dagNam
 	}
 }
 
-func (s *Server) handleEvent(ctx context.Context, ev trigger.Event, wg *sync.WaitGroup) {
-	cfg, ok := s.configs[ev.DAGName]
-	if !ok {
+// handleEvent applies the overlap policy and queues the run for dispatch.
+func (s *Server) handleEvent(ev trigger.Event) {
+	s.recordAudit(audit.Event{Action: audit.ActionTrigger, Source: ev.Source, DAGName: ev.DAGName, Detail: filesDetail(ev.Files)})
+
+	if s.isDraining() {
+		log.Printf("[%s] skipping: server is draining", ev.DAGName)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "draining"})
+		return
+	}
+
+	cfg := s.dagConfig(ev.DAGName)
+	if cfg == nil {
 		log.Printf("event for unknown DAG %q, skipping", ev.DAGName)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "unknown DAG"})
+		return
+	}
+
+	// Several schedules matching the same minute produce one run
+	if ev.Source == "cron" && s.duplicateCronFire(ev.DAGName, time.Now()) {
+		log.Printf("[%s] skipping: another schedule already fired this minute", ev.DAGName)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "duplicate schedule fire"})
 		return
 	}
 
+	// Workspace calendar applies to scheduled runs only
+	if ev.Source == "cron" && s.calendar != nil && !cfg.DAG.IgnoreCalendar {
+		if b := s.calendar.Check(time.Now()); b != nil {
+			s.holdForBlackout(ev, b)
+			return
+		}
+	}
+
 	// Check overlap policy
 	overlap := cfg.DAG.Overlap
 	if overlap == "" {
@@ -400,119 +532,558 @@ func (s *Server) handleEvent(ctx context.Context, ev trigger.Event, wg *sync.Wai
 	if isActive && overlap == "skip" {
 		s.mu.Unlock()
 		log.Printf("[%s] skipping: DAG already running (overlap=skip)", ev.DAGName)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "overlap=skip"})
+		return
+	}
+	if s.atMaxActive(cfg) {
+		s.mu.Unlock()
+		log.Printf("[%s] skipping: %d run(s) already active (max_active_runs)", ev.DAGName, cfg.DAG.MaxActiveRuns)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "max_active_runs"})
+		return
+	}
+	if ok, next := s.takeRunSlot(cfg, time.Now()); !ok {
+		s.mu.Unlock()
+		s.holdForRateLimit(ev, cfg, next)
 		return
 	}
 	s.activeRuns[ev.DAGName] = true
+	s.queue.Push(&queuedRun{Event: ev, Priority: cfg.DAG.Priority})
 	s.mu.Unlock()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer func() {
-			s.mu.Lock()
-			s.activeRuns[ev.DAGName] = false
-			s.mu.Unlock()
+	if s.queue.Len() > 1 {
+		log.Printf("[%s] queued (priority %d, %d waiting)", ev.DAGName, cfg.DAG.Priority, s.queue.Len())
+	}
+}
+
+// dispatch pulls queued runs in priority order and launches them once an
+// execution slot is free. Launched runs execute under runCtx. Returns when
+// ctx is cancelled.
+func (s *Server) dispatch(ctx, runCtx context.Context, wg *sync.WaitGroup) {
+	for {
+		// Acquire the slot before popping so a higher-priority run that
+		// arrives while we wait is not overtaken by an earlier pop.
+		if s.slots != nil {
+			select {
+			case s.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		qr := s.nextRun(ctx)
+		if qr == nil {
+			if s.slots != nil {
+				<-s.slots
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.slots != nil {
+				defer func() { <-s.slots }()
+			}
+			defer func() {
+				s.mu.Lock()
+				s.running[qr.Event.DAGName]--
+				s.mu.Unlock()
+				// A run held back by max_active_runs may start now
+				s.queue.Wake()
+			}()
+			s.executeEvent(runCtx, qr.Event, qr.RunID)
 		}()
+	}
+}
 
-		log.Printf("[%s] triggered by %s", ev.DAGName, ev.Source)
+// nextRun blocks until a queued run may start or ctx is cancelled, removes
+// it from the queue, and counts it as running. Runs of a DAG already
+// executing max_active_runs times stay queued, letting later runs of other
+// DAGs go first. Returns nil when ctx is cancelled.
+func (s *Server) nextRun(ctx context.Context) *queuedRun {
+	for {
+		s.mu.Lock()
+		qr := s.queue.TryPopFunc(func(r *queuedRun) bool {
+			cfg := s.dagConfig(r.Event.DAGName)
+			return cfg == nil || cfg.DAG.MaxActiveRuns <= 0 || s.running[r.Event.DAGName] < cfg.DAG.MaxActiveRuns
+		})
+		if qr != nil {
+			s.running[qr.Event.DAGName]++
+		}
+		s.mu.Unlock()
+		if qr != nil {
+			return qr
+		}
 
-		opts := s.opts
-		opts.Trigger = ev.Source
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.queue.Wait():
+		}
+	}
+}
 
-		// Resolve keep_artifacts: per-project > workspace > default
-		opts.KeepArtifacts = resolveArtifacts(cfg.DAG.KeepArtifacts, s.workspaceArtifacts)
+// atMaxActive reports whether dagName already has max_active_runs runs
+// queued or executing and its overflow policy skips further runs. Call
+// with s.mu held.
+func (s *Server) atMaxActive(cfg *config.ProjectConfig) bool {
+	limit := cfg.DAG.MaxActiveRuns
+	if limit <= 0 || cfg.DAG.MaxActiveOverflow == "queue" {
+		return false
+	}
+	return s.running[cfg.DAG.Name]+s.queue.Count(cfg.DAG.Name) >= limit
+}
 
-		// For FTP events, download files to temp dir
-		var seedDir string
-		if ev.Source == "ftp_watch" && len(ev.Files) > 0 {
-			var err error
-			seedDir, err = s.downloadFTPFiles(ev)
-			if err != nil {
-				log.Printf("[%s] FTP download failed: %v", ev.DAGName, err)
-				return
+// executeEvent runs a dequeued DAG event to completion. runID is the ID
+// assigned when the run was queued, or "" to generate one.
+func (s *Server) executeEvent(ctx context.Context, ev trigger.Event, runID string) {
+	cfg := s.dagConfig(ev.DAGName)
+	defer func() {
+		s.mu.Lock()
+		s.activeRuns[ev.DAGName] = false
+		s.mu.Unlock()
+	}()
+
+	log.Printf("[%s] triggered by %s", ev.DAGName, ev.Source)
+
+	opts := s.opts
+	opts.Trigger = ev.Source
+	opts.RunID = runID
+	if opts.RunID == "" {
+		opts.RunID = engine.GenerateRunID(ev.DAGName)
+	}
+	// A run that fails before the engine starts never completes in the
+	// hub; close it so streaming webhook clients are not left waiting.
+	endStatus := engine.StatusFailed
+	if s.logHub != nil {
+		defer func() {
+			if _, done := s.logHub.RunStatus(opts.RunID); !done {
+				s.logHub.Complete(opts.RunID, string(endStatus))
+			}
+		}()
+	}
+	opts.OnStall = s.stallReporter(ev.Source)
+
+	if cfg == nil {
+		// A reload removed the DAG while the run was queued
+		log.Printf("[%s] skipping: DAG is no longer registered", ev.DAGName)
+		s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "unknown DAG"})
+		endStatus = engine.StatusSkipped
+		return
+	}
+
+	// Honour overlap against `pit run` invocations of the same DAG
+	lock, err := s.acquireDAGLock(ctx, cfg, ev, opts.RunID)
+	if errors.Is(err, errOverlapSkipped) {
+		endStatus = engine.StatusSkipped
+		return
+	}
+	if err != nil {
+		log.Printf("[%s] %v", ev.DAGName, err)
+		return
+	}
+	if lock != nil {
+		defer func() {
+			if err := lock.Release(); err != nil {
+				log.Printf("[%s] warning: %v", ev.DAGName, err)
 			}
-			defer os.RemoveAll(seedDir)
-			opts.DataSeedDir = seedDir
+		}()
+	}
+
+	// Resolve keep_artifacts: per-project > workspace > default
+	opts.KeepArtifacts = resolveArtifacts(cfg.DAG.KeepArtifacts, s.workspaceArtifacts)
+
+	// For FTP events, download files to temp dir
+	var seedDir string
+	if ev.Source == "ftp_watch" && len(ev.Files) > 0 {
+		var err error
+		var origins map[string]engine.SeedOrigin
+		seedDir, origins, err = s.downloadFTPFiles(ev)
+		if err != nil {
+			log.Printf("[%s] FTP download failed: %v", ev.DAGName, err)
+			return
 		}
+		defer os.RemoveAll(seedDir)
+		opts.DataSeedDir = seedDir
+		opts.SeedOrigins = origins
+		opts.TriggerFiles = ev.Files
+	}
 
-		run, err := engine.Execute(ctx, cfg, opts)
+	s.recordAudit(audit.Event{Action: audit.ActionRunStarted, Source: ev.Source, DAGName: ev.DAGName, RunID: opts.RunID})
+	s.logShip.Ship(logship.Record{Level: logship.LevelInfo, Event: logship.EventRunStarted, DAG: ev.DAGName, RunID: opts.RunID, Trigger: ev.Source})
+
+	var status engine.TaskStatus
+	var run *engine.Run
+	if cfg.DAG.Worker != "" {
+		st, err := s.executeRemote(ctx, cfg, ev, opts.RunID, seedDir, opts.SeedOrigins)
+		if err != nil {
+			log.Printf("[%s] remote execution error: %v", ev.DAGName, err)
+			s.recordAudit(audit.Event{Action: audit.ActionRunFinished, Source: ev.Source, DAGName: ev.DAGName, RunID: opts.RunID, Status: string(engine.StatusFailed), Detail: err.Error()})
+			s.shipRunError(ev, opts.RunID, err)
+			return
+		}
+		status = st
+	} else {
+		var err error
+		run, err = executeWithRetry(ctx, cfg, opts, engine.Execute, func(attempt int, delay time.Duration, err error) {
+			log.Printf("[%s] infrastructure failure, retrying in %s (retry %d of %d): %v", ev.DAGName, delay, attempt, cfg.DAG.InfraRetries, err)
+			s.recordAudit(audit.Event{Action: audit.ActionRunRetried, Source: ev.Source, DAGName: ev.DAGName, RunID: opts.RunID, Detail: err.Error()})
+			// No task ran; clear the partial run directory so the retry
+			// snapshots into a clean one under the same run ID.
+			os.RemoveAll(filepath.Join(opts.RunsDir, opts.RunID))
+		})
 		if err != nil {
 			log.Printf("[%s] execution error: %v", ev.DAGName, err)
+			s.recordAudit(audit.Event{Action: audit.ActionRunFinished, Source: ev.Source, DAGName: ev.DAGName, RunID: opts.RunID, Status: string(engine.StatusFailed), Detail: err.Error()})
+			s.shipRunError(ev, opts.RunID, err)
 			return
 		}
+		status = run.Status
+		defer s.reportAnomalies(ev.Source, run)
+	}
 
-		log.Printf("[%s] completed: %s", ev.DAGName, run.Status)
+	log.Printf("[%s] completed: %s", ev.DAGName, status)
+	var detail string
+	if run != nil {
+		detail = reportFailures(run)
+	}
+	s.recordAudit(audit.Event{Action: audit.ActionRunFinished, Source: ev.Source, DAGName: ev.DAGName, RunID: opts.RunID, Status: string(status), Detail: detail})
+	if run != nil {
+		s.shipRun(ev.Source, run, detail)
+	} else {
+		s.logShip.Ship(logship.Record{Level: statusLevel(status), Event: logship.EventRunFinished, DAG: ev.DAGName, RunID: opts.RunID, Trigger: ev.Source, Status: string(status)})
+	}
 
-		// Archive FTP files on success
-		if ev.Source == "ftp_watch" && run.Status == engine.StatusSuccess {
-			if err := s.archiveFTPFiles(ev); err != nil {
-				log.Printf("[%s] FTP archive failed: %v", ev.DAGName, err)
-			}
+	// Archive FTP files on success
+	if ev.Source == "ftp_watch" && status == engine.StatusSuccess {
+		if err := s.archiveFTPFiles(ev); err != nil {
+			log.Printf("[%s] FTP archive failed: %v", ev.DAGName, err)
 		}
-	}()
+	}
 }
 
-// resolveFTPCredentials resolves host, user, and password for the FTP connection.
-// When cfg.Secret is set, all three are pulled from a structured secret.
-// Otherwise falls back to legacy cfg.Host / cfg.User / cfg.PasswordSecret fields.
-func (s *Server) resolveFTPCredentials(dagName string, ftpCfg *config.FTPWatchConfig) (host, user, password string, err error) {
-	if ftpCfg.Secret != "" {
-		host, err = s.store.ResolveField(dagName, ftpCfg.Secret, "host")
-		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.host: %w", ftpCfg.Secret, err)
+// defaultInfraRetryDelay is the wait before the first retry of a run that
+// failed with engine.ErrInfrastructure when infra_retry_delay is unset.
+const defaultInfraRetryDelay = 30 * time.Second
+
+// executeWithRetry runs execute, and runs it again after a growing delay
+// while it fails with engine.ErrInfrastructure, up to infra_retries more
+// times. Task failures and other errors are returned at once. onRetry is
+// called before each wait.
+func executeWithRetry(ctx context.Context, cfg *config.ProjectConfig, opts engine.ExecuteOpts,
+	execute func(context.Context, *config.ProjectConfig, engine.ExecuteOpts) (*engine.Run, error),
+	onRetry func(attempt int, delay time.Duration, err error)) (*engine.Run, error) {
+	delay := cfg.DAG.InfraRetryDelay.Duration
+	if delay <= 0 {
+		delay = defaultInfraRetryDelay
+	}
+	for attempt := 1; ; attempt++ {
+		run, err := execute(ctx, cfg, opts)
+		if err == nil || !errors.Is(err, engine.ErrInfrastructure) || attempt > cfg.DAG.InfraRetries {
+			return run, err
 		}
-		user, err = s.store.ResolveField(dagName, ftpCfg.Secret, "user")
-		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.user: %w", ftpCfg.Secret, err)
+		onRetry(attempt, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
 		}
-		password, err = s.store.ResolveField(dagName, ftpCfg.Secret, "password")
-		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.password: %w", ftpCfg.Secret, err)
+		delay *= 2
+	}
+}
+
+// errOverlapSkipped is returned by acquireDAGLock when the DAG's lock, or
+// every run slot, is held and its policy is to skip.
+var errOverlapSkipped = errors.New("run skipped (overlap=skip)")
+
+// acquireDAGLock takes the per-DAG lock file that `pit run` also takes, so
+// scheduled and CLI runs of the same DAG honour dag.overlap between them.
+// It returns a nil lock when overlap is "allow" (the default) and there is
+// no max_active_runs. With "skip" a held lock skips the run; with "wait"
+// the run waits, keeping its execution slot, until the holder finishes.
+// max_active_runs takes one of the DAG's run slots the same way, by its
+// overflow policy.
+func (s *Server) acquireDAGLock(ctx context.Context, cfg *config.ProjectConfig, ev trigger.Event, runID string) (*engine.DAGLock, error) {
+	switch cfg.DAG.Overlap {
+	case "skip":
+		lock, err := engine.TryDAGLock(s.opts.RunsDir, ev.DAGName, runID)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			log.Printf("[%s] skipping: %v (overlap=skip)", ev.DAGName, locked)
+			s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "overlap=skip: locked by " + locked.Holder.String()})
+			return nil, errOverlapSkipped
 		}
-		return host, user, password, nil
+		return lock, err
+	case "wait":
+		return engine.WaitDAGLock(ctx, s.opts.RunsDir, ev.DAGName, runID, func(holder engine.LockInfo) {
+			log.Printf("[%s] waiting: DAG is already running: %s (overlap=wait)", ev.DAGName, holder)
+		})
 	}
+	if limit := cfg.DAG.MaxActiveRuns; limit > 0 {
+		if cfg.DAG.MaxActiveOverflow == "queue" {
+			return engine.WaitDAGSlot(ctx, s.opts.RunsDir, ev.DAGName, runID, limit, func(holder engine.LockInfo) {
+				log.Printf("[%s] waiting: %d run(s) already active, the oldest %s (max_active_runs)", ev.DAGName, limit, holder)
+			})
+		}
+		lock, err := engine.TryDAGSlot(s.opts.RunsDir, ev.DAGName, runID, limit)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			log.Printf("[%s] skipping: %v", ev.DAGName, locked)
+			s.recordAudit(audit.Event{Action: audit.ActionRunSkipped, Source: ev.Source, DAGName: ev.DAGName, Detail: "max_active_runs: oldest " + locked.Holder.String()})
+			return nil, errOverlapSkipped
+		}
+		return lock, err
+	}
+	return nil, nil
+}
 
-	// Legacy: host and user from config, password from plain secret
-	password, err = s.store.Resolve(dagName, ftpCfg.PasswordSecret)
+// reportFailures logs the classified failure and relevant log lines of
+// each failed task in run, and returns a one-line summary for the audit log.
+func reportFailures(run *engine.Run) string {
+	var summary []string
+	for _, ti := range run.FailedTasks() {
+		log.Printf("[%s] task %s failed: %s", run.DAGName, ti.Name, ti.Failure)
+		for _, l := range ti.Failure.Lines {
+			log.Printf("[%s]   | %s", run.DAGName, l)
+		}
+		summary = append(summary, ti.Name+": "+ti.Failure.String())
+	}
+	return strings.Join(summary, "; ")
+}
+
+// shipRun ships a task_finished record for each task of run, then a
+// run_finished record with summary (the failures reportFailures found).
+func (s *Server) shipRun(source string, run *engine.Run, summary string) {
+	if s.logShip == nil {
+		return
+	}
+	for _, t := range run.Tasks {
+		instances := t.Instances
+		if len(instances) == 0 {
+			instances = []*engine.TaskInstance{t}
+		}
+		for _, ti := range instances {
+			r := logship.Record{
+				Level:    statusLevel(ti.Status),
+				Event:    logship.EventTaskFinished,
+				DAG:      run.DAGName,
+				RunID:    run.ID,
+				Task:     ti.Name,
+				Trigger:  source,
+				Status:   string(ti.Status),
+				Duration: taskDuration(ti),
+				Attempts: ti.Attempt,
+			}
+			if ti.Failure != nil {
+				r.Failure, r.Lines = ti.Failure.String(), ti.Failure.Lines
+			}
+			s.logShip.Ship(r)
+		}
+	}
+	s.logShip.Ship(logship.Record{
+		Level:    statusLevel(run.Status),
+		Event:    logship.EventRunFinished,
+		DAG:      run.DAGName,
+		RunID:    run.ID,
+		Trigger:  source,
+		Status:   string(run.Status),
+		Duration: run.EndedAt.Sub(run.StartedAt).Seconds(),
+		Message:  summary,
+	})
+}
+
+// shipRunError ships the run_finished record of a run that failed to execute.
+func (s *Server) shipRunError(ev trigger.Event, runID string, err error) {
+	s.logShip.Ship(logship.Record{Level: logship.LevelError, Event: logship.EventRunFinished, DAG: ev.DAGName, RunID: runID, Trigger: ev.Source, Status: string(engine.StatusFailed), Message: err.Error()})
+}
+
+// statusLevel is the level of a record for a task or run that ended in status.
+func statusLevel(status engine.TaskStatus) string {
+	switch status {
+	case engine.StatusFailed, engine.StatusUpstreamFailed:
+		return logship.LevelError
+	default:
+		return logship.LevelInfo
+	}
+}
+
+// taskDuration returns how long ti ran in seconds, or 0 if it never started.
+func taskDuration(ti *engine.TaskInstance) float64 {
+	if ti.StartedAt.IsZero() || ti.EndedAt.IsZero() {
+		return 0
+	}
+	return ti.EndedAt.Sub(ti.StartedAt).Seconds()
+}
+
+// reportAnomalies logs and audits tasks that ran unusually slowly in run.
+func (s *Server) reportAnomalies(source string, run *engine.Run) {
+	for _, a := range run.Anomalies {
+		log.Printf("[%s] warning: unusually slow: %s", run.DAGName, a)
+		s.recordAudit(audit.Event{Action: audit.ActionSlowTask, Source: source, DAGName: run.DAGName, RunID: run.ID, Detail: a.String()})
+		s.logShip.Ship(logship.Record{Level: logship.LevelWarning, Event: logship.EventWarning, DAG: run.DAGName, RunID: run.ID, Trigger: source, Message: "unusually slow: " + a.String()})
+	}
+}
+
+// stallReporter returns an engine.StallFunc that logs and audits stalled tasks.
+func (s *Server) stallReporter(source string) engine.StallFunc {
+	return func(run *engine.Run, task string, idle time.Duration) {
+		detail := fmt.Sprintf("%s produced no output for %s", task, idle.Round(time.Second))
+		log.Printf("[%s] warning: %s", run.DAGName, detail)
+		s.recordAudit(audit.Event{Action: audit.ActionStalledTask, Source: source, DAGName: run.DAGName, RunID: run.ID, Detail: detail})
+		s.logShip.Ship(logship.Record{Level: logship.LevelWarning, Event: logship.EventWarning, DAG: run.DAGName, RunID: run.ID, Task: task, Trigger: source, Message: detail})
+	}
+}
+
+// duplicateCronFire reports whether a cron event for dagName was already
+// accepted in the same minute as now, and records now otherwise.
+func (s *Server) duplicateCronFire(dagName string, now time.Time) bool {
+	minute := now.Truncate(time.Minute)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cronFired == nil {
+		s.cronFired = make(map[string]time.Time)
+	}
+	if s.cronFired[dagName].Equal(minute) {
+		return true
+	}
+	s.cronFired[dagName] = minute
+	return false
+}
+
+// recordAudit appends an event to the audit log, logging (not failing) on error.
+func (s *Server) recordAudit(e audit.Event) {
+	if err := s.audit.Record(e); err != nil {
+		log.Printf("warning: audit log: %v", err)
+	}
+}
+
+// filesDetail summarises FTP event files for the audit log.
+func filesDetail(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	return "files: " + strings.Join(files, ", ")
+}
+
+// ftpRateLimit returns the download rate for an FTP watch: its own
+// rate_limit, else the rate_limit field of its structured secret, else 0.
+func (s *Server) ftpRateLimit(dagName string, ftpCfg *config.FTPWatchConfig) (int64, error) {
+	if ftpCfg.RateLimit > 0 || ftpCfg.Secret == "" {
+		return int64(ftpCfg.RateLimit), nil
+	}
+	rateStr, err := s.current().store.ResolveField(dagName, ftpCfg.Secret, "rate_limit")
 	if err != nil {
-		return "", "", "", fmt.Errorf("resolving password: %w", err)
+		return 0, nil
 	}
-	return ftpCfg.Host, ftpCfg.User, password, nil
+	rate, err := pitftp.ParseRateLimit(rateStr)
+	if err != nil {
+		return 0, fmt.Errorf("%s.rate_limit: %w", ftpCfg.Secret, err)
+	}
+	return rate, nil
 }
 
-func (s *Server) downloadFTPFiles(ev trigger.Event) (string, error) {
-	ftpCfg, ok := s.ftpConfigs[ev.DAGName]
+func (s *Server) downloadFTPFiles(ev trigger.Event) (string, map[string]engine.SeedOrigin, error) {
+	set := s.current()
+	ftpCfg, ok := set.ftpConfigs[ev.DAGName]
 	if !ok {
-		return "", fmt.Errorf("no FTP config for DAG %q", ev.DAGName)
+		return "", nil, fmt.Errorf("no FTP config for DAG %q", ev.DAGName)
+	}
+
+	opts, err := trigger.FTPConnectOptions(set.store, ev.DAGName, ftpCfg)
+	if err != nil {
+		return "", nil, err
 	}
 
-	host, user, password, err := s.resolveFTPCredentials(ev.DAGName, ftpCfg)
+	rateLimit, err := s.ftpRateLimit(ev.DAGName, ftpCfg)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	client, err := pitftp.Connect(host, ftpCfg.Port, user, password, ftpCfg.TLS)
+	client, err := pitftp.Connect(opts)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	defer client.Close()
+	client.SetRateLimit(rateLimit)
+
+	server := ftpCfg.Secret
+	if server == "" {
+		server = ftpCfg.Host
+	}
 
 	tmpDir, err := os.MkdirTemp("", "pit-ftp-*")
 	if err != nil {
-		return "", fmt.Errorf("creating temp dir: %w", err)
+		return "", nil, fmt.Errorf("creating temp dir: %w", err)
 	}
 
+	// Record where each file came from for the run's input manifest
+	origins := make(map[string]engine.SeedOrigin, len(ev.Files))
 	for _, name := range ev.Files {
-		remotePath := filepath.Join(ftpCfg.Directory, name)
+		if !remotepath.IsName(name) {
+			os.RemoveAll(tmpDir)
+			return "", nil, fmt.Errorf("unsafe file name %q", name)
+		}
+		remotePath := remotepath.Join(ftpCfg.Directory, name)
 		localPath := filepath.Join(tmpDir, name)
 		if err := client.Download(remotePath, localPath); err != nil {
 			os.RemoveAll(tmpDir)
-			return "", fmt.Errorf("downloading %q: %w", name, err)
+			return "", nil, fmt.Errorf("downloading %q: %w", name, err)
 		}
 		log.Printf("[%s] downloaded %s", ev.DAGName, name)
+		origins[name] = engine.SeedOrigin{
+			Source:    engine.InputSourceFTPWatch,
+			Remote:    engine.FTPRemote(server, remotePath),
+			FetchedAt: time.Now(),
+		}
 	}
 
-	return tmpDir, nil
+	if ftpCfg.DecryptSecret != "" {
+		if err := s.decryptFTPFiles(ev.DAGName, ftpCfg.DecryptSecret, tmpDir, ev.Files); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", nil, err
+		}
+		// Decrypted files replace the downloads they came from
+		for _, name := range ev.Files {
+			if plainName, ok := pgp.DecryptedName(name); ok {
+				origins[plainName] = origins[name]
+				delete(origins, name)
+			}
+		}
+	}
+
+	return tmpDir, origins, nil
+}
+
+// decryptFTPFiles replaces each downloaded file ending in .pgp, .gpg, or
+// .asc with its plaintext, using the private key in secretName. Other
+// files, such as checksum sidecars, are left as they are.
+func (s *Server) decryptFTPFiles(dagName, secretName, dir string, files []string) error {
+	store := s.current().store
+	if store == nil {
+		return fmt.Errorf("secrets store required for ftp_watch.decrypt_secret")
+	}
+	key, err := store.ResolveField(dagName, secretName, "private_key")
+	if err != nil {
+		return fmt.Errorf("resolving %s.private_key: %w", secretName, err)
+	}
+	// Unprotected keys have no passphrase field
+	passphrase, _ := store.ResolveField(dagName, secretName, "passphrase")
+
+	for _, name := range files {
+		plainName, ok := pgp.DecryptedName(name)
+		if !ok {
+			continue
+		}
+		src := filepath.Join(dir, name)
+		if err := pgp.DecryptFile(src, filepath.Join(dir, plainName), key, passphrase); err != nil {
+			return fmt.Errorf("decrypting %q: %w", name, err)
+		}
+		if err := os.Remove(src); err != nil {
+			return err
+		}
+		log.Printf("[%s] decrypted %s → %s", dagName, name, plainName)
+	}
+	return nil
 }
 
 // resolveArtifacts returns the keep_artifacts list: per-project > workspace > default.
@@ -527,17 +1098,18 @@ func resolveArtifacts(perProject, workspace []string) []string {
 }
 
 func (s *Server) archiveFTPFiles(ev trigger.Event) error {
-	ftpCfg, ok := s.ftpConfigs[ev.DAGName]
+	set := s.current()
+	ftpCfg, ok := set.ftpConfigs[ev.DAGName]
 	if !ok || ftpCfg.ArchiveDir == "" {
 		return nil
 	}
 
-	host, user, password, err := s.resolveFTPCredentials(ev.DAGName, ftpCfg)
+	opts, err := trigger.FTPConnectOptions(set.store, ev.DAGName, ftpCfg)
 	if err != nil {
 		return err
 	}
 
-	client, err := pitftp.Connect(host, ftpCfg.Port, user, password, ftpCfg.TLS)
+	client, err := pitftp.Connect(opts)
 	if err != nil {
 		return err
 	}
@@ -546,8 +1118,8 @@ func (s *Server) archiveFTPFiles(ev trigger.Event) error {
 	client.MkdirAll(ftpCfg.ArchiveDir)
 
 	for _, name := range ev.Files {
-		src := filepath.Join(ftpCfg.Directory, name)
-		dst := filepath.Join(ftpCfg.ArchiveDir, name)
+		src := remotepath.Join(ftpCfg.Directory, name)
+		dst := remotepath.Join(ftpCfg.ArchiveDir, name)
 		if err := client.Move(src, dst); err != nil {
 			return fmt.Errorf("archiving %q: %w", name, err)
 		}
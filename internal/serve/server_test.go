@@ -1,14 +1,23 @@
 package serve
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/logship"
+	"github.com/druarnfield/pit/internal/secrets"
+	"github.com/druarnfield/pit/internal/trigger"
 )
 
 func TestNewServer_NoProjects(t *testing.T) {
@@ -156,6 +165,49 @@ ftp_pass = "secret123"
 	}
 }
 
+func TestNewServer_MultipleSchedules(t *testing.T) {
+	dir := t.TempDir()
+	mkProject(t, dir, "multi_cron", `[dag]
+name = "multi_cron"
+schedule = ["0 * * * 1-5", "0 6 * * 6"]
+
+[[tasks]]
+name = "hello"
+script = "tasks/hello.sh"
+`)
+
+	s, err := NewServer(dir, "", false, Options{})
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	if len(s.triggers) != 2 {
+		t.Errorf("len(triggers) = %d, want 2 (one per schedule)", len(s.triggers))
+	}
+}
+
+func TestHandleEvent_DedupsSameMinuteCron(t *testing.T) {
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"test": {DAG: config.DAGConfig{Name: "test"}},
+		},
+		queue:      newRunQueue(),
+		activeRuns: make(map[string]bool),
+	}
+
+	// Two schedules matching the same minute: only one run
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "cron"})
+	// Non-cron events are never deduplicated
+	s.handleEvent(trigger.Event{DAGName: "test", Source: "webhook"})
+
+	if s.queue.Len() != 2 {
+		t.Errorf("queue.Len() = %d, want 2", s.queue.Len())
+	}
+	if s.duplicateCronFire("test", time.Now().Add(time.Minute)) {
+		t.Error("a fire in the next minute should not be a duplicate")
+	}
+}
+
 func TestNewServer_InvalidCronSchedule(t *testing.T) {
 	dir := t.TempDir()
 	mkProject(t, dir, "bad_cron", `[dag]
@@ -237,3 +289,197 @@ func mkProject(t *testing.T, root, name, tomlContent string) {
 	os.WriteFile(filepath.Join(dir, "tasks", "hello.sh"), []byte("#!/bin/bash\necho hi"), 0o755)
 	os.WriteFile(filepath.Join(dir, "tasks", "process.py"), []byte("print('ok')"), 0o644)
 }
+
+func TestAcquireDAGLock_HeldByCLIRun(t *testing.T) {
+	runsDir := t.TempDir()
+	s := newControlServer("")
+	s.opts.RunsDir = runsDir
+	ev := trigger.Event{DAGName: "test", Source: "cron"}
+
+	held, err := engine.TryDAGLock(runsDir, "test", "cli-run")
+	if err != nil {
+		t.Fatalf("TryDAGLock() unexpected error: %v", err)
+	}
+
+	skipCfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test", Overlap: "skip"}}
+	if _, err := s.acquireDAGLock(context.Background(), skipCfg, ev, "serve-run"); !errors.Is(err, errOverlapSkipped) {
+		t.Errorf("acquireDAGLock(skip) error = %v, want errOverlapSkipped", err)
+	}
+
+	allowCfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}}
+	if lock, err := s.acquireDAGLock(context.Background(), allowCfg, ev, "serve-run"); lock != nil || err != nil {
+		t.Errorf("acquireDAGLock(allow) = %v, %v, want no lock and no error", lock, err)
+	}
+
+	waitCfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "test", Overlap: "wait"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Release()
+	}()
+	lock, err := s.acquireDAGLock(ctx, waitCfg, ev, "serve-run")
+	if err != nil {
+		t.Fatalf("acquireDAGLock(wait) unexpected error: %v", err)
+	}
+	lock.Release()
+}
+
+func TestFTPRateLimit(t *testing.T) {
+	store, err := secrets.LoadFromBytes([]byte(`
+[global.throttled]
+host = "ftp.example.com"
+rate_limit = "2MB"
+
+[global.bad]
+rate_limit = "fast"
+
+[global.open]
+host = "ftp.example.com"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{store: store}
+
+	tests := []struct {
+		name    string
+		cfg     config.FTPWatchConfig
+		want    int64
+		wantErr bool
+	}{
+		{"watch setting wins", config.FTPWatchConfig{Secret: "throttled", RateLimit: 1000}, 1000, false},
+		{"from secret", config.FTPWatchConfig{Secret: "throttled"}, 2_000_000, false},
+		{"secret without limit", config.FTPWatchConfig{Secret: "open"}, 0, false},
+		{"legacy fields", config.FTPWatchConfig{PasswordSecret: "pw"}, 0, false},
+		{"invalid secret value", config.FTPWatchConfig{Secret: "bad"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ftpRateLimit("ftp_dag", &tt.cfg)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ftpRateLimit() = %d, %v; want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShipRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pit.jsonl")
+	sink, err := logship.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{logShip: logship.New(sink)}
+
+	now := time.Now()
+	run := &engine.Run{
+		ID:        "20240115_143022.123_etl",
+		DAGName:   "etl",
+		Status:    engine.StatusFailed,
+		StartedAt: now,
+		EndedAt:   now.Add(3 * time.Second),
+		Tasks: []*engine.TaskInstance{
+			{Name: "extract", Status: engine.StatusSuccess, Attempt: 1, StartedAt: now, EndedAt: now.Add(time.Second)},
+			{Name: "load", Status: engine.StatusFailed, Attempt: 2, StartedAt: now.Add(time.Second), EndedAt: now.Add(3 * time.Second),
+				Failure: &engine.Failure{Class: engine.FailureExitCode, Detail: "exit status 1", Lines: []string{"ValueError: bad row"}}},
+		},
+	}
+	s.shipRun("cron", run, "load: exit_code (exit status 1)")
+	if err := s.logShip.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []logship.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r logship.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3:\n%s", len(records), data)
+	}
+	load := records[1]
+	if load.Event != logship.EventTaskFinished || load.Task != "load" || load.Level != logship.LevelError ||
+		load.Attempts != 2 || load.Duration != 2 || load.Failure != "exit_code (exit status 1)" || len(load.Lines) != 1 {
+		t.Errorf("load record = %+v", load)
+	}
+	end := records[2]
+	if end.Event != logship.EventRunFinished || end.Status != "failed" || end.Trigger != "cron" || end.Message != "load: exit_code (exit status 1)" {
+		t.Errorf("run record = %+v", end)
+	}
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	infraErr := fmt.Errorf("snapshot: %w", engine.ErrInfrastructure)
+	tests := []struct {
+		name      string
+		retries   int
+		errs      []error // returned by successive attempts; nil = success
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "recovers", retries: 3, errs: []error{infraErr, infraErr, nil}, wantCalls: 3},
+		{name: "gives up", retries: 2, errs: []error{infraErr, infraErr, infraErr, nil}, wantCalls: 3, wantErr: engine.ErrInfrastructure},
+		{name: "no retries configured", errs: []error{infraErr, nil}, wantCalls: 1, wantErr: engine.ErrInfrastructure},
+		{name: "dag error is not retried", retries: 3, errs: []error{engine.ErrInvalidDAG, nil}, wantCalls: 1, wantErr: engine.ErrInvalidDAG},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ProjectConfig{DAG: config.DAGConfig{
+				Name:            "daily",
+				InfraRetries:    tt.retries,
+				InfraRetryDelay: config.Duration{Duration: time.Millisecond},
+			}}
+			calls := 0
+			execute := func(ctx context.Context, cfg *config.ProjectConfig, opts engine.ExecuteOpts) (*engine.Run, error) {
+				err := tt.errs[calls]
+				calls++
+				if err != nil {
+					return nil, err
+				}
+				return &engine.Run{ID: opts.RunID, Status: engine.StatusSuccess}, nil
+			}
+			var delays []time.Duration
+			run, err := executeWithRetry(context.Background(), cfg, engine.ExecuteOpts{RunID: "r1"}, execute, func(attempt int, delay time.Duration, err error) {
+				delays = append(delays, delay)
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("execute called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || run != nil {
+					t.Errorf("executeWithRetry() = %v, %v; want nil, %v", run, err, tt.wantErr)
+				}
+			} else if err != nil || run == nil || run.ID != "r1" {
+				t.Errorf("executeWithRetry() = %v, %v; want run r1", run, err)
+			}
+			for i, d := range delays {
+				if want := time.Millisecond << i; d != want {
+					t.Errorf("retry %d delay = %s, want %s", i+1, d, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteWithRetry_Cancelled(t *testing.T) {
+	cfg := &config.ProjectConfig{DAG: config.DAGConfig{Name: "daily", InfraRetries: 5, InfraRetryDelay: config.Duration{Duration: time.Hour}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	execute := func(context.Context, *config.ProjectConfig, engine.ExecuteOpts) (*engine.Run, error) {
+		calls++
+		return nil, fmt.Errorf("loading secrets: %w", engine.ErrInfrastructure)
+	}
+	_, err := executeWithRetry(ctx, cfg, engine.ExecuteOpts{}, execute, func(int, time.Duration, error) { cancel() })
+	if !errors.Is(err, engine.ErrInfrastructure) || calls != 1 {
+		t.Errorf("executeWithRetry() after cancel = %v with %d calls, want the infrastructure error after 1", err, calls)
+	}
+}
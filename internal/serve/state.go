@@ -0,0 +1,217 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+// stateSaveInterval is how often serve state is flushed to disk while running.
+const stateSaveInterval = 30 * time.Second
+
+// serveState is the on-disk snapshot of scheduler state that must survive a restart.
+type serveState struct {
+	SavedAt  time.Time                  `json:"saved_at"`
+	Queued   []queuedEventState         `json:"queued"`
+	Deferred []deferredEventState       `json:"deferred,omitempty"`
+	Triggers map[string]json.RawMessage `json:"triggers"` // keyed by trigger.Name()
+}
+
+// queuedEventState is the persisted form of a queued run.
+type queuedEventState struct {
+	DAGName    string    `json:"dag_name"`
+	Source     string    `json:"source"`
+	Files      []string  `json:"files,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// deferredEventState is the persisted form of a run deferred by a blackout
+// or by max_runs_per_hour.
+type deferredEventState struct {
+	DAGName  string    `json:"dag_name"`
+	Source   string    `json:"source"`
+	Files    []string  `json:"files,omitempty"`
+	Blackout string    `json:"blackout"`
+	Until    time.Time `json:"until"`
+}
+
+// loadState reads a state file. Returns nil, nil if the file does not exist.
+func loadState(path string) (*serveState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %q: %w", path, err)
+	}
+	var st serveState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", path, err)
+	}
+	return &st, nil
+}
+
+// writeState atomically writes a state file via a temp file + rename.
+func writeState(path string, st *serveState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing %q: %w", path, err)
+	}
+	return nil
+}
+
+// snapshotState captures the current queue and trigger state.
+func (s *Server) snapshotState() *serveState {
+	st := &serveState{
+		SavedAt:  time.Now(),
+		Triggers: make(map[string]json.RawMessage),
+	}
+	for _, qr := range s.queue.Snapshot() {
+		st.Queued = append(st.Queued, queuedEventState{
+			DAGName:    qr.Event.DAGName,
+			Source:     qr.Event.Source,
+			Files:      qr.Event.Files,
+			EnqueuedAt: qr.EnqueuedAt,
+		})
+	}
+	for _, d := range s.deferredRuns() {
+		st.Deferred = append(st.Deferred, deferredEventState{
+			DAGName:  d.Event.DAGName,
+			Source:   d.Event.Source,
+			Files:    d.Event.Files,
+			Blackout: d.Blackout,
+			Until:    d.Until,
+		})
+	}
+	for _, t := range s.current().triggers {
+		sf, ok := t.(trigger.Stateful)
+		if !ok {
+			continue
+		}
+		data, err := sf.SaveState()
+		if err != nil {
+			log.Printf("warning: saving state for %s: %v", t.Name(), err)
+			continue
+		}
+		st.Triggers[t.Name()] = data
+	}
+	return st
+}
+
+// saveState writes the current state to the configured state file (no-op if unset).
+func (s *Server) saveState() {
+	if s.statePath == "" {
+		return
+	}
+	if err := writeState(s.statePath, s.snapshotState()); err != nil {
+		log.Printf("warning: saving serve state: %v", err)
+	}
+}
+
+// queuePending moves events still buffered in eventCh into the run queue so
+// the next saveState persists them. Call only once the event loop and the
+// triggers have stopped; the queued runs are not dispatched.
+func (s *Server) queuePending() {
+	n := 0
+	for {
+		select {
+		case ev := <-s.eventCh:
+			cfg := s.dagConfig(ev.DAGName)
+			if cfg == nil {
+				continue
+			}
+			s.mu.Lock()
+			s.activeRuns[ev.DAGName] = true
+			s.queue.Push(&queuedRun{Event: ev, Priority: cfg.DAG.Priority})
+			s.mu.Unlock()
+			n++
+		default:
+			if n > 0 {
+				log.Printf("pit serve: queued %d pending trigger event(s) for the next start", n)
+			}
+			return
+		}
+	}
+}
+
+// restoreState loads the state file and hands trigger state back to triggers
+// and queued events back to the run queue. Must be called before triggers start.
+func (s *Server) restoreState() {
+	if s.statePath == "" {
+		return
+	}
+	st, err := loadState(s.statePath)
+	if err != nil {
+		log.Printf("warning: ignoring serve state: %v", err)
+		return
+	}
+	if st == nil {
+		return
+	}
+
+	for _, t := range s.current().triggers {
+		sf, ok := t.(trigger.Stateful)
+		if !ok {
+			continue
+		}
+		data, ok := st.Triggers[t.Name()]
+		if !ok {
+			continue
+		}
+		if err := sf.RestoreState(data); err != nil {
+			log.Printf("warning: restoring state for %s: %v", t.Name(), err)
+		}
+	}
+
+	for _, q := range st.Queued {
+		cfg := s.dagConfig(q.DAGName)
+		if cfg == nil {
+			log.Printf("dropping queued run for unknown DAG %q", q.DAGName)
+			continue
+		}
+		s.mu.Lock()
+		s.activeRuns[q.DAGName] = true
+		s.queue.Push(&queuedRun{
+			Event:      trigger.Event{DAGName: q.DAGName, Source: q.Source, Files: q.Files},
+			Priority:   cfg.DAG.Priority,
+			EnqueuedAt: q.EnqueuedAt,
+		})
+		s.mu.Unlock()
+	}
+	if len(st.Queued) > 0 {
+		log.Printf("pit serve: restored %d queued run(s) from %s", len(st.Queued), s.statePath)
+	}
+
+	// Deferred runs whose blackout ended while the server was down are
+	// released straight away by their timers
+	for _, d := range st.Deferred {
+		if s.dagConfig(d.DAGName) == nil {
+			log.Printf("dropping deferred run for unknown DAG %q", d.DAGName)
+			continue
+		}
+		s.deferRun(&deferredRun{
+			Event:    trigger.Event{DAGName: d.DAGName, Source: d.Source, Files: d.Files},
+			Blackout: d.Blackout,
+			Until:    d.Until,
+		})
+	}
+	if len(st.Deferred) > 0 {
+		log.Printf("pit serve: restored %d deferred run(s) from %s", len(st.Deferred), s.statePath)
+	}
+}
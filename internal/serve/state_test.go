@@ -0,0 +1,118 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/trigger"
+)
+
+func TestLoadState_Missing(t *testing.T) {
+	st, err := loadState(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil {
+		t.Fatalf("loadState() unexpected error: %v", err)
+	}
+	if st != nil {
+		t.Errorf("loadState() = %+v, want nil for missing file", st)
+	}
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	os.WriteFile(path, []byte("{not json"), 0o644)
+	if _, err := loadState(path); err == nil {
+		t.Error("loadState() expected error for invalid JSON, got nil")
+	}
+}
+
+func TestServeState_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "serve.json")
+	cfgs := map[string]*config.ProjectConfig{
+		"nightly": {DAG: config.DAGConfig{Name: "nightly", Schedule: config.Schedules{"0 6 * * *"}, Priority: 3}},
+		"ingest":  {DAG: config.DAGConfig{Name: "ingest"}},
+	}
+
+	ct, err := trigger.NewCronTrigger("nightly", "0 6 * * *", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fired := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	if err := ct.RestoreState([]byte(`{"last_fired":"` + fired.Format(time.RFC3339) + `"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		configs:    cfgs,
+		triggers:   []trigger.Trigger{ct},
+		queue:      newRunQueue(),
+		statePath:  path,
+		activeRuns: make(map[string]bool),
+	}
+	s.handleEvent(trigger.Event{DAGName: "ingest", Source: "ftp_watch", Files: []string{"a.csv"}})
+	s.handleEvent(trigger.Event{DAGName: "nightly", Source: "cron"})
+	s.saveState()
+
+	// Fresh server restores from the same file
+	ct2, _ := trigger.NewCronTrigger("nightly", "0 6 * * *", 0)
+	s2 := &Server{
+		configs:    cfgs,
+		triggers:   []trigger.Trigger{ct2},
+		queue:      newRunQueue(),
+		statePath:  path,
+		activeRuns: make(map[string]bool),
+	}
+	s2.restoreState()
+
+	if !ct2.LastFired().Equal(fired) {
+		t.Errorf("restored LastFired = %v, want %v", ct2.LastFired(), fired)
+	}
+	if s2.queue.Len() != 2 {
+		t.Fatalf("restored queue.Len() = %d, want 2", s2.queue.Len())
+	}
+	first := s2.queue.TryPop()
+	if first.Event.DAGName != "nightly" || first.Priority != 3 {
+		t.Errorf("first restored run = %+v, want nightly with priority 3", first)
+	}
+	second := s2.queue.TryPop()
+	if second.Event.Source != "ftp_watch" || len(second.Event.Files) != 1 || second.Event.Files[0] != "a.csv" {
+		t.Errorf("second restored run = %+v, want ftp_watch event with a.csv", second)
+	}
+	if !s2.activeRuns["ingest"] {
+		t.Error("restored queued DAG should be marked active")
+	}
+}
+
+func TestQueuePending_SavesUnhandledEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serve.json")
+	s := &Server{
+		configs: map[string]*config.ProjectConfig{
+			"ingest": {DAG: config.DAGConfig{Name: "ingest", Priority: 2}},
+		},
+		queue:      newRunQueue(),
+		statePath:  path,
+		activeRuns: make(map[string]bool),
+		eventCh:    make(chan trigger.Event, 4),
+	}
+	s.eventCh <- trigger.Event{DAGName: "ingest", Source: "ftp_watch", Files: []string{"a.csv"}}
+	s.eventCh <- trigger.Event{DAGName: "retired", Source: "cron"}
+
+	s.queuePending()
+	s.saveState()
+
+	st, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState() error: %v", err)
+	}
+	if len(st.Queued) != 1 {
+		t.Fatalf("queued = %+v, want the ingest event only", st.Queued)
+	}
+	if q := st.Queued[0]; q.DAGName != "ingest" || len(q.Files) != 1 || q.Files[0] != "a.csv" {
+		t.Errorf("queued[0] = %+v, want ingest with a.csv", q)
+	}
+	if len(s.eventCh) != 0 {
+		t.Errorf("eventCh still holds %d event(s)", len(s.eventCh))
+	}
+}
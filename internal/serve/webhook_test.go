@@ -3,8 +3,11 @@ package serve
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/druarnfield/pit/internal/loghub"
 	"github.com/druarnfield/pit/internal/trigger"
 )
 
@@ -131,3 +134,40 @@ func TestWebhookHandler_EmptyDAGName(t *testing.T) {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
+
+func TestWebhookStream_QueuesRun(t *testing.T) {
+	s := newControlServer("")
+	s.logHub = loghub.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/test?stream=true", nil)
+	req.Header.Set("Authorization", "Bearer hooksecret")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.webhookHandler(w, req)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.queue.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("streaming webhook run was not queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	queued := s.queue.Snapshot()[0]
+	if queued.RunID == "" || queued.Event.Source != "webhook" {
+		t.Fatalf("queued run = %+v, want a webhook run with a pre-assigned run ID", queued)
+	}
+
+	// Clearing the queued run ends the stream
+	s.clearQueued("test")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after its queued run was cleared")
+	}
+	if !strings.Contains(w.Body.String(), `"status":"cancelled"`) {
+		t.Errorf("body = %q, want a cancelled completion event", w.Body.String())
+	}
+}
@@ -0,0 +1,77 @@
+// Package service registers pit serve as a long-running system service: a
+// Windows service managed by the Service Control Manager on Windows, and a
+// systemd unit everywhere else.
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultName is the service name used when none is given.
+const DefaultName = "pit"
+
+// Config describes the service to install.
+type Config struct {
+	Name        string
+	Description string
+	Executable  string   // absolute path to the pit binary
+	Args        []string // arguments passed to the executable, e.g. serve --project-dir /srv/pit
+	WorkDir     string   // working directory (systemd only; Windows services chdir at startup)
+	User        string   // account to run as (systemd only; empty = root)
+}
+
+// stopTimeoutSec is how long the service manager waits for in-flight runs to
+// wind down after a stop request before killing the process.
+const stopTimeoutSec = 300
+
+// SystemdUnit renders a systemd unit file for cfg. The unit restarts pit on
+// failure, sends SIGTERM to the main process only so pit can cancel its own
+// task subprocesses, and waits up to five minutes before escalating to SIGKILL.
+func SystemdUnit(cfg Config) string {
+	desc := cfg.Description
+	if desc == "" {
+		desc = "Pit data pipeline scheduler"
+	}
+
+	exec := make([]string, 0, len(cfg.Args)+1)
+	exec = append(exec, systemdQuote(cfg.Executable))
+	for _, a := range cfg.Args {
+		exec = append(exec, systemdQuote(a))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", desc)
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(exec, " "))
+	if cfg.WorkDir != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(cfg.WorkDir))
+	}
+	if cfg.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", cfg.User)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5s\n")
+	b.WriteString("KillSignal=SIGTERM\n")
+	b.WriteString("KillMode=mixed\n")
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", stopTimeoutSec)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes a value for a unit file when it contains whitespace or
+// quotes, and escapes % so systemd does not treat it as a specifier.
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
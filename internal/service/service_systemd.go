@@ -0,0 +1,76 @@
+//go:build !windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// UnitDir is where systemd unit files are installed.
+var UnitDir = "/etc/systemd/system"
+
+// Install writes a systemd unit for cfg, reloads systemd, and enables the
+// unit so it starts on boot. It does not start the service.
+func Install(cfg Config) error {
+	path := unitPath(cfg.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("service %q already installed at %s", cfg.Name, path)
+	}
+	if err := os.WriteFile(path, []byte(SystemdUnit(cfg)), 0o644); err != nil {
+		return fmt.Errorf("writing unit file: %w", err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", cfg.Name)
+}
+
+// Uninstall stops and disables the unit, removes its file, and reloads systemd.
+func Uninstall(name string) error {
+	path := unitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %q is not installed (%s not found)", name, path)
+	}
+	if err := systemctl("disable", "--now", name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing unit file: %w", err)
+	}
+	return systemctl("daemon-reload")
+}
+
+// StartHint returns the command an operator runs to start the installed service.
+func StartHint(name string) string {
+	return "systemctl start " + name
+}
+
+// IsService reports whether the process was started by the Windows Service
+// Control Manager. Always false outside Windows: systemd runs pit as a plain
+// foreground process and stops it with SIGTERM.
+func IsService() bool {
+	return false
+}
+
+// Run calls fn with ctx. Under systemd, shutdown arrives as SIGTERM, which the
+// caller already maps onto ctx.
+func Run(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+func unitPath(name string) string {
+	return filepath.Join(UnitDir, name+".service")
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
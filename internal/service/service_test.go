@@ -0,0 +1,58 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit(Config{
+		Name:       "pit",
+		Executable: "/usr/local/bin/pit",
+		Args:       []string{"serve", "--project-dir", "/srv/pit workspace", "--port", "9090"},
+		WorkDir:    "/srv/pit workspace",
+		User:       "pit",
+	})
+
+	for _, want := range []string{
+		"Description=Pit data pipeline scheduler\n",
+		`ExecStart=/usr/local/bin/pit serve --project-dir "/srv/pit workspace" --port 9090` + "\n",
+		`WorkingDirectory="/srv/pit workspace"` + "\n",
+		"User=pit\n",
+		"Restart=on-failure\n",
+		"KillMode=mixed\n",
+		"TimeoutStopSec=300\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdUnit_NoUser(t *testing.T) {
+	unit := SystemdUnit(Config{Name: "pit", Executable: "/usr/local/bin/pit", Args: []string{"serve"}})
+	if strings.Contains(unit, "User=") {
+		t.Errorf("unit should not set User= when none given:\n%s", unit)
+	}
+	if strings.Contains(unit, "WorkingDirectory=") {
+		t.Errorf("unit should not set WorkingDirectory= when none given:\n%s", unit)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"/path/with space", `"/path/with space"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"100%", "100%%"},
+		{"", `""`},
+	}
+	for _, tt := range tests {
+		if got := systemdQuote(tt.in); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
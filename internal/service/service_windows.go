@@ -0,0 +1,139 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Install registers cfg with the Service Control Manager as an automatic-start
+// service that restarts on failure. It does not start the service.
+func Install(cfg Config) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(cfg.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %q already installed", cfg.Name)
+	}
+
+	desc := cfg.Description
+	if desc == "" {
+		desc = "Pit data pipeline scheduler"
+	}
+	s, err := m.CreateService(cfg.Name, cfg.Executable, mgr.Config{
+		DisplayName: cfg.Name,
+		Description: desc,
+		StartType:   mgr.StartAutomatic,
+	}, cfg.Args...)
+	if err != nil {
+		return fmt.Errorf("creating service: %w", err)
+	}
+	defer s.Close()
+
+	// Restart after 5s on the first two failures, then after a minute;
+	// the failure count resets after a day without failures.
+	recovery := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}
+	if err := s.SetRecoveryActions(recovery, uint32((24 * time.Hour).Seconds())); err != nil {
+		s.Delete()
+		return fmt.Errorf("setting recovery actions: %w", err)
+	}
+	return nil
+}
+
+// Uninstall stops the service if it is running and removes it.
+func Uninstall(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %q is not installed", name)
+	}
+	defer s.Close()
+
+	// Ignore the error: the service may already be stopped.
+	s.Control(svc.Stop)
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("deleting service: %w", err)
+	}
+	return nil
+}
+
+// StartHint returns the command an operator runs to start the installed service.
+func StartHint(name string) string {
+	return "sc.exe start " + name
+}
+
+// IsService reports whether the process was started by the Service Control Manager.
+func IsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// Run calls fn with ctx. When started by the Service Control Manager, fn runs
+// under a service handler and ctx is also cancelled on a Stop or Shutdown
+// request, so pit serve drains exactly as it does on Ctrl+C.
+func Run(ctx context.Context, fn func(context.Context) error) error {
+	if !IsService() {
+		return fn(ctx)
+	}
+	h := &handler{ctx: ctx, fn: fn}
+	// The name is ignored for services running in their own process.
+	if err := svc.Run(DefaultName, h); err != nil {
+		return fmt.Errorf("running service: %w", err)
+	}
+	return h.err
+}
+
+type handler struct {
+	ctx context.Context
+	fn  func(context.Context) error
+	err error
+}
+
+func (h *handler) Execute(args []string, r <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.fn(ctx) }()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-done:
+			h.err = err
+			if err != nil {
+				// A service-specific exit code marks the stop as a failure,
+				// which triggers the recovery actions set at install time.
+				return true, 1
+			}
+			return false, 0
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				status <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: stopTimeoutSec * 1000}
+				cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package trigger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+// Calendar decides whether scheduled triggers may fire at a given time,
+// based on the workspace [calendar] holidays and blackout windows.
+type Calendar struct {
+	holidays     map[string]bool // "2006-01-02" in local time
+	holidayDefer bool
+	windows      []blackoutWindow
+}
+
+type blackoutWindow struct {
+	name      string
+	start     time.Time // fixed span [start, end); zero for monthly windows
+	end       time.Time
+	monthDays []int
+	deferRuns bool
+}
+
+// Blackout describes the holiday or window in effect at a point in time.
+type Blackout struct {
+	Name  string
+	Defer bool      // hold the run until End instead of dropping it
+	End   time.Time // when the blackout lifts
+}
+
+// dateTimeLayouts are the accepted forms for blackout start/end.
+var dateTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+// NewCalendar parses and validates a calendar config. Times are interpreted
+// in the server's local time zone.
+func NewCalendar(cfg *config.CalendarConfig) (*Calendar, error) {
+	holidayDefer, err := parseAction(cfg.HolidayAction)
+	if err != nil {
+		return nil, fmt.Errorf("calendar holiday_action: %w", err)
+	}
+	c := &Calendar{holidays: make(map[string]bool), holidayDefer: holidayDefer}
+
+	for _, h := range cfg.Holidays {
+		d, err := time.ParseInLocation("2006-01-02", h, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("calendar holiday %q: expected YYYY-MM-DD", h)
+		}
+		c.holidays[d.Format("2006-01-02")] = true
+	}
+
+	for i, b := range cfg.Blackouts {
+		name := b.Name
+		if name == "" {
+			name = fmt.Sprintf("blackout #%d", i+1)
+		}
+		w := blackoutWindow{name: name}
+		if w.deferRuns, err = parseAction(b.Action); err != nil {
+			return nil, fmt.Errorf("calendar %s: action: %w", name, err)
+		}
+
+		fixed := b.Start != "" || b.End != ""
+		switch {
+		case fixed && len(b.MonthDays) > 0:
+			return nil, fmt.Errorf("calendar %s: set either start/end or month_days, not both", name)
+		case fixed:
+			if b.Start == "" || b.End == "" {
+				return nil, fmt.Errorf("calendar %s: start and end are both required", name)
+			}
+			if w.start, _, err = parseBound(b.Start); err != nil {
+				return nil, fmt.Errorf("calendar %s: start: %w", name, err)
+			}
+			end, dateOnly, err := parseBound(b.End)
+			if err != nil {
+				return nil, fmt.Errorf("calendar %s: end: %w", name, err)
+			}
+			if dateOnly {
+				end = end.AddDate(0, 0, 1)
+			}
+			w.end = end
+			if !w.end.After(w.start) {
+				return nil, fmt.Errorf("calendar %s: end must be after start", name)
+			}
+		case len(b.MonthDays) > 0:
+			for _, d := range b.MonthDays {
+				if d == 0 || d > 31 || d < -31 {
+					return nil, fmt.Errorf("calendar %s: invalid month_days entry %d (use 1..31 or -1..-31)", name, d)
+				}
+			}
+			w.monthDays = b.MonthDays
+		default:
+			return nil, fmt.Errorf("calendar %s: set start/end or month_days", name)
+		}
+		c.windows = append(c.windows, w)
+	}
+
+	return c, nil
+}
+
+// Check returns the blackout in effect at t, or nil if scheduled runs may fire.
+// Holidays are checked first, then windows in config order.
+func (c *Calendar) Check(t time.Time) *Blackout {
+	t = t.In(time.Local)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	nextDay := day.AddDate(0, 0, 1)
+
+	if c.holidays[day.Format("2006-01-02")] {
+		return &Blackout{Name: "holiday " + day.Format("2006-01-02"), Defer: c.holidayDefer, End: nextDay}
+	}
+
+	for _, w := range c.windows {
+		if w.monthDays != nil {
+			if matchesMonthDay(day, w.monthDays) {
+				return &Blackout{Name: w.name, Defer: w.deferRuns, End: nextDay}
+			}
+			continue
+		}
+		if !t.Before(w.start) && t.Before(w.end) {
+			return &Blackout{Name: w.name, Defer: w.deferRuns, End: w.end}
+		}
+	}
+	return nil
+}
+
+// matchesMonthDay reports whether day is one of days, where negative values
+// count back from the end of the month (-1 = last day).
+func matchesMonthDay(day time.Time, days []int) bool {
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.Local).Day()
+	for _, d := range days {
+		if d > 0 && day.Day() == d {
+			return true
+		}
+		if d < 0 && day.Day() == last+d+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBound parses a blackout start/end, reporting whether it was date-only.
+func parseBound(s string) (time.Time, bool, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, layout == "2006-01-02", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q (expected YYYY-MM-DD or YYYY-MM-DD HH:MM)", s)
+}
+
+// parseAction maps a calendar action to whether runs are deferred.
+func parseAction(action string) (bool, error) {
+	switch action {
+	case "", "skip":
+		return false, nil
+	case "defer":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid action %q (must be skip or defer)", action)
+	}
+}
@@ -0,0 +1,92 @@
+package trigger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+)
+
+func at(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCalendar_Check(t *testing.T) {
+	cal, err := NewCalendar(&config.CalendarConfig{
+		Holidays: []string{"2026-12-25"},
+		Blackouts: []config.BlackoutWindow{
+			{Name: "month-end", MonthDays: []int{-1}, Action: "defer"},
+			{Name: "migration", Start: "2026-04-01 18:00", End: "2026-04-02"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCalendar() error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantName  string // "" = not blacked out
+		wantDefer bool
+		wantEnd   time.Time
+	}{
+		{"ordinary day", at("2026-03-10 06:00"), "", false, time.Time{}},
+		{"holiday", at("2026-12-25 06:00"), "holiday 2026-12-25", false, at("2026-12-26 00:00")},
+		{"last day of 30-day month", at("2026-04-30 23:59"), "month-end", true, at("2026-05-01 00:00")},
+		{"last day of february", at("2026-02-28 01:00"), "month-end", true, at("2026-03-01 00:00")},
+		{"day before month end", at("2026-04-29 12:00"), "", false, time.Time{}},
+		{"before fixed window", at("2026-04-01 17:59"), "", false, time.Time{}},
+		{"start of fixed window", at("2026-04-01 18:00"), "migration", false, at("2026-04-03 00:00")},
+		{"date-only end covers whole day", at("2026-04-02 23:00"), "migration", false, at("2026-04-03 00:00")},
+		{"after fixed window", at("2026-04-03 00:00"), "", false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := cal.Check(tt.at)
+			if tt.wantName == "" {
+				if b != nil {
+					t.Errorf("Check() = %+v, want nil", b)
+				}
+				return
+			}
+			if b == nil {
+				t.Fatalf("Check() = nil, want blackout %q", tt.wantName)
+			}
+			if b.Name != tt.wantName || b.Defer != tt.wantDefer || !b.End.Equal(tt.wantEnd) {
+				t.Errorf("Check() = %+v, want name %q defer %v end %s", b, tt.wantName, tt.wantDefer, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNewCalendar_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.CalendarConfig
+		wantErr string
+	}{
+		{"bad holiday", config.CalendarConfig{Holidays: []string{"25/12/2026"}}, "expected YYYY-MM-DD"},
+		{"bad holiday action", config.CalendarConfig{HolidayAction: "pause"}, "invalid action"},
+		{"empty window", config.CalendarConfig{Blackouts: []config.BlackoutWindow{{Name: "x"}}}, "set start/end or month_days"},
+		{"both forms", config.CalendarConfig{Blackouts: []config.BlackoutWindow{{Start: "2026-01-01", End: "2026-01-02", MonthDays: []int{1}}}}, "not both"},
+		{"missing end", config.CalendarConfig{Blackouts: []config.BlackoutWindow{{Start: "2026-01-01"}}}, "both required"},
+		{"end before start", config.CalendarConfig{Blackouts: []config.BlackoutWindow{{Start: "2026-01-02 10:00", End: "2026-01-02 09:00"}}}, "end must be after start"},
+		{"bad month day", config.CalendarConfig{Blackouts: []config.BlackoutWindow{{MonthDays: []int{0}}}}, "invalid month_days"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalendar(&tt.cfg)
+			if err == nil {
+				t.Fatalf("NewCalendar() = nil error, want %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewCalendar() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
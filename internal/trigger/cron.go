@@ -2,7 +2,12 @@ package trigger
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
@@ -11,15 +16,47 @@ import (
 type CronTrigger struct {
 	dagName  string
 	schedule string
+	delay    time.Duration // stable per-DAG offset within schedule_jitter
+
+	mu        sync.Mutex
+	lastFired time.Time
+}
+
+// cronState is the persisted form of a CronTrigger's state.
+type cronState struct {
+	LastFired time.Time `json:"last_fired"`
 }
 
 // NewCronTrigger creates a trigger that fires on the given cron schedule.
+// A non-zero jitter delays every fire by the same pseudo-random offset in
+// [0, jitter), derived from the DAG name, so DAGs sharing a schedule spread
+// out while each one keeps a predictable fire time.
 // Returns an error if the schedule expression is invalid.
-func NewCronTrigger(dagName, schedule string) (*CronTrigger, error) {
+func NewCronTrigger(dagName, schedule string, jitter time.Duration) (*CronTrigger, error) {
 	if _, err := cron.ParseStandard(schedule); err != nil {
 		return nil, fmt.Errorf("invalid cron schedule %q: %w", schedule, err)
 	}
-	return &CronTrigger{dagName: dagName, schedule: schedule}, nil
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid schedule jitter %s (must be >= 0)", jitter)
+	}
+	return &CronTrigger{dagName: dagName, schedule: schedule, delay: jitterOffset(dagName, jitter)}, nil
+}
+
+// jitterOffset returns a stable offset in [0, jitter) for dagName, rounded
+// down to whole seconds.
+func jitterOffset(dagName string, jitter time.Duration) time.Duration {
+	if jitter < time.Second {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(dagName))
+	secs := uint64(jitter / time.Second)
+	return time.Duration(h.Sum64()%secs) * time.Second
+}
+
+// Delay returns the fixed offset applied to every scheduled fire.
+func (ct *CronTrigger) Delay() time.Duration {
+	return ct.delay
 }
 
 // Name returns a human-readable identifier for this trigger.
@@ -32,7 +69,23 @@ func (ct *CronTrigger) Name() string {
 func (ct *CronTrigger) Start(ctx context.Context, events chan<- Event) error {
 	c := cron.New()
 
+	ct.warnMissed(time.Now())
+
+	if ct.delay > 0 {
+		log.Printf("[cron] %s: fires %s after each scheduled time (schedule_jitter)", ct.dagName, ct.delay)
+	}
+
 	_, err := c.AddFunc(ct.schedule, func() {
+		if ct.delay > 0 {
+			select {
+			case <-time.After(ct.delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+		ct.mu.Lock()
+		ct.lastFired = time.Now()
+		ct.mu.Unlock()
 		select {
 		case events <- Event{
 			DAGName: ct.dagName,
@@ -50,3 +103,44 @@ func (ct *CronTrigger) Start(ctx context.Context, events chan<- Event) error {
 	c.Stop()
 	return nil
 }
+
+// LastFired returns the time the trigger last fired (zero if never).
+func (ct *CronTrigger) LastFired() time.Time {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.lastFired
+}
+
+// SaveState implements Stateful.
+func (ct *CronTrigger) SaveState() (json.RawMessage, error) {
+	return json.Marshal(cronState{LastFired: ct.LastFired()})
+}
+
+// RestoreState implements Stateful.
+func (ct *CronTrigger) RestoreState(data json.RawMessage) error {
+	var st cronState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("decoding cron state: %w", err)
+	}
+	ct.mu.Lock()
+	ct.lastFired = st.LastFired
+	ct.mu.Unlock()
+	return nil
+}
+
+// warnMissed logs when a scheduled fire was due between the last recorded
+// fire and now — i.e. the server was down over a scheduled run.
+func (ct *CronTrigger) warnMissed(now time.Time) {
+	last := ct.LastFired()
+	if last.IsZero() {
+		return
+	}
+	sched, err := cron.ParseStandard(ct.schedule)
+	if err != nil {
+		return
+	}
+	if next := sched.Next(last); next.Before(now) {
+		log.Printf("[cron] %s: missed scheduled run at %s (last fired %s)",
+			ct.dagName, next.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+}
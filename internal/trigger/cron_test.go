@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewCronTrigger_InvalidSchedule(t *testing.T) {
-	_, err := NewCronTrigger("test", "not a schedule")
+	_, err := NewCronTrigger("test", "not a schedule", 0)
 	if err == nil {
 		t.Error("NewCronTrigger() expected error for invalid schedule, got nil")
 	}
@@ -26,7 +26,7 @@ func TestNewCronTrigger_ValidSchedules(t *testing.T) {
 	}
 	for _, s := range schedules {
 		t.Run(s, func(t *testing.T) {
-			ct, err := NewCronTrigger("test", s)
+			ct, err := NewCronTrigger("test", s, 0)
 			if err != nil {
 				t.Fatalf("NewCronTrigger(%q) error: %v", s, err)
 			}
@@ -38,7 +38,7 @@ func TestNewCronTrigger_ValidSchedules(t *testing.T) {
 }
 
 func TestCronTrigger_Name(t *testing.T) {
-	ct, err := NewCronTrigger("my_dag", "0 6 * * *")
+	ct, err := NewCronTrigger("my_dag", "0 6 * * *", 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,7 +49,7 @@ func TestCronTrigger_Name(t *testing.T) {
 }
 
 func TestCronTrigger_Start_Delivers(t *testing.T) {
-	ct, err := NewCronTrigger("test_dag", "@every 100ms")
+	ct, err := NewCronTrigger("test_dag", "@every 100ms", 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -77,7 +77,7 @@ func TestCronTrigger_Start_Delivers(t *testing.T) {
 }
 
 func TestCronTrigger_Start_CancelStops(t *testing.T) {
-	ct, err := NewCronTrigger("test_dag", "@every 100ms")
+	ct, err := NewCronTrigger("test_dag", "@every 100ms", 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,3 +106,94 @@ func TestCronTrigger_Start_CancelStops(t *testing.T) {
 		t.Fatal("Start() did not return after cancel")
 	}
 }
+
+func TestCronTrigger_StateRoundTrip(t *testing.T) {
+	ct, err := NewCronTrigger("my_dag", "0 6 * * *", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fired := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	if err := ct.RestoreState([]byte(`{"last_fired":"2026-03-01T06:00:00Z"}`)); err != nil {
+		t.Fatalf("RestoreState() error: %v", err)
+	}
+	if !ct.LastFired().Equal(fired) {
+		t.Errorf("LastFired() = %v, want %v", ct.LastFired(), fired)
+	}
+
+	data, err := ct.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState() error: %v", err)
+	}
+	ct2, _ := NewCronTrigger("my_dag", "0 6 * * *", 0)
+	if err := ct2.RestoreState(data); err != nil {
+		t.Fatalf("RestoreState() error: %v", err)
+	}
+	if !ct2.LastFired().Equal(fired) {
+		t.Errorf("round-tripped LastFired() = %v, want %v", ct2.LastFired(), fired)
+	}
+}
+
+func TestCronTrigger_RestoreStateInvalid(t *testing.T) {
+	ct, _ := NewCronTrigger("my_dag", "0 6 * * *", 0)
+	if err := ct.RestoreState([]byte("garbage")); err == nil {
+		t.Error("RestoreState() expected error for invalid JSON, got nil")
+	}
+}
+
+func TestNewCronTrigger_NegativeJitter(t *testing.T) {
+	if _, err := NewCronTrigger("test", "0 6 * * *", -time.Minute); err == nil {
+		t.Error("NewCronTrigger() expected error for negative jitter, got nil")
+	}
+}
+
+func TestJitterOffset(t *testing.T) {
+	jitter := 10 * time.Minute
+
+	a1 := jitterOffset("daily_claims", jitter)
+	a2 := jitterOffset("daily_claims", jitter)
+	if a1 != a2 {
+		t.Errorf("jitterOffset not stable: %s then %s", a1, a2)
+	}
+
+	seen := make(map[time.Duration]bool)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		off := jitterOffset(name, jitter)
+		if off < 0 || off >= jitter {
+			t.Errorf("jitterOffset(%q) = %s, want within [0, %s)", name, off, jitter)
+		}
+		if off%time.Second != 0 {
+			t.Errorf("jitterOffset(%q) = %s, want whole seconds", name, off)
+		}
+		seen[off] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jitterOffset gave every DAG the same offset")
+	}
+
+	if off := jitterOffset("x", 0); off != 0 {
+		t.Errorf("jitterOffset with zero jitter = %s, want 0", off)
+	}
+}
+
+func TestCronTrigger_JitterDelaysFire(t *testing.T) {
+	ct, err := NewCronTrigger("test_dag", "@every 100ms", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct.delay = 300 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events := make(chan Event, 10)
+	start := time.Now()
+	go ct.Start(ctx, events)
+
+	select {
+	case <-events:
+		if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+			t.Errorf("first event after %s, want >= 400ms (100ms schedule + 300ms delay)", elapsed)
+		}
+	case <-ctx.Done():
+		t.Fatal("no event received")
+	}
+}
@@ -2,12 +2,16 @@ package trigger
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/druarnfield/pit/internal/config"
 	pitftp "github.com/druarnfield/pit/internal/ftp"
+	"github.com/druarnfield/pit/internal/remotepath"
 )
 
 // SecretsResolver resolves secrets by project scope.
@@ -16,10 +20,12 @@ type SecretsResolver interface {
 	ResolveField(project, secret, field string) (string, error)
 }
 
-// fileState tracks a file's stability during polling.
+// fileState tracks a file's stability during polling. A stable file stays
+// tracked, with StableAt set, until its batch is sent.
 type fileState struct {
-	Size      int64
-	FirstSeen time.Time
+	Size      int64     `json:"size"`
+	FirstSeen time.Time `json:"first_seen"`
+	StableAt  time.Time `json:"stable_at,omitzero"`
 }
 
 // FTPWatchTrigger polls an FTP server for stable files matching a pattern.
@@ -27,6 +33,10 @@ type FTPWatchTrigger struct {
 	dagName string
 	cfg     *config.FTPWatchConfig
 	secrets SecretsResolver
+
+	mu       sync.Mutex           // guards tracking and health; never held during FTP I/O
+	tracking map[string]fileState // filename → stability state
+	health   Health
 }
 
 // NewFTPWatchTrigger creates an FTP watch trigger.
@@ -34,7 +44,13 @@ func NewFTPWatchTrigger(dagName string, cfg *config.FTPWatchConfig, secrets Secr
 	if secrets == nil {
 		return nil, fmt.Errorf("secrets store required for FTP watch")
 	}
-	return &FTPWatchTrigger{dagName: dagName, cfg: cfg, secrets: secrets}, nil
+	return &FTPWatchTrigger{
+		dagName:  dagName,
+		cfg:      cfg,
+		secrets:  secrets,
+		tracking: make(map[string]fileState),
+		health:   Health{DAGName: dagName, Source: "ftp_watch"},
+	}, nil
 }
 
 // Name returns a human-readable identifier for this trigger.
@@ -43,78 +59,178 @@ func (ft *FTPWatchTrigger) Name() string {
 		ft.cfg.Host, ft.cfg.Port, ft.cfg.Directory, ft.cfg.Pattern, ft.dagName)
 }
 
-// Start begins the poll loop and sends events when stable files are found.
+// Start begins the poll loop and sends events when stable files are found,
+// batched by batch_window and max_batch_size.
+// While polls fail, the delay between them doubles up to max_backoff.
 // Blocks until the context is cancelled.
 func (ft *FTPWatchTrigger) Start(ctx context.Context, events chan<- Event) error {
-	ticker := time.NewTicker(ft.cfg.PollInterval.Duration)
-	defer ticker.Stop()
-
-	tracking := make(map[string]fileState)
+	timer := time.NewTimer(ft.cfg.PollInterval.Duration)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-ticker.C:
-			ft.poll(ctx, events, tracking)
+		case <-timer.C:
+			err := ft.poll(ctx, events)
+			timer.Reset(ft.recordPoll(err, time.Now()))
 		}
 	}
 }
 
-// resolveFTPCredentials resolves host, user, and password for the FTP connection.
-// When cfg.Secret is set, all three are pulled from a structured secret.
+// Health implements HealthReporter.
+func (ft *FTPWatchTrigger) Health() Health {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return ft.health
+}
+
+// recordPoll updates the trigger's health with the result of a poll and
+// returns the delay before the next one.
+func (ft *FTPWatchTrigger) recordPoll(err error, now time.Time) time.Duration {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	h := &ft.health
+
+	if err == nil {
+		if h.Failures > 0 {
+			log.Printf("[ftp_watch] %s: recovered after %d failed poll(s), degraded since %s",
+				ft.dagName, h.Failures, h.DegradedSince.Format(time.RFC3339))
+		}
+		h.Failures = 0
+		h.DegradedSince = time.Time{}
+		h.LastError = ""
+		h.NextPoll = now.Add(ft.cfg.PollInterval.Duration)
+		return ft.cfg.PollInterval.Duration
+	}
+
+	if h.Failures == 0 {
+		h.DegradedSince = now
+	}
+	h.Failures++
+	h.LastError = err.Error()
+	delay := backoff(ft.cfg.PollInterval.Duration, ft.cfg.MaxBackoff.Duration, h.Failures)
+	h.NextPoll = now.Add(delay)
+	log.Printf("[ftp_watch] %s: %v (degraded since %s, retrying in %s)",
+		ft.dagName, err, h.DegradedSince.Format(time.RFC3339), delay)
+	return delay
+}
+
+// SaveState implements Stateful, persisting the file stability timers.
+func (ft *FTPWatchTrigger) SaveState() (json.RawMessage, error) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return json.Marshal(ft.tracking)
+}
+
+// RestoreState implements Stateful.
+func (ft *FTPWatchTrigger) RestoreState(data json.RawMessage) error {
+	tracking := make(map[string]fileState)
+	if err := json.Unmarshal(data, &tracking); err != nil {
+		return fmt.Errorf("decoding ftp_watch state: %w", err)
+	}
+	ft.mu.Lock()
+	ft.tracking = tracking
+	ft.mu.Unlock()
+	return nil
+}
+
+// FTPConnectOptions resolves how to reach the server of an ftp_watch.
+// When cfg.Secret is set, the connection comes from the structured secret,
+// with the watch's own protocol, port, and tls taking precedence.
 // Otherwise falls back to legacy cfg.Host / cfg.User / cfg.PasswordSecret fields.
-func (ft *FTPWatchTrigger) resolveFTPCredentials() (host, user, password string, err error) {
-	if ft.cfg.Secret != "" {
-		host, err = ft.secrets.ResolveField(ft.dagName, ft.cfg.Secret, "host")
+func FTPConnectOptions(secrets SecretsResolver, dagName string, cfg *config.FTPWatchConfig) (pitftp.Options, error) {
+	if cfg.Secret != "" {
+		opts, err := pitftp.OptionsFromSecret(cfg.Secret, func(name string) (string, error) {
+			return secrets.ResolveField(dagName, cfg.Secret, name)
+		})
 		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.host: %w", ft.cfg.Secret, err)
+			return pitftp.Options{}, err
 		}
-		user, err = ft.secrets.ResolveField(ft.dagName, ft.cfg.Secret, "user")
-		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.user: %w", ft.cfg.Secret, err)
+		if cfg.Protocol != "" {
+			opts.Protocol = cfg.Protocol
 		}
-		password, err = ft.secrets.ResolveField(ft.dagName, ft.cfg.Secret, "password")
-		if err != nil {
-			return "", "", "", fmt.Errorf("resolving %s.password: %w", ft.cfg.Secret, err)
+		if cfg.Port > 0 {
+			opts.Port = cfg.Port
 		}
-		return host, user, password, nil
+		opts.TLS = opts.TLS || cfg.TLS
+		return opts, nil
 	}
 
 	// Legacy: host and user from config, password from plain secret
-	password, err = ft.secrets.Resolve(ft.dagName, ft.cfg.PasswordSecret)
+	password, err := secrets.Resolve(dagName, cfg.PasswordSecret)
 	if err != nil {
-		return "", "", "", fmt.Errorf("resolving password secret %q: %w", ft.cfg.PasswordSecret, err)
+		return pitftp.Options{}, fmt.Errorf("resolving password secret %q: %w", cfg.PasswordSecret, err)
 	}
-	return ft.cfg.Host, ft.cfg.User, password, nil
+	return pitftp.Options{
+		Protocol: cfg.Protocol,
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		User:     cfg.User,
+		Password: password,
+		TLS:      cfg.TLS,
+	}, nil
 }
 
-func (ft *FTPWatchTrigger) poll(ctx context.Context, events chan<- Event, tracking map[string]fileState) {
-	host, user, password, err := ft.resolveFTPCredentials()
+// poll lists the watched directory and sends an event for each batch of
+// stable files that is due. The listing runs without holding ft.mu so a
+// slow or hung FTP server does not block SaveState.
+func (ft *FTPWatchTrigger) poll(ctx context.Context, events chan<- Event) error {
+	opts, err := FTPConnectOptions(ft.secrets, ft.dagName, ft.cfg)
 	if err != nil {
-		log.Printf("[ftp_watch] %s: %v", ft.dagName, err)
-		return
+		return err
 	}
 
-	client, err := pitftp.Connect(host, ft.cfg.Port, user, password, ft.cfg.TLS)
+	client, err := pitftp.Connect(opts)
 	if err != nil {
-		log.Printf("[ftp_watch] %s: connect: %v", ft.dagName, err)
-		return
+		return fmt.Errorf("connect: %w", err)
 	}
-	defer client.Close()
-
 	files, err := client.List(ft.cfg.Directory, ft.cfg.Pattern)
+	client.Close()
 	if err != nil {
-		log.Printf("[ftp_watch] %s: list: %v", ft.dagName, err)
-		return
+		return fmt.Errorf("list: %w", err)
 	}
 
-	now := time.Now()
-	stableThreshold := time.Duration(ft.cfg.StableSeconds) * time.Second
+	batches, released := ft.update(files, time.Now())
+	for i, batch := range batches {
+		select {
+		case events <- Event{
+			DAGName: ft.dagName,
+			Source:  "ftp_watch",
+			Files:   batch,
+		}:
+		case <-ctx.Done():
+			// Shutting down: track the unsent files again so they are saved
+			// with the trigger state and fire as soon as serve restarts
+			ft.mu.Lock()
+			for _, unsent := range batches[i:] {
+				for _, name := range unsent {
+					ft.tracking[name] = released[name]
+				}
+			}
+			ft.mu.Unlock()
+			return nil
+		}
+	}
+	return nil
+}
+
+// update applies a directory listing to the stability timers and removes
+// and returns the batches of stable files that are due, along with the
+// states of their files.
+func (ft *FTPWatchTrigger) update(files []pitftp.FileInfo, now time.Time) ([][]string, map[string]fileState) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	tracking := ft.tracking
 
 	// Update tracking map with current files
 	seen := make(map[string]bool, len(files))
 	for _, f := range files {
+		if !remotepath.IsName(f.Name) {
+			// A name with a separator could write outside the run's data dir
+			log.Printf("[ftp_watch] %s: ignoring unsafe file name %q", ft.dagName, f.Name)
+			continue
+		}
 		seen[f.Name] = true
 		prev, exists := tracking[f.Name]
 		if !exists || prev.Size != f.Size {
@@ -130,25 +246,61 @@ func (ft *FTPWatchTrigger) poll(ctx context.Context, events chan<- Event, tracki
 		}
 	}
 
-	// Find stable files
-	stable := FindStableFiles(tracking, stableThreshold, now)
-	if len(stable) == 0 {
-		return
+	// Mark newly stable files; they wait in tracking for their batch
+	for _, name := range FindStableFiles(tracking, time.Duration(ft.cfg.StableSeconds)*time.Second, now) {
+		if st := tracking[name]; st.StableAt.IsZero() {
+			st.StableAt = now
+			tracking[name] = st
+		}
 	}
 
-	// Remove stable files from tracking before sending event
-	for _, name := range stable {
-		delete(tracking, name)
+	// Remove the files of due batches from tracking before sending events
+	batches := ft.dueBatches(now)
+	released := make(map[string]fileState)
+	for _, batch := range batches {
+		for _, name := range batch {
+			released[name] = tracking[name]
+			delete(tracking, name)
+		}
 	}
+	return batches, released
+}
+
+// dueBatches groups the stable tracked files, oldest first, into the events
+// to send at now. Files wait until batch_window has passed since the oldest
+// became stable; a batch of max_batch_size files goes out without waiting.
+// Without either setting, all stable files go out in one event. Call with
+// ft.mu held.
+func (ft *FTPWatchTrigger) dueBatches(now time.Time) [][]string {
+	var stable []string
+	for name, st := range ft.tracking {
+		if !st.StableAt.IsZero() {
+			stable = append(stable, name)
+		}
+	}
+	if len(stable) == 0 {
+		return nil
+	}
+	sort.Slice(stable, func(i, j int) bool {
+		a, b := ft.tracking[stable[i]].StableAt, ft.tracking[stable[j]].StableAt
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return stable[i] < stable[j]
+	})
 
-	select {
-	case events <- Event{
-		DAGName: ft.dagName,
-		Source:  "ftp_watch",
-		Files:   stable,
-	}:
-	case <-ctx.Done():
+	size := ft.cfg.MaxBatchSize
+	if size <= 0 {
+		size = len(stable)
+	}
+	due := now.Sub(ft.tracking[stable[0]].StableAt) >= ft.cfg.BatchWindow.Duration
+	var batches [][]string
+	for len(stable) >= size || due && len(stable) > 0 {
+		n := min(size, len(stable))
+		batches = append(batches, stable[:n:n])
+		stable = stable[n:]
 	}
+	return batches
 }
 
 // FindStableFiles returns filenames that have been stable for at least the threshold duration.
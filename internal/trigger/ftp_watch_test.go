@@ -1,11 +1,18 @@
 package trigger
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/druarnfield/pit/internal/config"
+	pitftp "github.com/druarnfield/pit/internal/ftp"
 )
 
 func TestFindStableFiles_Empty(t *testing.T) {
@@ -94,3 +101,290 @@ func TestNewFTPWatchTrigger_NilSecrets(t *testing.T) {
 		t.Error("NewFTPWatchTrigger() expected error for nil secrets, got nil")
 	}
 }
+
+func TestFTPWatchTrigger_StateRoundTrip(t *testing.T) {
+	ft, err := NewFTPWatchTrigger("test", &config.FTPWatchConfig{PasswordSecret: "pass"}, fakeResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	ft.tracking["sales.csv"] = fileState{Size: 42, FirstSeen: seen}
+
+	data, err := ft.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState() error: %v", err)
+	}
+
+	ft2, _ := NewFTPWatchTrigger("test", &config.FTPWatchConfig{PasswordSecret: "pass"}, fakeResolver{})
+	if err := ft2.RestoreState(data); err != nil {
+		t.Fatalf("RestoreState() error: %v", err)
+	}
+	got, ok := ft2.tracking["sales.csv"]
+	if !ok {
+		t.Fatal("restored tracking missing sales.csv")
+	}
+	if got.Size != 42 || !got.FirstSeen.Equal(seen) {
+		t.Errorf("restored state = %+v, want size 42 first seen %v", got, seen)
+	}
+}
+
+func TestFTPWatchTrigger_Update(t *testing.T) {
+	ft, err := NewFTPWatchTrigger("test", &config.FTPWatchConfig{PasswordSecret: "pass", StableSeconds: 30}, fakeResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	ft.tracking["ready.csv"] = fileState{Size: 10, FirstSeen: now.Add(-time.Minute)}
+	ft.tracking["growing.csv"] = fileState{Size: 10, FirstSeen: now.Add(-time.Minute)}
+	ft.tracking["gone.csv"] = fileState{Size: 10, FirstSeen: now.Add(-time.Minute)}
+
+	batches, released := ft.update([]pitftp.FileInfo{
+		{Name: "ready.csv", Size: 10},
+		{Name: "growing.csv", Size: 20},
+		{Name: "new.csv", Size: 5},
+		{Name: `..\evil.csv`, Size: 5},
+	}, now)
+
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0] != "ready.csv" {
+		t.Errorf("batches = %v, want [[ready.csv]]", batches)
+	}
+	if st, ok := released["ready.csv"]; !ok || st.Size != 10 {
+		t.Errorf("released = %+v, want ready.csv with its state", released)
+	}
+	got := make([]string, 0, len(ft.tracking))
+	for name := range ft.tracking {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "growing.csv" || got[1] != "new.csv" {
+		t.Errorf("tracking = %v, want [growing.csv new.csv]", got)
+	}
+	if !ft.tracking["growing.csv"].FirstSeen.Equal(now) {
+		t.Error("growing.csv stability timer was not restarted")
+	}
+}
+
+func TestFTPWatchTrigger_Batching(t *testing.T) {
+	ft, err := NewFTPWatchTrigger("test", &config.FTPWatchConfig{
+		PasswordSecret: "pass",
+		StableSeconds:  30,
+		BatchWindow:    config.Duration{Duration: 2 * time.Minute},
+		MaxBatchSize:   3,
+	}, fakeResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	listing := func(names ...string) []pitftp.FileInfo {
+		files := make([]pitftp.FileInfo, len(names))
+		for i, name := range names {
+			files[i] = pitftp.FileInfo{Name: name, Size: 10}
+		}
+		return files
+	}
+
+	// a and b are seen, then stable 30s later: the window is still open
+	ft.update(listing("a.csv", "b.csv"), start)
+	if batches, _ := ft.update(listing("a.csv", "b.csv"), start.Add(30*time.Second)); len(batches) != 0 {
+		t.Fatalf("batches after stable = %v, want none within batch_window", batches)
+	}
+
+	// Three more turn stable: a full batch goes out at once, oldest first
+	ft.update(listing("a.csv", "b.csv", "c.csv", "d.csv", "e.csv"), start.Add(time.Minute))
+	batches, released := ft.update(listing("a.csv", "b.csv", "c.csv", "d.csv", "e.csv"), start.Add(90*time.Second))
+	if len(batches) != 1 || strings.Join(batches[0], ",") != "a.csv,b.csv,c.csv" {
+		t.Fatalf("batches with a full batch = %v, want [[a.csv b.csv c.csv]]", batches)
+	}
+	if len(released) != 3 {
+		t.Errorf("released = %v, want the 3 files of the batch", released)
+	}
+
+	// The rest go out once the window since d became stable has passed
+	if batches, _ := ft.update(listing("d.csv", "e.csv"), start.Add(3*time.Minute)); len(batches) != 0 {
+		t.Fatalf("batches = %v, want none within batch_window", batches)
+	}
+	batches, _ = ft.update(listing("d.csv", "e.csv"), start.Add(4*time.Minute))
+	if len(batches) != 1 || strings.Join(batches[0], ",") != "d.csv,e.csv" {
+		t.Errorf("batches after batch_window = %v, want [[d.csv e.csv]]", batches)
+	}
+	if len(ft.tracking) != 0 {
+		t.Errorf("tracking = %v, want empty", ft.tracking)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		limit    time.Duration
+		want     time.Duration
+	}{
+		{0, 5 * time.Minute, 30 * time.Second},
+		{1, 5 * time.Minute, time.Minute},
+		{2, 5 * time.Minute, 2 * time.Minute},
+		{3, 5 * time.Minute, 4 * time.Minute},
+		{4, 5 * time.Minute, 5 * time.Minute},
+		{100, 5 * time.Minute, 5 * time.Minute},
+		{3, 10 * time.Second, 30 * time.Second}, // limit below interval: no backoff
+	}
+	for _, tt := range tests {
+		if got := backoff(30*time.Second, tt.limit, tt.failures); got != tt.want {
+			t.Errorf("backoff(30s, %s, %d) = %s, want %s", tt.limit, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestFTPWatchTrigger_RecordPoll(t *testing.T) {
+	ft, err := NewFTPWatchTrigger("test", &config.FTPWatchConfig{
+		PasswordSecret: "pass",
+		PollInterval:   config.Duration{Duration: 30 * time.Second},
+		MaxBackoff:     config.Duration{Duration: 5 * time.Minute},
+	}, fakeResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if got := ft.recordPoll(errors.New("connect: refused"), start); got != time.Minute {
+		t.Errorf("delay after 1 failure = %s, want 1m", got)
+	}
+	if got := ft.recordPoll(errors.New("connect: timeout"), start.Add(time.Minute)); got != 2*time.Minute {
+		t.Errorf("delay after 2 failures = %s, want 2m", got)
+	}
+	h := ft.Health()
+	if !h.Degraded() || h.Failures != 2 || !h.DegradedSince.Equal(start) || h.LastError != "connect: timeout" {
+		t.Errorf("Health() = %+v, want 2 failures since %s with the last error", h, start)
+	}
+	if h.DAGName != "test" || h.Source != "ftp_watch" {
+		t.Errorf("Health() = %+v, want test/ftp_watch", h)
+	}
+	if want := start.Add(3 * time.Minute); !h.NextPoll.Equal(want) {
+		t.Errorf("NextPoll = %s, want %s", h.NextPoll, want)
+	}
+
+	if got := ft.recordPoll(nil, start.Add(3*time.Minute)); got != 30*time.Second {
+		t.Errorf("delay after recovery = %s, want 30s", got)
+	}
+	if h := ft.Health(); h.Degraded() || !h.DegradedSince.IsZero() || h.LastError != "" {
+		t.Errorf("Health() after recovery = %+v, want healthy", h)
+	}
+}
+
+// fakeResolver is a no-op SecretsResolver for constructing triggers in tests.
+type fakeResolver struct{}
+
+func (fakeResolver) Resolve(project, key string) (string, error) { return "", nil }
+func (fakeResolver) ResolveField(project, secret, field string) (string, error) {
+	return "", nil
+}
+
+// mapResolver resolves secrets and structured secret fields from maps.
+type mapResolver struct {
+	plain  map[string]string
+	fields map[string]string
+}
+
+func (r mapResolver) Resolve(project, key string) (string, error) {
+	if v, ok := r.plain[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("no secret " + key)
+}
+
+func (r mapResolver) ResolveField(project, secret, field string) (string, error) {
+	if v, ok := r.fields[field]; ok {
+		return v, nil
+	}
+	return "", errors.New("no field " + field)
+}
+
+func TestFTPConnectOptions(t *testing.T) {
+	secrets := mapResolver{
+		plain:  map[string]string{"ftp_pass": "pw"},
+		fields: map[string]string{"host": "files.example.com", "user": "pit", "password": "pw", "port": "2222", "protocol": "sftp"},
+	}
+
+	// The secret's port and protocol stand unless the watch sets its own
+	got, err := FTPConnectOptions(secrets, "sales", &config.FTPWatchConfig{Secret: "src"})
+	if err != nil {
+		t.Fatalf("FTPConnectOptions() error: %v", err)
+	}
+	if got.Protocol != pitftp.ProtocolSFTP || got.Port != 2222 || got.Host != "files.example.com" {
+		t.Errorf("FTPConnectOptions() = %+v, want the secret's sftp on 2222", got)
+	}
+	got, err = FTPConnectOptions(secrets, "sales", &config.FTPWatchConfig{Secret: "src", Protocol: "ftp", Port: 21, TLS: true})
+	if err != nil {
+		t.Fatalf("FTPConnectOptions() error: %v", err)
+	}
+	if got.Protocol != pitftp.ProtocolFTP || got.Port != 21 || !got.TLS {
+		t.Errorf("FTPConnectOptions() = %+v, want the watch's ftp on 21 with TLS", got)
+	}
+
+	// Legacy fields
+	got, err = FTPConnectOptions(secrets, "sales", &config.FTPWatchConfig{
+		Protocol: "sftp", Host: "legacy.example.com", Port: 22, User: "old", PasswordSecret: "ftp_pass",
+	})
+	if err != nil {
+		t.Fatalf("FTPConnectOptions() legacy error: %v", err)
+	}
+	want := pitftp.Options{Protocol: "sftp", Host: "legacy.example.com", Port: 22, User: "old", Password: "pw"}
+	if got != want {
+		t.Errorf("FTPConnectOptions() legacy = %+v, want %+v", got, want)
+	}
+	if _, err := FTPConnectOptions(secrets, "sales", &config.FTPWatchConfig{PasswordSecret: "missing"}); err == nil {
+		t.Error("FTPConnectOptions() with a missing password secret succeeded, want error")
+	}
+}
+
+// TestFTPWatchTrigger_EndToEnd polls an embedded server over each protocol
+// and waits for the event for the files dropped on it.
+func TestFTPWatchTrigger_EndToEnd(t *testing.T) {
+	for _, protocol := range []string{pitftp.ProtocolFTP, pitftp.ProtocolSFTP} {
+		t.Run(protocol, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.Mkdir(filepath.Join(root, "incoming"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"sales_1.csv", "sales_2.csv", "notes.txt"} {
+				if err := os.WriteFile(filepath.Join(root, "incoming", name), []byte(name), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			srv, err := pitftp.NewServer(pitftp.ServerOptions{Protocol: protocol, Root: root, User: "pit", Password: "secret"})
+			if err != nil {
+				t.Fatalf("NewServer() error: %v", err)
+			}
+			go srv.Serve()
+			defer srv.Close()
+
+			o := srv.ClientOptions()
+			secrets := mapResolver{fields: map[string]string{
+				"host": o.Host, "port": strconv.Itoa(o.Port), "user": o.User, "password": o.Password,
+				"protocol": o.Protocol, "host_key": o.HostKey,
+			}}
+			ft, err := NewFTPWatchTrigger("sales", &config.FTPWatchConfig{
+				Secret:       "partner_ftp",
+				Directory:    "/incoming",
+				Pattern:      "sales_*.csv",
+				PollInterval: config.Duration{Duration: 10 * time.Millisecond},
+			}, secrets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+			defer cancel()
+			events := make(chan Event, 1)
+			go ft.Start(ctx, events)
+
+			select {
+			case ev := <-events:
+				sort.Strings(ev.Files)
+				if ev.DAGName != "sales" || ev.Source != "ftp_watch" || strings.Join(ev.Files, ",") != "sales_1.csv,sales_2.csv" {
+					t.Errorf("event = %+v, want sales_1.csv and sales_2.csv for sales", ev)
+				}
+			case <-ctx.Done():
+				t.Fatalf("no event before timeout; health %+v", ft.Health())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package trigger
+
+import "time"
+
+// Health describes the recent polls of a trigger that checks an external
+// system. A trigger is degraded while its polls keep failing.
+type Health struct {
+	DAGName       string
+	Source        string    // "ftp_watch"
+	Failures      int       // consecutive failed polls (0 = healthy)
+	DegradedSince time.Time // time of the first failure in the current run of failures
+	LastError     string
+	NextPoll      time.Time
+}
+
+// Degraded reports whether the most recent poll failed.
+func (h Health) Degraded() bool {
+	return h.Failures > 0
+}
+
+// HealthReporter is implemented by polling triggers that back off while
+// the system they watch is failing.
+type HealthReporter interface {
+	Trigger
+	Health() Health
+}
+
+// backoff returns the delay before the next poll after failures
+// consecutive errors: interval, doubled for each failure, capped at limit.
+// A limit below interval disables the backoff.
+func backoff(interval, limit time.Duration, failures int) time.Duration {
+	if limit < interval {
+		return interval
+	}
+	d := interval
+	for i := 0; i < failures && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
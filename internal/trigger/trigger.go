@@ -1,6 +1,9 @@
 package trigger
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+)
 
 // Event represents a trigger firing for a DAG.
 type Event struct {
@@ -14,3 +17,14 @@ type Trigger interface {
 	Start(ctx context.Context, events chan<- Event) error
 	Name() string
 }
+
+// Stateful is implemented by triggers whose in-memory state (stability
+// timers, last fire times) should survive a serve restart.
+type Stateful interface {
+	Trigger
+	// SaveState returns an opaque snapshot of the trigger's state.
+	SaveState() (json.RawMessage, error)
+	// RestoreState loads a snapshot previously produced by SaveState.
+	// Must be called before Start.
+	RestoreState(data json.RawMessage) error
+}
@@ -0,0 +1,298 @@
+"use strict";
+
+// The dashboard polls the REST API and control endpoints of pit serve.
+// Everything is read-only; the API token, when one is configured, is kept
+// in localStorage and sent as a bearer token.
+
+const REFRESH_MS = 10000;
+const LOG_LINES = 1000;
+
+let selectedRun = null;
+let logAbort = null;
+
+function token() {
+  return localStorage.getItem("pit_api_token") || "";
+}
+
+function authHeaders() {
+  const t = token();
+  return t ? { Authorization: "Bearer " + t } : {};
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: authHeaders() });
+  if (!resp.ok) {
+    let msg = resp.statusText;
+    try {
+      msg = (await resp.json()).error || msg;
+    } catch (e) {}
+    const err = new Error(path + ": " + msg);
+    err.status = resp.status;
+    throw err;
+  }
+  return resp.json();
+}
+
+function el(tag, text, className) {
+  const e = document.createElement(tag);
+  if (text !== undefined && text !== null) e.textContent = text;
+  if (className) e.className = className;
+  return e;
+}
+
+function row(cells, onclick) {
+  const tr = document.createElement("tr");
+  for (const c of cells) {
+    tr.appendChild(c instanceof Node ? wrapCell(c) : el("td", c));
+  }
+  if (onclick) {
+    tr.className = "clickable";
+    tr.addEventListener("click", onclick);
+  }
+  return tr;
+}
+
+function wrapCell(node) {
+  const td = document.createElement("td");
+  td.appendChild(node);
+  return td;
+}
+
+function statusCell(status) {
+  return el("span", status, "status-" + status);
+}
+
+function fill(tableID, rows, empty) {
+  const tbody = document.querySelector("#" + tableID + " tbody");
+  tbody.replaceChildren(...rows);
+  if (rows.length === 0) {
+    const cols = document.querySelectorAll("#" + tableID + " th").length;
+    const td = el("td", empty, "muted");
+    td.colSpan = cols;
+    const tr = document.createElement("tr");
+    tr.appendChild(td);
+    tbody.appendChild(tr);
+  }
+}
+
+function fmtTime(s) {
+  return s ? new Date(s).toLocaleString() : "-";
+}
+
+function fmtDuration(start, end) {
+  if (!start) return "-";
+  const ms = (end ? new Date(end) : new Date()) - new Date(start);
+  const secs = Math.max(0, Math.round(ms / 1000));
+  if (secs < 60) return secs + "s";
+  const mins = Math.floor(secs / 60);
+  if (mins < 60) return mins + "m" + (secs % 60) + "s";
+  return Math.floor(mins / 60) + "h" + (mins % 60) + "m";
+}
+
+function showError(msg) {
+  const p = document.getElementById("error");
+  p.textContent = msg || "";
+  p.hidden = !msg;
+}
+
+async function loadHealth() {
+  const h = await getJSON("/api/health");
+  document.getElementById("version").textContent = h.version || "";
+  const health = document.getElementById("health");
+  health.textContent = h.status;
+  health.className = h.status === "ok" ? "status-success" : "status-failed";
+}
+
+async function loadDAGs() {
+  const { dags } = await getJSON("/api/dags");
+  fill("dags", dags.map((d) => {
+    const lr = d.latest_run;
+    const success = d.stats && d.stats.runs > 0
+      ? Math.round(d.stats.success_rate * 100) + "% of " + d.stats.runs
+      : "-";
+    return row([
+      d.name,
+      (d.schedules || []).join(", ") || "-",
+      String(d.task_count),
+      lr ? fmtTime(lr.started_at) : "-",
+      lr ? statusCell(lr.status) : "-",
+      success,
+    ], lr ? () => selectRun(lr.id) : null);
+  }), "No DAGs registered.");
+}
+
+async function loadRuns() {
+  const { runs } = await getJSON("/api/runs?limit=25");
+  fill("runs", runs.map((r) => row([
+    r.id,
+    r.dag_name,
+    fmtTime(r.started_at),
+    fmtDuration(r.started_at, r.ended_at),
+    r.trigger,
+    statusCell(r.status),
+  ], () => selectRun(r.id))), "No runs recorded yet.");
+  return runs;
+}
+
+async function loadRunning(runs) {
+  const active = runs.filter((r) => r.status === "running");
+  const details = await Promise.all(active.map((r) => getJSON("/api/runs/" + encodeURIComponent(r.id))));
+  const rows = [];
+  for (const run of details) {
+    for (const t of run.tasks.filter((t) => t.status === "running")) {
+      rows.push(row([
+        run.dag_name,
+        run.id,
+        t.name,
+        fmtTime(t.started_at),
+        String(t.attempts),
+      ], () => selectRun(run.id)));
+    }
+  }
+  fill("running", rows, "No tasks running.");
+}
+
+// loadControl reads the queue and trigger health. The control endpoints
+// need the API token, or a loopback client when none is set; without
+// access the sections say so instead of failing the whole page.
+async function loadControl() {
+  const summary = document.getElementById("queue-summary");
+  try {
+    const q = await getJSON("/control/queue");
+    const queued = q.queued.map((e) => e.dag_name).join(", ");
+    summary.textContent = q.running + " run(s) executing, " + q.queued.length + " queued" +
+      (queued ? ": " + queued : "");
+  } catch (e) {
+    summary.textContent = "Queue unavailable (" + e.message + ")";
+  }
+
+  try {
+    const { triggers } = await getJSON("/control/triggers");
+    fill("triggers", triggers.map((t) => row([
+      t.dag_name,
+      t.source,
+      t.degraded_since ? statusCell("degraded since " + fmtTime(t.degraded_since)) : statusCell("ok"),
+      fmtTime(t.next_poll),
+      t.last_error || "",
+    ])), "No polling triggers registered.");
+  } catch (e) {
+    fill("triggers", [], "Trigger health unavailable (" + e.message + ")");
+  }
+}
+
+async function selectRun(id) {
+  selectedRun = id;
+  document.getElementById("run-detail").hidden = false;
+  document.getElementById("log-task").value = "";
+  await loadRunDetail();
+  streamLogs();
+}
+
+async function loadRunDetail() {
+  if (!selectedRun) return;
+  const run = await getJSON("/api/runs/" + encodeURIComponent(selectedRun));
+  document.getElementById("run-title").textContent = run.dag_name + " — " + run.id + " (" + run.status + ")";
+  fill("tasks", run.tasks.map((t) => row([
+    t.name,
+    statusCell(t.status),
+    fmtTime(t.started_at),
+    fmtDuration(t.started_at, t.ended_at),
+    String(t.attempts),
+    t.error || "",
+  ])), "No tasks recorded.");
+
+  const select = document.getElementById("log-task");
+  const current = select.value;
+  select.replaceChildren(el("option", "all"));
+  select.options[0].value = "";
+  for (const t of run.tasks) {
+    const opt = el("option", t.name);
+    opt.value = t.name;
+    select.appendChild(opt);
+  }
+  select.value = current;
+}
+
+// streamLogs reads the run's SSE log stream with fetch rather than
+// EventSource, which cannot send the Authorization header.
+async function streamLogs() {
+  if (logAbort) logAbort.abort();
+  logAbort = new AbortController();
+  const signal = logAbort.signal;
+  const pre = document.getElementById("logs");
+  pre.replaceChildren();
+  const only = document.getElementById("log-task").value;
+
+  let resp;
+  try {
+    resp = await fetch("/api/runs/" + encodeURIComponent(selectedRun) + "/logs?lines=" + LOG_LINES,
+      { headers: authHeaders(), signal });
+  } catch (e) {
+    return;
+  }
+  if (!resp.ok) {
+    pre.textContent = "Logs unavailable (" + resp.statusText + ")";
+    return;
+  }
+
+  const reader = resp.body.getReader();
+  const decoder = new TextDecoder();
+  let buf = "";
+  try {
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) break;
+      buf += decoder.decode(value, { stream: true });
+      let sep;
+      while ((sep = buf.indexOf("\n\n")) >= 0) {
+        handleSSE(buf.slice(0, sep), pre, only);
+        buf = buf.slice(sep + 2);
+      }
+    }
+  } catch (e) {
+    // aborted by a newer selection
+  }
+}
+
+function handleSSE(block, pre, only) {
+  let event = "message";
+  let data = "";
+  for (const line of block.split("\n")) {
+    if (line.startsWith("event: ")) event = line.slice(7);
+    else if (line.startsWith("data: ")) data += line.slice(6);
+  }
+  const payload = JSON.parse(data || "{}");
+  if (event === "complete") {
+    pre.appendChild(el("div", "-- run " + payload.status + " --", "muted"));
+    loadRunDetail().catch(() => {});
+    return;
+  }
+  if (event !== "log" || (only && payload.task_name !== only)) return;
+  const line = el("div", "[" + payload.task_name + "] " + payload.message, "level-" + payload.level);
+  pre.appendChild(line);
+  pre.scrollTop = pre.scrollHeight;
+}
+
+async function refresh() {
+  try {
+    await loadHealth();
+    await loadDAGs();
+    const runs = await loadRuns();
+    await loadRunning(runs);
+    await loadControl();
+    showError("");
+  } catch (e) {
+    showError(e.status === 401 ? "Unauthorized: enter the API token." : e.message);
+  }
+}
+
+document.getElementById("token").value = token();
+document.getElementById("token-form").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  localStorage.setItem("pit_api_token", document.getElementById("token").value);
+  refresh();
+});
+document.getElementById("log-task").addEventListener("change", streamLogs);
+
+refresh();
+setInterval(refresh, REFRESH_MS);
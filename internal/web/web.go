@@ -0,0 +1,31 @@
+// Package web serves the read-only dashboard for pit serve. The dashboard
+// is a single embedded page that reads everything it shows from the REST
+// API (/api/) and the control endpoints (/control/), so it has no state of
+// its own and needs nothing beyond the handlers pit serve already has.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// Handler returns the dashboard UI together with the GET routes under
+// /api/ and /control/ of backend, which the page calls from the browser.
+// Only GET requests reach backend, so the listener can be exposed to
+// operators without opening drains, queue changes, or webhooks to them.
+func Handler(backend http.Handler) http.Handler {
+	static, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic(err) // the embedded tree always has a static directory
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/", backend)
+	mux.Handle("GET /control/", backend)
+	mux.Handle("GET /", http.FileServerFS(static))
+	return mux
+}
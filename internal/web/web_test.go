@@ -0,0 +1,58 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_ServesDashboard(t *testing.T) {
+	h := Handler(http.NotFoundHandler())
+
+	for _, path := range []string{"/", "/app.js", "/style.css"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want 200", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), `<script src="app.js">`) {
+		t.Errorf("index page does not load app.js:\n%s", body)
+	}
+}
+
+func TestHandler_ForwardsOnlyGETs(t *testing.T) {
+	var got []string
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Handler(backend)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/dags", http.StatusTeapot},
+		{http.MethodGet, "/control/queue", http.StatusTeapot},
+		{http.MethodPost, "/control/drain", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/control/queue/claims", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/webhook/claims", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+	if want := "GET /api/dags,GET /control/queue"; strings.Join(got, ",") != want {
+		t.Errorf("backend saw %v, want %s", got, want)
+	}
+}
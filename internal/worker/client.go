@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/loghub"
+)
+
+// Client dispatches runs to a remote worker.
+type Client struct {
+	Name  string
+	URL   string // base URL, e.g. "http://winbox:9191"
+	Token string
+	HTTP  *http.Client // nil = http.DefaultClient
+}
+
+// Result is the outcome of a remote run.
+type Result struct {
+	RunID     string
+	Status    string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Error     string       // first failed task's error, or why the run could not start
+	Tasks     []TaskResult // empty when the worker predates task reporting
+}
+
+// TaskResult is the outcome of one task of a remote run.
+type TaskResult struct {
+	Name           string    `json:"name"`
+	Status         string    `json:"status"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	Attempts       int       `json:"attempts"`
+	Error          string    `json:"error,omitempty"`
+	FailedUpstream []string  `json:"failed_upstream,omitempty"`
+}
+
+// Run dispatches req to the worker and blocks until the run completes,
+// calling onEntry for every streamed log entry (onEntry may be nil).
+func (c *Client) Run(ctx context.Context, req RunRequest, onEntry func(loghub.Entry)) (*Result, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding run request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(c.URL, "/")+"/worker/runs", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("worker %q: %w", c.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("worker %q: %s: %s", c.Name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return readStream(resp.Body, onEntry)
+}
+
+// readStream parses the worker's SSE stream until the complete event.
+func readStream(r io.Reader, onEntry func(loghub.Entry)) (*Result, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var event string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := []byte(strings.TrimPrefix(line, "data: "))
+			switch event {
+			case "log":
+				if onEntry == nil {
+					continue
+				}
+				var entry loghub.Entry
+				if err := json.Unmarshal(data, &entry); err == nil {
+					onEntry(entry)
+				}
+			case "complete":
+				var done completion
+				if err := json.Unmarshal(data, &done); err != nil {
+					return nil, fmt.Errorf("decoding completion event: %w", err)
+				}
+				return &Result{
+					RunID:     done.RunID,
+					Status:    done.Status,
+					StartedAt: done.StartedAt,
+					EndedAt:   done.EndedAt,
+					Error:     done.Error,
+					Tasks:     done.Tasks,
+				}, nil
+			}
+		case line == "":
+			event = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading worker stream: %w", err)
+	}
+	return nil, fmt.Errorf("worker stream ended before run completed")
+}
@@ -0,0 +1,352 @@
+// Package worker implements remote execution of DAG runs. A worker process
+// (pit worker) exposes an authenticated HTTP endpoint; a coordinator
+// (pit serve) dispatches runs to it and receives the run's logs back as a
+// Server-Sent Events stream.
+package worker
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/buildinfo"
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/loghub"
+	"github.com/druarnfield/pit/internal/runid"
+)
+
+// RunRequest is the body of POST /worker/runs.
+type RunRequest struct {
+	DAGName string            `json:"dag_name"`
+	Trigger string            `json:"trigger"`
+	RunID   string            `json:"run_id,omitempty"`
+	Files   map[string]string `json:"files,omitempty"` // filename → base64 content, seeded into data/
+
+	// Origins says where seed files were fetched from, by filename, for the
+	// run's input manifest. Files without one are recorded as seeds.
+	Origins map[string]engine.SeedOrigin `json:"origins,omitempty"`
+}
+
+// DefaultMaxRequestBytes caps the size of a run request body, including
+// base64-encoded seed files, when Limits.MaxRequestBytes is 0.
+const DefaultMaxRequestBytes = 256 << 20
+
+// Limits bounds the work a worker accepts from coordinators.
+type Limits struct {
+	MaxRuns         int   // runs executing at once; further requests get 503 (0 = unlimited)
+	MaxRequestBytes int64 // largest accepted request body (0 = DefaultMaxRequestBytes)
+}
+
+// Server executes runs dispatched by a coordinator.
+type Server struct {
+	configs  map[string]*config.ProjectConfig
+	token    string
+	opts     engine.ExecuteOpts
+	hub      *loghub.Hub
+	maxBytes int64
+	slots    chan struct{} // nil = unlimited
+}
+
+// NewServer discovers the worker's local projects. The worker must have the
+// same workspace checked out as the coordinator; only DAG names travel over
+// the wire. token is required — workers never run unauthenticated.
+func NewServer(rootDir, token string, limits Limits, opts engine.ExecuteOpts) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("worker token is required (set worker_token in pit_config.toml)")
+	}
+	configs, err := config.Discover(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("discovering projects: %w", err)
+	}
+	hub := loghub.New()
+	opts.LogHub = hub
+	s := &Server{configs: configs, token: token, opts: opts, hub: hub, maxBytes: limits.MaxRequestBytes}
+	if s.maxBytes <= 0 {
+		s.maxBytes = DefaultMaxRequestBytes
+	}
+	if limits.MaxRuns > 0 {
+		s.slots = make(chan struct{}, limits.MaxRuns)
+	}
+	return s, nil
+}
+
+// acquire takes a run slot without waiting, reporting false when the
+// worker is already running its maximum number of runs.
+func (s *Server) acquire() bool {
+	if s.slots == nil {
+		return true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release returns a slot taken by acquire.
+func (s *Server) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// Handler returns the worker's HTTP routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /worker/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok", "version": buildinfo.Version()})
+	})
+	mux.HandleFunc("POST /worker/runs", s.handleRun)
+	return s.authMiddleware(mux)
+}
+
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/worker/health" && !checkBearer(r, s.token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkBearer compares the request's bearer token against expected in constant time.
+func checkBearer(r *http.Request, expected string) bool {
+	authHeader := r.Header.Get("Authorization")
+	var provided string
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		provided = authHeader[len("Bearer "):]
+	}
+	want := sha256.Sum256([]byte(expected))
+	got := sha256.Sum256([]byte(provided))
+	return subtle.ConstantTimeCompare(want[:], got[:]) == 1
+}
+
+// handleRun executes the requested DAG and streams its logs until completion.
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if !s.acquire() {
+		http.Error(w, "worker is at its run limit", http.StatusServiceUnavailable)
+		return
+	}
+	// The slot passes to the run goroutine once it starts.
+	started := false
+	defer func() {
+		if !started {
+			s.release()
+		}
+	}()
+
+	var req RunRequest
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	cfg, ok := s.configs[req.DAGName]
+	if !ok {
+		http.Error(w, "unknown DAG", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	opts := s.opts
+	opts.Trigger = req.Trigger
+	if opts.Trigger == "" {
+		opts.Trigger = "remote"
+	}
+	opts.KeepArtifacts = resolveArtifacts(cfg.DAG.KeepArtifacts, s.opts.KeepArtifacts)
+	runID := req.RunID
+	if runID == "" {
+		runID = engine.GenerateRunID(req.DAGName)
+	} else if err := checkRunID(runID, req.DAGName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.RunID = runID
+
+	if len(req.Files) > 0 {
+		seedDir, err := writeSeedFiles(req.Files)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer os.RemoveAll(seedDir)
+		opts.DataSeedDir = seedDir
+		opts.SeedOrigins = req.Origins
+		for name := range req.Files {
+			opts.TriggerFiles = append(opts.TriggerFiles, name)
+		}
+		sort.Strings(opts.TriggerFiles)
+	}
+
+	// Subscribe before starting so no entries are missed
+	s.hub.Activate(runID)
+	ch := s.hub.Subscribe(runID)
+	defer s.hub.Unsubscribe(runID, ch)
+
+	// Copy the config: Execute mutates Tasks for transform projects.
+	runCfg := *cfg
+	started = true
+	done := make(chan completion, 1)
+	go func() {
+		defer s.release()
+		log.Printf("[%s] remote run %s started (trigger: %s)", req.DAGName, runID, opts.Trigger)
+		startedAt := time.Now()
+		run, err := engine.Execute(r.Context(), &runCfg, opts)
+		if err != nil {
+			log.Printf("[%s] remote run %s error: %v", req.DAGName, runID, err)
+			done <- completion{RunID: runID, Status: string(engine.StatusFailed), StartedAt: startedAt, EndedAt: time.Now(), Error: err.Error()}
+			s.hub.Complete(runID, string(engine.StatusFailed))
+			return
+		}
+		log.Printf("[%s] remote run %s completed: %s", req.DAGName, runID, run.Status)
+		done <- newCompletion(run)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case entry, open := <-ch:
+			if !open {
+				// The engine completes the hub just before Execute returns.
+				var c completion
+				select {
+				case c = <-done:
+				case <-r.Context().Done():
+					return
+				}
+				data, _ := json.Marshal(c)
+				fmt.Fprintf(w, "event: complete\ndata: %s\n\n", data)
+				flusher.Flush()
+				return
+			}
+			data, _ := json.Marshal(entry)
+			fmt.Fprintf(w, "event: log\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// completion is the data of the stream's final "complete" event: the run's
+// outcome and its tasks, so the coordinator can record the run in its own
+// metadata store.
+type completion struct {
+	RunID     string       `json:"run_id"`
+	Status    string       `json:"status"`
+	StartedAt time.Time    `json:"started_at"`
+	EndedAt   time.Time    `json:"ended_at"`
+	Error     string       `json:"error,omitempty"`
+	Tasks     []TaskResult `json:"tasks,omitempty"`
+}
+
+// newCompletion summarises a finished run. Mapped tasks are followed by
+// their instances, matching the rows the engine records locally.
+func newCompletion(run *engine.Run) completion {
+	c := completion{RunID: run.ID, Status: string(run.Status), StartedAt: run.StartedAt, EndedAt: run.EndedAt}
+	var add func(ti *engine.TaskInstance)
+	add = func(ti *engine.TaskInstance) {
+		tr := TaskResult{
+			Name:           ti.Name,
+			Status:         string(ti.Status),
+			StartedAt:      ti.StartedAt,
+			EndedAt:        ti.EndedAt,
+			Attempts:       ti.Attempt,
+			FailedUpstream: ti.FailedUpstream,
+		}
+		if ti.Error != nil {
+			tr.Error = ti.Error.Error()
+			if c.Error == "" && ti.Status == engine.StatusFailed {
+				c.Error = tr.Error
+			}
+		}
+		c.Tasks = append(c.Tasks, tr)
+		for _, inst := range ti.Instances {
+			add(inst)
+		}
+	}
+	for _, ti := range run.Tasks {
+		add(ti)
+	}
+	return c
+}
+
+// checkRunID rejects a coordinator-supplied run ID that is not a run ID for
+// dagName. The ID names the run's directory, so path separators and ".."
+// are refused outright.
+func checkRunID(runID, dagName string) error {
+	if strings.ContainsAny(runID, `/\`) || strings.Contains(runID, "..") {
+		return fmt.Errorf("invalid run ID %q", runID)
+	}
+	name, err := runid.DAGName(runID)
+	if err != nil {
+		return fmt.Errorf("invalid run ID: %w", err)
+	}
+	if name != dagName {
+		return fmt.Errorf("run ID %q is not for DAG %q", runID, dagName)
+	}
+	return nil
+}
+
+// writeSeedFiles decodes base64 files into a fresh temp directory.
+func writeSeedFiles(files map[string]string) (string, error) {
+	dir, err := os.MkdirTemp("", "pit-worker-seed-*")
+	if err != nil {
+		return "", fmt.Errorf("creating seed dir: %w", err)
+	}
+	for name, b64 := range files {
+		if name != filepath.Base(name) || name == "." || name == ".." {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("invalid seed file name %q", name)
+		}
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("decoding seed file %q: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("writing seed file %q: %w", name, err)
+		}
+	}
+	return dir, nil
+}
+
+// resolveArtifacts returns the keep_artifacts list: per-project > workspace > default.
+func resolveArtifacts(perProject, workspace []string) []string {
+	if len(perProject) > 0 {
+		return perProject
+	}
+	if workspace != nil {
+		return workspace
+	}
+	return config.DefaultKeepArtifacts
+}
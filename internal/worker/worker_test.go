@@ -0,0 +1,204 @@
+package worker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/loghub"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	proj := filepath.Join(dir, "projects", "remote_dag")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proj, "pit.toml"), []byte("[dag]\nname = \"remote_dag\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(dir, "worker-secret", Limits{MaxRuns: 1, MaxRequestBytes: 1024}, engine.ExecuteOpts{RunsDir: filepath.Join(dir, "runs")})
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestNewServer_RequiresToken(t *testing.T) {
+	_, err := NewServer(t.TempDir(), "", Limits{}, engine.ExecuteOpts{})
+	if err == nil {
+		t.Fatal("NewServer() expected error for empty token, got nil")
+	}
+	if !strings.Contains(err.Error(), "worker_token") {
+		t.Errorf("error = %q, want it to contain %q", err, "worker_token")
+	}
+}
+
+func TestHandler_Auth(t *testing.T) {
+	h := newTestServer(t).Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		want   int
+	}{
+		{name: "health is public", method: http.MethodGet, path: "/worker/health", want: http.StatusOK},
+		{name: "missing token", method: http.MethodPost, path: "/worker/runs", want: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, path: "/worker/runs", auth: "Bearer nope", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleRun_UnknownDAG(t *testing.T) {
+	h := newTestServer(t).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/worker/runs", strings.NewReader(`{"dag_name":"missing"}`))
+	req.Header.Set("Authorization", "Bearer worker-secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleRun_RejectsBadRunID(t *testing.T) {
+	h := newTestServer(t).Handler()
+	for _, id := range []string{
+		"../../etc",
+		"20240115_143022.123-../../_remote_dag",
+		`20240115_143022.123-abc\de_remote_dag`,
+		"20240115_143022.123-3fa9c1_other_dag",
+		"not-a-run-id",
+	} {
+		t.Run(id, func(t *testing.T) {
+			body := fmt.Sprintf(`{"dag_name":"remote_dag","run_id":%q}`, id)
+			req := httptest.NewRequest(http.MethodPost, "/worker/runs", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer worker-secret")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandleRun_Limits(t *testing.T) {
+	s := newTestServer(t)
+	h := s.Handler()
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/worker/runs", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer worker-secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	big := fmt.Sprintf(`{"dag_name":"remote_dag","files":{"a.csv":%q}}`, strings.Repeat("A", 2048))
+	if code := post(big); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body status = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+
+	if !s.acquire() {
+		t.Fatal("acquire() = false, want a free slot after the refused request")
+	}
+	defer s.release()
+	if code := post(`{"dag_name":"remote_dag"}`); code != http.StatusServiceUnavailable {
+		t.Errorf("status at run limit = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestCheckRunID(t *testing.T) {
+	if err := checkRunID("20240115_143022.123-3fa9c1_remote_dag", "remote_dag"); err != nil {
+		t.Errorf("checkRunID() unexpected error: %v", err)
+	}
+}
+
+func TestWriteSeedFiles(t *testing.T) {
+	dir, err := writeSeedFiles(map[string]string{
+		"sales.csv": base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n")),
+	})
+	if err != nil {
+		t.Fatalf("writeSeedFiles() unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, "sales.csv"))
+	if err != nil {
+		t.Fatalf("reading seeded file: %v", err)
+	}
+	if string(data) != "a,b\n1,2\n" {
+		t.Errorf("seeded content = %q, want %q", data, "a,b\n1,2\n")
+	}
+}
+
+func TestWriteSeedFiles_RejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../escape.csv", "sub/dir.csv", ".."} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := writeSeedFiles(map[string]string{name: ""}); err == nil {
+				t.Errorf("writeSeedFiles(%q) expected error, got nil", name)
+			}
+		})
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	stream := "event: log\ndata: {\"task_name\":\"extract\",\"message\":\"hello\"}\n\n" +
+		"event: log\ndata: {\"task_name\":\"load\",\"message\":\"world\"}\n\n" +
+		"event: complete\ndata: {\"status\":\"success\",\"run_id\":\"r1\"}\n\n"
+
+	var got []loghub.Entry
+	res, err := readStream(strings.NewReader(stream), func(e loghub.Entry) { got = append(got, e) })
+	if err != nil {
+		t.Fatalf("readStream() unexpected error: %v", err)
+	}
+	if res.Status != "success" || res.RunID != "r1" {
+		t.Errorf("readStream() = %+v, want status success run r1", res)
+	}
+	if len(got) != 2 || got[0].Message != "hello" || got[1].TaskName != "load" {
+		t.Errorf("entries = %+v, want hello/extract and world/load", got)
+	}
+}
+
+func TestReadStream_CompletionTasks(t *testing.T) {
+	stream := "event: complete\ndata: " +
+		`{"status":"failed","run_id":"r1","error":"boom","tasks":[{"name":"load","status":"failed","attempts":2,"error":"boom"},{"name":"report","status":"upstream_failed","failed_upstream":["load"]}]}` +
+		"\n\n"
+	res, err := readStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("readStream() unexpected error: %v", err)
+	}
+	if res.Error != "boom" || len(res.Tasks) != 2 {
+		t.Fatalf("readStream() = %+v, want error boom and 2 tasks", res)
+	}
+	if res.Tasks[0].Attempts != 2 || res.Tasks[1].FailedUpstream[0] != "load" {
+		t.Errorf("tasks = %+v, want load with 2 attempts and report blocked by load", res.Tasks)
+	}
+}
+
+func TestReadStream_Truncated(t *testing.T) {
+	_, err := readStream(strings.NewReader("event: log\ndata: {}\n\n"), nil)
+	if err == nil {
+		t.Fatal("readStream() expected error for stream without complete event, got nil")
+	}
+}
@@ -0,0 +1,473 @@
+// Package pit is the public Go API for embedding pit in another program.
+// It loads and validates projects, executes DAG runs in-process, and lists
+// past runs, with the same snapshotting, logging, and metadata as the pit
+// CLI. Everything else lives under internal/ and may change between
+// releases; the types here are kept stable.
+//
+//	ws, err := pit.Open("/srv/pipelines")
+//	if err != nil { ... }
+//	run, err := ws.Execute(ctx, "daily_sales", pit.Options{Params: map[string]string{"region": "emea"}})
+//	if err != nil { ... }
+//	if run.Status != pit.StatusSuccess { ... }
+package pit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/dag"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/meta"
+)
+
+// Status is the state of a run or task.
+type Status string
+
+const (
+	StatusPending        Status = "pending"
+	StatusRunning        Status = "running"
+	StatusSuccess        Status = "success"
+	StatusFailed         Status = "failed"
+	StatusSkipped        Status = "skipped"
+	StatusUpstreamFailed Status = "upstream_failed"
+	StatusSkippedFresh   Status = "skipped_fresh"
+)
+
+// Errors for telling failures apart with errors.Is.
+var (
+	// ErrOverlapSkipped is returned by Execute when the DAG sets
+	// overlap = "skip" and another run of it holds the DAG lock.
+	ErrOverlapSkipped = errors.New("DAG is already running (overlap=skip)")
+	// ErrMaxActiveRuns is returned by Execute when the DAG already has
+	// max_active_runs runs and max_active_overflow is "skip".
+	ErrMaxActiveRuns = errors.New("DAG is at max_active_runs")
+	// ErrInvalidDAG matches ValidationErrors and DAGs that fail to start
+	// for a configuration problem, such as an unknown Options.Task.
+	ErrInvalidDAG = engine.ErrInvalidDAG
+	// ErrSnapshot matches failures to copy the project into the run directory.
+	ErrSnapshot = engine.ErrSnapshot
+	// ErrInfrastructure matches failures of the host before any task
+	// started: the snapshot, data directory, secrets, or SDK server. The
+	// same run may succeed when tried again.
+	ErrInfrastructure = engine.ErrInfrastructure
+	// ErrRunner matches tasks whose runner or script could not be resolved.
+	ErrRunner = engine.ErrRunner
+	// ErrTimeout matches tasks killed by their timeout.
+	ErrTimeout = engine.ErrTimeout
+	// ErrTaskFailed matches every *TaskError.
+	ErrTaskFailed = engine.ErrTaskFailed
+)
+
+// TaskError is the failure of one task, found in Run.Err. It carries the
+// DAG and task names and unwraps to the task's error.
+type TaskError = engine.TaskError
+
+// DAG is a loaded project: one pit.toml and the tasks it declares.
+type DAG struct {
+	cfg *config.ProjectConfig
+}
+
+// LoadDAG loads a project from its pit.toml, or from the directory holding
+// it. The DAG name defaults to the directory name. Workspace [defaults] are
+// not applied; use Open for that.
+func LoadDAG(path string) (*DAG, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "pit.toml")
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DAG.Name == "" {
+		cfg.DAG.Name = filepath.Base(cfg.Dir())
+	}
+	return &DAG{cfg: cfg}, nil
+}
+
+// Name returns the DAG name.
+func (d *DAG) Name() string { return d.cfg.DAG.Name }
+
+// Dir returns the project directory.
+func (d *DAG) Dir() string { return d.cfg.Dir() }
+
+// Tasks returns the task names in the order pit.toml declares them.
+func (d *DAG) Tasks() []string {
+	names := make([]string, len(d.cfg.Tasks))
+	for i, t := range d.cfg.Tasks {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Validate checks the project the way pit validate does. It returns nil or
+// a ValidationErrors.
+func (d *DAG) Validate() error {
+	return validationErrors(dag.Validate(d.cfg, d.cfg.Dir()))
+}
+
+// ValidationError is a single problem found by Validate.
+type ValidationError struct {
+	DAG     string
+	Task    string // empty for DAG-level problems
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Task != "" {
+		return fmt.Sprintf("[%s] task %q: %s", e.DAG, e.Task, e.Message)
+	}
+	return fmt.Sprintf("[%s] %s", e.DAG, e.Message)
+}
+
+// ValidationErrors is every problem found by one Validate call.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("validation failed:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// Is reports whether target is ErrInvalidDAG.
+func (e ValidationErrors) Is(target error) bool { return target == ErrInvalidDAG }
+
+func validationErrors(errs []*dag.ValidationError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	out := make(ValidationErrors, len(errs))
+	for i, e := range errs {
+		out[i] = ValidationError{DAG: e.DAG, Task: e.Task, Message: e.Message}
+	}
+	return out
+}
+
+// Workspace is a pit workspace: a root directory with an optional
+// pit_config.toml and the projects its project_globs match.
+type Workspace struct {
+	root string
+	cfg  *config.PitConfig // nil without pit_config.toml
+	dags map[string]*DAG
+}
+
+// Open loads the workspace at root: its pit_config.toml, if any, and every
+// project it discovers, with workspace [defaults] applied.
+func Open(root string) (*Workspace, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadPitConfig(root)
+	if err != nil {
+		return nil, fmt.Errorf("loading pit_config.toml: %w", err)
+	}
+	configs, err := config.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	dags := make(map[string]*DAG, len(configs))
+	for name, c := range configs {
+		dags[name] = &DAG{cfg: c}
+	}
+	return &Workspace{root: root, cfg: cfg, dags: dags}, nil
+}
+
+// Root returns the absolute workspace directory.
+func (w *Workspace) Root() string { return w.root }
+
+// DAGs returns the workspace's DAGs sorted by name.
+func (w *Workspace) DAGs() []*DAG {
+	out := make([]*DAG, 0, len(w.dags))
+	for _, d := range w.dags {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// DAG returns the named DAG.
+func (w *Workspace) DAG(name string) (*DAG, bool) {
+	d, ok := w.dags[name]
+	return d, ok
+}
+
+// Validate checks every DAG and the requires links between them. It
+// returns nil or a ValidationErrors.
+func (w *Workspace) Validate() error {
+	var errs []*dag.ValidationError
+	configs := make(map[string]*config.ProjectConfig, len(w.dags))
+	for _, d := range w.DAGs() {
+		errs = append(errs, dag.Validate(d.cfg, d.cfg.Dir())...)
+		configs[d.Name()] = d.cfg
+	}
+	errs = append(errs, dag.ValidateRequires(configs)...)
+	return validationErrors(errs)
+}
+
+// Execute runs the named DAG with the workspace's settings from
+// pit_config.toml (runs directory, secrets, metadata database, snapshot
+// policy, plugins, and so on). Fields set in opts take precedence.
+func (w *Workspace) Execute(ctx context.Context, name string, opts Options) (*Run, error) {
+	d, ok := w.dags[name]
+	if !ok {
+		return nil, fmt.Errorf("DAG %q not found in %s", name, w.root)
+	}
+	if opts.RunsDir == "" {
+		opts.RunsDir = w.RunsDir()
+	}
+	if opts.SecretsPath == "" && w.cfg != nil {
+		opts.SecretsPath = w.cfg.SecretsDir
+	}
+	if opts.MetadataDB == "" {
+		opts.MetadataDB = filepath.Join(w.root, "pit_metadata.db")
+		if w.cfg != nil && w.cfg.MetadataDB != "" {
+			opts.MetadataDB = w.cfg.MetadataDB
+		}
+	}
+	eo := w.engineOpts(d)
+	return execute(ctx, d, opts, eo)
+}
+
+// RunsDir returns the directory the workspace keeps run snapshots in.
+func (w *Workspace) RunsDir() string {
+	if w.cfg != nil && w.cfg.RunsDir != "" {
+		return w.cfg.RunsDir
+	}
+	return filepath.Join(w.root, "runs")
+}
+
+// Runs lists the workspace's past runs of dagName (all DAGs if empty).
+func (w *Workspace) Runs(dagName string) ([]RunInfo, error) {
+	return Runs(w.RunsDir(), dagName)
+}
+
+// engineOpts returns the engine options pit_config.toml sets for d.
+func (w *Workspace) engineOpts(d *DAG) engine.ExecuteOpts {
+	eo := engine.ExecuteOpts{
+		RepoCacheDir:  filepath.Join(w.root, "repo_cache"),
+		DBTDriver:     config.DefaultDBTDriver,
+		KeepArtifacts: d.cfg.DAG.KeepArtifacts,
+	}
+	c := w.cfg
+	if c == nil {
+		return eo
+	}
+	if c.RepoCacheDir != "" {
+		eo.RepoCacheDir = c.RepoCacheDir
+	}
+	if c.DBTDriver != "" {
+		eo.DBTDriver = c.DBTDriver
+	}
+	if len(eo.KeepArtifacts) == 0 && c.KeepArtifacts != nil {
+		eo.KeepArtifacts = c.KeepArtifacts
+	}
+	eo.AgeIdentity = c.AgeIdentity
+	eo.SDKSocketDir = c.SDKSocketDir
+	if c.MinFreeSpace != nil {
+		eo.MinFreeSpace = int64(*c.MinFreeSpace)
+		if eo.MinFreeSpace == 0 {
+			eo.MinFreeSpace = -1
+		}
+	}
+	if c.Snapshot != nil {
+		eo.SnapshotWorkers = c.Snapshot.Workers
+		eo.SnapshotSymlinks = c.Snapshot.Symlinks
+		eo.SnapshotReadOnly = c.Snapshot.ReadOnly
+	}
+	eo.ArtifactStore = c.ArtifactStore
+	eo.Loader = c.Loader
+	eo.Env = c.Env
+	eo.Lineage = c.Lineage
+	eo.Plugins = c.Plugins
+	return eo
+}
+
+// Options controls a run started by Execute.
+type Options struct {
+	RunsDir     string            // where run snapshots go (default "runs", or the workspace's runs_dir)
+	Task        string            // run only this task
+	Params      map[string]string // run parameters, overriding [dag.params]
+	RunID       string            // use this run ID instead of generating one
+	Trigger     string            // recorded trigger source, seen by run_if (default "manual")
+	Concurrency int               // max parallel tasks (0 = dag.max_parallel_tasks)
+	SecretsPath string            // secrets.toml or a directory of secrets files (default none, or the workspace's secrets_dir)
+	DataSeedDir string            // copied into the run's data directory before the first task
+	MetadataDB  string            // SQLite metadata database to record the run in (default none, or the workspace's)
+	Verbose     bool              // copy task output to Output as well as the task logs
+	Output      io.Writer         // run summary and verbose task output (default io.Discard)
+}
+
+// Execute validates d and runs it in the calling goroutine until every
+// task has finished or ctx is cancelled. A run whose tasks failed is not an
+// error: check Run.Status or Run.Err. Invalid projects return
+// ValidationErrors.
+func Execute(ctx context.Context, d *DAG, opts Options) (*Run, error) {
+	return execute(ctx, d, opts, engine.ExecuteOpts{
+		RepoCacheDir:  "repo_cache",
+		DBTDriver:     config.DefaultDBTDriver,
+		KeepArtifacts: d.cfg.DAG.KeepArtifacts,
+	})
+}
+
+func execute(ctx context.Context, d *DAG, opts Options, eo engine.ExecuteOpts) (*Run, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.RunsDir == "" {
+		opts.RunsDir = "runs"
+	}
+	if opts.Output == nil {
+		opts.Output = io.Discard
+	}
+	if opts.RunID == "" {
+		opts.RunID = engine.GenerateRunID(d.Name())
+	}
+	eo.RunsDir = opts.RunsDir
+	eo.TaskName = opts.Task
+	eo.Params = opts.Params
+	eo.RunID = opts.RunID
+	eo.Trigger = opts.Trigger
+	eo.Concurrency = opts.Concurrency
+	eo.SecretsPath = opts.SecretsPath
+	eo.DataSeedDir = opts.DataSeedDir
+	eo.Verbose = opts.Verbose
+	eo.Output = opts.Output
+	if eo.KeepArtifacts == nil {
+		eo.KeepArtifacts = config.DefaultKeepArtifacts
+	}
+
+	if opts.MetadataDB != "" {
+		store, err := meta.Open(opts.MetadataDB)
+		if err != nil {
+			return nil, fmt.Errorf("opening metadata store: %w", err)
+		}
+		defer store.Close()
+		eo.MetaStore = store
+	}
+
+	lock, err := acquireLock(ctx, d, opts.RunsDir, opts.RunID)
+	if err != nil {
+		return nil, err
+	}
+	if lock != nil {
+		defer lock.Release()
+	}
+
+	r, err := engine.Execute(ctx, d.cfg, eo)
+	if err != nil {
+		return nil, err
+	}
+	return newRun(r, opts.RunsDir), nil
+}
+
+// acquireLock takes the DAG lock pit run and pit serve use, following the
+// DAG's overlap policy, or one of its run slots when max_active_runs is
+// set. It returns nil when neither limits concurrent runs.
+func acquireLock(ctx context.Context, d *DAG, runsDir, runID string) (*engine.DAGLock, error) {
+	switch d.cfg.DAG.Overlap {
+	case "skip":
+		lock, err := engine.TryDAGLock(runsDir, d.Name(), runID)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			return nil, fmt.Errorf("%w: %v", ErrOverlapSkipped, locked)
+		}
+		return lock, err
+	case "wait":
+		return engine.WaitDAGLock(ctx, runsDir, d.Name(), runID, nil)
+	}
+	if limit := d.cfg.DAG.MaxActiveRuns; limit > 0 {
+		if d.cfg.DAG.MaxActiveOverflow == "queue" {
+			return engine.WaitDAGSlot(ctx, runsDir, d.Name(), runID, limit, nil)
+		}
+		lock, err := engine.TryDAGSlot(runsDir, d.Name(), runID, limit)
+		var locked *engine.LockedError
+		if errors.As(err, &locked) {
+			return nil, fmt.Errorf("%w: %v", ErrMaxActiveRuns, locked)
+		}
+		return lock, err
+	}
+	return nil, nil
+}
+
+// Run is the outcome of a finished run.
+type Run struct {
+	ID        string
+	DAG       string
+	Status    Status
+	StartedAt time.Time
+	EndedAt   time.Time
+	Dir       string // runs/<id>: snapshot, logs, and data
+	Tasks     []Task
+	Err       error // nil on success; otherwise the failed tasks' *TaskError values, joined
+}
+
+// Task is the outcome of one task in a run. A mapped task is reported once,
+// with the combined status of its instances.
+type Task struct {
+	Name      string
+	Status    Status
+	Attempts  int
+	StartedAt time.Time
+	EndedAt   time.Time
+	Error     string // empty unless the task failed
+}
+
+func newRun(r *engine.Run, runsDir string) *Run {
+	out := &Run{
+		ID:        r.ID,
+		DAG:       r.DAGName,
+		Status:    Status(r.Status),
+		StartedAt: r.StartedAt,
+		EndedAt:   r.EndedAt,
+		Dir:       filepath.Join(runsDir, r.ID),
+		Tasks:     make([]Task, len(r.Tasks)),
+		Err:       r.Err(),
+	}
+	for i, ti := range r.Tasks {
+		t := Task{
+			Name:      ti.Name,
+			Status:    Status(ti.Status),
+			Attempts:  ti.Attempt,
+			StartedAt: ti.StartedAt,
+			EndedAt:   ti.EndedAt,
+		}
+		if ti.Error != nil {
+			t.Error = ti.Error.Error()
+		}
+		out.Tasks[i] = t
+	}
+	return out
+}
+
+// RunInfo identifies a past run on disk.
+type RunInfo struct {
+	ID        string
+	DAG       string
+	StartedAt time.Time
+	Dir       string
+}
+
+// Runs lists the runs of dagName (all DAGs if empty) under runsDir. A
+// missing runsDir has no runs.
+func Runs(runsDir, dagName string) ([]RunInfo, error) {
+	runs, err := engine.DiscoverRuns(runsDir, dagName)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RunInfo, len(runs))
+	for i, r := range runs {
+		out[i] = RunInfo{ID: r.ID, DAG: r.DAGName, StartedAt: r.Timestamp, Dir: r.Dir}
+	}
+	return out, nil
+}
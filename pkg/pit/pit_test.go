@@ -0,0 +1,175 @@
+package pit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mkTestProject writes a project with one bash task per entry in scripts.
+func mkTestProject(t *testing.T, dir, toml string, scripts map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "tasks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pit.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, "tasks", name), []byte(body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const helloToml = `[dag]
+name = "hello"
+
+[[tasks]]
+name = "greet"
+script = "tasks/greet.sh"
+
+[[tasks]]
+name = "farewell"
+script = "tasks/farewell.sh"
+depends_on = ["greet"]
+`
+
+func TestLoadDAG(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hello")
+	mkTestProject(t, dir, helloToml, map[string]string{"greet.sh": "echo hi\n", "farewell.sh": "echo bye\n"})
+
+	for _, path := range []string{dir, filepath.Join(dir, "pit.toml")} {
+		d, err := LoadDAG(path)
+		if err != nil {
+			t.Fatalf("LoadDAG(%q) error: %v", path, err)
+		}
+		if d.Name() != "hello" || d.Dir() != dir {
+			t.Errorf("LoadDAG(%q) = %s in %s, want hello in %s", path, d.Name(), d.Dir(), dir)
+		}
+		if got := strings.Join(d.Tasks(), ","); got != "greet,farewell" {
+			t.Errorf("Tasks() = %s, want greet,farewell", got)
+		}
+		if err := d.Validate(); err != nil {
+			t.Errorf("Validate() error: %v", err)
+		}
+	}
+
+	if _, err := LoadDAG(t.TempDir()); err == nil {
+		t.Error("LoadDAG() of a directory without pit.toml: expected error, got nil")
+	}
+}
+
+func TestDAG_Validate(t *testing.T) {
+	dir := t.TempDir()
+	mkTestProject(t, dir, helloToml, map[string]string{"greet.sh": "echo hi\n"})
+	d, err := LoadDAG(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Validate()
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Validate() = %v, want ValidationErrors", err)
+	}
+	if len(verrs) != 1 || verrs[0].Task != "farewell" {
+		t.Errorf("Validate() = %+v, want one error for task farewell", verrs)
+	}
+	if !errors.Is(err, ErrInvalidDAG) {
+		t.Error("ValidationErrors does not match ErrInvalidDAG")
+	}
+
+	if _, err := Execute(context.Background(), d, Options{RunsDir: t.TempDir()}); !errors.As(err, &verrs) {
+		t.Errorf("Execute() of an invalid DAG = %v, want ValidationErrors", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	root := t.TempDir()
+	mkTestProject(t, filepath.Join(root, "projects", "hello"), helloToml, map[string]string{"greet.sh": "echo hi\n", "farewell.sh": "echo bye\n"})
+	mkTestProject(t, filepath.Join(root, "projects", "etl"), "[dag]\n\n[[tasks]]\nname = \"load\"\nscript = \"tasks/load.sh\"\n", map[string]string{"load.sh": "true\n"})
+	os.WriteFile(filepath.Join(root, "pit_config.toml"), []byte("runs_dir = \"out/runs\"\n"), 0o644)
+
+	ws, err := Open(root)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	var names []string
+	for _, d := range ws.DAGs() {
+		names = append(names, d.Name())
+	}
+	if got := strings.Join(names, ","); got != "etl,hello" {
+		t.Errorf("DAGs() = %s, want etl,hello", got)
+	}
+	if _, ok := ws.DAG("missing"); ok {
+		t.Error("DAG(\"missing\") found a DAG")
+	}
+	if err := ws.Validate(); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+	if want := filepath.Join(root, "out", "runs"); ws.RunsDir() != want {
+		t.Errorf("RunsDir() = %s, want %s", ws.RunsDir(), want)
+	}
+	if _, err := ws.Execute(context.Background(), "missing", Options{}); err == nil {
+		t.Error("Execute() of an unknown DAG: expected error, got nil")
+	}
+}
+
+func TestWorkspace_Execute(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	root := t.TempDir()
+	mkTestProject(t, filepath.Join(root, "projects", "hello"), helloToml, map[string]string{
+		"greet.sh":    "echo \"hi $PIT_PARAM_WHO\"\n",
+		"farewell.sh": "exit 3\n",
+	})
+
+	ws, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := ws.Execute(context.Background(), "hello", Options{Params: map[string]string{"who": "there"}})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if run.DAG != "hello" || run.Status != StatusFailed {
+		t.Errorf("run = %s %s, want hello failed", run.DAG, run.Status)
+	}
+	if len(run.Tasks) != 2 {
+		t.Fatalf("run has %d tasks, want 2", len(run.Tasks))
+	}
+	if got := run.Tasks[0]; got.Name != "greet" || got.Status != StatusSuccess || got.Attempts != 1 {
+		t.Errorf("task 0 = %+v, want greet success after 1 attempt", got)
+	}
+	if got := run.Tasks[1]; got.Status != StatusFailed || got.Error == "" {
+		t.Errorf("task 1 = %+v, want failed with an error", got)
+	}
+	var te *TaskError
+	if !errors.Is(run.Err, ErrTaskFailed) || !errors.As(run.Err, &te) || te.Task != "farewell" {
+		t.Errorf("run.Err = %v, want a TaskError for farewell", run.Err)
+	}
+	if log, err := os.ReadFile(filepath.Join(run.Dir, "logs", "greet.log")); err != nil || !strings.Contains(string(log), "hi") {
+		t.Errorf("greet.log = %q (err %v), want it to contain %q", log, err, "hi")
+	}
+	if _, err := os.Stat(filepath.Join(root, "pit_metadata.db")); err != nil {
+		t.Errorf("metadata database not written: %v", err)
+	}
+
+	runs, err := ws.Runs("hello")
+	if err != nil {
+		t.Fatalf("Runs() error: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID || runs[0].Dir != run.Dir {
+		t.Errorf("Runs() = %+v, want the one run %s", runs, run.ID)
+	}
+
+	if _, err := ws.Execute(context.Background(), "hello", Options{Task: "missing"}); !errors.Is(err, ErrInvalidDAG) {
+		t.Errorf("Execute() of an unknown task = %v, want ErrInvalidDAG", err)
+	}
+}
@@ -0,0 +1,283 @@
+// Package pittest runs a project's task scripts and load flows locally,
+// so they can be unit tested in Go and in CI without the databases and FTP
+// servers they use in production. A Harness serves the pit SDK the way a
+// run does, with fake secrets, a temporary data directory, and a SQLite
+// database standing in for the warehouse.
+//
+//	func TestLoadSales(t *testing.T) {
+//		h := pittest.New(t, pittest.Options{Project: "..", Secrets: map[string]string{"api_key": "test"}})
+//		h.WriteFile("sales.parquet", fixture)
+//		if out, err := h.Command("load_sales", "uv", "run", "tasks/load_sales.py").CombinedOutput(); err != nil {
+//			t.Fatalf("load_sales: %v\n%s", err, out)
+//		}
+//		if n := h.Count("sales"); n != 3 { ... }
+//	}
+package pittest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/config"
+	"github.com/druarnfield/pit/internal/engine"
+	"github.com/druarnfield/pit/internal/loader"
+	"github.com/druarnfield/pit/internal/runner"
+	"github.com/druarnfield/pit/internal/sdk"
+	"github.com/druarnfield/pit/internal/secrets"
+)
+
+// RunID is the PIT_RUN_ID tasks see under a Harness.
+const RunID = "pittest"
+
+// defaultConnection is the secret that resolves to the harness database
+// when the project names no connections.
+const defaultConnection = "warehouse"
+
+// Options configures a Harness.
+type Options struct {
+	// Project is the directory holding the project's pit.toml. Commands
+	// run there. Empty runs the harness for a bare DAG named "test".
+	Project string
+	// Secrets are the plain secrets the DAG resolves, by key.
+	Secrets map[string]string
+	// StructuredSecrets are the secrets with fields, such as FTP
+	// credentials, by key.
+	StructuredSecrets map[string]map[string]string
+	// Connections are the secrets that resolve to the harness database.
+	// Empty means every connection the project's pit.toml names, or
+	// "warehouse" when it names none. A key also in Secrets keeps the
+	// value given there.
+	Connections []string
+	// Params are run parameters, on top of the DAG's defaults, that tasks
+	// see as PIT_PARAM_<NAME>.
+	Params map[string]string
+}
+
+// Harness serves the pit SDK for one DAG for the length of a test.
+// Everything it creates is removed when the test ends.
+type Harness struct {
+	// DataDir is the data directory, PIT_DATA_DIR. SDK file methods such
+	// as load_data and ftp_download read and write there.
+	DataDir string
+	// Warehouse is the sqlite:// connection string of the harness
+	// database, which the Connections secrets resolve to.
+	Warehouse string
+
+	t          testing.TB
+	cfg        *config.ProjectConfig
+	dir        string // project directory commands run in
+	scratchDir string
+	params     map[string]string
+	session    *engine.SDKSession
+	db         *sql.DB
+}
+
+// New starts a Harness for the project in opts, failing t if it cannot.
+// Load methods put tables in the SQLite database's main schema whatever
+// [dag.sql] schema the project sets.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	root := t.TempDir()
+	h := &Harness{
+		DataDir:    filepath.Join(root, "data"),
+		Warehouse:  "sqlite://" + filepath.Join(root, "warehouse.db"),
+		t:          t,
+		dir:        opts.Project,
+		scratchDir: filepath.Join(root, "scratch"),
+	}
+	for _, dir := range []string{h.DataDir, h.scratchDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("pittest: %v", err)
+		}
+	}
+
+	if opts.Project == "" {
+		h.cfg = &config.ProjectConfig{DAG: config.DAGConfig{Name: "test"}}
+		h.dir = root
+	} else {
+		cfg, err := config.Load(filepath.Join(opts.Project, "pit.toml"))
+		if err != nil {
+			t.Fatalf("pittest: %v", err)
+		}
+		h.cfg = cfg
+	}
+	h.params = make(map[string]string, len(h.cfg.DAG.Params)+len(opts.Params))
+	for k, v := range h.cfg.DAG.Params {
+		h.params[k] = v
+	}
+	for k, v := range opts.Params {
+		h.params[k] = v
+	}
+
+	store, err := h.fakeSecrets(opts)
+	if err != nil {
+		t.Fatalf("pittest: %v", err)
+	}
+
+	// SQLite has only the main schema; keep [dag.sql] schema out of loads
+	sessionCfg := *h.cfg
+	sessionCfg.DAG.SQL.Schema = ""
+	h.session, err = engine.StartSDKSession(&sessionCfg, store, root, h.DataDir)
+	if err != nil {
+		t.Fatalf("pittest: %v", err)
+	}
+	t.Cleanup(func() { h.session.Close() })
+
+	h.db, err = runner.OpenDB("sqlite", h.Warehouse)
+	if err != nil {
+		t.Fatalf("pittest: opening harness database: %v", err)
+	}
+	t.Cleanup(func() { h.db.Close() })
+	return h
+}
+
+// fakeSecrets builds a secrets store from opts, with the connection
+// secrets pointing at the harness database.
+func (h *Harness) fakeSecrets(opts Options) (*secrets.Store, error) {
+	conns := opts.Connections
+	if len(conns) == 0 {
+		conns = projectConnections(h.cfg)
+	}
+
+	var data []byte
+	var err error
+	set := func(key, value string, fields map[string]string) {
+		if err == nil {
+			data, err = secrets.SetSecret(data, h.cfg.DAG.Name, key, value, fields)
+		}
+	}
+	for _, key := range conns {
+		set(key, h.Warehouse, nil)
+	}
+	for key, value := range opts.Secrets {
+		set(key, value, nil)
+	}
+	for key, fields := range opts.StructuredSecrets {
+		set(key, "", fields)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fake secrets: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return secrets.LoadFromBytes(data)
+}
+
+// projectConnections lists the connection secrets cfg names, sorted, or
+// defaultConnection when there are none.
+func projectConnections(cfg *config.ProjectConfig) []string {
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" {
+			seen[name] = true
+		}
+	}
+	add(cfg.DAG.SQL.Connection)
+	for _, t := range cfg.Tasks {
+		add(t.Connection)
+	}
+	for _, o := range cfg.Outputs {
+		add(o.Connection)
+	}
+	if len(seen) == 0 {
+		return []string{defaultConnection}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DAG returns the name of the DAG the harness serves.
+func (h *Harness) DAG() string { return h.cfg.DAG.Name }
+
+// Env returns the PIT_* variables a run gives task, pointing at the
+// harness: the SDK socket, the data and scratch directories, and the
+// params.
+func (h *Harness) Env(task string) []string {
+	env := []string{
+		"PIT_RUN_ID=" + RunID,
+		"PIT_TASK_NAME=" + task,
+		"PIT_DAG_NAME=" + h.cfg.DAG.Name,
+		"PIT_SOCKET=" + h.session.Addr(),
+		"PIT_DATA_DIR=" + h.DataDir,
+		"PIT_SCRATCH_DIR=" + h.scratchDir,
+	}
+	keys := make([]string, 0, len(h.params))
+	for k := range h.params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, "PIT_PARAM_"+strings.ToUpper(k)+"="+h.params[k])
+	}
+	return env
+}
+
+// Command returns a command that runs name with args in the project
+// directory as task, with the environment of the test process plus Env.
+// Unlike pit run, it does not snapshot the project, apply [env] policies,
+// or pick a runner by file extension.
+func (h *Harness) Command(task, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(h.t.Context(), name, args...)
+	cmd.Dir = h.dir
+	cmd.Env = append(os.Environ(), h.Env(task)...)
+	return cmd
+}
+
+// Call invokes an SDK method the way a task does, so load flows can be
+// tested from Go without a script.
+func (h *Harness) Call(method string, params map[string]string) (string, error) {
+	return sdk.Call(h.t.Context(), h.session.Addr(), method, params)
+}
+
+// WriteFile writes a fixture to name in the data directory and returns its
+// path.
+func (h *Harness) WriteFile(name string, data []byte) string {
+	h.t.Helper()
+	path := filepath.Join(h.DataDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		h.t.Fatalf("pittest: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		h.t.Fatalf("pittest: %v", err)
+	}
+	return path
+}
+
+// DB returns the harness database, to seed tables and check what tasks
+// loaded.
+func (h *Harness) DB() *sql.DB { return h.db }
+
+// Count returns the number of rows in table, failing the test if it
+// cannot be counted.
+func (h *Harness) Count(table string) int64 {
+	h.t.Helper()
+	q := (&loader.SQLiteDriver{}).Quoting()
+	var n int64
+	if err := h.db.QueryRowContext(h.t.Context(), "SELECT COUNT(*) FROM "+q.Quote(table)).Scan(&n); err != nil {
+		h.t.Fatalf("pittest: counting %s: %v", table, err)
+	}
+	return n
+}
+
+// Output returns the value task published for key with set_output.
+func (h *Harness) Output(task, key string) (string, bool) {
+	return h.session.Output(task, key)
+}
+
+// Produced reports whether a task registered the declared output with
+// register_output, which task, and the row count it gave (-1 for none).
+func (h *Harness) Produced(output string) (task string, rows int64, ok bool) {
+	return h.session.Produced(output)
+}
@@ -0,0 +1,122 @@
+package pittest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/druarnfield/pit/internal/loader"
+)
+
+func TestHarness_CommandEnv(t *testing.T) {
+	h := New(t, Options{Params: map[string]string{"region": "emea"}})
+
+	script := `printf '%s|%s|%s|%s' "$PIT_DAG_NAME" "$PIT_TASK_NAME" "$PIT_PARAM_REGION" "$PIT_RUN_ID" > "$PIT_DATA_DIR/out.txt"`
+	if out, err := h.Command("greet", "sh", "-c", script).CombinedOutput(); err != nil {
+		t.Fatalf("command: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(filepath.Join(h.DataDir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "test|greet|emea|" + RunID; string(got) != want {
+		t.Errorf("task saw %q, want %q", got, want)
+	}
+}
+
+func TestHarness_SecretsAndConnections(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "pit.toml"), []byte(`[dag]
+name = "sales"
+
+[dag.sql]
+connection = "dw"
+schema = "dbo"
+
+[[tasks]]
+name = "load"
+script = "tasks/load.py"
+`), 0o644)
+
+	h := New(t, Options{
+		Project:           dir,
+		Secrets:           map[string]string{"api_key": "test-key"},
+		StructuredSecrets: map[string]map[string]string{"partner_ftp": {"host": "localhost", "user": "feeds"}},
+	})
+	if h.DAG() != "sales" {
+		t.Errorf("DAG() = %q, want sales", h.DAG())
+	}
+
+	tests := []struct {
+		method string
+		params map[string]string
+		want   string
+	}{
+		{"get_secret", map[string]string{"key": "api_key"}, "test-key"},
+		{"get_secret", map[string]string{"key": "dw"}, h.Warehouse},
+		{"get_secret_field", map[string]string{"secret": "partner_ftp", "field": "user"}, "feeds"},
+	}
+	for _, tt := range tests {
+		got, err := h.Call(tt.method, tt.params)
+		if err != nil {
+			t.Errorf("%s %v: %v", tt.method, tt.params, err)
+		} else if got != tt.want {
+			t.Errorf("%s %v = %q, want %q", tt.method, tt.params, got, tt.want)
+		}
+	}
+	if _, err := h.Call("get_secret", map[string]string{"key": "missing"}); err == nil {
+		t.Error("get_secret of an unknown key succeeded")
+	}
+}
+
+func TestHarness_LoadData(t *testing.T) {
+	h := New(t, Options{})
+
+	// Build a Parquet fixture from a seeded table
+	if _, err := h.DB().Exec(`CREATE TABLE src (id INTEGER, region TEXT);
+		INSERT INTO src VALUES (1, 'emea'), (2, 'apac'), (3, 'emea')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.Save(t.Context(), loader.SaveParams{
+		Query:    "SELECT id, region FROM src",
+		FilePath: filepath.Join(h.DataDir, "sales.parquet"),
+		ConnStr:  h.Warehouse,
+	}); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	got, err := h.Call("load_data", map[string]string{
+		"file":       "sales.parquet",
+		"table":      "sales",
+		"connection": "warehouse",
+		"mode":       "create_or_replace",
+	})
+	if err != nil {
+		t.Fatalf("load_data: %v", err)
+	}
+	if got != "3 rows loaded" {
+		t.Errorf("load_data = %q, want 3 rows loaded", got)
+	}
+	if n := h.Count("sales"); n != 3 {
+		t.Errorf("Count(sales) = %d, want 3", n)
+	}
+
+	_, err = h.Call("load_data", map[string]string{"file": "../escape.parquet", "table": "sales", "connection": "warehouse"})
+	if err == nil || !strings.Contains(err.Error(), "data directory") {
+		t.Errorf("load_data outside the data dir: error = %v", err)
+	}
+}
+
+func TestHarness_Outputs(t *testing.T) {
+	h := New(t, Options{})
+	if _, err := h.Call("set_output", map[string]string{"task": "extract", "key": "rows", "value": "42"}); err != nil {
+		t.Fatalf("set_output: %v", err)
+	}
+	if v, ok := h.Output("extract", "rows"); !ok || v != "42" {
+		t.Errorf("Output(extract, rows) = %q, %v; want 42", v, ok)
+	}
+	if _, ok := h.Output("extract", "other"); ok {
+		t.Error("Output() found a key that was never set")
+	}
+}
@@ -1,11 +1,21 @@
 from pit_sdk.secret import get_secret, get_secret_field
 from pit_sdk.db import read_sql, output_sql
-from pit_sdk.data import write_output, read_input, load_data
+from pit_sdk.data import write_output, read_input, load_data, load_dataset
+from pit_sdk.data import TempTable, create_temp_table, drop_temp_table
 from pit_sdk.ftp import ftp_list, ftp_download, ftp_upload, ftp_move
+from pit_sdk.archive import unzip, gzip, gunzip
+from pit_sdk.pgp import pgp_decrypt, pgp_encrypt
+from pit_sdk.task import set_output, register_output
+from pit_sdk.plugin import call
 
 __all__ = [
     "get_secret", "get_secret_field",
     "read_sql", "output_sql",
-    "write_output", "read_input", "load_data",
+    "write_output", "read_input", "load_data", "load_dataset",
+    "TempTable", "create_temp_table", "drop_temp_table",
     "ftp_list", "ftp_download", "ftp_upload", "ftp_move",
+    "unzip", "gzip", "gunzip",
+    "pgp_decrypt", "pgp_encrypt",
+    "set_output", "register_output",
+    "call",
 ]
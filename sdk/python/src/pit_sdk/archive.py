@@ -0,0 +1,62 @@
+"""Archive helpers for files in the run's data directory.
+
+Extraction and compression run in the Go orchestrator through the SDK
+socket. Every path is relative to PIT_DATA_DIR; paths that leave it, and
+zip entries that would be written outside the destination, are rejected.
+"""
+
+import json
+
+from pit_sdk.secret import _request
+
+
+def unzip(archive: str, dest: str | None = None, *, pattern: str | None = None) -> list[str]:
+    """Extract a zip archive in the data directory.
+
+    Args:
+        archive: Zip file name in PIT_DATA_DIR.
+        dest: Directory in PIT_DATA_DIR to extract into (default: the
+            data directory itself).
+        pattern: Only extract entries whose file name matches this glob,
+            e.g. ``"*.csv"``.
+
+    Returns:
+        List of extracted file paths (absolute, inside PIT_DATA_DIR).
+    """
+    params = {"archive": archive}
+    if dest is not None:
+        params["dest"] = dest
+    if pattern is not None:
+        params["pattern"] = pattern
+    return json.loads(_request("unzip", params))
+
+
+def gzip(name: str, *, remove: bool = False) -> str:
+    """Compress a file in the data directory to ``<name>.gz``.
+
+    Args:
+        name: File name in PIT_DATA_DIR.
+        remove: Delete the original once it is compressed.
+
+    Returns:
+        Path of the compressed file (absolute, inside PIT_DATA_DIR).
+    """
+    return _request("gzip", {"name": name, "remove": "true" if remove else "false"})
+
+
+def gunzip(name: str, dest: str | None = None, *, remove: bool = False) -> str:
+    """Decompress a ``.gz`` file in the data directory.
+
+    Args:
+        name: Compressed file name in PIT_DATA_DIR.
+        dest: Output file name in PIT_DATA_DIR (default: ``name`` without
+            ``.gz``; required if ``name`` does not end in ``.gz``).
+        remove: Delete the compressed file once it is decompressed.
+
+    Returns:
+        Path of the decompressed file (absolute, inside PIT_DATA_DIR).
+    """
+    params = {"name": name, "remove": "true" if remove else "false"}
+    if dest is not None:
+        params["dest"] = dest
+    return _request("gunzip", params)
@@ -2,10 +2,14 @@
 
 Tasks write named outputs as Parquet files into the run's data directory.
 Downstream tasks read them back. The Go orchestrator can bulk-load
-Parquet files into databases via the load_data RPC.
+Parquet files into databases via the load_data and load_dataset RPCs,
+into tables of their own or into temporary tables from create_temp_table.
 """
 
+import json
 import os
+from collections.abc import Sequence
+from typing import NamedTuple
 
 import pyarrow as pa
 import pyarrow.parquet as pq
@@ -78,8 +82,12 @@ def load_data(
     table: str,
     connection: str,
     *,
-    schema: str = "dbo",
+    schema: str = "",
     mode: str = "append",
+    exclude_columns: Sequence[str] = (),
+    timezone_policy: str = "",
+    reject_file: str = "",
+    max_rejects: int = 0,
 ) -> str:
     """Trigger a Go-side bulk load of a Parquet file into a database table.
 
@@ -93,13 +101,29 @@ def load_data(
         table: Target table name.
         connection: Secret key for the connection string
                     (resolved from secrets store).
-        schema: Target schema (default "dbo").
-        mode: Load mode — "append", "truncate_and_load", or
-              "create_or_replace" (drops and recreates the table
-              from the Parquet schema).
+        schema: Target schema. Empty uses ``schema`` from ``[dag.sql]`` or
+                the workspace ``[loader]``, then the driver's default
+                (e.g. "dbo" for SQL Server, "public" for PostgreSQL).
+        mode: Load mode — "append", "append_or_create" (creates the
+              table from the Parquet schema if it does not exist),
+              "truncate_and_load", or "create_or_replace" (drops and
+              recreates the table from the Parquet schema).
+        exclude_columns: Parquet columns not to load. Identity and
+              computed columns of the target table are skipped
+              automatically.
+        timezone_policy: How timestamp columns with a time zone are
+              loaded — "error" (default: refuse), "offset" (keep the
+              offset, e.g. DATETIMEOFFSET), or "utc" (convert to UTC).
+        reject_file: File in the data directory (``.csv`` or ``.parquet``)
+              for rows the database rejects, with the reason. When set,
+              bad rows are set aside and the rest are loaded; otherwise
+              one bad row fails the load.
+        max_rejects: Fail the load after this many rejected rows
+              (0 = no limit). Only used with ``reject_file``.
 
     Returns:
-        A message from the orchestrator (e.g. "1000 rows loaded").
+        A message from the orchestrator (e.g. "1000 rows loaded" or
+        "998 rows loaded, 2 rejected (see rejects.csv)").
 
     Raises:
         RuntimeError: If PIT_SOCKET is not set or the RPC fails.
@@ -114,10 +138,144 @@ def load_data(
             "connection": connection,
             "schema": schema,
             "mode": mode,
+            "exclude_columns": ",".join(exclude_columns),
+            "timezone_policy": timezone_policy,
+            "reject_file": reject_file,
+            "max_rejects": str(max_rejects),
         },
     )
 
 
+def load_dataset(
+    names: Sequence[str],
+    table: str,
+    connection: str,
+    *,
+    schema: str = "",
+    mode: str = "append",
+    exclude_columns: Sequence[str] = (),
+    timezone_policy: str = "",
+    parallel: int = 4,
+) -> str:
+    """Bulk-load several Parquet files with the same columns into one table.
+
+    For tasks that write many part files. The orchestrator loads up to
+    ``parallel`` files at a time, each over its own connection. The
+    mode's create or truncate runs once before any file is loaded; every
+    file is then appended and committed on its own. If a file fails, the
+    files not yet started are skipped, and files already loaded stay.
+
+    Args:
+        names: Output names (without extension). Reads from
+               ``{data_dir}/{name}.parquet``.
+        table: Target table name.
+        connection: Secret key for the connection string
+                    (resolved from secrets store).
+        schema: Target schema; see ``load_data``.
+        mode: Load mode — "append", "append_or_create",
+              "truncate_and_load", or "create_or_replace". A table that
+              is created is built from the first file's schema.
+        exclude_columns: Parquet columns not to load; see ``load_data``.
+        timezone_policy: How timestamp columns with a time zone are
+              loaded; see ``load_data``.
+        parallel: Maximum number of files loaded at once.
+
+    Returns:
+        A message from the orchestrator (e.g. "250000 rows loaded from
+        12 files").
+
+    Raises:
+        RuntimeError: If PIT_SOCKET is not set or the RPC fails.
+    """
+    from pit_sdk.secret import _request
+
+    return _request(
+        "load_dataset",
+        {
+            "files": json.dumps([f"{name}.parquet" for name in names]),
+            "table": table,
+            "connection": connection,
+            "schema": schema,
+            "mode": mode,
+            "exclude_columns": ",".join(exclude_columns),
+            "timezone_policy": timezone_policy,
+            "parallel": str(parallel),
+        },
+    )
+
+
+class TempTable(NamedTuple):
+    """A table created with ``create_temp_table``."""
+
+    table: str  # table name, e.g. for load_data
+    schema: str  # schema the table is in
+    qualified: str  # quoted schema.table for use in SQL
+
+
+def create_temp_table(
+    connection: str,
+    columns: str = "",
+    *,
+    prefix: str = "scratch",
+    schema: str = "",
+) -> TempTable:
+    """Create a uniquely named table for the run's intermediate data.
+
+    The table is named ``pit_<prefix>_<random>`` and is dropped when the
+    run ends, whether it succeeds or fails, unless a task drops it first
+    with ``drop_temp_table``. Use it to stage data between tasks without
+    leaving objects behind in the warehouse.
+
+    Args:
+        connection: Secret key for the connection string
+                    (resolved from secrets store).
+        columns: Column definitions in the database's SQL, e.g.
+                 ``"id int, name varchar(100)"``. When empty, no table is
+                 created: only the name is reserved, for ``load_data``
+                 with ``mode="append_or_create"`` to create.
+        prefix: Part of the name identifying the use, e.g. ``"claims"``.
+                Letters, digits, and underscores, at most 30.
+        schema: Schema for the table; defaults as for ``load_data``.
+
+    Returns:
+        The table's name, schema, and quoted qualified name.
+
+    Raises:
+        RuntimeError: If PIT_SOCKET is not set or the RPC fails.
+    """
+    from pit_sdk.secret import _request
+
+    result = _request(
+        "create_temp_table",
+        {
+            "connection": connection,
+            "columns": columns,
+            "prefix": prefix,
+            "schema": schema,
+        },
+    )
+    return TempTable(**json.loads(result))
+
+
+def drop_temp_table(table: TempTable | str) -> str:
+    """Drop a table created with ``create_temp_table`` before the run ends.
+
+    Args:
+        table: The ``TempTable``, or its table name.
+
+    Returns:
+        A message from the orchestrator.
+
+    Raises:
+        RuntimeError: If PIT_SOCKET is not set, the table was not created
+                      by this run, or the RPC fails.
+    """
+    from pit_sdk.secret import _request
+
+    name = table.table if isinstance(table, TempTable) else table
+    return _request("drop_temp_table", {"table": name})
+
+
 def _is_pandas_df(obj) -> bool:
     """Check if obj is a pandas DataFrame without importing pandas."""
     return type(obj).__module__.startswith("pandas") and type(obj).__name__ == "DataFrame"
@@ -0,0 +1,56 @@
+"""PGP helpers for encrypted partner feeds in the run's data directory.
+
+Encryption and decryption run in the Go orchestrator through the SDK
+socket, so keys never reach the task process. Keys are read from
+structured secrets: ``private_key`` (and ``passphrase``, if the key is
+protected) to decrypt, ``public_key`` to encrypt. Every path is relative
+to PIT_DATA_DIR.
+"""
+
+from pit_sdk.secret import _request
+
+
+def pgp_decrypt(name: str, secret: str, dest: str | None = None, *, remove: bool = False) -> str:
+    """Decrypt a PGP file in the data directory.
+
+    Args:
+        name: Encrypted file name in PIT_DATA_DIR (binary or armored).
+        secret: Structured secret holding ``private_key``.
+        dest: Output file name in PIT_DATA_DIR (default: ``name`` without
+            ``.pgp``, ``.gpg``, or ``.asc``; required otherwise).
+        remove: Delete the encrypted file once it is decrypted.
+
+    Returns:
+        Path of the decrypted file (absolute, inside PIT_DATA_DIR).
+    """
+    params = {"name": name, "secret": secret, "remove": "true" if remove else "false"}
+    if dest is not None:
+        params["dest"] = dest
+    return _request("pgp_decrypt", params)
+
+
+def pgp_encrypt(
+    name: str, secret: str, dest: str | None = None, *, armor: bool = False, remove: bool = False
+) -> str:
+    """Encrypt a file in the data directory to a partner's public key.
+
+    Args:
+        name: File name in PIT_DATA_DIR.
+        secret: Structured secret holding ``public_key``.
+        dest: Output file name in PIT_DATA_DIR (default: ``<name>.pgp``,
+            or ``<name>.asc`` with ``armor``).
+        armor: Write ASCII-armored output instead of binary.
+        remove: Delete the plaintext once it is encrypted.
+
+    Returns:
+        Path of the encrypted file (absolute, inside PIT_DATA_DIR).
+    """
+    params = {
+        "name": name,
+        "secret": secret,
+        "armor": "true" if armor else "false",
+        "remove": "true" if remove else "false",
+    }
+    if dest is not None:
+        params["dest"] = dest
+    return _request("pgp_encrypt", params)
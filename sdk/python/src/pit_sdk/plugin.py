@@ -0,0 +1,25 @@
+"""Calls to SDK methods provided by plugins.
+
+Plugins listed under ``[plugins.<name>]`` in pit_config.toml can add SDK
+methods (``sdk_methods``). pit forwards each call to the plugin program and
+returns its result.
+"""
+
+from pit_sdk.secret import _request
+
+
+def call(method: str, **params: str) -> str:
+    """Call an SDK method provided by a plugin.
+
+    Args:
+        method: Method name, as listed in the plugin's ``sdk_methods``.
+        **params: String parameters passed to the plugin.
+
+    Returns:
+        The plugin's result string.
+
+    Raises:
+        RuntimeError: If not running inside a Pit task, the method is not
+                      provided by any plugin, or the plugin returns an error.
+    """
+    return _request(method, {k: str(v) for k, v in params.items()})
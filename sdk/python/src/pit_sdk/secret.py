@@ -29,7 +29,13 @@ def _connect(addr: str) -> socket.socket:
 
 
 def _request(method: str, params: dict[str, str] | None = None) -> str:
-    """Send a JSON request to the SDK server and return the result."""
+    """Send a JSON request to the SDK server and return the result.
+
+    The result is requested as a stream: the server replies with one JSON
+    object per line, each holding the next piece of the result, with
+    ``more`` set on all but the last. This keeps large results, such as
+    big structured secrets, clear of the server's single-message limit.
+    """
     sock_addr = os.environ.get("PIT_SOCKET")
     if not sock_addr:
         raise RuntimeError(
@@ -37,25 +43,28 @@ def _request(method: str, params: dict[str, str] | None = None) -> str:
             "are you running inside a Pit task?"
         )
 
-    payload = json.dumps({"method": method, "params": params or {}}).encode()
+    payload = json.dumps(
+        {"method": method, "params": params or {}, "stream": True}
+    ).encode()
 
+    parts: list[str] = []
     with _connect(sock_addr) as s:
         s.sendall(payload)
         s.shutdown(socket.SHUT_WR)
 
-        chunks: list[bytes] = []
-        while True:
-            chunk = s.recv(4096)
-            if not chunk:
-                break
-            chunks.append(chunk)
-
-    resp = json.loads(b"".join(chunks))
-
-    if resp.get("error"):
-        raise RuntimeError(f"SDK error: {resp['error']}")
-
-    return resp.get("result", "")
+        with s.makefile("rb") as f:
+            while True:
+                line = f.readline()
+                if not line:
+                    raise RuntimeError("SDK error: connection closed mid-response")
+                resp = json.loads(line)
+                if resp.get("error"):
+                    raise RuntimeError(f"SDK error: {resp['error']}")
+                parts.append(resp.get("result", ""))
+                if not resp.get("more"):
+                    break
+
+    return "".join(parts)
 
 
 def get_secret(key: str) -> str:
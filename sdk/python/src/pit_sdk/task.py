@@ -0,0 +1,65 @@
+"""Task helpers for the Pit orchestrator."""
+
+import json
+import os
+
+from pit_sdk.secret import _request
+
+
+def set_output(key: str, value: object) -> None:
+    """Publish a value for downstream ``run_if`` / ``skip_if`` conditions.
+
+    Downstream tasks can reference it as ``outputs.<task>.<key>``, e.g.
+    ``run_if = "outputs.extract.rows > 0"``. A list can drive a mapped
+    task with ``map_over = "outputs.<task>.<key>"``.
+
+    Args:
+        key: Output name.
+        value: Output value. Strings are sent as-is; anything else
+               (numbers, booleans, lists) is JSON-encoded.
+
+    Raises:
+        RuntimeError: If not running inside a Pit task or the SDK server
+                      returns an error.
+    """
+    task = os.environ.get("PIT_TASK_NAME")
+    if not task:
+        raise RuntimeError(
+            "PIT_TASK_NAME environment variable not set — "
+            "are you running inside a Pit task?"
+        )
+    if not isinstance(value, str):
+        value = json.dumps(value)
+    _request("set_output", {"task": task, "key": key, "value": value})
+
+
+def register_output(output: str, rows: int | None = None) -> str:
+    """Report that this task produced one of the DAG's declared outputs.
+
+    Pit records the task, the time, and the row count against the matching
+    ``[[outputs]]`` entry in pit.toml, for ``pit outputs`` and the
+    ``/api/outputs`` endpoint. Registered outputs are recorded even if a
+    later task fails the run.
+
+    Args:
+        output: The output's name, or its location, e.g.
+                ``"warehouse.staging.claims"``.
+        rows: Row count of what was produced, if known.
+
+    Returns:
+        The name of the matched output.
+
+    Raises:
+        RuntimeError: If not running inside a Pit task, no declared output
+                      matches, or the SDK server returns an error.
+    """
+    task = os.environ.get("PIT_TASK_NAME")
+    if not task:
+        raise RuntimeError(
+            "PIT_TASK_NAME environment variable not set — "
+            "are you running inside a Pit task?"
+        )
+    params = {"task": task, "output": output}
+    if rows is not None:
+        params["rows"] = str(rows)
+    return _request("register_output", params)